@@ -2,6 +2,9 @@ package alias
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/meigma/blob-cli/internal/audit"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 var Cmd = &cobra.Command{
@@ -19,3 +22,11 @@ func init() {
 	Cmd.AddCommand(setCmd)
 	Cmd.AddCommand(removeCmd)
 }
+
+// recordChange adds an alias change to the config audit log. The config is
+// already saved, so a failure to record it is only a warning.
+func recordChange(configPath string, entry audit.Entry) {
+	if err := audit.Record(configPath, entry); err != nil {
+		warn.Printf("%v", err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/meigma/blob-cli/internal/audit"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
 )
 
@@ -51,13 +52,10 @@ func TestListCmd_Empty(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset viper for each test
-			viper.Reset()
-			viper.Set("output", tt.output)
-
 			cfg := &internalcfg.Config{
 				Aliases: map[string]string{},
 				Quiet:   tt.quiet,
+				Output:  tt.output,
 			}
 
 			ctx := internalcfg.WithConfig(context.Background(), cfg)
@@ -136,12 +134,10 @@ zebra  -> ghcr.io/acme/repo/zebra
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			viper.Reset()
-			viper.Set("output", tt.output)
-
 			cfg := &internalcfg.Config{
 				Aliases: tt.aliases,
 				Quiet:   tt.quiet,
+				Output:  tt.output,
 			}
 
 			ctx := internalcfg.WithConfig(context.Background(), cfg)
@@ -251,15 +247,14 @@ func TestSetCmd(t *testing.T) {
 			tmpDir := t.TempDir()
 			configPath := filepath.Join(tmpDir, "config.yaml")
 
-			viper.Reset()
-			viper.Set("output", tt.output)
-			viper.Set("internal.config_path", configPath)
+			writeAliases(t, configPath, tt.existingAlias)
 
 			cfg := &internalcfg.Config{
-				Output:      "text",
 				Compression: "zstd",
 				Aliases:     tt.existingAlias,
 				Quiet:       tt.quiet,
+				Output:      tt.output,
+				File:        configPath,
 			}
 
 			ctx := internalcfg.WithConfig(context.Background(), cfg)
@@ -373,15 +368,14 @@ func TestRemoveCmd(t *testing.T) {
 			tmpDir := t.TempDir()
 			configPath := filepath.Join(tmpDir, "config.yaml")
 
-			viper.Reset()
-			viper.Set("output", tt.output)
-			viper.Set("internal.config_path", configPath)
+			writeAliases(t, configPath, tt.existingAlias)
 
 			cfg := &internalcfg.Config{
-				Output:      "text",
 				Compression: "zstd",
 				Aliases:     tt.existingAlias,
 				Quiet:       tt.quiet,
+				Output:      tt.output,
+				File:        configPath,
 			}
 
 			ctx := internalcfg.WithConfig(context.Background(), cfg)
@@ -439,9 +433,6 @@ func TestRemoveCmd(t *testing.T) {
 }
 
 func TestListCmd_NilConfig(t *testing.T) {
-	viper.Reset()
-	viper.Set("output", "text")
-
 	// Don't set config in context
 	ctx := context.Background()
 
@@ -453,9 +444,6 @@ func TestListCmd_NilConfig(t *testing.T) {
 }
 
 func TestSetCmd_NilConfig(t *testing.T) {
-	viper.Reset()
-	viper.Set("output", "text")
-
 	ctx := context.Background()
 
 	setCmd.SetContext(ctx)
@@ -466,9 +454,6 @@ func TestSetCmd_NilConfig(t *testing.T) {
 }
 
 func TestRemoveCmd_NilConfig(t *testing.T) {
-	viper.Reset()
-	viper.Set("output", "text")
-
 	ctx := context.Background()
 
 	removeCmd.SetContext(ctx)
@@ -477,3 +462,36 @@ func TestRemoveCmd_NilConfig(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "configuration not loaded")
 }
+
+func TestSetRemoveCmd_RecordsAuditLog(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	cfg := &internalcfg.Config{Output: "text", File: configPath, Quiet: true, Aliases: map[string]string{"prod": "ghcr.io/acme/app:v1"}}
+	writeAliases(t, configPath, cfg.Aliases)
+	ctx := internalcfg.WithConfig(context.Background(), cfg)
+
+	setCmd.SetContext(ctx)
+	require.NoError(t, setCmd.RunE(setCmd, []string{"prod", "ghcr.io/acme/app:v2"}))
+	removeCmd.SetContext(ctx)
+	require.NoError(t, removeCmd.RunE(removeCmd, []string{"prod"}))
+
+	entries, err := audit.Read(configPath)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "alias set", entries[0].Command)
+	assert.Equal(t, "aliases.prod", entries[0].Key)
+	assert.Equal(t, "ghcr.io/acme/app:v1", entries[0].Old)
+	assert.Equal(t, "ghcr.io/acme/app:v2", entries[0].New)
+	assert.Equal(t, "alias remove", entries[1].Command)
+	assert.Equal(t, "ghcr.io/acme/app:v2", entries[1].Old)
+	assert.Empty(t, entries[1].New)
+}
+
+// writeAliases writes a config file defining aliases.
+func writeAliases(t *testing.T, path string, aliases map[string]string) {
+	t.Helper()
+	for name, ref := range aliases {
+		_, _, err := internalcfg.SetAliasInFile(path, name, ref)
+		require.NoError(t, err)
+	}
+}
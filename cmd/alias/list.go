@@ -10,7 +10,6 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	internalcfg "github.com/meigma/blob-cli/internal/config"
 )
@@ -21,7 +20,8 @@ var listCmd = &cobra.Command{
 	Long: `List all configured aliases.
 
 Displays all aliases defined in the configuration file along with
-their target references.`,
+their target references, noting aliases with a policy or a default
+pull destination.`,
 	Example: `  blob alias list
   blob alias list --output json`,
 	Args: cobra.NoArgs,
@@ -35,7 +35,7 @@ their target references.`,
 			return nil
 		}
 
-		if viper.GetString("output") == internalcfg.OutputJSON {
+		if cfg.Output == internalcfg.OutputJSON {
 			return listJSON(cfg)
 		}
 		return listText(cfg)
@@ -76,8 +76,23 @@ func listText(cfg *internalcfg.Config) error {
 	}
 
 	for _, name := range names {
-		fmt.Printf("%-*s  -> %s\n", maxLen, name, cfg.Aliases[name])
+		fmt.Printf("%-*s  -> %s%s\n", maxLen, name, cfg.Aliases[name], aliasNotes(cfg.AliasDefaults[name]))
 	}
 
 	return nil
 }
+
+// aliasNotes describes the settings of an alias besides its reference.
+func aliasNotes(d internalcfg.AliasDefaults) string {
+	var notes []string
+	if d.Policy != nil {
+		notes = append(notes, "policy")
+	}
+	if d.DefaultDest != "" {
+		notes = append(notes, "pulls to "+d.DefaultDest)
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(notes, ", ") + ")"
+}
@@ -7,8 +7,8 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
+	"github.com/meigma/blob-cli/internal/audit"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
 )
 
@@ -28,29 +28,25 @@ Deletes the specified alias. This action cannot be undone.`,
 			return errors.New("configuration not loaded")
 		}
 
-		// Check if alias exists
-		if _, exists := cfg.Aliases[name]; !exists {
-			return fmt.Errorf("alias %q not found", name)
-		}
-
-		// Create new config with alias removed
-		newCfg := cfg.RemoveAlias(name)
-
-		// Get config path and save
-		path, err := internalcfg.ConfigPathUsed()
+		path, err := cfg.FilePath()
 		if err != nil {
 			return fmt.Errorf("determining config path: %w", err)
 		}
 
-		if err := internalcfg.Save(newCfg, path); err != nil {
+		oldRef, err := internalcfg.RemoveAliasFromFile(path, name)
+		if errors.Is(err, internalcfg.ErrAliasNotFound) {
+			return fmt.Errorf("alias %q not found", name)
+		}
+		if err != nil {
 			return fmt.Errorf("saving config: %w", err)
 		}
+		recordChange(path, audit.Entry{Command: "alias remove", Key: "aliases." + name, Old: oldRef})
 
 		// Output result (respects --quiet for all formats)
 		if cfg.Quiet {
 			return nil
 		}
-		if viper.GetString("output") == internalcfg.OutputJSON {
+		if cfg.Output == internalcfg.OutputJSON {
 			return removeJSON(name)
 		}
 		return removeText(name)
@@ -7,8 +7,8 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
+	"github.com/meigma/blob-cli/internal/audit"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
 )
 
@@ -19,9 +19,16 @@ var setCmd = &cobra.Command{
 
 Creates a new alias or updates an existing one. The alias maps
 a short name to a full registry reference. The reference may
-optionally include a tag.`,
+optionally include a tag.
+
+With --verify, the reference is checked before the alias is saved:
+the repository must exist and the reference must point to a blob
+archive. A reference without a tag is stored with the repository's
+default tag, "latest" if it exists or otherwise the highest release
+version, so a typo is caught now rather than on first use.`,
 	Example: `  blob alias set foo ghcr.io/acme/repo/foo
-  blob alias set prod ghcr.io/acme/repo/app:stable`,
+  blob alias set prod ghcr.io/acme/repo/app:stable
+  blob alias set --verify configs ghcr.io/acme/configs`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
@@ -32,34 +39,50 @@ optionally include a tag.`,
 			return errors.New("configuration not loaded")
 		}
 
-		// Check if this is an update or new alias
-		_, isUpdate := cfg.Aliases[name]
-
-		// Create new config with alias set
-		newCfg := cfg.SetAlias(name, ref)
+		verify, err := cmd.Flags().GetBool("verify")
+		if err != nil {
+			return fmt.Errorf("reading verify flag: %w", err)
+		}
+		var digest string
+		if verify {
+			target, err := verifyTarget(cmd.Context(), cfg, ref)
+			if err != nil {
+				return fmt.Errorf("verifying alias target: %w", err)
+			}
+			ref, digest = target.ref, target.digest
+		}
 
-		// Get config path and save
-		path, err := internalcfg.ConfigPathUsed()
+		path, err := cfg.FilePath()
 		if err != nil {
 			return fmt.Errorf("determining config path: %w", err)
 		}
 
-		if err := internalcfg.Save(newCfg, path); err != nil {
+		// Read-modify-write of the file happens under its lock, so
+		// concurrent alias changes are not lost.
+		oldRef, isUpdate, err := internalcfg.SetAliasInFile(path, name, ref)
+		if err != nil {
 			return fmt.Errorf("saving config: %w", err)
 		}
+		if oldRef != ref {
+			recordChange(path, audit.Entry{Command: "alias set", Key: "aliases." + name, Old: oldRef, New: ref})
+		}
 
 		// Output result (respects --quiet for all formats)
 		if cfg.Quiet {
 			return nil
 		}
-		if viper.GetString("output") == internalcfg.OutputJSON {
-			return setJSON(name, ref, isUpdate)
+		if cfg.Output == internalcfg.OutputJSON {
+			return setJSON(name, ref, digest, isUpdate)
 		}
-		return setText(name, ref, isUpdate)
+		return setText(name, ref, digest, isUpdate)
 	},
 }
 
-func setJSON(name, ref string, isUpdate bool) error {
+func init() {
+	setCmd.Flags().Bool("verify", false, "check that the reference is a blob archive before saving")
+}
+
+func setJSON(name, ref, digest string, isUpdate bool) error {
 	action := "created"
 	if isUpdate {
 		action = "updated"
@@ -69,16 +92,22 @@ func setJSON(name, ref string, isUpdate bool) error {
 		"name":   name,
 		"ref":    ref,
 	}
+	if digest != "" {
+		data["digest"] = digest
+	}
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
 	return enc.Encode(data)
 }
 
-func setText(name, ref string, isUpdate bool) error {
+func setText(name, ref, digest string, isUpdate bool) error {
 	if isUpdate {
 		fmt.Printf("Updated alias %q -> %s\n", name, ref)
 	} else {
 		fmt.Printf("Created alias %q -> %s\n", name, ref)
 	}
+	if digest != "" {
+		fmt.Printf("Verified blob archive %s\n", digest)
+	}
 	return nil
 }
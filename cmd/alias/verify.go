@@ -0,0 +1,92 @@
+package alias
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/meigma/blob"
+	blobregistry "github.com/meigma/blob/registry/oras"
+	"oras.land/oras-go/v2/registry/remote"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/semver"
+)
+
+// defaultTag is the tag an alias without one resolves to.
+const defaultTag = "latest"
+
+// verifiedTarget describes the archive an alias was verified against.
+type verifiedTarget struct {
+	ref    string // reference to store, including any discovered tag
+	digest string
+}
+
+// verifyTarget checks that ref names a blob archive in an existing
+// repository. A reference without a tag or digest gets the repository's
+// default tag: "latest" if it exists, otherwise the highest release version.
+func verifyTarget(ctx context.Context, cfg *internalcfg.Config, ref string) (verifiedTarget, error) {
+	target := verifiedTarget{ref: ref}
+	if _, _, ok := internalcfg.SplitRef(ref); !ok {
+		tag, err := discoverDefaultTag(ctx, cfg, ref)
+		if err != nil {
+			return target, err
+		}
+		target.ref = ref + ":" + tag
+	}
+
+	opts := []blob.Option{blob.WithPlainHTTP(cfg.PlainHTTP)}
+	if cfg.Registry.UserAgent != "" {
+		opts = append(opts, blob.WithUserAgent(cfg.Registry.UserAgent))
+	}
+	result, err := archive.Inspect(ctx, target.ref, opts...)
+	if err != nil {
+		return target, err
+	}
+	target.digest = result.Digest()
+	return target, nil
+}
+
+// discoverDefaultTag picks the tag an alias for repo should default to.
+func discoverDefaultTag(ctx context.Context, cfg *internalcfg.Config, repo string) (string, error) {
+	ociOpts := []blobregistry.Option{
+		blobregistry.WithDockerConfig(),
+		blobregistry.WithPlainHTTP(cfg.PlainHTTP),
+	}
+	if cfg.Registry.UserAgent != "" {
+		ociOpts = append(ociOpts, blobregistry.WithUserAgent(cfg.Registry.UserAgent))
+	}
+	httpClient, err := blobregistry.New(ociOpts...).AuthClient(repo)
+	if err != nil {
+		return "", fmt.Errorf("invalid repository %q: %w", repo, err)
+	}
+	repository, err := remote.NewRepository(repo)
+	if err != nil {
+		return "", fmt.Errorf("invalid repository %q: %w", repo, err)
+	}
+	repository.Client = httpClient
+	repository.PlainHTTP = cfg.PlainHTTP
+
+	var tags []string
+	err = repository.Tags(ctx, "", func(page []string) error {
+		tags = append(tags, page...)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("listing tags for %s: %w", repo, err)
+	}
+
+	if slices.Contains(tags, defaultTag) {
+		return defaultTag, nil
+	}
+	query, err := semver.Parse(semver.LatestStable)
+	if err != nil {
+		return "", err
+	}
+	tag, err := query.Best(tags)
+	if err != nil {
+		return "", fmt.Errorf("%s has no %q tag or release version; give the alias a tag", repo, defaultTag)
+	}
+	return tag, nil
+}
@@ -0,0 +1,76 @@
+package alias
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// newTagsServer serves tag lists for the given repositories.
+func newTagsServer(t *testing.T, repos map[string][]string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/v2/"), "/tags/list")
+		tags, found := repos[name]
+		if !ok || !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		//nolint:errcheck // test server
+		json.NewEncoder(w).Encode(map[string]any{"name": name, "tags": tags})
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestDiscoverDefaultTag(t *testing.T) {
+	host := newTagsServer(t, map[string][]string{
+		"acme/latest":   {"v1.0.0", "latest"},
+		"acme/releases": {"v1.0.0", "v1.2.0", "v2.0.0-rc.1", "dev"},
+		"acme/dev":      {"dev", "main"},
+	})
+	cfg := &internalcfg.Config{PlainHTTP: true}
+
+	tag, err := discoverDefaultTag(t.Context(), cfg, host+"/acme/latest")
+	require.NoError(t, err)
+	assert.Equal(t, "latest", tag)
+
+	tag, err = discoverDefaultTag(t.Context(), cfg, host+"/acme/releases")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0", tag)
+
+	_, err = discoverDefaultTag(t.Context(), cfg, host+"/acme/dev")
+	require.ErrorContains(t, err, "give the alias a tag")
+
+	_, err = discoverDefaultTag(t.Context(), cfg, host+"/acme/missing")
+	require.ErrorContains(t, err, "listing tags")
+}
+
+func TestSetCmd_VerifyFailureKeepsConfig(t *testing.T) {
+	host := newTagsServer(t, map[string][]string{})
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	require.NoError(t, setCmd.Flags().Set("verify", "true"))
+	t.Cleanup(func() {
+		setCmd.Flags().Set("verify", "false") //nolint:errcheck // test cleanup
+	})
+
+	cfg := &internalcfg.Config{Output: "text", File: configPath, PlainHTTP: true, Aliases: map[string]string{}}
+	setCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	err := setCmd.RunE(setCmd, []string{"configs", host + "/acme/cofnigs"})
+
+	require.ErrorContains(t, err, "verifying alias target")
+	_, statErr := os.Stat(configPath)
+	assert.True(t, os.IsNotExist(statErr), "config should not be written")
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/meigma/blob/policy/sigstore"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/identity"
+)
+
+const (
+	// inTotoStatementType is the in-toto statement version produced by attest.
+	inTotoStatementType = "https://in-toto.io/Statement/v1"
+
+	// inTotoPayloadType is the DSSE payload type of in-toto statements.
+	inTotoPayloadType = "application/vnd.in-toto+json"
+)
+
+// predicateTypeAliases maps short names accepted by --type to predicate
+// type URIs.
+var predicateTypeAliases = map[string]string{
+	"slsaprovenance":  "https://slsa.dev/provenance/v0.2",
+	"slsaprovenance1": "https://slsa.dev/provenance/v1",
+	"spdxjson":        "https://spdx.dev/Document",
+	"cyclonedx":       "https://cyclonedx.org/bom",
+	"vuln":            "https://cosign.sigstore.dev/attestation/vuln/v1",
+}
+
+var attestCmd = &cobra.Command{
+	Use:   "attest <ref>",
+	Short: "Attach a signed in-toto attestation to an archive",
+	Long: `Attach a signed in-toto attestation to an archive.
+
+Wraps the JSON predicate in an in-toto statement whose subject is the
+archive manifest, signs it as a DSSE envelope with Sigstore, and pushes
+the resulting bundle as an OCI referrer of the archive. "blob verify"
+policies, such as SLSA provenance requirements, read attestations from
+there.
+
+--type is the predicate type URI. These short names are also accepted:
+  slsaprovenance    https://slsa.dev/provenance/v0.2
+  slsaprovenance1   https://slsa.dev/provenance/v1
+  spdxjson          https://spdx.dev/Document
+  cyclonedx         https://cyclonedx.org/bom
+  vuln              https://cosign.sigstore.dev/attestation/vuln/v1
+
+Signing works as for "blob sign": keyless by default, using an OIDC
+identity token, or with --key for a private key.`,
+	Example: `  blob attest --predicate provenance.json --type slsaprovenance1 ghcr.io/acme/configs:v1.0.0
+  blob attest --predicate sbom.spdx.json --type spdxjson ghcr.io/acme/configs:v1.0.0
+  blob attest --key cosign.key --predicate review.json --type https://acme.dev/review/v1 configs:v1.0.0
+  generate-provenance | blob attest --predicate - --type slsaprovenance1 configs:v1.0.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAttest,
+}
+
+func init() {
+	attestCmd.Flags().String("predicate", "", `predicate JSON file ("-" for stdin)`)
+	attestCmd.Flags().String("type", "", "predicate type URI or short name")
+	attestCmd.Flags().String("key", "", "sign with a private key instead of keyless")
+	addIdentityFlags(attestCmd)
+	_ = attestCmd.MarkFlagRequired("predicate")
+	_ = attestCmd.MarkFlagRequired("type")
+}
+
+// attestResult contains the result of an attest operation.
+type attestResult struct {
+	Ref               string `json:"ref"`
+	ResolvedRef       string `json:"resolved_ref,omitempty"`
+	PredicateType     string `json:"predicate_type"`
+	AttestationDigest string `json:"attestation_digest"`
+	Status            string `json:"status"`
+}
+
+// attestFlags holds the parsed command flags.
+type attestFlags struct {
+	predicate     json.RawMessage
+	predicateType string
+	sign          signFlags
+}
+
+// inTotoStatement is an in-toto v1 statement.
+type inTotoStatement struct {
+	Type          string          `json:"_type"`
+	Subject       []inTotoSubject `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+func runAttest(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	inputRef := args[0]
+	flags, err := parseAttestFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	resolvedRef, err := resolveRef(ctx, cfg, inputRef)
+	if err != nil {
+		return err
+	}
+
+	signer, err := buildAttestationSigner(ctx, flags.sign)
+	if err != nil {
+		return fmt.Errorf("creating signer: %w", err)
+	}
+	signer.subject = repositoryOf(resolvedRef)
+	signer.predicateType = flags.predicateType
+	signer.predicate = flags.predicate
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+
+	// The client hands the signer the archive manifest and pushes what it
+	// returns as a referrer, as for signatures.
+	attDigest, err := client.Sign(ctx, resolvedRef, signer)
+	if err != nil {
+		return fmt.Errorf("attesting archive: %w", err)
+	}
+
+	result := attestResult{
+		Ref:               inputRef,
+		PredicateType:     flags.predicateType,
+		AttestationDigest: attDigest,
+		Status:            "success",
+	}
+	if inputRef != resolvedRef {
+		result.ResolvedRef = resolvedRef
+	}
+	return outputAttestResult(cfg, &result)
+}
+
+// parseAttestFlags extracts and validates flags from the command.
+func parseAttestFlags(cmd *cobra.Command) (attestFlags, error) {
+	var flags attestFlags
+
+	predicatePath, err := cmd.Flags().GetString("predicate")
+	if err != nil {
+		return flags, fmt.Errorf("reading predicate flag: %w", err)
+	}
+	flags.predicate, err = readPredicate(cmd.InOrStdin(), predicatePath)
+	if err != nil {
+		return flags, err
+	}
+
+	flags.predicateType, err = cmd.Flags().GetString("type")
+	if err != nil {
+		return flags, fmt.Errorf("reading type flag: %w", err)
+	}
+	flags.predicateType = resolvePredicateType(flags.predicateType)
+	if flags.predicateType == "" {
+		return flags, errors.New("--type is required")
+	}
+
+	flags.sign.keyPath, err = cmd.Flags().GetString("key")
+	if err != nil {
+		return flags, fmt.Errorf("reading key flag: %w", err)
+	}
+
+	flags.sign.identity, err = parseIdentityFlags(cmd)
+	if err != nil {
+		return flags, err
+	}
+
+	return flags, nil
+}
+
+// readPredicate reads the predicate from path, or stdin for "-", and checks
+// that it is a JSON object.
+func readPredicate(stdin io.Reader, path string) (json.RawMessage, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path) //nolint:gosec // predicate path is user-provided
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading predicate: %w", err)
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid predicate: must be a JSON object: %w", err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("invalid predicate: %w", err)
+	}
+	return compact.Bytes(), nil
+}
+
+// resolvePredicateType expands a short predicate type name.
+func resolvePredicateType(t string) string {
+	if uri, ok := predicateTypeAliases[t]; ok {
+		return uri
+	}
+	return t
+}
+
+// attestationSigner signs in-toto statements about the manifests it is
+// given. It implements blob.ManifestSigner so the client can attach the
+// attestation the same way it attaches signatures.
+type attestationSigner struct {
+	keypair sign.Keypair
+	opts    sign.BundleOptions
+
+	subject       string
+	predicateType string
+	predicate     json.RawMessage
+}
+
+// buildAttestationSigner creates a signer from the same key or keyless
+// identity as buildSigner.
+func buildAttestationSigner(ctx context.Context, flags signFlags) (*attestationSigner, error) {
+	s := &attestationSigner{}
+	fulcioURL, rekorURL := sigstoreURLs(ctx)
+	s.opts.TransparencyLogs = []sign.Transparency{sign.NewRekor(&sign.RekorOptions{BaseURL: rekorURL})}
+
+	if flags.keyPath != "" {
+		pemData, password, err := readSigningKey(flags.keyPath)
+		if err != nil {
+			return nil, err
+		}
+		key, err := sigstore.ParsePrivateKeyPEM(pemData, password)
+		if err != nil {
+			return nil, err
+		}
+		s.keypair, err = sigstore.NewStaticKeypair(key)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	token, err := identity.Token(ctx, identityOptions(ctx, flags.identity))
+	if err != nil {
+		return nil, err
+	}
+	s.keypair, err = sign.NewEphemeralKeypair(nil)
+	if err != nil {
+		return nil, err
+	}
+	s.opts.CertificateProvider = sign.NewFulcio(&sign.FulcioOptions{BaseURL: fulcioURL})
+	s.opts.CertificateProviderOptions = &sign.CertificateProviderOptions{IDToken: token}
+	return s, nil
+}
+
+// SignManifest returns a Sigstore bundle holding a DSSE envelope of an
+// in-toto statement whose subject is the manifest.
+func (s *attestationSigner) SignManifest(ctx context.Context, manifest []byte) ([]byte, string, error) {
+	statement, err := s.statement(manifest)
+	if err != nil {
+		return nil, "", err
+	}
+
+	opts := s.opts
+	opts.Context = ctx
+	bundle, err := sign.Bundle(&sign.DSSEData{Data: statement, PayloadType: inTotoPayloadType}, s.keypair, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("sigstore sign: %w", err)
+	}
+	data, err := protojson.Marshal(bundle)
+	if err != nil {
+		return nil, "", fmt.Errorf("sigstore marshal bundle: %w", err)
+	}
+	return data, sigstore.SignatureArtifactType, nil
+}
+
+// statement builds the in-toto statement for manifest.
+func (s *attestationSigner) statement(manifest []byte) ([]byte, error) {
+	sum := sha256.Sum256(manifest)
+	return json.Marshal(inTotoStatement{
+		Type: inTotoStatementType,
+		Subject: []inTotoSubject{{
+			Name:   s.subject,
+			Digest: map[string]string{"sha256": hex.EncodeToString(sum[:])},
+		}},
+		PredicateType: s.predicateType,
+		Predicate:     s.predicate,
+	})
+}
+
+// outputAttestResult formats and outputs the attest result.
+func outputAttestResult(cfg *internalcfg.Config, result *attestResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	fmt.Printf("Attested %s\n", result.Ref)
+	if result.ResolvedRef != "" {
+		fmt.Printf("  Resolved: %s\n", result.ResolvedRef)
+	}
+	fmt.Printf("  Predicate: %s\n", result.PredicateType)
+	fmt.Printf("Attestation: %s\n", result.AttestationDigest)
+	return nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/meigma/blob/policy/sigstore"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPredicate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "predicate.json")
+	require.NoError(t, os.WriteFile(path, []byte("{\n  \"builder\": {\"id\": \"ci\"}\n}\n"), 0o644))
+
+	got, err := readPredicate(nil, path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"builder":{"id":"ci"}}`, string(got))
+	assert.NotContains(t, string(got), "\n")
+
+	got, err = readPredicate(strings.NewReader(`{"ok":true}`), "-")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok":true}`, string(got))
+
+	_, err = readPredicate(strings.NewReader(`["not", "an", "object"]`), "-")
+	require.ErrorContains(t, err, "must be a JSON object")
+
+	_, err = readPredicate(nil, filepath.Join(dir, "missing.json"))
+	require.ErrorContains(t, err, "reading predicate")
+}
+
+func TestResolvePredicateType(t *testing.T) {
+	assert.Equal(t, "https://slsa.dev/provenance/v1", resolvePredicateType("slsaprovenance1"))
+	assert.Equal(t, "https://acme.dev/review/v1", resolvePredicateType("https://acme.dev/review/v1"))
+}
+
+func TestAttestationSigner_SignManifest(t *testing.T) {
+	keypair, err := sign.NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	signer := &attestationSigner{
+		keypair:       keypair,
+		subject:       "ghcr.io/acme/configs",
+		predicateType: "https://slsa.dev/provenance/v1",
+		predicate:     json.RawMessage(`{"buildDefinition":{"buildType":"test"}}`),
+	}
+	manifest := []byte(`{"schemaVersion":2}`)
+
+	data, mediaType, err := signer.SignManifest(t.Context(), manifest)
+	require.NoError(t, err)
+	assert.Equal(t, sigstore.SignatureArtifactType, mediaType)
+
+	var bundle struct {
+		DSSEEnvelope struct {
+			PayloadType string `json:"payloadType"`
+			Payload     string `json:"payload"`
+		} `json:"dsseEnvelope"`
+	}
+	require.NoError(t, json.Unmarshal(data, &bundle))
+	assert.Equal(t, inTotoPayloadType, bundle.DSSEEnvelope.PayloadType)
+
+	payload, err := base64.StdEncoding.DecodeString(bundle.DSSEEnvelope.Payload)
+	require.NoError(t, err)
+	var statement inTotoStatement
+	require.NoError(t, json.Unmarshal(payload, &statement))
+	sum := sha256.Sum256(manifest)
+	assert.Equal(t, inTotoStatementType, statement.Type)
+	assert.Equal(t, "https://slsa.dev/provenance/v1", statement.PredicateType)
+	require.Len(t, statement.Subject, 1)
+	assert.Equal(t, "ghcr.io/acme/configs", statement.Subject[0].Name)
+	assert.Equal(t, hex.EncodeToString(sum[:]), statement.Subject[0].Digest["sha256"])
+	assert.JSONEq(t, `{"buildDefinition":{"buildType":"test"}}`, string(statement.Predicate))
+}
@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/referrers"
+)
+
+// maxAttestationSize bounds the manifests and layers read by attestation
+// get, so a misbehaving registry cannot make it buffer arbitrary data.
+const maxAttestationSize = 16 << 20
+
+var attestationCmd = &cobra.Command{
+	Use:   "attestation",
+	Short: "Read attestations attached to archives",
+	Long: `Read attestations attached to archives.
+
+Attestations are in-toto statements attached to an archive as OCI
+referrers, by "blob attest" or by other tools such as cosign. They are
+listed by "blob inspect".`,
+}
+
+var attestationGetCmd = &cobra.Command{
+	Use:   "get <ref>",
+	Short: "Print the predicates of an archive's attestations",
+	Long: `Print the predicates of an archive's attestations.
+
+Fetches the attestations attached to the archive, decodes their DSSE
+envelopes, whether bare or inside Sigstore bundles, and prints each
+in-toto predicate as JSON. Sigstore bundles holding plain signatures
+rather than attestations are skipped.
+
+Signatures are not checked; use "blob verify" with a policy to rely on
+an attestation's contents.
+
+By default, referrers with the Sigstore bundle and in-toto artifact
+types are read. --type selects a single artifact type instead.`,
+	Example: `  blob attestation get ghcr.io/acme/configs:v1.0.0
+  blob attestation get --output json ghcr.io/acme/configs:v1.0.0 | jq '.attestations[].predicate'
+  blob attestation get --type application/vnd.in-toto+json configs:v1.0.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAttestationGet,
+}
+
+func init() {
+	attestationGetCmd.Flags().String("type", "", "only read referrers with this artifact type")
+	attestationCmd.AddCommand(attestationGetCmd)
+}
+
+// attestationGetResult contains the result of an attestation get operation.
+type attestationGetResult struct {
+	Ref          string              `json:"ref"`
+	ResolvedRef  string              `json:"resolved_ref,omitempty"`
+	Digest       string              `json:"digest"`
+	Attestations []attestationResult `json:"attestations"`
+}
+
+// attestationResult is one decoded attestation.
+type attestationResult struct {
+	Digest        string          `json:"digest"`
+	ArtifactType  string          `json:"artifact_type"`
+	PredicateType string          `json:"predicate_type"`
+	Subject       []inTotoSubject `json:"subject"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// dsseEnvelope is a DSSE envelope, as stored bare or in a Sigstore bundle.
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+}
+
+// errNotAttestation marks referrer content that holds no in-toto statement.
+var errNotAttestation = errors.New("not an attestation")
+
+func runAttestationGet(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	inputRef := args[0]
+	artifactType, err := cmd.Flags().GetString("type")
+	if err != nil {
+		return fmt.Errorf("reading type flag: %w", err)
+	}
+
+	ctx := cmd.Context()
+	resolvedRef, err := resolveRef(ctx, cfg, inputRef)
+	if err != nil {
+		return err
+	}
+	parsed, err := registry.ParseReference(resolvedRef)
+	if err != nil {
+		return fmt.Errorf("invalid reference %q: %w", resolvedRef, err)
+	}
+	repository, err := newRemoteRepository(cfg, parsed.Registry+"/"+parsed.Repository)
+	if err != nil {
+		return err
+	}
+	subject, err := repository.Resolve(ctx, parsed.Reference)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", resolvedRef, err)
+	}
+
+	attestations, err := fetchAttestations(ctx, repository, subject, artifactType)
+	if err != nil {
+		return err
+	}
+	if len(attestations) == 0 {
+		return fmt.Errorf("no attestations attached to %s", inputRef)
+	}
+
+	result := attestationGetResult{
+		Ref:          inputRef,
+		Digest:       subject.Digest.String(),
+		Attestations: attestations,
+	}
+	if inputRef != resolvedRef {
+		result.ResolvedRef = resolvedRef
+	}
+	return outputAttestationGetResult(cfg, &result)
+}
+
+// fetchAttestations returns the decoded attestations among the referrers of
+// subject. An empty artifactType reads Sigstore bundles and in-toto
+// referrers.
+func fetchAttestations(ctx context.Context, repository *remote.Repository, subject ocispec.Descriptor, artifactType string) ([]attestationResult, error) {
+	descs, err := referrers.List(ctx, repository, subject, artifactType)
+	if err != nil {
+		return nil, err
+	}
+
+	var attestations []attestationResult
+	for _, desc := range descs {
+		if artifactType == "" && desc.ArtifactType != sigstoreArtifactType && desc.ArtifactType != inTotoArtifactType {
+			continue
+		}
+		found, err := fetchReferrerAttestations(ctx, repository, desc)
+		if err != nil {
+			return nil, err
+		}
+		attestations = append(attestations, found...)
+	}
+	return attestations, nil
+}
+
+// fetchReferrerAttestations reads the layers of one referrer manifest and
+// decodes those holding attestations.
+func fetchReferrerAttestations(ctx context.Context, repository *remote.Repository, desc ocispec.Descriptor) ([]attestationResult, error) {
+	if desc.Size > maxAttestationSize {
+		return nil, fmt.Errorf("referrer %s is too large (%d bytes)", desc.Digest, desc.Size)
+	}
+	data, err := content.FetchAll(ctx, repository.Manifests(), desc)
+	if err != nil {
+		return nil, fmt.Errorf("fetching referrer %s: %w", desc.Digest, err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing referrer %s: %w", desc.Digest, err)
+	}
+	artifactType := manifest.ArtifactType
+	if artifactType == "" {
+		artifactType = desc.ArtifactType
+	}
+
+	var attestations []attestationResult
+	for _, layer := range manifest.Layers {
+		if layer.Size > maxAttestationSize {
+			return nil, fmt.Errorf("referrer %s: layer %s is too large (%d bytes)", desc.Digest, layer.Digest, layer.Size)
+		}
+		data, err := content.FetchAll(ctx, repository.Blobs(), layer)
+		if err != nil {
+			return nil, fmt.Errorf("fetching referrer %s: %w", desc.Digest, err)
+		}
+		statement, err := decodeAttestation(data)
+		if errors.Is(err, errNotAttestation) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding referrer %s: %w", desc.Digest, err)
+		}
+		attestations = append(attestations, attestationResult{
+			Digest:        desc.Digest.String(),
+			ArtifactType:  artifactType,
+			PredicateType: statement.PredicateType,
+			Subject:       statement.Subject,
+			Predicate:     statement.Predicate,
+		})
+	}
+	return attestations, nil
+}
+
+// decodeAttestation extracts the in-toto statement from a Sigstore bundle,
+// a bare DSSE envelope, or an unsigned statement. It returns
+// errNotAttestation for anything else, such as a signature bundle.
+func decodeAttestation(data []byte) (*inTotoStatement, error) {
+	var doc struct {
+		Type         string        `json:"_type"`
+		DSSEEnvelope *dsseEnvelope `json:"dsseEnvelope"`
+		dsseEnvelope
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errNotAttestation
+	}
+
+	var payload []byte
+	switch {
+	case doc.Type != "":
+		payload = data
+	case doc.DSSEEnvelope != nil:
+		envelope, err := decodeDSSE(doc.DSSEEnvelope)
+		if err != nil {
+			return nil, err
+		}
+		payload = envelope
+	case doc.PayloadType != "":
+		envelope, err := decodeDSSE(&doc.dsseEnvelope)
+		if err != nil {
+			return nil, err
+		}
+		payload = envelope
+	default:
+		return nil, errNotAttestation
+	}
+
+	var statement inTotoStatement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil, fmt.Errorf("invalid in-toto statement: %w", err)
+	}
+	if statement.PredicateType == "" {
+		return nil, errNotAttestation
+	}
+	return &statement, nil
+}
+
+// decodeDSSE returns the payload of an in-toto DSSE envelope.
+func decodeDSSE(envelope *dsseEnvelope) ([]byte, error) {
+	if envelope.PayloadType != inTotoPayloadType {
+		return nil, errNotAttestation
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSSE payload: %w", err)
+	}
+	return payload, nil
+}
+
+// outputAttestationGetResult formats and outputs the attestation get result.
+func outputAttestationGetResult(cfg *internalcfg.Config, result *attestationGetResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	for i, a := range result.Attestations {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Attestation %s\n", a.Digest)
+		fmt.Printf("  Predicate type: %s\n", a.PredicateType)
+		var predicate bytes.Buffer
+		if err := json.Indent(&predicate, a.Predicate, "", "  "); err != nil {
+			return fmt.Errorf("formatting predicate: %w", err)
+		}
+		fmt.Println(predicate.String())
+	}
+	return nil
+}
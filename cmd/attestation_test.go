@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestAttestationGetCmd(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	subject := reg.add(t, "v1", nil, "")
+
+	keypair, err := sign.NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	signer := &attestationSigner{
+		keypair:       keypair,
+		subject:       "acme/configs",
+		predicateType: "https://slsa.dev/provenance/v1",
+		predicate:     json.RawMessage(`{"buildDefinition":{"buildType":"test"}}`),
+	}
+	bundle, _, err := signer.SignManifest(t.Context(), reg.manifestData(subject.Digest.String()))
+	require.NoError(t, err)
+	att := reg.addWithLayers(t, "", &subject, sigstoreArtifactType, reg.addBlob(sigstoreArtifactType, bundle))
+	// A signature bundle is not an attestation and is skipped.
+	reg.addWithLayers(t, "", &subject, sigstoreArtifactType,
+		reg.addBlob(sigstoreArtifactType, []byte(`{"messageSignature":{"signature":"c2ln"}}`)))
+
+	cfg := newRegistryTestConfig(t)
+	cfg.Quiet = false
+	cfg.Output = internalcfg.OutputJSON
+	attestationGetCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	out, err := captureStdout(t, func() error {
+		return attestationGetCmd.RunE(attestationGetCmd, []string{reg.repo + ":v1"})
+	})
+	require.NoError(t, err)
+	var result attestationGetResult
+	require.NoError(t, json.Unmarshal(out, &result))
+	assert.Equal(t, subject.Digest.String(), result.Digest)
+	require.Len(t, result.Attestations, 1)
+	assert.Equal(t, att.Digest.String(), result.Attestations[0].Digest)
+	assert.Equal(t, "https://slsa.dev/provenance/v1", result.Attestations[0].PredicateType)
+	assert.JSONEq(t, `{"buildDefinition":{"buildType":"test"}}`, string(result.Attestations[0].Predicate))
+}
+
+func TestAttestationGetCmd_None(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	reg.add(t, "v1", nil, "")
+
+	cfg := newRegistryTestConfig(t)
+	attestationGetCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	err := attestationGetCmd.RunE(attestationGetCmd, []string{reg.repo + ":v1"})
+	require.ErrorContains(t, err, "no attestations attached")
+}
+
+func TestDecodeAttestation(t *testing.T) {
+	statement := `{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"configs","digest":{"sha256":"abc"}}],"predicateType":"https://spdx.dev/Document","predicate":{"spdxVersion":"SPDX-2.3"}}`
+	payload := base64.StdEncoding.EncodeToString([]byte(statement))
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{name: "bare statement", data: statement},
+		{name: "DSSE envelope", data: `{"payloadType":"application/vnd.in-toto+json","payload":"` + payload + `","signatures":[]}`},
+		{name: "sigstore bundle", data: `{"mediaType":"application/vnd.dev.sigstore.bundle.v0.3+json","dsseEnvelope":{"payloadType":"application/vnd.in-toto+json","payload":"` + payload + `"}}`},
+		{name: "signature bundle", data: `{"messageSignature":{"signature":"c2ln"}}`, wantErr: errNotAttestation},
+		{name: "other DSSE payload", data: `{"payloadType":"text/plain","payload":"aGk="}`, wantErr: errNotAttestation},
+		{name: "not JSON", data: `not json`, wantErr: errNotAttestation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeAttestation([]byte(tt.data))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "https://spdx.dev/Document", got.PredicateType)
+			assert.JSONEq(t, `{"spdxVersion":"SPDX-2.3"}`, string(got.Predicate))
+		})
+	}
+}
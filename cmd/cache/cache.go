@@ -19,11 +19,18 @@ Blob maintains several caches to improve performance:
 Cache location follows XDG Base Directory Specification:
 $XDG_CACHE_HOME/blob or ~/.cache/blob by default.
 
-Override with cache.dir in config file or BLOB_CACHE_DIR environment variable.`,
+Override with cache.dir in config file or BLOB_CACHE_DIR environment variable.
+
+cache.max_size caps the combined size of the caches; pull, cp, and
+blob cache gc evict the least recently used entries to stay under it.
+Archives pinned with blob cache pin are kept apart and never evicted.`,
 }
 
 func init() {
 	Cmd.AddCommand(statusCmd)
+	Cmd.AddCommand(statsCmd)
+	Cmd.AddCommand(lsCmd)
 	Cmd.AddCommand(clearCmd)
+	Cmd.AddCommand(gcCmd)
 	Cmd.AddCommand(pathCmd)
 }
@@ -11,7 +11,6 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
@@ -55,7 +54,7 @@ func runClear(cmd *cobra.Command, args []string) error {
 		return errors.New("configuration not loaded")
 	}
 
-	targetType, typesToClear, err := parseClearArgs(args)
+	targetType, typesToClear, err := parseCacheTypeArgs(args)
 	if err != nil {
 		return err
 	}
@@ -73,7 +72,7 @@ func runClear(cmd *cobra.Command, args []string) error {
 	totalSize, totalFiles := calculateCacheSizes(cacheDir, typesToClear)
 
 	// Require --force for non-interactive (JSON) output
-	if viper.GetString("output") == internalcfg.OutputJSON && !force {
+	if cfg.Output == internalcfg.OutputJSON && !force {
 		return errors.New("--force required when using --output json")
 	}
 
@@ -96,8 +95,9 @@ func runClear(cmd *cobra.Command, args []string) error {
 	return outputClearResult(cfg, result)
 }
 
-// parseClearArgs parses and validates the cache type argument.
-func parseClearArgs(args []string) (string, []cacheType, error) {
+// parseCacheTypeArgs parses and validates the cache type argument, which
+// defaults to all, and returns the cache types it selects.
+func parseCacheTypeArgs(args []string) (string, []cacheType, error) {
 	targetType := cacheTypeAll
 	if len(args) > 0 {
 		targetType = args[0]
@@ -184,7 +184,7 @@ func outputClearResult(cfg *internalcfg.Config, result *clearResult) error {
 	if cfg.Quiet {
 		return nil
 	}
-	if viper.GetString("output") == internalcfg.OutputJSON {
+	if cfg.Output == internalcfg.OutputJSON {
 		return clearJSON(result)
 	}
 	return clearText(result)
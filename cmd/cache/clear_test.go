@@ -6,7 +6,7 @@ import (
 	"testing"
 )
 
-func TestParseClearArgs(t *testing.T) {
+func TestParseCacheTypeArgs(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
@@ -53,7 +53,7 @@ func TestParseClearArgs(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			gotType, gotTypes, err := parseClearArgs(tt.args)
+			gotType, gotTypes, err := parseCacheTypeArgs(tt.args)
 
 			if tt.wantErr {
 				if err == nil {
@@ -70,11 +70,11 @@ func TestParseClearArgs(t *testing.T) {
 			}
 
 			if gotType != tt.wantType {
-				t.Errorf("parseClearArgs() type = %q, want %q", gotType, tt.wantType)
+				t.Errorf("parseCacheTypeArgs() type = %q, want %q", gotType, tt.wantType)
 			}
 
 			if len(gotTypes) != tt.wantCount {
-				t.Errorf("parseClearArgs() types count = %d, want %d", len(gotTypes), tt.wantCount)
+				t.Errorf("parseCacheTypeArgs() types count = %d, want %d", len(gotTypes), tt.wantCount)
 			}
 		})
 	}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	"github.com/meigma/blob-cli/internal/cachegc"
+	"github.com/meigma/blob-cli/internal/cachestats"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Evict least recently used entries until the caches fit cache.max_size",
+	Long: `Evict least recently used entries until the caches fit cache.max_size.
+
+The combined size of the content, blocks, refs, manifests, and indexes
+caches is compared with cache.max_size, and the entries used least
+recently are removed until it fits. pull and cp do the same after every
+run, so this is only needed to shrink the caches right away, for example
+after lowering the limit.
+
+An entry's last use is the later of its access and modification times.
+Where the file system does not record access times, it is when the entry
+was cached.
+
+Use --dry-run to list what would be evicted, and --max-size to collect to
+a different limit than the configured one.`,
+	Example: `  blob cache gc
+  blob cache gc --dry-run
+  blob cache gc --max-size 500MB --output json`,
+	Args: cobra.NoArgs,
+	RunE: runGC,
+}
+
+func init() {
+	gcCmd.Flags().Bool("dry-run", false, "show what would be evicted without removing anything")
+	gcCmd.Flags().String("max-size", "", "collect to this size instead of cache.max_size (e.g. 500MB)")
+}
+
+// gcCacheSummary sums the entries evicted from one cache.
+type gcCacheSummary struct {
+	Name  string `json:"name"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// gcResult contains the gc output data.
+type gcResult struct {
+	Root string `json:"root"`
+	*cachegc.Result
+	MaxSizeHuman      string           `json:"max_size_human"`
+	SizeAfterHuman    string           `json:"size_after_human"`
+	EvictedBytesHuman string           `json:"evicted_bytes_human"`
+	Caches            []gcCacheSummary `json:"caches"`
+}
+
+func runGC(cmd *cobra.Command, _ []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return fmt.Errorf("reading dry-run flag: %w", err)
+	}
+	maxSizeFlag, err := cmd.Flags().GetString("max-size")
+	if err != nil {
+		return fmt.Errorf("reading max-size flag: %w", err)
+	}
+
+	limits := cfg.Cache
+	if maxSizeFlag != "" {
+		limits.MaxSize = maxSizeFlag
+	}
+	maxSize, err := limits.MaxSizeBytes()
+	if err != nil {
+		return err
+	}
+	if maxSize <= 0 {
+		return errors.New("cache.max_size is not set; set it or give --max-size")
+	}
+
+	cacheDir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return fmt.Errorf("determining cache directory: %w", err)
+	}
+
+	opts := cachegc.Options{DryRun: dryRun}
+	if !dryRun {
+		opts.Stats = cachestats.NewRecorder(cacheDir, 0)
+	}
+	collected, err := cachegc.Collect(cacheDir, maxSize, opts)
+	if err != nil {
+		return fmt.Errorf("collecting caches: %w", err)
+	}
+	if opts.Stats != nil {
+		_ = opts.Stats.Flush() //nolint:errcheck // stats are best-effort
+	}
+
+	if cfg.Quiet {
+		return nil
+	}
+	result := newGCResult(cacheDir, collected)
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	gcText(result)
+	return nil
+}
+
+// newGCResult summarizes collected for output.
+func newGCResult(cacheDir string, collected *cachegc.Result) *gcResult {
+	result := &gcResult{
+		Root:              cacheDir,
+		Result:            collected,
+		MaxSizeHuman:      formatSize(collected.MaxSize),
+		SizeAfterHuman:    formatSize(collected.SizeAfter),
+		EvictedBytesHuman: formatSize(collected.EvictedBytes),
+		Caches:            []gcCacheSummary{},
+	}
+	for _, ct := range cacheTypes {
+		summary := gcCacheSummary{Name: ct.Name}
+		for _, e := range collected.Evicted {
+			if e.Cache == ct.Name {
+				summary.Files++
+				summary.Bytes += e.Size
+			}
+		}
+		if summary.Files > 0 {
+			result.Caches = append(result.Caches, summary)
+		}
+	}
+	return result
+}
+
+func gcText(result *gcResult) {
+	if len(result.Evicted) == 0 {
+		fmt.Printf("Caches use %s of %s; nothing to evict\n", formatSize(result.SizeBefore), result.MaxSizeHuman)
+		return
+	}
+	verb := "Evicted"
+	if result.DryRun {
+		verb = "Would evict"
+	}
+	fmt.Printf("%s %d files (%s)\n", verb, len(result.Evicted), result.EvictedBytesHuman)
+	for _, c := range result.Caches {
+		fmt.Printf("  %-10s %d files, %s\n", c.Name+":", c.Files, formatSize(c.Bytes))
+	}
+	if result.DryRun {
+		fmt.Printf("Caches would use %s of %s\n", result.SizeAfterHuman, result.MaxSizeHuman)
+		return
+	}
+	fmt.Printf("Caches use %s of %s\n", result.SizeAfterHuman, result.MaxSizeHuman)
+}
+
+// formatSize formats a byte count that may be negative as zero.
+func formatSize(n int64) string {
+	return archive.FormatSize(uint64(max(0, n))) //nolint:gosec // clamped to non-negative
+}
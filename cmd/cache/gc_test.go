@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/meigma/blob-cli/internal/cachegc"
+)
+
+func TestNewGCResult(t *testing.T) {
+	t.Parallel()
+
+	result := newGCResult("/cache", &cachegc.Result{
+		MaxSize:      2048,
+		SizeBefore:   4096,
+		SizeAfter:    1024,
+		EvictedBytes: 3072,
+		Evicted: []cachegc.Entry{
+			{Cache: "manifests", Path: "/cache/manifests/aa/aa01", Size: 1024, LastUsed: time.Now()},
+			{Cache: "content", Path: "/cache/content/bb/bb01", Size: 1024, LastUsed: time.Now()},
+			{Cache: "content", Path: "/cache/content/cc/cc01", Size: 1024, LastUsed: time.Now()},
+		},
+	})
+
+	if result.MaxSizeHuman != "2.0K" || result.SizeAfterHuman != "1.0K" || result.EvictedBytesHuman != "3.0K" {
+		t.Errorf("human sizes = %q, %q, %q", result.MaxSizeHuman, result.SizeAfterHuman, result.EvictedBytesHuman)
+	}
+	want := []gcCacheSummary{
+		{Name: "content", Files: 2, Bytes: 2048},
+		{Name: "manifests", Files: 1, Bytes: 1024},
+	}
+	if len(result.Caches) != len(want) {
+		t.Fatalf("Caches = %+v, want %+v", result.Caches, want)
+	}
+	for i := range want {
+		if result.Caches[i] != want[i] {
+			t.Errorf("Caches[%d] = %+v, want %+v", i, result.Caches[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+var lsCmd = &cobra.Command{
+	Use:   "ls [type]",
+	Short: "List cached entries",
+	Long: `List the entries in the local caches.
+
+Each entry is shown with its key, size, and the time it was cached. The
+caches do not record hits, so the time is when the entry was last written.
+
+Entries in the refs, manifests, and indexes caches are shown with the
+references they belong to where these are known: recently used references,
+aliases, and default_ref. A reference cache entry is stored under a hash of
+its reference, so entries for other references are listed without one.
+
+Cache types:
+  content    File content cache
+  blocks     HTTP range block cache
+  refs       Tag to digest mappings
+  manifests  OCI manifest cache
+  indexes    Archive index cache
+  all        All caches (default)`,
+	Example: `  blob cache ls
+  blob cache ls manifests
+  blob cache ls refs --output json`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeCacheType,
+	RunE:              runLs,
+}
+
+// lsEntry describes a single cached entry.
+type lsEntry struct {
+	Cache     string    `json:"cache"`
+	Key       string    `json:"key"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	SizeHuman string    `json:"size_human"`
+	Cached    time.Time `json:"cached"`
+	Digest    string    `json:"digest,omitempty"`
+	Refs      []string  `json:"refs,omitempty"`
+}
+
+// lsResult contains the ls output data.
+type lsResult struct {
+	Root           string    `json:"root"`
+	Entries        []lsEntry `json:"entries"`
+	TotalEntries   int       `json:"total_entries"`
+	TotalSize      int64     `json:"total_size"`
+	TotalSizeHuman string    `json:"total_size_human"`
+}
+
+func runLs(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	_, types, err := parseCacheTypeArgs(args)
+	if err != nil {
+		return err
+	}
+
+	cacheDir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return fmt.Errorf("determining cache directory: %w", err)
+	}
+
+	result := collectEntries(cacheDir, types, knownRefs(cfg, cacheDir))
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	return lsText(result)
+}
+
+// completeCacheType completes the cache type argument.
+func completeCacheType(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return cacheTypeNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// collectEntries lists the entries of the given cache types under cacheDir,
+// newest first within each cache, and attaches the known references to
+// refs, manifest, and index entries.
+func collectEntries(cacheDir string, types []cacheType, refs []string) *lsResult {
+	result := &lsResult{Root: cacheDir, Entries: []lsEntry{}}
+	var all []lsEntry
+	for _, ct := range types {
+		entries := listCacheDir(ct.Name, filepath.Join(cacheDir, ct.SubDir))
+		slices.SortStableFunc(entries, func(a, b lsEntry) int {
+			return b.Cached.Compare(a.Cached)
+		})
+		all = append(all, entries...)
+	}
+	// Associations cross caches, so they are worked out from the caches on
+	// disk rather than only the listed ones.
+	assoc := associateRefs(cacheDir, refs)
+	for _, e := range all {
+		e.Refs = assoc[e.Cache+"/"+e.Key]
+		result.Entries = append(result.Entries, e)
+		result.TotalSize += e.Size
+	}
+	result.TotalEntries = len(result.Entries)
+	result.TotalSizeHuman = formatSize(result.TotalSize)
+	return result
+}
+
+// listCacheDir returns the entries in the cache directory dir. Files are
+// sharded into subdirectories by a prefix of their name; partially written
+// files, whose names are not hex, are skipped.
+func listCacheDir(name, dir string) []lsEntry {
+	var entries []lsEntry
+	//nolint:errcheck // a missing or unreadable cache lists no entries
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fs.SkipDir
+		}
+		if d.IsDir() || !isHex(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		entry := lsEntry{
+			Cache:     name,
+			Key:       entryKey(name, d.Name()),
+			Path:      path,
+			Size:      info.Size(),
+			SizeHuman: formatSize(info.Size()),
+			Cached:    info.ModTime(),
+		}
+		if name == "refs" {
+			if data, err := os.ReadFile(path); err == nil { //nolint:gosec // path is inside the cache dir
+				entry.Digest = strings.TrimSpace(string(data))
+			}
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries
+}
+
+// entryKey returns the key of a cache file. Files in the content,
+// manifests, and indexes caches are named after a SHA-256 digest.
+func entryKey(cache, name string) string {
+	switch cache {
+	case "content", "manifests", "indexes":
+		return "sha256:" + name
+	default:
+		return name
+	}
+}
+
+// isHex reports whether s is a non-empty lowercase hex string.
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// knownRefs returns the references whose cache entries can be named:
+// recently used references, aliases, and default_ref.
+func knownRefs(cfg *internalcfg.Config, cacheDir string) []string {
+	var refs []string
+	//nolint:gosec // path is derived from the cache dir
+	if data, err := os.ReadFile(filepath.Join(cacheDir, "completion", "refs.json")); err == nil {
+		var recent struct {
+			Refs []string `json:"refs"`
+		}
+		if json.Unmarshal(data, &recent) == nil {
+			refs = append(refs, recent.Refs...)
+		}
+	}
+	for name := range cfg.Aliases {
+		refs = append(refs, cfg.ResolveAlias(name))
+	}
+	if cfg.DefaultRef != "" {
+		refs = append(refs, cfg.ResolveAlias(cfg.DefaultRef))
+	}
+	slices.Sort(refs)
+	return slices.Compact(refs)
+}
+
+// associateRefs maps "cache/key" to the references a cache entry belongs
+// to. A refs entry is keyed by the hash of its reference; a manifest
+// belongs to the references that name its digest or are cached as
+// resolving to it, and an index to the references of the manifests that
+// list it as a layer.
+func associateRefs(cacheDir string, refs []string) map[string][]string {
+	assoc := make(map[string][]string)
+	add := func(key, ref string) {
+		if !slices.Contains(assoc[key], ref) {
+			assoc[key] = append(assoc[key], ref)
+		}
+	}
+
+	cached := make(map[string]string)
+	for _, e := range listCacheDir("refs", filepath.Join(cacheDir, "refs")) {
+		cached[e.Key] = e.Digest
+	}
+	for _, ref := range refs {
+		if _, dgst, ok := strings.Cut(ref, "@"); ok {
+			add("manifests/"+dgst, ref)
+		}
+		for _, candidate := range refCandidates(ref) {
+			sum := sha256.Sum256([]byte(candidate))
+			key := hex.EncodeToString(sum[:])
+			dgst, ok := cached[key]
+			if !ok {
+				continue
+			}
+			add("refs/"+key, ref)
+			add("manifests/"+dgst, ref)
+		}
+	}
+
+	for _, e := range listCacheDir("manifests", filepath.Join(cacheDir, "manifests")) {
+		manifestRefs := assoc["manifests/"+e.Key]
+		if len(manifestRefs) == 0 {
+			continue
+		}
+		for _, layer := range manifestLayers(e.Path) {
+			for _, ref := range manifestRefs {
+				add("indexes/"+layer, ref)
+			}
+		}
+	}
+	for _, v := range assoc {
+		slices.Sort(v)
+	}
+	return assoc
+}
+
+// refCandidates returns the forms ref may be cached under: as given, and
+// with the implicit latest tag if it has neither a tag nor a digest.
+func refCandidates(ref string) []string {
+	if strings.Contains(ref, "@") {
+		return []string{ref}
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return []string{ref}
+	}
+	return []string{ref, ref + ":latest"}
+}
+
+// manifestLayers returns the layer digests of the cached manifest at path.
+func manifestLayers(path string) []string {
+	data, err := os.ReadFile(path) //nolint:gosec // path is inside the cache dir
+	if err != nil {
+		return nil
+	}
+	var manifest struct {
+		Layers []struct {
+			Digest string `json:"digest"`
+		} `json:"layers"`
+	}
+	if json.Unmarshal(data, &manifest) != nil {
+		return nil
+	}
+	layers := make([]string, 0, len(manifest.Layers))
+	for _, l := range manifest.Layers {
+		layers = append(layers, l.Digest)
+	}
+	return layers
+}
+
+func lsText(result *lsResult) error {
+	if result.TotalEntries == 0 {
+		fmt.Println("No cached entries")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CACHE\tKEY\tSIZE\tCACHED\tREFS")
+	for _, e := range result.Entries {
+		refs := strings.Join(e.Refs, ", ")
+		if refs == "" {
+			refs = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			e.Cache, shortKey(e.Key), e.SizeHuman, e.Cached.Local().Format(time.DateTime), refs)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d entries, %s\n", result.TotalEntries, result.TotalSizeHuman)
+	return nil
+}
+
+// shortKey abbreviates a key to its algorithm and first 12 hex characters.
+func shortKey(key string) string {
+	algo, hexPart, ok := strings.Cut(key, ":")
+	if !ok {
+		algo, hexPart = "", key
+	}
+	if len(hexPart) > 12 {
+		hexPart = hexPart[:12]
+	}
+	return strings.TrimPrefix(algo+":"+hexPart, ":")
+}
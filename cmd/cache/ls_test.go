@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// writeCacheFile writes data to a sharded cache file named name in the
+// cache subdirectory sub, with the given modification time.
+func writeCacheFile(t *testing.T, dir, sub, name, data string, mtime time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, sub, name[:2], name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func hexSum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCollectEntries(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now()
+	manifestHex := hexSum("manifest")
+	indexHex := hexSum("index")
+	otherHex := hexSum("other")
+	manifest := `{"layers":[{"digest":"sha256:` + indexHex + `"}]}`
+
+	writeCacheFile(t, dir, "refs", hexSum("ghcr.io/acme/configs:v1"), "sha256:"+manifestHex, now)
+	writeCacheFile(t, dir, "refs", hexSum("ghcr.io/acme/unknown:v1"), "sha256:"+otherHex, now)
+	writeCacheFile(t, dir, "manifests", manifestHex, manifest, now.Add(-time.Hour))
+	writeCacheFile(t, dir, "manifests", otherHex, `{}`, now)
+	writeCacheFile(t, dir, "indexes", indexHex, "index", now)
+	writeCacheFile(t, dir, "content", hexSum("a"), "a", now)
+	// Partially written files are skipped
+	writeCacheFile(t, dir, "content", "ref-"+hexSum("b"), "b", now)
+
+	result := collectEntries(dir, cacheTypes, []string{"ghcr.io/acme/configs:v1"})
+	if result.TotalEntries != 6 {
+		t.Fatalf("TotalEntries = %d, want 6", result.TotalEntries)
+	}
+
+	byKey := make(map[string]lsEntry)
+	for _, e := range result.Entries {
+		byKey[e.Cache+"/"+e.Key] = e
+	}
+	want := []string{"ghcr.io/acme/configs:v1"}
+	for _, key := range []string{
+		"refs/" + hexSum("ghcr.io/acme/configs:v1"),
+		"manifests/sha256:" + manifestHex,
+		"indexes/sha256:" + indexHex,
+	} {
+		if got := byKey[key].Refs; !slices.Equal(got, want) {
+			t.Errorf("%s refs = %v, want %v", key, got, want)
+		}
+	}
+	if got := byKey["manifests/sha256:"+otherHex].Refs; got != nil {
+		t.Errorf("unknown manifest refs = %v, want none", got)
+	}
+	if got := byKey["refs/"+hexSum("ghcr.io/acme/configs:v1")].Digest; got != "sha256:"+manifestHex {
+		t.Errorf("ref digest = %q", got)
+	}
+
+	// Newest first within each cache
+	var manifests []string
+	for _, e := range result.Entries {
+		if e.Cache == "manifests" {
+			manifests = append(manifests, e.Key)
+		}
+	}
+	if !slices.Equal(manifests, []string{"sha256:" + otherHex, "sha256:" + manifestHex}) {
+		t.Errorf("manifest order = %v", manifests)
+	}
+}
+
+func TestCollectEntries_SingleType(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeCacheFile(t, dir, "content", hexSum("a"), "abc", time.Now())
+	writeCacheFile(t, dir, "blocks", hexSum("b"), "b", time.Now())
+
+	_, types, err := parseCacheTypeArgs([]string{"content"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := collectEntries(dir, types, nil)
+	if result.TotalEntries != 1 || result.Entries[0].Cache != "content" {
+		t.Fatalf("entries = %+v, want one content entry", result.Entries)
+	}
+	if result.TotalSize != 3 {
+		t.Errorf("TotalSize = %d, want 3", result.TotalSize)
+	}
+}
+
+func TestKnownRefs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "completion"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "completion", "refs.json"),
+		[]byte(`{"refs":["ghcr.io/acme/a:v1","ghcr.io/acme/b:v2"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &internalcfg.Config{
+		Aliases:    internalcfg.AliasMap{"b": "ghcr.io/acme/b:v2", "c": "ghcr.io/acme/c"},
+		DefaultRef: "ghcr.io/acme/d:v4",
+	}
+
+	got := knownRefs(cfg, dir)
+	want := []string{"ghcr.io/acme/a:v1", "ghcr.io/acme/b:v2", "ghcr.io/acme/c:latest", "ghcr.io/acme/d:v4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("knownRefs() = %v, want %v", got, want)
+	}
+}
+
+func TestShortKey(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("ab", 32)
+	tests := map[string]string{
+		"sha256:" + long: "sha256:abababababab",
+		long:             "abababababab",
+		"abc":            "abc",
+	}
+	for key, want := range tests {
+		if got := shortKey(key); got != want {
+			t.Errorf("shortKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
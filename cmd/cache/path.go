@@ -8,7 +8,6 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	internalcfg "github.com/meigma/blob-cli/internal/config"
 )
@@ -55,7 +54,7 @@ func runPath(cmd *cobra.Command, _ []string) error {
 		return nil
 	}
 
-	if viper.GetString("output") == internalcfg.OutputJSON {
+	if cfg.Output == internalcfg.OutputJSON {
 		return pathJSON(&result)
 	}
 	return pathText(&result)
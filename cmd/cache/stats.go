@@ -0,0 +1,298 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	"github.com/meigma/blob-cli/internal/cachestats"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// defaultStatsInterval is the default refresh interval for --watch.
+const defaultStatsInterval = 2 * time.Second
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache hit/miss rates and evictions",
+	Long: `Show cache hit/miss rates and evictions.
+
+Every blob process that uses the cache records hits, misses, and evictions
+in a shared log in the cache directory. This command sums the log and shows
+the totals next to the current size of each cache.
+
+With --watch, the view refreshes every --interval while other blob
+processes run. With --output json, each refresh is written as one JSON
+line (NDJSON) including the change since the previous sample.
+
+Use --reset to start counting from zero.`,
+	Example: `  blob cache stats
+  blob cache stats --watch
+  blob cache stats --watch --interval 5s --output json
+  blob cache stats --reset`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+func init() {
+	statsCmd.Flags().BoolP("watch", "w", false, "refresh the view until interrupted")
+	statsCmd.Flags().Duration("interval", defaultStatsInterval, "refresh interval for --watch")
+	statsCmd.Flags().Bool("reset", false, "clear recorded statistics")
+}
+
+// statsFlags holds the parsed command flags.
+type statsFlags struct {
+	watch    bool
+	interval time.Duration
+	reset    bool
+}
+
+// statsEntry holds sizes and counters for a single cache type.
+type statsEntry struct {
+	Name         string               `json:"name"`
+	Enabled      bool                 `json:"enabled"`
+	Size         int64                `json:"size"`
+	SizeHuman    string               `json:"size_human"`
+	Files        int                  `json:"files"`
+	Hits         int64                `json:"hits"`
+	Misses       int64                `json:"misses"`
+	HitRate      float64              `json:"hit_rate"`
+	Evictions    int64                `json:"evictions"`
+	EvictedBytes int64                `json:"evicted_bytes"`
+	Delta        *cachestats.Counters `json:"delta,omitempty"`
+}
+
+// statsResult contains the stats output data.
+type statsResult struct {
+	Root      string       `json:"root"`
+	Timestamp time.Time    `json:"timestamp"`
+	Caches    []statsEntry `json:"caches"`
+	Total     statsEntry   `json:"total"`
+}
+
+func runStats(cmd *cobra.Command, _ []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	flags, err := parseStatsFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	cacheDir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return fmt.Errorf("determining cache directory: %w", err)
+	}
+
+	if flags.reset {
+		if err := cachestats.Reset(cacheDir); err != nil {
+			return err
+		}
+		if !cfg.Quiet && cfg.Output != internalcfg.OutputJSON {
+			fmt.Println("Cache statistics reset")
+		}
+		return nil
+	}
+
+	jsonOutput := cfg.Output == internalcfg.OutputJSON
+
+	if !flags.watch {
+		result, err := collectStats(cfg, cacheDir, nil)
+		if err != nil {
+			return err
+		}
+		if cfg.Quiet {
+			return nil
+		}
+		if jsonOutput {
+			return statsJSON(os.Stdout, result, "  ")
+		}
+		return statsText(os.Stdout, result)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	var prev cachestats.Snapshot
+	return watchStats(ctx, flags.interval, func() error {
+		result, err := collectStats(cfg, cacheDir, prev)
+		if err != nil {
+			return err
+		}
+		prev = snapshotOf(result)
+		if jsonOutput {
+			return statsJSON(os.Stdout, result, "")
+		}
+		fmt.Print(clearScreen)
+		fmt.Printf("Every %s, updated %s (Ctrl-C to stop)\n\n", flags.interval, result.Timestamp.Local().Format(time.TimeOnly))
+		return statsText(os.Stdout, result)
+	})
+}
+
+// parseStatsFlags extracts and validates flags from the command.
+func parseStatsFlags(cmd *cobra.Command) (statsFlags, error) {
+	var flags statsFlags
+	var err error
+
+	flags.watch, err = cmd.Flags().GetBool("watch")
+	if err != nil {
+		return flags, fmt.Errorf("reading watch flag: %w", err)
+	}
+
+	flags.interval, err = cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return flags, fmt.Errorf("reading interval flag: %w", err)
+	}
+	if flags.interval <= 0 {
+		return flags, errors.New("--interval must be positive")
+	}
+
+	flags.reset, err = cmd.Flags().GetBool("reset")
+	if err != nil {
+		return flags, fmt.Errorf("reading reset flag: %w", err)
+	}
+	if flags.reset && flags.watch {
+		return flags, errors.New("--reset cannot be used with --watch")
+	}
+
+	return flags, nil
+}
+
+// watchStats calls sample immediately and then every interval until ctx is done.
+func watchStats(ctx context.Context, interval time.Duration, sample func() error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := sample(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectStats reads cache sizes and recorded counters. If prev is non-nil,
+// each entry includes the change since prev.
+func collectStats(cfg *internalcfg.Config, cacheDir string, prev cachestats.Snapshot) (*statsResult, error) {
+	counters, err := cachestats.Read(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &statsResult{
+		Root:      cacheDir,
+		Timestamp: time.Now(),
+		Caches:    make([]statsEntry, 0, len(cacheTypes)),
+	}
+
+	var total, totalDelta cachestats.Counters
+	var totalSize int64
+	var totalFiles int
+	for _, ct := range cacheTypes {
+		path := filepath.Join(cacheDir, ct.SubDir)
+		size := getDirSize(path)
+		entry := newStatsEntry(ct.Name, counters[ct.Name])
+		entry.Enabled = isCacheTypeEnabled(cfg, ct.Name)
+		entry.Size = size
+		entry.SizeHuman = archive.FormatSize(uint64(max(0, size))) //nolint:gosec // size is always non-negative
+		entry.Files = countFiles(path)
+		if prev != nil {
+			delta := counters[ct.Name].Sub(prev[ct.Name])
+			entry.Delta = &delta
+			totalDelta = totalDelta.Add(delta)
+		}
+
+		result.Caches = append(result.Caches, entry)
+		total = total.Add(counters[ct.Name])
+		totalSize += entry.Size
+		totalFiles += entry.Files
+	}
+
+	result.Total = newStatsEntry("total", total)
+	result.Total.Enabled = cfg.Cache.Enabled
+	result.Total.Size = totalSize
+	result.Total.SizeHuman = archive.FormatSize(uint64(max(0, totalSize))) //nolint:gosec // size is always non-negative
+	result.Total.Files = totalFiles
+	if prev != nil {
+		result.Total.Delta = &totalDelta
+	}
+
+	return result, nil
+}
+
+func newStatsEntry(name string, c cachestats.Counters) statsEntry {
+	return statsEntry{
+		Name:         name,
+		Hits:         c.Hits,
+		Misses:       c.Misses,
+		HitRate:      c.HitRate(),
+		Evictions:    c.Evictions,
+		EvictedBytes: c.EvictedBytes,
+	}
+}
+
+// snapshotOf extracts the counters from a result for computing deltas.
+func snapshotOf(result *statsResult) cachestats.Snapshot {
+	snap := make(cachestats.Snapshot, len(result.Caches))
+	for _, e := range result.Caches {
+		snap[e.Name] = cachestats.Counters{
+			Hits:         e.Hits,
+			Misses:       e.Misses,
+			Evictions:    e.Evictions,
+			EvictedBytes: e.EvictedBytes,
+		}
+	}
+	return snap
+}
+
+func statsJSON(w io.Writer, result *statsResult, indent string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", indent)
+	return enc.Encode(result)
+}
+
+func statsText(w io.Writer, result *statsResult) error {
+	fmt.Fprintf(w, "Cache directory: %s\n\n", result.Root)
+
+	fmt.Fprintf(w, "  %-10s  %8s  %6s  %8s  %8s  %8s  %9s  %8s\n",
+		"CACHE", "SIZE", "FILES", "HITS", "MISSES", "HIT RATE", "EVICTIONS", "EVICTED")
+	for _, e := range result.Caches {
+		printStatsRow(w, &e)
+	}
+	fmt.Fprintln(w)
+	printStatsRow(w, &result.Total)
+
+	return nil
+}
+
+func printStatsRow(w io.Writer, e *statsEntry) {
+	rate := "-"
+	if e.Hits+e.Misses > 0 {
+		rate = fmt.Sprintf("%.1f%%", e.HitRate*100)
+	}
+	status := ""
+	if !e.Enabled {
+		status = " (disabled)"
+	}
+	evicted := archive.FormatSize(uint64(max(0, e.EvictedBytes))) //nolint:gosec // evicted bytes are non-negative
+	fmt.Fprintf(w, "  %-10s  %8s  %6d  %8d  %8d  %8s  %9d  %8s%s\n",
+		e.Name, e.SizeHuman, e.Files, e.Hits, e.Misses, rate, e.Evictions, evicted, status)
+}
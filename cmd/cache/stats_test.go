@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meigma/blob-cli/internal/cachestats"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestCollectStats(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "content"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "content", "a"), []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := cachestats.NewRecorder(dir, 0)
+	rec.Hit("content")
+	rec.Hit("content")
+	rec.Hit("content")
+	rec.Miss("content")
+	rec.Evict("refs", 71)
+	if err := rec.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &internalcfg.Config{Cache: internalcfg.CacheConfig{Enabled: true}}
+	result, err := collectStats(cfg, dir, nil)
+	if err != nil {
+		t.Fatalf("collectStats() error = %v", err)
+	}
+
+	if len(result.Caches) != len(cacheTypes) {
+		t.Fatalf("got %d caches, want %d", len(result.Caches), len(cacheTypes))
+	}
+	content := result.Caches[0]
+	if content.Name != "content" || content.Hits != 3 || content.Misses != 1 || content.Size != 5 {
+		t.Errorf("content = %+v, want 3 hits, 1 miss, 5 bytes", content)
+	}
+	if content.HitRate != 0.75 {
+		t.Errorf("content hit rate = %v, want 0.75", content.HitRate)
+	}
+	if content.Delta != nil {
+		t.Error("delta should be nil without a previous sample")
+	}
+	if result.Total.Evictions != 1 || result.Total.EvictedBytes != 71 {
+		t.Errorf("total = %+v, want 1 eviction of 71 bytes", result.Total)
+	}
+
+	// A second sample reports the change since the first.
+	rec.Miss("content")
+	if err := rec.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	next, err := collectStats(cfg, dir, snapshotOf(result))
+	if err != nil {
+		t.Fatalf("collectStats() error = %v", err)
+	}
+	if d := next.Caches[0].Delta; d == nil || d.Misses != 1 || d.Hits != 0 {
+		t.Errorf("content delta = %+v, want 1 miss", d)
+	}
+	if d := next.Total.Delta; d == nil || d.Misses != 1 {
+		t.Errorf("total delta = %+v, want 1 miss", d)
+	}
+}
+
+func TestStatsText(t *testing.T) {
+	t.Parallel()
+
+	result := &statsResult{
+		Root: "/cache",
+		Caches: []statsEntry{
+			{Name: "content", Enabled: true, SizeHuman: "5 B", Hits: 3, Misses: 1, HitRate: 0.75},
+			{Name: "blocks", Enabled: false, SizeHuman: "0 B"},
+		},
+		Total: statsEntry{Name: "total", Enabled: true, SizeHuman: "5 B", Hits: 3, Misses: 1, HitRate: 0.75},
+	}
+
+	var buf bytes.Buffer
+	if err := statsText(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{"Cache directory: /cache", "HIT RATE", "75.0%", "(disabled)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWatchStats(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	samples := 0
+	err := watchStats(ctx, time.Millisecond, func() error {
+		samples++
+		if samples == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("watchStats() error = %v", err)
+	}
+	if samples != 3 {
+		t.Errorf("samples = %d, want 3", samples)
+	}
+}
+
+func TestParseStatsFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   map[string]string
+		wantErr string
+	}{
+		{name: "defaults"},
+		{name: "zero interval", flags: map[string]string{"interval": "0s"}, wantErr: "--interval must be positive"},
+		{name: "reset with watch", flags: map[string]string{"reset": "true", "watch": "true"}, wantErr: "cannot be used with --watch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.flags {
+				if err := statsCmd.Flags().Set(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Cleanup(func() {
+				_ = statsCmd.Flags().Set("interval", defaultStatsInterval.String())
+				_ = statsCmd.Flags().Set("reset", "false")
+				_ = statsCmd.Flags().Set("watch", "false")
+			})
+
+			flags, err := parseStatsFlags(statsCmd)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if flags.interval != defaultStatsInterval {
+					t.Errorf("interval = %v, want %v", flags.interval, defaultStatsInterval)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
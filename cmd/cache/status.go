@@ -8,7 +8,6 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
@@ -85,7 +84,7 @@ func runStatus(cmd *cobra.Command, _ []string) error {
 		return nil
 	}
 
-	if viper.GetString("output") == internalcfg.OutputJSON {
+	if cfg.Output == internalcfg.OutputJSON {
 		return statusJSON(&result)
 	}
 	return statusText(&result)
@@ -1,12 +1,11 @@
 package cache
 
 import (
-	"fmt"
 	"io/fs"
-	"os"
 	"path/filepath"
 
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 // cacheTypeAll is the special cache type name for all caches.
@@ -79,7 +78,7 @@ func getDirSize(dir string) int64 {
 		return nil
 	})
 	if hadError {
-		fmt.Fprintf(os.Stderr, "Warning: some files in %s could not be accessed; size may be incomplete\n", dir)
+		warn.Printf("some files in %s could not be accessed; size may be incomplete", dir)
 	}
 	return size
 }
@@ -101,7 +100,7 @@ func countFiles(dir string) int {
 		return nil
 	})
 	if hadError {
-		fmt.Fprintf(os.Stderr, "Warning: some files in %s could not be accessed; count may be incomplete\n", dir)
+		warn.Printf("some files in %s could not be accessed; count may be incomplete", dir)
 	}
 	return count
 }
@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/meigma/blob"
+	blobcore "github.com/meigma/blob/core"
+	"github.com/spf13/cobra"
+
+	"github.com/meigma/blob-cli/cmd/cache"
+	"github.com/meigma/blob-cli/internal/archive"
+	"github.com/meigma/blob-cli/internal/cachepin"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+var cachePinCmd = &cobra.Command{
+	Use:   "pin [ref]...",
+	Short: "Keep archives in the cache for offline use",
+	Long: `Keep archives in the cache for offline use.
+
+Downloads each archive whole, verifying it against the config policies as
+pull does, and keeps it in the cache directory apart from the caches that
+blob cache gc and blob cache clear manage, so it is never evicted.
+
+With --offline, pull, cat, ls, and cp read a pinned reference from the
+cache without contacting the registry. A pinned tag keeps pointing at the
+digest it was pinned at; pin it again to update it. Pinned archives are not
+verified again when they are read, so a pin that was not verified is
+refused once a policy applies to it. Online, the registry is read as usual.
+
+With no arguments, lists the pinned references, as blob cache pins does.`,
+	Example: `  blob cache pin ghcr.io/acme/configs:v1.0.0
+  blob cache pin foo:v1 foo:v2                      # Using aliases
+  blob cache pin                                    # List pins`,
+	RunE: runCachePin,
+}
+
+var cachePinsCmd = &cobra.Command{
+	Use:   "pins",
+	Short: "List archives pinned with blob cache pin",
+	Long: `List archives pinned with blob cache pin.
+
+Shows each pinned reference with the digest it was pinned at, the size
+of the archive kept for it, and when it was pinned.`,
+	Example: `  blob cache pins
+  blob cache pins --output json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runCachePin(cmd, nil)
+	},
+}
+
+var cacheUnpinCmd = &cobra.Command{
+	Use:   "unpin <ref>...",
+	Short: "Remove archives pinned with blob cache pin",
+	Long: `Remove archives pinned with blob cache pin.
+
+An archive pinned by several references is removed with the last of them.`,
+	Example: `  blob cache unpin ghcr.io/acme/configs:v1.0.0
+  blob cache unpin foo:v1                           # Using alias`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completePinnedRefs,
+	RunE:              runCacheUnpin,
+}
+
+func init() {
+	cache.Cmd.AddCommand(cachePinCmd)
+	cache.Cmd.AddCommand(cachePinsCmd)
+	cache.Cmd.AddCommand(cacheUnpinCmd)
+}
+
+// pinResult describes a pinned reference.
+type pinResult struct {
+	Ref       string    `json:"ref"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	SizeHuman string    `json:"size_human"`
+	Verified  bool      `json:"verified"`
+	PinnedAt  time.Time `json:"pinned_at"`
+}
+
+func runCachePin(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+	cacheDir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return fmt.Errorf("resolving cache directory: %w", err)
+	}
+
+	var results []pinResult
+	if len(args) == 0 {
+		pins, err := cachepin.List(cacheDir)
+		if err != nil {
+			return err
+		}
+		for _, p := range pins {
+			results = append(results, newPinResult(p))
+		}
+	}
+	for _, arg := range args {
+		p, err := pinArchive(cmd.Context(), cfg, cacheDir, arg)
+		if err != nil {
+			return err
+		}
+		results = append(results, newPinResult(p))
+	}
+
+	if cfg.Quiet {
+		return nil
+	}
+	if results == nil {
+		results = []pinResult{}
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]any{"pins": results})
+	}
+	if len(args) > 0 {
+		for _, r := range results {
+			fmt.Printf("Pinned %s\n", r.Ref)
+			fmt.Printf("  Digest: %s\n", r.Digest)
+			fmt.Printf("  Size: %s\n", r.SizeHuman)
+		}
+		return nil
+	}
+	if len(results) == 0 {
+		fmt.Println("No pinned references")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REF\tDIGEST\tSIZE\tPINNED")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Ref, shortDigest(r.Digest), r.SizeHuman, r.PinnedAt.Local().Format(time.DateTime))
+	}
+	return w.Flush()
+}
+
+// pinArchive downloads the archive at ref, enforcing the config policies,
+// and pins it in cacheDir.
+func pinArchive(ctx context.Context, cfg *internalcfg.Config, cacheDir, ref string) (cachepin.Pin, error) {
+	resolved, err := resolveRef(ctx, cfg, ref)
+	if err != nil {
+		return cachepin.Pin{}, err
+	}
+	policyOpts, verification, err := readPolicyOpts(cfg, resolved, false)
+	if err != nil {
+		return cachepin.Pin{}, err
+	}
+	client, err := newClient(cfg, policyOpts...)
+	if err != nil {
+		return cachepin.Pin{}, fmt.Errorf("creating client: %w", err)
+	}
+	manifest, err := fetchPullManifest(ctx, client, resolved, false)
+	if err != nil {
+		return cachepin.Pin{}, err
+	}
+	digest := manifest.Digest()
+
+	indexPath := cachepin.IndexPath(cacheDir, digest)
+	dataPath := cachepin.DataPath(cacheDir, digest)
+	if _, err := os.Stat(dataPath); err != nil {
+		blobArchive, err := client.Pull(ctx, repositoryOf(resolved)+"@"+digest)
+		if err != nil {
+			return cachepin.Pin{}, fmt.Errorf("pulling archive: %w", err)
+		}
+		if err := blobArchive.Save(indexPath, dataPath); err != nil {
+			return cachepin.Pin{}, fmt.Errorf("saving archive: %w", err)
+		}
+		want := manifest.DataDescriptor().Digest.String()
+		if got, err := fileDigest(dataPath); err != nil || got != want {
+			os.RemoveAll(cachepin.ArchiveDir(cacheDir, digest)) //nolint:errcheck // best-effort cleanup
+			return cachepin.Pin{}, fmt.Errorf("pinned data of %s does not match digest %s", resolved, want)
+		}
+	}
+
+	pin := cachepin.Pin{
+		Ref:         resolved,
+		Repository:  repositoryOf(resolved),
+		Digest:      digest,
+		Annotations: manifest.Annotations(),
+		Size:        manifest.DataDescriptor().Size + manifest.IndexDescriptor().Size,
+		Verified:    verification.Verified,
+		PinnedAt:    time.Now().UTC(),
+	}
+	if err := cachepin.Add(cacheDir, pin); err != nil {
+		return cachepin.Pin{}, err
+	}
+	return pin, nil
+}
+
+func runCacheUnpin(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+	cacheDir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return fmt.Errorf("resolving cache directory: %w", err)
+	}
+
+	results := make([]pinResult, 0, len(args))
+	for _, arg := range args {
+		p, err := cachepin.Remove(cacheDir, cfg.ResolveAlias(arg))
+		if err != nil {
+			return err
+		}
+		results = append(results, newPinResult(p))
+	}
+
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]any{"unpinned": results})
+	}
+	for _, r := range results {
+		fmt.Printf("Unpinned %s\n", r.Ref)
+	}
+	return nil
+}
+
+// completePinnedRefs completes the pinned references.
+func completePinnedRefs(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := completionConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cacheDir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	pins, _ := cachepin.List(cacheDir) //nolint:errcheck // completion is best-effort
+	refs := make([]string, 0, len(pins))
+	for _, p := range pins {
+		refs = append(refs, p.Ref)
+	}
+	return refs, cobra.ShellCompDirectiveNoFileComp
+}
+
+func newPinResult(p cachepin.Pin) pinResult {
+	return pinResult{
+		Ref:       p.Ref,
+		Digest:    p.Digest,
+		Size:      p.Size,
+		SizeHuman: archive.FormatSize(uint64(max(p.Size, 0))), //nolint:gosec // clamped to non-negative
+		Verified:  p.Verified,
+		PinnedAt:  p.PinnedAt,
+	}
+}
+
+// checkPinVerified refuses a pin that was not verified when it was
+// pinned if policies now apply to its archive, since they cannot be
+// evaluated against it without the registry.
+func checkPinVerified(ref string, pin cachepin.Pin, policies int) error {
+	if pin.Verified || policies == 0 {
+		return nil
+	}
+	return &ExitError{
+		Code: exitCodePolicyViolation,
+		Err:  fmt.Errorf("pinned archive %s was not verified when it was pinned, but %d policies apply to it: pin it again to verify it", ref, policies),
+	}
+}
+
+// checkPinnedRead checks that a command reading ref may read its pin, and
+// returns how the pin was verified: it must have been verified when it was
+// pinned if config policies apply to ref, unless noVerify skips them.
+func checkPinnedRead(cfg *internalcfg.Config, ref string, pin cachepin.Pin, noVerify bool) (verificationStatus, error) {
+	_, verification, err := readPolicyOpts(cfg, ref, noVerify)
+	if err != nil {
+		return verificationStatus{}, err
+	}
+	if err := checkPinVerified(ref, pin, verification.PoliciesApplied); err != nil {
+		return verificationStatus{}, err
+	}
+	if noVerify {
+		return verificationStatus{}, nil
+	}
+	return verificationStatus{Verified: pin.Verified, PoliciesApplied: verification.PoliciesApplied}, nil
+}
+
+// openPinned opens the pinned archive for ref, if there is one.
+func openPinned(cfg *internalcfg.Config, ref string) (*blob.Archive, cachepin.Pin, bool) {
+	cacheDir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return nil, cachepin.Pin{}, false
+	}
+	p, ok := cachepin.Lookup(cacheDir, ref)
+	if !ok {
+		return nil, cachepin.Pin{}, false
+	}
+	f, err := blobcore.OpenFile(cachepin.IndexPath(cacheDir, p.Digest), cachepin.DataPath(cacheDir, p.Digest))
+	if err != nil {
+		return nil, cachepin.Pin{}, false
+	}
+	// The data file stays open for the rest of the command.
+	return &blob.Archive{Blob: f.Blob}, p, true
+}
+
+// pinnedIndex returns the index of the pinned archive for ref, if there
+// is one, without opening its data.
+func pinnedIndex(cfg *internalcfg.Config, ref string) (*blob.IndexView, cachepin.Pin, bool) {
+	cacheDir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return nil, cachepin.Pin{}, false
+	}
+	p, ok := cachepin.Lookup(cacheDir, ref)
+	if !ok {
+		return nil, cachepin.Pin{}, false
+	}
+	data, err := os.ReadFile(cachepin.IndexPath(cacheDir, p.Digest))
+	if err != nil {
+		return nil, cachepin.Pin{}, false
+	}
+	index, err := blobcore.NewIndexView(data)
+	if err != nil {
+		return nil, cachepin.Pin{}, false
+	}
+	return index, p, true
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/meigma/blob-cli/internal/cachepin"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestCachePin_Offline(t *testing.T) {
+	reg, srv := newTestRegistry(t)
+	repo := reg.repo
+	v1 := reg.addArchive(t, "v1", map[string]string{"etc/app.conf": "app"})
+
+	cfg := newRegistryTestConfig(t)
+	cacheDir := cfg.Cache.Dir
+	ctx := internalcfg.WithConfig(context.Background(), cfg)
+	cachePinCmd.SetContext(ctx)
+	require.NoError(t, cachePinCmd.RunE(cachePinCmd, []string{repo + ":v1"}))
+
+	pins, err := cachepin.List(cacheDir)
+	require.NoError(t, err)
+	require.Len(t, pins, 1)
+	assert.Equal(t, v1.Digest.String(), pins[0].Digest)
+	assert.FileExists(t, cachepin.DataPath(cacheDir, v1.Digest.String()))
+
+	listCfg := *cfg
+	listCfg.Quiet = false
+	listCfg.Output = internalcfg.OutputJSON
+	cachePinsCmd.SetContext(internalcfg.WithConfig(context.Background(), &listCfg))
+	out, err := captureStdout(t, func() error { return cachePinsCmd.RunE(cachePinsCmd, nil) })
+	require.NoError(t, err)
+	var listed struct {
+		Pins []pinResult `json:"pins"`
+	}
+	require.NoError(t, json.Unmarshal(out, &listed))
+	require.Len(t, listed.Pins, 1)
+	assert.Equal(t, repo+":v1", listed.Pins[0].Ref)
+	assert.Equal(t, v1.Digest.String(), listed.Pins[0].Digest)
+
+	// Online, the registry answers for a pinned tag, which may have moved
+	reg.addArchive(t, "v1", map[string]string{"etc/app.conf": "app v2"})
+	dest := t.TempDir()
+	cpCmd.SetContext(ctx)
+	require.NoError(t, cpCmd.RunE(cpCmd, []string{repo + ":v1:/etc/app.conf", dest + "/"}))
+	content, err := os.ReadFile(filepath.Join(dest, "etc", "app.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "app v2", string(content))
+
+	// Offline, reads of the pinned reference come from the pin
+	srv.Close()
+	cfg.Offline = true
+	transport, err := buildRegistryTransport(cfg, "")
+	require.NoError(t, err)
+	keepRegistryTransport(t)
+	useRegistryTransport(transport)
+	dest = t.TempDir()
+	require.NoError(t, cpCmd.RunE(cpCmd, []string{repo + ":v1:/etc/app.conf", dest + "/"}))
+	content, err = os.ReadFile(filepath.Join(dest, "etc", "app.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "app", string(content))
+
+	lsCmd.SetContext(ctx)
+	require.NoError(t, lsCmd.RunE(lsCmd, []string{repo + "@" + v1.Digest.String(), "/etc"}))
+	catCmd.SetContext(ctx)
+	require.NoError(t, catCmd.RunE(catCmd, []string{repo + ":v1", "etc/app.conf"}))
+
+	// The pin was not verified, so it is refused once a policy applies,
+	// unless --no-verify skips the policy
+	cfg.Policies = []internalcfg.PolicyRule{{Match: ".*", Policy: internalcfg.Policy{
+		Provenance: &internalcfg.ProvenancePolicy{SLSA: &internalcfg.SLSAConfig{Builder: "https://builder.acme.example"}},
+	}}}
+	refused := t.TempDir()
+	for _, run := range []func() error{
+		func() error { return cpCmd.RunE(cpCmd, []string{repo + ":v1:/etc/app.conf", refused + "/"}) },
+		func() error { return lsCmd.RunE(lsCmd, []string{repo + ":v1", "/etc"}) },
+		func() error { return catCmd.RunE(catCmd, []string{repo + ":v1", "etc/app.conf"}) },
+	} {
+		requireExitCode(t, run(), exitCodePolicyViolation)
+	}
+	assert.NoFileExists(t, filepath.Join(refused, "etc", "app.conf"))
+
+	require.NoError(t, cpCmd.Flags().Set("no-verify", "true"))
+	err = cpCmd.RunE(cpCmd, []string{repo + ":v1:/etc/app.conf", refused + "/"})
+	require.NoError(t, cpCmd.Flags().Set("no-verify", "false"))
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(refused, "etc", "app.conf"))
+	cfg.Policies = nil
+
+	cacheUnpinCmd.SetContext(ctx)
+	require.NoError(t, cacheUnpinCmd.RunE(cacheUnpinCmd, []string{repo + ":v1"}))
+	assert.NoDirExists(t, cachepin.ArchiveDir(cacheDir, v1.Digest.String()))
+	requireExitCode(t, catCmd.RunE(catCmd, []string{repo + ":v1", "etc/app.conf"}), exitCodeNotFound)
+
+	err = cacheUnpinCmd.RunE(cacheUnpinCmd, []string{repo + ":v1"})
+	require.ErrorIs(t, err, cachepin.ErrNotPinned)
+}
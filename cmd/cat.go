@@ -1,15 +1,23 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/meigma/blob"
 	"github.com/spf13/cobra"
 
+	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/daemon"
+	"github.com/meigma/blob-cli/internal/transfer"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 var catCmd = &cobra.Command{
@@ -19,16 +27,70 @@ var catCmd = &cobra.Command{
 
 Useful for viewing, piping, or combining files from an archive.
 Uses HTTP range requests to fetch only the requested files without
-downloading the entire archive.`,
+downloading the entire archive. If "blob daemon" is running, the files
+are read through it.
+
+Files from several archives can be combined by giving each argument as
+<ref>:<path> (the path must start with /, as for "blob cp").
+
+--range start:end prints only bytes [start, end) of a single file;
+either bound may be omitted. For files stored uncompressed only the
+requested bytes are fetched. Partial output cannot be checked against
+the file's hash, so it is not verified.
+
+Config policies that match an archive are enforced as for "blob pull";
+--no-verify skips them. Such archives are read directly rather than
+through the daemon.
+
+With --output json, the registry requests, bytes transferred, and cache
+hits of a direct read are written to stderr as a JSON object, as the
+file contents take stdout.
+
+A missing archive exits with code 4 and a missing file with code 7.
+--ignore-missing skips missing files with a warning instead, printing
+the rest; the archives are then read directly rather than through the
+daemon.`,
 	Example: `  blob cat ghcr.io/acme/configs:v1.0.0 config.json
   blob cat ghcr.io/acme/configs:v1.0.0 config.json | jq .
-  blob cat ghcr.io/acme/configs:v1.0.0 header.txt body.txt footer.txt > combined.txt`,
-	Args: cobra.MinimumNArgs(2),
+  blob cat ghcr.io/acme/configs:v1.0.0 header.txt body.txt footer.txt > combined.txt
+  blob cat configs:v1:/base.yaml overrides:v3:/prod.yaml > merged.yaml
+  blob cat --range 0:4096 ghcr.io/acme/data:v1 dump.bin | xxd
+  blob cat --range 1048576: ghcr.io/acme/logs:v1 app.log
+  blob cat --ignore-missing configs:v1:/base.yaml configs:v1:/local.yaml`,
+	Args: catArgs,
 	RunE: runCat,
 }
 
 func init() {
 	catCmd.Flags().Bool("skip-cache", false, "bypass registry caches for this operation")
+	catCmd.Flags().String("range", "", "print only bytes start:end of a single file")
+	catCmd.Flags().Bool("ignore-missing", false, "skip files not found in the archive with a warning")
+	addNoVerifyFlag(catCmd)
+}
+
+// catArgs accepts <ref> <file>... or one or more <ref>:<path> arguments.
+func catArgs(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 && isCatSourceArgs(args) {
+		return nil
+	}
+	return cobra.MinimumNArgs(2)(cmd, args)
+}
+
+// isCatSourceArgs reports whether args use the <ref>:<path> form.
+func isCatSourceArgs(args []string) bool {
+	return strings.Contains(args[0], ":/")
+}
+
+// catGroup is a run of consecutive files from the same archive.
+type catGroup struct {
+	ref   string
+	paths []string
+}
+
+// byteRange is a half-open byte range; end is -1 for end of file.
+type byteRange struct {
+	start int64
+	end   int64
 }
 
 func runCat(cmd *cobra.Command, args []string) error {
@@ -38,57 +100,118 @@ func runCat(cmd *cobra.Command, args []string) error {
 		return errors.New("configuration not loaded")
 	}
 
-	// 2. Parse arguments
-	inputRef := args[0]
-	filePaths := args[1:]
-
-	// 3. Parse flags
+	// 2. Parse flags
 	skipCache, flagErr := cmd.Flags().GetBool("skip-cache")
 	if flagErr != nil {
 		return fmt.Errorf("reading skip-cache flag: %w", flagErr)
 	}
+	noVerify, flagErr := cmd.Flags().GetBool("no-verify")
+	if flagErr != nil {
+		return fmt.Errorf("reading no-verify flag: %w", flagErr)
+	}
+	ignoreMissing, flagErr := cmd.Flags().GetBool("ignore-missing")
+	if flagErr != nil {
+		return fmt.Errorf("reading ignore-missing flag: %w", flagErr)
+	}
+	rangeFlag, flagErr := cmd.Flags().GetString("range")
+	if flagErr != nil {
+		return fmt.Errorf("reading range flag: %w", flagErr)
+	}
+	var rng *byteRange
+	if rangeFlag != "" {
+		r, err := parseByteRange(rangeFlag)
+		if err != nil {
+			return err
+		}
+		rng = &r
+	}
 
-	// 4. Resolve alias
-	resolvedRef := cfg.ResolveAlias(inputRef)
+	// 3. Parse arguments and resolve aliases
+	groups, err := parseCatArgs(args, cfg)
+	if err != nil {
+		return err
+	}
+	if rng != nil && (len(groups) != 1 || len(groups[0].paths) != 1) {
+		return errors.New("--range requires exactly one file")
+	}
+	ctx := cmd.Context()
+	refs := make([]*string, len(groups))
+	for i := range groups {
+		refs[i] = &groups[i].ref
+	}
+	if err := resolveSemverRefs(ctx, cfg, refs...); err != nil {
+		return err
+	}
 
-	// 5. Create client (lazy - only downloads manifest + index)
-	var client *blob.Client
-	var err error
-	if skipCache {
-		client, err = blob.NewClient(clientOptsNoCache(cfg)...)
-	} else {
-		client, err = newClient(cfg)
+	// 4. Offline, read pinned archives from the cache. They were verified
+	// when pinned.
+	archives := make(map[string]*blob.Archive)
+	if cfg.Offline && !skipCache {
+		for _, g := range groups {
+			if pinned, pin, ok := openPinned(cfg, g.ref); ok {
+				if _, err := checkPinnedRead(cfg, g.ref, pin, noVerify); err != nil {
+					return err
+				}
+				archives[g.ref] = pinned
+			}
+		}
 	}
-	if err != nil {
-		return fmt.Errorf("creating client: %w", err)
+
+	// 5. Delegate to a running daemon, which keeps the archive index warm.
+	// The daemon does not enforce policies, so verified archives are read here.
+	if !skipCache && len(archives) == 0 && rng == nil && !ignoreMissing && (noVerify || !catNeedsVerification(cfg, groups)) {
+		if dc := connectDaemon(ctx, cfg); dc != nil {
+			return catViaDaemon(ctx, cfg, dc, groups)
+		}
 	}
 
-	// 6. Pull archive (lazy - does NOT download data blob)
-	ctx := cmd.Context()
+	// 6. Pull each other archive (lazy - does NOT download data blobs), with a
+	// client enforcing the policies for its ref.
+	// A ranged read skips the content cache, which would fetch the whole file.
+	clientCfg := cfg
+	if rng != nil {
+		clientCfg = withoutContentCache(cfg)
+	}
 	var pullOpts []blob.PullOption
 	if skipCache {
 		pullOpts = append(pullOpts, blob.PullWithSkipCache())
 	}
-	blobArchive, err := client.Pull(ctx, resolvedRef, pullOpts...)
-	if err != nil {
-		return fmt.Errorf("accessing archive %s: %w", resolvedRef, err)
+	if rng != nil {
+		// Draining the file on close to verify it would defeat the range.
+		pullOpts = append(pullOpts, blob.PullWithVerifyOnClose(false))
+	}
+	for _, g := range groups {
+		if _, ok := archives[g.ref]; ok {
+			continue
+		}
+		policyOpts, _, err := readPolicyOpts(cfg, g.ref, noVerify)
+		if err != nil {
+			return err
+		}
+		var client *blob.Client
+		if skipCache {
+			client, err = blob.NewClient(append(clientOptsNoCache(clientCfg), policyOpts...)...)
+		} else {
+			client, err = newClient(clientCfg, policyOpts...)
+		}
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+		blobArchive, err := client.Pull(ctx, g.ref, pullOpts...)
+		if err != nil {
+			return archiveError(fmt.Errorf("accessing archive %s: %w", g.ref, err))
+		}
+		archives[g.ref] = blobArchive
 	}
 
 	// 7. Validate all files exist and are not directories before outputting anything
-	normalizedPaths, err := blobArchive.ValidateFiles(filePaths...)
-	if err != nil {
-		var ve *blob.ValidationError
-		if errors.As(err, &ve) {
-			switch ve.Reason {
-			case "is a directory":
-				return fmt.Errorf("cannot cat directory: %s", ve.Path)
-			case "not found":
-				return fmt.Errorf("file not found: %s", ve.Path)
-			default:
-				return fmt.Errorf("invalid path: %s: %s", ve.Path, ve.Reason)
-			}
+	normalized := make([][]string, len(groups))
+	for i, g := range groups {
+		paths, err := validateCatFiles(archives[g.ref], g, ignoreMissing)
+		if err != nil {
+			return err
 		}
-		return fmt.Errorf("validating files: %w", err)
+		normalized[i] = paths
 	}
 
 	// 8. Check quiet mode - suppress output only after validation
@@ -97,15 +220,180 @@ func runCat(cmd *cobra.Command, args []string) error {
 	}
 
 	// 9. Stream each file to stdout
-	for _, normalizedPath := range normalizedPaths {
-		if err := catFile(blobArchive, normalizedPath); err != nil {
+	if rng != nil {
+		if len(normalized[0]) == 0 {
+			return nil
+		}
+		if err := catFileRange(archives[groups[0].ref], normalized[0][0], *rng); err != nil {
 			return err
 		}
+		return writeCatTransfer(cfg)
+	}
+	for i, g := range groups {
+		for _, normalizedPath := range normalized[i] {
+			if err := catFile(archives[g.ref], normalizedPath); err != nil {
+				return err
+			}
+		}
 	}
 
+	return writeCatTransfer(cfg)
+}
+
+// writeCatTransfer writes the registry traffic of a cat to stderr as a
+// JSON object for --output json, since stdout holds the file contents.
+func writeCatTransfer(cfg *internalcfg.Config) error {
+	if cfg.Output != internalcfg.OutputJSON {
+		return nil
+	}
+	return json.NewEncoder(os.Stderr).Encode(struct {
+		Transfer *transfer.Stats `json:"transfer"`
+	}{transferStats()})
+}
+
+// parseCatArgs groups the files to print by archive, preserving order.
+func parseCatArgs(args []string, cfg *internalcfg.Config) ([]catGroup, error) {
+	if !isCatSourceArgs(args) {
+		return []catGroup{{ref: cfg.ResolveAlias(args[0]), paths: args[1:]}}, nil
+	}
+
+	sources, err := parseSourceArgs(args, cfg)
+	if err != nil {
+		return nil, err
+	}
+	var groups []catGroup
+	for _, src := range sources {
+		if n := len(groups); n > 0 && groups[n-1].ref == src.ref {
+			groups[n-1].paths = append(groups[n-1].paths, src.path)
+			continue
+		}
+		groups = append(groups, catGroup{ref: src.ref, paths: []string{src.path}})
+	}
+	return groups, nil
+}
+
+// catNeedsVerification reports whether a config policy matches any of
+// the archives in groups.
+func catNeedsVerification(cfg *internalcfg.Config, groups []catGroup) bool {
+	for _, g := range groups {
+		if len(cfg.MatchedPolicyRules(g.ref)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseByteRange parses "start:end", where either bound may be omitted.
+func parseByteRange(s string) (byteRange, error) {
+	startStr, endStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return byteRange{}, fmt.Errorf("invalid range %q: expected start:end", s)
+	}
+	r := byteRange{end: -1}
+	var err error
+	if startStr != "" {
+		if r.start, err = strconv.ParseInt(startStr, 10, 64); err != nil || r.start < 0 {
+			return byteRange{}, fmt.Errorf("invalid range %q: start must be a non-negative integer", s)
+		}
+	}
+	if endStr != "" {
+		if r.end, err = strconv.ParseInt(endStr, 10, 64); err != nil || r.end < r.start {
+			return byteRange{}, fmt.Errorf("invalid range %q: end must be an integer not less than start", s)
+		}
+	}
+	return r, nil
+}
+
+// withoutContentCache returns a copy of cfg with the content cache disabled.
+func withoutContentCache(cfg *internalcfg.Config) *internalcfg.Config {
+	c := *cfg
+	disabled := false
+	c.Cache.Content = &internalcfg.IndividualCacheConfig{Enabled: &disabled}
+	return &c
+}
+
+// validateCatFiles checks that the paths of g are files in blobArchive and
+// returns them normalized. With ignoreMissing, missing paths are skipped
+// with a warning.
+func validateCatFiles(blobArchive *blob.Archive, g catGroup, ignoreMissing bool) ([]string, error) {
+	if !ignoreMissing {
+		paths, err := blobArchive.ValidateFiles(g.paths...)
+		if err != nil {
+			return nil, catFilesError(err)
+		}
+		return paths, nil
+	}
+
+	paths := make([]string, 0, len(g.paths))
+	for _, p := range g.paths {
+		normalized, err := blobArchive.ValidateFiles(p)
+		if err != nil {
+			err = catFilesError(err)
+			if isPathNotFound(err) {
+				warn.Printf("skipping %s: not found in %s", p, g.ref)
+				continue
+			}
+			return nil, err
+		}
+		paths = append(paths, normalized...)
+	}
+	return paths, nil
+}
+
+// catFilesError converts an archive validation error to cat's wording.
+func catFilesError(err error) error {
+	var ve *blob.ValidationError
+	if errors.As(err, &ve) {
+		return catValidationError(ve.Path, ve.Reason)
+	}
+	return fmt.Errorf("validating files: %w", err)
+}
+
+// catValidationError describes a path that cannot be printed.
+func catValidationError(path, reason string) error {
+	switch reason {
+	case "is a directory":
+		return fmt.Errorf("cannot cat directory: %s", path)
+	case "not found":
+		return pathNotFoundError(fmt.Errorf("file not found: %s", path))
+	default:
+		return fmt.Errorf("invalid path: %s: %s", path, reason)
+	}
+}
+
+// catViaDaemon prints the files using a running daemon. With several
+// archives, all files are validated before anything is printed.
+func catViaDaemon(ctx context.Context, cfg *internalcfg.Config, dc *daemon.Client, groups []catGroup) error {
+	if len(groups) > 1 || cfg.Quiet {
+		for _, g := range groups {
+			req := daemon.CatRequest{Ref: g.ref, Paths: g.paths, ValidateOnly: true}
+			if err := daemonCatError(dc.Cat(ctx, req, io.Discard)); err != nil {
+				return err
+			}
+		}
+		if cfg.Quiet {
+			return nil
+		}
+	}
+	for _, g := range groups {
+		req := daemon.CatRequest{Ref: g.ref, Paths: g.paths}
+		if err := daemonCatError(dc.Cat(ctx, req, os.Stdout)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// daemonCatError converts a daemon validation error to cat's wording and
+// gives a missing archive its exit code.
+func daemonCatError(err error) error {
+	var ve *daemon.ValidationError
+	if errors.As(err, &ve) {
+		return catValidationError(ve.Path, ve.Reason)
+	}
+	return archiveError(err)
+}
+
 // catFile streams a single file from the archive to stdout.
 // Each file read triggers an HTTP range request for just that file's bytes.
 func catFile(archive *blob.Archive, filePath string) error {
@@ -123,3 +411,23 @@ func catFile(archive *blob.Archive, filePath string) error {
 
 	return nil
 }
+
+// catFileRange streams part of a file to stdout. Uncompressed files are read
+// with range requests for just the requested bytes; compressed files are
+// decompressed from the start and stop at the end of the range.
+func catFileRange(blobArchive *blob.Archive, filePath string, r byteRange) error {
+	if _, ok := blobArchive.Entry(filePath); !ok {
+		return catValidationError(filePath, "not found")
+	}
+
+	src, err := archive.OpenRange(blobArchive, filePath, r.start, r.end)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if _, err := io.Copy(os.Stdout, src); err != nil {
+		return fmt.Errorf("reading %s: %w", filePath, err)
+	}
+	return nil
+}
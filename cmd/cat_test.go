@@ -4,14 +4,14 @@ import (
 	"context"
 	"testing"
 
-	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 func TestCatCmd_NilConfig(t *testing.T) {
-	viper.Reset()
-
 	ctx := context.Background()
 
 	catCmd.SetContext(ctx)
@@ -35,3 +35,125 @@ func TestCatCmd_MinimumArgs(t *testing.T) {
 	err = catCmd.Args(catCmd, []string{"ref", "file1", "file2"})
 	require.NoError(t, err)
 }
+
+func TestCatCmd_SourceArgs(t *testing.T) {
+	err := catCmd.Args(catCmd, []string{"ghcr.io/acme/configs:v1:/config.json"})
+	require.NoError(t, err)
+
+	err = catCmd.Args(catCmd, []string{"a:v1:/one.txt", "b:v2:/two.txt"})
+	require.NoError(t, err)
+}
+
+func TestParseCatArgs(t *testing.T) {
+	cfg := &internalcfg.Config{Aliases: map[string]string{"configs": "ghcr.io/acme/configs"}}
+
+	tests := []struct {
+		name string
+		args []string
+		want []catGroup
+	}{
+		{
+			name: "ref and files",
+			args: []string{"configs:v1", "a.json", "b.json"},
+			want: []catGroup{{ref: "ghcr.io/acme/configs:v1", paths: []string{"a.json", "b.json"}}},
+		},
+		{
+			name: "sources from several archives",
+			args: []string{"configs:v1:/a.json", "configs:v1:/b.json", "ghcr.io/acme/other:v2:/c.json", "configs:v1:/d.json"},
+			want: []catGroup{
+				{ref: "ghcr.io/acme/configs:v1", paths: []string{"/a.json", "/b.json"}},
+				{ref: "ghcr.io/acme/other:v2", paths: []string{"/c.json"}},
+				{ref: "ghcr.io/acme/configs:v1", paths: []string{"/d.json"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCatArgs(tt.args, cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := parseCatArgs([]string{"configs:v1:/a.json", "b.json"}, cfg)
+	require.Error(t, err)
+}
+
+func TestParseByteRange(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    byteRange
+		wantErr bool
+	}{
+		{in: "0:4096", want: byteRange{start: 0, end: 4096}},
+		{in: "100:", want: byteRange{start: 100, end: -1}},
+		{in: ":512", want: byteRange{start: 0, end: 512}},
+		{in: "10:10", want: byteRange{start: 10, end: 10}},
+		{in: "100", wantErr: true},
+		{in: "-1:10", wantErr: true},
+		{in: "20:10", wantErr: true},
+		{in: "a:b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseByteRange(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCatCmd_RangeRequiresOneFile(t *testing.T) {
+	catCmd.SetContext(internalcfg.WithConfig(context.Background(), &internalcfg.Config{}))
+	require.NoError(t, catCmd.Flags().Set("range", "0:10"))
+	t.Cleanup(func() {
+		catCmd.Flags().Set("range", "") //nolint:errcheck // test cleanup
+	})
+
+	err := catCmd.RunE(catCmd, []string{"ghcr.io/test:v1", "a.txt", "b.txt"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--range requires exactly one file")
+}
+
+func TestCatCmd_MissingExitCodes(t *testing.T) {
+	warn.Reset()
+	warn.SetQuiet(true)
+	t.Cleanup(func() {
+		warn.Reset()
+	})
+
+	reg, _ := newTestRegistry(t)
+	reg.addArchive(t, "v1", map[string]string{"a.txt": "alpha"})
+
+	cfg := newRegistryTestConfig(t)
+	cfg.Quiet = false
+	catCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	err := catCmd.RunE(catCmd, []string{reg.repo + ":v2", "a.txt"})
+	requireExitCode(t, err, exitCodeNotFound)
+
+	out, err := captureStdout(t, func() error {
+		return catCmd.RunE(catCmd, []string{reg.repo + ":v1", "a.txt", "missing.txt"})
+	})
+	requireExitCode(t, err, exitCodePathNotFound)
+	assert.Empty(t, out, "nothing is written when a file is missing")
+
+	require.NoError(t, catCmd.Flags().Set("ignore-missing", "true"))
+	t.Cleanup(func() { catCmd.Flags().Set("ignore-missing", "false") }) //nolint:errcheck // test cleanup
+	out, err = captureStdout(t, func() error {
+		return catCmd.RunE(catCmd, []string{reg.repo + ":v1", "a.txt", "missing.txt"})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alpha", string(out))
+	assert.Equal(t, 1, warn.Count())
+
+	// Only missing files are skipped
+	err = catCmd.RunE(catCmd, []string{reg.repo + ":v2", "a.txt"})
+	requireExitCode(t, err, exitCodeNotFound)
+}
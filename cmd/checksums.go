@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/meigma/blob"
+	blobcore "github.com/meigma/blob/core"
+)
+
+// Statuses of a file checked by --verify-checksums.
+const (
+	checksumOK       = "ok"
+	checksumMismatch = "mismatch"
+	checksumMissing  = "missing"
+)
+
+// checksumFile is an extracted file and the digest the archive index
+// records for it.
+type checksumFile struct {
+	Path        string `json:"path"`
+	Destination string `json:"destination"`
+	Digest      string `json:"digest"`
+}
+
+// checksumResult is the outcome of checking one file.
+type checksumResult struct {
+	checksumFile
+	Status string `json:"status"`
+	Actual string `json:"actual,omitempty"`
+}
+
+// checksumReport is the --verify-checksums report.
+type checksumReport struct {
+	Checked int              `json:"checked"`
+	Failed  int              `json:"failed"`
+	Files   []checksumResult `json:"files"`
+}
+
+// extractedFiles collects the files an extraction writes, for checking
+// afterwards. A nil *extractedFiles records nothing.
+type extractedFiles struct {
+	mu    sync.Mutex
+	files []checksumFile
+}
+
+// add records the archive file at archivePath, extracted to dest.
+func (f *extractedFiles) add(blobArchive *blob.Archive, archivePath, dest string) {
+	if f == nil {
+		return
+	}
+	entry, ok := blobArchive.Entry(archivePath)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files = append(f.files, checksumFile{
+		Path:        "/" + archivePath,
+		Destination: dest,
+		Digest:      "sha256:" + hex.EncodeToString(entry.HashBytes()),
+	})
+}
+
+// copyOption returns a copy option recording the files extracted into
+// destDir.
+func (f *extractedFiles) copyOption(blobArchive *blob.Archive, destDir string) blob.CopyOption {
+	return blobcore.CopyWithProgress(func(ev blob.ProgressEvent) {
+		if ev.Stage == blob.StageExtracting {
+			f.add(blobArchive, ev.Path, filepath.Join(destDir, filepath.FromSlash(ev.Path)))
+		}
+	})
+}
+
+// verifyChecksums re-reads each file from disk and compares its SHA-256
+// digest with the archive's, so corruption after the archive's own
+// verification (by a proxy, a disk, or a concurrent writer) is caught.
+// Files are reported in destination order.
+func verifyChecksums(files []checksumFile) *checksumReport {
+	report := &checksumReport{Files: make([]checksumResult, 0, len(files))}
+	for _, f := range files {
+		result := checksumResult{checksumFile: f, Status: checksumOK}
+		actual, err := fileDigest(f.Destination)
+		switch {
+		case err != nil:
+			result.Status = checksumMissing
+		case actual != f.Digest:
+			result.Status = checksumMismatch
+			result.Actual = actual
+		}
+		if result.Status != checksumOK {
+			report.Failed++
+		}
+		report.Files = append(report.Files, result)
+	}
+	slices.SortFunc(report.Files, func(a, b checksumResult) int {
+		return cmp.Compare(a.Destination, b.Destination)
+	})
+	report.Checked = len(report.Files)
+	return report
+}
+
+// fileDigest returns the SHA-256 digest of the file at path.
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // path is a file this command extracted
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// checksumError returns an error with exit code 8 if report has failures.
+func checksumError(report *checksumReport) error {
+	if report == nil || report.Failed == 0 {
+		return nil
+	}
+	return &ExitError{
+		Code: exitCodeChecksum,
+		Err:  fmt.Errorf("checksum verification failed for %d of %s", report.Failed, pluralize(report.Checked, "file", "files")),
+	}
+}
+
+// printChecksumReport prints a summary of report and the files that failed.
+func printChecksumReport(report *checksumReport) {
+	if report.Failed == 0 {
+		fmt.Printf("  Checksums: %d verified\n", report.Checked)
+		return
+	}
+	fmt.Printf("  Checksums: %d of %d failed\n", report.Failed, report.Checked)
+	for _, r := range report.Files {
+		if r.Status != checksumOK {
+			fmt.Printf("    %-8s %s\n", r.Status, r.Destination)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestVerifyChecksums(t *testing.T) {
+	arch := newTestArchive(t, map[string]string{
+		"a.txt":     "alpha",
+		"etc/b.txt": "bravo",
+		"c.txt":     "charlie",
+	})
+	cfg := &internalcfg.Config{Cache: internalcfg.CacheConfig{Dir: t.TempDir()}}
+	dest := t.TempDir()
+	files := &extractedFiles{}
+	_, _, err := extractPull(cfg, arch, dest, pullFlags{prefix: "."}, files, nil)
+	require.NoError(t, err)
+	require.Len(t, files.files, 3)
+
+	report := verifyChecksums(files.files)
+	assert.Equal(t, 3, report.Checked)
+	assert.Equal(t, 0, report.Failed)
+	require.NoError(t, checksumError(report))
+
+	// Corrupt one file and lose another
+	require.NoError(t, os.WriteFile(filepath.Join(dest, "a.txt"), []byte("alphA"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(dest, "c.txt")))
+
+	report = verifyChecksums(files.files)
+	assert.Equal(t, 2, report.Failed)
+	statuses := make(map[string]string)
+	for _, r := range report.Files {
+		statuses[r.Path] = r.Status
+	}
+	assert.Equal(t, map[string]string{
+		"/a.txt":     checksumMismatch,
+		"/etc/b.txt": checksumOK,
+		"/c.txt":     checksumMissing,
+	}, statuses)
+	assert.NotEmpty(t, report.Files[0].Actual, "mismatches report the digest found")
+
+	err = checksumError(report)
+	var exitErr *ExitError
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, exitCodeChecksum, exitErr.Code)
+	assert.EqualError(t, err, "checksum verification failed for 2 of 3 files")
+}
+
+func TestVerifyChecksums_StripComponents(t *testing.T) {
+	arch := newTestArchive(t, map[string]string{"etc/nginx/nginx.conf": "events {}"})
+	cfg := &internalcfg.Config{Cache: internalcfg.CacheConfig{Dir: t.TempDir()}}
+	dest := t.TempDir()
+	files := &extractedFiles{}
+	_, _, err := extractPull(cfg, arch, dest, pullFlags{prefix: "etc/nginx", strip: 2}, files, nil)
+	require.NoError(t, err)
+
+	require.Len(t, files.files, 1)
+	assert.Equal(t, "/etc/nginx/nginx.conf", files.files[0].Path)
+	assert.Equal(t, filepath.Join(dest, "nginx.conf"), files.files[0].Destination)
+	assert.Zero(t, verifyChecksums(files.files).Failed)
+}
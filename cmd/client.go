@@ -1,16 +1,71 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
+	"net/http"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/meigma/blob"
+	coredisk "github.com/meigma/blob/core/cache/disk"
+	registrydisk "github.com/meigma/blob/registry/cache/disk"
+	"oras.land/oras-go/v2/registry/remote/retry"
 
+	"github.com/meigma/blob-cli/internal/cachegc"
+	"github.com/meigma/blob-cli/internal/cachestats"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
+// buildRegistryTransport builds the transport registry requests are sent
+// through, from cfg and the --trace destination. From the registry client
+// down, a request is given the configured extra headers, authenticated
+// with the credentials of the registries section, sent to a mirror if the
+// registry has any, logged when tracing, counted for JSON results, and
+// sent with the connection settings of the registry it is for. With
+// --offline, every request then fails instead.
+func buildRegistryTransport(cfg *internalcfg.Config, traceDest string) (http.RoundTripper, error) {
+	rt, err := registryBaseTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		rt = countTransfers(rt)
+	}
+	if traceDest != "" {
+		if rt, err = startTrace(rt, traceDest); err != nil {
+			return nil, err
+		}
+	}
+	if rt, err = registryMirrorTransport(rt, cfg.Registries); err != nil {
+		return nil, err
+	}
+	if rt, err = registryCredentialTransport(rt, cfg.Registries); err != nil {
+		return nil, err
+	}
+	rt = requestHeaderTransport(rt, cfg.Registry.ExtraHeaders)
+	return rt, nil
+}
+
+// registryBaseTransport returns the bottom layer of the registry
+// transport: each registry's connection settings over a clone of the
+// default transport, or a transport that refuses every request offline.
+func registryBaseTransport(cfg *internalcfg.Config) (http.RoundTripper, error) {
+	if cfg.Offline {
+		return offlineTransport{}, nil
+	}
+	base, _ := http.DefaultTransport.(*http.Transport) //nolint:errcheck // blob never replaces it
+	return registryHostTransport(base.Clone(), cfg.Registries)
+}
+
+// useRegistryTransport sends the requests of every registry client
+// through rt. The registry clients of blob and oras-go all send through
+// oras-go's retry client, which nothing else uses, so Sigstore, TUF,
+// OIDC, and KMS requests are not affected.
+func useRegistryTransport(rt http.RoundTripper) {
+	retry.DefaultClient.Transport = retry.NewTransport(rt)
+}
+
 // newClient creates a new blob client with options from config.
 func newClient(cfg *internalcfg.Config, opts ...blob.Option) (*blob.Client, error) {
 	baseOpts := clientOpts(cfg)
@@ -23,16 +78,14 @@ func newClient(cfg *internalcfg.Config, opts ...blob.Option) (*blob.Client, erro
 // If caching is enabled but the cache directory cannot be resolved, a warning
 // is written to stderr and caching is disabled for this operation.
 func clientOpts(cfg *internalcfg.Config) []blob.Option {
-	opts := []blob.Option{blob.WithDockerConfig()}
+	opts := []blob.Option{blob.WithDockerConfig(), blob.WithUserAgent(userAgent(cfg))}
 	if cfg.PlainHTTP {
 		opts = append(opts, blob.WithPlainHTTP(true))
 	}
 	if cfg.Cache.Enabled {
 		cacheDir, err := resolveCacheDir(cfg)
 		if err != nil {
-			if !cfg.Quiet {
-				fmt.Fprintf(os.Stderr, "Warning: cache disabled: %v\n", err)
-			}
+			warn.Printf("cache disabled: %v", err)
 		} else {
 			opts = append(opts, buildCacheOpts(cfg, cacheDir)...)
 		}
@@ -42,40 +95,174 @@ func clientOpts(cfg *internalcfg.Config) []blob.Option {
 
 // buildCacheOpts returns cache options based on config.
 // Each cache type is enabled individually based on the config settings.
+// Caches report hits, misses, and evictions to the process's stats recorder.
 func buildCacheOpts(cfg *internalcfg.Config, cacheDir string) []blob.Option {
 	var opts []blob.Option
 	cache := &cfg.Cache
+	rec := cacheStatsRecorder(cacheDir)
 
 	if cache.ContentEnabled() {
-		opts = append(opts, blob.WithContentCacheDir(filepath.Join(cacheDir, "content")))
+		opts = append(opts, withContentCache(filepath.Join(cacheDir, "content"), rec))
 	}
 	if cache.BlocksEnabled() {
-		opts = append(opts, blob.WithBlockCacheDir(filepath.Join(cacheDir, "blocks")))
+		opts = append(opts, withBlockCache(filepath.Join(cacheDir, "blocks"), rec))
+	}
+
+	var ttl time.Duration
+	if cache.RefsEnabled() && cache.RefTTL != "" {
+		switch d, err := time.ParseDuration(cache.RefTTL); {
+		case err != nil:
+			warn.Printf("ignoring invalid cache.ref_ttl %q: %v", cache.RefTTL, err)
+		case d < 0:
+			warn.Printf("ignoring negative cache.ref_ttl %q", cache.RefTTL)
+		default:
+			ttl = d
+		}
+	}
+	// Offline, a cached tag is the only way to resolve it, however old
+	if cfg.Offline {
+		ttl = 0
 	}
 	if cache.RefsEnabled() {
-		opts = append(opts, blob.WithRefCacheDir(filepath.Join(cacheDir, "refs")))
+		opts = append(opts, withRefCache(filepath.Join(cacheDir, "refs"), ttl, rec))
 	}
 	if cache.ManifestsEnabled() {
-		opts = append(opts, blob.WithManifestCacheDir(filepath.Join(cacheDir, "manifests")))
+		opts = append(opts, withManifestCache(filepath.Join(cacheDir, "manifests"), rec))
 	}
 	if cache.IndexesEnabled() {
-		opts = append(opts, blob.WithIndexCacheDir(filepath.Join(cacheDir, "indexes")))
+		opts = append(opts, withIndexCache(filepath.Join(cacheDir, "indexes"), rec))
 	}
 
 	// Only set TTL if refs cache is enabled
-	if cache.RefsEnabled() && cache.RefTTL != "" {
-		if ttl, err := time.ParseDuration(cache.RefTTL); err == nil {
-			opts = append(opts, blob.WithRefCacheTTL(ttl))
-		}
+	if ttl > 0 {
+		opts = append(opts, blob.WithRefCacheTTL(ttl))
 	}
 
 	return opts
 }
 
+// withContentCache enables the content cache with the default size limit.
+func withContentCache(dir string, rec *cachestats.Recorder) blob.Option {
+	return func(c *blob.Client) error {
+		cache, err := coredisk.New(dir,
+			coredisk.WithMaxBytes(blob.DefaultContentCacheSize),
+			coredisk.WithLogger(rec.Logger("content")),
+		)
+		if err != nil {
+			return err
+		}
+		return blob.WithContentCache(cache)(c)
+	}
+}
+
+// withBlockCache enables the block cache with the default size limit.
+func withBlockCache(dir string, rec *cachestats.Recorder) blob.Option {
+	return func(c *blob.Client) error {
+		cache, err := coredisk.NewBlockCache(dir,
+			coredisk.WithBlockMaxBytes(blob.DefaultBlockCacheSize),
+			coredisk.WithBlockLogger(rec.Logger("blocks")),
+		)
+		if err != nil {
+			return err
+		}
+		return blob.WithBlockCache(cache)(c)
+	}
+}
+
+// withRefCache enables the ref cache with the default size limit.
+// A ttl of 0 disables expiry.
+func withRefCache(dir string, ttl time.Duration, rec *cachestats.Recorder) blob.Option {
+	return func(c *blob.Client) error {
+		cache, err := registrydisk.NewRefCache(dir,
+			registrydisk.WithMaxBytes(blob.DefaultRefCacheSize),
+			registrydisk.WithRefCacheTTL(ttl),
+			registrydisk.WithLogger(rec.Logger("refs")),
+		)
+		if err != nil {
+			return err
+		}
+		return blob.WithRefCache(rec.WrapRefCache("refs", cache))(c)
+	}
+}
+
+// withManifestCache enables the manifest cache with the default size limit.
+func withManifestCache(dir string, rec *cachestats.Recorder) blob.Option {
+	return func(c *blob.Client) error {
+		cache, err := registrydisk.NewManifestCache(dir,
+			registrydisk.WithMaxBytes(blob.DefaultManifestCacheSize),
+			registrydisk.WithLogger(rec.Logger("manifests")),
+		)
+		if err != nil {
+			return err
+		}
+		return blob.WithManifestCache(rec.WrapManifestCache("manifests", cache))(c)
+	}
+}
+
+// withIndexCache enables the index cache with the default size limit.
+func withIndexCache(dir string, rec *cachestats.Recorder) blob.Option {
+	return func(c *blob.Client) error {
+		cache, err := registrydisk.NewIndexCache(dir,
+			registrydisk.WithMaxBytes(blob.DefaultIndexCacheSize),
+			registrydisk.WithLogger(rec.Logger("indexes")),
+		)
+		if err != nil {
+			return err
+		}
+		return blob.WithIndexCache(rec.WrapIndexCache("indexes", cache))(c)
+	}
+}
+
+// cacheStats is the process-wide cache stats recorder.
+var cacheStats struct {
+	mu  sync.Mutex
+	rec *cachestats.Recorder
+}
+
+// cacheStatsRecorder returns the recorder for cacheDir, creating it on first use.
+func cacheStatsRecorder(cacheDir string) *cachestats.Recorder {
+	cacheStats.mu.Lock()
+	defer cacheStats.mu.Unlock()
+	if cacheStats.rec == nil {
+		cacheStats.rec = cachestats.NewRecorder(cacheDir, cachestats.DefaultFlushInterval)
+	}
+	return cacheStats.rec
+}
+
+// flushCacheStats writes any pending cache stats before the process exits.
+func flushCacheStats() {
+	cacheStats.mu.Lock()
+	rec := cacheStats.rec
+	cacheStats.mu.Unlock()
+	if rec != nil {
+		_ = rec.Flush() //nolint:errcheck // stats are best-effort
+	}
+}
+
+// collectCaches evicts the least recently used cache entries until the
+// caches fit cache.max_size. It runs after commands that fill the caches
+// and only warns on failure.
+func collectCaches(cfg *internalcfg.Config) {
+	if !cfg.Cache.Enabled {
+		return
+	}
+	maxSize, err := cfg.Cache.MaxSizeBytes()
+	if err != nil || maxSize <= 0 {
+		return
+	}
+	cacheDir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return
+	}
+	if _, err := cachegc.Collect(cacheDir, maxSize, cachegc.Options{Stats: cacheStatsRecorder(cacheDir)}); err != nil {
+		warn.Printf("collecting caches: %v", err)
+	}
+}
+
 // clientOptsNoCache returns client options without caching.
 // Use this when --skip-cache flag is set.
 func clientOptsNoCache(cfg *internalcfg.Config) []blob.Option {
-	opts := []blob.Option{blob.WithDockerConfig()}
+	opts := []blob.Option{blob.WithDockerConfig(), blob.WithUserAgent(userAgent(cfg))}
 	if cfg.PlainHTTP {
 		opts = append(opts, blob.WithPlainHTTP(true))
 	}
@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"oras.land/oras-go/v2/registry/remote/retry"
+
 	internalcfg "github.com/meigma/blob-cli/internal/config"
 )
 
@@ -88,9 +95,9 @@ func TestClientOpts(t *testing.T) {
 
 		opts := clientOpts(cfg)
 
-		// Should have only 1 option: WithDockerConfig
-		if len(opts) != 1 {
-			t.Errorf("clientOpts() returned %d options, want 1", len(opts))
+		// Should have only 2 options: WithDockerConfig and WithUserAgent
+		if len(opts) != 2 {
+			t.Errorf("clientOpts() returned %d options, want 2", len(opts))
 		}
 	})
 
@@ -106,9 +113,9 @@ func TestClientOpts(t *testing.T) {
 
 		opts := clientOpts(cfg)
 
-		// Should have 2 options: WithDockerConfig and WithPlainHTTP
-		if len(opts) != 2 {
-			t.Errorf("clientOpts() returned %d options, want 2", len(opts))
+		// Should have 3 options: WithDockerConfig, WithUserAgent, and WithPlainHTTP
+		if len(opts) != 3 {
+			t.Errorf("clientOpts() returned %d options, want 3", len(opts))
 		}
 	})
 }
@@ -128,9 +135,9 @@ func TestClientOptsNoCache(t *testing.T) {
 
 		opts := clientOptsNoCache(cfg)
 
-		// Should have only 1 option: WithDockerConfig
-		if len(opts) != 1 {
-			t.Errorf("clientOptsNoCache() returned %d options, want 1", len(opts))
+		// Should have only 2 options: WithDockerConfig and WithUserAgent
+		if len(opts) != 2 {
+			t.Errorf("clientOptsNoCache() returned %d options, want 2", len(opts))
 		}
 	})
 
@@ -147,9 +154,9 @@ func TestClientOptsNoCache(t *testing.T) {
 
 		opts := clientOptsNoCache(cfg)
 
-		// Should have 2 options: WithDockerConfig and WithPlainHTTP
-		if len(opts) != 2 {
-			t.Errorf("clientOptsNoCache() returned %d options, want 2", len(opts))
+		// Should have 3 options: WithDockerConfig, WithUserAgent, and WithPlainHTTP
+		if len(opts) != 3 {
+			t.Errorf("clientOptsNoCache() returned %d options, want 3", len(opts))
 		}
 	})
 }
@@ -279,6 +286,109 @@ func TestBuildCacheOpts(t *testing.T) {
 	})
 }
 
+func TestCollectCaches(t *testing.T) {
+	dir := t.TempDir()
+	entry := filepath.Join(dir, "content", "ab", "abcd")
+	if err := os.MkdirAll(filepath.Dir(entry), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(entry, []byte("cached"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a limit, or with caching disabled, nothing is evicted
+	collectCaches(&internalcfg.Config{Cache: internalcfg.CacheConfig{Enabled: true, Dir: dir}})
+	collectCaches(&internalcfg.Config{Cache: internalcfg.CacheConfig{Dir: dir, MaxSize: "1B"}})
+	if _, err := os.Stat(entry); err != nil {
+		t.Fatalf("entry evicted without an enforced limit: %v", err)
+	}
+
+	collectCaches(&internalcfg.Config{Cache: internalcfg.CacheConfig{Enabled: true, Dir: dir, MaxSize: "1B"}})
+	if _, err := os.Stat(entry); !os.IsNotExist(err) {
+		t.Errorf("entry over cache.max_size was not evicted: %v", err)
+	}
+}
+
+// keepRegistryTransport restores the transport of registry clients when
+// the test ends.
+func keepRegistryTransport(t *testing.T) {
+	t.Helper()
+	old := retry.DefaultClient.Transport
+	t.Cleanup(func() { retry.DefaultClient.Transport = old })
+}
+
+func TestBuildRegistryTransport_Trace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	traceFile := filepath.Join(t.TempDir(), "trace.log")
+	t.Cleanup(func() {
+		stopTrace()
+		tracer.transport, tracer.w, tracer.file = nil, nil, nil
+	})
+
+	rt, err := buildRegistryTransport(&internalcfg.Config{}, traceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keepRegistryTransport(t)
+	useRegistryTransport(rt)
+
+	get := func(client *http.Client, path string) {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL+path, http.NoBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	get(retry.DefaultClient, "/v2/registry")
+	get(http.DefaultClient, "/sigstore")
+
+	data, err := os.ReadFile(traceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "/v2/registry") {
+		t.Errorf("registry request not traced:\n%s", data)
+	}
+	if strings.Contains(string(data), "/sigstore") {
+		t.Errorf("request outside the registry client traced:\n%s", data)
+	}
+}
+
+func TestBuildRegistryTransport_Transfer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("manifest")) //nolint:errcheck // test
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { transferCounter = nil })
+
+	rt, err := buildRegistryTransport(&internalcfg.Config{Output: internalcfg.OutputJSON}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL+"/v2/acme/configs/manifests/v1", http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck // test
+	resp.Body.Close()
+
+	stats := transferStats()
+	if stats.Requests != 1 || stats.BytesDown != int64(len("manifest")) {
+		t.Errorf("transferStats() = %+v, want 1 request of %d bytes", stats, len("manifest"))
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Ensure tests don't accidentally use real config
 	os.Exit(m.Run())
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meigma/blob-cli/internal/clipboard"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// clipboardCopy places text on the clipboard. Tests replace it.
+var clipboardCopy = clipboard.Copy
+
+// copyFlagValue places value on the clipboard if the command's --copy flag
+// is set, noting what was copied on stderr unless quiet. Over SSH the
+// value is sent to the local terminal with OSC 52.
+func copyFlagValue(cmd *cobra.Command, cfg *internalcfg.Config, what, value string) error {
+	copyValue, err := cmd.Flags().GetBool("copy")
+	if err != nil {
+		return fmt.Errorf("reading copy flag: %w", err)
+	}
+	if !copyValue {
+		return nil
+	}
+	if _, err := clipboardCopy(value); err != nil {
+		return fmt.Errorf("copying %s to clipboard: %w", what, err)
+	}
+	if !cfg.Quiet {
+		fmt.Fprintf(os.Stderr, "Copied %s to clipboard\n", what)
+	}
+	return nil
+}
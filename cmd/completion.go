@@ -0,0 +1,381 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	blobcore "github.com/meigma/blob/core"
+	"github.com/spf13/cobra"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+const (
+	// completionTagsEnv enables registry tag completion for aliases. The
+	// scripts generated by "completion --alias-tags" set it.
+	completionTagsEnv = "BLOB_COMPLETION_ALIAS_TAGS"
+
+	// completionTagsTTL is how long a repository's tag list is reused by
+	// completion before the registry is asked again.
+	completionTagsTTL = time.Minute
+
+	// completionTagsTimeout bounds the registry request made on TAB.
+	completionTagsTimeout = 3 * time.Second
+
+	// completionRecentRefs is how many recently used references are kept
+	// for completion.
+	completionRecentRefs = 50
+)
+
+// completionEnvLines sets completionTagsEnv in each shell's script.
+var completionEnvLines = map[string]string{
+	"bash":       "export " + completionTagsEnv + "=1",
+	"zsh":        "export " + completionTagsEnv + "=1",
+	"fish":       "set -gx " + completionTagsEnv + " 1",
+	"powershell": "$env:" + completionTagsEnv + ` = "1"`,
+}
+
+// completionTags is the cached tag list of a repository.
+type completionTags struct {
+	Repository string    `json:"repository"`
+	Fetched    time.Time `json:"fetched"`
+	Tags       []string  `json:"tags"`
+}
+
+// completionRefs is the list of recently used references, most recent
+// first.
+type completionRefs struct {
+	Refs []string `json:"refs"`
+}
+
+// refCommands are the commands whose first argument is a reference.
+// Successful runs record it for completion.
+var refCommands = map[*cobra.Command]bool{}
+
+// setupCompletion adds --alias-tags to cobra's completion command and
+// registers reference completion on the commands that take references.
+// It is called once every command has been added to the root.
+func setupCompletion() {
+	rootCmd.InitDefaultCompletionCmd()
+	completionCmd, _, err := rootCmd.Find([]string{"completion"})
+	if err != nil || completionCmd == rootCmd {
+		return
+	}
+	completionCmd.Long += `
+References complete from aliases and from recent successful commands.
+For cat, ls, tree, and cp, paths inside the archive complete from its
+index ("blob cp myalias:v1:/etc/<TAB>").
+
+With --alias-tags, the script also completes the tags of aliases
+("myalias:<TAB>") by listing them from the registry. Tag lists are
+cached for a minute.`
+	completionCmd.PersistentFlags().Bool("alias-tags", false, "complete alias tags from the registry")
+
+	for _, shellCmd := range completionCmd.Commands() {
+		line, ok := completionEnvLines[shellCmd.Name()]
+		if !ok || shellCmd.RunE == nil {
+			continue
+		}
+		generate := shellCmd.RunE
+		shellCmd.RunE = func(cmd *cobra.Command, args []string) error {
+			if err := generate(cmd, args); err != nil {
+				return err
+			}
+			aliasTags, err := cmd.Flags().GetBool("alias-tags")
+			if err != nil {
+				return fmt.Errorf("reading alias-tags flag: %w", err)
+			}
+			if aliasTags {
+				fmt.Fprintln(cmd.OutOrStdout(), line)
+			}
+			return nil
+		}
+	}
+
+	// The first argument of these commands is a reference.
+	for _, c := range []*cobra.Command{
+		pullCmd, inspectCmd, openCmd, signCmd, attestCmd, attestationGetCmd,
+		verifyCmd, tagCmd, tagsCmd, resolveCmd, promoteCmd, migrateCmd, metaGetCmd, mountCmd,
+		diffCmd, exportCmd, copyCmd,
+	} {
+		c.ValidArgsFunction = completeFirstRef
+		refCommands[c] = true
+	}
+	// These take a reference followed by a path in the archive.
+	for _, c := range []*cobra.Command{lsCmd, treeCmd} {
+		c.ValidArgsFunction = completeRefPath
+		refCommands[c] = true
+	}
+	rootCmd.ValidArgsFunction = completeShortcut
+	catCmd.ValidArgsFunction = completeCat
+	cpCmd.ValidArgsFunction = completeCp
+	verifyFileCmd.ValidArgsFunction = completeCp
+	refCommands[catCmd] = true
+	refCommands[cpCmd] = true
+	refCommands[verifyFileCmd] = true
+	// Every argument of these commands is a reference.
+	for _, c := range []*cobra.Command{rmCmd, storeAddCmd, cachePinCmd} {
+		c.ValidArgsFunction = completeRef
+	}
+}
+
+// completeRefPath completes a reference, then a path in that archive.
+func completeRefPath(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeRef(cmd, args, toComplete)
+	case 1:
+		return completeArchivePath(cmd, args[0], toComplete, "")
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeCat completes "cat <ref> <file>..." and "cat <ref>:<path>...".
+func completeCat(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if ref, path, ok := strings.Cut(toComplete, ":/"); ok {
+		return completeArchivePath(cmd, ref, "/"+path, ref+":")
+	}
+	if len(args) == 0 || isCatSourceArgs(args) {
+		return completeRef(cmd, args, toComplete)
+	}
+	return completeArchivePath(cmd, args[0], toComplete, "")
+}
+
+// completeCp completes "<ref>:<path>" sources and, once a source is given,
+// the local destination.
+func completeCp(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if ref, path, ok := strings.Cut(toComplete, ":/"); ok {
+		return completeArchivePath(cmd, ref, "/"+path, ref+":")
+	}
+	if len(args) == 0 {
+		return completeRef(cmd, args, toComplete)
+	}
+	return nil, cobra.ShellCompDirectiveDefault
+}
+
+// completeArchivePath completes toComplete as a path in the archive at ref
+// by reading its index. Directories end in "/" so completion can continue
+// into them. Each completion is prefixed with prefix.
+func completeArchivePath(cmd *cobra.Command, ref, toComplete, prefix string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	cfg, err := completionConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionTagsTimeout)
+	defer cancel()
+	// Pull is lazy, and indexes are cached by digest
+	blobArchive, err := client.Pull(ctx, cfg.ResolveAlias(ref))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	index, err := blobcore.NewIndexView(blobArchive.IndexData())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	dir := ""
+	if i := strings.LastIndex(toComplete, "/"); i >= 0 {
+		dir = toComplete[:i+1]
+	}
+	entries, err := archive.ListDir(index, dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []cobra.Completion
+	directive := cobra.ShellCompDirectiveNoFileComp
+	for _, e := range entries {
+		candidate := dir + e.Name
+		if e.IsDir {
+			candidate += "/"
+		}
+		if !strings.HasPrefix(candidate, toComplete) {
+			continue
+		}
+		if e.IsDir {
+			directive |= cobra.ShellCompDirectiveNoSpace
+		}
+		completions = append(completions, prefix+candidate)
+	}
+	return completions, directive
+}
+
+func completeFirstRef(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return completeRef(cmd, args, toComplete)
+}
+
+// completeRef completes alias names and, when completionTagsEnv is set,
+// the tags of "alias:" from the registry.
+func completeRef(cmd *cobra.Command, _ []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	cfg, err := completionConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	name, tagPrefix, hasTag := strings.Cut(toComplete, ":")
+	ref, isAlias := cfg.Aliases[name]
+	if !hasTag || !isAlias {
+		var completions []cobra.Completion
+		if !hasTag {
+			for alias, ref := range cfg.Aliases {
+				if strings.HasPrefix(alias, toComplete) {
+					completions = append(completions, cobra.CompletionWithDesc(alias, ref))
+				}
+			}
+			slices.Sort(completions)
+		}
+		for _, ref := range recentRefs(cfg) {
+			if strings.HasPrefix(ref, toComplete) {
+				completions = append(completions, cobra.CompletionWithDesc(ref, "recently used"))
+			}
+		}
+		// No space, so a tag or path can follow
+		return completions, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+	}
+
+	if os.Getenv(completionTagsEnv) == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	repo, _, _ := internalcfg.SplitRef(ref)
+	tags := cachedCompletionTags(cmd.Context(), cfg, repo)
+
+	var completions []cobra.Completion
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, tagPrefix) {
+			completions = append(completions, name+":"+tag)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionConfig loads the configuration for a completion request. The
+// config in the context was loaded before the completed command's flags,
+// such as --config, were parsed, so it is read again.
+func completionConfig() (*internalcfg.Config, error) {
+	initConfig()
+	return internalcfg.LoadFromViper()
+}
+
+// recentRefsPath returns the file recently used references are kept in.
+func recentRefsPath(cfg *internalcfg.Config) (string, error) {
+	dir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "completion", "refs.json"), nil
+}
+
+// recentRefs returns the recently used references, most recent first.
+func recentRefs(cfg *internalcfg.Config) []string {
+	path, err := recentRefsPath(cfg)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // path is derived from the cache dir
+	if err != nil {
+		return nil
+	}
+	var recent completionRefs
+	if json.Unmarshal(data, &recent) != nil {
+		return nil
+	}
+	return recent.Refs
+}
+
+// recordRecentRef remembers the reference a command was run with, so
+// completion can offer it. Aliases are completed from config and are not
+// recorded. Failures are ignored: the list is best effort.
+func recordRecentRef(cmd *cobra.Command, args []string) {
+	if !refCommands[cmd] || len(args) == 0 {
+		return
+	}
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return
+	}
+	ref, _, _ := strings.Cut(args[0], ":/")
+	if name, _, _ := strings.Cut(ref, ":"); ref == "" || cfg.Aliases[name] != "" {
+		return
+	}
+	path, err := recentRefsPath(cfg)
+	if err != nil {
+		return
+	}
+
+	refs := recentRefs(cfg)
+	if len(refs) > 0 && refs[0] == ref {
+		return
+	}
+	refs = slices.DeleteFunc(refs, func(r string) bool { return r == ref })
+	refs = slices.Insert(refs, 0, ref)
+	refs = refs[:min(len(refs), completionRecentRefs)]
+
+	data, err := json.Marshal(completionRefs{Refs: refs})
+	if err == nil && os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+		os.WriteFile(path, data, 0o600) //nolint:errcheck,gosec // the list is best effort
+	}
+}
+
+// cachedCompletionTags returns the tags of repo, from the completion cache
+// if they were listed within completionTagsTTL. If the registry cannot be
+// reached, a stale cached list is better than none.
+func cachedCompletionTags(ctx context.Context, cfg *internalcfg.Config, repo string) []string {
+	path := ""
+	if dir, err := resolveCacheDir(cfg); err == nil {
+		sum := sha256.Sum256([]byte(repo))
+		path = filepath.Join(dir, "completion", "tags-"+hex.EncodeToString(sum[:8])+".json")
+	}
+
+	var cached completionTags
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil { //nolint:gosec // path is derived from the cache dir
+			if json.Unmarshal(data, &cached) != nil || cached.Repository != repo {
+				cached = completionTags{}
+			}
+		}
+	}
+	if cached.Repository != "" && time.Since(cached.Fetched) < completionTagsTTL {
+		return cached.Tags
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionTagsTimeout)
+	defer cancel()
+	tags, err := listTags(ctx, cfg, repo)
+	if err != nil {
+		return cached.Tags
+	}
+	slices.Sort(tags)
+
+	if path != "" {
+		data, err := json.Marshal(completionTags{Repository: repo, Fetched: time.Now(), Tags: tags})
+		if err == nil && os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+			os.WriteFile(path, data, 0o600) //nolint:errcheck,gosec // the cache is best effort
+		}
+	}
+	return tags
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// setupCompletionConfig points the completion functions at a config file
+// with the given contents.
+func setupCompletionConfig(t *testing.T, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	oldCfgFile := cfgFile
+	cfgFile = path
+	viper.Reset()
+	t.Cleanup(func() {
+		cfgFile = oldCfgFile
+		viper.Reset()
+	})
+}
+
+func TestCompleteRef_Aliases(t *testing.T) {
+	setupCompletionConfig(t, `aliases:
+  configs: ghcr.io/acme/configs
+  cfg: ghcr.io/acme/app:v1
+  prod: ghcr.io/acme/app:stable
+`)
+
+	completions, directive := completeRef(pullCmd, nil, "c")
+	assert.Equal(t, []cobra.Completion{
+		"cfg\tghcr.io/acme/app:v1",
+		"configs\tghcr.io/acme/configs",
+	}, completions)
+	assert.Equal(t, cobra.ShellCompDirectiveNoSpace|cobra.ShellCompDirectiveNoFileComp, directive)
+}
+
+func TestCompleteRef_AliasTags(t *testing.T) {
+	reg, srv := newTestRegistry(t)
+	reg.add(t, "v1.0.0", nil, "")
+	reg.add(t, "v1.1.0", nil, "")
+	reg.add(t, "latest", nil, "")
+
+	setupCompletionConfig(t, `plain-http: true
+cache:
+  dir: `+t.TempDir()+`
+aliases:
+  configs: `+reg.repo+`:latest
+`)
+
+	// Without the environment variable set by the script, tags are not listed.
+	completions, _ := completeRef(pullCmd, nil, "configs:")
+	assert.Empty(t, completions)
+
+	t.Setenv(completionTagsEnv, "1")
+	completions, directive := completeRef(pullCmd, nil, "configs:v1")
+	assert.Equal(t, []cobra.Completion{"configs:v1.0.0", "configs:v1.1.0"}, completions)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	// Within the TTL, the cached list is used without asking the registry.
+	srv.Close()
+	completions, _ = completeRef(pullCmd, nil, "configs:l")
+	assert.Equal(t, []cobra.Completion{"configs:latest"}, completions)
+}
+
+func TestCompletionCmd_AliasTags(t *testing.T) {
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"completion", "bash", "--alias-tags"})
+	keepRegistryTransport(t)
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	})
+
+	require.NoError(t, rootCmd.Execute())
+	assert.True(t, strings.HasSuffix(buf.String(), "export "+completionTagsEnv+"=1\n"))
+}
+
+func TestCompleteArchivePath(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	reg.addArchive(t, "v1", map[string]string{
+		"config.json":        "{}",
+		"etc/app.yaml":       "app: true",
+		"etc/conf.d/db.yaml": "db: pg",
+	})
+
+	setupCompletionConfig(t, `plain-http: true
+cache:
+  dir: `+t.TempDir()+`
+aliases:
+  configs: `+reg.repo+`
+`)
+
+	tests := []struct {
+		name          string
+		cmd           *cobra.Command
+		args          []string
+		toComplete    string
+		want          []cobra.Completion
+		wantDirective cobra.ShellCompDirective
+	}{
+		{
+			name:          "ls root",
+			cmd:           lsCmd,
+			args:          []string{"configs:v1"},
+			want:          []cobra.Completion{"config.json", "etc/"},
+			wantDirective: cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "cat nested directory",
+			cmd:           catCmd,
+			args:          []string{"configs:v1"},
+			toComplete:    "etc/a",
+			want:          []cobra.Completion{"etc/app.yaml"},
+			wantDirective: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "cp source",
+			cmd:           cpCmd,
+			toComplete:    "configs:v1:/etc/c",
+			want:          []cobra.Completion{"configs:v1:/etc/conf.d/"},
+			wantDirective: cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:          "cp destination",
+			cmd:           cpCmd,
+			args:          []string{"configs:v1:/etc"},
+			toComplete:    "./",
+			wantDirective: cobra.ShellCompDirectiveDefault,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completions, directive := tt.cmd.ValidArgsFunction(tt.cmd, tt.args, tt.toComplete)
+			assert.Equal(t, tt.want, completions)
+			assert.Equal(t, tt.wantDirective, directive)
+		})
+	}
+}
+
+func TestRecordRecentRef(t *testing.T) {
+	cacheDir := t.TempDir()
+	setupCompletionConfig(t, `cache:
+  dir: `+cacheDir+`
+aliases:
+  configs: ghcr.io/acme/configs
+`)
+	cfg, err := completionConfig()
+	require.NoError(t, err)
+	ctx := internalcfg.WithConfig(context.Background(), cfg)
+	t.Cleanup(func() {
+		pullCmd.SetContext(context.Background())
+		cpCmd.SetContext(context.Background())
+	})
+	pullCmd.SetContext(ctx)
+	cpCmd.SetContext(ctx)
+
+	recordRecentRef(pullCmd, []string{"ghcr.io/acme/app:v1", "./out"})
+	recordRecentRef(cpCmd, []string{"ghcr.io/acme/data:v2:/etc", "./etc"})
+	recordRecentRef(pullCmd, []string{"ghcr.io/acme/app:v1"})
+	recordRecentRef(pullCmd, []string{"configs:v1"}) // aliases are not recorded
+	recordRecentRef(tagsCmd, nil)
+
+	assert.Equal(t, []string{"ghcr.io/acme/app:v1", "ghcr.io/acme/data:v2"}, recentRefs(cfg))
+
+	completions, _ := completeRef(pullCmd, nil, "ghcr.io/acme/d")
+	assert.Equal(t, []cobra.Completion{"ghcr.io/acme/data:v2\trecently used"}, completions)
+}
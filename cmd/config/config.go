@@ -20,4 +20,5 @@ func init() {
 	Cmd.AddCommand(showCmd)
 	Cmd.AddCommand(pathCmd)
 	Cmd.AddCommand(editCmd)
+	Cmd.AddCommand(logCmd)
 }
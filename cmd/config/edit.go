@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,7 +10,9 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/meigma/blob-cli/internal/audit"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 var editCmd = &cobra.Command{
@@ -20,11 +23,18 @@ var editCmd = &cobra.Command{
 Opens the configuration file in your default editor. Uses $EDITOR,
 falling back to $VISUAL, then vi (or notepad on Windows).
 
-Creates the config file with defaults if it doesn't exist.`,
+Creates the config file with defaults if it doesn't exist. Keys
+changed in the editor are recorded in the audit log shown by
+"blob config log".`,
 	Example: `  blob config edit`,
 	Args:    cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		path, err := internalcfg.ConfigPathUsed()
+		cfg := internalcfg.FromContext(cmd.Context())
+		if cfg == nil {
+			return errors.New("configuration not loaded")
+		}
+
+		path, err := cfg.FilePath()
 		if err != nil {
 			return err
 		}
@@ -36,6 +46,11 @@ Creates the config file with defaults if it doesn't exist.`,
 			}
 		}
 
+		before, err := os.ReadFile(path) //nolint:gosec // path is the user's config file
+		if err != nil {
+			return fmt.Errorf("reading config file: %w", err)
+		}
+
 		editorCmd, editorArgs := parseEditor(getEditor())
 		allArgs := append(editorArgs, path)
 
@@ -44,10 +59,30 @@ Creates the config file with defaults if it doesn't exist.`,
 		c.Stdout = os.Stdout
 		c.Stderr = os.Stderr
 
-		return c.Run()
+		if err := c.Run(); err != nil {
+			return err
+		}
+		recordEdit(path, before)
+		return nil
 	},
 }
 
+// recordEdit records the keys changed by an edit in the audit log. The
+// edit is already saved, so problems are only reported as warnings.
+func recordEdit(path string, before []byte) {
+	after, err := os.ReadFile(path) //nolint:gosec // path is the user's config file
+	if err == nil {
+		var entries []audit.Entry
+		entries, err = audit.Changes("config edit", before, after)
+		if err == nil {
+			err = audit.Record(path, entries...)
+		}
+	}
+	if err != nil {
+		warn.Printf("not recorded in audit log: %v", err)
+	}
+}
+
 // getEditor returns the user's preferred editor.
 func getEditor() string {
 	if editor := os.Getenv("EDITOR"); editor != "" {
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meigma/blob-cli/internal/audit"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log [key]",
+	Short: "Show the configuration change history",
+	Long: `Show the configuration change history.
+
+Lists the changes made to the configuration file by "blob alias set",
+"blob alias remove", and "blob config edit", newest first, with the
+time, the user, the command, and the old and new values. The history
+is kept next to the config file (config.audit.jsonl).
+
+With a key, only changes to that key or keys below it are shown, for
+example "aliases" or "aliases.prod".`,
+	Example: `  blob config log
+  blob config log aliases.prod
+  blob config log -n 10 --output json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := internalcfg.FromContext(cmd.Context())
+		if cfg == nil {
+			return errors.New("configuration not loaded")
+		}
+
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			return fmt.Errorf("reading limit flag: %w", err)
+		}
+		if limit < 0 {
+			return errors.New("--limit cannot be negative")
+		}
+
+		path, err := cfg.FilePath()
+		if err != nil {
+			return fmt.Errorf("determining config path: %w", err)
+		}
+		entries, err := audit.Read(path)
+		if err != nil {
+			return err
+		}
+
+		key := ""
+		if len(args) == 1 {
+			key = args[0]
+		}
+		entries = filterLog(entries, key, limit)
+
+		if cfg.Quiet {
+			return nil
+		}
+		if cfg.Output == internalcfg.OutputJSON {
+			return logJSON(entries)
+		}
+		return logText(entries)
+	},
+}
+
+func init() {
+	logCmd.Flags().IntP("limit", "n", 0, "show at most this many changes (0 for all)")
+}
+
+// filterLog returns the entries for key and the keys below it, newest
+// first, keeping at most limit entries if limit is positive.
+func filterLog(entries []audit.Entry, key string, limit int) []audit.Entry {
+	filtered := make([]audit.Entry, 0, len(entries))
+	for _, e := range slices.Backward(entries) {
+		if key != "" && e.Key != key && !strings.HasPrefix(e.Key, key+".") {
+			continue
+		}
+		filtered = append(filtered, e)
+		if limit > 0 && len(filtered) == limit {
+			break
+		}
+	}
+	return filtered
+}
+
+func logJSON(entries []audit.Entry) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string][]audit.Entry{"changes": entries})
+}
+
+func logText(entries []audit.Entry) error {
+	if len(entries) == 0 {
+		fmt.Println("No configuration changes recorded.")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Printf("%s  %s  %s  %s\n", e.Time.Local().Format(time.DateTime), e.User, e.Command, e.Key)
+		switch {
+		case e.Old == "":
+			fmt.Printf("  + %s\n", e.New)
+		case e.New == "":
+			fmt.Printf("  - %s\n", e.Old)
+		default:
+			fmt.Printf("  - %s\n  + %s\n", e.Old, e.New)
+		}
+	}
+	return nil
+}
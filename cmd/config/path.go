@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -18,7 +19,12 @@ follows the XDG Base Directory Specification.`,
 	Example: `  blob config path`,
 	Args:    cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		path, err := internalcfg.ConfigPathUsed()
+		cfg := internalcfg.FromContext(cmd.Context())
+		if cfg == nil {
+			return errors.New("configuration not loaded")
+		}
+
+		path, err := cfg.FilePath()
 		if err != nil {
 			return err
 		}
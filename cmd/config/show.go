@@ -10,7 +10,6 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	internalcfg "github.com/meigma/blob-cli/internal/config"
 )
@@ -31,7 +30,7 @@ config file, environment variables).`,
 			return errors.New("configuration not loaded")
 		}
 
-		output := viper.GetString("output")
+		output := cfg.Output
 		if output == "json" {
 			return showJSON(cfg)
 		}
@@ -55,6 +54,11 @@ func showText(cfg *internalcfg.Config) error {
 	fmt.Printf("verbose:      %d\n", cfg.Verbose)
 	fmt.Printf("quiet:        %t\n", cfg.Quiet)
 	fmt.Printf("no-color:     %t\n", cfg.NoColor)
+	fmt.Printf("strict:       %t\n", cfg.Strict)
+	fmt.Printf("offline:      %t\n", cfg.Offline)
+	if cfg.DefaultRef != "" {
+		fmt.Printf("default_ref:  %s\n", cfg.DefaultRef)
+	}
 
 	// Cache settings
 	fmt.Println()
@@ -67,7 +71,7 @@ func showText(cfg *internalcfg.Config) error {
 		fmt.Printf("  ref_ttl:    %s\n", cfg.Cache.RefTTL)
 	}
 	if cfg.Cache.MaxSize != "" {
-		fmt.Printf("  max_size:   %s (deprecated)\n", cfg.Cache.MaxSize)
+		fmt.Printf("  max_size:   %s\n", cfg.Cache.MaxSize)
 	}
 
 	// Per-cache settings (only show if explicitly configured)
@@ -83,6 +87,16 @@ func showText(cfg *internalcfg.Config) error {
 	showCacheType("manifests", cfg.Cache.Manifests, cfg.Cache.ManifestsEnabled())
 	showCacheType("indexes", cfg.Cache.Indexes, cfg.Cache.IndexesEnabled())
 
+	// Push settings
+	fmt.Println()
+	fmt.Println("push:")
+	fmt.Printf("  skip_compress_min_size:    %d\n", cfg.Push.SkipCompressMinSize)
+	if len(cfg.Push.SkipCompressExtensions) > 0 {
+		fmt.Printf("  skip_compress_extensions:  %s\n", strings.Join(cfg.Push.SkipCompressExtensions, ", "))
+	} else {
+		fmt.Println("  skip_compress_extensions:  (built-in)")
+	}
+
 	// Aliases (sorted for deterministic output)
 	fmt.Println()
 	if len(cfg.Aliases) == 0 {
@@ -99,6 +113,29 @@ func showText(cfg *internalcfg.Config) error {
 		}
 	}
 
+	// Shortcuts
+	if len(cfg.Shortcuts) > 0 {
+		fmt.Println()
+		fmt.Println("shortcuts:")
+		names := make([]string, 0, len(cfg.Shortcuts))
+		for name := range cfg.Shortcuts {
+			names = append(names, name)
+		}
+		slices.SortFunc(names, cmp.Compare)
+		for _, name := range names {
+			fmt.Printf("  %s -> %s\n", name, cfg.Shortcuts[name])
+		}
+	}
+
+	// Registries
+	if len(cfg.Registries) > 0 {
+		fmt.Println()
+		fmt.Println("registries:")
+		for _, r := range cfg.Registries {
+			fmt.Printf("  %s%s\n", r.Host, registryAuthSummary(r))
+		}
+	}
+
 	// Policies
 	fmt.Println()
 	if len(cfg.Policies) == 0 {
@@ -106,9 +143,79 @@ func showText(cfg *internalcfg.Config) error {
 	} else {
 		fmt.Println("policies:")
 		for _, rule := range cfg.Policies {
+			var notes []string
+			if rule.Source != "" {
+				notes = append(notes, "from "+rule.Source)
+			}
+			if rule.Alias != "" {
+				notes = append(notes, "from alias "+rule.Alias)
+			}
+			if rule.Mode == internalcfg.PolicyModeWarn {
+				notes = append(notes, "warn only")
+			}
+			if len(notes) > 0 {
+				fmt.Printf("  match: %s (%s)\n", rule.Match, strings.Join(notes, ", "))
+				continue
+			}
 			fmt.Printf("  match: %s\n", rule.Match)
 		}
 	}
 
+	// Schemas
+	if len(cfg.Schemas) > 0 {
+		fmt.Println()
+		fmt.Println("schemas:")
+		for _, rule := range cfg.Schemas {
+			fmt.Printf("  %s -> %s\n", rule.Match, rule.Schema)
+		}
+	}
+
+	// Hooks
+	if len(cfg.Hooks.PrePush) > 0 || len(cfg.Hooks.PriorityExtracted) > 0 {
+		fmt.Println()
+		fmt.Println("hooks:")
+		for _, hook := range []struct {
+			name     string
+			commands []string
+		}{
+			{"pre_push", cfg.Hooks.PrePush},
+			{"priority_extracted", cfg.Hooks.PriorityExtracted},
+		} {
+			if len(hook.commands) == 0 {
+				continue
+			}
+			fmt.Printf("  %s:\n", hook.name)
+			for _, command := range hook.commands {
+				fmt.Printf("    %s\n", command)
+			}
+		}
+	}
+
 	return nil
 }
+
+// registryAuthSummary describes how a registry is accessed, without secrets.
+func registryAuthSummary(r internalcfg.RegistryAuth) string {
+	var parts []string
+	switch {
+	case r.CredentialHelper != "":
+		parts = append(parts, "credential helper "+r.CredentialHelper)
+	case r.PasswordEnv != "":
+		parts = append(parts, "password from $"+r.PasswordEnv)
+	case r.IdentityTokenEnv != "":
+		parts = append(parts, "identity token from $"+r.IdentityTokenEnv)
+	}
+	if r.PlainHTTP {
+		parts = append(parts, "plain HTTP")
+	}
+	if r.CAFile != "" {
+		parts = append(parts, "CA "+r.CAFile)
+	}
+	if r.SkipTLSVerify {
+		parts = append(parts, "TLS verification skipped")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/registry/remote"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+var copyCmd = &cobra.Command{
+	Use:   "copy <src-ref> <dst-ref>",
+	Short: "Copy an archive from one registry to another",
+	Long: `Copy an archive from one registry to another.
+
+Streams the manifest and blobs of the source archive to the destination
+without extracting anything locally. Blobs the destination already
+holds are not sent again, and within one registry they are mounted from
+the source repository instead of uploaded. The copy has the same
+digest as the source, so its signatures still apply.
+
+The source manifest is checked against the config policies first, as
+pull does, so only an archive that passes is copied; --no-verify skips
+them. --include-referrers also copies the archive's referrers, such as
+signatures and attestations.
+
+A destination without a tag or digest gets the tag of the source, or
+only the digest if the source has none. A destination with a digest
+must match the source.`,
+	Example: `  blob copy ghcr.io/acme/configs:v1.0.0 registry.internal/acme/configs:v1.0.0
+  blob copy --include-referrers ghcr.io/acme/configs:v1.0.0 registry.internal/acme/configs
+  blob copy foo:v1 ghcr.io/acme/configs-mirror:stable      # Using alias`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCopy,
+}
+
+func init() {
+	copyCmd.Flags().Bool("include-referrers", false, "also copy the archive's signatures and attestations")
+	addNoVerifyFlag(copyCmd)
+}
+
+// copyResult contains the copy output data.
+type copyResult struct {
+	Source         string `json:"source"`
+	ResolvedSource string `json:"resolved_source,omitempty"`
+	Dest           string `json:"dest"`
+	ResolvedDest   string `json:"resolved_dest,omitempty"`
+	Digest         string `json:"digest"`
+	graphCopyStats
+	verificationStatus
+}
+
+// graphCopyStats counts what copying a graph to a registry transferred.
+type graphCopyStats struct {
+	Blobs     int   `json:"blobs"`
+	Size      int64 `json:"size"`
+	Existing  int   `json:"existing"`
+	Mounted   int   `json:"mounted,omitempty"`
+	Referrers int   `json:"referrers"`
+}
+
+// options returns copy options recording into s the copy of the graph
+// rooted at root. Manifests other than root are counted as referrers.
+func (s *graphCopyStats) options(root ocispec.Descriptor) oras.ExtendedCopyGraphOptions {
+	var mu sync.Mutex // blobs are copied concurrently
+	countReferrer := func(desc ocispec.Descriptor) {
+		if isManifestMediaType(desc.MediaType) && desc.Digest != root.Digest {
+			s.Referrers++
+		}
+	}
+	var opts oras.ExtendedCopyGraphOptions
+	opts.PostCopy = func(_ context.Context, desc ocispec.Descriptor) error {
+		mu.Lock()
+		defer mu.Unlock()
+		s.Blobs++
+		s.Size += desc.Size
+		countReferrer(desc)
+		return nil
+	}
+	opts.OnCopySkipped = func(_ context.Context, desc ocispec.Descriptor) error {
+		mu.Lock()
+		defer mu.Unlock()
+		s.Existing++
+		countReferrer(desc)
+		return nil
+	}
+	opts.OnMounted = func(_ context.Context, _ ocispec.Descriptor) error {
+		mu.Lock()
+		defer mu.Unlock()
+		s.Mounted++
+		return nil
+	}
+	return opts
+}
+
+func runCopy(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	includeReferrers, err := cmd.Flags().GetBool("include-referrers")
+	if err != nil {
+		return fmt.Errorf("reading include-referrers flag: %w", err)
+	}
+	noVerify, err := cmd.Flags().GetBool("no-verify")
+	if err != nil {
+		return fmt.Errorf("reading no-verify flag: %w", err)
+	}
+
+	inputSrc, inputDst := args[0], args[1]
+	ctx := cmd.Context()
+	srcRef, err := resolveRef(ctx, cfg, inputSrc)
+	if err != nil {
+		return err
+	}
+	dstRef := copyDestRef(srcRef, cfg.ResolveAlias(inputDst))
+
+	// Check the manifest against the policies, then copy it by digest so
+	// the artifact copied is the one that was checked
+	policyOpts, verification, err := readPolicyOpts(cfg, srcRef, noVerify)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(cfg, policyOpts...)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	manifest, err := fetchPullManifest(ctx, client, srcRef, false)
+	if err != nil {
+		return archiveError(err)
+	}
+	if _, want, ok := strings.Cut(dstRef, "@"); ok && want != manifest.Digest() {
+		return fmt.Errorf("%s is %s, not the digest in %s", srcRef, manifest.Digest(), dstRef)
+	}
+
+	src, err := newRemoteRepository(cfg, repositoryOf(srcRef))
+	if err != nil {
+		return err
+	}
+	dst, err := newRemoteRepository(cfg, repositoryOf(dstRef))
+	if err != nil {
+		return err
+	}
+	root, err := src.Resolve(ctx, manifest.Digest())
+	if err != nil {
+		return archiveError(fmt.Errorf("resolving %s: %w", srcRef, err))
+	}
+
+	result := copyResult{
+		Source:             inputSrc,
+		Dest:               inputDst,
+		Digest:             manifest.Digest(),
+		verificationStatus: verification,
+	}
+	if srcRef != inputSrc {
+		result.ResolvedSource = srcRef
+	}
+	if dstRef != inputDst {
+		result.ResolvedDest = dstRef
+	}
+
+	opts := result.options(root)
+	opts.MountFrom = mountFrom(src, dst)
+	if includeReferrers {
+		err = oras.ExtendedCopyGraph(ctx, src, dst, root, opts)
+	} else {
+		err = oras.CopyGraph(ctx, src, dst, root, opts.CopyGraphOptions)
+	}
+	if err != nil {
+		return archiveError(fmt.Errorf("copying archive: %w", err))
+	}
+	if _, tag, ok := splitTag(dstRef); ok {
+		if err := dst.Tag(ctx, root, tag); err != nil {
+			return fmt.Errorf("tagging %s: %w", dstRef, err)
+		}
+	}
+
+	return outputCopyResult(cfg, &result)
+}
+
+// copyDestRef returns dst with the tag of src added if dst names only a
+// repository.
+func copyDestRef(src, dst string) string {
+	if strings.Contains(dst, "@") || repositoryOf(dst) != dst {
+		return dst
+	}
+	if _, tag, ok := splitTag(src); ok {
+		return dst + ":" + tag
+	}
+	return dst
+}
+
+// mountFrom returns a MountFrom function offering the source repository
+// for cross-repository blob mounts when src and dst are on the same
+// registry, or nil otherwise.
+func mountFrom(src, dst *remote.Repository) func(context.Context, ocispec.Descriptor) ([]string, error) {
+	if src.Reference.Registry != dst.Reference.Registry || src.Reference.Repository == dst.Reference.Repository {
+		return nil
+	}
+	return func(context.Context, ocispec.Descriptor) ([]string, error) {
+		return []string{src.Reference.Repository}, nil
+	}
+}
+
+// outputCopyResult formats and outputs the copy result.
+func outputCopyResult(cfg *internalcfg.Config, result *copyResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	fmt.Printf("Copied %s to %s\n", result.Source, result.Dest)
+	if result.ResolvedSource != "" {
+		fmt.Printf("  Source: %s\n", result.ResolvedSource)
+	}
+	if result.ResolvedDest != "" {
+		fmt.Printf("  Dest: %s\n", result.ResolvedDest)
+	}
+	fmt.Printf("  Digest: %s\n", result.Digest)
+	printGraphCopyStats(&result.graphCopyStats)
+	if result.Verified {
+		fmt.Printf("  Verified: %d policies applied\n", result.PoliciesApplied)
+	} else {
+		fmt.Println("  Verified: no policies applied")
+	}
+	return nil
+}
+
+// printGraphCopyStats prints the blob and referrer counts of a copy.
+func printGraphCopyStats(s *graphCopyStats) {
+	line := fmt.Sprintf("  Blobs: %d copied (%s), %d already present",
+		s.Blobs, archive.FormatSize(uint64(s.Size)), s.Existing) //nolint:gosec // sizes are non-negative
+	if s.Mounted > 0 {
+		line += fmt.Sprintf(", %d mounted", s.Mounted)
+	}
+	fmt.Println(line)
+	fmt.Printf("  Referrers: %d\n", s.Referrers)
+}
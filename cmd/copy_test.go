@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestCopy(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	v1 := reg.addArchive(t, "v1", map[string]string{"etc/app.conf": "app"})
+	sig := reg.add(t, "", &v1, "application/vnd.dev.sigstore.bundle.v0.3+json")
+	reg.addBlob(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	t.Cleanup(func() {
+		copyCmd.Flags().Set("include-referrers", "false")
+	})
+
+	cfg := newRegistryTestConfig(t)
+	copyCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	dstReg, _ := newTestRegistry(t)
+	require.NoError(t, copyCmd.RunE(copyCmd, []string{reg.repo + ":v1", dstReg.repo + ":stable"}))
+	assert.Equal(t, v1.Digest.String(), dstReg.tag("stable"))
+	assert.False(t, dstReg.hasManifest(sig.Digest.String()), "referrers are left out by default")
+	assert.Equal(t, map[string]string{"etc/app.conf": "app"}, pulledFiles(t, cfg, dstReg.repo+":stable"))
+
+	// A bare repository takes the source tag; referrers come along on request
+	require.NoError(t, copyCmd.Flags().Set("include-referrers", "true"))
+	require.NoError(t, copyCmd.RunE(copyCmd, []string{reg.repo + ":v1", dstReg.repo}))
+	assert.Equal(t, v1.Digest.String(), dstReg.tag("v1"))
+	assert.True(t, dstReg.hasManifest(sig.Digest.String()))
+
+	wrong := "sha256:" + strings.Repeat("0", 64)
+	err := copyCmd.RunE(copyCmd, []string{reg.repo + ":v1", dstReg.repo + "@" + wrong})
+	require.ErrorContains(t, err, "not the digest")
+	assert.False(t, dstReg.hasManifest(wrong))
+	err = copyCmd.RunE(copyCmd, []string{reg.repo + ":missing", dstReg.repo})
+	requireExitCode(t, err, exitCodeNotFound)
+	assert.Empty(t, dstReg.tag("missing"))
+}
+
+func TestCopyDestRef(t *testing.T) {
+	assert.Equal(t, "reg.io/mirror:v1", copyDestRef("ghcr.io/acme/app:v1", "reg.io/mirror"))
+	assert.Equal(t, "reg.io/mirror:stable", copyDestRef("ghcr.io/acme/app:v1", "reg.io/mirror:stable"))
+	assert.Equal(t, "localhost:5000/mirror:v1", copyDestRef("ghcr.io/acme/app:v1", "localhost:5000/mirror"))
+	assert.Equal(t, "reg.io/mirror", copyDestRef("ghcr.io/acme/app@sha256:abc", "reg.io/mirror"))
+	assert.Equal(t, "reg.io/mirror@sha256:abc", copyDestRef("ghcr.io/acme/app:v1", "reg.io/mirror@sha256:abc"))
+}
@@ -1,26 +1,34 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/meigma/blob"
+	blobcore "github.com/meigma/blob/core"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/transfer"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 var cpCmd = &cobra.Command{
-	Use:   "cp <ref>:<path>... <dest>",
-	Short: "Copy files or directories from an archive to the local filesystem",
-	Long: `Copy files or directories from an archive to the local filesystem.
+	Use:   "cp <src>... <dest>",
+	Short: "Copy files or directories between an archive and the local filesystem",
+	Long: `Copy files or directories between an archive and the local filesystem.
 
 Uses HTTP range requests to fetch only the requested files without
 downloading the entire archive. Multiple source paths can be specified.
@@ -29,10 +37,50 @@ Behavior:
   - Single file to file:      blob cp reg/repo:v1:/config.json ./config.json
   - Single file to dir:       blob cp reg/repo:v1:/config.json ./output/
   - Multiple files to dir:    blob cp reg/repo:v1:/a.json reg/repo:v1:/b.json ./output/
-  - Directory to directory:   blob cp reg/repo:v1:/etc/nginx ./nginx-config`,
+  - Directory to directory:   blob cp reg/repo:v1:/etc/nginx ./nginx-config
+
+Up to --jobs files are fetched at once, shared between the sources
+copied at the same time. Use --jobs 1 to copy one file at a time.
+Sources that would write the same destination file are refused.
+
+With --platform, source paths are relative to the directory of the
+archive's variant for that platform (see "blob push --platform").
+
+--archive-manifest writes a JSON manifest of every file copied (archive
+path, destination path, size, digest, and mode) for deployment tooling
+that tracks what was placed where. Files skipped because they already
+exist are not listed.
+
+--verify-checksums reads every copied file back from disk and compares
+its SHA-256 digest with the archive index, as for "blob pull". Any
+mismatch exits with code 8.
+
+Config policies that match a source archive are enforced as for
+"blob pull"; --no-verify skips them.
+
+A missing archive exits with code 4 and a missing source path with
+code 7. --ignore-missing skips missing source paths with a warning and
+copies the rest; they are listed under "missing" in JSON output.
+
+When the destination is <ref>:<path>, local files and directories are
+copied into the archive and the result is pushed as a new archive,
+tagged with the destination's tag or --tag. Files already at the
+destination path are replaced and every other file is kept. A blob
+archive stores its files in a single data blob, so unchanged files are
+read from the source archive (or the local cache) rather than supplied
+again, but the new data blob is uploaded in full. Compression follows
+the config file, pre_push hooks run unless --no-hooks is set, and the
+source manifest's annotations are kept. The new manifest has a new
+digest: signatures and attestations of the source archive do not carry
+over, so sign it again if policies require it.`,
 	Example: `  blob cp ghcr.io/acme/configs:v1.0.0:/config.json ./config.json
   blob cp ghcr.io/acme/configs:v1.0.0:/etc/nginx/ ./nginx/
-  blob cp ghcr.io/acme/configs:v1.0.0:/a.json ghcr.io/acme/configs:v1.0.0:/b.json ./`,
+  blob cp ghcr.io/acme/configs:v1.0.0:/a.json ghcr.io/acme/configs:v1.0.0:/b.json ./
+  blob cp --platform linux/arm64 ghcr.io/acme/tools:v2:/bin/tool ./tool
+  blob cp --jobs 32 ghcr.io/acme/site:v3:/assets ./assets
+  blob cp --archive-manifest placed.json ghcr.io/acme/configs:v1.0.0:/etc ./etc
+  blob cp ./file.json ghcr.io/acme/configs:v1:/etc/file.json
+  blob cp --tag v1.0.1 ./nginx.conf ./mime.types ghcr.io/acme/configs:v1.0.0:/etc/nginx/`,
 	Args: cobra.MinimumNArgs(2),
 	RunE: runCp,
 }
@@ -42,14 +90,30 @@ func init() {
 	cpCmd.Flags().Bool("preserve", false, "preserve file permissions and timestamps from archive")
 	cpCmd.Flags().BoolP("force", "f", false, "overwrite existing files")
 	cpCmd.Flags().Bool("skip-cache", false, "bypass registry caches for this operation")
+	cpCmd.Flags().String("platform", "", "copy from the archive's variant for os/arch[/variant]")
+	cpCmd.Flags().IntP("jobs", "j", runtime.NumCPU(), "number of files to fetch in parallel")
+	cpCmd.Flags().String("archive-manifest", "", "write a JSON manifest of the copied files to this path")
+	cpCmd.Flags().Bool("ignore-missing", false, "skip source paths not found in the archive with a warning")
+	cpCmd.Flags().Bool("verify-checksums", false, "re-hash copied files and report any that do not match the archive")
+	cpCmd.Flags().String("tag", "", "when copying into an archive, tag the result with this tag instead of the destination's")
+	cpCmd.Flags().Bool("no-hooks", false, "when copying into an archive, skip pre_push hooks from config")
+	addNoVerifyFlag(cpCmd)
 }
 
 // cpFlags holds the parsed command flags.
 type cpFlags struct {
-	recursive bool
-	preserve  bool
-	force     bool
-	skipCache bool
+	recursive     bool
+	preserve      bool
+	force         bool
+	skipCache     bool
+	noVerify      bool
+	ignoreMissing bool
+	platform      string
+	jobs          int
+	manifest      string // path of the --archive-manifest output
+	tag           string // tag for the result of copying into an archive
+	noHooks       bool
+	checksums     bool // --verify-checksums
 }
 
 // cpSource represents a parsed source argument (ref:/path).
@@ -62,8 +126,9 @@ type cpSource struct {
 // cpResolvedSource represents a source with its archive and detected type.
 type cpResolvedSource struct {
 	cpSource
-	archive *blob.Archive
-	isDir   bool
+	archive      *blob.Archive
+	isDir        bool
+	verification verificationStatus
 }
 
 // cpResult contains the result of a copy operation.
@@ -73,12 +138,114 @@ type cpResult struct {
 	FileCount   int              `json:"file_count"`
 	TotalSize   uint64           `json:"total_size"`
 	SizeHuman   string           `json:"size_human,omitempty"`
+	Manifest    string           `json:"archive_manifest,omitempty"`
+	Missing     []cpMissing      `json:"missing,omitempty"`
+	Checksums   *checksumReport  `json:"checksums,omitempty"`
+	Transfer    *transfer.Stats  `json:"transfer,omitempty"`
+
+	// files lists the copied files for --archive-manifest.
+	files *cpManifestRecorder
+}
+
+// cpManifest is the --archive-manifest output.
+type cpManifest struct {
+	Destination string            `json:"destination"`
+	Files       []cpManifestEntry `json:"files"`
+}
+
+// cpManifestEntry describes a copied file.
+type cpManifestEntry struct {
+	Ref         string `json:"ref"`
+	Path        string `json:"path"`
+	Destination string `json:"destination"`
+	Size        uint64 `json:"size"`
+	Digest      string `json:"digest"`
+	Mode        string `json:"mode"`
+}
+
+// cpManifestRecorder collects manifest entries from concurrent copies.
+type cpManifestRecorder struct {
+	mu      sync.Mutex
+	entries []cpManifestEntry
+}
+
+// record adds the archive file at archivePath, copied from src to dest.
+func (r *cpManifestRecorder) record(src cpResolvedSource, archivePath, dest string) {
+	entry, ok := src.archive.Entry(archivePath)
+	if !ok {
+		return
+	}
+	mode := entry.Mode().Perm()
+	if info, err := os.Stat(dest); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, cpManifestEntry{
+		Ref:         src.inputRef,
+		Path:        "/" + archivePath,
+		Destination: dest,
+		Size:        entry.OriginalSize(),
+		Digest:      "sha256:" + hex.EncodeToString(entry.HashBytes()),
+		Mode:        fmt.Sprintf("%04o", mode),
+	})
+}
+
+// copyOption returns a copy option recording the files copied from src
+// into destDir.
+func (r *cpManifestRecorder) copyOption(src cpResolvedSource, destDir string) blob.CopyOption {
+	return blobcore.CopyWithProgress(func(ev blob.ProgressEvent) {
+		if ev.Stage == blob.StageExtracting {
+			r.record(src, ev.Path, filepath.Join(destDir, filepath.FromSlash(ev.Path)))
+		}
+	})
+}
+
+// checksumFiles returns the recorded files for --verify-checksums.
+func (r *cpManifestRecorder) checksumFiles() []checksumFile {
+	if r == nil {
+		return nil
+	}
+	files := make([]checksumFile, 0, len(r.entries))
+	for _, e := range r.entries {
+		files = append(files, checksumFile{Path: e.Path, Destination: e.Destination, Digest: e.Digest})
+	}
+	return files
+}
+
+// writeCpManifest writes the files recorded in result to path, sorted by
+// destination.
+func writeCpManifest(path string, result *cpResult) error {
+	manifest := cpManifest{Destination: result.Destination, Files: []cpManifestEntry{}}
+	if result.files != nil {
+		manifest.Files = append(manifest.Files, result.files.entries...)
+	}
+	slices.SortFunc(manifest.Files, func(a, b cpManifestEntry) int {
+		return cmp.Compare(a.Destination, b.Destination)
+	})
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding archive manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil { //nolint:gosec // the manifest is not secret
+		return fmt.Errorf("writing archive manifest: %w", err)
+	}
+	return nil
+}
+
+// cpMissing is a source path skipped by --ignore-missing.
+type cpMissing struct {
+	Ref  string `json:"ref"`
+	Path string `json:"path"`
 }
 
 // cpSourceResult represents a single source in the result.
 type cpSourceResult struct {
 	Ref  string `json:"ref"`
 	Path string `json:"path"`
+	verificationStatus
 }
 
 func runCp(cmd *cobra.Command, args []string) error {
@@ -97,6 +264,15 @@ func runCp(cmd *cobra.Command, args []string) error {
 	// 3. Parse source arguments (all but last)
 	sourceArgs := args[:len(args)-1]
 	dest := args[len(args)-1]
+	if isArchiveArg(dest) {
+		if flags.checksums {
+			return errors.New("--verify-checksums requires a local destination")
+		}
+		return runCpToArchive(cmd.Context(), cfg, sourceArgs, dest, flags)
+	}
+	if flags.tag != "" {
+		return errors.New("--tag requires an archive destination (<ref>:<path>)")
+	}
 
 	sources, err := parseSourceArgs(sourceArgs, cfg)
 	if err != nil {
@@ -105,88 +281,136 @@ func runCp(cmd *cobra.Command, args []string) error {
 
 	// 4. Pull archives and resolve source types
 	ctx := cmd.Context()
-	archiveCache := make(map[string]*blob.Archive)
+	refs := make([]*string, len(sources))
+	for i := range sources {
+		refs[i] = &sources[i].ref
+	}
+	if err := resolveSemverRefs(ctx, cfg, refs...); err != nil {
+		return err
+	}
+	if flags.platform != "" {
+		sources, err = selectSourcePlatform(ctx, cfg, sources, flags.platform, flags.skipCache)
+		if err != nil {
+			return err
+		}
+	}
+	archiveCache := make(map[string]*cpArchive)
 	resolvedSources := make([]cpResolvedSource, 0, len(sources))
+	var missing []cpMissing
 
 	for _, src := range sources {
-		rsrc, resolveErr := resolveSource(ctx, cfg, src, archiveCache, flags.skipCache)
+		rsrc, resolveErr := resolveSource(ctx, cfg, src, archiveCache, flags)
 		if resolveErr != nil {
+			if flags.ignoreMissing && isPathNotFound(resolveErr) {
+				warn.Printf("skipping %s:%s: not found", src.inputRef, src.path)
+				missing = append(missing, cpMissing{Ref: src.inputRef, Path: src.path})
+				continue
+			}
 			return resolveErr
 		}
 		resolvedSources = append(resolvedSources, rsrc)
 	}
 
 	// 5. Validate destination and determine overall copy mode
-	destPath, err := validateAndPrepareDestination(resolvedSources, dest, flags)
-	if err != nil {
-		return err
+	result := &cpResult{Sources: []cpSourceResult{}, Destination: dest}
+	if abs, absErr := filepath.Abs(dest); absErr == nil {
+		result.Destination = abs
 	}
+	if len(resolvedSources) > 0 {
+		destPath, err := validateAndPrepareDestination(resolvedSources, dest, flags)
+		if err != nil {
+			return err
+		}
 
-	// 6. Execute copy operations
-	result := &cpResult{
-		Sources:     make([]cpSourceResult, 0, len(sources)),
-		Destination: destPath,
-	}
-
-	copyOpts := buildCopyOpts(flags)
-
-	for _, rsrc := range resolvedSources {
-		count, size, err := copyResolvedSource(rsrc, destPath, flags, copyOpts, len(resolvedSources) > 1)
+		// 6. Execute copy operations
+		result, err = copyResolvedSources(ctx, resolvedSources, destPath, flags)
 		if err != nil {
 			return err
 		}
-		result.FileCount += count
-		result.TotalSize += size
-		result.Sources = append(result.Sources, cpSourceResult{
-			Ref:  rsrc.inputRef,
-			Path: rsrc.path,
-		})
+		collectCaches(cfg)
 	}
-
+	result.Missing = missing
 	result.SizeHuman = archive.FormatSize(result.TotalSize)
+	if flags.manifest != "" {
+		if err := writeCpManifest(flags.manifest, result); err != nil {
+			return err
+		}
+		result.Manifest = flags.manifest
+	}
+	if flags.checksums {
+		result.Checksums = verifyChecksums(result.files.checksumFiles())
+	}
 
 	// 7. Output result
-	return outputCpResult(cfg, result)
+	if err := outputCpResult(cfg, result); err != nil {
+		return err
+	}
+	return checksumError(result.Checksums)
+}
+
+// cpArchive is a pulled source archive and how it was verified.
+type cpArchive struct {
+	archive      *blob.Archive
+	verification verificationStatus
 }
 
-// resolveSource pulls the archive (if not cached) and detects if the source is a file or directory.
-func resolveSource(ctx context.Context, cfg *internalcfg.Config, src cpSource, cache map[string]*blob.Archive, skipCache bool) (cpResolvedSource, error) {
+// resolveSource pulls the archive (if not cached), enforcing the config
+// policies for its ref, and detects if the source is a file or directory.
+func resolveSource(ctx context.Context, cfg *internalcfg.Config, src cpSource, cache map[string]*cpArchive, flags cpFlags) (cpResolvedSource, error) {
 	// Get or create archive for this ref
-	blobArchive, ok := cache[src.ref]
+	pulled, ok := cache[src.ref]
+	if !ok && cfg.Offline && !flags.skipCache {
+		// Offline, a pinned archive is read. It was verified when it was
+		// pinned.
+		if pinned, pin, found := openPinned(cfg, src.ref); found {
+			verification, err := checkPinnedRead(cfg, src.ref, pin, flags.noVerify)
+			if err != nil {
+				return cpResolvedSource{}, err
+			}
+			pulled = &cpArchive{archive: pinned, verification: verification}
+			cache[src.ref] = pulled
+			ok = true
+		}
+	}
 	if !ok {
+		policyOpts, verification, err := readPolicyOpts(cfg, src.ref, flags.noVerify)
+		if err != nil {
+			return cpResolvedSource{}, err
+		}
 		var client *blob.Client
-		var clientErr error
-		if skipCache {
-			client, clientErr = blob.NewClient(clientOptsNoCache(cfg)...)
+		if flags.skipCache {
+			client, err = blob.NewClient(append(clientOptsNoCache(cfg), policyOpts...)...)
 		} else {
-			client, clientErr = newClient(cfg)
+			client, err = newClient(cfg, policyOpts...)
 		}
-		if clientErr != nil {
-			return cpResolvedSource{}, fmt.Errorf("creating client: %w", clientErr)
+		if err != nil {
+			return cpResolvedSource{}, fmt.Errorf("creating client: %w", err)
 		}
 		var pullOpts []blob.PullOption
-		if skipCache {
+		if flags.skipCache {
 			pullOpts = append(pullOpts, blob.PullWithSkipCache())
 		}
-		var pullErr error
-		blobArchive, pullErr = client.Pull(ctx, src.ref, pullOpts...)
-		if pullErr != nil {
-			return cpResolvedSource{}, fmt.Errorf("accessing archive %s: %w", src.ref, pullErr)
+		blobArchive, err := client.Pull(ctx, src.ref, pullOpts...)
+		if err != nil {
+			return cpResolvedSource{}, archiveError(fmt.Errorf("accessing archive %s: %w", src.ref, err))
 		}
-		cache[src.ref] = blobArchive
+		pulled = &cpArchive{archive: blobArchive, verification: verification}
+		cache[src.ref] = pulled
 	}
+	blobArchive := pulled.archive
 
 	// Detect if source is a file or directory
 	srcPath := blob.NormalizePath(src.path)
 	if !blobArchive.Exists(srcPath) {
-		return cpResolvedSource{}, fmt.Errorf("path not found in archive: %s", src.path)
+		return cpResolvedSource{}, pathNotFoundError(fmt.Errorf("path not found in archive: %s", src.path))
 	}
 	isDir := blobArchive.IsDir(srcPath)
 
 	return cpResolvedSource{
-		cpSource: src,
-		archive:  blobArchive,
-		isDir:    isDir,
+		cpSource:     src,
+		archive:      blobArchive,
+		isDir:        isDir,
+		verification: pulled.verification,
 	}, nil
 }
 
@@ -296,9 +520,124 @@ func prepareSingleSourceDest(src cpResolvedSource, di destInfo) (string, error)
 	return di.absPath, nil
 }
 
-// copyResolvedSource copies a resolved source to the destination.
-func copyResolvedSource(rsrc cpResolvedSource, destPath string, flags cpFlags, opts []blob.CopyOption, multiSource bool) (fileCount int, totalSize uint64, err error) {
+// copyResolvedSources copies sources to destPath, sharing flags.jobs
+// workers among the sources copied at once. Sources are reported in
+// argument order regardless of completion order.
+func copyResolvedSources(ctx context.Context, sources []cpResolvedSource, destPath string, flags cpFlags) (*cpResult, error) {
+	if err := checkCpCollisions(sources); err != nil {
+		return nil, err
+	}
+	sourceJobs, workers := splitCpJobs(flags.jobs, len(sources))
+	sourceFlags := flags
+	sourceFlags.jobs = workers
+	copyOpts := buildCopyOpts(sourceFlags)
+	multiSource := len(sources) > 1
+	var files *cpManifestRecorder
+	if flags.manifest != "" || flags.checksums {
+		files = &cpManifestRecorder{}
+	}
+
+	type copied struct {
+		count int
+		size  uint64
+	}
+	results := make([]copied, len(sources))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(sourceJobs)
+	for i, rsrc := range sources {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			count, size, err := copyResolvedSource(rsrc, destPath, flags, copyOpts, multiSource, files)
+			if err != nil {
+				return err
+			}
+			results[i] = copied{count: count, size: size}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	result := &cpResult{
+		Sources:     make([]cpSourceResult, 0, len(sources)),
+		Destination: destPath,
+		files:       files,
+	}
+	for i, rsrc := range sources {
+		result.FileCount += results[i].count
+		result.TotalSize += results[i].size
+		result.Sources = append(result.Sources, cpSourceResult{
+			Ref:                rsrc.inputRef,
+			Path:               rsrc.path,
+			verificationStatus: rsrc.verification,
+		})
+	}
+	return result, nil
+}
+
+// splitCpJobs divides jobs between sources: it returns how many sources
+// to copy at once and how many workers each gets, so that no more than
+// jobs files are fetched at a time.
+func splitCpJobs(jobs, sources int) (sourceJobs, workers int) {
+	jobs = max(jobs, 1)
+	sourceJobs = max(min(jobs, sources), 1)
+	return sourceJobs, max(jobs/sourceJobs, 1)
+}
+
+// checkCpCollisions returns an error if two sources would write the same
+// file, which copying them at once would race on.
+func checkCpCollisions(sources []cpResolvedSource) error {
+	if len(sources) < 2 {
+		return nil
+	}
+	owners := make(map[string]int)
+	for i, rsrc := range sources {
+		for _, path := range cpSourceFiles(rsrc) {
+			j, ok := owners[path]
+			if !ok {
+				owners[path] = i
+				continue
+			}
+			if j != i {
+				return fmt.Errorf("%s:%s and %s:%s both copy /%s to the destination",
+					sources[j].inputRef, sources[j].path, rsrc.inputRef, rsrc.path, path)
+			}
+		}
+	}
+	return nil
+}
+
+// cpSourceFiles returns the archive paths of the files rsrc copies, which
+// are also their paths under the destination.
+func cpSourceFiles(rsrc cpResolvedSource) []string {
+	srcPath := blob.NormalizePath(rsrc.path)
+	if !rsrc.isDir {
+		return []string{srcPath}
+	}
+	entries := rsrc.archive.Entries()
+	if srcPath != "" && srcPath != "." {
+		entries = rsrc.archive.EntriesWithPrefix(srcPath + "/")
+	}
+	var paths []string
+	for e := range entries {
+		if !e.Mode().IsDir() {
+			paths = append(paths, e.Path())
+		}
+	}
+	return paths
+}
+
+// copyResolvedSource copies a resolved source to the destination. Copied
+// files are recorded in files, if it is not nil.
+func copyResolvedSource(rsrc cpResolvedSource, destPath string, flags cpFlags, opts []blob.CopyOption, multiSource bool, files *cpManifestRecorder) (fileCount int, totalSize uint64, err error) {
 	srcPath := blob.NormalizePath(rsrc.path)
+	if files != nil {
+		opts = append(slices.Clip(opts), files.copyOption(rsrc, destPath))
+	}
 
 	if rsrc.isDir {
 		return copyDirectory(rsrc.archive, srcPath, rsrc.path, destPath, opts)
@@ -312,7 +651,11 @@ func copyResolvedSource(rsrc cpResolvedSource, destPath string, flags cpFlags, o
 		return copyFileToDir(rsrc.archive, srcPath, rsrc.path, destPath, opts)
 	}
 
-	return copyFileToFile(rsrc.archive, srcPath, rsrc.path, destPath, flags)
+	fileCount, totalSize, err = copyFileToFile(rsrc.archive, srcPath, rsrc.path, destPath, flags)
+	if err == nil && fileCount > 0 && files != nil {
+		files.record(rsrc, srcPath, destPath)
+	}
+	return fileCount, totalSize, err
 }
 
 // copyDirectory copies a directory recursively.
@@ -415,9 +758,58 @@ func parseCpFlags(cmd *cobra.Command) (cpFlags, error) {
 		return flags, fmt.Errorf("reading skip-cache flag: %w", err)
 	}
 
+	flags.noVerify, err = cmd.Flags().GetBool("no-verify")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-verify flag: %w", err)
+	}
+
+	flags.ignoreMissing, err = cmd.Flags().GetBool("ignore-missing")
+	if err != nil {
+		return flags, fmt.Errorf("reading ignore-missing flag: %w", err)
+	}
+
+	flags.platform, err = cmd.Flags().GetString("platform")
+	if err != nil {
+		return flags, fmt.Errorf("reading platform flag: %w", err)
+	}
+
+	flags.manifest, err = cmd.Flags().GetString("archive-manifest")
+	if err != nil {
+		return flags, fmt.Errorf("reading archive-manifest flag: %w", err)
+	}
+
+	flags.tag, err = cmd.Flags().GetString("tag")
+	if err != nil {
+		return flags, fmt.Errorf("reading tag flag: %w", err)
+	}
+
+	flags.noHooks, err = cmd.Flags().GetBool("no-hooks")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-hooks flag: %w", err)
+	}
+
+	flags.checksums, err = cmd.Flags().GetBool("verify-checksums")
+	if err != nil {
+		return flags, fmt.Errorf("reading verify-checksums flag: %w", err)
+	}
+
+	flags.jobs, err = cmd.Flags().GetInt("jobs")
+	if err != nil {
+		return flags, fmt.Errorf("reading jobs flag: %w", err)
+	}
+	if flags.jobs < 1 {
+		return flags, errors.New("--jobs must be at least 1")
+	}
+
 	return flags, nil
 }
 
+// isArchiveArg reports whether arg is in "ref:/path" format rather than a
+// local path. A single letter before ":/" is a Windows drive, not a ref.
+func isArchiveArg(arg string) bool {
+	return strings.Index(arg, ":/") > 1
+}
+
 // parseSourceArg parses a single source argument in "ref:/path" format.
 func parseSourceArg(arg string, cfg *internalcfg.Config) (cpSource, error) {
 	// Find ":/" which separates ref from archive path
@@ -462,6 +854,9 @@ func buildCopyOpts(flags cpFlags) []blob.CopyOption {
 	if flags.preserve {
 		opts = append(opts, blob.CopyWithPreserveMode(true), blob.CopyWithPreserveTimes(true))
 	}
+	if flags.jobs > 0 {
+		opts = append(opts, blob.CopyWithWorkers(flags.jobs), blob.CopyWithReadConcurrency(flags.jobs))
+	}
 	return opts
 }
 
@@ -470,7 +865,8 @@ func outputCpResult(cfg *internalcfg.Config, result *cpResult) error {
 	if cfg.Quiet {
 		return nil
 	}
-	if viper.GetString("output") == internalcfg.OutputJSON {
+	if cfg.Output == internalcfg.OutputJSON {
+		result.Transfer = transferStats()
 		return cpJSON(result)
 	}
 	return cpText(result)
@@ -488,5 +884,14 @@ func cpText(result *cpResult) error {
 		fmt.Printf("  %s:%s\n", src.Ref, src.Path)
 	}
 	fmt.Printf("  → %s\n", result.Destination)
+	if len(result.Missing) > 0 {
+		fmt.Printf("Skipped %d missing path(s)\n", len(result.Missing))
+		for _, m := range result.Missing {
+			fmt.Printf("  %s:%s\n", m.Ref, m.Path)
+		}
+	}
+	if result.Checksums != nil {
+		printChecksumReport(result.Checksums)
+	}
 	return nil
 }
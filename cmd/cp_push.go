@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/meigma/blob"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// cpPushResult contains the result of copying local files into an archive.
+type cpPushResult struct {
+	Source    string       `json:"source"`
+	Ref       string       `json:"ref"`
+	Files     []cpPushFile `json:"files"`
+	FileCount int          `json:"file_count"`
+	TotalSize uint64       `json:"total_size"`
+	SizeHuman string       `json:"size_human,omitempty"`
+	verificationStatus
+}
+
+// cpPushFile describes a local file copied into the archive.
+type cpPushFile struct {
+	Path   string `json:"path"`
+	Local  string `json:"local"`
+	Status string `json:"status"` // "added" or "replaced"
+	Size   uint64 `json:"size"`
+}
+
+// runCpToArchive copies the local sourceArgs into the archive named by
+// dest and pushes the result. The source archive is extracted to a
+// temporary directory, the local files are laid over it, and the
+// directory is pushed as a new archive.
+func runCpToArchive(ctx context.Context, cfg *internalcfg.Config, sourceArgs []string, dest string, flags cpFlags) error {
+	for _, arg := range sourceArgs {
+		if isArchiveArg(arg) {
+			return fmt.Errorf("cannot copy %s into an archive: sources must be local paths", arg)
+		}
+	}
+	if flags.platform != "" {
+		return errors.New("--platform cannot be used when copying into an archive")
+	}
+	if flags.manifest != "" {
+		return errors.New("--archive-manifest cannot be used when copying into an archive")
+	}
+	if flags.ignoreMissing {
+		return errors.New("--ignore-missing cannot be used when copying into an archive")
+	}
+
+	target, err := parseSourceArg(dest, cfg)
+	if err != nil {
+		return err
+	}
+	if err := resolveSemverRefs(ctx, cfg, &target.ref); err != nil {
+		return err
+	}
+	pushRef, err := cpPushRef(target.ref, flags.tag)
+	if err != nil {
+		return err
+	}
+
+	blobArchive, annotations, verification, err := pullVerifiedArchive(ctx, cfg, target.ref, flags)
+	if err != nil {
+		return err
+	}
+
+	workDir, err := os.MkdirTemp("", "blob-cp-")
+	if err != nil {
+		return fmt.Errorf("creating work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	if _, err := blobArchive.CopyDir(workDir, ".",
+		blob.CopyWithPreserveMode(true),
+		blob.CopyWithPreserveTimes(true),
+		blob.CopyWithWorkers(flags.jobs),
+		blob.CopyWithReadConcurrency(flags.jobs),
+	); err != nil {
+		return fmt.Errorf("extracting archive %s: %w", target.ref, err)
+	}
+
+	files, err := overlayLocalSources(blobArchive, sourceArgs, target.path, workDir, flags.recursive)
+	if err != nil {
+		return err
+	}
+
+	if err := validateSourceMetadata(workDir); err != nil {
+		return err
+	}
+	if !flags.noHooks {
+		if err := runPrePushHooks(ctx, cfg, pushRef, workDir); err != nil {
+			return err
+		}
+	}
+
+	compression, err := mapCompression(cfg.Compression)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	pushOpts := buildPushOptions(pushFlags{
+		compression:    compression,
+		skipCompressed: true,
+		annotations:    annotations,
+	}, &cfg.Push)
+	if err := client.Push(ctx, pushRef, workDir, pushOpts...); err != nil {
+		return fmt.Errorf("pushing archive: %w", err)
+	}
+
+	result := cpPushResult{
+		Source:             target.inputRef,
+		Ref:                pushRef,
+		Files:              files,
+		FileCount:          len(files),
+		verificationStatus: verification,
+	}
+	for _, f := range files {
+		result.TotalSize += f.Size
+	}
+	result.SizeHuman = archive.FormatSize(result.TotalSize)
+	return outputCpPushResult(cfg, &result)
+}
+
+// cpPushRef returns the ref the patched archive is pushed to: ref with its
+// tag replaced by tag, if set. The result must name a tag.
+func cpPushRef(ref, tag string) (string, error) {
+	if tag != "" {
+		return repositoryOf(ref) + ":" + tag, nil
+	}
+	if strings.Contains(ref, "@") || repositoryOf(ref) == ref {
+		return "", fmt.Errorf("destination %s has no tag to push to; use --tag", ref)
+	}
+	return ref, nil
+}
+
+// pullVerifiedArchive pulls the archive at ref, enforcing the config
+// policies that match it, and returns it with its manifest annotations.
+// The archive is pulled by digest, so it is the one that was verified even
+// if the tag moves.
+func pullVerifiedArchive(ctx context.Context, cfg *internalcfg.Config, ref string, flags cpFlags) (*blob.Archive, map[string]string, verificationStatus, error) {
+	policyOpts, verification, err := readPolicyOpts(cfg, ref, flags.noVerify)
+	if err != nil {
+		return nil, nil, verificationStatus{}, err
+	}
+	baseOpts := clientOpts(cfg)
+	if flags.skipCache {
+		baseOpts = clientOptsNoCache(cfg)
+	}
+	checker, err := blob.NewClient(append(baseOpts, policyOpts...)...)
+	if err != nil {
+		return nil, nil, verificationStatus{}, fmt.Errorf("creating client: %w", err)
+	}
+	var fetchOpts []blob.FetchOption
+	if flags.skipCache {
+		fetchOpts = append(fetchOpts, blob.FetchWithSkipCache())
+	}
+	manifest, err := checker.Fetch(ctx, ref, fetchOpts...)
+	if err != nil {
+		return nil, nil, verificationStatus{}, archiveError(fmt.Errorf("accessing archive %s: %w", ref, err))
+	}
+
+	client, err := blob.NewClient(baseOpts...)
+	if err != nil {
+		return nil, nil, verificationStatus{}, fmt.Errorf("creating client: %w", err)
+	}
+	blobArchive, err := client.Pull(ctx, repositoryOf(ref)+"@"+manifest.Digest())
+	if err != nil {
+		return nil, nil, verificationStatus{}, fmt.Errorf("accessing archive %s: %w", ref, err)
+	}
+
+	// The push records its own creation time
+	annotations := make(map[string]string, len(manifest.Annotations()))
+	for k, v := range manifest.Annotations() {
+		if k != ocispec.AnnotationCreated {
+			annotations[k] = v
+		}
+	}
+	return blobArchive, annotations, verification, nil
+}
+
+// overlayLocalSources copies the local sources into workDir, which holds
+// the extracted archive, at destPath within the archive. As with cp(1),
+// sources are placed by name in destPath if it is a directory in the
+// archive, ends in "/", or there are several sources; otherwise the single
+// source is copied to destPath itself.
+func overlayLocalSources(blobArchive *blob.Archive, sources []string, destPath, workDir string, recursive bool) ([]cpPushFile, error) {
+	dir := blob.NormalizePath(destPath)
+	intoDir := len(sources) > 1 || strings.HasSuffix(destPath, "/") || blobArchive.IsDir(dir)
+
+	var files []cpPushFile
+	for _, src := range sources {
+		info, err := os.Stat(src)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("source path does not exist: %s", src)
+			}
+			return nil, fmt.Errorf("accessing source path: %w", err)
+		}
+
+		target := dir
+		if intoDir {
+			target = path.Join(dir, filepath.Base(filepath.Clean(src)))
+		}
+
+		if !info.IsDir() {
+			if target == "." {
+				return nil, fmt.Errorf("cannot replace the archive root with file %s", src)
+			}
+			f, err := overlayFile(blobArchive, src, target, workDir)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, f)
+			continue
+		}
+
+		if !recursive {
+			return nil, fmt.Errorf("cannot copy directory %s without -r flag", src)
+		}
+		if blobArchive.IsFile(target) {
+			return nil, fmt.Errorf("cannot replace file /%s with directory %s", target, src)
+		}
+		err = filepath.WalkDir(src, func(p string, d fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(src, p)
+			if err != nil {
+				return err
+			}
+			f, err := overlayFile(blobArchive, p, path.Join(target, filepath.ToSlash(rel)), workDir)
+			if err != nil {
+				return err
+			}
+			files = append(files, f)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("copying %s: %w", src, err)
+		}
+	}
+	return files, nil
+}
+
+// overlayFile copies the local file src to archivePath in workDir,
+// keeping its permissions and modification time.
+func overlayFile(blobArchive *blob.Archive, src, archivePath, workDir string) (cpPushFile, error) {
+	if blobArchive.IsDir(archivePath) {
+		return cpPushFile{}, fmt.Errorf("cannot replace directory /%s with file %s", archivePath, src)
+	}
+	status := "added"
+	if blobArchive.IsFile(archivePath) {
+		status = "replaced"
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return cpPushFile{}, fmt.Errorf("opening %s: %w", src, err)
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return cpPushFile{}, fmt.Errorf("reading %s: %w", src, err)
+	}
+
+	dest := filepath.Join(workDir, filepath.FromSlash(archivePath))
+	if err := ensureDir(filepath.Dir(dest)); err != nil {
+		return cpPushFile{}, err
+	}
+	// Replace rather than truncate, so the file takes src's permissions
+	if err := os.Remove(dest); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return cpPushFile{}, fmt.Errorf("replacing %s: %w", archivePath, err)
+	}
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm()) //nolint:gosec // dest is inside the work directory
+	if err != nil {
+		return cpPushFile{}, fmt.Errorf("writing %s: %w", archivePath, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return cpPushFile{}, fmt.Errorf("writing %s: %w", archivePath, err)
+	}
+	if err := out.Close(); err != nil {
+		return cpPushFile{}, fmt.Errorf("writing %s: %w", archivePath, err)
+	}
+	if err := os.Chtimes(dest, info.ModTime(), info.ModTime()); err != nil {
+		return cpPushFile{}, fmt.Errorf("writing %s: %w", archivePath, err)
+	}
+
+	return cpPushFile{
+		Path:   "/" + archivePath,
+		Local:  src,
+		Status: status,
+		Size:   uint64(info.Size()), //nolint:gosec // file sizes are non-negative
+	}, nil
+}
+
+// outputCpPushResult formats and outputs the result of copying into an archive.
+func outputCpPushResult(cfg *internalcfg.Config, result *cpPushResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	fmt.Printf("Pushed %s (%d file(s) from %s, %s)\n", result.Ref, result.FileCount, result.Source, result.SizeHuman)
+	for _, f := range result.Files {
+		fmt.Printf("  %-8s %s\n", f.Status, f.Path)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestIsArchiveArg(t *testing.T) {
+	assert.True(t, isArchiveArg("ghcr.io/acme/configs:v1:/etc/file.json"))
+	assert.True(t, isArchiveArg("configs:/"))
+	assert.False(t, isArchiveArg("./file.json"))
+	assert.False(t, isArchiveArg(`C:/Users/dev/file.json`))
+	assert.False(t, isArchiveArg("/abs/path"))
+}
+
+func TestCpPushRef(t *testing.T) {
+	ref, err := cpPushRef("ghcr.io/acme/configs:v1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/acme/configs:v1", ref)
+
+	ref, err = cpPushRef("ghcr.io/acme/configs:v1", "v1.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/acme/configs:v1.0.1", ref)
+
+	ref, err = cpPushRef("ghcr.io/acme/configs@sha256:abc", "v2")
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/acme/configs:v2", ref)
+
+	_, err = cpPushRef("ghcr.io/acme/configs@sha256:abc", "")
+	require.ErrorContains(t, err, "use --tag")
+}
+
+func TestCpCmd_ToArchive(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	reg.addArchive(t, "v1", map[string]string{
+		"config.json":   `{"unchanged":true}`,
+		"etc/file.json": "old",
+	})
+	repo := reg.repo
+	oldDigest := reg.tag("v1")
+
+	local := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(local, "file.json"), []byte("new"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(local, "conf.d"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(local, "conf.d", "extra.conf"), []byte("extra"), 0o644))
+
+	cfg := newRegistryTestConfig(t)
+	cfg.Compression = internalcfg.CompressionZstd
+	cpCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	// Replace a file in place
+	err := cpCmd.RunE(cpCmd, []string{filepath.Join(local, "file.json"), repo + ":v1:/etc/file.json"})
+	require.NoError(t, err)
+	assert.NotEqual(t, oldDigest, reg.tag("v1"))
+	assert.Equal(t, map[string]string{
+		"config.json":   `{"unchanged":true}`,
+		"etc/file.json": "new",
+	}, pulledFiles(t, cfg, repo+":v1"))
+
+	// Add a directory under /etc, tagging the result separately
+	require.NoError(t, cpCmd.Flags().Set("tag", "v2"))
+	t.Cleanup(func() { cpCmd.Flags().Set("tag", "") }) //nolint:errcheck // test cleanup
+	err = cpCmd.RunE(cpCmd, []string{filepath.Join(local, "conf.d"), repo + ":v1:/etc/"})
+	require.NoError(t, err)
+	require.NoError(t, cpCmd.Flags().Set("tag", ""))
+
+	assert.Equal(t, map[string]string{
+		"config.json":           `{"unchanged":true}`,
+		"etc/file.json":         "new",
+		"etc/conf.d/extra.conf": "extra",
+	}, pulledFiles(t, cfg, repo+":v2"))
+}
+
+func TestCpCmd_ToArchiveRejects(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	v1 := reg.addArchive(t, "v1", map[string]string{"etc/file.json": "old"})
+	repo := reg.repo
+
+	local := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(local, "conf"), 0o755))
+
+	cfg := newRegistryTestConfig(t)
+	cpCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	err := cpCmd.RunE(cpCmd, []string{repo + ":v1:/etc/file.json", repo + ":v1:/etc/copy.json"})
+	require.ErrorContains(t, err, "sources must be local paths")
+
+	// A directory cannot replace a file
+	err = cpCmd.RunE(cpCmd, []string{filepath.Join(local, "conf"), repo + ":v1:/etc/file.json"})
+	require.ErrorContains(t, err, "cannot replace file /etc/file.json")
+
+	err = cpCmd.RunE(cpCmd, []string{filepath.Join(local, "missing"), repo + ":v1:/etc/"})
+	require.ErrorContains(t, err, "does not exist")
+
+	// Nothing was pushed
+	assert.Equal(t, v1.Digest.String(), reg.tag("v1"))
+}
@@ -1,22 +1,25 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"testing"
 
-	"github.com/spf13/viper"
+	"github.com/meigma/blob"
+	blobcore "github.com/meigma/blob/core"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 func TestCpCmd_NilConfig(t *testing.T) {
-	viper.Reset()
-
 	ctx := context.Background()
 
 	cpCmd.SetContext(ctx)
@@ -28,7 +31,7 @@ func TestCpCmd_NilConfig(t *testing.T) {
 
 func TestCpCmd_MinimumArgs(t *testing.T) {
 	// Verify command requires at least 2 args (source + dest)
-	assert.Equal(t, "cp <ref>:<path>... <dest>", cpCmd.Use)
+	assert.Equal(t, "cp <src>... <dest>", cpCmd.Use)
 
 	// Cobra's MinimumNArgs(2) is set
 	err := cpCmd.Args(cpCmd, []string{"only-one-arg"})
@@ -366,4 +369,205 @@ func TestBuildCopyOpts(t *testing.T) {
 	flags = cpFlags{recursive: true, preserve: false, force: true}
 	opts = buildCopyOpts(flags)
 	assert.Len(t, opts, 1) // overwrite option (set to true)
+
+	// With jobs
+	flags = cpFlags{recursive: true, jobs: 8}
+	opts = buildCopyOpts(flags)
+	assert.Len(t, opts, 3) // overwrite + workers + read concurrency
+}
+
+func TestCpFlags_Jobs(t *testing.T) {
+	t.Cleanup(func() {
+		cpCmd.Flags().Set("jobs", strconv.Itoa(runtime.NumCPU())) //nolint:errcheck // test cleanup
+	})
+
+	require.NoError(t, cpCmd.Flags().Set("jobs", "16"))
+	flags, err := parseCpFlags(cpCmd)
+	require.NoError(t, err)
+	assert.Equal(t, 16, flags.jobs)
+
+	require.NoError(t, cpCmd.Flags().Set("jobs", "0"))
+	_, err = parseCpFlags(cpCmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--jobs must be at least 1")
+}
+
+// memSource serves archive data from memory.
+type memSource struct {
+	*bytes.Reader
+}
+
+func (memSource) SourceID() string { return "test" }
+
+// newTestArchive builds an in-memory archive from the given files.
+func newTestArchive(t *testing.T, files map[string]string) *blob.Archive {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	var indexBuf, dataBuf bytes.Buffer
+	require.NoError(t, blobcore.Create(t.Context(), dir, &indexBuf, &dataBuf))
+
+	b, err := blobcore.New(indexBuf.Bytes(), memSource{bytes.NewReader(dataBuf.Bytes())})
+	require.NoError(t, err)
+	return &blob.Archive{Blob: b}
+}
+
+func TestCopyResolvedSources(t *testing.T) {
+	arch := newTestArchive(t, map[string]string{
+		"a.json":          `{"a":1}`,
+		"b.json":          `{"b":2}`,
+		"etc/app.yaml":    "app: true",
+		"etc/nginx/x.cfg": "x",
+	})
+	sources := []cpResolvedSource{
+		{cpSource: cpSource{inputRef: "test:v1", path: "/a.json"}, archive: arch},
+		{cpSource: cpSource{inputRef: "test:v1", path: "/etc"}, archive: arch, isDir: true},
+		{cpSource: cpSource{inputRef: "test:v1", path: "/b.json"}, archive: arch},
+	}
+	dest := t.TempDir()
+
+	result, err := copyResolvedSources(t.Context(), sources, dest, cpFlags{recursive: true, jobs: 4})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, result.FileCount)
+	require.Len(t, result.Sources, 3)
+	assert.Equal(t, "/a.json", result.Sources[0].Path)
+	assert.Equal(t, "/etc", result.Sources[1].Path)
+	assert.Equal(t, "/b.json", result.Sources[2].Path)
+
+	for name, want := range map[string]string{
+		"a.json":          `{"a":1}`,
+		"b.json":          `{"b":2}`,
+		"etc/app.yaml":    "app: true",
+		"etc/nginx/x.cfg": "x",
+	} {
+		got, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+		require.NoError(t, err, name)
+		assert.Equal(t, want, string(got), name)
+	}
+}
+
+func TestSplitCpJobs(t *testing.T) {
+	tests := []struct {
+		jobs, sources         int
+		wantSources, wantEach int
+	}{
+		{jobs: 8, sources: 1, wantSources: 1, wantEach: 8},
+		{jobs: 8, sources: 2, wantSources: 2, wantEach: 4},
+		{jobs: 8, sources: 3, wantSources: 3, wantEach: 2},
+		{jobs: 4, sources: 10, wantSources: 4, wantEach: 1},
+		{jobs: 1, sources: 5, wantSources: 1, wantEach: 1},
+	}
+	for _, tt := range tests {
+		sourceJobs, workers := splitCpJobs(tt.jobs, tt.sources)
+		assert.Equal(t, tt.wantSources, sourceJobs, "%d jobs, %d sources", tt.jobs, tt.sources)
+		assert.Equal(t, tt.wantEach, workers, "%d jobs, %d sources", tt.jobs, tt.sources)
+		assert.LessOrEqual(t, sourceJobs*workers, tt.jobs)
+	}
+}
+
+func TestCopyResolvedSources_Collision(t *testing.T) {
+	v1 := newTestArchive(t, map[string]string{"etc/app.conf": "v1", "etc/db.conf": "db"})
+	v2 := newTestArchive(t, map[string]string{"etc/app.conf": "v2"})
+	dest := t.TempDir()
+
+	for _, sources := range [][]cpResolvedSource{
+		{
+			{cpSource: cpSource{inputRef: "test:v1", path: "/etc/app.conf"}, archive: v1},
+			{cpSource: cpSource{inputRef: "test:v2", path: "/etc/app.conf"}, archive: v2},
+		},
+		{
+			{cpSource: cpSource{inputRef: "test:v1", path: "/etc"}, archive: v1, isDir: true},
+			{cpSource: cpSource{inputRef: "test:v2", path: "/etc/app.conf"}, archive: v2},
+		},
+	} {
+		_, err := copyResolvedSources(t.Context(), sources, dest, cpFlags{recursive: true, jobs: 4})
+		require.ErrorContains(t, err, "both copy /etc/app.conf")
+	}
+	entries, err := os.ReadDir(dest)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "nothing is copied")
+}
+
+func TestCopyResolvedSources_ArchiveManifest(t *testing.T) {
+	arch := newTestArchive(t, map[string]string{
+		"a.json":          `{"a":1}`,
+		"etc/app.yaml":    "app: true",
+		"etc/nginx/x.cfg": "x",
+	})
+	sources := []cpResolvedSource{
+		{cpSource: cpSource{inputRef: "test:v1", path: "/a.json"}, archive: arch},
+		{cpSource: cpSource{inputRef: "test:v1", path: "/etc"}, archive: arch, isDir: true},
+	}
+	dest := t.TempDir()
+	manifestPath := filepath.Join(t.TempDir(), "placed.json")
+
+	result, err := copyResolvedSources(t.Context(), sources, dest, cpFlags{recursive: true, jobs: 4, manifest: manifestPath})
+	require.NoError(t, err)
+	require.NoError(t, writeCpManifest(manifestPath, result))
+
+	data, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	var manifest cpManifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+
+	assert.Equal(t, dest, manifest.Destination)
+	require.Len(t, manifest.Files, 3)
+	want := []string{"/a.json", "/etc/app.yaml", "/etc/nginx/x.cfg"}
+	for i, f := range manifest.Files {
+		assert.Equal(t, want[i], f.Path)
+		assert.Equal(t, "test:v1", f.Ref)
+		assert.Equal(t, filepath.Join(dest, filepath.FromSlash(want[i][1:])), f.Destination)
+		assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, f.Digest)
+		assert.Regexp(t, `^0[0-7]{3}$`, f.Mode)
+	}
+	assert.Equal(t, uint64(len(`{"a":1}`)), manifest.Files[0].Size)
+}
+
+func TestCopyResolvedSources_ArchiveManifestFile(t *testing.T) {
+	arch := newTestArchive(t, map[string]string{"a.json": `{"a":1}`})
+	sources := []cpResolvedSource{
+		{cpSource: cpSource{inputRef: "test:v1", path: "/a.json"}, archive: arch},
+	}
+	dest := filepath.Join(t.TempDir(), "renamed.json")
+
+	result, err := copyResolvedSources(t.Context(), sources, dest, cpFlags{jobs: 1, manifest: "unused"})
+	require.NoError(t, err)
+	require.NotNil(t, result.files)
+	require.Len(t, result.files.entries, 1)
+	assert.Equal(t, "/a.json", result.files.entries[0].Path)
+	assert.Equal(t, dest, result.files.entries[0].Destination)
+}
+
+func TestCpCmd_IgnoreMissing(t *testing.T) {
+	warn.Reset()
+	warn.SetQuiet(true)
+	t.Cleanup(func() {
+		warn.Reset()
+	})
+
+	reg, _ := newTestRegistry(t)
+	reg.addArchive(t, "v1", map[string]string{"a.txt": "alpha"})
+	ref := reg.repo + ":v1"
+	dest := t.TempDir()
+
+	cfg := newRegistryTestConfig(t)
+	cpCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	args := []string{ref + ":/a.txt", ref + ":/missing.txt", dest}
+
+	err := cpCmd.RunE(cpCmd, args)
+	requireExitCode(t, err, exitCodePathNotFound)
+	assert.NoFileExists(t, filepath.Join(dest, "a.txt"), "nothing is copied when a path is missing")
+
+	require.NoError(t, cpCmd.Flags().Set("ignore-missing", "true"))
+	t.Cleanup(func() { cpCmd.Flags().Set("ignore-missing", "false") }) //nolint:errcheck // test cleanup
+	require.NoError(t, cpCmd.RunE(cpCmd, args))
+	assert.FileExists(t, filepath.Join(dest, "a.txt"))
+	assert.Equal(t, 1, warn.Count())
 }
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/meigma/blob"
+	"github.com/spf13/cobra"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/daemon"
+)
+
+// daemonSocketEnv overrides the daemon socket path for both the daemon and
+// the commands that delegate to it.
+const daemonSocketEnv = "BLOB_DAEMON_SOCKET"
+
+// noDaemonEnv disables delegation to a running daemon when set.
+const noDaemonEnv = "BLOB_NO_DAEMON"
+
+// defaultDaemonTTL matches the default cache.ref_ttl.
+const defaultDaemonTTL = 5 * time.Minute
+
+// daemonMaxArchives bounds how many opened archives the daemon keeps.
+const daemonMaxArchives = 64
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run a background process that keeps registry state warm",
+	Long: `Run a background process that keeps registry state warm.
+
+The daemon listens on a Unix socket and keeps authenticated registry
+connections, parsed archive indexes, and caches in memory. While it
+runs, "blob cat" sends its work to the daemon instead of connecting to
+the registry itself, which removes the TLS, authentication, and index
+overhead from each invocation.
+
+Indexes are kept for cache.ref_ttl (default 5m) before the tag is
+resolved again. Up to 64 references are kept; the least recently used
+is dropped to make room for another. The daemon uses the configuration
+it was started with; aliases are resolved by the calling command. Commands run with
+--skip-cache, or with BLOB_NO_DAEMON=1, do not use the daemon.
+
+Socket: <cache dir>/daemon.sock, or BLOB_DAEMON_SOCKET.`,
+	Example: `  blob daemon &
+  blob cat ghcr.io/acme/configs:v1.0.0 config.json   # served by the daemon
+  BLOB_NO_DAEMON=1 blob cat ghcr.io/acme/configs:v1.0.0 config.json`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+func init() {
+	daemonCmd.Flags().String("socket", "", "socket path (default: <cache dir>/daemon.sock)")
+}
+
+func runDaemon(cmd *cobra.Command, _ []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	socket, err := cmd.Flags().GetString("socket")
+	if err != nil {
+		return fmt.Errorf("reading socket flag: %w", err)
+	}
+	if socket == "" {
+		socket, err = daemonSocket(cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Replace a stale socket left by a daemon that did not shut down cleanly.
+	if client, err := daemon.Connect(ctx, socket); err == nil {
+		if ping, err := client.Ping(ctx); err == nil {
+			return fmt.Errorf("daemon already running on %s (pid %d)", socket, ping.PID)
+		}
+		return fmt.Errorf("daemon already running on %s", socket)
+	}
+	if err := os.Remove(socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing stale socket: %w", err)
+	}
+	if err := ensureDir(filepath.Dir(socket)); err != nil {
+		return err
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	archives := newDaemonArchives(client, daemonTTL(&cfg.Cache), daemonMaxArchives)
+
+	var logger *slog.Logger
+	if cfg.Verbose > 0 {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	ln, err := listenDaemonSocket(ctx, socket)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", socket, err)
+	}
+	defer os.Remove(socket)
+
+	srv := &http.Server{
+		Handler:           daemon.NewHandler(archives.open, version, logger),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if !cfg.Quiet && cfg.Output != internalcfg.OutputJSON {
+		fmt.Printf("Daemon listening on %s (pid %d)\n", socket, os.Getpid())
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("serving: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down: %w", err)
+	}
+	return nil
+}
+
+// daemonSocket returns the socket path for cfg.
+func daemonSocket(cfg *internalcfg.Config) (string, error) {
+	if socket := os.Getenv(daemonSocketEnv); socket != "" {
+		return socket, nil
+	}
+	cacheDir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return "", fmt.Errorf("determining cache directory: %w", err)
+	}
+	return daemon.SocketPath(cacheDir), nil
+}
+
+// connectDaemon returns a client for a running daemon, or nil if there is
+// none or delegation is disabled. The daemon reads from registries itself,
+// so it is not used offline.
+func connectDaemon(ctx context.Context, cfg *internalcfg.Config) *daemon.Client {
+	if os.Getenv(noDaemonEnv) != "" || cfg.Offline {
+		return nil
+	}
+	socket, err := daemonSocket(cfg)
+	if err != nil {
+		return nil
+	}
+	client, err := daemon.Connect(ctx, socket)
+	if err != nil {
+		if cfg.Verbose > 0 && !errors.Is(err, daemon.ErrUnavailable) {
+			fmt.Fprintf(os.Stderr, "Note: not using daemon: %v\n", err)
+		}
+		return nil
+	}
+	return client
+}
+
+// daemonTTL returns how long the daemon keeps an opened archive: the
+// configured reference cache TTL, or the config default.
+func daemonTTL(cache *internalcfg.CacheConfig) time.Duration {
+	if cache.RefTTL != "" {
+		if d, err := time.ParseDuration(cache.RefTTL); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultDaemonTTL
+}
+
+// daemonArchives keeps opened archives (and their parsed indexes) for the
+// daemon, re-resolving a reference once its entry is older than ttl and
+// dropping the least recently used entry beyond max.
+type daemonArchives struct {
+	client *blob.Client
+	ttl    time.Duration
+	max    int
+
+	mu      sync.Mutex
+	lru     *list.List // of *daemonArchive, most recently used first
+	entries map[string]*list.Element
+}
+
+type daemonArchive struct {
+	ref     string
+	archive *blob.Archive
+	expires time.Time
+}
+
+func newDaemonArchives(client *blob.Client, ttl time.Duration, maxArchives int) *daemonArchives {
+	return &daemonArchives{
+		client:  client,
+		ttl:     ttl,
+		max:     maxArchives,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (d *daemonArchives) open(ctx context.Context, ref string) (daemon.Archive, error) {
+	if archive, ok := d.cached(ref); ok {
+		return daemonBlobArchive{archive}, nil
+	}
+
+	blobArchive, err := d.client.Pull(ctx, ref)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, fmt.Errorf("accessing archive %s: %w: %w", ref, daemon.ErrNotFound, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("accessing archive %s: %w", ref, err)
+	}
+
+	d.store(ref, blobArchive)
+	return daemonBlobArchive{blobArchive}, nil
+}
+
+// cached returns the unexpired archive kept for ref and marks it recently
+// used. An expired entry is dropped.
+func (d *daemonArchives) cached(ref string) (*blob.Archive, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	el, ok := d.entries[ref]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*daemonArchive) //nolint:errcheck // the list only holds archives
+	if !time.Now().Before(entry.expires) {
+		d.lru.Remove(el)
+		delete(d.entries, ref)
+		return nil, false
+	}
+	d.lru.MoveToFront(el)
+	return entry.archive, true
+}
+
+// store keeps archive for ref, dropping the least recently used entries
+// beyond max.
+func (d *daemonArchives) store(ref string, archive *blob.Archive) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if el, ok := d.entries[ref]; ok {
+		d.lru.Remove(el)
+	}
+	d.entries[ref] = d.lru.PushFront(&daemonArchive{ref: ref, archive: archive, expires: time.Now().Add(d.ttl)})
+
+	for d.lru.Len() > max(d.max, 1) {
+		oldest := d.lru.Back()
+		d.lru.Remove(oldest)
+		delete(d.entries, oldest.Value.(*daemonArchive).ref) //nolint:errcheck // the list only holds archives
+	}
+}
+
+// daemonBlobArchive reports blob validation errors in the daemon's format.
+type daemonBlobArchive struct {
+	*blob.Archive
+}
+
+func (a daemonBlobArchive) ValidateFiles(paths ...string) ([]string, error) {
+	normalized, err := a.Archive.ValidateFiles(paths...)
+	var ve *blob.ValidationError
+	if errors.As(err, &ve) {
+		return nil, &daemon.ValidationError{Path: ve.Path, Reason: ve.Reason}
+	}
+	return normalized, err
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package cmd
+
+import (
+	"context"
+	"net"
+)
+
+// listenDaemonSocket listens on a Unix socket at path. Without a umask,
+// who can connect is decided by the access control of its directory,
+// which the socket inherits when it is created.
+func listenDaemonSocket(ctx context.Context, path string) (net.Listener, error) {
+	var lc net.ListenConfig
+	return lc.Listen(ctx, "unix", path)
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package cmd
+
+import (
+	"context"
+	"net"
+	"syscall"
+)
+
+// listenDaemonSocket listens on a Unix socket at path that only the
+// current user can connect to. The umask is narrowed while the socket is
+// created, so it never exists with looser permissions; the daemon starts
+// nothing else that creates files meanwhile.
+func listenDaemonSocket(ctx context.Context, path string) (net.Listener, error) {
+	old := syscall.Umask(0o177)
+	defer syscall.Umask(old)
+	var lc net.ListenConfig
+	return lc.Listen(ctx, "unix", path)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestDaemonTTL(t *testing.T) {
+	tests := []struct {
+		name   string
+		refTTL string
+		want   time.Duration
+	}{
+		{"default", "", defaultDaemonTTL},
+		{"configured", "30s", 30 * time.Second},
+		{"invalid", "soon", defaultDaemonTTL},
+		{"zero", "0s", defaultDaemonTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, daemonTTL(&internalcfg.CacheConfig{RefTTL: tt.refTTL}))
+		})
+	}
+}
+
+func TestConnectDaemon(t *testing.T) {
+	cfg := internalcfg.Default()
+	cfg.Cache.Dir = t.TempDir()
+
+	t.Setenv(daemonSocketEnv, "")
+	socket, err := daemonSocket(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cfg.Cache.Dir, "daemon.sock"), socket)
+
+	t.Setenv(daemonSocketEnv, filepath.Join(t.TempDir(), "custom.sock"))
+	socket, err = daemonSocket(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(filepath.Dir(socket), "custom.sock"), socket)
+
+	// No daemon is listening, so commands run locally.
+	assert.Nil(t, connectDaemon(context.Background(), cfg))
+}
+
+func TestDaemonArchives_EvictsLeastRecentlyUsed(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	for _, tag := range []string{"v1", "v2", "v3"} {
+		reg.addArchive(t, tag, map[string]string{"app.conf": tag})
+	}
+	client, err := newClient(newRegistryTestConfig(t))
+	require.NoError(t, err)
+
+	archives := newDaemonArchives(client, time.Hour, 2)
+	ctx := context.Background()
+	for _, tag := range []string{"v1", "v2", "v1", "v3"} {
+		_, err := archives.open(ctx, reg.repo+":"+tag)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, archives.lru.Len())
+	assert.Contains(t, archives.entries, reg.repo+":v1", "v1 was used after v2")
+	assert.Contains(t, archives.entries, reg.repo+":v3")
+	assert.NotContains(t, archives.entries, reg.repo+":v2")
+}
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"slices"
+
+	"github.com/meigma/blob"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/gittree"
+	"github.com/meigma/blob-cli/internal/warn"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff --git <tree-ish> <ref>",
+	Short: "Compare an archive with a git tree",
+	Long: `Compare an archive with a git tree.
+
+For archives built from a git repository, --git compares the archive's
+files with those of a tree-ish in the repository containing the current
+directory, showing drift between what was committed and what was
+published. Use <commit>:<dir> (for example HEAD:config) when the archive
+was pushed from a subdirectory.
+
+Only the archive index is fetched: file content is compared using the
+SHA-256 digests it records, which are computed locally for the git
+files. Files are reported as modified, added (only in the archive),
+deleted (only in git), or mode (the executable bit differs). Symlinks
+and submodules are skipped, since archives cannot hold them.
+
+If the manifest records an org.opencontainers.image.revision annotation,
+it is shown alongside the commit compared.
+
+--exit-code exits with 1 if there are differences, as "git diff" does.
+Config policies that match the archive are enforced as for "blob pull";
+--no-verify skips them.`,
+	Example: `  blob diff --git HEAD ghcr.io/acme/configs:v1.0.0
+  blob diff --git v1.0.0:config ghcr.io/acme/configs:v1.0.0
+  blob diff --exit-code --git "$GITHUB_SHA:dist" ghcr.io/acme/site:latest`,
+	Args: defaultRefArgs(cobra.ExactArgs(1)),
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().String("git", "", "compare with this git tree-ish (e.g. HEAD or HEAD:config)")
+	diffCmd.Flags().Bool("exit-code", false, "exit with 1 if there are differences")
+	diffCmd.Flags().Bool("skip-cache", false, "bypass registry caches for this operation")
+	addNoVerifyFlag(diffCmd)
+}
+
+// Statuses of a diffChange, from git to the archive.
+const (
+	diffModified = "modified"
+	diffAdded    = "added"
+	diffDeleted  = "deleted"
+	diffMode     = "mode"
+)
+
+// diffFlags holds the parsed command flags.
+type diffFlags struct {
+	git       string
+	exitCode  bool
+	skipCache bool
+	noVerify  bool
+}
+
+// diffResult contains the diff output data for JSON format.
+type diffResult struct {
+	Ref       string       `json:"ref"`
+	Git       string       `json:"git"`
+	GitObject string       `json:"git_object"`
+	Revision  string       `json:"revision,omitempty"`
+	Identical bool         `json:"identical"`
+	Files     int          `json:"files"`
+	Changes   []diffChange `json:"changes"`
+	verificationStatus
+}
+
+// diffChange is a file that differs between git and the archive.
+type diffChange struct {
+	Path          string `json:"path"`
+	Status        string `json:"status"`
+	GitDigest     string `json:"git_digest,omitempty"`
+	ArchiveDigest string `json:"archive_digest,omitempty"`
+	GitMode       string `json:"git_mode,omitempty"`
+	ArchiveMode   string `json:"archive_mode,omitempty"`
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+	args, err := withDefaultRef(cfg, args)
+	if err != nil {
+		return err
+	}
+
+	flags, err := parseDiffFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if flags.git == "" {
+		return errors.New("nothing to compare with: use --git <tree-ish>")
+	}
+
+	ctx := cmd.Context()
+	ref, err := resolveRef(ctx, cfg, args[0])
+	if err != nil {
+		return err
+	}
+
+	tree, err := gittree.Read(ctx, "", flags.git)
+	if err != nil {
+		return fmt.Errorf("reading git tree %s: %w", flags.git, err)
+	}
+	if tree.Skipped > 0 {
+		warn.Printf("skipped %d symlinks and submodules in %s, which archives cannot hold", tree.Skipped, flags.git)
+	}
+
+	policyOpts, verification, err := readPolicyOpts(cfg, ref, flags.noVerify)
+	if err != nil {
+		return err
+	}
+	var opts archive.InspectOptions
+	if flags.skipCache {
+		opts.ClientOpts = clientOptsNoCache(cfg)
+		opts.InspectOpts = []blob.InspectOption{blob.InspectWithSkipCache()}
+	} else {
+		opts.ClientOpts = clientOpts(cfg)
+	}
+	opts.ClientOpts = append(opts.ClientOpts, policyOpts...)
+
+	inspected, err := archive.InspectWithOptions(ctx, ref, opts)
+	if err != nil {
+		return archiveError(err)
+	}
+
+	changes := diffGitTree(tree.Files, inspected.Index().Entries())
+	result := diffResult{
+		Ref:                args[0],
+		Git:                flags.git,
+		GitObject:          tree.ID,
+		Revision:           inspected.Manifest().Annotations()[ocispec.AnnotationRevision],
+		Identical:          len(changes) == 0,
+		Files:              len(tree.Files),
+		Changes:            changes,
+		verificationStatus: verification,
+	}
+
+	if err := outputDiffResult(cfg, &result); err != nil {
+		return err
+	}
+	if flags.exitCode && !result.Identical {
+		return &ExitError{
+			Code: 1,
+			Err:  fmt.Errorf("%s differs from git %s in %s", args[0], flags.git, pluralize(len(changes), "file", "files")),
+		}
+	}
+	return nil
+}
+
+// parseDiffFlags extracts flags from the command.
+func parseDiffFlags(cmd *cobra.Command) (diffFlags, error) {
+	var flags diffFlags
+	var err error
+
+	flags.git, err = cmd.Flags().GetString("git")
+	if err != nil {
+		return flags, fmt.Errorf("reading git flag: %w", err)
+	}
+
+	flags.exitCode, err = cmd.Flags().GetBool("exit-code")
+	if err != nil {
+		return flags, fmt.Errorf("reading exit-code flag: %w", err)
+	}
+
+	flags.skipCache, err = cmd.Flags().GetBool("skip-cache")
+	if err != nil {
+		return flags, fmt.Errorf("reading skip-cache flag: %w", err)
+	}
+
+	flags.noVerify, err = cmd.Flags().GetBool("no-verify")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-verify flag: %w", err)
+	}
+
+	return flags, nil
+}
+
+// diffGitTree compares the git files with the archive entries and returns
+// the differences sorted by path. A file whose content differs is reported
+// as modified even if its mode differs too.
+func diffGitTree(files []gittree.File, entries iter.Seq[blob.EntryView]) []diffChange {
+	inArchive := make(map[string]blob.EntryView)
+	for e := range entries {
+		if e.Mode().IsRegular() {
+			inArchive[e.Path()] = e
+		}
+	}
+
+	changes := []diffChange{}
+	for _, f := range files {
+		gitDigest := "sha256:" + hex.EncodeToString(f.Digest)
+		e, ok := inArchive[f.Path]
+		if !ok {
+			changes = append(changes, diffChange{Path: f.Path, Status: diffDeleted, GitDigest: gitDigest, GitMode: f.Mode})
+			continue
+		}
+		delete(inArchive, f.Path)
+
+		archiveDigest := "sha256:" + hex.EncodeToString(e.HashBytes())
+		archiveMode := fmt.Sprintf("%04o", e.Mode().Perm())
+		switch {
+		case !bytes.Equal(f.Digest, e.HashBytes()):
+			changes = append(changes, diffChange{
+				Path: f.Path, Status: diffModified,
+				GitDigest: gitDigest, ArchiveDigest: archiveDigest,
+			})
+		case f.Executable() != (e.Mode().Perm()&0o111 != 0):
+			changes = append(changes, diffChange{
+				Path: f.Path, Status: diffMode,
+				GitMode: f.Mode, ArchiveMode: archiveMode,
+			})
+		}
+	}
+	for p, e := range inArchive {
+		changes = append(changes, diffChange{
+			Path: p, Status: diffAdded,
+			ArchiveDigest: "sha256:" + hex.EncodeToString(e.HashBytes()),
+		})
+	}
+
+	slices.SortFunc(changes, func(a, b diffChange) int { return cmp.Compare(a.Path, b.Path) })
+	return changes
+}
+
+// outputDiffResult formats and outputs the diff result.
+func outputDiffResult(cfg *internalcfg.Config, result *diffResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		return diffJSON(result)
+	}
+	return diffText(result)
+}
+
+func diffJSON(result *diffResult) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+func diffText(result *diffResult) error {
+	fmt.Printf("Comparing %s with git %s (%s)\n", result.Ref, result.Git, shortObject(result.GitObject))
+	if result.Revision != "" {
+		fmt.Printf("Archive revision: %s\n", result.Revision)
+	}
+	for _, c := range result.Changes {
+		if c.Status == diffMode {
+			fmt.Printf("  %-9s %s (git %s, archive %s)\n", c.Status, c.Path, c.GitMode, c.ArchiveMode)
+			continue
+		}
+		fmt.Printf("  %-9s %s\n", c.Status, c.Path)
+	}
+	if result.Identical {
+		fmt.Printf("No differences (%s)\n", pluralize(result.Files, "file", "files"))
+	} else {
+		fmt.Printf("%d difference(s) across %s in git\n", len(result.Changes), pluralize(result.Files, "file", "files"))
+	}
+	return nil
+}
+
+// shortObject abbreviates a git object id for display.
+func shortObject(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/gittree"
+)
+
+// gitFile returns a git tree file with content.
+func gitFile(path, mode, content string) gittree.File {
+	digest := sha256.Sum256([]byte(content))
+	return gittree.File{Path: path, Mode: mode, Digest: digest[:], Size: int64(len(content))}
+}
+
+func TestDiffGitTree(t *testing.T) {
+	blobArchive := newTestArchive(t, map[string]string{
+		"same.txt":     "same",
+		"changed.txt":  "archive",
+		"extra.txt":    "extra",
+		"bin/tool":     "#!/bin/sh",
+		"dir/nest.txt": "nested",
+	})
+	files := []gittree.File{
+		gitFile("same.txt", gittree.ModeFile, "same"),
+		gitFile("changed.txt", gittree.ModeFile, "git"),
+		gitFile("removed.txt", gittree.ModeFile, "removed"),
+		gitFile("bin/tool", gittree.ModeExecutable, "#!/bin/sh"),
+		gitFile("dir/nest.txt", gittree.ModeFile, "nested"),
+	}
+
+	changes := diffGitTree(files, blobArchive.Entries())
+	got := make(map[string]string, len(changes))
+	paths := make([]string, 0, len(changes))
+	for _, c := range changes {
+		got[c.Path] = c.Status
+		paths = append(paths, c.Path)
+	}
+	assert.Equal(t, map[string]string{
+		"changed.txt": diffModified,
+		"extra.txt":   diffAdded,
+		"removed.txt": diffDeleted,
+		"bin/tool":    diffMode,
+	}, got)
+	assert.IsIncreasing(t, paths)
+
+	assert.Empty(t, diffGitTree(files[:1], newTestArchive(t, map[string]string{"same.txt": "same"}).Entries()))
+}
+
+func TestDiffCmd_RequiresGit(t *testing.T) {
+	diffCmd.SetContext(internalcfg.WithConfig(context.Background(), &internalcfg.Config{}))
+	err := diffCmd.RunE(diffCmd, []string{"ghcr.io/acme/configs:v1"})
+	require.ErrorContains(t, err, "--git")
+}
+
+func TestDiffCmd_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	files := map[string]string{"config/app.conf": "key=value", "README.md": "readme"}
+	repo := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		git := exec.Command("git", args...)
+		git.Dir = repo
+		out, err := git.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	t.Chdir(repo)
+
+	reg, _ := newTestRegistry(t)
+	reg.addArchive(t, "v1", map[string]string{"app.conf": "key=value"})
+	reg.addArchive(t, "v2", map[string]string{"app.conf": "key=other"})
+
+	cfg := newRegistryTestConfig(t)
+	diffCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	require.NoError(t, diffCmd.Flags().Set("git", "HEAD:config"))
+	require.NoError(t, diffCmd.Flags().Set("exit-code", "true"))
+	t.Cleanup(func() {
+		diffCmd.Flags().Set("git", "")            //nolint:errcheck // test cleanup
+		diffCmd.Flags().Set("exit-code", "false") //nolint:errcheck // test cleanup
+	})
+
+	require.NoError(t, diffCmd.RunE(diffCmd, []string{reg.repo + ":v1"}))
+
+	err := diffCmd.RunE(diffCmd, []string{reg.repo + ":v2"})
+	requireExitCode(t, err, 1)
+	assert.Contains(t, err.Error(), "1 file")
+}
@@ -1,5 +1,40 @@
 package cmd
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/meigma/blob"
+	"github.com/meigma/blob/registry"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/daemon"
+	"github.com/meigma/blob-cli/internal/policy"
+	"github.com/meigma/blob-cli/internal/rekor"
+	"github.com/meigma/blob-cli/internal/warn"
+)
+
+const (
+	// exitCodeNotFound is the exit code for a reference with no archive.
+	exitCodeNotFound = 4
+
+	// exitCodeStrict is the exit code for warnings treated as errors by --strict.
+	exitCodeStrict = 6
+
+	// exitCodePathNotFound is the exit code for a path missing from an archive.
+	exitCodePathNotFound = 7
+
+	// exitCodeChecksum is the exit code for extracted files that do not
+	// match the archive's checksums.
+	exitCodeChecksum = 8
+
+	// exitCodeInfrastructure is the exit code for verification that could
+	// not be completed because Sigstore services were unavailable.
+	exitCodeInfrastructure = 9
+)
+
 // ExitError is an error that carries a specific exit code.
 // The main function should check for this error type and exit with the code.
 type ExitError struct {
@@ -17,3 +52,76 @@ func (e *ExitError) Error() string {
 func (e *ExitError) Unwrap() error {
 	return e.Err
 }
+
+// strictError returns an error if the command reported warnings and strict
+// mode is on in cfg. Warnings do not stop a command, so it fails once
+// finished. A nil cfg, for a command that ran without loading the config,
+// is not strict.
+func strictError(cfg *internalcfg.Config) error {
+	n := warn.Count()
+	if n == 0 || cfg == nil || !cfg.Strict {
+		return nil
+	}
+	return &ExitError{
+		Code: exitCodeStrict,
+		Err:  fmt.Errorf("%d warning(s) treated as errors (--strict)", n),
+	}
+}
+
+// archiveError gives an error accessing an archive its exit code: a
+// missing archive, or one not cached with --offline, exits with 4 and a
+// policy violation with 5. Other errors are returned unchanged.
+func archiveError(err error) error {
+	if errors.Is(err, blob.ErrNotFound) || errors.Is(err, daemon.ErrNotFound) || errors.Is(err, errOffline) {
+		return &ExitError{Code: exitCodeNotFound, Err: err}
+	}
+	return policyError(err)
+}
+
+// isInfrastructureError reports whether err comes from a Sigstore
+// service being unavailable, after retries, rather than from a policy
+// rejecting an archive.
+func isInfrastructureError(err error) bool {
+	return errors.Is(err, rekor.ErrUnavailable) || errors.Is(err, policy.ErrTrustedRootUnavailable)
+}
+
+// infrastructureErrors records the Sigstore outages policies run into.
+// The client reports every policy error as a violation, so outages are
+// told apart as the policies return them.
+type infrastructureErrors struct {
+	mu  sync.Mutex
+	err error
+}
+
+// wrap returns a policy that records an outage p runs into.
+func (e *infrastructureErrors) wrap(p registry.Policy) registry.Policy {
+	return registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+		err := p.Evaluate(ctx, req)
+		if isInfrastructureError(err) {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			if e.err == nil {
+				e.err = err
+			}
+		}
+		return err
+	})
+}
+
+// first returns the first outage recorded, or nil.
+func (e *infrastructureErrors) first() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// pathNotFoundError reports a path missing from an archive, with exit code 7.
+func pathNotFoundError(err error) error {
+	return &ExitError{Code: exitCodePathNotFound, Err: err}
+}
+
+// isPathNotFound reports whether err is from pathNotFoundError.
+func isPathNotFound(err error) bool {
+	var exitErr *ExitError
+	return errors.As(err, &exitErr) && exitErr.Code == exitCodePathNotFound
+}
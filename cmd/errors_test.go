@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/meigma/blob"
+	"github.com/meigma/blob/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/daemon"
+	"github.com/meigma/blob-cli/internal/policy"
+	"github.com/meigma/blob-cli/internal/rekor"
+	"github.com/meigma/blob-cli/internal/warn"
+)
+
+func TestStrictError(t *testing.T) {
+	warn.Reset()
+	warn.SetQuiet(true)
+	t.Cleanup(warn.Reset)
+
+	// Warnings without --strict are not errors
+	warn.Printf("failed to fetch %s", "signatures")
+	require.NoError(t, strictError(&internalcfg.Config{}))
+	require.NoError(t, strictError(nil))
+
+	cfg := &internalcfg.Config{Strict: true}
+	err := strictError(cfg)
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, exitCodeStrict, exitErr.Code)
+	assert.Contains(t, err.Error(), "1 warning(s)")
+
+	warn.Reset()
+	require.NoError(t, strictError(cfg))
+}
+
+func TestArchiveError(t *testing.T) {
+	var exitErr *ExitError
+	for _, err := range []error{blob.ErrNotFound, daemon.ErrNotFound} {
+		require.ErrorAs(t, archiveError(fmt.Errorf("accessing archive: %w", err)), &exitErr)
+		assert.Equal(t, exitCodeNotFound, exitErr.Code)
+	}
+
+	require.ErrorAs(t, archiveError(blob.ErrPolicyViolation), &exitErr)
+	assert.Equal(t, exitCodePolicyViolation, exitErr.Code)
+
+	err := errors.New("connection refused")
+	assert.Equal(t, err, archiveError(err))
+}
+
+func TestPathNotFoundError(t *testing.T) {
+	err := pathNotFoundError(errors.New("file not found: a.txt"))
+	assert.True(t, isPathNotFound(err))
+	assert.True(t, isPathNotFound(fmt.Errorf("copying: %w", err)))
+	assert.False(t, isPathNotFound(errors.New("file not found: a.txt")))
+	assert.False(t, isPathNotFound(archiveError(blob.ErrNotFound)))
+}
+
+func TestInfrastructureErrors(t *testing.T) {
+	outage := fmt.Errorf("searching rekor: %w: 503 Service Unavailable", rekor.ErrUnavailable)
+	assert.True(t, isInfrastructureError(fmt.Errorf("sigstore: list referrers: %w", outage)))
+	assert.True(t, isInfrastructureError(fmt.Errorf("config policy 0: %w", policy.ErrTrustedRootUnavailable)))
+	assert.False(t, isInfrastructureError(errors.New("sigstore: verification failed")))
+	assert.False(t, isInfrastructureError(nil))
+
+	infra := &infrastructureErrors{}
+	failing := registry.PolicyFunc(func(context.Context, registry.PolicyRequest) error {
+		return errors.New("identity mismatch")
+	})
+	require.Error(t, infra.wrap(failing).Evaluate(t.Context(), registry.PolicyRequest{}))
+	require.NoError(t, infra.first(), "policy failures are not outages")
+
+	down := registry.PolicyFunc(func(context.Context, registry.PolicyRequest) error {
+		return outage
+	})
+	require.ErrorIs(t, infra.wrap(down).Evaluate(t.Context(), registry.PolicyRequest{}), rekor.ErrUnavailable)
+	assert.Equal(t, outage, infra.first())
+}
+
+func TestVerifyInfrastructureError(t *testing.T) {
+	cfg := &internalcfg.Config{Output: internalcfg.OutputJSON}
+	result := verifyResult{Ref: "ghcr.io/acme/app:v1"}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := verifyInfrastructureError(cfg, &result, policy.ErrTrustedRootUnavailable)
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, exitCodeInfrastructure, exitErr.Code)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "infrastructure_error", got["status"])
+	assert.Equal(t, false, got["verified"])
+	assert.Contains(t, got["error"], "trusted root unavailable")
+}
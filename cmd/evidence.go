@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/spf13/cobra"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/evidence"
+	"github.com/meigma/blob-cli/internal/policy"
+	"github.com/meigma/blob-cli/internal/warn"
+)
+
+// evidenceTrustedRoot fetches the Sigstore trusted root, or loads the
+// configured sigstore.trust_root, so the same root can be used for
+// verification and saved as evidence. If it cannot be loaded, a warning is
+// printed and verification falls back to the policy default.
+func evidenceTrustedRoot(cfg *internalcfg.Config) ([]policy.BuildOption, []byte) {
+	var tr *root.TrustedRoot
+	var err error
+	if cfg.Sigstore.TrustRoot != "" {
+		tr, err = root.NewTrustedRootFromPath(cfg.Sigstore.TrustRoot)
+	} else {
+		tr, err = root.FetchTrustedRoot()
+	}
+	if err == nil {
+		var data []byte
+		data, err = tr.MarshalJSON()
+		if err == nil {
+			return []policy.BuildOption{policy.WithTrustedRoot(tr)}, data
+		}
+	}
+
+	warn.Printf("trusted root not saved, offline signature verification will not be possible: %v", err)
+	return nil, nil
+}
+
+// evidenceSources collects the policy documents used by verify.
+func evidenceSources(cfg *internalcfg.Config, ref string, flags *verifyFlags, trustedRoot []byte) *evidence.Sources {
+	src := &evidence.Sources{
+		PolicyFiles: flags.policyFiles,
+		PolicyRego:  flags.policyRego,
+		TrustedRoot: trustedRoot,
+	}
+	if !flags.noDefaultPolicy {
+		src.ConfigPolicies = cfg.GetPoliciesForRef(ref)
+	}
+	return src
+}
+
+// runVerifyFromEvidence re-evaluates policies against a saved evidence
+// directory. Nothing is fetched from the registry or Sigstore services.
+func runVerifyFromEvidence(cmd *cobra.Command, cfg *internalcfg.Config, args []string, flags *verifyFlags) error {
+	// 1. Load the evidence
+	bundle, err := evidence.Load(flags.fromEvidence)
+	if err != nil {
+		return err
+	}
+	record := bundle.Record
+
+	// 2. Check the reference argument against the record
+	if len(args) > 0 {
+		ref := cfg.ResolveAlias(args[0])
+		if ref != record.Ref && ref != record.Digest && ref != repositoryOf(record.Ref)+"@"+record.Digest {
+			return fmt.Errorf("evidence in %s is for %s, not %s", flags.fromEvidence, record.Ref, ref)
+		}
+	}
+
+	// 3. Build the recorded policies against the recorded trusted root
+	var buildOpts []policy.BuildOption
+	if path := bundle.TrustedRootPath(); path != "" {
+		tr, err := root.NewTrustedRootFromPath(path)
+		if err != nil {
+			return fmt.Errorf("loading evidence trusted root: %w", err)
+		}
+		buildOpts = append(buildOpts, policy.WithTrustedRoot(tr))
+	}
+	regoFile := bundle.PolicyRego()
+	if flags.policyRego != "" {
+		regoFile = flags.policyRego
+	}
+	policyFiles := slices.Concat(bundle.PolicyFiles(), flags.policyFiles)
+	policies, err := policy.BuildPolicies(nil, record.Ref, policyFiles, regoFile, true, buildOpts...)
+	if err != nil {
+		return fmt.Errorf("building policies: %w", err)
+	}
+	if len(policies) == 0 {
+		return errors.New("evidence contains no policies to evaluate")
+	}
+
+	// 4. Evaluate every policy against the recorded material
+	req := bundle.Request()
+	for _, p := range policies {
+		if err := p.Evaluate(cmd.Context(), req); err != nil {
+			return &ExitError{
+				Code: exitCodePolicyViolation,
+				Err:  fmt.Errorf("verification failed: %w", err),
+			}
+		}
+	}
+
+	result := verifyResult{
+		Ref:             record.Ref,
+		Digest:          record.Digest,
+		Verified:        true,
+		Status:          "verified",
+		PoliciesApplied: len(policies),
+		Signatures:      recordedReferrers(record, sigstoreArtifactType),
+		Attestations:    recordedReferrers(record, inTotoArtifactType),
+		Evidence:        flags.fromEvidence,
+		Offline:         true,
+	}
+	return outputVerifyResult(cfg, &result)
+}
+
+// recordedReferrers returns the recorded referrers of an artifact type.
+func recordedReferrers(record *evidence.Record, artifactType string) []referrerInfo {
+	for _, set := range record.Referrers {
+		if set.ArtifactType != artifactType || len(set.Descriptors) == 0 {
+			continue
+		}
+		result := make([]referrerInfo, len(set.Descriptors))
+		for i, d := range set.Descriptors {
+			result[i] = referrerInfo{
+				Digest:       d.Digest.String(),
+				ArtifactType: d.ArtifactType,
+				Annotations:  d.Annotations,
+			}
+		}
+		return result
+	}
+	return nil
+}
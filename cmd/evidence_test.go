@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/evidence"
+)
+
+// writeTestEvidence writes an evidence directory with a single SLSA policy
+// and no recorded attestations.
+func writeTestEvidence(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	manifest := []byte(`{"schemaVersion":2}`)
+	subject := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+
+	policyFile := "policies/file-0-policy.yaml"
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "policies"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.FromSlash(policyFile)),
+		[]byte("provenance:\n  slsa:\n    builder: https://github.com/actions/runner\n"), 0o600))
+
+	record := evidence.Record{
+		Version:  evidence.FormatVersion,
+		Ref:      "ghcr.io/acme/configs:v1",
+		Digest:   subject.Digest.String(),
+		Subject:  subject,
+		Policies: evidence.PolicySet{Files: []string{policyFile}},
+		Decision: evidence.Decision{Verified: true, Status: "verified", PoliciesApplied: 1},
+	}
+	data, err := json.Marshal(&record)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, evidence.RecordFile), data, 0o600))
+
+	return dir
+}
+
+func TestRunVerifyFromEvidence(t *testing.T) {
+	cfg := internalcfg.Default()
+	cfg.Quiet = true
+	verifyCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	t.Run("reference mismatch", func(t *testing.T) {
+		flags := verifyFlags{fromEvidence: writeTestEvidence(t)}
+		err := runVerifyFromEvidence(verifyCmd, cfg, []string{"ghcr.io/acme/other:v1"}, &flags)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is for ghcr.io/acme/configs:v1")
+	})
+
+	t.Run("missing material fails policy", func(t *testing.T) {
+		flags := verifyFlags{fromEvidence: writeTestEvidence(t)}
+		err := runVerifyFromEvidence(verifyCmd, cfg, []string{"ghcr.io/acme/configs:v1"}, &flags)
+		require.Error(t, err)
+
+		var exitErr *ExitError
+		require.True(t, errors.As(err, &exitErr))
+		assert.Equal(t, exitCodePolicyViolation, exitErr.Code)
+	})
+
+	t.Run("missing evidence", func(t *testing.T) {
+		flags := verifyFlags{fromEvidence: t.TempDir()}
+		err := runVerifyFromEvidence(verifyCmd, cfg, nil, &flags)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "reading evidence record")
+	})
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// Export formats.
+const (
+	exportFormatOCILayout = "oci-layout"
+	exportFormatTar       = "tar"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <ref> <dest>",
+	Short: "Write an archive to a local OCI layout or tarball",
+	Long: `Write an archive to a local OCI layout or tarball.
+
+Copies the full OCI artifact, its manifest, index and data blobs, and its
+referrers such as signatures and attestations, into an OCI image layout
+directory (--format oci-layout, the default) or a tar file of one
+(--format tar), for carrying into an air-gapped network. Tools that read
+OCI layouts, such as oras and skopeo, can push it to another registry.
+
+The manifest is checked against the config policies first, as pull
+does, so only an archive that passes is exported; --no-verify skips
+them. The layout records the archive under its tag, or its digest if the
+reference has none.
+
+An existing OCI layout directory is added to, so several archives can be
+exported into one. A tar file is never overwritten.`,
+	Example: `  blob export ghcr.io/acme/configs:v1.0.0 ./configs-layout
+  blob export --format tar ghcr.io/acme/configs:v1.0.0 configs.tar
+  blob export --no-referrers foo:v1 ./layout      # Using alias, archive only`,
+	Args: cobra.ExactArgs(2),
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().String("format", exportFormatOCILayout, "output format: oci-layout or tar")
+	exportCmd.Flags().Bool("no-referrers", false, "export the archive without its signatures and attestations")
+	addNoVerifyFlag(exportCmd)
+}
+
+// exportResult contains the export output data.
+type exportResult struct {
+	Ref         string `json:"ref"`
+	ResolvedRef string `json:"resolved_ref,omitempty"`
+	Digest      string `json:"digest"`
+	Dest        string `json:"dest"`
+	Format      string `json:"format"`
+	Name        string `json:"name"`
+	Blobs       int    `json:"blobs"`
+	Size        int64  `json:"size"`
+	Referrers   int    `json:"referrers"`
+	verificationStatus
+}
+
+// exportFlags holds the parsed command flags.
+type exportFlags struct {
+	format      string
+	noReferrers bool
+	noVerify    bool
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	flags, err := parseExportFlags(cmd)
+	if err != nil {
+		return err
+	}
+	inputRef, dest := args[0], args[1]
+	if err := checkExportDest(dest, flags.format); err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	resolvedRef, err := resolveRef(ctx, cfg, inputRef)
+	if err != nil {
+		return err
+	}
+
+	// Check the manifest against the policies, then copy it by digest so
+	// the artifact exported is the one that was checked
+	policyOpts, verification, err := readPolicyOpts(cfg, resolvedRef, flags.noVerify)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(cfg, policyOpts...)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	manifest, err := fetchPullManifest(ctx, client, resolvedRef, false)
+	if err != nil {
+		return archiveError(err)
+	}
+	repository, err := newRemoteRepository(cfg, repositoryOf(resolvedRef))
+	if err != nil {
+		return err
+	}
+	root, err := repository.Resolve(ctx, manifest.Digest())
+	if err != nil {
+		return archiveError(fmt.Errorf("resolving %s: %w", resolvedRef, err))
+	}
+
+	name := manifest.Digest()
+	if _, tag, ok := splitTag(resolvedRef); ok {
+		name = tag
+	}
+	result := exportResult{
+		Ref:                inputRef,
+		Digest:             manifest.Digest(),
+		Dest:               dest,
+		Format:             flags.format,
+		Name:               name,
+		verificationStatus: verification,
+	}
+	if resolvedRef != inputRef {
+		result.ResolvedRef = resolvedRef
+	}
+
+	layoutDir := dest
+	if flags.format == exportFormatTar {
+		layoutDir, err = os.MkdirTemp(filepath.Dir(dest), ".blob-export-*")
+		if err != nil {
+			return fmt.Errorf("creating temporary layout: %w", err)
+		}
+		defer os.RemoveAll(layoutDir)
+	}
+	if err := exportLayout(ctx, repository, root, layoutDir, name, flags.noReferrers, &result); err != nil {
+		return err
+	}
+	if flags.format == exportFormatTar {
+		if err := writeExportTar(layoutDir, dest); err != nil {
+			return err
+		}
+	}
+
+	return outputExportResult(cfg, &result)
+}
+
+func parseExportFlags(cmd *cobra.Command) (exportFlags, error) {
+	var flags exportFlags
+	var err error
+
+	flags.format, err = cmd.Flags().GetString("format")
+	if err != nil {
+		return flags, fmt.Errorf("reading format flag: %w", err)
+	}
+	if flags.format != exportFormatOCILayout && flags.format != exportFormatTar {
+		return flags, fmt.Errorf("invalid format %q: expected %s or %s", flags.format, exportFormatOCILayout, exportFormatTar)
+	}
+	flags.noReferrers, err = cmd.Flags().GetBool("no-referrers")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-referrers flag: %w", err)
+	}
+	flags.noVerify, err = cmd.Flags().GetBool("no-verify")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-verify flag: %w", err)
+	}
+	return flags, nil
+}
+
+// checkExportDest fails before anything is fetched if dest cannot be
+// written: a tar file must not exist, and a directory must be empty or
+// already an OCI layout.
+func checkExportDest(dest, format string) error {
+	info, err := os.Stat(dest)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("checking destination: %w", err)
+	}
+	if format == exportFormatTar {
+		return fmt.Errorf("%s already exists", dest)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dest)
+	}
+	if _, err := os.Stat(filepath.Join(dest, ocispec.ImageLayoutFile)); err == nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dest)
+	if err != nil {
+		return fmt.Errorf("checking destination: %w", err)
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s is not empty and not an OCI layout", dest)
+	}
+	return nil
+}
+
+// exportLayout copies the graph rooted at root from repository into the
+// OCI layout at dir, with its referrers unless noReferrers is set, and
+// records it under name. Blobs the layout already holds are counted but
+// not copied again.
+func exportLayout(ctx context.Context, repository oras.ReadOnlyGraphTarget, root ocispec.Descriptor, dir, name string, noReferrers bool, result *exportResult) error {
+	store, err := oci.New(dir)
+	if err != nil {
+		return fmt.Errorf("opening OCI layout: %w", err)
+	}
+
+	var mu sync.Mutex // blobs are copied concurrently
+	count := func(_ context.Context, desc ocispec.Descriptor) error {
+		mu.Lock()
+		defer mu.Unlock()
+		result.Blobs++
+		result.Size += desc.Size
+		if isManifestMediaType(desc.MediaType) && desc.Digest != root.Digest {
+			result.Referrers++
+		}
+		return nil
+	}
+	var opts oras.ExtendedCopyGraphOptions
+	opts.PostCopy = count
+	opts.OnCopySkipped = count
+	if noReferrers {
+		err = oras.CopyGraph(ctx, repository, store, root, opts.CopyGraphOptions)
+	} else {
+		err = oras.ExtendedCopyGraph(ctx, repository, store, root, opts)
+	}
+	if err != nil {
+		return archiveError(fmt.Errorf("exporting archive: %w", err))
+	}
+	if err := store.Tag(ctx, root, name); err != nil {
+		return fmt.Errorf("writing OCI layout index: %w", err)
+	}
+	return nil
+}
+
+// isManifestMediaType reports whether mediaType is that of a manifest or
+// index, rather than a blob.
+func isManifestMediaType(mediaType string) bool {
+	switch mediaType {
+	case ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageIndex,
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json":
+		return true
+	}
+	return false
+}
+
+// writeExportTar writes the OCI layout at dir to a new tar file at dest.
+// A partly written file is removed.
+func writeExportTar(dir, dest string) error {
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644) //nolint:gosec // dest is the user's output path
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", dest, err)
+	}
+	if err := archive.WriteTar(f, dir); err != nil {
+		f.Close()
+		os.Remove(dest)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(dest)
+		return fmt.Errorf("writing %s: %w", dest, err)
+	}
+	return nil
+}
+
+// outputExportResult formats and outputs the export result.
+func outputExportResult(cfg *internalcfg.Config, result *exportResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	fmt.Printf("Exported %s to %s (%s)\n", result.Ref, result.Dest, result.Format)
+	if result.ResolvedRef != "" {
+		fmt.Printf("  Resolved: %s\n", result.ResolvedRef)
+	}
+	fmt.Printf("  Digest: %s\n", result.Digest)
+	fmt.Printf("  Name: %s\n", result.Name)
+	fmt.Printf("  Blobs: %d (%s)\n", result.Blobs, archive.FormatSize(uint64(result.Size))) //nolint:gosec // sizes are non-negative
+	fmt.Printf("  Referrers: %d\n", result.Referrers)
+	if result.Verified {
+		fmt.Printf("  Verified: %d policies applied\n", result.PoliciesApplied)
+	} else {
+		fmt.Println("  Verified: no policies applied")
+	}
+	return nil
+}
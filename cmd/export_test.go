@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestExport(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	repo := reg.repo
+	v1 := reg.addArchive(t, "v1", map[string]string{"etc/app.conf": "app"})
+	v2 := reg.addArchive(t, "v2", map[string]string{"etc/app.conf": "app v2"})
+	sig := reg.add(t, "", &v1, "application/vnd.dev.sigstore.bundle.v0.3+json")
+	reg.addBlob(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	t.Cleanup(func() {
+		exportCmd.Flags().Set("format", exportFormatOCILayout)
+		exportCmd.Flags().Set("no-referrers", "false")
+	})
+
+	cfg := newRegistryTestConfig(t)
+	exportCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	layout := filepath.Join(t.TempDir(), "layout")
+	require.NoError(t, exportCmd.RunE(exportCmd, []string{repo + ":v1", layout}))
+	assert.Equal(t, map[string]string{v1.Digest.String(): "v1", sig.Digest.String(): ""}, layoutNames(t, layout))
+	for _, d := range []string{v1.Digest.Encoded(), sig.Digest.Encoded()} {
+		assert.FileExists(t, filepath.Join(layout, "blobs", "sha256", d), "the archive and its referrers are exported")
+	}
+
+	// A layout is added to; anything else is refused
+	require.NoError(t, exportCmd.RunE(exportCmd, []string{repo + ":v2", layout}))
+	assert.Equal(t, "v2", layoutNames(t, layout)[v2.Digest.String()])
+	require.ErrorContains(t, exportCmd.RunE(exportCmd, []string{repo + ":v1", filepath.Join(layout, "blobs")}), "not an OCI layout")
+	assert.NoFileExists(t, filepath.Join(layout, "blobs", ocispec.ImageIndexFile), "a refused directory is left alone")
+
+	require.NoError(t, exportCmd.Flags().Set("format", exportFormatTar))
+	require.NoError(t, exportCmd.Flags().Set("no-referrers", "true"))
+	tarPath := filepath.Join(t.TempDir(), "configs.tar")
+	require.NoError(t, exportCmd.RunE(exportCmd, []string{repo + ":v1", tarPath}))
+	before, err := os.ReadFile(tarPath)
+	require.NoError(t, err)
+	require.ErrorContains(t, exportCmd.RunE(exportCmd, []string{repo + ":v1", tarPath}), "already exists")
+	after, err := os.ReadFile(tarPath)
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "an existing tarball is not overwritten")
+
+	f, err := os.Open(tarPath)
+	require.NoError(t, err)
+	defer f.Close()
+	extracted := t.TempDir()
+	_, err = archive.ExtractTar(f, extracted)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{v1.Digest.String(): "v1"}, layoutNames(t, extracted))
+	assert.NoFileExists(t, filepath.Join(extracted, "blobs", "sha256", sig.Digest.Encoded()), "--no-referrers leaves them out")
+	entries, err := os.ReadDir(filepath.Dir(tarPath))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the temporary layout is removed")
+}
+
+// layoutNames returns the ref names of the manifests in the index of the
+// OCI layout at dir, by digest.
+func layoutNames(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, ocispec.ImageIndexFile))
+	require.NoError(t, err)
+	var index ocispec.Index
+	require.NoError(t, json.Unmarshal(data, &index))
+	names := make(map[string]string, len(index.Manifests))
+	for _, desc := range index.Manifests {
+		names[desc.Digest.String()] = desc.Annotations[ocispec.AnnotationRefName]
+	}
+	return names
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// userAgent returns the User-Agent for registry requests.
+func userAgent(cfg *internalcfg.Config) string {
+	if cfg.Registry.UserAgent != "" {
+		return cfg.Registry.UserAgent
+	}
+	return "blob-cli/" + version
+}
+
+// parseHeaderFlags parses --header values ("Name: value") into a map.
+func parseHeaderFlags(values []string) (map[string]string, error) {
+	headers := make(map[string]string, len(values))
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, ":")
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if !ok || !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			return nil, fmt.Errorf("invalid header %q: expected \"Name: value\"", v)
+		}
+		headers[name] = value
+	}
+	return headers, nil
+}
+
+// requestHeaderTransport returns base wrapped so registry API requests
+// carry headers. Requests to other hosts, such as a registry's token
+// service, are sent unchanged.
+func requestHeaderTransport(base http.RoundTripper, headers map[string]string) http.RoundTripper {
+	if len(headers) == 0 {
+		return base
+	}
+	h := make(http.Header, len(headers))
+	for name, value := range headers {
+		h.Set(name, value)
+	}
+	return &headerTransport{base: base, headers: h}
+}
+
+// headerTransport adds headers to registry API requests that do not
+// already set them.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isRegistryAPI(req.URL) {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		if _, ok := req.Header[name]; !ok {
+			req.Header[name] = values
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// isRegistryAPI reports whether u is an endpoint of the OCI distribution
+// API, which registries serve under /v2/.
+func isRegistryAPI(u *url.URL) bool {
+	return u.Path == "/v2" || strings.HasPrefix(u.Path, "/v2/")
+}
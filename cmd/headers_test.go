@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestParseHeaderFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "none",
+			values: nil,
+			want:   map[string]string{},
+		},
+		{
+			name:   "trims whitespace",
+			values: []string{"X-Tenant: acme", "X-Route:eu-west "},
+			want:   map[string]string{"X-Tenant": "acme", "X-Route": "eu-west"},
+		},
+		{
+			name:   "value with colon",
+			values: []string{"X-Origin: https://ci.example.com"},
+			want:   map[string]string{"X-Origin": "https://ci.example.com"},
+		},
+		{
+			name:    "missing colon",
+			values:  []string{"X-Tenant=acme"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			values:  []string{"X Tenant: acme"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHeaderFlags(tt.values)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHeaderTransport(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: requestHeaderTransport(http.DefaultTransport, map[string]string{
+		"x-tenant":      "acme",
+		"Authorization": "Bearer gateway",
+	})}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL+"/v2/acme/configs/manifests/v1", http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer registry")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, "acme", got.Get("X-Tenant"))
+	assert.Equal(t, "Bearer registry", got.Get("Authorization"), "client headers must not be replaced")
+	assert.Empty(t, req.Header.Get("X-Tenant"), "original request must not be modified")
+
+	// Requests outside the registry API, such as for tokens, are not changed
+	req, err = http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL+"/token?scope=repository:acme/configs:pull", http.NoBody)
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Empty(t, got.Get("X-Tenant"))
+	assert.Empty(t, got.Get("Authorization"))
+}
+
+func TestUserAgent(t *testing.T) {
+	cfg := internalcfg.Default()
+	assert.Equal(t, "blob-cli/"+version, userAgent(cfg))
+
+	cfg.Registry.UserAgent = "acme-deploy/2.0"
+	assert.Equal(t, "acme-deploy/2.0", userAgent(cfg))
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/meigma/blob/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <source> <ref>",
+	Short: "Push an archive from a local OCI layout or tarball",
+	Long: `Push an archive from a local OCI layout or tarball.
+
+The counterpart of export: reads an OCI image layout directory, or a tar
+file of one, and copies an archive from it to the registry at ref, with
+its referrers such as signatures and attestations unless --no-referrers
+is set. Content is copied byte for byte, so the manifest digest is
+preserved and the signatures still apply.
+
+The archive is chosen by --name, the name it has in the layout (a tag or
+a digest). Without it, the tag or digest of ref is used if the layout
+has an archive under that name, or else the layout's only archive.
+
+If ref has no tag the archive is pushed by digest only; a ref with a
+digest must match the archive.`,
+	Example: `  blob import ./configs-layout ghcr.io/acme/configs:v1.0.0
+  blob import configs.tar ghcr.io/mirror/configs:v1.0.0
+  blob import --name v1.0.0 ./layout ghcr.io/mirror/configs:stable`,
+	Args: cobra.ExactArgs(2),
+	RunE: runImport,
+}
+
+func init() {
+	importCmd.Flags().String("name", "", "name of the archive in the layout (default: the tag or digest of ref, or the only archive)")
+	importCmd.Flags().Bool("no-referrers", false, "import the archive without its signatures and attestations")
+}
+
+// importResult contains the import output data.
+type importResult struct {
+	Source      string `json:"source"`
+	Name        string `json:"name"`
+	Ref         string `json:"ref"`
+	ResolvedRef string `json:"resolved_ref,omitempty"`
+	Digest      string `json:"digest"`
+	graphCopyStats
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return fmt.Errorf("reading name flag: %w", err)
+	}
+	noReferrers, err := cmd.Flags().GetBool("no-referrers")
+	if err != nil {
+		return fmt.Errorf("reading no-referrers flag: %w", err)
+	}
+
+	source, inputRef := args[0], args[1]
+	resolvedRef := cfg.ResolveAlias(inputRef)
+	ctx := cmd.Context()
+
+	layoutDir, cleanup, err := openImportSource(source)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	store, err := oci.NewFromFS(ctx, os.DirFS(layoutDir))
+	if err != nil {
+		return fmt.Errorf("reading OCI layout %s: %w", source, err)
+	}
+
+	_, tag, hasTag := splitTag(resolvedRef)
+	_, wantDigest, hasDigest := strings.Cut(resolvedRef, "@")
+	hint := tag
+	if hasDigest {
+		hint = wantDigest
+	}
+	root, name, err := selectImportRoot(ctx, store, name, hint)
+	if err != nil {
+		return err
+	}
+	if err := checkImportManifest(ctx, store, root); err != nil {
+		return err
+	}
+	if hasDigest && wantDigest != root.Digest.String() {
+		return fmt.Errorf("%s is %s, not the digest in %s", name, root.Digest, resolvedRef)
+	}
+
+	repository, err := newRemoteRepository(cfg, repositoryOf(resolvedRef))
+	if err != nil {
+		return err
+	}
+
+	result := importResult{
+		Source: source,
+		Name:   name,
+		Ref:    inputRef,
+		Digest: root.Digest.String(),
+	}
+	if resolvedRef != inputRef {
+		result.ResolvedRef = resolvedRef
+	}
+
+	opts := result.options(root)
+	if noReferrers {
+		err = oras.CopyGraph(ctx, store, repository, root, opts.CopyGraphOptions)
+	} else {
+		err = oras.ExtendedCopyGraph(ctx, store, repository, root, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("importing archive: %w", err)
+	}
+	if hasTag {
+		if err := repository.Tag(ctx, root, tag); err != nil {
+			return fmt.Errorf("tagging %s: %w", resolvedRef, err)
+		}
+	}
+
+	return outputImportResult(cfg, &result)
+}
+
+// openImportSource returns the OCI layout directory at source, extracting
+// it to a temporary directory first if source is a tar file. cleanup
+// removes anything extracted.
+func openImportSource(source string) (dir string, cleanup func(), err error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading %s: %w", source, err)
+	}
+	if info.IsDir() {
+		return source, func() {}, nil
+	}
+
+	f, err := os.Open(source) //nolint:gosec // source is the user's input path
+	if err != nil {
+		return "", nil, fmt.Errorf("reading %s: %w", source, err)
+	}
+	defer f.Close()
+	dir, err = os.MkdirTemp("", "blob-import-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("creating temporary layout: %w", err)
+	}
+	if _, err := archive.ExtractTar(f, dir); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("extracting %s: %w", source, err)
+	}
+	return dir, func() { os.RemoveAll(dir) }, nil
+}
+
+// selectImportRoot returns the manifest in store to import and its name:
+// the one named name if set, else the one hint (the tag or digest of the
+// destination) names if there is one, else the only named manifest.
+func selectImportRoot(ctx context.Context, store *oci.ReadOnlyStore, name, hint string) (ocispec.Descriptor, string, error) {
+	if name != "" {
+		desc, err := store.Resolve(ctx, name)
+		if err != nil {
+			return ocispec.Descriptor{}, "", fmt.Errorf("no archive named %q in the layout", name)
+		}
+		return desc, name, nil
+	}
+	if hint != "" {
+		if desc, err := store.Resolve(ctx, hint); err == nil {
+			return desc, hint, nil
+		}
+	}
+
+	var names []string
+	if err := store.Tags(ctx, "", func(tags []string) error {
+		names = append(names, tags...)
+		return nil
+	}); err != nil {
+		return ocispec.Descriptor{}, "", fmt.Errorf("reading OCI layout index: %w", err)
+	}
+	switch len(names) {
+	case 0:
+		return ocispec.Descriptor{}, "", errors.New("the layout has no named archives; set --name to a digest")
+	case 1:
+		desc, err := store.Resolve(ctx, names[0])
+		if err != nil {
+			return ocispec.Descriptor{}, "", fmt.Errorf("resolving %s in the layout: %w", names[0], err)
+		}
+		return desc, names[0], nil
+	}
+	return ocispec.Descriptor{}, "", fmt.Errorf("the layout has %d archives (%s); choose one with --name",
+		len(names), strings.Join(names, ", "))
+}
+
+// checkImportManifest fails unless root is the manifest of a blob archive.
+func checkImportManifest(ctx context.Context, store content.Fetcher, root ocispec.Descriptor) error {
+	if root.MediaType != ocispec.MediaTypeImageManifest {
+		return fmt.Errorf("%s is not a blob archive", root.Digest)
+	}
+	data, err := content.FetchAll(ctx, store, root)
+	if err != nil {
+		return fmt.Errorf("reading manifest %s: %w", root.Digest, err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("reading manifest %s: %w", root.Digest, err)
+	}
+	if manifest.ArtifactType != registry.ArtifactType {
+		return fmt.Errorf("%s is not a blob archive", root.Digest)
+	}
+	return nil
+}
+
+// outputImportResult formats and outputs the import result.
+func outputImportResult(cfg *internalcfg.Config, result *importResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	fmt.Printf("Imported %s from %s\n", result.Ref, result.Source)
+	if result.ResolvedRef != "" {
+		fmt.Printf("  Resolved: %s\n", result.ResolvedRef)
+	}
+	fmt.Printf("  Digest: %s\n", result.Digest)
+	fmt.Printf("  Name: %s\n", result.Name)
+	printGraphCopyStats(&result.graphCopyStats)
+	return nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestImport(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	repo := reg.repo
+	v1 := reg.addArchive(t, "v1", map[string]string{"etc/app.conf": "app"})
+	v2 := reg.addArchive(t, "v2", map[string]string{"etc/app.conf": "app v2"})
+	sig := reg.add(t, "", &v1, "application/vnd.dev.sigstore.bundle.v0.3+json")
+	reg.addBlob(ocispec.MediaTypeEmptyJSON, ocispec.DescriptorEmptyJSON.Data)
+	t.Cleanup(func() {
+		exportCmd.Flags().Set("format", exportFormatOCILayout)
+		importCmd.Flags().Set("name", "")
+		importCmd.Flags().Set("no-referrers", "false")
+	})
+
+	cfg := newRegistryTestConfig(t)
+	ctx := internalcfg.WithConfig(context.Background(), cfg)
+	exportCmd.SetContext(ctx)
+	importCmd.SetContext(ctx)
+
+	layout := filepath.Join(t.TempDir(), "layout")
+	require.NoError(t, exportCmd.RunE(exportCmd, []string{repo + ":v1", layout}))
+
+	// The only archive in the layout is pushed, with its referrers,
+	// under the same digest
+	mirrorReg, _ := newTestRegistry(t)
+	mirror := mirrorReg.repo
+	require.NoError(t, importCmd.RunE(importCmd, []string{layout, mirror + ":stable"}))
+	assert.Equal(t, v1.Digest.String(), mirrorReg.tag("stable"))
+	assert.True(t, mirrorReg.hasManifest(sig.Digest.String()), "the signature is imported")
+	assert.Equal(t, map[string]string{"etc/app.conf": "app"}, pulledFiles(t, cfg, mirror+":stable"))
+
+	// With several archives one must be chosen, by --name or the tag
+	require.NoError(t, exportCmd.RunE(exportCmd, []string{repo + ":v2", layout}))
+	require.ErrorContains(t, importCmd.RunE(importCmd, []string{layout, mirror + ":latest"}), "choose one with --name")
+	assert.Empty(t, mirrorReg.tag("latest"), "nothing is pushed when the archive is ambiguous")
+	require.NoError(t, importCmd.RunE(importCmd, []string{layout, mirror + ":v2"}))
+	assert.Equal(t, v2.Digest.String(), mirrorReg.tag("v2"))
+	require.NoError(t, importCmd.RunE(importCmd, []string{layout, mirror + "@" + v1.Digest.String()}))
+
+	require.NoError(t, importCmd.Flags().Set("name", "v1"))
+	require.ErrorContains(t, importCmd.RunE(importCmd, []string{layout, mirror + "@" + v2.Digest.String()}), "not the digest")
+	require.NoError(t, importCmd.Flags().Set("name", "v3"))
+	require.ErrorContains(t, importCmd.RunE(importCmd, []string{layout, mirror + ":v3"}), `no archive named "v3"`)
+	assert.Empty(t, mirrorReg.tag("v3"))
+
+	// A tarball is read as well
+	require.NoError(t, exportCmd.Flags().Set("format", exportFormatTar))
+	tarPath := filepath.Join(t.TempDir(), "configs.tar")
+	require.NoError(t, exportCmd.RunE(exportCmd, []string{repo + ":v2", tarPath}))
+	require.NoError(t, importCmd.Flags().Set("name", ""))
+	require.NoError(t, importCmd.Flags().Set("no-referrers", "true"))
+	otherReg, _ := newTestRegistry(t)
+	require.NoError(t, importCmd.RunE(importCmd, []string{tarPath, otherReg.repo + ":v2"}))
+	assert.Equal(t, v2.Digest.String(), otherReg.tag("v2"))
+	assert.Equal(t, map[string]string{"etc/app.conf": "app v2"}, pulledFiles(t, cfg, otherReg.repo+":v2"))
+}
@@ -1,18 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/meigma/blob"
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/platform"
+	"github.com/meigma/blob-cli/internal/referrers"
+	"github.com/meigma/blob-cli/internal/transfer"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 const (
@@ -34,29 +40,53 @@ Displays information including:
   - Compression type
   - Signatures (if any)
   - Attestations (if any)
-  - Annotations`,
+  - Annotations
+  - Archive description, owners, and links (if the archive
+    contains .blob/README.md or .blob/metadata.yaml)
+
+With --stats, also shows bytes and file counts broken down by file
+extension and by compression effectiveness. Use this to spot file
+types that compress poorly (candidates for --skip-compressed) or
+binaries that were included by accident.
+
+Platform variants declared with "blob push --platform" are listed
+under Platforms. With --platform, only that variant is shown, and the
+command fails if the archive has no variant for it.`,
 	Example: `  blob inspect ghcr.io/acme/configs:v1.0.0
-  blob inspect --output json ghcr.io/acme/configs:v1.0.0`,
-	Args: cobra.ExactArgs(1),
+  blob inspect --stats ghcr.io/acme/configs:v1.0.0
+  blob inspect --output json ghcr.io/acme/configs:v1.0.0
+  blob inspect --platform linux/arm64 ghcr.io/acme/tools:v2
+  blob inspect --copy ghcr.io/acme/configs:v1.0.0`,
+	Args: defaultRefArgs(cobra.ExactArgs(1)),
 	RunE: runInspect,
 }
 
 func init() {
+	inspectCmd.Flags().Bool("stats", false, "show per-extension and compression statistics")
 	inspectCmd.Flags().Bool("skip-cache", false, "bypass registry caches for this operation")
+	inspectCmd.Flags().String("platform", "", "show only the archive's variant for os/arch[/variant]")
+	inspectCmd.Flags().Bool("copy", false, "copy the manifest digest to the clipboard")
 }
 
 // inspectOutput contains the inspect output data for JSON format.
 type inspectOutput struct {
-	Ref          string            `json:"ref"`
-	ResolvedRef  string            `json:"resolved_ref,omitempty"`
-	Digest       string            `json:"digest"`
-	Created      string            `json:"created,omitempty"`
-	Files        int               `json:"files"`
-	Size         sizeInfo          `json:"size"`
-	Compression  string            `json:"compression"`
-	Signatures   []referrerInfo    `json:"signatures,omitempty"`
-	Attestations []referrerInfo    `json:"attestations,omitempty"`
-	Annotations  map[string]string `json:"annotations,omitempty"`
+	Ref          string             `json:"ref"`
+	ResolvedRef  string             `json:"resolved_ref,omitempty"`
+	Digest       string             `json:"digest"`
+	Created      string             `json:"created,omitempty"`
+	Files        int                `json:"files"`
+	Size         sizeInfo           `json:"size"`
+	Compression  string             `json:"compression"`
+	Signatures   []referrerInfo     `json:"signatures,omitempty"`
+	Attestations []referrerInfo     `json:"attestations,omitempty"`
+	Annotations  map[string]string  `json:"annotations,omitempty"`
+	Platforms    []platform.Variant `json:"platforms,omitempty"`
+	Metadata     *archive.Metadata  `json:"metadata,omitempty"`
+	Stats        *statsInfo         `json:"stats,omitempty"`
+	Transfer     *transfer.Stats    `json:"transfer,omitempty"`
+
+	// About is the archive README/metadata summary (text output only).
+	About *archive.About `json:"-"`
 }
 
 // sizeInfo contains size information.
@@ -66,6 +96,22 @@ type sizeInfo struct {
 	Ratio        float64 `json:"ratio"`
 }
 
+// statsInfo contains per-extension and compression effectiveness statistics.
+type statsInfo struct {
+	ByExtension     []statsGroup `json:"by_extension"`
+	ByEffectiveness []statsGroup `json:"by_compression"`
+}
+
+// statsGroup contains statistics for a group of files.
+type statsGroup struct {
+	Name            string  `json:"name"`
+	Files           int     `json:"files"`
+	CompressedFiles int     `json:"compressed_files"`
+	Size            uint64  `json:"size"`
+	StoredSize      uint64  `json:"stored_size"`
+	Ratio           float64 `json:"ratio"`
+}
+
 // referrerInfo contains information about a signature or attestation.
 type referrerInfo struct {
 	Digest       string            `json:"digest"`
@@ -78,13 +124,28 @@ func runInspect(cmd *cobra.Command, args []string) error {
 	if cfg == nil {
 		return errors.New("configuration not loaded")
 	}
+	args, err := withDefaultRef(cfg, args)
+	if err != nil {
+		return err
+	}
 
 	inputRef := args[0]
-	resolvedRef := cfg.ResolveAlias(inputRef)
+	resolvedRef, err := resolveRef(cmd.Context(), cfg, inputRef)
+	if err != nil {
+		return err
+	}
 	skipCache, err := cmd.Flags().GetBool("skip-cache")
 	if err != nil {
 		return fmt.Errorf("reading skip-cache flag: %w", err)
 	}
+	showStats, err := cmd.Flags().GetBool("stats")
+	if err != nil {
+		return fmt.Errorf("reading stats flag: %w", err)
+	}
+	wantPlatform, err := cmd.Flags().GetString("platform")
+	if err != nil {
+		return fmt.Errorf("reading platform flag: %w", err)
+	}
 
 	var opts archive.InspectOptions
 	if skipCache {
@@ -103,33 +164,131 @@ func runInspect(cmd *cobra.Command, args []string) error {
 
 	// Fetch referrers (signatures and attestations).
 	ctx := cmd.Context()
-	signatures, sigErr := result.Referrers(ctx, sigstoreArtifactType)
-	attestations, attErr := result.Referrers(ctx, inTotoArtifactType)
+	signatures, sigErr := inspectReferrers(ctx, cfg, resolvedRef, result, sigstoreArtifactType)
+	attestations, attErr := inspectReferrers(ctx, cfg, resolvedRef, result, inTotoArtifactType)
 
 	output := buildInspectOutput(inputRef, resolvedRef, result, compression, signatures, attestations)
+	if showStats {
+		output.Stats = convertStats(archive.ComputeStats(result.Index()))
+	}
 
-	if cfg.Quiet {
-		return nil
+	annotations := result.Manifest().Annotations()
+	if wantPlatform != "" {
+		variant, err := selectPlatform(resolvedRef, annotations, wantPlatform)
+		if err != nil {
+			return err
+		}
+		output.Platforms = []platform.Variant{variant}
+	} else if variants, err := platform.FromAnnotations(annotations); err != nil {
+		warn.Printf("%v", err)
+	} else {
+		output.Platforms = variants
+	}
+
+	if err := copyFlagValue(cmd, cfg, "digest", output.Digest); err != nil {
+		return err
 	}
 
 	// Warn on unexpected referrer errors (ignore ErrReferrersUnsupported).
-	// Placed after quiet check to respect --quiet flag.
+	// These are counted under --quiet too, for --strict.
 	warnReferrerError(sigErr, "signatures")
 	warnReferrerError(attErr, "attestations")
 
-	if viper.GetString("output") == internalcfg.OutputJSON {
+	if cfg.Quiet {
+		return nil
+	}
+
+	if archive.HasAbout(result.Index()) {
+		about, err := loadInspectAbout(cmd.Context(), resolvedRef, result, opts.ClientOpts, skipCache)
+		if err != nil {
+			warn.Printf("failed to read archive metadata: %v", err)
+		} else {
+			output.About = about
+			output.Metadata = about.Metadata
+		}
+	}
+
+	if cfg.Output == internalcfg.OutputJSON {
+		output.Transfer = transferStats()
 		return inspectJSON(&output)
 	}
 	return inspectText(&output)
 }
 
-// warnReferrerError prints a warning to stderr for unexpected referrer errors.
+// inspectReferrers lists the referrers of artifactType of the inspected
+// archive, adding those in the referrers tag index of its repository.
+func inspectReferrers(
+	ctx context.Context,
+	cfg *internalcfg.Config,
+	ref string,
+	result *blob.InspectResult,
+	artifactType string,
+) ([]blob.Referrer, error) {
+	refs, err := result.Referrers(ctx, artifactType)
+	if err != nil && !errors.Is(err, blob.ErrReferrersUnsupported) {
+		return nil, err
+	}
+	repository, repoErr := newRemoteRepository(cfg, repositoryOf(ref))
+	if repoErr != nil {
+		return nil, repoErr
+	}
+	tagged, tagErr := referrers.ByTag(ctx, repository, digest.Digest(result.Digest()), artifactType)
+	if tagErr != nil {
+		return nil, tagErr
+	}
+	for _, d := range tagged {
+		if slices.ContainsFunc(refs, func(r blob.Referrer) bool { return r.Digest == d.Digest.String() }) {
+			continue
+		}
+		refs = append(refs, blob.Referrer{
+			Digest:       d.Digest.String(),
+			Size:         d.Size,
+			MediaType:    d.MediaType,
+			ArtifactType: d.ArtifactType,
+			Annotations:  d.Annotations,
+		})
+	}
+	if err != nil && len(refs) == 0 {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// warnReferrerError reports a warning for unexpected referrer errors.
 // ErrReferrersUnsupported is silently ignored since many registries don't support referrers.
 func warnReferrerError(err error, kind string) {
 	if err == nil || errors.Is(err, blob.ErrReferrersUnsupported) {
 		return
 	}
-	fmt.Fprintf(os.Stderr, "Warning: failed to fetch %s: %v\n", kind, err)
+	warn.Printf("failed to fetch %s: %v", kind, err)
+}
+
+// loadInspectAbout reads the archive README and metadata manifest.
+// Only the two small files are fetched, via range requests.
+func loadInspectAbout(
+	ctx context.Context,
+	ref string,
+	result *blob.InspectResult,
+	clientOpts []blob.Option,
+	skipCache bool,
+) (*archive.About, error) {
+	client, err := blob.NewClient(clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating client: %w", err)
+	}
+
+	var pullOpts []blob.PullOption
+	if skipCache {
+		pullOpts = append(pullOpts, blob.PullWithSkipCache())
+	}
+
+	// Pull by digest so the files match the inspected manifest.
+	blobArchive, err := client.Pull(ctx, repositoryOf(ref)+"@"+result.Digest(), pullOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("accessing archive: %w", err)
+	}
+
+	return archive.LoadAbout(result.Index(), blobArchive)
 }
 
 // determineCompression checks entries for compression type.
@@ -192,6 +351,29 @@ func convertReferrers(refs []blob.Referrer) []referrerInfo {
 	return result
 }
 
+// convertStats converts archive statistics to their output form.
+func convertStats(stats *archive.Stats) *statsInfo {
+	return &statsInfo{
+		ByExtension:     convertStatsGroups(stats.ByExtension),
+		ByEffectiveness: convertStatsGroups(stats.ByEffectiveness),
+	}
+}
+
+func convertStatsGroups(groups []archive.GroupStats) []statsGroup {
+	result := make([]statsGroup, len(groups))
+	for i, g := range groups {
+		result[i] = statsGroup{
+			Name:            g.Name,
+			Files:           g.Files,
+			CompressedFiles: g.CompressedFiles,
+			Size:            g.Size,
+			StoredSize:      g.StoredSize,
+			Ratio:           g.Ratio,
+		}
+	}
+	return result
+}
+
 func inspectJSON(output *inspectOutput) error {
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
@@ -213,6 +395,32 @@ func inspectText(output *inspectOutput) error {
 		fmt.Printf("Created:      %s\n", output.Created)
 	}
 
+	if len(output.Platforms) > 0 {
+		fmt.Println()
+		fmt.Println("Platforms:")
+		for _, v := range output.Platforms {
+			if v.Dir == "" {
+				fmt.Printf("  %s\n", v.Platform)
+			} else {
+				fmt.Printf("  %-16s /%s\n", v.Platform, v.Dir)
+			}
+		}
+	}
+
+	if output.About != nil {
+		if lines := output.About.Summary(); len(lines) > 0 {
+			fmt.Println()
+			fmt.Println("About:")
+			for _, line := range lines {
+				fmt.Printf("  %s\n", line)
+			}
+		}
+	}
+
+	if output.Stats != nil {
+		printStats(output.Stats)
+	}
+
 	if len(output.Signatures) > 0 {
 		fmt.Println()
 		fmt.Println("Signatures:")
@@ -239,3 +447,29 @@ func inspectText(output *inspectOutput) error {
 
 	return nil
 }
+
+// printStats prints the statistics tables.
+func printStats(stats *statsInfo) {
+	fmt.Println()
+	fmt.Println("By extension:")
+	printStatsGroups(stats.ByExtension)
+
+	fmt.Println()
+	fmt.Println("By compression:")
+	printStatsGroups(stats.ByEffectiveness)
+}
+
+func printStatsGroups(groups []statsGroup) {
+	nameWidth := len("NAME")
+	for _, g := range groups {
+		nameWidth = max(nameWidth, len(g.Name))
+	}
+
+	fmt.Printf("  %-*s  %7s  %9s  %9s  %6s\n", nameWidth, "NAME", "FILES", "SIZE", "STORED", "SAVED")
+	for _, g := range groups {
+		fmt.Printf("  %-*s  %7d  %9s  %9s  %5.1f%%\n",
+			nameWidth, g.Name, g.Files,
+			archive.FormatSize(g.Size), archive.FormatSize(g.StoredSize),
+			(1-g.Ratio)*100)
+	}
+}
@@ -9,16 +9,13 @@ import (
 	"testing"
 
 	"github.com/meigma/blob"
-	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/meigma/blob-cli/internal/archive"
 )
 
 func TestInspectCmd_NilConfig(t *testing.T) {
-	// Reset viper and restore after test to avoid affecting other tests.
-	viper.Reset()
-	t.Cleanup(viper.Reset)
-
 	ctx := context.Background()
 
 	inspectCmd.SetContext(ctx)
@@ -118,6 +115,27 @@ func TestWarnReferrerError_OtherError(t *testing.T) {
 	assert.Contains(t, got, "authentication failed")
 }
 
+func TestInspectReferrers_Tag(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	subject := reg.addArchive(t, "v1", map[string]string{"app.yaml": "a: 1"})
+	apiSig := reg.addWithLayers(t, "", &subject, sigstoreArtifactType, reg.addBlob(sigstoreArtifactType, []byte(`{}`)))
+	tagSig := reg.addTagReferrer(t, subject, sigstoreArtifactType)
+	reg.addTagReferrer(t, subject, inTotoArtifactType)
+
+	cfg := newRegistryTestConfig(t)
+	ref := reg.repo + ":v1"
+	result, err := archive.InspectWithOptions(t.Context(), ref, archive.InspectOptions{ClientOpts: clientOpts(cfg)})
+	require.NoError(t, err)
+
+	refs, err := inspectReferrers(t.Context(), cfg, ref, result, sigstoreArtifactType)
+	require.NoError(t, err)
+	digests := make([]string, len(refs))
+	for i, r := range refs {
+		digests[i] = r.Digest
+	}
+	assert.Equal(t, []string{apiSig.Digest.String(), tagSig.Digest.String()}, digests)
+}
+
 func TestInspectText_Basic(t *testing.T) {
 	output := &inspectOutput{
 		Ref:         "ghcr.io/test:v1",
@@ -227,6 +245,31 @@ func TestInspectText_WithSections(t *testing.T) {
 	assert.Contains(t, got, "org.example.key: value")
 }
 
+func TestInspectText_WithAbout(t *testing.T) {
+	output := &inspectOutput{
+		Ref:         "ghcr.io/test:v1",
+		Digest:      "sha256:abc123",
+		Files:       1,
+		Compression: "none",
+		Size:        sizeInfo{Compressed: 100, Uncompressed: 100},
+		About: &archive.About{
+			Metadata: &archive.Metadata{
+				Description: "Shared service configs",
+				Owners:      []string{"platform-team"},
+				Links:       []archive.Link{{Name: "Docs", URL: "https://example.com/docs"}},
+			},
+		},
+	}
+
+	out, err := captureStdout(t, func() error { return inspectText(output) })
+	require.NoError(t, err)
+	got := string(out)
+	assert.Contains(t, got, "About:")
+	assert.Contains(t, got, "  Shared service configs")
+	assert.Contains(t, got, "  Owners: platform-team")
+	assert.Contains(t, got, "  Docs: https://example.com/docs")
+}
+
 func TestInspectJSON(t *testing.T) {
 	output := &inspectOutput{
 		Ref:         "ghcr.io/test:v1",
@@ -308,3 +351,33 @@ func TestInspectJSON_OmitsEmpty(t *testing.T) {
 	assert.NotContains(t, jsonStr, "attestations")
 	assert.NotContains(t, jsonStr, "annotations")
 }
+
+func TestInspectText_WithStats(t *testing.T) {
+	output := &inspectOutput{
+		Ref:         "ghcr.io/test:v1",
+		Digest:      "sha256:abc123",
+		Files:       3,
+		Compression: "zstd",
+		Size:        sizeInfo{Compressed: 1100, Uncompressed: 3000},
+		Stats: convertStats(&archive.Stats{
+			ByExtension: []archive.GroupStats{
+				{Name: ".json", Files: 2, CompressedFiles: 2, Size: 2000, StoredSize: 200, Ratio: 0.1},
+				{Name: ".png", Files: 1, CompressedFiles: 0, Size: 1000, StoredSize: 1000, Ratio: 1.0},
+			},
+			ByEffectiveness: []archive.GroupStats{
+				{Name: archive.EffectivenessStored, Files: 1, Size: 1000, StoredSize: 1000, Ratio: 1.0},
+				{Name: archive.EffectivenessGood, Files: 2, CompressedFiles: 2, Size: 2000, StoredSize: 200, Ratio: 0.1},
+			},
+		}),
+	}
+
+	out, err := captureStdout(t, func() error { return inspectText(output) })
+	require.NoError(t, err)
+	got := string(out)
+	assert.Contains(t, got, "By extension:")
+	assert.Contains(t, got, ".json")
+	assert.Contains(t, got, "90.0%")
+	assert.Contains(t, got, "By compression:")
+	assert.Contains(t, got, "stored")
+	assert.Contains(t, got, "0.0%")
+}
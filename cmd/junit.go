@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/meigma/blob/registry"
+
+	"github.com/meigma/blob-cli/internal/policy"
+)
+
+// policyOutcome is the result of evaluating one policy.
+type policyOutcome struct {
+	name     string
+	duration time.Duration
+	err      error
+}
+
+// policyOutcomes records the outcome of every policy evaluated for a
+// reference. The client stops at the first failing policy, so the wrapped
+// policies always pass and the failure is reported afterwards by failed.
+type policyOutcomes struct {
+	mu       sync.Mutex
+	outcomes []policyOutcome
+}
+
+// wrap returns a policy that records the outcome of np and never fails.
+func (o *policyOutcomes) wrap(np policy.NamedPolicy) registry.Policy {
+	return registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+		start := time.Now()
+		err := np.Policy.Evaluate(ctx, req)
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.outcomes = append(o.outcomes, policyOutcome{name: np.Name, duration: time.Since(start), err: err})
+		return nil
+	})
+}
+
+// failed returns the first recorded policy error, or nil if all passed.
+func (o *policyOutcomes) failed() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, outcome := range o.outcomes {
+		if outcome.err != nil {
+			return fmt.Errorf("%s: %w", outcome.name, outcome.err)
+		}
+	}
+	return nil
+}
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite groups the policy results for one reference.
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+
+	duration time.Duration
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// junitTestCase is the result of one policy.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// newJUnitSuite builds the test suite for a verify result, with one test
+// case per policy. A result with no policies, or one that was skipped, is
+// reported as one skipped test so the reference still shows up in CI.
+func newJUnitSuite(result *verifyResult) junitTestSuite {
+	ref := result.Ref
+	if result.ResolvedRef != "" {
+		ref = result.ResolvedRef
+	}
+	suite := junitTestSuite{
+		Name:      ref,
+		Timestamp: result.started.UTC().Format(time.RFC3339),
+	}
+	if result.Digest != "" {
+		suite.Properties = []junitProperty{{Name: "digest", Value: result.Digest}}
+	}
+
+	var total time.Duration
+	for _, outcome := range result.outcomes {
+		tc := junitTestCase{
+			Name:      outcome.name,
+			ClassName: ref,
+			Time:      junitSeconds(outcome.duration),
+		}
+		if outcome.err != nil {
+			tc.Failure = &junitFailure{
+				Message: outcome.err.Error(),
+				Type:    "PolicyViolation",
+				Text:    outcome.err.Error(),
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+		total += outcome.duration
+	}
+	if len(suite.Cases) == 0 {
+		name, message := "no policies", "no policies applied - archive not verified"
+		if result.skipped != "" {
+			name, message = "skipped", result.skipped
+		}
+		suite.Cases = append(suite.Cases, junitTestCase{
+			Name:      name,
+			ClassName: ref,
+			Time:      junitSeconds(0),
+			Skipped:   &junitSkipped{Message: message},
+		})
+		suite.Skipped = 1
+	}
+	suite.Tests = len(suite.Cases)
+	suite.Time = junitSeconds(total)
+	suite.duration = total
+	return suite
+}
+
+// writeJUnit writes suites as a JUnit XML report.
+func writeJUnit(w io.Writer, suites ...junitTestSuite) error {
+	report := junitTestSuites{Name: "blob verify", Suites: suites}
+	var total time.Duration
+	for _, s := range suites {
+		report.Tests += s.Tests
+		report.Failures += s.Failures
+		report.Skipped += s.Skipped
+		total += s.duration
+	}
+	report.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
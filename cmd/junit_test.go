@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/meigma/blob/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/meigma/blob-cli/internal/policy"
+)
+
+func TestPolicyOutcomes(t *testing.T) {
+	var outcomes policyOutcomes
+	pass := registry.PolicyFunc(func(context.Context, registry.PolicyRequest) error { return nil })
+	fail := registry.PolicyFunc(func(context.Context, registry.PolicyRequest) error {
+		return errors.New("no matching signature")
+	})
+
+	for _, np := range []policy.NamedPolicy{
+		{Name: "policy file sig.yaml", Policy: fail},
+		{Name: "rego policy custom.rego", Policy: pass},
+	} {
+		require.NoError(t, outcomes.wrap(np).Evaluate(t.Context(), registry.PolicyRequest{}))
+	}
+
+	require.Len(t, outcomes.outcomes, 2)
+	assert.Equal(t, "policy file sig.yaml", outcomes.outcomes[0].name)
+	require.Error(t, outcomes.outcomes[0].err)
+	require.NoError(t, outcomes.outcomes[1].err)
+
+	err := outcomes.failed()
+	require.Error(t, err)
+	assert.Equal(t, "policy file sig.yaml: no matching signature", err.Error())
+}
+
+func TestWriteJUnit(t *testing.T) {
+	result := &verifyResult{
+		Ref:         "configs:v1.0.0",
+		ResolvedRef: "ghcr.io/acme/configs:v1.0.0",
+		Digest:      "sha256:abc123",
+		started:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		outcomes: []policyOutcome{
+			{name: "config policy (match ghcr\\.io/acme/.*)", duration: 1500 * time.Millisecond},
+			{name: "policy file sig.yaml", duration: 250 * time.Millisecond, err: errors.New("no matching signature")},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeJUnit(&buf, newJUnitSuite(result)))
+
+	var report junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, 2, report.Tests)
+	assert.Equal(t, 1, report.Failures)
+	assert.Equal(t, "1.750", report.Time)
+
+	require.Len(t, report.Suites, 1)
+	suite := report.Suites[0]
+	assert.Equal(t, "ghcr.io/acme/configs:v1.0.0", suite.Name)
+	assert.Equal(t, "2026-01-02T03:04:05Z", suite.Timestamp)
+	assert.Equal(t, []junitProperty{{Name: "digest", Value: "sha256:abc123"}}, suite.Properties)
+
+	require.Len(t, suite.Cases, 2)
+	assert.Equal(t, "ghcr.io/acme/configs:v1.0.0", suite.Cases[0].ClassName)
+	assert.Equal(t, "1.500", suite.Cases[0].Time)
+	assert.Nil(t, suite.Cases[0].Failure)
+	require.NotNil(t, suite.Cases[1].Failure)
+	assert.Equal(t, "no matching signature", suite.Cases[1].Failure.Message)
+}
+
+func TestWriteJUnit_NoPolicies(t *testing.T) {
+	result := &verifyResult{Ref: "ghcr.io/acme/configs:v1.0.0", Status: "no_policies"}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeJUnit(&buf, newJUnitSuite(result)))
+
+	var report junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, 1, report.Tests)
+	assert.Equal(t, 1, report.Skipped)
+	require.Len(t, report.Suites, 1)
+	require.Len(t, report.Suites[0].Cases, 1)
+	assert.NotNil(t, report.Suites[0].Cases[0].Skipped)
+}
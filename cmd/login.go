@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login <registry>",
+	Short: "Log in to a registry",
+	Long: `Log in to a registry.
+
+Checks the credentials against the registry and stores them in the
+Docker config file ($DOCKER_CONFIG/config.json, or ~/.docker/config.json),
+where blob and other OCI tools read them. If the config file names a
+credential helper or credential store, the credentials are saved there
+instead of in the file, so the docker CLI is not needed to authenticate.
+
+The username and password are prompted for unless given. Pass the
+password or token on stdin with --password-stdin to keep it out of the
+shell history and process list.`,
+	Example: `  blob login ghcr.io
+  echo "$GITHUB_TOKEN" | blob login ghcr.io -u octocat --password-stdin
+  blob logout ghcr.io`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLogin,
+}
+
+func init() {
+	loginCmd.Flags().StringP("username", "u", "", "registry username")
+	loginCmd.Flags().Bool("password-stdin", false, "read the password or token from stdin")
+}
+
+// loginResult contains the result of a login or logout.
+type loginResult struct {
+	Registry string `json:"registry"`
+	Username string `json:"username,omitempty"`
+	Status   string `json:"status"`
+}
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+	host, err := registryHost(args[0])
+	if err != nil {
+		return err
+	}
+
+	username, err := cmd.Flags().GetString("username")
+	if err != nil {
+		return fmt.Errorf("reading username flag: %w", err)
+	}
+	passwordStdin, err := cmd.Flags().GetBool("password-stdin")
+	if err != nil {
+		return fmt.Errorf("reading password-stdin flag: %w", err)
+	}
+
+	cred, err := readLoginCredential(os.Stdin, username, passwordStdin)
+	if err != nil {
+		return err
+	}
+
+	store, err := credentialStore()
+	if err != nil {
+		return err
+	}
+	if err := loginRegistry(cmd, cfg, store, host, cred); err != nil {
+		return err
+	}
+
+	return outputLoginResult(cfg, &loginResult{Registry: host, Username: cred.Username, Status: "logged_in"})
+}
+
+// registryHost returns the registry host of arg, which may be a host,
+// a URL, or a reference.
+func registryHost(arg string) (string, error) {
+	host := arg
+	if _, rest, ok := strings.Cut(host, "://"); ok {
+		host = rest
+	}
+	host, _, _ = strings.Cut(host, "/")
+	if host == "" {
+		return "", fmt.Errorf("invalid registry %q", arg)
+	}
+	if host == "index.docker.io" || host == "registry-1.docker.io" {
+		host = "docker.io"
+	}
+	return host, nil
+}
+
+// readLoginCredential reads the username and password. With passwordStdin
+// the password is read from in; otherwise missing values are prompted
+// for on the terminal.
+func readLoginCredential(in io.Reader, username string, passwordStdin bool) (auth.Credential, error) {
+	var cred auth.Credential
+	interactive := !passwordStdin && term.IsTerminal(int(os.Stdin.Fd())) //nolint:gosec // file descriptors fit in an int
+
+	cred.Username = username
+	if cred.Username == "" {
+		if !interactive {
+			return cred, errors.New("--username is required when not running in a terminal")
+		}
+		fmt.Fprint(os.Stderr, "Username: ")
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return cred, fmt.Errorf("reading username: %w", err)
+		}
+		cred.Username = strings.TrimSpace(line)
+		if cred.Username == "" {
+			return cred, errors.New("username is required")
+		}
+	}
+
+	if passwordStdin {
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return cred, fmt.Errorf("reading password from stdin: %w", err)
+		}
+		cred.Password = strings.TrimRight(string(data), "\r\n")
+	} else {
+		if !interactive {
+			return cred, errors.New("no password given: use --password-stdin when not running in a terminal")
+		}
+		fmt.Fprint(os.Stderr, "Password: ")
+		data, err := term.ReadPassword(int(os.Stdin.Fd())) //nolint:gosec // file descriptors fit in an int
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return cred, fmt.Errorf("reading password: %w", err)
+		}
+		cred.Password = string(data)
+	}
+	if cred.Password == "" {
+		return cred, errors.New("password is required")
+	}
+	return cred, nil
+}
+
+// credentialStore opens the user's Docker config credential store, saving
+// in plain text only when no credential helper is configured or detected.
+func credentialStore() (credentials.Store, error) {
+	dir, err := dockerConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	store, err := credentials.NewStore(filepath.Join(dir, "config.json"), credentials.StoreOptions{
+		AllowPlaintextPut:        true,
+		DetectDefaultNativeStore: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("opening Docker config: %w", err)
+	}
+	return store, nil
+}
+
+// loginRegistry checks cred against the registry at host and stores it.
+func loginRegistry(cmd *cobra.Command, cfg *internalcfg.Config, store credentials.Store, host string, cred auth.Credential) error {
+	// Docker Hub is stored as docker.io but served from registry-1.docker.io
+	apiHost := host
+	if host == "docker.io" {
+		apiHost = "registry-1.docker.io"
+	}
+	reg, err := remote.NewRegistry(apiHost)
+	if err != nil {
+		return fmt.Errorf("invalid registry %q: %w", host, err)
+	}
+	reg.PlainHTTP = cfg.PlainHTTP
+	// Only the registry's connection settings apply: the mirror and
+	// credential layers of the registry transport would send the check to
+	// a mirror or replace the credentials being checked.
+	rt, err := registryBaseTransport(cfg)
+	if err != nil {
+		return err
+	}
+	reg.Client = &auth.Client{
+		Client:     &http.Client{Transport: retry.NewTransport(rt)},
+		Header:     http.Header{"User-Agent": {userAgent(cfg)}},
+		Credential: auth.StaticCredential(apiHost, cred),
+	}
+	if err := reg.Ping(cmd.Context()); err != nil {
+		return fmt.Errorf("logging in to %s: %w", host, err)
+	}
+
+	if err := store.Put(cmd.Context(), credentials.ServerAddressFromRegistry(host), cred); err != nil {
+		return fmt.Errorf("storing credentials for %s: %w", host, err)
+	}
+	return nil
+}
+
+// outputLoginResult formats and outputs a login or logout result.
+func outputLoginResult(cfg *internalcfg.Config, result *loginResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	if result.Status == "logged_out" {
+		fmt.Printf("Removed credentials for %s\n", result.Registry)
+		return nil
+	}
+	fmt.Printf("Login succeeded for %s as %s\n", result.Registry, result.Username)
+	return nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/v2/registry/remote/auth"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestRegistryHost(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{arg: "ghcr.io", want: "ghcr.io"},
+		{arg: "https://ghcr.io/v2/", want: "ghcr.io"},
+		{arg: "ghcr.io/acme/configs:v1", want: "ghcr.io"},
+		{arg: "localhost:5000", want: "localhost:5000"},
+		{arg: "index.docker.io", want: "docker.io"},
+	}
+	for _, tt := range tests {
+		got, err := registryHost(tt.arg)
+		require.NoError(t, err, tt.arg)
+		assert.Equal(t, tt.want, got, tt.arg)
+	}
+
+	_, err := registryHost("https://")
+	require.Error(t, err)
+}
+
+func TestReadLoginCredential_PasswordStdin(t *testing.T) {
+	cred, err := readLoginCredential(strings.NewReader("s3cret\n"), "octocat", true)
+	require.NoError(t, err)
+	assert.Equal(t, auth.Credential{Username: "octocat", Password: "s3cret"}, cred)
+
+	_, err = readLoginCredential(strings.NewReader("\n"), "octocat", true)
+	require.ErrorContains(t, err, "password is required")
+
+	_, err = readLoginCredential(strings.NewReader("s3cret\n"), "", true)
+	require.ErrorContains(t, err, "--username is required")
+}
+
+func TestLoginLogout(t *testing.T) {
+	dockerConfig := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dockerConfig)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "octocat" || pass != "s3cret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	cfg := &internalcfg.Config{PlainHTTP: true, Quiet: true}
+	loginCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	store, err := credentialStore()
+	require.NoError(t, err)
+
+	// Rejected credentials are not stored
+	err = loginRegistry(loginCmd, cfg, store, host, auth.Credential{Username: "octocat", Password: "wrong"})
+	require.ErrorContains(t, err, "logging in to "+host)
+	_, statErr := os.Stat(filepath.Join(dockerConfig, "config.json"))
+	assert.True(t, os.IsNotExist(statErr))
+
+	cred := auth.Credential{Username: "octocat", Password: "s3cret"}
+	require.NoError(t, loginRegistry(loginCmd, cfg, store, host, cred))
+	got, err := store.Get(context.Background(), host)
+	require.NoError(t, err)
+	assert.Equal(t, cred, got)
+
+	logoutCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	require.NoError(t, logoutCmd.RunE(logoutCmd, []string{host}))
+	store, err = credentialStore()
+	require.NoError(t, err)
+	got, err = store.Get(context.Background(), host)
+	require.NoError(t, err)
+	assert.Equal(t, auth.EmptyCredential, got)
+}
+
+func TestLoginRegistry_IgnoresMirrorsAndConfiguredCredentials(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	t.Setenv("BLOB_TEST_PASSWORD", "s3cret")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "octocat" || pass != "s3cret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	var mirrorHits atomic.Int32
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mirrorHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(mirror.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	// The registry transport would answer from the mirror and sign in
+	// with the configured password instead of the one being checked
+	cfg := &internalcfg.Config{PlainHTTP: true, Quiet: true, Registries: []internalcfg.RegistryAuth{{
+		Host:        host,
+		Username:    "octocat",
+		PasswordEnv: "BLOB_TEST_PASSWORD",
+		PlainHTTP:   true,
+		Mirrors:     []string{strings.TrimPrefix(mirror.URL, "http://")},
+	}}}
+	transport, err := buildRegistryTransport(cfg, "")
+	require.NoError(t, err)
+	keepRegistryTransport(t)
+	useRegistryTransport(transport)
+
+	store, err := credentialStore()
+	require.NoError(t, err)
+	err = loginRegistry(loginCmd, cfg, store, host, auth.Credential{Username: "octocat", Password: "wrong"})
+	require.ErrorContains(t, err, "logging in to "+host)
+	got, err := store.Get(context.Background(), host)
+	require.NoError(t, err)
+	assert.Equal(t, auth.EmptyCredential, got, "rejected credentials are not stored")
+	assert.Zero(t, mirrorHits.Load(), "the registry itself is checked")
+
+	require.NoError(t, loginRegistry(loginCmd, cfg, store, host, auth.Credential{Username: "octocat", Password: "s3cret"}))
+}
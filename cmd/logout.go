@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout <registry>",
+	Short: "Log out of a registry",
+	Long: `Log out of a registry.
+
+Removes the registry's credentials from the Docker config file, or from
+the credential helper or store it names.`,
+	Example: `  blob logout ghcr.io`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runLogout,
+}
+
+func runLogout(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+	host, err := registryHost(args[0])
+	if err != nil {
+		return err
+	}
+
+	store, err := credentialStore()
+	if err != nil {
+		return err
+	}
+	if err := credentials.Logout(cmd.Context(), store, host); err != nil {
+		return err
+	}
+
+	return outputLoginResult(cfg, &loginResult{Registry: host, Status: "logged_out"})
+}
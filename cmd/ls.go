@@ -1,16 +1,24 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
+	"math"
 	"os"
+	"path"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/meigma/blob"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
@@ -22,12 +30,43 @@ var lsCmd = &cobra.Command{
 	Long: `List files and directories in an archive.
 
 Lists the contents of an archive at the specified path. If no path
-is provided, lists the root directory.`,
+is provided, lists the root directory.
+
+Use --max-depth to include entries from nested directories; nested
+entries are shown with their path relative to the listed directory.
+--dirs-only and --files-only limit the output to one entry type.
+
+Entries whose name starts with "." are hidden unless -a is set or
+ls.show_hidden is enabled in the config file.
+
+-R lists the whole archive below the path. With --output csv or tsv,
+each entry is written as a path,size,mode,mtime,digest row under a
+header row, for spreadsheets and inventory imports.
+
+For very large archives, --limit and --offset page through the
+listing, and --ndjson streams one JSON object per entry instead of
+a single JSON document.
+
+With --platform, the listing starts at the directory of the archive's
+variant for that platform (see "blob push --platform").
+
+On a terminal, names are colored by file type, permissions are colored
+in long format, and short listings are laid out in columns. Directories
+are listed before files unless --dirs-first=false. --icons shows a file
+type icon before each name (requires a Nerd Font). Defaults for these
+come from the ls section of the config file.`,
 	Example: `  blob ls ghcr.io/acme/configs:v1.0.0
   blob ls -lh ghcr.io/acme/configs:v1.0.0 /etc
-  blob ls --digest ghcr.io/acme/configs:v1.0.0`,
-	Args: cobra.RangeArgs(1, 2),
-	RunE: runLs,
+  blob ls --digest ghcr.io/acme/configs:v1.0.0
+  blob ls --dirs-only ghcr.io/acme/configs:v1.0.0
+  blob ls --files-only --max-depth 3 ghcr.io/acme/configs:v1.0.0 /etc
+  blob ls --ndjson --limit 1000 --offset 2000 ghcr.io/acme/data:v1
+  blob ls -R --files-only --output csv ghcr.io/acme/configs:v1.0.0 > inventory.csv
+  blob ls --platform darwin/arm64 ghcr.io/acme/tools:v2 /bin
+  blob ls -l --icons ghcr.io/acme/configs:v1.0.0`,
+	Args:        defaultRefArgs(cobra.RangeArgs(1, 2)),
+	RunE:        runLs,
+	Annotations: outputFormats(internalcfg.OutputCSV, internalcfg.OutputTSV),
 }
 
 func init() {
@@ -35,23 +74,56 @@ func init() {
 	lsCmd.Flags().Bool("help", false, "help for ls")
 	lsCmd.Flags().BoolP("human", "h", false, "human-readable sizes (use with -l)")
 	lsCmd.Flags().BoolP("long", "l", false, "long format (permissions, size, hash)")
+	lsCmd.Flags().BoolP("all", "a", false, "include entries whose name starts with \".\"")
 	lsCmd.Flags().Bool("digest", false, "show file digests")
+	lsCmd.Flags().Bool("dirs-only", false, "list directories only")
+	lsCmd.Flags().Bool("files-only", false, "list files only")
+	lsCmd.Flags().Int("max-depth", 1, "include entries up to n levels deep")
+	lsCmd.Flags().BoolP("recursive", "R", false, "list all entries below the path")
+	lsCmd.Flags().Int("limit", 0, "show at most n entries (0 = unlimited)")
+	lsCmd.Flags().Int("offset", 0, "skip the first n entries")
+	lsCmd.Flags().Bool("ndjson", false, "stream entries as newline-delimited JSON")
 	lsCmd.Flags().Bool("skip-cache", false, "bypass registry caches for this operation")
+	lsCmd.Flags().String("platform", "", "list the archive's variant for os/arch[/variant]")
+	lsCmd.Flags().Bool("icons", false, "show file type icons (requires a Nerd Font)")
+	lsCmd.Flags().Bool("dirs-first", true, "list directories before files")
+	addNoVerifyFlag(lsCmd)
+	lsCmd.MarkFlagsMutuallyExclusive("dirs-only", "files-only")
+	lsCmd.MarkFlagsMutuallyExclusive("recursive", "max-depth")
 }
 
 // lsFlags holds the parsed command flags.
 type lsFlags struct {
 	long      bool
+	all       bool
 	human     bool
 	digest    bool
+	dirsOnly  bool
+	filesOnly bool
+	maxDepth  int
+	limit     int
+	offset    int
+	ndjson    bool
 	skipCache bool
+	noVerify  bool
+	platform  string
+	icons     bool
+	dirsFirst bool
+
+	// color and width are resolved from the terminal and config, not flags.
+	// A zero width prints one entry per line.
+	color bool
+	width int
 }
 
 // lsResult contains the ls output data for JSON format.
 type lsResult struct {
 	Ref     string        `json:"ref"`
 	Path    string        `json:"path"`
+	Total   int           `json:"total"`
+	Offset  int           `json:"offset,omitempty"`
 	Entries []lsEntryJSON `json:"entries"`
+	verificationStatus
 }
 
 // lsEntryJSON represents a single entry in JSON output.
@@ -71,8 +143,15 @@ func runLs(cmd *cobra.Command, args []string) error {
 	if cfg == nil {
 		return errors.New("configuration not loaded")
 	}
+	args, err := withDefaultRef(cfg, args)
+	if err != nil {
+		return err
+	}
 
-	ref := cfg.ResolveAlias(args[0])
+	ref, err := resolveRef(cmd.Context(), cfg, args[0])
+	if err != nil {
+		return err
+	}
 	dirPath := "/"
 	if len(args) > 1 {
 		dirPath = args[1]
@@ -82,21 +161,27 @@ func runLs(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	resolveLsDisplay(cmd, cfg, &flags)
 
-	var opts archive.InspectOptions
-	if flags.skipCache {
-		opts.ClientOpts = clientOptsNoCache(cfg)
-		opts.InspectOpts = []blob.InspectOption{blob.InspectWithSkipCache()}
-	} else {
-		opts.ClientOpts = clientOpts(cfg)
-	}
-
-	result, err := archive.InspectWithOptions(cmd.Context(), ref, opts)
+	index, annotations, verification, err := lsIndex(cmd.Context(), cfg, ref, flags)
 	if err != nil {
 		return err
 	}
 
-	entries, err := archive.ListDir(result.Index(), dirPath)
+	if flags.platform != "" {
+		variant, err := selectPlatform(ref, annotations, flags.platform)
+		if err != nil {
+			return err
+		}
+		dirPath = variant.Path(dirPath)
+	}
+
+	entries, err := archive.ListDirWithOptions(index, dirPath, archive.ListOptions{
+		DirsOnly:     flags.dirsOnly,
+		FilesOnly:    flags.filesOnly,
+		MaxDepth:     flags.maxDepth,
+		HideDotfiles: !flags.all,
+	})
 	if err != nil {
 		return err
 	}
@@ -105,10 +190,58 @@ func runLs(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	if viper.GetString("output") == internalcfg.OutputJSON {
-		return lsJSON(ref, dirPath, entries, flags)
+	output := cfg.Output
+	if flags.dirsFirst && !flags.ndjson && output == internalcfg.OutputText {
+		archive.SortDirsFirst(entries)
+	}
+
+	total := len(entries)
+	entries = archive.Page(entries, flags.offset, flags.limit)
+
+	if flags.ndjson {
+		return lsNDJSON(entries, flags)
+	}
+	switch {
+	case output == internalcfg.OutputJSON:
+		return lsJSON(ref, dirPath, entries, total, flags, verification)
+	case isTabularOutput(output):
+		return lsTabular(entries, output)
+	default:
+		return lsText(entries, flags)
+	}
+}
+
+// lsIndex returns the index and manifest annotations of the archive at
+// ref, from a pinned archive if there is one offline.
+func lsIndex(ctx context.Context, cfg *internalcfg.Config, ref string, flags lsFlags) (*blob.IndexView, map[string]string, verificationStatus, error) {
+	if cfg.Offline && !flags.skipCache {
+		if index, pin, ok := pinnedIndex(cfg, ref); ok {
+			verification, err := checkPinnedRead(cfg, ref, pin, flags.noVerify)
+			if err != nil {
+				return nil, nil, verificationStatus{}, err
+			}
+			return index, pin.Annotations, verification, nil
+		}
+	}
+
+	policyOpts, verification, err := readPolicyOpts(cfg, ref, flags.noVerify)
+	if err != nil {
+		return nil, nil, verificationStatus{}, err
+	}
+	var opts archive.InspectOptions
+	if flags.skipCache {
+		opts.ClientOpts = clientOptsNoCache(cfg)
+		opts.InspectOpts = []blob.InspectOption{blob.InspectWithSkipCache()}
+	} else {
+		opts.ClientOpts = clientOpts(cfg)
+	}
+	opts.ClientOpts = append(opts.ClientOpts, policyOpts...)
+
+	result, err := archive.InspectWithOptions(ctx, ref, opts)
+	if err != nil {
+		return nil, nil, verificationStatus{}, archiveError(err)
 	}
-	return lsText(entries, flags)
+	return result.Index(), result.Manifest().Annotations(), verification, nil
 }
 
 func parseLsFlags(cmd *cobra.Command) (lsFlags, error) {
@@ -120,6 +253,11 @@ func parseLsFlags(cmd *cobra.Command) (lsFlags, error) {
 		return flags, fmt.Errorf("reading long flag: %w", err)
 	}
 
+	flags.all, err = cmd.Flags().GetBool("all")
+	if err != nil {
+		return flags, fmt.Errorf("reading all flag: %w", err)
+	}
+
 	flags.human, err = cmd.Flags().GetBool("human")
 	if err != nil {
 		return flags, fmt.Errorf("reading human flag: %w", err)
@@ -130,49 +268,192 @@ func parseLsFlags(cmd *cobra.Command) (lsFlags, error) {
 		return flags, fmt.Errorf("reading digest flag: %w", err)
 	}
 
+	flags.dirsOnly, err = cmd.Flags().GetBool("dirs-only")
+	if err != nil {
+		return flags, fmt.Errorf("reading dirs-only flag: %w", err)
+	}
+
+	flags.filesOnly, err = cmd.Flags().GetBool("files-only")
+	if err != nil {
+		return flags, fmt.Errorf("reading files-only flag: %w", err)
+	}
+	if flags.dirsOnly && flags.filesOnly {
+		return flags, errors.New("--dirs-only and --files-only are mutually exclusive")
+	}
+
+	flags.maxDepth, err = cmd.Flags().GetInt("max-depth")
+	if err != nil {
+		return flags, fmt.Errorf("reading max-depth flag: %w", err)
+	}
+	if flags.maxDepth < 1 {
+		return flags, fmt.Errorf("invalid --max-depth %d: must be at least 1", flags.maxDepth)
+	}
+
+	recursive, err := cmd.Flags().GetBool("recursive")
+	if err != nil {
+		return flags, fmt.Errorf("reading recursive flag: %w", err)
+	}
+	if recursive {
+		flags.maxDepth = math.MaxInt
+	}
+
+	flags.limit, flags.offset, err = parsePageFlags(cmd)
+	if err != nil {
+		return flags, err
+	}
+
+	flags.ndjson, err = cmd.Flags().GetBool("ndjson")
+	if err != nil {
+		return flags, fmt.Errorf("reading ndjson flag: %w", err)
+	}
+
 	flags.skipCache, err = cmd.Flags().GetBool("skip-cache")
 	if err != nil {
 		return flags, fmt.Errorf("reading skip-cache flag: %w", err)
 	}
 
+	flags.noVerify, err = cmd.Flags().GetBool("no-verify")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-verify flag: %w", err)
+	}
+
+	flags.platform, err = cmd.Flags().GetString("platform")
+	if err != nil {
+		return flags, fmt.Errorf("reading platform flag: %w", err)
+	}
+
+	flags.icons, err = cmd.Flags().GetBool("icons")
+	if err != nil {
+		return flags, fmt.Errorf("reading icons flag: %w", err)
+	}
+
+	flags.dirsFirst, err = cmd.Flags().GetBool("dirs-first")
+	if err != nil {
+		return flags, fmt.Errorf("reading dirs-first flag: %w", err)
+	}
+
 	return flags, nil
 }
 
-func lsJSON(ref, dirPath string, entries []*archive.DirEntry, flags lsFlags) error {
+// resolveLsDisplay applies the ls config defaults for flags that were not
+// set, and detects color and column layout from the terminal.
+func resolveLsDisplay(cmd *cobra.Command, cfg *internalcfg.Config, flags *lsFlags) {
+	if !cmd.Flags().Changed("all") {
+		flags.all = cfg.Ls.ShowHidden
+	}
+	if !cmd.Flags().Changed("icons") {
+		flags.icons = cfg.Ls.Icons
+	}
+	if !cmd.Flags().Changed("dirs-first") {
+		flags.dirsFirst = cfg.Ls.DirsFirst
+	}
+	tty := stdoutIsTerminal()
+	flags.color = useColor(cfg, cfg.Ls.Color, tty)
+	if tty {
+		flags.width = terminalWidth()
+	}
+}
+
+// parsePageFlags reads the --limit and --offset pagination flags.
+func parsePageFlags(cmd *cobra.Command) (limit, offset int, err error) {
+	limit, err = cmd.Flags().GetInt("limit")
+	if err != nil {
+		return 0, 0, fmt.Errorf("reading limit flag: %w", err)
+	}
+	if limit < 0 {
+		return 0, 0, fmt.Errorf("invalid --limit %d: cannot be negative", limit)
+	}
+
+	offset, err = cmd.Flags().GetInt("offset")
+	if err != nil {
+		return 0, 0, fmt.Errorf("reading offset flag: %w", err)
+	}
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("invalid --offset %d: cannot be negative", offset)
+	}
+
+	return limit, offset, nil
+}
+
+func lsJSON(ref, dirPath string, entries []*archive.DirEntry, total int, flags lsFlags, verification verificationStatus) error {
 	result := lsResult{
-		Ref:     ref,
-		Path:    dirPath,
-		Entries: make([]lsEntryJSON, 0, len(entries)),
+		Ref:                ref,
+		Path:               dirPath,
+		Total:              total,
+		Offset:             flags.offset,
+		Entries:            make([]lsEntryJSON, 0, len(entries)),
+		verificationStatus: verification,
 	}
 
 	for _, entry := range entries {
-		jsonEntry := lsEntryJSON{
-			Name:  entry.Name,
-			Path:  entry.Path,
-			IsDir: entry.IsDir,
-		}
+		result.Entries = append(result.Entries, newLsEntryJSON(entry, flags))
+	}
 
-		if flags.long {
-			jsonEntry.Mode = archive.FormatMode(entry.Mode, entry.IsDir)
-			if !entry.IsDir {
-				jsonEntry.Size = entry.Size
-				jsonEntry.ModTime = entry.ModTime.Format(time.RFC3339)
-				if flags.human {
-					jsonEntry.SizeHuman = archive.FormatSize(entry.Size)
-				}
-			}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// lsNDJSON writes one JSON object per entry, without buffering the listing.
+func lsNDJSON(entries []*archive.DirEntry, flags lsFlags) error {
+	w := bufio.NewWriter(os.Stdout)
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := enc.Encode(newLsEntryJSON(entry, flags)); err != nil {
+			return err
 		}
+	}
+	return w.Flush()
+}
 
-		if flags.digest && !entry.IsDir && len(entry.Hash) > 0 {
-			jsonEntry.Digest = archive.FormatDigest(entry.Hash)
+func newLsEntryJSON(entry *archive.DirEntry, flags lsFlags) lsEntryJSON {
+	jsonEntry := lsEntryJSON{
+		Name:  entry.Name,
+		Path:  entry.Path,
+		IsDir: entry.IsDir,
+	}
+
+	if flags.long {
+		jsonEntry.Mode = archive.FormatMode(entry.Mode, entry.IsDir)
+		if !entry.IsDir {
+			jsonEntry.Size = entry.Size
+			jsonEntry.ModTime = entry.ModTime.Format(time.RFC3339)
+			if flags.human {
+				jsonEntry.SizeHuman = archive.FormatSize(entry.Size)
+			}
 		}
+	}
 
-		result.Entries = append(result.Entries, jsonEntry)
+	if flags.digest && !entry.IsDir && len(entry.Hash) > 0 {
+		jsonEntry.Digest = archive.FormatDigest(entry.Hash)
 	}
 
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	return enc.Encode(result)
+	return jsonEntry
+}
+
+// lsTabular writes one path,size,mode,mtime,digest row per entry. Sizes are
+// in bytes, times are RFC 3339 UTC, and digests are complete; directories
+// leave size, mtime, and digest empty.
+func lsTabular(entries []*archive.DirEntry, output string) error {
+	w := newTabularWriter(os.Stdout, output)
+	if err := w.Write([]string{"path", "size", "mode", "mtime", "digest"}); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		row := []string{entry.Path, "", archive.FormatMode(entry.Mode, entry.IsDir), "", ""}
+		if !entry.IsDir {
+			row[1] = strconv.FormatUint(entry.Size, 10)
+			row[3] = entry.ModTime.UTC().Format(time.RFC3339)
+			if len(entry.Hash) > 0 {
+				row[4] = "sha256:" + hex.EncodeToString(entry.Hash)
+			}
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
 }
 
 func lsText(entries []*archive.DirEntry, flags lsFlags) error {
@@ -180,6 +461,11 @@ func lsText(entries []*archive.DirEntry, flags lsFlags) error {
 		return nil
 	}
 
+	if flags.width > 0 && !flags.long && !flags.digest {
+		printLsGrid(entries, flags)
+		return nil
+	}
+
 	maxSizeWidth := calculateMaxSizeWidth(entries, flags)
 
 	for _, entry := range entries {
@@ -212,16 +498,13 @@ func formatEntrySize(size uint64, human bool) string {
 }
 
 func printLsEntry(entry *archive.DirEntry, flags lsFlags, maxSizeWidth int) {
-	name := entry.Name
-	if entry.IsDir {
-		name += "/"
-	}
+	name := lsName(entry, flags)
 
 	switch {
 	case flags.long && flags.digest:
-		printLongWithDigest(entry, name, maxSizeWidth, flags.human)
+		printLongWithDigest(entry, name, lsMode(entry, flags.color), maxSizeWidth, flags.human)
 	case flags.long:
-		printLong(entry, name, maxSizeWidth, flags.human)
+		printLong(entry, name, lsMode(entry, flags.color), maxSizeWidth, flags.human)
 	case flags.digest:
 		printDigestOnly(entry, name)
 	default:
@@ -229,15 +512,13 @@ func printLsEntry(entry *archive.DirEntry, flags lsFlags, maxSizeWidth int) {
 	}
 }
 
-func printLongWithDigest(entry *archive.DirEntry, name string, maxSizeWidth int, human bool) {
-	mode := archive.FormatMode(entry.Mode, entry.IsDir)
+func printLongWithDigest(entry *archive.DirEntry, name, mode string, maxSizeWidth int, human bool) {
 	sizeStr := formatEntrySize(entry.Size, human)
 	digest := formatEntryDigest(entry)
 	fmt.Printf("%s  %*s  %-20s  %s\n", mode, maxSizeWidth, sizeStr, digest, name)
 }
 
-func printLong(entry *archive.DirEntry, name string, maxSizeWidth int, human bool) {
-	mode := archive.FormatMode(entry.Mode, entry.IsDir)
+func printLong(entry *archive.DirEntry, name, mode string, maxSizeWidth int, human bool) {
 	sizeStr := formatEntrySize(entry.Size, human)
 	fmt.Printf("%s  %*s  %s\n", mode, maxSizeWidth, sizeStr, name)
 }
@@ -253,3 +534,175 @@ func formatEntryDigest(entry *archive.DirEntry) string {
 	}
 	return archive.FormatDigest(entry.Hash)
 }
+
+// ANSI styles for ls text output.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiDir     = "\x1b[1;34m"
+	ansiExec    = "\x1b[1;32m"
+	ansiSymlink = "\x1b[36m"
+	ansiRead    = "\x1b[33m"
+	ansiWrite   = "\x1b[31m"
+	ansiNone    = "\x1b[90m"
+)
+
+// lsGridGap is the number of spaces between grid columns.
+const lsGridGap = 2
+
+// lsIcons maps file extensions to Nerd Font icons.
+var lsIcons = map[string]string{
+	".go":   "\ue627",
+	".json": "\ue60b",
+	".md":   "\uf48a",
+	".yaml": "\ue6a8",
+	".yml":  "\ue6a8",
+	".toml": "\ue6b2",
+	".sh":   "\uf489",
+	".txt":  "\uf15c",
+	".png":  "\uf1c5",
+	".jpg":  "\uf1c5",
+	".jpeg": "\uf1c5",
+	".gif":  "\uf1c5",
+	".svg":  "\uf1c5",
+	".zip":  "\uf410",
+	".gz":   "\uf410",
+	".tar":  "\uf410",
+	".zst":  "\uf410",
+	".lock": "\uf023",
+}
+
+const (
+	lsIconDir  = "\uf07b"
+	lsIconExec = "\uf489"
+	lsIconFile = "\uf15b"
+)
+
+// lsName returns the display name of entry: an optional icon, the name
+// colored by file type, and a trailing slash for directories.
+func lsName(entry *archive.DirEntry, flags lsFlags) string {
+	name := entry.Name
+	if entry.IsDir {
+		name += "/"
+	}
+	if flags.color {
+		if style := lsNameStyle(entry); style != "" {
+			name = style + name + ansiReset
+		}
+	}
+	if flags.icons {
+		name = lsIcon(entry) + " " + name
+	}
+	return name
+}
+
+func lsNameStyle(entry *archive.DirEntry) string {
+	switch {
+	case entry.IsDir:
+		return ansiDir
+	case entry.Mode&fs.ModeSymlink != 0:
+		return ansiSymlink
+	case entry.Mode&0o111 != 0:
+		return ansiExec
+	default:
+		return ""
+	}
+}
+
+func lsIcon(entry *archive.DirEntry) string {
+	if entry.IsDir {
+		return lsIconDir
+	}
+	if icon, ok := lsIcons[strings.ToLower(path.Ext(entry.Name))]; ok {
+		return icon
+	}
+	if entry.Mode&0o111 != 0 {
+		return lsIconExec
+	}
+	return lsIconFile
+}
+
+// lsMode returns the formatted mode of entry, with each permission
+// character colored when color is enabled.
+func lsMode(entry *archive.DirEntry, color bool) string {
+	mode := archive.FormatMode(entry.Mode, entry.IsDir)
+	if !color {
+		return mode
+	}
+	var b strings.Builder
+	for _, c := range mode {
+		var style string
+		switch c {
+		case 'd':
+			style = ansiDir
+		case 'r':
+			style = ansiRead
+		case 'w':
+			style = ansiWrite
+		case 'x', 's', 't':
+			style = ansiExec
+		case '-':
+			style = ansiNone
+		}
+		if style == "" {
+			b.WriteRune(c)
+			continue
+		}
+		b.WriteString(style)
+		b.WriteRune(c)
+		b.WriteString(ansiReset)
+	}
+	return b.String()
+}
+
+// printLsGrid prints names in columns that fit the terminal width, filled
+// top to bottom like ls.
+func printLsGrid(entries []*archive.DirEntry, flags lsFlags) {
+	names := make([]string, len(entries))
+	widths := make([]int, len(entries))
+	for i, entry := range entries {
+		names[i] = lsName(entry, flags)
+		widths[i] = lipgloss.Width(names[i])
+	}
+
+	rows, colWidths := lsGrid(widths, flags.width)
+	var b strings.Builder
+	for r := range rows {
+		for c, colWidth := range colWidths {
+			i := c*rows + r
+			if i >= len(names) {
+				break
+			}
+			b.WriteString(names[i])
+			if c < len(colWidths)-1 && i+rows < len(names) {
+				b.WriteString(strings.Repeat(" ", colWidth-widths[i]+lsGridGap))
+			}
+		}
+		b.WriteByte('\n')
+	}
+	fmt.Print(b.String())
+}
+
+// lsGrid returns the fewest rows, and the resulting column widths, that fit
+// cells of the given widths into width columns when filled top to bottom.
+// If nothing fits, every cell gets its own row.
+func lsGrid(widths []int, width int) (rows int, colWidths []int) {
+	n := len(widths)
+	if n == 0 {
+		return 0, nil
+	}
+	for rows = 1; rows < n; rows++ {
+		cols := (n + rows - 1) / rows
+		colWidths = make([]int, cols)
+		total := lsGridGap * (cols - 1)
+		for c := range cols {
+			for _, w := range widths[c*rows : min(n, (c+1)*rows)] {
+				colWidths[c] = max(colWidths[c], w)
+			}
+			total += colWidths[c]
+		}
+		if total <= width {
+			return rows, colWidths
+		}
+	}
+	return n, []int{slices.Max(widths)}
+}
@@ -5,20 +5,20 @@ import (
 	"context"
 	"encoding/json"
 	"io/fs"
+	"math"
 	"os"
 	"testing"
 	"time"
 
-	"github.com/spf13/viper"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
 )
 
 func TestLsCmd_NilConfig(t *testing.T) {
-	viper.Reset()
-
 	ctx := context.Background()
 
 	lsCmd.SetContext(ctx)
@@ -165,7 +165,7 @@ func TestLsJSON(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err := lsJSON("ghcr.io/test:v1", "/", entries, flags)
+	err := lsJSON("ghcr.io/test:v1", "/", entries, len(entries), flags, verificationStatus{})
 
 	w.Close()
 	os.Stdout = oldStdout
@@ -181,6 +181,7 @@ func TestLsJSON(t *testing.T) {
 
 	assert.Equal(t, "ghcr.io/test:v1", got.Ref)
 	assert.Equal(t, "/", got.Path)
+	assert.Equal(t, 2, got.Total)
 	require.Len(t, got.Entries, 2)
 
 	// Check directory entry
@@ -246,3 +247,233 @@ func TestFormatEntryDigest(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLsFlags_Filters(t *testing.T) {
+	t.Cleanup(func() {
+		_ = lsCmd.Flags().Set("dirs-only", "false")
+		_ = lsCmd.Flags().Set("files-only", "false")
+		_ = lsCmd.Flags().Set("max-depth", "1")
+	})
+
+	require.NoError(t, lsCmd.Flags().Set("files-only", "true"))
+	require.NoError(t, lsCmd.Flags().Set("max-depth", "3"))
+
+	flags, err := parseLsFlags(lsCmd)
+	require.NoError(t, err)
+	assert.True(t, flags.filesOnly)
+	assert.False(t, flags.dirsOnly)
+	assert.Equal(t, 3, flags.maxDepth)
+
+	require.NoError(t, lsCmd.Flags().Set("dirs-only", "true"))
+	_, err = parseLsFlags(lsCmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mutually exclusive")
+
+	require.NoError(t, lsCmd.Flags().Set("dirs-only", "false"))
+	require.NoError(t, lsCmd.Flags().Set("max-depth", "0"))
+	_, err = parseLsFlags(lsCmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max-depth")
+}
+
+func TestLsNDJSON(t *testing.T) {
+	entries := []*archive.DirEntry{
+		{Name: "config", Path: "config", IsDir: true},
+		{Name: "file.txt", Path: "file.txt", Size: 10},
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := lsNDJSON(entries, lsFlags{})
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var first lsEntryJSON
+	require.NoError(t, json.Unmarshal(lines[0], &first))
+	assert.Equal(t, "config", first.Name)
+	assert.True(t, first.IsDir)
+
+	var second lsEntryJSON
+	require.NoError(t, json.Unmarshal(lines[1], &second))
+	assert.Equal(t, "file.txt", second.Name)
+}
+
+func TestParsePageFlags(t *testing.T) {
+	t.Cleanup(func() {
+		_ = lsCmd.Flags().Set("limit", "0")
+		_ = lsCmd.Flags().Set("offset", "0")
+	})
+
+	require.NoError(t, lsCmd.Flags().Set("limit", "10"))
+	require.NoError(t, lsCmd.Flags().Set("offset", "20"))
+	limit, offset, err := parsePageFlags(lsCmd)
+	require.NoError(t, err)
+	assert.Equal(t, 10, limit)
+	assert.Equal(t, 20, offset)
+
+	require.NoError(t, lsCmd.Flags().Set("offset", "-1"))
+	_, _, err = parsePageFlags(lsCmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--offset")
+}
+
+func TestLsText_Grid(t *testing.T) {
+	entries := []*archive.DirEntry{
+		{Name: "alpha", IsDir: true},
+		{Name: "beta.txt"},
+		{Name: "c"},
+		{Name: "delta.json"},
+	}
+	flags := lsFlags{width: 30}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := lsText(entries, flags)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	require.NoError(t, err)
+	assert.Equal(t, "alpha/    c\nbeta.txt  delta.json\n", buf.String())
+}
+
+func TestLsGrid(t *testing.T) {
+	rows, colWidths := lsGrid([]int{3, 3, 3}, 80)
+	assert.Equal(t, 1, rows)
+	assert.Equal(t, []int{3, 3, 3}, colWidths)
+
+	rows, colWidths = lsGrid([]int{6, 8, 1, 10}, 20)
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, []int{8, 10}, colWidths)
+
+	rows, colWidths = lsGrid([]int{30, 5}, 20)
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, []int{30}, colWidths)
+}
+
+func TestLsName(t *testing.T) {
+	dir := &archive.DirEntry{Name: "etc", IsDir: true, Mode: fs.ModeDir | 0o755}
+	exe := &archive.DirEntry{Name: "tool", Mode: 0o755}
+	file := &archive.DirEntry{Name: "app.json", Mode: 0o644}
+
+	assert.Equal(t, "etc/", lsName(dir, lsFlags{}))
+	assert.Equal(t, ansiDir+"etc/"+ansiReset, lsName(dir, lsFlags{color: true}))
+	assert.Equal(t, ansiExec+"tool"+ansiReset, lsName(exe, lsFlags{color: true}))
+	assert.Equal(t, "app.json", lsName(file, lsFlags{color: true}))
+	assert.Equal(t, lsIcons[".json"]+" app.json", lsName(file, lsFlags{icons: true}))
+	assert.Equal(t, lsIconDir+" etc/", lsName(dir, lsFlags{icons: true}))
+}
+
+func TestLsMode(t *testing.T) {
+	entry := &archive.DirEntry{Name: "tool", Mode: 0o750}
+	assert.Equal(t, "-rwxr-x---", lsMode(entry, false))
+
+	colored := lsMode(entry, true)
+	assert.Contains(t, colored, ansiWrite+"w"+ansiReset)
+	assert.Contains(t, colored, ansiExec+"x"+ansiReset)
+	assert.Equal(t, 10, lipgloss.Width(colored))
+}
+
+func TestResolveLsDisplay(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Cleanup(func() {
+		_ = lsCmd.Flags().Set("icons", "false")
+		_ = lsCmd.Flags().Set("dirs-first", "true")
+		_ = lsCmd.Flags().Set("all", "false")
+		lsCmd.Flags().Lookup("icons").Changed = false
+		lsCmd.Flags().Lookup("dirs-first").Changed = false
+		lsCmd.Flags().Lookup("all").Changed = false
+	})
+
+	cfg := &internalcfg.Config{Ls: internalcfg.LsConfig{Color: internalcfg.ColorAlways, Icons: true, ShowHidden: true}}
+	flags, err := parseLsFlags(lsCmd)
+	require.NoError(t, err)
+	resolveLsDisplay(lsCmd, cfg, &flags)
+	assert.True(t, flags.icons)
+	assert.False(t, flags.dirsFirst)
+	assert.True(t, flags.all)
+	assert.True(t, flags.color)
+
+	require.NoError(t, lsCmd.Flags().Set("icons", "false"))
+	require.NoError(t, lsCmd.Flags().Set("dirs-first", "true"))
+	require.NoError(t, lsCmd.Flags().Set("all", "false"))
+	cfg.NoColor = true
+	flags, err = parseLsFlags(lsCmd)
+	require.NoError(t, err)
+	resolveLsDisplay(lsCmd, cfg, &flags)
+	assert.False(t, flags.icons)
+	assert.True(t, flags.dirsFirst)
+	assert.False(t, flags.all)
+	assert.False(t, flags.color)
+}
+
+func TestLsTabular(t *testing.T) {
+	modTime := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+	entries := []*archive.DirEntry{
+		{Name: "etc", Path: "etc", IsDir: true, Mode: fs.ModeDir | 0o755},
+		{Name: "etc/app,prod.yaml", Path: "etc/app,prod.yaml", Mode: 0o644, Size: 42, ModTime: modTime, Hash: []byte{0xab, 0xcd}},
+	}
+
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{
+			output: "csv",
+			want: "path,size,mode,mtime,digest\n" +
+				"etc,,drwxr-xr-x,,\n" +
+				"\"etc/app,prod.yaml\",42,-rw-r--r--,2026-01-15T10:30:00Z,sha256:abcd\n",
+		},
+		{
+			output: "tsv",
+			want: "path\tsize\tmode\tmtime\tdigest\n" +
+				"etc\t\tdrwxr-xr-x\t\t\n" +
+				"etc/app,prod.yaml\t42\t-rw-r--r--\t2026-01-15T10:30:00Z\tsha256:abcd\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err := lsTabular(entries, tt.output)
+
+			w.Close()
+			os.Stdout = oldStdout
+
+			var buf bytes.Buffer
+			buf.ReadFrom(r)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestParseLsFlags_Recursive(t *testing.T) {
+	t.Cleanup(func() {
+		_ = lsCmd.Flags().Set("recursive", "false")
+	})
+
+	require.NoError(t, lsCmd.Flags().Set("recursive", "true"))
+	flags, err := parseLsFlags(lsCmd)
+	require.NoError(t, err)
+	assert.Equal(t, math.MaxInt, flags.maxDepth)
+}
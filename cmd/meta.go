@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+var metaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Read and write archive metadata",
+	Long: `Read and write archive metadata.
+
+Archive metadata is stored in the archive itself at
+/.blob/metadata.yaml and describes the archive for people and
+catalog tooling:
+
+  schema_version: 1
+  description: Shared service configuration
+  owners:
+    - platform-team
+  links:
+    - name: Runbook
+      url: https://wiki.example.com/configs
+
+Use "meta set" on a source directory before pushing, and "meta get"
+to read the metadata back from a pushed archive. The metadata is
+validated during push and included in "inspect --output json".
+
+Fields: schema_version (read-only), description, owners
+(comma-separated), links.<name>`,
+}
+
+var metaGetCmd = &cobra.Command{
+	Use:   "get <ref> [field]",
+	Short: "Show metadata from an archive",
+	Long: `Show metadata from an archive.
+
+Reads /.blob/metadata.yaml from the archive using range requests.
+With a field argument, prints only that field's value.`,
+	Example: `  blob meta get ghcr.io/acme/configs:v1.0.0
+  blob meta get ghcr.io/acme/configs:v1.0.0 owners
+  blob meta get --output json ghcr.io/acme/configs:v1.0.0`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runMetaGet,
+}
+
+var metaSetCmd = &cobra.Command{
+	Use:   "set <dir> <field=value>...",
+	Short: "Set metadata in a source directory",
+	Long: `Set metadata in a source directory.
+
+Creates or updates <dir>/.blob/metadata.yaml so that the next push
+includes it. An empty value clears a field or removes a link.`,
+	Example: `  blob meta set ./config description="Shared service configuration"
+  blob meta set ./config owners=platform-team,alice@example.com
+  blob meta set ./config links.Runbook=https://wiki.example.com/configs`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runMetaSet,
+}
+
+func init() {
+	metaCmd.AddCommand(metaGetCmd)
+	metaCmd.AddCommand(metaSetCmd)
+}
+
+// metaGetResult contains the result of a meta get operation.
+type metaGetResult struct {
+	Ref         string            `json:"ref"`
+	ResolvedRef string            `json:"resolved_ref,omitempty"`
+	Field       string            `json:"field,omitempty"`
+	Value       *string           `json:"value,omitempty"`
+	Metadata    *archive.Metadata `json:"metadata,omitempty"`
+}
+
+// metaSetResult contains the result of a meta set operation.
+type metaSetResult struct {
+	Path     string            `json:"path"`
+	Created  bool              `json:"created"`
+	Metadata *archive.Metadata `json:"metadata"`
+}
+
+func runMetaGet(cmd *cobra.Command, args []string) error {
+	// 1. Get config from context
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	// 2. Parse arguments
+	inputRef := args[0]
+	var field string
+	if len(args) > 1 {
+		field = args[1]
+	}
+
+	// 3. Resolve alias
+	resolvedRef, err := resolveRef(cmd.Context(), cfg, inputRef)
+	if err != nil {
+		return err
+	}
+
+	// 4. Create client and pull archive (lazy - only the index is fetched)
+	client, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+
+	blobArchive, err := client.Pull(cmd.Context(), resolvedRef)
+	if err != nil {
+		return fmt.Errorf("accessing archive %s: %w", resolvedRef, err)
+	}
+
+	// 5. Read metadata manifest
+	data, err := blobArchive.ReadFile(archive.MetadataPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("archive %s has no metadata (%s)", inputRef, archive.MetadataPath)
+		}
+		return fmt.Errorf("reading metadata: %w", err)
+	}
+	metadata, err := archive.ParseMetadata(data)
+	if err != nil {
+		return err
+	}
+
+	// 6. Build result
+	result := metaGetResult{
+		Ref:      inputRef,
+		Metadata: metadata,
+	}
+	if inputRef != resolvedRef {
+		result.ResolvedRef = resolvedRef
+	}
+	if field != "" {
+		value, err := metadata.Get(field)
+		if err != nil {
+			return err
+		}
+		result.Field = field
+		result.Value = &value
+		result.Metadata = nil
+	}
+
+	return outputMetaGetResult(cfg, &result)
+}
+
+func runMetaSet(cmd *cobra.Command, args []string) error {
+	// 1. Get config from context
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	// 2. Parse arguments
+	dir := args[0]
+	if err := validateSourcePath(dir); err != nil {
+		return err
+	}
+	assignments, err := parseMetaAssignments(args[1:])
+	if err != nil {
+		return err
+	}
+
+	// 3. Load existing metadata (if any)
+	created := false
+	metadata, err := archive.ReadMetadataFile(dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		metadata = &archive.Metadata{}
+		created = true
+	}
+
+	// 4. Apply assignments in order
+	for _, a := range assignments {
+		if err := metadata.Set(a[0], a[1]); err != nil {
+			return err
+		}
+	}
+
+	// 5. Write metadata back
+	if err := archive.WriteMetadataFile(dir, metadata); err != nil {
+		return err
+	}
+
+	result := metaSetResult{
+		Path:     dir + "/" + archive.MetadataPath,
+		Created:  created,
+		Metadata: metadata,
+	}
+	return outputMetaSetResult(cfg, &result)
+}
+
+// parseMetaAssignments parses field=value arguments, preserving order.
+func parseMetaAssignments(args []string) ([][2]string, error) {
+	result := make([][2]string, 0, len(args))
+	for _, arg := range args {
+		field, value, ok := strings.Cut(arg, "=")
+		if !ok || field == "" {
+			return nil, fmt.Errorf("invalid assignment %q: must be field=value", arg)
+		}
+		result = append(result, [2]string{field, value})
+	}
+	return result, nil
+}
+
+// outputMetaGetResult formats and outputs the meta get result.
+func outputMetaGetResult(cfg *internalcfg.Config, result *metaGetResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		return metaJSON(result)
+	}
+	return metaGetText(result)
+}
+
+// outputMetaSetResult formats and outputs the meta set result.
+func outputMetaSetResult(cfg *internalcfg.Config, result *metaSetResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		return metaJSON(result)
+	}
+	return metaSetText(result)
+}
+
+func metaJSON(result any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+func metaGetText(result *metaGetResult) error {
+	if result.Value != nil {
+		fmt.Println(*result.Value)
+		return nil
+	}
+	printMetadata(result.Metadata)
+	return nil
+}
+
+func metaSetText(result *metaSetResult) error {
+	if result.Created {
+		fmt.Printf("Created %s\n", result.Path)
+	} else {
+		fmt.Printf("Updated %s\n", result.Path)
+	}
+	return nil
+}
+
+// printMetadata prints metadata fields in aligned text form.
+func printMetadata(m *archive.Metadata) {
+	fmt.Printf("Schema:       %d\n", m.SchemaVersion)
+	if m.Description != "" {
+		fmt.Printf("Description:  %s\n", m.Description)
+	}
+	if len(m.Owners) > 0 {
+		fmt.Printf("Owners:       %s\n", strings.Join(m.Owners, ", "))
+	}
+	if len(m.Links) > 0 {
+		fmt.Println("Links:")
+		for _, link := range m.Links {
+			fmt.Printf("  %s: %s\n", link.Name, link.URL)
+		}
+	}
+}
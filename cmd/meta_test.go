@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestMetaGetCmd_NilConfig(t *testing.T) {
+	metaGetCmd.SetContext(context.Background())
+	err := metaGetCmd.RunE(metaGetCmd, []string{"ghcr.io/test:v1"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration not loaded")
+}
+
+func TestMetaSetCmd_NilConfig(t *testing.T) {
+	metaSetCmd.SetContext(context.Background())
+	err := metaSetCmd.RunE(metaSetCmd, []string{t.TempDir(), "description=x"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration not loaded")
+}
+
+func TestParseMetaAssignments(t *testing.T) {
+	got, err := parseMetaAssignments([]string{"description=a=b", "owners="})
+	require.NoError(t, err)
+	assert.Equal(t, [][2]string{{"description", "a=b"}, {"owners", ""}}, got)
+
+	_, err = parseMetaAssignments([]string{"description"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be field=value")
+
+	_, err = parseMetaAssignments([]string{"=value"})
+	require.Error(t, err)
+}
+
+func TestMetaSetCmd_CreatesAndUpdates(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &internalcfg.Config{Quiet: true}
+	metaSetCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	err := metaSetCmd.RunE(metaSetCmd, []string{dir, "description=Configs", "owners=a, b"})
+	require.NoError(t, err)
+
+	err = metaSetCmd.RunE(metaSetCmd, []string{dir, "links.Docs=https://example.com"})
+	require.NoError(t, err)
+
+	m, err := archive.ReadMetadataFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, archive.MetadataSchemaVersion, m.SchemaVersion)
+	assert.Equal(t, "Configs", m.Description)
+	assert.Equal(t, []string{"a", "b"}, m.Owners)
+	assert.Equal(t, []archive.Link{{Name: "Docs", URL: "https://example.com"}}, m.Links)
+}
+
+func TestMetaSetCmd_UnknownField(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &internalcfg.Config{Quiet: true}
+	metaSetCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	err := metaSetCmd.RunE(metaSetCmd, []string{dir, "color=blue"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown metadata field")
+
+	_, statErr := os.Stat(filepath.Join(dir, ".blob", "metadata.yaml"))
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestMetaGetText(t *testing.T) {
+	result := &metaGetResult{
+		Ref: "ghcr.io/test:v1",
+		Metadata: &archive.Metadata{
+			SchemaVersion: 1,
+			Description:   "Configs",
+			Owners:        []string{"a", "b"},
+			Links:         []archive.Link{{Name: "Docs", URL: "https://example.com"}},
+		},
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := metaGetText(result)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	require.NoError(t, err)
+	got := buf.String()
+	assert.Contains(t, got, "Schema:       1")
+	assert.Contains(t, got, "Description:  Configs")
+	assert.Contains(t, got, "Owners:       a, b")
+	assert.Contains(t, got, "Docs: https://example.com")
+}
+
+func TestMetaGetJSON_Field(t *testing.T) {
+	value := "a,b"
+	result := &metaGetResult{
+		Ref:   "ghcr.io/test:v1",
+		Field: "owners",
+		Value: &value,
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := metaJSON(result)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "owners", got["field"])
+	assert.Equal(t, "a,b", got["value"])
+	assert.NotContains(t, got, "metadata")
+}
@@ -0,0 +1,443 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/meigma/blob"
+	"github.com/meigma/blob/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/file"
+	"oras.land/oras-go/v2/registry/remote"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/identity"
+)
+
+// annotationMigratedFrom records the artifact an archive was migrated from.
+const annotationMigratedFrom = "io.meigma.blob.migrated-from"
+
+// maxMigrateManifestSize bounds the source manifest read by migrate.
+const maxMigrateManifestSize = 4 << 20
+
+// Legacy artifact layouts migrate converts.
+const (
+	migrateFormatTar   = "tar"   // one or more tar layers, unpacked in order
+	migrateFormatFiles = "files" // ORAS-style layers named by their title annotation
+)
+
+// tarLayerMediaTypes are the layer media types holding a tar stream,
+// plain or gzip-compressed.
+var tarLayerMediaTypes = map[string]bool{
+	ocispec.MediaTypeImageLayer:                                    true,
+	ocispec.MediaTypeImageLayerGzip:                                true,
+	"application/vnd.docker.image.rootfs.diff.tar.gzip":            true,
+	"application/vnd.oci.image.layer.nondistributable.v1.tar":      true,
+	"application/vnd.oci.image.layer.nondistributable.v1.tar+gzip": true,
+	"application/x-tar":                                            true,
+	"application/tar":                                              true,
+	"application/gzip":                                             true,
+	"application/x-gzip":                                           true,
+	"application/tar+gzip":                                         true,
+}
+
+// imageConfigMediaTypes mark container images, which are not migrated.
+var imageConfigMediaTypes = map[string]bool{
+	ocispec.MediaTypeImageConfig:                     true,
+	"application/vnd.docker.container.image.v1+json": true,
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate <ref> [dest-ref]",
+	Short: "Convert a tar or ORAS artifact into a blob archive",
+	Long: `Convert a tar or ORAS artifact into a blob archive.
+
+Reads an artifact pushed by other tools and pushes its files as a blob
+archive, so existing repositories can move to random-access archives.
+Two layouts are recognized:
+
+  tar    layers holding a tar stream, plain or gzip-compressed, such as
+         a directory pushed with "oras push" or an image-style layer.
+         Several layers are unpacked in order, later files replacing
+         earlier ones.
+  files  layers named by the org.opencontainers.image.title annotation,
+         as "oras push" and "cosign upload blob" store single files.
+         Layers ORAS marks for unpacking hold a tarred directory.
+
+Container images are refused, as are artifacts that are already blob
+archives.
+
+The archive replaces the artifact at ref unless dest-ref is given; the
+old manifest stays in the registry by digest. A dest-ref without a
+registry or repository (e.g. "v1.0.0-blob") is a tag in the source
+repository. The manifest annotations are carried over, except the
+creation time, and io.meigma.blob.migrated-from records the source
+digest. Tar entries may not leave the archive root, and links and
+special files are rejected.
+
+Commands listed under hooks.pre_push run before the archive is pushed,
+unless --no-hooks is set. With --sign, the new archive is signed.`,
+	Example: `  blob migrate ghcr.io/acme/configs:v1.0.0
+  blob migrate ghcr.io/acme/configs:v1.0.0 v1.0.0-blob
+  blob migrate --sign ghcr.io/acme/configs:v1.0.0 ghcr.io/acme/configs-blob:v1.0.0`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runMigrate,
+}
+
+func init() {
+	migrateCmd.Flags().Bool("sign", false, "sign the migrated archive")
+	migrateCmd.Flags().String("key", "", "sign with a private key file, KMS key URI, or PKCS#11 URI instead of keyless")
+	migrateCmd.Flags().Bool("no-hooks", false, "skip pre_push hooks from config")
+	addIdentityFlags(migrateCmd)
+}
+
+// migrateResult contains the result of a migrate operation.
+type migrateResult struct {
+	Source          string `json:"source"`
+	SourceDigest    string `json:"source_digest"`
+	Format          string `json:"format"`
+	Ref             string `json:"ref"`
+	FileCount       int    `json:"file_count"`
+	TotalSize       uint64 `json:"total_size"`
+	SizeHuman       string `json:"size_human,omitempty"`
+	Signed          bool   `json:"signed,omitempty"`
+	SignatureDigest string `json:"signature_digest,omitempty"`
+}
+
+// migrateFlags holds the parsed command flags.
+type migrateFlags struct {
+	sign     bool
+	keyPath  string
+	noHooks  bool
+	identity identity.Options
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	flags, err := parseMigrateFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	srcRef, err := resolveRef(ctx, cfg, args[0])
+	if err != nil {
+		return err
+	}
+	destRef := srcRef
+	if len(args) > 1 {
+		destRef, err = resolvePromoteTarget(cfg, srcRef, args[1])
+		if err != nil {
+			return err
+		}
+	} else if strings.Contains(srcRef, "@") {
+		return errors.New("give a dest-ref to migrate an artifact referenced by digest")
+	}
+
+	repository, err := newRemoteRepository(cfg, repositoryOf(srcRef))
+	if err != nil {
+		return err
+	}
+	desc, manifest, err := fetchMigrateManifest(ctx, repository, srcRef)
+	if err != nil {
+		return err
+	}
+	format, err := legacyFormat(manifest)
+	if err != nil {
+		return fmt.Errorf("cannot migrate %s: %w", srcRef, err)
+	}
+
+	workDir, err := os.MkdirTemp("", "blob-migrate-")
+	if err != nil {
+		return fmt.Errorf("creating work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	for _, layer := range manifest.Layers {
+		if err := unpackLegacyLayer(ctx, repository, layer, format, workDir); err != nil {
+			return err
+		}
+	}
+	stats, err := migrateDirStats(workDir)
+	if err != nil {
+		return err
+	}
+	if stats.FileCount == 0 {
+		return fmt.Errorf("cannot migrate %s: the artifact holds no files", srcRef)
+	}
+
+	if err := validateSourceMetadata(workDir); err != nil {
+		return err
+	}
+	if !flags.noHooks {
+		if err := runPrePushHooks(ctx, cfg, destRef, workDir); err != nil {
+			return err
+		}
+	}
+
+	annotations := make(map[string]string, len(manifest.Annotations)+1)
+	for k, v := range manifest.Annotations {
+		if k != ocispec.AnnotationCreated {
+			annotations[k] = v
+		}
+	}
+	annotations[annotationMigratedFrom] = repositoryOf(srcRef) + "@" + desc.Digest.String()
+
+	compression, err := mapCompression(cfg.Compression)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	pushOpts := buildPushOptions(pushFlags{
+		compression:    compression,
+		skipCompressed: true,
+		annotations:    annotations,
+	}, &cfg.Push)
+	if err := client.Push(ctx, destRef, workDir, pushOpts...); err != nil {
+		return fmt.Errorf("pushing archive: %w", err)
+	}
+
+	result := migrateResult{
+		Source:       args[0],
+		SourceDigest: desc.Digest.String(),
+		Format:       format,
+		Ref:          destRef,
+		FileCount:    stats.FileCount,
+		TotalSize:    stats.TotalBytes,
+		SizeHuman:    archive.FormatSize(stats.TotalBytes),
+	}
+
+	if flags.sign {
+		signer, err := buildSigner(ctx, signFlags{keyPath: flags.keyPath, identity: flags.identity})
+		if err != nil {
+			return fmt.Errorf("creating signer: %w", err)
+		}
+		sigDigest, err := client.Sign(ctx, destRef, signer)
+		if err != nil {
+			return fmt.Errorf("signing migrated archive: %w", err)
+		}
+		result.Signed = true
+		result.SignatureDigest = sigDigest
+	}
+
+	return outputMigrateResult(cfg, &result)
+}
+
+// parseMigrateFlags extracts and validates flags from the command.
+func parseMigrateFlags(cmd *cobra.Command) (migrateFlags, error) {
+	var flags migrateFlags
+	var err error
+
+	flags.sign, err = cmd.Flags().GetBool("sign")
+	if err != nil {
+		return flags, fmt.Errorf("reading sign flag: %w", err)
+	}
+
+	flags.keyPath, err = cmd.Flags().GetString("key")
+	if err != nil {
+		return flags, fmt.Errorf("reading key flag: %w", err)
+	}
+
+	flags.noHooks, err = cmd.Flags().GetBool("no-hooks")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-hooks flag: %w", err)
+	}
+
+	flags.identity, err = parseIdentityFlags(cmd)
+	if err != nil {
+		return flags, err
+	}
+
+	return flags, nil
+}
+
+// fetchMigrateManifest resolves ref and reads its image manifest.
+func fetchMigrateManifest(ctx context.Context, repository *remote.Repository, ref string) (ocispec.Descriptor, *ocispec.Manifest, error) {
+	reference := strings.TrimPrefix(ref, repositoryOf(ref))
+	reference = strings.TrimLeft(reference, ":@")
+	desc, err := repository.Resolve(ctx, reference)
+	if err != nil {
+		return desc, nil, archiveError(fmt.Errorf("resolving %s: %w", ref, err))
+	}
+	if desc.MediaType != ocispec.MediaTypeImageManifest {
+		return desc, nil, fmt.Errorf("cannot migrate %s: %s is not an image manifest", ref, desc.MediaType)
+	}
+	if desc.Size > maxMigrateManifestSize {
+		return desc, nil, fmt.Errorf("manifest of %s is too large (%d bytes)", ref, desc.Size)
+	}
+	data, err := content.FetchAll(ctx, repository.Manifests(), desc)
+	if err != nil {
+		return desc, nil, fmt.Errorf("fetching manifest of %s: %w", ref, err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return desc, nil, fmt.Errorf("parsing manifest of %s: %w", ref, err)
+	}
+	return desc, &manifest, nil
+}
+
+// legacyFormat reports the layout of the legacy artifact manifest, or why
+// it cannot be migrated.
+func legacyFormat(manifest *ocispec.Manifest) (string, error) {
+	switch {
+	case manifest.ArtifactType == registry.ArtifactType:
+		return "", errors.New("already a blob archive")
+	case imageConfigMediaTypes[manifest.Config.MediaType]:
+		return "", errors.New("a container image, not an artifact")
+	case len(manifest.Layers) == 0:
+		return "", errors.New("the artifact has no layers")
+	}
+
+	titled := 0
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == registry.MediaTypeIndex || layer.MediaType == registry.MediaTypeData {
+			return "", errors.New("already a blob archive")
+		}
+		if layer.Annotations[ocispec.AnnotationTitle] != "" {
+			titled++
+			continue
+		}
+		if !tarLayerMediaTypes[layer.MediaType] {
+			return "", fmt.Errorf("layer %s (%s) is neither a tar stream nor a named file", layer.Digest, layer.MediaType)
+		}
+	}
+	switch titled {
+	case len(manifest.Layers):
+		return migrateFormatFiles, nil
+	case 0:
+		return migrateFormatTar, nil
+	default:
+		return "", errors.New("the artifact mixes named files with unnamed tar layers")
+	}
+}
+
+// unpackLegacyLayer writes the files of layer into dir: a tar layer is
+// unpacked, and a named file layer is written to its title, or unpacked
+// into a directory of that name if ORAS marked it for unpacking.
+func unpackLegacyLayer(ctx context.Context, repository *remote.Repository, layer ocispec.Descriptor, format, dir string) error {
+	rc, err := repository.Blobs().Fetch(ctx, layer)
+	if err != nil {
+		return fmt.Errorf("fetching layer %s: %w", layer.Digest, err)
+	}
+	defer rc.Close()
+	vr := content.NewVerifyReader(rc, layer)
+
+	switch {
+	case format == migrateFormatTar:
+		if _, err := archive.ExtractTar(vr, dir); err != nil {
+			return fmt.Errorf("unpacking layer %s: %w", layer.Digest, err)
+		}
+	case layer.Annotations[file.AnnotationUnpack] == "true":
+		target, err := legacyLayerPath(dir, layer)
+		if err != nil {
+			return err
+		}
+		if err := ensureDir(target); err != nil {
+			return err
+		}
+		if _, err := archive.ExtractTar(vr, target); err != nil {
+			return fmt.Errorf("unpacking layer %s: %w", layer.Digest, err)
+		}
+	default:
+		target, err := legacyLayerPath(dir, layer)
+		if err != nil {
+			return err
+		}
+		if err := writeLegacyFile(target, vr); err != nil {
+			return fmt.Errorf("writing layer %s: %w", layer.Digest, err)
+		}
+	}
+
+	// Tar readers stop at the end-of-archive marker, before any padding.
+	if _, err := io.Copy(io.Discard, vr); err != nil {
+		return fmt.Errorf("reading layer %s: %w", layer.Digest, err)
+	}
+	if err := vr.Verify(); err != nil {
+		return fmt.Errorf("verifying layer %s: %w", layer.Digest, err)
+	}
+	return nil
+}
+
+// legacyLayerPath returns where the layer named by its title annotation is
+// written in dir. Titles may not leave dir.
+func legacyLayerPath(dir string, layer ocispec.Descriptor) (string, error) {
+	title := layer.Annotations[ocispec.AnnotationTitle]
+	clean := path.Clean(filepath.ToSlash(title))
+	if path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("layer %s: file name %q escapes the archive root", layer.Digest, title)
+	}
+	return filepath.Join(dir, filepath.FromSlash(clean)), nil
+}
+
+// writeLegacyFile writes r to the new file target.
+func writeLegacyFile(target string, r io.Reader) error {
+	if err := ensureDir(filepath.Dir(target)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644) //nolint:gosec // target is inside the work directory
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// migrateDirStats counts the files in dir and their total size.
+func migrateDirStats(dir string) (blob.CopyStats, error) {
+	var stats blob.CopyStats
+	err := filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		stats.FileCount++
+		stats.TotalBytes += uint64(info.Size()) //nolint:gosec // file sizes are non-negative
+		return nil
+	})
+	return stats, err
+}
+
+// outputMigrateResult formats and outputs the migrate result.
+func outputMigrateResult(cfg *internalcfg.Config, result *migrateResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	fmt.Printf("Migrated %s (%s)\n", result.Source, result.Format)
+	fmt.Printf("  Source: %s\n", result.SourceDigest)
+	fmt.Printf("  Archive: %s\n", result.Ref)
+	fmt.Printf("  Files: %d\n", result.FileCount)
+	fmt.Printf("  Size: %s\n", result.SizeHuman)
+	if result.Signed {
+		fmt.Printf("  Signed: %s\n", result.SignatureDigest)
+	}
+	return nil
+}
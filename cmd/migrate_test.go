@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/meigma/blob/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/v2/content/file"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// gzipTar returns a gzip-compressed tar stream of files.
+func gzipTar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func titled(desc ocispec.Descriptor, title string, unpack bool) ocispec.Descriptor {
+	desc.Annotations = map[string]string{ocispec.AnnotationTitle: title}
+	if unpack {
+		desc.Annotations[file.AnnotationUnpack] = "true"
+	}
+	return desc
+}
+
+func runMigrateForTest(t *testing.T, args ...string) error {
+	t.Helper()
+	cfg := newRegistryTestConfig(t)
+	migrateCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	return migrateCmd.RunE(migrateCmd, args)
+}
+
+// pullMigrated checks that the archive tagged tag is a blob archive
+// holding exactly files and returns its manifest annotations.
+func pullMigrated(t *testing.T, reg *testRegistry, tag string, files map[string]string) map[string]string {
+	t.Helper()
+	manifest := reg.manifest(t, tag)
+	assert.Equal(t, registry.ArtifactType, manifest.ArtifactType)
+	assert.Equal(t, files, pulledFiles(t, newRegistryTestConfig(t), reg.repo+":"+tag))
+	return manifest.Annotations
+}
+
+func TestMigrateCmd_NilConfig(t *testing.T) {
+	migrateCmd.SetContext(context.Background())
+	err := migrateCmd.RunE(migrateCmd, []string{"ghcr.io/acme/configs:v1"})
+	require.EqualError(t, err, "configuration not loaded")
+}
+
+func TestMigrateCmd_TarLayers(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	repo := reg.repo
+	src := reg.addWithLayers(t, "v1", nil, "",
+		reg.addBlob(ocispec.MediaTypeImageLayerGzip, gzipTar(t, map[string]string{
+			"config.json":  "old",
+			"etc/app.conf": "app",
+		})),
+		reg.addBlob(ocispec.MediaTypeImageLayerGzip, gzipTar(t, map[string]string{
+			"config.json": "new",
+		})))
+
+	require.NoError(t, runMigrateForTest(t, repo+":v1"))
+	annotations := pullMigrated(t, reg, "v1", map[string]string{
+		"config.json":  "new",
+		"etc/app.conf": "app",
+	})
+	assert.Equal(t, repo+"@"+src.Digest.String(), annotations[annotationMigratedFrom])
+
+	// The result is a blob archive, which is not migrated again
+	err := runMigrateForTest(t, repo+":v1")
+	require.ErrorContains(t, err, "already a blob archive")
+}
+
+func TestMigrateCmd_FileLayers(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	repo := reg.repo
+	reg.addWithLayers(t, "v1", nil, "application/vnd.acme.config",
+		titled(reg.addBlob("application/json", []byte(`{"a":1}`)), "config.json", false),
+		titled(reg.addBlob(ocispec.MediaTypeImageLayerGzip, gzipTar(t, map[string]string{
+			"app.conf": "app",
+		})), "etc", true))
+
+	// A bare tag is a destination in the source repository
+	require.NoError(t, runMigrateForTest(t, repo+":v1", "v1-blob"))
+	pullMigrated(t, reg, "v1-blob", map[string]string{
+		"config.json":  `{"a":1}`,
+		"etc/app.conf": "app",
+	})
+}
+
+func TestMigrateCmd_DigestNeedsDestination(t *testing.T) {
+	err := runMigrateForTest(t, "ghcr.io/acme/configs@sha256:"+strings.Repeat("a", 64))
+	require.ErrorContains(t, err, "give a dest-ref")
+}
+
+func TestLegacyFormat(t *testing.T) {
+	tarLayer := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayer}
+	fileLayer := ocispec.Descriptor{MediaType: "text/plain", Annotations: map[string]string{ocispec.AnnotationTitle: "a.txt"}}
+
+	tests := []struct {
+		name     string
+		manifest ocispec.Manifest
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "tar layers",
+			manifest: ocispec.Manifest{Config: ocispec.DescriptorEmptyJSON, Layers: []ocispec.Descriptor{tarLayer, tarLayer}},
+			want:     migrateFormatTar,
+		},
+		{
+			name:     "file layers",
+			manifest: ocispec.Manifest{Config: ocispec.DescriptorEmptyJSON, Layers: []ocispec.Descriptor{fileLayer}},
+			want:     migrateFormatFiles,
+		},
+		{
+			name:     "blob archive",
+			manifest: ocispec.Manifest{ArtifactType: registry.ArtifactType},
+			wantErr:  "already a blob archive",
+		},
+		{
+			name: "container image",
+			manifest: ocispec.Manifest{
+				Config: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageConfig},
+				Layers: []ocispec.Descriptor{tarLayer},
+			},
+			wantErr: "container image",
+		},
+		{
+			name:     "mixed layers",
+			manifest: ocispec.Manifest{Config: ocispec.DescriptorEmptyJSON, Layers: []ocispec.Descriptor{tarLayer, fileLayer}},
+			wantErr:  "mixes named files",
+		},
+		{
+			name: "unknown layer",
+			manifest: ocispec.Manifest{
+				Config: ocispec.DescriptorEmptyJSON,
+				Layers: []ocispec.Descriptor{{MediaType: "application/octet-stream"}},
+			},
+			wantErr: "neither a tar stream nor a named file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := legacyFormat(&tt.manifest)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLegacyLayerPath(t *testing.T) {
+	dir := t.TempDir()
+	_, err := legacyLayerPath(dir, titled(ocispec.Descriptor{}, "../escape", false))
+	require.ErrorContains(t, err, "escapes the archive root")
+	_, err = legacyLayerPath(dir, titled(ocispec.Descriptor{}, "/etc/passwd", false))
+	require.ErrorContains(t, err, "escapes the archive root")
+
+	p, err := legacyLayerPath(dir, titled(ocispec.Descriptor{}, "etc/./app.conf", false))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "etc", "app.conf"), p)
+}
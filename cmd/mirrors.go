@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// mirrorCooldown is how long a mirror that failed is skipped before it
+// is tried again.
+const mirrorCooldown = 30 * time.Second
+
+// dockerHubHost is the host Docker Hub's registry API is served from,
+// which requests for docker.io are sent to.
+const dockerHubHost = "registry-1.docker.io"
+
+// registryMirrorTransport returns base wrapped so reads from registries
+// with mirrors try the mirrors in the configured order. Requests to a
+// mirror go through base too, so they use the mirror's own connection
+// settings, and are authenticated to the mirror on their own: the
+// registry's credentials are never sent to it.
+func registryMirrorTransport(base http.RoundTripper, registries []internalcfg.RegistryAuth) (http.RoundTripper, error) {
+	hosts := make(map[string][]*mirrorHost)
+	for _, r := range registries {
+		if len(r.Mirrors) == 0 {
+			continue
+		}
+		order, err := mirrorOrder(base, r, registries)
+		if err != nil {
+			return nil, err
+		}
+		hosts[r.Host] = order
+		if r.Host == "docker.io" {
+			hosts[dockerHubHost] = order
+		}
+	}
+	if len(hosts) == 0 {
+		return base, nil
+	}
+	return &mirrorTransport{base: base, hosts: hosts, now: time.Now}, nil
+}
+
+// mirrorOrder returns the hosts to try for r: its mirrors in order, then
+// the registry itself unless it is listed among them. The registry is an
+// empty host, meaning the host the request was sent to.
+func mirrorOrder(base http.RoundTripper, r internalcfg.RegistryAuth, registries []internalcfg.RegistryAuth) ([]*mirrorHost, error) {
+	order := make([]*mirrorHost, 0, len(r.Mirrors)+1)
+	upstream := false
+	for _, m := range r.Mirrors {
+		if m == r.Host {
+			upstream = true
+			order = append(order, &mirrorHost{})
+			continue
+		}
+		cred, err := mirrorCredential(m, registries)
+		if err != nil {
+			return nil, err
+		}
+		order = append(order, &mirrorHost{host: m, client: newAuthClient(base, cred)})
+	}
+	if !upstream {
+		order = append(order, &mirrorHost{})
+	}
+	return order, nil
+}
+
+// mirrorCredential returns the credentials for a mirror: those configured
+// for it in the registries section, or else those in the Docker config.
+func mirrorCredential(host string, registries []internalcfg.RegistryAuth) (auth.CredentialFunc, error) {
+	for _, r := range registries {
+		if r.Host != host {
+			continue
+		}
+		c, err := resolveRegistryCredential(r, os.Getenv)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			return c.credential, nil
+		}
+	}
+	return func(ctx context.Context, hostport string) (auth.Credential, error) {
+		store, err := credentials.NewStoreFromDocker(credentials.StoreOptions{})
+		if err != nil {
+			return auth.EmptyCredential, nil //nolint:nilerr // no Docker config means no credentials
+		}
+		return store.Get(ctx, hostport)
+	}, nil
+}
+
+// mirrorHost is one host to try for a registry, with its health.
+type mirrorHost struct {
+	host   string
+	client *auth.Client // authenticates to the mirror; nil for the registry
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+// healthy reports whether the host has not failed within mirrorCooldown.
+func (m *mirrorHost) healthy(now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !now.Before(m.downUntil)
+}
+
+// roundTrip sends req to the host, or through base to the registry. A
+// request sent to a mirror loses the registry's credentials and is
+// authenticated by the mirror's own client.
+func (m *mirrorHost) roundTrip(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if m.host == "" {
+		return base.RoundTrip(req)
+	}
+	r := req.Clone(req.Context())
+	r.URL.Host = m.host
+	r.Host = ""
+	r.Header.Del("Authorization")
+	r.Header.Del("Cookie")
+	return m.client.Do(r)
+}
+
+// markDown skips the host until mirrorCooldown has passed.
+func (m *mirrorHost) markDown(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.downUntil = now.Add(mirrorCooldown)
+}
+
+// mirrorTransport sends reads from registries with mirrors to the first
+// healthy host that answers them, and all other requests through base.
+type mirrorTransport struct {
+	base  http.RoundTripper
+	hosts map[string][]*mirrorHost
+	now   func() time.Time
+}
+
+// orderFor returns the hosts to try for the registry u points at, matching
+// the host with its port first.
+func (t *mirrorTransport) orderFor(u *url.URL) []*mirrorHost {
+	if order, ok := t.hosts[u.Host]; ok {
+		return order
+	}
+	return t.hosts[u.Hostname()]
+}
+
+// RoundTrip implements http.RoundTripper. Only GET and HEAD requests are
+// sent to mirrors, since mirrors serve reads. A host that cannot be
+// reached or answers with a server error is marked down and the next one
+// is tried; one that does not have the content (404) or refuses access
+// (401, 403) is passed over without being marked down. The last host is always tried, so a request
+// is never refused only because every host was recently down.
+func (t *mirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	order := t.orderFor(req.URL)
+	if order == nil || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return t.base.RoundTrip(req)
+	}
+	for _, m := range order[:len(order)-1] {
+		if !m.healthy(t.now()) {
+			continue
+		}
+		resp, err := m.roundTrip(t.base, req)
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+		switch {
+		case err != nil:
+			m.markDown(t.now())
+		case resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests:
+			m.markDown(t.now())
+			discardResponse(resp)
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnauthorized ||
+			resp.StatusCode == http.StatusForbidden:
+			discardResponse(resp)
+		default:
+			return resp, nil
+		}
+	}
+	return order[len(order)-1].roundTrip(t.base, req)
+}
+
+// discardResponse drains and closes the body of a response that is not
+// used, so its connection can be reused.
+func discardResponse(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) //nolint:errcheck // best effort
+	resp.Body.Close()
+}
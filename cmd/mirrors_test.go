@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestRegistryMirrors(t *testing.T) {
+	var brokenHits atomic.Int64
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		brokenHits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(broken.Close)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "mirror") //nolint:errcheck // test
+	}))
+	t.Cleanup(mirror.Close)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "upstream "+r.Method) //nolint:errcheck // test
+	}))
+	t.Cleanup(upstream.Close)
+	brokenHost := broken.Listener.Addr().String()
+	mirrorHost := mirror.Listener.Addr().String()
+	upstreamHost := upstream.Listener.Addr().String()
+
+	now := time.Now()
+	client := &http.Client{}
+	install := func(registries ...internalcfg.RegistryAuth) {
+		rt, err := registryMirrorTransport(http.DefaultTransport, registries)
+		require.NoError(t, err)
+		if mt, ok := rt.(*mirrorTransport); ok {
+			mt.now = func() time.Time { return now }
+		}
+		client.Transport = rt
+	}
+	do := func(method, path string) string {
+		req, err := http.NewRequestWithContext(t.Context(), method, upstream.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// A failing mirror is passed over, then skipped until the cooldown ends
+	install(internalcfg.RegistryAuth{Host: upstreamHost, Mirrors: []string{brokenHost, mirrorHost}})
+	assert.Equal(t, "mirror", do(http.MethodGet, "/v2/app/manifests/v1"))
+	assert.Equal(t, "mirror", do(http.MethodGet, "/v2/app/manifests/v1"))
+	assert.Equal(t, int64(1), brokenHits.Load())
+	now = now.Add(mirrorCooldown)
+	assert.Equal(t, "mirror", do(http.MethodGet, "/v2/app/manifests/v1"))
+	assert.Equal(t, int64(2), brokenHits.Load())
+
+	// Content a mirror does not have comes from the registry
+	assert.Equal(t, "upstream GET", do(http.MethodGet, "/v2/app/manifests/missing"))
+
+	// Writes always go to the registry
+	assert.Equal(t, "upstream PUT", do(http.MethodPut, "/v2/app/manifests/v1"))
+
+	// Listing the registry among its mirrors sets its place in the order
+	install(internalcfg.RegistryAuth{Host: upstreamHost, Mirrors: []string{upstreamHost, mirrorHost}})
+	assert.Equal(t, "upstream GET", do(http.MethodGet, "/v2/app/manifests/v1"))
+
+	// Other registries are not affected
+	install(internalcfg.RegistryAuth{Host: "ghcr.io", Mirrors: []string{brokenHost}})
+	hits := brokenHits.Load()
+	assert.Equal(t, "upstream GET", do(http.MethodGet, "/v2/app/manifests/v1"))
+	assert.Equal(t, hits, brokenHits.Load())
+}
+
+func TestRegistryMirrors_Credentials(t *testing.T) {
+	t.Setenv("MIRROR_PASSWORD", "mirror-secret")
+	var mirrorAuth []string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorAuth = append(mirrorAuth, r.Header.Get("Authorization"))
+		if user, pass, ok := r.BasicAuth(); !ok || user != "mirror" || pass != "mirror-secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="mirror"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, "mirror") //nolint:errcheck // test
+	}))
+	t.Cleanup(mirror.Close)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "upstream "+r.Header.Get("Authorization")) //nolint:errcheck // test
+	}))
+	t.Cleanup(upstream.Close)
+	mirrorHost := mirror.Listener.Addr().String()
+	upstreamHost := upstream.Listener.Addr().String()
+
+	do := func(registries ...internalcfg.RegistryAuth) string {
+		rt, err := registryMirrorTransport(http.DefaultTransport, registries)
+		require.NoError(t, err)
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, upstream.URL+"/v2/app/manifests/v1", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer upstream-token")
+		resp, err := (&http.Client{Transport: rt}).Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// The mirror is authenticated with its own credentials
+	assert.Equal(t, "mirror", do(
+		internalcfg.RegistryAuth{Host: upstreamHost, Mirrors: []string{mirrorHost}},
+		internalcfg.RegistryAuth{Host: mirrorHost, Username: "mirror", PasswordEnv: "MIRROR_PASSWORD"},
+	))
+
+	// A mirror that refuses access is passed over for the registry
+	t.Setenv(dockerConfigEnv, t.TempDir())
+	assert.Equal(t, "upstream Bearer upstream-token", do(
+		internalcfg.RegistryAuth{Host: upstreamHost, Mirrors: []string{mirrorHost}},
+	))
+
+	require.NotEmpty(t, mirrorAuth)
+	for _, got := range mirrorAuth {
+		assert.NotContains(t, got, "upstream-token", "the mirror must never see the registry's credentials")
+	}
+}
+
+func TestMirrorOrder(t *testing.T) {
+	hosts := func(order []*mirrorHost) []string {
+		var out []string
+		for _, m := range order {
+			out = append(out, m.host)
+		}
+		return out
+	}
+	order := func(r internalcfg.RegistryAuth) []*mirrorHost {
+		o, err := mirrorOrder(http.DefaultTransport, r, nil)
+		require.NoError(t, err)
+		return o
+	}
+	assert.Equal(t, []string{"a", "b", ""}, hosts(order(internalcfg.RegistryAuth{Host: "ghcr.io", Mirrors: []string{"a", "b"}})))
+	assert.Equal(t, []string{"a", "", "b"}, hosts(order(internalcfg.RegistryAuth{Host: "ghcr.io", Mirrors: []string{"a", "ghcr.io", "b"}})))
+}
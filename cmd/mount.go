@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/meigma/blob"
+	blobcore "github.com/meigma/blob/core"
+	"github.com/spf13/cobra"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/fusefs"
+	"github.com/meigma/blob-cli/internal/policy"
+	"github.com/meigma/blob-cli/internal/warn"
+)
+
+var mountCmd = &cobra.Command{
+	Use:   "mount <ref> <mountpoint>",
+	Short: "Mount an archive as a read-only filesystem",
+	Long: `Mount an archive as a read-only filesystem.
+
+Exposes the archive at the mountpoint with FUSE, so any program can read
+its files without extracting them first. Only the index is downloaded
+up front; reads are translated into range requests for blocks of
+--block-size KiB, and recently read blocks are kept in memory up to
+--cache-size MiB. Compressed files are fetched whole on first read.
+
+The command stays in the foreground until interrupted (Ctrl-C) or until
+the filesystem is unmounted with umount or fusermount -u. Verification
+policies from config are enforced before mounting.
+
+Requires FUSE: the fuse kernel module and fusermount on Linux, or
+macFUSE on macOS. Not supported on Windows.`,
+	Example: `  blob mount ghcr.io/acme/configs:v1.0.0 /mnt/configs
+  blob mount --cache-size 1024 ghcr.io/acme/data:v2 ./data &
+  ls ./data && umount ./data`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMount,
+}
+
+func init() {
+	mountCmd.Flags().Int("block-size", fusefs.DefaultBlockSize>>10, "size of each range request in KiB")
+	mountCmd.Flags().Int("cache-size", fusefs.DefaultCacheSize>>20, "memory for cached blocks in MiB")
+	mountCmd.Flags().Bool("allow-other", false, "let other users access the mount (needs user_allow_other in /etc/fuse.conf)")
+}
+
+// mountResult describes a mounted archive for JSON output.
+type mountResult struct {
+	Ref         string `json:"ref"`
+	ResolvedRef string `json:"resolved_ref,omitempty"`
+	Mountpoint  string `json:"mountpoint"`
+	Files       int    `json:"files"`
+}
+
+// mountFlags holds the parsed command flags.
+type mountFlags struct {
+	blockSize  int
+	cacheSize  int
+	allowOther bool
+}
+
+func runMount(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+	inputRef, mountpoint := args[0], args[1]
+
+	flags, err := parseMountFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(mountpoint)
+	if err != nil {
+		return fmt.Errorf("checking mountpoint: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("mountpoint %s is not a directory", mountpoint)
+	}
+
+	resolvedRef, err := resolveRef(cmd.Context(), cfg, inputRef)
+	if err != nil {
+		return err
+	}
+
+	policies, err := policy.BuildPolicies(cfg, resolvedRef, nil, "", false)
+	if err != nil {
+		return fmt.Errorf("building policies: %w", err)
+	}
+	policies = withTagReferrers(cfg, policies)
+	policyOpts := make([]blob.Option, 0, len(policies))
+	for _, p := range policies {
+		policyOpts = append(policyOpts, blob.WithPolicy(p))
+	}
+	client, err := newClient(cfg, policyOpts...)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+
+	// Pull is lazy: only the index is downloaded here
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	blobArchive, err := client.Pull(ctx, resolvedRef)
+	if err != nil {
+		if errors.Is(err, blob.ErrPolicyViolation) {
+			return fmt.Errorf("verification failed: %w", err)
+		}
+		return fmt.Errorf("pulling archive: %w", err)
+	}
+	index, err := blobcore.NewIndexView(blobArchive.IndexData())
+	if err != nil {
+		return fmt.Errorf("parsing index: %w", err)
+	}
+
+	srv, err := fusefs.Mount(blobArchive, index, mountpoint, fusefs.Options{
+		Name:       resolvedRef,
+		BlockSize:  int64(flags.blockSize) << 10,
+		CacheSize:  int64(flags.cacheSize) << 20,
+		AllowOther: flags.allowOther,
+		Debug:      cfg.Verbose > 2,
+	})
+	if err != nil {
+		return err
+	}
+
+	result := mountResult{
+		Ref:        inputRef,
+		Mountpoint: mountpoint,
+		Files:      index.Len(),
+	}
+	if inputRef != resolvedRef {
+		result.ResolvedRef = resolvedRef
+	}
+	if err := outputMountResult(cfg, &result); err != nil {
+		srv.Unmount() //nolint:errcheck // already failing
+		return err
+	}
+
+	// Unmount on interrupt; Wait also returns when unmounted externally
+	go func() {
+		<-ctx.Done()
+		if err := srv.Unmount(); err != nil {
+			warn.Printf("unmounting %s: %v", mountpoint, err)
+		}
+	}()
+	srv.Wait()
+	return nil
+}
+
+// parseMountFlags extracts and validates flags from the command.
+func parseMountFlags(cmd *cobra.Command) (mountFlags, error) {
+	var flags mountFlags
+	var err error
+
+	flags.blockSize, err = cmd.Flags().GetInt("block-size")
+	if err != nil {
+		return flags, fmt.Errorf("reading block-size flag: %w", err)
+	}
+	if flags.blockSize < 1 {
+		return flags, fmt.Errorf("--block-size must be at least 1 KiB, got %d", flags.blockSize)
+	}
+
+	flags.cacheSize, err = cmd.Flags().GetInt("cache-size")
+	if err != nil {
+		return flags, fmt.Errorf("reading cache-size flag: %w", err)
+	}
+	if flags.cacheSize < 1 {
+		return flags, fmt.Errorf("--cache-size must be at least 1 MiB, got %d", flags.cacheSize)
+	}
+
+	flags.allowOther, err = cmd.Flags().GetBool("allow-other")
+	if err != nil {
+		return flags, fmt.Errorf("reading allow-other flag: %w", err)
+	}
+	return flags, nil
+}
+
+// outputMountResult reports the mount.
+func outputMountResult(cfg *internalcfg.Config, result *mountResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	fmt.Printf("Mounted %s at %s (%d files, Ctrl-C to unmount)\n", result.Ref, result.Mountpoint, result.Files)
+	return nil
+}
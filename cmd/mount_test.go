@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestMountCmd_NilConfig(t *testing.T) {
+	mountCmd.SetContext(context.Background())
+	err := mountCmd.RunE(mountCmd, []string{"ghcr.io/test:v1", t.TempDir()})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration not loaded")
+}
+
+func TestMountCmd_MissingMountpoint(t *testing.T) {
+	mountCmd.SetContext(internalcfg.WithConfig(context.Background(), &internalcfg.Config{}))
+	err := mountCmd.RunE(mountCmd, []string{"ghcr.io/test:v1", filepath.Join(t.TempDir(), "missing")})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checking mountpoint")
+}
+
+func TestParseMountFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   map[string]string
+		wantErr string
+	}{
+		{name: "defaults"},
+		{name: "zero block size", flags: map[string]string{"block-size": "0"}, wantErr: "--block-size"},
+		{name: "negative cache size", flags: map[string]string{"cache-size": "-1"}, wantErr: "--cache-size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				mountCmd.Flags().Set("block-size", "1024") //nolint:errcheck // test cleanup
+				mountCmd.Flags().Set("cache-size", "256")  //nolint:errcheck // test cleanup
+			})
+			for name, value := range tt.flags {
+				require.NoError(t, mountCmd.Flags().Set(name, value))
+			}
+
+			flags, err := parseMountFlags(mountCmd)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1024, flags.blockSize)
+			assert.Equal(t, 256, flags.cacheSize)
+		})
+	}
+}
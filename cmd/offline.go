@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// errOffline is returned for any registry request made with --offline, so
+// data missing from the local caches fails at once rather than waiting on
+// the network.
+var errOffline = errors.New("not in the local cache and --offline is set")
+
+// offlineTransport fails every request with errOffline. With --offline it
+// takes the place of the network at the bottom of the registry transport.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, fmt.Errorf("%w (would contact %s)", errOffline, req.URL.Host)
+}
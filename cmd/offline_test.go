@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestOffline(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	repo := reg.repo
+	reg.addArchive(t, "v1", map[string]string{"etc/app.conf": "app"})
+	reg.addArchive(t, "v2", map[string]string{"etc/app.conf": "app v2"})
+
+	cfg := newRegistryTestConfig(t)
+	ctx := internalcfg.WithConfig(context.Background(), cfg)
+	cachePinCmd.SetContext(ctx)
+	require.NoError(t, cachePinCmd.RunE(cachePinCmd, []string{repo + ":v1"}))
+
+	cfg.Offline = true
+	transport, err := buildRegistryTransport(cfg, "")
+	require.NoError(t, err)
+	keepRegistryTransport(t)
+	useRegistryTransport(transport)
+
+	// A pinned archive is pulled without the registry
+	dest := filepath.Join(t.TempDir(), "out")
+	pullCmd.SetContext(ctx)
+	require.NoError(t, pullCmd.RunE(pullCmd, []string{repo + ":v1", dest}))
+	content, err := os.ReadFile(filepath.Join(dest, "etc", "app.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "app", string(content))
+
+	// A pin that was not verified is refused once a policy applies, from
+	// the config or from --policy
+	builder := internalcfg.Policy{Provenance: &internalcfg.ProvenancePolicy{
+		SLSA: &internalcfg.SLSAConfig{Builder: "https://builder.acme.example"},
+	}}
+	cfg.Policies = []internalcfg.PolicyRule{{Match: ".*", Policy: builder}}
+	refused := filepath.Join(t.TempDir(), "out")
+	err = pullCmd.RunE(pullCmd, []string{repo + ":v1", refused})
+	requireExitCode(t, err, exitCodePolicyViolation)
+	assert.NoDirExists(t, refused)
+	cfg.Policies = nil
+
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(policyFile, []byte("provenance:\n  slsa:\n    builder: https://builder.acme.example\n"), 0o600))
+	policyFlag := pullCmd.Flags().Lookup("policy").Value.(pflag.SliceValue)
+	require.NoError(t, policyFlag.Replace([]string{policyFile}))
+	err = pullCmd.RunE(pullCmd, []string{repo + ":v1", refused})
+	_ = policyFlag.Replace(nil)
+	requireExitCode(t, err, exitCodePolicyViolation)
+	assert.NoDirExists(t, refused)
+
+	// Anything else fails at once, as not found
+	catCmd.SetContext(ctx)
+	err = catCmd.RunE(catCmd, []string{repo + ":v2", "etc/app.conf"})
+	require.ErrorIs(t, err, errOffline)
+	requireExitCode(t, err, exitCodeNotFound)
+
+	missing := filepath.Join(t.TempDir(), "out")
+	err = pullCmd.RunE(pullCmd, []string{repo + ":v2", missing})
+	require.ErrorIs(t, err, errOffline)
+	requireExitCode(t, err, exitCodeNotFound)
+	assert.NoDirExists(t, missing)
+}
@@ -11,7 +11,9 @@ import (
 	"github.com/spf13/cobra"
 
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/policy"
 	"github.com/meigma/blob-cli/internal/tui/open"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 var openCmd = &cobra.Command{
@@ -21,23 +23,35 @@ var openCmd = &cobra.Command{
 
 Features a split-view layout with file tree on the left and content
 preview on the right. Files load on-demand via HTTP range requests
-for fast navigation.
+for fast navigation. Files over 512 KiB are shown as a hex dump that
+fetches further ranges as you scroll.
 
 Navigation:
   Arrow keys    Navigate file list / scroll preview
   Tab           Switch focus between tree and preview
-  Enter/Right   Enter directory or preview file
+  Enter/Right   Enter directory, preview file, or follow symlink
   Left          Go to parent directory
+  . / Alt+Enter Actions for the selected entry (copy, raw/hex view, clipboard)
   c             Copy selected file (prompts for path)
-  q/Esc         Quit`,
+  x             Extract selected directory, or the current one, with progress
+  y / Y         Copy selected path / digest to the clipboard (OSC 52 over SSH)
+  /             Search below the current directory (substring or glob)
+  a             Show archive README/metadata (if present)
+  q/Esc         Quit
+
+Config policies that match the archive are checked before it is shown.
+If one fails, the failures are listed and browsing is blocked; when every
+failed policy is in warn mode, press o to open the archive anyway.
+--no-verify skips the policies.`,
 	Example: `  blob open ghcr.io/acme/configs:v1.0.0
   blob open myalias`,
-	Args: cobra.ExactArgs(1),
+	Args: defaultRefArgs(cobra.ExactArgs(1)),
 	RunE: runOpen,
 }
 
 func init() {
 	rootCmd.AddCommand(openCmd)
+	addNoVerifyFlag(openCmd)
 }
 
 func runOpen(cmd *cobra.Command, args []string) error {
@@ -46,24 +60,51 @@ func runOpen(cmd *cobra.Command, args []string) error {
 	if cfg == nil {
 		return errors.New("configuration not loaded")
 	}
+	args, err := withDefaultRef(cfg, args)
+	if err != nil {
+		return err
+	}
 
 	// 2. Parse arguments
 	inputRef := args[0]
 
 	// 3. Resolve alias
-	resolvedRef := cfg.ResolveAlias(inputRef)
+	resolvedRef, err := resolveRef(cmd.Context(), cfg, inputRef)
+	if err != nil {
+		return err
+	}
 
-	// 4. Create client
+	// 4. Build the policies checked before the archive is shown
+	noVerify, err := cmd.Flags().GetBool("no-verify")
+	if err != nil {
+		return fmt.Errorf("reading no-verify flag: %w", err)
+	}
+	var policies []policy.NamedPolicy
+	if noVerify {
+		if _, _, err := readPolicyOpts(cfg, resolvedRef, true); err != nil {
+			return err
+		}
+	} else {
+		policies, err = policy.BuildNamedPolicies(cfg, resolvedRef, nil, "", false)
+		if err != nil {
+			return fmt.Errorf("building policies: %w", err)
+		}
+	}
+
+	// 5. Create client
 	client, err := newClient(cfg)
 	if err != nil {
 		return fmt.Errorf("creating client: %w", err)
 	}
 
-	// 5. Create loader function for async archive loading
+	// 6. Create loader function for async archive loading
 	ctx := cmd.Context()
 	loader := makeArchiveLoader(ctx, client, resolvedRef)
+	if len(policies) > 0 {
+		loader = makeVerifiedArchiveLoader(ctx, cfg, client, resolvedRef, policies)
+	}
 
-	// 6. Create and run the TUI (starts with loading screen)
+	// 7. Create and run the TUI (starts with loading screen)
 	model := open.New(resolvedRef, loader)
 	p := tea.NewProgram(
 		model,
@@ -71,9 +112,13 @@ func runOpen(cmd *cobra.Command, args []string) error {
 		tea.WithMouseCellMotion(),
 	)
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		return fmt.Errorf("running TUI: %w", err)
 	}
+	if m, ok := final.(open.Model); ok && m.Unverified() {
+		warn.Printf("opened %s although it failed verification", resolvedRef)
+	}
 
 	return nil
 }
@@ -96,3 +141,47 @@ func makeArchiveLoader(ctx context.Context, client *blob.Client, ref string) ope
 		return index, archive, nil
 	}
 }
+
+// makeVerifiedArchiveLoader creates a LoadFunc that evaluates policies
+// before loading the archive. If any fail, it returns an open.PolicyError
+// that keeps the archive hidden; when every failed policy is in warn mode,
+// the error lets the user open the archive anyway.
+func makeVerifiedArchiveLoader(ctx context.Context, cfg *internalcfg.Config, client *blob.Client, ref string, policies []policy.NamedPolicy) open.LoadFunc {
+	return func() (*blob.IndexView, *blob.Archive, error) {
+		outcomes := &policyOutcomes{}
+		tagged := tagReferrers(cfg)
+		warnOnly := make(map[string]bool, len(policies))
+		opts := make([]blob.Option, 0, len(policies))
+		for _, np := range policies {
+			warnOnly[np.Name] = np.Warn
+			opts = append(opts, blob.WithPolicy(tagged.Wrap(outcomes.wrap(np))))
+		}
+		checker, err := newClient(cfg, opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating client: %w", err)
+		}
+		manifest, err := checker.Fetch(ctx, ref)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetching manifest: %w", err)
+		}
+		// Load the archive that was checked, even if the tag has moved
+		load := makeArchiveLoader(ctx, client, repositoryOf(ref)+"@"+manifest.Digest())
+
+		var failures []string
+		overridable := true
+		for _, outcome := range outcomes.outcomes {
+			if outcome.err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", outcome.name, outcome.err))
+				overridable = overridable && warnOnly[outcome.name]
+			}
+		}
+		if len(failures) == 0 {
+			return load()
+		}
+		policyErr := &open.PolicyError{Failures: failures}
+		if overridable {
+			policyErr.Override = load
+		}
+		return nil, nil, policyErr
+	}
+}
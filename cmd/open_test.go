@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/policy"
+	"github.com/meigma/blob-cli/internal/tui/open"
+)
+
+func TestMakeVerifiedArchiveLoader(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	reg.addArchive(t, "v1", map[string]string{"config.json": "{}"})
+	ref := reg.repo + ":v1"
+
+	load := func(mode string) (*open.PolicyError, error) {
+		cfg := provenancePolicyConfig(".*")
+		cfg.PlainHTTP = true
+		cfg.Policies[0].Mode = mode
+		policies, err := policy.BuildNamedPolicies(cfg, ref, nil, "", false)
+		require.NoError(t, err)
+		client, err := newClient(cfg)
+		require.NoError(t, err)
+
+		// The archive has no provenance attestation
+		_, _, err = makeVerifiedArchiveLoader(context.Background(), cfg, client, ref, policies)()
+		var policyErr *open.PolicyError
+		if !assert.ErrorAs(t, err, &policyErr) {
+			return nil, err
+		}
+		return policyErr, nil
+	}
+
+	policyErr, err := load(internalcfg.PolicyModeEnforce)
+	require.NoError(t, err)
+	require.Len(t, policyErr.Failures, 1)
+	assert.Nil(t, policyErr.Override, "enforced policies cannot be overridden")
+
+	policyErr, err = load(internalcfg.PolicyModeWarn)
+	require.NoError(t, err)
+	require.NotNil(t, policyErr.Override)
+	index, archive, err := policyErr.Override()
+	require.NoError(t, err)
+	require.NotNil(t, archive)
+	assert.Equal(t, 1, index.Len())
+	data, err := archive.ReadFile("config.json")
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(data), "the overridden archive is readable")
+}
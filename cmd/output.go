@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// outputFormatsAnnotation lists the --output formats a command supports
+// beyond text and json, comma-separated.
+const outputFormatsAnnotation = "blob/output-formats"
+
+// outputFormats returns command annotations declaring support for formats
+// beyond text and json.
+func outputFormats(formats ...string) map[string]string {
+	return map[string]string{outputFormatsAnnotation: strings.Join(formats, ",")}
+}
+
+// checkOutputFormat rejects output formats the command does not declare,
+// rather than silently printing text.
+func checkOutputFormat(cmd *cobra.Command, output string) error {
+	if output == internalcfg.OutputText || output == internalcfg.OutputJSON {
+		return nil
+	}
+	if slices.Contains(strings.Split(cmd.Annotations[outputFormatsAnnotation], ","), output) {
+		return nil
+	}
+	return fmt.Errorf("--output %s is not supported by %q (use text or json)", output, cmd.CommandPath())
+}
+
+// isTabularOutput reports whether output is csv or tsv.
+func isTabularOutput(output string) bool {
+	return output == internalcfg.OutputCSV || output == internalcfg.OutputTSV
+}
+
+// newTabularWriter returns a CSV writer for output, using tabs as the
+// separator for tsv.
+func newTabularWriter(w io.Writer, output string) *csv.Writer {
+	cw := csv.NewWriter(w)
+	if output == internalcfg.OutputTSV {
+		cw.Comma = '\t'
+	}
+	return cw
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckOutputFormat(t *testing.T) {
+	require.NoError(t, checkOutputFormat(lsCmd, "csv"))
+	require.NoError(t, checkOutputFormat(lsCmd, "tsv"))
+	require.NoError(t, checkOutputFormat(tagsCmd, "json"))
+	require.NoError(t, checkOutputFormat(verifyCmd, "junit"))
+
+	err := checkOutputFormat(tagsCmd, "csv")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--output csv is not supported")
+
+	err = checkOutputFormat(lsCmd, "junit")
+	require.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/meigma/blob"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/platform"
+)
+
+// selectPlatform returns the archive variant for the --platform value, given
+// the archive's manifest annotations.
+func selectPlatform(ref string, annotations map[string]string, want string) (platform.Variant, error) {
+	variants, err := platform.FromAnnotations(annotations)
+	if err != nil {
+		return platform.Variant{}, fmt.Errorf("%s: %w", ref, err)
+	}
+	v, err := platform.Select(variants, want)
+	if errors.Is(err, platform.ErrNotAnnotated) {
+		return platform.Variant{}, fmt.Errorf("%s: --platform %s: %w (see push --platform)", ref, want, err)
+	}
+	if err != nil {
+		return platform.Variant{}, fmt.Errorf("%s: %w", ref, err)
+	}
+	return v, nil
+}
+
+// selectSourcePlatform maps sources to the files of the requested platform.
+// Each archive's manifest is fetched once, and sources are pinned to the
+// fetched digest so the files match the annotations that selected them.
+func selectSourcePlatform(ctx context.Context, cfg *internalcfg.Config, sources []cpSource, want string, skipCache bool) ([]cpSource, error) {
+	var client *blob.Client
+	var err error
+	if skipCache {
+		client, err = blob.NewClient(clientOptsNoCache(cfg)...)
+	} else {
+		client, err = newClient(cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating client: %w", err)
+	}
+	var fetchOpts []blob.FetchOption
+	if skipCache {
+		fetchOpts = append(fetchOpts, blob.FetchWithSkipCache())
+	}
+
+	type selection struct {
+		ref     string
+		variant platform.Variant
+	}
+	selected := make(map[string]selection)
+
+	result := make([]cpSource, len(sources))
+	for i, src := range sources {
+		sel, ok := selected[src.ref]
+		if !ok {
+			manifest, err := client.Fetch(ctx, src.ref, fetchOpts...)
+			if err != nil {
+				return nil, fmt.Errorf("fetching manifest for %s: %w", src.ref, err)
+			}
+			v, err := selectPlatform(src.ref, manifest.Annotations(), want)
+			if err != nil {
+				return nil, err
+			}
+			sel = selection{ref: repositoryOf(src.ref) + "@" + manifest.Digest(), variant: v}
+			selected[src.ref] = sel
+		}
+		result[i] = cpSource{
+			inputRef: src.inputRef,
+			ref:      sel.ref,
+			path:     sel.variant.Path(src.path),
+		}
+	}
+	return result, nil
+}
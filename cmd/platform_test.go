@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/meigma/blob-cli/internal/platform"
+)
+
+func TestSelectPlatform(t *testing.T) {
+	annotations := map[string]string{
+		platform.Annotation: "linux/amd64=dist/linux-amd64,darwin/arm64=dist/darwin-arm64",
+	}
+
+	v, err := selectPlatform("ghcr.io/acme/tools:v2", annotations, "darwin/arm64")
+	require.NoError(t, err)
+	assert.Equal(t, "dist/darwin-arm64", v.Dir)
+
+	_, err = selectPlatform("ghcr.io/acme/tools:v2", annotations, "windows/amd64")
+	require.ErrorIs(t, err, platform.ErrNoVariant)
+	assert.Contains(t, err.Error(), "ghcr.io/acme/tools:v2")
+
+	_, err = selectPlatform("ghcr.io/acme/tools:v2", nil, "linux/amd64")
+	require.ErrorIs(t, err, platform.ErrNotAnnotated)
+	assert.Contains(t, err.Error(), "push --platform")
+
+	_, err = selectPlatform("ghcr.io/acme/tools:v2", annotations, "linux")
+	require.Error(t, err)
+}
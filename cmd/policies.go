@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/meigma/blob"
+	"github.com/meigma/blob/registry"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/registry/remote"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/policy"
+	"github.com/meigma/blob-cli/internal/referrers"
+	"github.com/meigma/blob-cli/internal/warn"
+)
+
+// verificationStatus reports, in JSON results, whether an archive read by
+// a command was verified against the config policies.
+type verificationStatus struct {
+	Verified        bool `json:"verified"`
+	PoliciesApplied int  `json:"policies_applied,omitempty"`
+}
+
+// addNoVerifyFlag adds --no-verify to a command that reads archives.
+func addNoVerifyFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-verify", false, "skip the config policies that match the archive")
+}
+
+// readPolicyOpts returns client options that enforce the config policies
+// matching ref, so that commands reading an archive verify it as pull
+// does. With noVerify, no policies are applied and a warning names the
+// policies skipped.
+func readPolicyOpts(cfg *internalcfg.Config, ref string, noVerify bool) ([]blob.Option, verificationStatus, error) {
+	if noVerify {
+		if n := len(cfg.MatchedPolicyRules(ref)); n > 0 {
+			warn.Printf("not verifying %s: --no-verify skips %d matching policies", ref, n)
+		}
+		return nil, verificationStatus{}, nil
+	}
+
+	policies, err := policy.BuildPolicies(cfg, ref, nil, "", false)
+	if err != nil {
+		return nil, verificationStatus{}, fmt.Errorf("building policies: %w", err)
+	}
+	policies = withTagReferrers(cfg, policies)
+	opts := make([]blob.Option, 0, len(policies))
+	for _, p := range policies {
+		opts = append(opts, blob.WithPolicy(p))
+	}
+	return opts, verificationStatus{Verified: len(policies) > 0, PoliciesApplied: len(policies)}, nil
+}
+
+// tagReferrers returns a fallback that shows policies the referrers in
+// the tag index of an archive's repository, where registries without the
+// referrers API keep signatures and attestations.
+func tagReferrers(cfg *internalcfg.Config) *referrers.Fallback {
+	return referrers.NewFallback(func(ref string) (*remote.Repository, error) {
+		return newRemoteRepository(cfg, repositoryOf(ref))
+	})
+}
+
+// withTagReferrers wraps policies with a tagReferrers fallback.
+func withTagReferrers(cfg *internalcfg.Config, policies []registry.Policy) []registry.Policy {
+	fallback := tagReferrers(cfg)
+	wrapped := make([]registry.Policy, len(policies))
+	for i, p := range policies {
+		wrapped[i] = fallback.Wrap(p)
+	}
+	return wrapped
+}
+
+// policyError gives a policy violation the verification exit code.
+// Other errors are returned unchanged.
+func policyError(err error) error {
+	if errors.Is(err, blob.ErrPolicyViolation) {
+		return &ExitError{
+			Code: exitCodePolicyViolation,
+			Err:  fmt.Errorf("verification failed: %w", err),
+		}
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/meigma/blob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/warn"
+)
+
+// provenancePolicyConfig returns a config whose only policy requires SLSA
+// provenance for refs matching match.
+func provenancePolicyConfig(match string) *internalcfg.Config {
+	return &internalcfg.Config{
+		Policies: []internalcfg.PolicyRule{{
+			Match: match,
+			Policy: internalcfg.Policy{
+				Provenance: &internalcfg.ProvenancePolicy{
+					SLSA: &internalcfg.SLSAConfig{Repository: "acme/configs"},
+				},
+			},
+		}},
+	}
+}
+
+func TestReadPolicyOpts(t *testing.T) {
+	warn.Reset()
+	warn.SetQuiet(true)
+	t.Cleanup(warn.Reset)
+	cfg := provenancePolicyConfig(`ghcr\.io/acme/.*`)
+
+	opts, status, err := readPolicyOpts(cfg, "ghcr.io/acme/configs:v1", false)
+	require.NoError(t, err)
+	assert.Len(t, opts, 1)
+	assert.Equal(t, verificationStatus{Verified: true, PoliciesApplied: 1}, status)
+
+	opts, status, err = readPolicyOpts(cfg, "docker.io/library/alpine:3", false)
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+	assert.Equal(t, verificationStatus{}, status)
+	assert.Zero(t, warn.Count())
+
+	// Skipping matching policies is reported, so --strict can catch it
+	opts, status, err = readPolicyOpts(cfg, "ghcr.io/acme/configs:v1", true)
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+	assert.Equal(t, verificationStatus{}, status)
+	assert.Equal(t, 1, warn.Count())
+}
+
+func TestPolicyError(t *testing.T) {
+	err := policyError(errors.New("not found"))
+	var exitErr *ExitError
+	assert.False(t, errors.As(err, &exitErr))
+
+	err = policyError(blob.ErrPolicyViolation)
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, exitCodePolicyViolation, exitErr.Code)
+	require.ErrorIs(t, err, blob.ErrPolicyViolation)
+}
+
+func TestLsCmd_EnforcesPolicies(t *testing.T) {
+	warn.Reset()
+	warn.SetQuiet(true)
+	t.Cleanup(warn.Reset)
+
+	reg, _ := newTestRegistry(t)
+	reg.addArchive(t, "v1", map[string]string{"config.json": "{}"})
+	ref := reg.repo + ":v1"
+
+	cfg := newRegistryTestConfig(t)
+	cfg.Quiet = false
+	cfg.Policies = provenancePolicyConfig(".*").Policies
+	lsCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	// The archive has no provenance attestation
+	out, err := captureStdout(t, func() error { return lsCmd.RunE(lsCmd, []string{ref}) })
+	requireExitCode(t, err, exitCodePolicyViolation)
+	assert.Empty(t, out, "nothing is listed from an archive that fails its policies")
+
+	require.NoError(t, lsCmd.Flags().Set("no-verify", "true"))
+	t.Cleanup(func() { lsCmd.Flags().Set("no-verify", "false") }) //nolint:errcheck // test cleanup
+	out, err = captureStdout(t, func() error { return lsCmd.RunE(lsCmd, []string{ref}) })
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "config.json")
+}
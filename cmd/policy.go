@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/meigma/blob"
+	"github.com/meigma/blob/registry"
+	"github.com/spf13/cobra"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/policy"
+)
+
+// Statuses of a policy rule tested by policy test.
+const (
+	ruleStatusPass = "pass"
+	ruleStatusFail = "fail"
+	ruleStatusSkip = "skip"
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Work with verification policies",
+	Long: `Work with verification policies.
+
+Policies are YAML files or OPA Rego policies, as given to "blob verify"
+with --policy and --policy-rego.`,
+}
+
+var policyTestCmd = &cobra.Command{
+	Use:   "test --against <file|ref>",
+	Short: "Evaluate policies against an attestation or archive",
+	Long: `Evaluate policies against an attestation or archive.
+
+Each rule is evaluated on its own and reported as passing or failing,
+with the reason for each failure: the signature and provenance sections
+of every --policy file, and the --policy-rego policy as a whole. Unlike
+"blob verify", evaluation does not stop at the first failing rule, and
+config policies are not applied.
+
+--against names either a local attestation file or an archive
+reference. A file may be a Sigstore bundle, a DSSE envelope, or a bare
+in-toto statement, so provenance rules can be tried against a statement
+while it is being written, without signing or pushing it. Rules that need
+more than the attestation, such as signature rules, which check the
+archive's manifest, are reported as skipped; test them against a
+reference.
+
+The command exits with code 5 if any rule fails.`,
+	Example: `  blob policy test --policy policy.yaml --against attestation.json
+  blob policy test --policy policy.yaml --against ghcr.io/acme/configs:v1.0.0
+  blob policy test --policy-rego custom.rego --against provenance.intoto.json --output json`,
+	Args: cobra.NoArgs,
+	RunE: runPolicyTest,
+}
+
+var policyLintCmd = &cobra.Command{
+	Use:   "lint <file|dir>...",
+	Short: "Check policy files for problems",
+	Long: `Check policy files for problems.
+
+Every problem found is reported with its file and line, rather than the
+first one a command would stop at:
+
+  - YAML policy files must have only known fields, and pass the checks made
+    when "blob verify" builds the policy, such as keyless signatures naming
+    both an issuer and an identity.
+  - In config files, every rule of policies is checked the same way, and
+    its match pattern must be a valid regular expression and its mode
+    enforce or warn. Other config settings are not checked.
+  - Rego policies (.rego) must parse and compile, and define allow or deny
+    rules in package blob.policy.
+
+A directory is searched for *.yaml, *.yml, and *.rego files. The command
+exits with an error if any problem is found.`,
+	Example: `  blob policy lint policy.yaml
+  blob policy lint policies/
+  blob policy lint ~/.config/blob/config.yaml custom.rego --output json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPolicyLint,
+}
+
+func init() {
+	policyTestCmd.Flags().StringArray("policy", nil, "policy file to test (repeatable)")
+	policyTestCmd.Flags().String("policy-rego", "", "OPA Rego policy file to test")
+	policyTestCmd.Flags().String("against", "", "attestation file or archive reference to evaluate against")
+	_ = policyTestCmd.MarkFlagRequired("against")
+	policyCmd.AddCommand(policyTestCmd)
+	policyCmd.AddCommand(policyLintCmd)
+}
+
+// policyTestResult contains the result of a policy test.
+type policyTestResult struct {
+	Against string             `json:"against"`
+	Digest  string             `json:"digest"`
+	Passed  bool               `json:"passed"`
+	Rules   []policyRuleResult `json:"rules"`
+}
+
+// policyRuleResult is the outcome of one rule.
+type policyRuleResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "pass", "fail", or "skip"
+	Reason string `json:"reason,omitempty"`
+}
+
+// policyLintResult contains the result of a policy lint.
+type policyLintResult struct {
+	Files    []string         `json:"files"`
+	Problems []policy.Problem `json:"problems"`
+}
+
+// policyTestFlags holds the parsed command flags.
+type policyTestFlags struct {
+	policyFiles []string
+	policyRego  string
+	against     string
+}
+
+func runPolicyTest(cmd *cobra.Command, _ []string) error {
+	// 1. Get config from context
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	// 2. Parse flags
+	flags, err := parsePolicyTestFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	// 3. Build each rule separately
+	rules, err := policy.BuildRules(cfg, flags.policyFiles, flags.policyRego)
+	if err != nil {
+		if isInfrastructureError(err) {
+			return &ExitError{Code: exitCodeInfrastructure, Err: fmt.Errorf("building policies: %w", err)}
+		}
+		return fmt.Errorf("building policies: %w", err)
+	}
+	if len(rules) == 0 {
+		return errors.New("the policies have no rules to test")
+	}
+
+	// 4. Evaluate against a local attestation or an archive
+	result := policyTestResult{Against: flags.against}
+	if info, statErr := os.Stat(flags.against); statErr == nil && info.Mode().IsRegular() {
+		err = testPolicyAttestation(cmd.Context(), flags.against, rules, &result)
+	} else {
+		err = testPolicyArchive(cmd.Context(), cfg, flags.against, rules, &result)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 5. Report
+	result.Passed = true
+	failed := 0
+	for _, r := range result.Rules {
+		if r.Status == ruleStatusFail {
+			result.Passed = false
+			failed++
+		}
+	}
+	if err := outputPolicyTestResult(cfg, &result); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return &ExitError{
+			Code: exitCodePolicyViolation,
+			Err:  fmt.Errorf("%d of %d policy rules failed", failed, len(result.Rules)),
+		}
+	}
+	return nil
+}
+
+func runPolicyLint(cmd *cobra.Command, args []string) error {
+	// 1. Get config from context
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	// 2. Find the files to lint
+	result := policyLintResult{Problems: []policy.Problem{}}
+	for _, arg := range args {
+		files, err := policy.LintFiles(arg)
+		if err != nil {
+			return fmt.Errorf("finding policy files: %w", err)
+		}
+		result.Files = append(result.Files, files...)
+	}
+	if len(result.Files) == 0 {
+		return errors.New("no policy files found")
+	}
+
+	// 3. Lint each file
+	for _, file := range result.Files {
+		problems, err := policy.LintFile(file)
+		if err != nil {
+			return fmt.Errorf("reading policy file: %w", err)
+		}
+		result.Problems = append(result.Problems, problems...)
+	}
+
+	// 4. Report
+	if err := outputPolicyLintResult(cfg, &result); err != nil {
+		return err
+	}
+	if len(result.Problems) > 0 {
+		return fmt.Errorf("%d problem(s) found in policy files", len(result.Problems))
+	}
+	return nil
+}
+
+// outputPolicyLintResult formats and outputs the policy lint result.
+func outputPolicyLintResult(cfg *internalcfg.Config, result *policyLintResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	for _, p := range result.Problems {
+		fmt.Println(p)
+	}
+	if len(result.Problems) == 0 {
+		fmt.Printf("%d policy file(s) OK\n", len(result.Files))
+	}
+	return nil
+}
+
+// parsePolicyTestFlags extracts and validates flags from the command.
+func parsePolicyTestFlags(cmd *cobra.Command) (policyTestFlags, error) {
+	var flags policyTestFlags
+	var err error
+
+	flags.policyFiles, err = cmd.Flags().GetStringArray("policy")
+	if err != nil {
+		return flags, fmt.Errorf("reading policy flag: %w", err)
+	}
+
+	flags.policyRego, err = cmd.Flags().GetString("policy-rego")
+	if err != nil {
+		return flags, fmt.Errorf("reading policy-rego flag: %w", err)
+	}
+	if len(flags.policyFiles) == 0 && flags.policyRego == "" {
+		return flags, errors.New("requires --policy or --policy-rego")
+	}
+
+	flags.against, err = cmd.Flags().GetString("against")
+	if err != nil {
+		return flags, fmt.Errorf("reading against flag: %w", err)
+	}
+	if flags.against == "" {
+		return flags, errors.New("--against cannot be empty")
+	}
+
+	return flags, nil
+}
+
+// testPolicyAttestation evaluates rules against a local attestation file.
+func testPolicyAttestation(ctx context.Context, path string, rules []policy.NamedPolicy, result *policyTestResult) error {
+	att, err := policy.LoadAttestation(path)
+	if err != nil {
+		return err
+	}
+	result.Digest = att.Subject()
+
+	req := att.Request()
+	result.Rules = make([]policyRuleResult, len(rules))
+	for i, rule := range rules {
+		result.Rules[i] = ruleResult(rule, rule.Policy.Evaluate(ctx, req))
+	}
+	return nil
+}
+
+// testPolicyArchive evaluates rules against an archive in a registry,
+// recording every rule's outcome rather than stopping at the first
+// failure.
+func testPolicyArchive(ctx context.Context, cfg *internalcfg.Config, ref string, rules []policy.NamedPolicy, result *policyTestResult) error {
+	resolvedRef, err := resolveRef(ctx, cfg, ref)
+	if err != nil {
+		return err
+	}
+
+	tagged := tagReferrers(cfg)
+	result.Rules = make([]policyRuleResult, len(rules))
+	opts := make([]blob.Option, 0, len(rules))
+	for i, rule := range rules {
+		p := registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+			result.Rules[i] = ruleResult(rule, rule.Policy.Evaluate(ctx, req))
+			return nil
+		})
+		opts = append(opts, blob.WithPolicy(tagged.Wrap(p)))
+	}
+
+	client, err := newClient(cfg, opts...)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	inspectResult, err := client.Inspect(ctx, resolvedRef)
+	if err != nil {
+		return archiveError(err)
+	}
+	result.Digest = inspectResult.Digest()
+	return nil
+}
+
+// ruleResult returns the outcome of a rule that returned err. A rule that
+// needs more than a local attestation holds is skipped.
+func ruleResult(rule policy.NamedPolicy, err error) policyRuleResult {
+	r := policyRuleResult{Name: rule.Name, Status: ruleStatusPass}
+	switch {
+	case err == nil:
+	case errors.Is(err, policy.ErrNotLocal):
+		r.Status = ruleStatusSkip
+		r.Reason = "needs the archive, not only an attestation (test against a reference)"
+	default:
+		r.Status = ruleStatusFail
+		r.Reason = err.Error()
+	}
+	return r
+}
+
+// outputPolicyTestResult formats and outputs the policy test result.
+func outputPolicyTestResult(cfg *internalcfg.Config, result *policyTestResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	counts := make(map[string]int, 3)
+	for _, r := range result.Rules {
+		counts[r.Status]++
+		switch r.Status {
+		case ruleStatusPass:
+			fmt.Printf("PASS  %s\n", r.Name)
+		case ruleStatusFail:
+			fmt.Printf("FAIL  %s\n      %s\n", r.Name, r.Reason)
+		default:
+			fmt.Printf("SKIP  %s\n      %s\n", r.Name, r.Reason)
+		}
+	}
+	fmt.Printf("\n%s (%s): %d passed, %d failed, %d skipped\n",
+		result.Against, result.Digest, counts[ruleStatusPass], counts[ruleStatusFail], counts[ruleStatusSkip])
+	return nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestPolicyTest_Attestation(t *testing.T) {
+	dir := t.TempDir()
+	subject := digest.FromString("manifest")
+	attestation := filepath.Join(dir, "provenance.intoto.json")
+	require.NoError(t, os.WriteFile(attestation, []byte(`{"_type":"https://in-toto.io/Statement/v1",`+
+		`"subject":[{"name":"archive","digest":{"sha256":"`+subject.Encoded()+`"}}],`+
+		`"predicateType":"https://slsa.dev/provenance/v1",`+
+		`"predicate":{"runDetails":{"builder":{"id":"https://github.com/actions/runner"}}}}`), 0o600))
+	pass := filepath.Join(dir, "pass.yaml")
+	require.NoError(t, os.WriteFile(pass, []byte("provenance:\n  slsa:\n    builder: https://github.com/actions/runner\n"), 0o600))
+	fail := filepath.Join(dir, "fail.yaml")
+	require.NoError(t, os.WriteFile(fail, []byte("provenance:\n  slsa:\n    builder: https://gitlab.com/runner\n"), 0o600))
+
+	cfg := internalcfg.Default()
+	cfg.Output = internalcfg.OutputJSON
+	policyTestCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	require.NoError(t, policyTestCmd.Flags().Set("against", attestation))
+	require.NoError(t, policyTestCmd.Flags().Set("policy", pass))
+	require.NoError(t, policyTestCmd.Flags().Set("policy", fail))
+	t.Cleanup(func() {
+		_ = policyTestCmd.Flags().Set("against", "")
+		_ = policyTestCmd.Flags().Lookup("policy").Value.(pflag.SliceValue).Replace(nil)
+	})
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := policyTestCmd.RunE(policyTestCmd, nil)
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	var exitErr *ExitError
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, exitCodePolicyViolation, exitErr.Code)
+
+	var result policyTestResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, subject.String(), result.Digest)
+	assert.False(t, result.Passed)
+	require.Len(t, result.Rules, 2)
+	assert.Equal(t, policyRuleResult{Name: pass + ": provenance", Status: ruleStatusPass}, result.Rules[0])
+	assert.Equal(t, ruleStatusFail, result.Rules[1].Status)
+	assert.Contains(t, result.Rules[1].Reason, "https://gitlab.com/runner")
+}
+
+func TestParsePolicyTestFlags(t *testing.T) {
+	_, err := parsePolicyTestFlags(policyTestCmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires --policy or --policy-rego")
+}
+
+func TestPolicyLint(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ok.yaml"),
+		[]byte("provenance:\n  slsa:\n    builder: https://github.com/actions/runner\n"), 0o600))
+	bad := filepath.Join(dir, "bad.yaml")
+	require.NoError(t, os.WriteFile(bad, []byte("signature:\n  keyless:\n    issuer: https://token.actions.githubusercontent.com\n"), 0o600))
+
+	cfg := internalcfg.Default()
+	cfg.Output = internalcfg.OutputJSON
+	policyLintCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := policyLintCmd.RunE(policyLintCmd, []string{dir})
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 problem(s) found")
+
+	var result policyLintResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Len(t, result.Files, 2)
+	require.Len(t, result.Problems, 1)
+	assert.Equal(t, bad, result.Problems[0].File)
+	assert.Equal(t, 2, result.Problems[0].Line)
+	assert.Equal(t, "signature.keyless: keyless identity is required", result.Problems[0].Message)
+}
@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/meigma/blob"
+	"github.com/spf13/cobra"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/identity"
+	"github.com/meigma/blob-cli/internal/policy"
+	"github.com/meigma/blob-cli/internal/warn"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote <src-ref>",
+	Short: "Promote a verified archive to release tags",
+	Long: `Promote a verified archive to release tags.
+
+Verifies the source archive against the applicable policies, then
+tags the verified manifest digest with each target. Targets without
+a registry or repository (e.g. "v1.4.0") are tags in the source
+repository; full references and aliases are also accepted.
+
+Tagging the digest (rather than the source tag) guarantees that every
+target points at exactly the content that was verified. With --sign,
+the promoted digest is signed once all targets have been tagged.
+
+A single report describing the whole promotion is written on success.`,
+	Example: `  blob promote ghcr.io/acme/cfg:rc-123 --to v1.4.0,latest
+  blob promote ghcr.io/acme/cfg:rc-123 --to v1.4.0 --require-verified
+  blob promote --policy policy.yaml --sign ghcr.io/acme/cfg:rc-123 --to v1.4.0
+  blob promote -o json ghcr.io/acme/cfg:rc-123 --to ghcr.io/acme/prod-cfg:v1.4.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPromote,
+}
+
+func init() {
+	promoteCmd.Flags().StringSlice("to", nil, "target tags or references (comma-separated or repeatable)")
+	promoteCmd.Flags().Bool("require-verified", false, "fail if no policies apply to the source")
+	promoteCmd.Flags().StringArray("policy", nil, "policy file for verification (repeatable)")
+	promoteCmd.Flags().String("policy-rego", "", "OPA Rego policy file")
+	promoteCmd.Flags().Bool("no-default-policy", false, "skip policies from config file")
+	promoteCmd.Flags().Bool("sign", false, "sign the promoted digest")
+	promoteCmd.Flags().String("key", "", "sign with a private key file, KMS key URI, or PKCS#11 URI instead of keyless")
+	addIdentityFlags(promoteCmd)
+	_ = promoteCmd.MarkFlagRequired("to")
+}
+
+// promoteResult contains the result of a promote operation.
+type promoteResult struct {
+	Source          string          `json:"source"`
+	ResolvedSource  string          `json:"resolved_source,omitempty"`
+	Digest          string          `json:"digest"`
+	Verified        bool            `json:"verified"`
+	PoliciesApplied int             `json:"policies_applied"`
+	Targets         []promoteTarget `json:"targets"`
+	Signed          bool            `json:"signed"`
+	SignatureDigest string          `json:"signature_digest,omitempty"`
+	Status          string          `json:"status"`
+}
+
+// promoteTarget describes a single promotion target.
+type promoteTarget struct {
+	Target string `json:"target"`
+	Ref    string `json:"ref"`
+}
+
+// promoteFlags holds the parsed command flags.
+type promoteFlags struct {
+	targets         []string
+	requireVerified bool
+	policyFiles     []string
+	policyRego      string
+	noDefaultPolicy bool
+	sign            bool
+	keyPath         string
+	identity        identity.Options
+}
+
+func runPromote(cmd *cobra.Command, args []string) error {
+	// 1. Get config from context
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	// 2. Parse arguments
+	inputRef := args[0]
+
+	// 3. Parse flags
+	flags, err := parsePromoteFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	// 4. Resolve source alias and targets
+	resolvedRef, err := resolveRef(cmd.Context(), cfg, inputRef)
+	if err != nil {
+		return err
+	}
+	targets := make([]promoteTarget, 0, len(flags.targets))
+	for _, t := range flags.targets {
+		ref, err := resolvePromoteTarget(cfg, resolvedRef, t)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, promoteTarget{Target: t, Ref: ref})
+	}
+
+	// 5. Build policies from config + flags
+	policies, err := policy.BuildPolicies(
+		cfg,
+		resolvedRef,
+		flags.policyFiles,
+		flags.policyRego,
+		flags.noDefaultPolicy,
+	)
+	if err != nil {
+		return fmt.Errorf("building policies: %w", err)
+	}
+	if flags.requireVerified && len(policies) == 0 {
+		return &ExitError{
+			Code: exitCodePolicyViolation,
+			Err:  fmt.Errorf("no policies apply to %s: --require-verified needs at least one policy", resolvedRef),
+		}
+	}
+
+	// 6. Create client with policies for verification
+	policies = withTagReferrers(cfg, policies)
+	policyOpts := make([]blob.Option, 0, len(policies))
+	for _, p := range policies {
+		policyOpts = append(policyOpts, blob.WithPolicy(p))
+	}
+	client, err := newClient(cfg, policyOpts...)
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+
+	// 7. Verify the source (policies are evaluated on fetch)
+	ctx := cmd.Context()
+	manifest, err := client.Fetch(ctx, resolvedRef)
+	if err != nil {
+		if errors.Is(err, blob.ErrPolicyViolation) {
+			return &ExitError{
+				Code: exitCodePolicyViolation,
+				Err:  fmt.Errorf("verification failed: %w", err),
+			}
+		}
+		return fmt.Errorf("fetching source manifest: %w", err)
+	}
+	digest := manifest.Digest()
+
+	if len(policies) == 0 {
+		warn.Printf("No policies applied - source not verified")
+	}
+
+	// 8. Tag each target with the verified digest
+	for _, t := range targets {
+		if err := client.Tag(ctx, t.Ref, digest); err != nil {
+			return fmt.Errorf("tagging %s: %w", t.Ref, err)
+		}
+	}
+
+	result := promoteResult{
+		Source:          inputRef,
+		Digest:          digest,
+		Verified:        len(policies) > 0,
+		PoliciesApplied: len(policies),
+		Targets:         targets,
+		Status:          "success",
+	}
+	if inputRef != resolvedRef {
+		result.ResolvedSource = resolvedRef
+	}
+
+	// 9. Optionally sign the promoted digest
+	if flags.sign {
+		signer, err := buildSigner(ctx, signFlags{keyPath: flags.keyPath, identity: flags.identity})
+		if err != nil {
+			return fmt.Errorf("creating signer: %w", err)
+		}
+		sigDigest, err := client.Sign(ctx, repositoryOf(resolvedRef)+"@"+digest, signer)
+		if err != nil {
+			return fmt.Errorf("signing promoted archive: %w", err)
+		}
+		result.Signed = true
+		result.SignatureDigest = sigDigest
+	}
+
+	return outputPromoteResult(cfg, &result)
+}
+
+// parsePromoteFlags extracts and validates flags from the command.
+func parsePromoteFlags(cmd *cobra.Command) (promoteFlags, error) {
+	var flags promoteFlags
+	var err error
+
+	flags.targets, err = cmd.Flags().GetStringSlice("to")
+	if err != nil {
+		return flags, fmt.Errorf("reading to flag: %w", err)
+	}
+	if len(flags.targets) == 0 {
+		return flags, errors.New("at least one target is required (--to)")
+	}
+
+	flags.requireVerified, err = cmd.Flags().GetBool("require-verified")
+	if err != nil {
+		return flags, fmt.Errorf("reading require-verified flag: %w", err)
+	}
+
+	flags.policyFiles, err = cmd.Flags().GetStringArray("policy")
+	if err != nil {
+		return flags, fmt.Errorf("reading policy flag: %w", err)
+	}
+
+	flags.policyRego, err = cmd.Flags().GetString("policy-rego")
+	if err != nil {
+		return flags, fmt.Errorf("reading policy-rego flag: %w", err)
+	}
+
+	flags.noDefaultPolicy, err = cmd.Flags().GetBool("no-default-policy")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-default-policy flag: %w", err)
+	}
+
+	flags.sign, err = cmd.Flags().GetBool("sign")
+	if err != nil {
+		return flags, fmt.Errorf("reading sign flag: %w", err)
+	}
+
+	flags.keyPath, err = cmd.Flags().GetString("key")
+	if err != nil {
+		return flags, fmt.Errorf("reading key flag: %w", err)
+	}
+
+	flags.identity, err = parseIdentityFlags(cmd)
+	if err != nil {
+		return flags, err
+	}
+
+	return flags, nil
+}
+
+// resolvePromoteTarget expands a promotion target into a full reference.
+// Bare tags are applied to the source repository; anything else is treated
+// as a reference (or alias) in its own right.
+func resolvePromoteTarget(cfg *internalcfg.Config, srcRef, target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", errors.New("empty promotion target")
+	}
+	if _, ok := cfg.Aliases[target]; ok || strings.ContainsAny(target, ":/@") {
+		return cfg.ResolveAlias(target), nil
+	}
+	return repositoryOf(srcRef) + ":" + target, nil
+}
+
+// repositoryOf strips the tag or digest from a reference.
+func repositoryOf(ref string) string {
+	if idx := strings.LastIndex(ref, "@"); idx != -1 {
+		ref = ref[:idx]
+	}
+	lastSlash := strings.LastIndex(ref, "/")
+	if idx := strings.LastIndex(ref[lastSlash+1:], ":"); idx != -1 {
+		return ref[:lastSlash+1+idx]
+	}
+	return ref
+}
+
+// outputPromoteResult formats and outputs the promote result.
+func outputPromoteResult(cfg *internalcfg.Config, result *promoteResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		return promoteJSON(result)
+	}
+	return promoteText(result)
+}
+
+func promoteJSON(result *promoteResult) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+func promoteText(result *promoteResult) error {
+	fmt.Printf("Promoted %s\n", result.Source)
+	if result.ResolvedSource != "" {
+		fmt.Printf("  Resolved: %s\n", result.ResolvedSource)
+	}
+	fmt.Printf("Digest: %s\n", result.Digest)
+	if result.Verified {
+		fmt.Printf("Policies: %d applied\n", result.PoliciesApplied)
+	} else {
+		fmt.Println("Policies: none (not verified)")
+	}
+
+	fmt.Println()
+	fmt.Println("Targets:")
+	for _, t := range result.Targets {
+		fmt.Printf("  %s\n", t.Ref)
+	}
+
+	if result.Signed {
+		fmt.Println()
+		fmt.Printf("Signature: %s\n", result.SignatureDigest)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestPromoteCmd_NilConfig(t *testing.T) {
+	ctx := context.Background()
+
+	promoteCmd.SetContext(ctx)
+	err := promoteCmd.RunE(promoteCmd, []string{"ghcr.io/test:rc-1"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration not loaded")
+}
+
+func TestResolvePromoteTarget(t *testing.T) {
+	cfg := &internalcfg.Config{
+		Aliases: map[string]string{
+			"prod": "ghcr.io/acme/prod-cfg",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		target  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "bare tag uses source repository",
+			src:    "ghcr.io/acme/cfg:rc-123",
+			target: "v1.4.0",
+			want:   "ghcr.io/acme/cfg:v1.4.0",
+		},
+		{
+			name:   "bare tag with digest source",
+			src:    "ghcr.io/acme/cfg@sha256:abc123",
+			target: "latest",
+			want:   "ghcr.io/acme/cfg:latest",
+		},
+		{
+			name:   "bare tag with registry port",
+			src:    "localhost:5000/cfg:rc-1",
+			target: "v1",
+			want:   "localhost:5000/cfg:v1",
+		},
+		{
+			name:   "full reference",
+			src:    "ghcr.io/acme/cfg:rc-123",
+			target: "ghcr.io/acme/other:v1",
+			want:   "ghcr.io/acme/other:v1",
+		},
+		{
+			name:   "alias with tag",
+			src:    "ghcr.io/acme/cfg:rc-123",
+			target: "prod:v1.4.0",
+			want:   "ghcr.io/acme/prod-cfg:v1.4.0",
+		},
+		{
+			name:   "surrounding whitespace is trimmed",
+			src:    "ghcr.io/acme/cfg:rc-123",
+			target: " latest ",
+			want:   "ghcr.io/acme/cfg:latest",
+		},
+		{
+			name:    "empty target",
+			src:     "ghcr.io/acme/cfg:rc-123",
+			target:  " ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolvePromoteTarget(cfg, tt.src, tt.target)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRepositoryOf(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"ghcr.io/acme/cfg:v1", "ghcr.io/acme/cfg"},
+		{"ghcr.io/acme/cfg@sha256:abc", "ghcr.io/acme/cfg"},
+		{"ghcr.io/acme/cfg", "ghcr.io/acme/cfg"},
+		{"localhost:5000/cfg:v1", "localhost:5000/cfg"},
+		{"localhost:5000/cfg", "localhost:5000/cfg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			assert.Equal(t, tt.want, repositoryOf(tt.ref))
+		})
+	}
+}
+
+func TestPromoteText(t *testing.T) {
+	result := &promoteResult{
+		Source:          "cfg:rc-123",
+		ResolvedSource:  "ghcr.io/acme/cfg:rc-123",
+		Digest:          "sha256:abc123",
+		Verified:        true,
+		PoliciesApplied: 2,
+		Targets: []promoteTarget{
+			{Target: "v1.4.0", Ref: "ghcr.io/acme/cfg:v1.4.0"},
+			{Target: "latest", Ref: "ghcr.io/acme/cfg:latest"},
+		},
+		Signed:          true,
+		SignatureDigest: "sha256:sig456",
+		Status:          "success",
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := promoteText(result)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	require.NoError(t, err)
+	got := buf.String()
+	assert.Contains(t, got, "Promoted cfg:rc-123")
+	assert.Contains(t, got, "Resolved: ghcr.io/acme/cfg:rc-123")
+	assert.Contains(t, got, "Digest: sha256:abc123")
+	assert.Contains(t, got, "Policies: 2 applied")
+	assert.Contains(t, got, "ghcr.io/acme/cfg:v1.4.0")
+	assert.Contains(t, got, "ghcr.io/acme/cfg:latest")
+	assert.Contains(t, got, "Signature: sha256:sig456")
+}
+
+func TestPromoteText_Unverified(t *testing.T) {
+	result := &promoteResult{
+		Source:  "ghcr.io/acme/cfg:rc-123",
+		Digest:  "sha256:abc123",
+		Targets: []promoteTarget{{Target: "v1", Ref: "ghcr.io/acme/cfg:v1"}},
+		Status:  "success",
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := promoteText(result)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	require.NoError(t, err)
+	got := buf.String()
+	assert.Contains(t, got, "Policies: none (not verified)")
+	assert.NotContains(t, got, "Resolved:")
+	assert.NotContains(t, got, "Signature:")
+}
+
+func TestPromoteJSON(t *testing.T) {
+	result := &promoteResult{
+		Source:          "ghcr.io/acme/cfg:rc-123",
+		Digest:          "sha256:abc123",
+		Verified:        true,
+		PoliciesApplied: 1,
+		Targets:         []promoteTarget{{Target: "v1.4.0", Ref: "ghcr.io/acme/cfg:v1.4.0"}},
+		Status:          "success",
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := promoteJSON(result)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	require.NoError(t, err)
+
+	var got promoteResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "ghcr.io/acme/cfg:rc-123", got.Source)
+	assert.Equal(t, "sha256:abc123", got.Digest)
+	assert.True(t, got.Verified)
+	assert.Equal(t, 1, got.PoliciesApplied)
+	require.Len(t, got.Targets, 1)
+	assert.Equal(t, "ghcr.io/acme/cfg:v1.4.0", got.Targets[0].Ref)
+	assert.False(t, got.Signed)
+	assert.Equal(t, "success", got.Status)
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/meigma/blob"
+	"github.com/spf13/cobra"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/policy"
+	"github.com/meigma/blob-cli/internal/proxy"
+)
+
+// defaultProxyAddr is the default listen address for blob proxy.
+const defaultProxyAddr = "localhost:8081"
+
+// proxyMaxClients bounds how many per-reference clients the proxy keeps.
+const proxyMaxClients = 64
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Serve archive files over a local HTTP API",
+	Long: `Serve archive files over a local HTTP API.
+
+Runs a read-through HTTP service so applications without an OCI client
+can fetch individual files. Files are read with range requests and the
+configured caches, exactly as "blob cat" does:
+
+  GET /v1/archives/{ref}/files/{path}
+
+Slashes in the reference must be escaped as %2F; aliases avoid this.
+Responses carry the file's SHA-256 as ETag. Errors are JSON objects
+with an "error" field: 404 for a missing archive or file, 403 when a
+verification policy from config rejects the archive, 502 for registry
+failures.
+
+The service has no authentication and listens on localhost by default.
+Only bind it to other interfaces on trusted networks.`,
+	Example: `  blob proxy
+  blob proxy --addr :8081
+  curl http://localhost:8081/v1/archives/configs:v1/files/app/config.yaml
+  curl http://localhost:8081/v1/archives/ghcr.io%2Facme%2Fconfigs:v1/files/nginx.conf`,
+	Args: cobra.NoArgs,
+	RunE: runProxy,
+}
+
+func init() {
+	proxyCmd.Flags().String("addr", defaultProxyAddr, "address to listen on")
+}
+
+func runProxy(cmd *cobra.Command, _ []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	addr, err := cmd.Flags().GetString("addr")
+	if err != nil {
+		return fmt.Errorf("reading addr flag: %w", err)
+	}
+
+	var logger *slog.Logger
+	if cfg.Verbose > 0 {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	clients := newProxyClients(cfg, proxyMaxClients)
+	srv := &http.Server{
+		Handler:           proxy.NewHandler(clients.open, logger),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	var lc net.ListenConfig
+	ln, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+
+	if !cfg.Quiet && cfg.Output != internalcfg.OutputJSON {
+		fmt.Printf("Serving archives on http://%s (Ctrl-C to stop)\n", ln.Addr())
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("serving: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down: %w", err)
+	}
+	return nil
+}
+
+// proxyClients opens archives for the proxy, reusing one client per
+// resolved reference so policies are only built once. Beyond max, the
+// least recently used client is dropped.
+type proxyClients struct {
+	cfg *internalcfg.Config
+	max int
+
+	mu      sync.Mutex
+	lru     *list.List // of *proxyClient, most recently used first
+	clients map[string]*list.Element
+}
+
+type proxyClient struct {
+	ref    string
+	client *blob.Client
+}
+
+func newProxyClients(cfg *internalcfg.Config, maxClients int) *proxyClients {
+	return &proxyClients{
+		cfg:     cfg,
+		max:     maxClients,
+		lru:     list.New(),
+		clients: make(map[string]*list.Element),
+	}
+}
+
+// open resolves aliases, applies config policies, and pulls the archive index.
+func (p *proxyClients) open(ctx context.Context, ref string) (fs.FS, error) {
+	resolvedRef := p.cfg.ResolveAlias(ref)
+
+	client, err := p.client(resolvedRef)
+	if err != nil {
+		return nil, err
+	}
+
+	blobArchive, err := client.Pull(ctx, resolvedRef)
+	if err != nil {
+		switch {
+		case errors.Is(err, blob.ErrPolicyViolation):
+			return nil, fmt.Errorf("%w: verification failed: %w", proxy.ErrForbidden, err)
+		case errors.Is(err, blob.ErrNotFound):
+			return nil, fmt.Errorf("%w: %w", proxy.ErrNotFound, err)
+		case errors.Is(err, blob.ErrInvalidReference):
+			return nil, fmt.Errorf("%w: %w", proxy.ErrBadRequest, err)
+		}
+		return nil, fmt.Errorf("pulling archive: %w", err)
+	}
+	return proxyArchive{blobArchive}, nil
+}
+
+func (p *proxyClients) client(resolvedRef string) (*blob.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if el, ok := p.clients[resolvedRef]; ok {
+		p.lru.MoveToFront(el)
+		return el.Value.(*proxyClient).client, nil //nolint:errcheck // the list only holds clients
+	}
+
+	policies, err := policy.BuildPolicies(p.cfg, resolvedRef, nil, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("building policies: %w", err)
+	}
+	policies = withTagReferrers(p.cfg, policies)
+	policyOpts := make([]blob.Option, 0, len(policies))
+	for _, pol := range policies {
+		policyOpts = append(policyOpts, blob.WithPolicy(pol))
+	}
+
+	client, err := newClient(p.cfg, policyOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating client: %w", err)
+	}
+	p.clients[resolvedRef] = p.lru.PushFront(&proxyClient{ref: resolvedRef, client: client})
+	for p.lru.Len() > max(p.max, 1) {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		delete(p.clients, oldest.Value.(*proxyClient).ref) //nolint:errcheck // the list only holds clients
+	}
+	return client, nil
+}
+
+// proxyArchive reports content hashes from the archive index for ETags.
+type proxyArchive struct {
+	*blob.Archive
+}
+
+func (a proxyArchive) FileHash(name string) ([]byte, bool) {
+	entry, ok := a.Entry(name)
+	if !ok {
+		return nil, false
+	}
+	return entry.HashBytes(), true
+}
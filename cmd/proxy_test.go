@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyClients_EvictsLeastRecentlyUsed(t *testing.T) {
+	clients := newProxyClients(newRegistryTestConfig(t), 2)
+
+	first, err := clients.client("example.com/acme/configs:v1")
+	require.NoError(t, err)
+	for _, ref := range []string{"example.com/acme/configs:v2", "example.com/acme/configs:v1", "example.com/acme/configs:v3"} {
+		_, err := clients.client(ref)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, clients.lru.Len())
+	assert.NotContains(t, clients.clients, "example.com/acme/configs:v2")
+	again, err := clients.client("example.com/acme/configs:v1")
+	require.NoError(t, err)
+	assert.Same(t, first, again, "v1 was used after v2 and kept")
+}
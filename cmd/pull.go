@@ -1,19 +1,27 @@
 package cmd
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/meigma/blob"
+	blobcore "github.com/meigma/blob/core"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
 	"github.com/meigma/blob-cli/internal/policy"
+	"github.com/meigma/blob-cli/internal/pullstate"
+	"github.com/meigma/blob-cli/internal/resume"
+	"github.com/meigma/blob-cli/internal/transfer"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 var pullCmd = &cobra.Command{
@@ -22,15 +30,71 @@ var pullCmd = &cobra.Command{
 	Long: `Pull an archive from an OCI registry to a local directory.
 
 Downloads and extracts the blob archive to the specified destination
-directory. If no path is provided, extracts to the current directory.
+directory. If no path is provided, extracts to the alias's default_dest
+from the config file, if the reference is an alias with one, or else to
+the current directory.
+With no arguments at all, the reference is taken from default_ref in the
+config file or BLOB_DEFAULT_REF, for containers configured only through
+the environment.
 
 Verification policies can be specified to enforce signature and
-attestation requirements before extraction.`,
+attestation requirements before extraction.
+
+--require-annotation refuses to extract archives whose manifest lacks an
+annotation (key) or has a different value (key=value), without writing
+a full policy. Failures exit with code 5.
+
+Each extracted file is recorded under the cache directory as it is
+written. If a pull is interrupted, rerun it with --resume to skip files
+that were already extracted and still match the archive; everything else
+is extracted again.
+
+--index-out saves the archive's file index (path, size, digest, mode, and
+compression of every file) as JSON. With --manifest-only, only the index
+is fetched and no files are downloaded or extracted, so tooling can plan
+or diff before committing to a download; the index is printed to stdout
+unless --index-out is given. Policies are still enforced.
+
+--prefix extracts only one directory of the archive, and
+--strip-components removes leading components from the path of each
+extracted file, as tar does; files whose path has no more components
+are not extracted. Together they place a subtree at the destination:
+--prefix /etc/nginx --strip-components 2 writes etc/nginx/nginx.conf
+to nginx.conf.
+
+The reference, the manifest digest it resolved to, and the digest of
+every file are recorded in a .blob-state file in the destination, which
+blob status reads to tell whether the directory is still current.
+
+--verify-checksums reads every extracted file back from disk and compares
+its SHA-256 digest with the archive index, to catch corruption from a
+broken proxy or disk after the download was verified. Each file's result
+is listed in JSON output, and any mismatch exits with code 8.
+
+Archives pushed with --priority list files to extract first. pull
+extracts those in the order listed, then runs the commands under
+hooks.priority_extracted in the config file, with BLOB_PULL_DIR and
+BLOB_PULL_REF set in their environment, while it extracts the rest, so
+a service can start before large data is in place. A failing hook is
+reported as a warning; --no-hooks skips them. With --strip-components
+files are extracted in the default order.
+
+Files already in the destination are kept, not overwritten. For
+provisioning that must never merge into an existing tree,
+--require-empty fails before anything is written unless the destination
+is missing or empty.`,
 	Example: `  blob pull ghcr.io/acme/configs:v1.0.0 ./local
   blob pull foo:v1 ./local                          # Using alias
   blob pull --policy policy.yaml ghcr.io/acme/configs:v1.0.0
-  blob pull --no-default-policy foo:v1 ./local      # Skip config policies`,
-	Args: cobra.RangeArgs(1, 2),
+  blob pull --no-default-policy foo:v1 ./local      # Skip config policies
+  blob pull --validate ghcr.io/acme/configs:v1.0.0 ./local
+  blob pull --require-annotation environment=production foo:v1 ./local
+  blob pull --resume ghcr.io/acme/data:v2 ./data     # Continue an interrupted pull
+  blob pull --manifest-only --index-out index.json ghcr.io/acme/data:v2
+  blob pull --prefix /etc/nginx --strip-components 2 foo:v1 ./nginx
+  blob pull --verify-checksums ghcr.io/acme/data:v2 ./data
+  blob pull --require-empty ghcr.io/acme/configs:v1.0.0 /srv/app`,
+	Args: defaultRefArgs(cobra.RangeArgs(1, 2)),
 	RunE: runPull,
 }
 
@@ -39,6 +103,24 @@ func init() {
 	pullCmd.Flags().String("policy-rego", "", "OPA Rego policy file")
 	pullCmd.Flags().Bool("no-default-policy", false, "skip policies from config file")
 	pullCmd.Flags().Bool("skip-cache", false, "bypass registry caches for this operation")
+	pullCmd.Flags().Bool("validate", false, "validate files against schemas from config before extracting")
+	pullCmd.Flags().StringArray("require-annotation", nil, "require a manifest annotation, as key or key=value (repeatable)")
+	pullCmd.Flags().Bool("resume", false, "skip files already extracted by an interrupted pull")
+	pullCmd.Flags().Bool("manifest-only", false, "fetch only the archive index, without extracting files")
+	pullCmd.Flags().String("index-out", "", "write the archive index as JSON to this path")
+	pullCmd.Flags().String("prefix", "", "extract only this directory of the archive")
+	pullCmd.Flags().Int("strip-components", 0, "remove this many leading path components from extracted files")
+	pullCmd.Flags().Bool("verify-checksums", false, "re-hash extracted files and report any that do not match the archive")
+	pullCmd.Flags().Bool("no-hooks", false, "skip priority_extracted hooks from config")
+	pullCmd.Flags().Bool("require-empty", false, "fail unless the destination directory is missing or empty")
+	pullCmd.MarkFlagsMutuallyExclusive("manifest-only", "resume")
+	pullCmd.MarkFlagsMutuallyExclusive("manifest-only", "validate")
+	pullCmd.MarkFlagsMutuallyExclusive("manifest-only", "prefix")
+	pullCmd.MarkFlagsMutuallyExclusive("manifest-only", "strip-components")
+	pullCmd.MarkFlagsMutuallyExclusive("resume", "strip-components")
+	pullCmd.MarkFlagsMutuallyExclusive("manifest-only", "verify-checksums")
+	pullCmd.MarkFlagsMutuallyExclusive("manifest-only", "require-empty")
+	pullCmd.MarkFlagsMutuallyExclusive("resume", "require-empty")
 }
 
 // pullResult contains the result of a pull operation.
@@ -51,6 +133,21 @@ type pullResult struct {
 	TotalSizeHuman string `json:"total_size_human,omitempty"`
 	Verified       bool   `json:"verified"`
 	PoliciesCount  int    `json:"policies_applied,omitempty"`
+	Resumed        int    `json:"resumed,omitempty"`
+	PriorityFiles  int    `json:"priority_files,omitempty"`
+	IndexOut       string `json:"index_out,omitempty"`
+
+	Checksums *checksumReport `json:"checksums,omitempty"`
+	Transfer  *transfer.Stats `json:"transfer,omitempty"`
+}
+
+// pullIndex is the archive index written by --index-out and
+// --manifest-only.
+type pullIndex struct {
+	Ref         string            `json:"ref"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	*archive.Index
 }
 
 // pullFlags holds the parsed command flags.
@@ -59,6 +156,23 @@ type pullFlags struct {
 	policyRego      string
 	noDefaultPolicy bool
 	skipCache       bool
+	validate        bool
+	requireAnnots   []requiredAnnotation
+	resume          bool
+	manifestOnly    bool
+	indexOut        string
+	prefix          string // normalized; "." for the whole archive
+	strip           int
+	verifyChecksums bool
+	noHooks         bool
+	requireEmpty    bool
+}
+
+// requiredAnnotation is a manifest annotation that must be present.
+type requiredAnnotation struct {
+	Key   string
+	Value string
+	Any   bool // Any value is accepted; only presence is required
 }
 
 func runPull(cmd *cobra.Command, args []string) error {
@@ -67,12 +181,18 @@ func runPull(cmd *cobra.Command, args []string) error {
 	if cfg == nil {
 		return errors.New("configuration not loaded")
 	}
+	args, err := withDefaultRef(cfg, args)
+	if err != nil {
+		return err
+	}
 
 	// 2. Parse arguments
 	inputRef := args[0]
 	destDir := "."
 	if len(args) > 1 {
 		destDir = args[1]
+	} else if d, ok := cfg.DefaultsFor(inputRef); ok && d.DefaultDest != "" {
+		destDir = d.DefaultDest
 	}
 
 	// 3. Parse flags
@@ -80,9 +200,16 @@ func runPull(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if flags.manifestOnly && len(args) > 1 {
+		return errors.New("--manifest-only does not extract files; omit the destination path")
+	}
 
 	// 4. Resolve alias FIRST (before policy matching)
-	resolvedRef := cfg.ResolveAlias(inputRef)
+	ctx := cmd.Context()
+	resolvedRef, err := resolveRef(ctx, cfg, inputRef)
+	if err != nil {
+		return err
+	}
 
 	// 5. Build policies from config + flags (before creating destination)
 	policies, err := policy.BuildPolicies(
@@ -95,8 +222,35 @@ func runPull(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("building policies: %w", err)
 	}
+	if cfg.Strict && len(policies) == 0 {
+		return &ExitError{
+			Code: exitCodeStrict,
+			Err:  fmt.Errorf("no policies apply to %s: --strict does not allow unverified pulls", resolvedRef),
+		}
+	}
+
+	// Offline, a pinned archive is extracted without the registry. It was
+	// verified when it was pinned; one that was not is refused if any
+	// policy applies.
+	if cfg.Offline && !flags.skipCache && !flags.manifestOnly {
+		if blobArchive, pin, ok := openPinned(cfg, resolvedRef); ok {
+			if err := checkPinVerified(resolvedRef, pin, len(policies)); err != nil {
+				return err
+			}
+			if err := checkPullAnnotations(pin.Annotations, flags.requireAnnots); err != nil {
+				return err
+			}
+			return extractPulled(ctx, cfg, &pulledArchive{
+				archive:      blobArchive,
+				digest:       pin.Digest,
+				annotations:  pin.Annotations,
+				verification: verificationStatus{Verified: pin.Verified, PoliciesApplied: len(policies)},
+			}, inputRef, resolvedRef, destDir, flags)
+		}
+	}
 
 	// 6. Create client with policies
+	policies = withTagReferrers(cfg, policies)
 	policyOpts := make([]blob.Option, 0, len(policies))
 	for _, p := range policies {
 		policyOpts = append(policyOpts, blob.WithPolicy(p))
@@ -115,12 +269,44 @@ func runPull(cmd *cobra.Command, args []string) error {
 	}
 
 	// 7. Pull archive (policy verification happens here)
-	ctx := cmd.Context()
 	var pullOpts []blob.PullOption
 	if flags.skipCache {
 		pullOpts = append(pullOpts, blob.PullWithSkipCache())
 	}
-	blobArchive, err := client.Pull(ctx, resolvedRef, pullOpts...)
+	pullRef := resolvedRef
+	var manifest *blob.Manifest
+	if len(flags.requireAnnots) > 0 || flags.manifestOnly || flags.indexOut != "" {
+		manifest, err = fetchPullManifest(ctx, client, resolvedRef, flags.skipCache)
+		if err != nil {
+			return err
+		}
+		if err := checkPullAnnotations(manifest.Annotations(), flags.requireAnnots); err != nil {
+			return err
+		}
+		// Pin the digest so the archive pulled is the one that was checked
+		pullRef = repositoryOf(resolvedRef) + "@" + manifest.Digest()
+	}
+	if flags.manifestOnly {
+		return pullIndexOnly(ctx, cfg, client, inputRef, pullRef, manifest, flags)
+	}
+	pullDigest := ""
+	var annotations map[string]string
+	if manifest != nil {
+		pullDigest = manifest.Digest()
+		annotations = manifest.Annotations()
+	} else {
+		// Pin the digest so the state recorded for blob status names the
+		// archive that was extracted
+		pullDigest, annotations, err = resolveManifest(ctx, cfg, resolvedRef)
+		if err != nil {
+			if errors.Is(err, errNoTagOrDigest) {
+				return fmt.Errorf("invalid reference %q: %w", inputRef, err)
+			}
+			return archiveError(fmt.Errorf("pulling archive: %w", err))
+		}
+		pullRef = repositoryOf(resolvedRef) + "@" + pullDigest
+	}
+	blobArchive, err := client.Pull(ctx, pullRef, pullOpts...)
 	if err != nil {
 		if errors.Is(err, blob.ErrPolicyViolation) {
 			return fmt.Errorf("verification failed: %w", err)
@@ -128,30 +314,86 @@ func runPull(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pulling archive: %w", err)
 	}
 
-	// 8. Prepare destination directory (only after successful pull)
-	destDir, err = prepareDestination(destDir)
+	return extractPulled(ctx, cfg, &pulledArchive{
+		archive:      blobArchive,
+		digest:       pullDigest,
+		annotations:  annotations,
+		verification: verificationStatus{Verified: len(policies) > 0, PoliciesApplied: len(policies)},
+	}, inputRef, resolvedRef, destDir, flags)
+}
+
+// pulledArchive is an archive to extract and what is known about it.
+type pulledArchive struct {
+	archive      *blob.Archive
+	digest       string
+	annotations  map[string]string
+	verification verificationStatus
+}
+
+// extractPulled extracts pulled into destDir and reports the result.
+func extractPulled(ctx context.Context, cfg *internalcfg.Config, pulled *pulledArchive, inputRef, resolvedRef, destDir string, flags pullFlags) error {
+	blobArchive := pulled.archive
+	if flags.prefix != "." && !blobArchive.IsDir(flags.prefix) {
+		return pathNotFoundError(fmt.Errorf("directory not found in archive: %s", flags.prefix))
+	}
+
+	// 8. Validate content against schemas (before writing anything)
+	if flags.validate {
+		if err := validateContent(cfg, blobArchive); err != nil {
+			return err
+		}
+	}
+
+	// 9. Prepare destination directory (only after successful pull and validation)
+	if flags.requireEmpty {
+		if err := requireEmptyDestination(destDir); err != nil {
+			return err
+		}
+	}
+	destDir, err := prepareDestination(destDir)
 	if err != nil {
 		return err
 	}
 
-	// 9. Extract files
-	copyOpts := []blob.CopyOption{
-		blob.CopyWithOverwrite(false),
-		blob.CopyWithPreserveMode(true),
-		blob.CopyWithPreserveTimes(true),
+	// 10. Extract files
+	var files *extractedFiles
+	if flags.verifyChecksums {
+		files = &extractedFiles{}
 	}
-	copyStats, err := blobArchive.CopyDir(destDir, ".", copyOpts...)
+	pri := newPullPriority(ctx, cfg, pulled.annotations, resolvedRef, destDir, flags.noHooks)
+	copyStats, resumed, err := extractPull(cfg, blobArchive, destDir, flags, files, pri)
 	if err != nil {
-		return fmt.Errorf("extracting files: %w", err)
+		return err
 	}
+	collectCaches(cfg)
 
-	// 10. Build result
+	state := newPullState(blobArchive, inputRef, resolvedRef, pulled.digest, flags)
+	if err := pullstate.Write(destDir, state); err != nil {
+		warn.Printf("%v", err)
+	}
+
+	if flags.indexOut != "" {
+		index, err := blobcore.NewIndexView(blobArchive.IndexData())
+		if err != nil {
+			return fmt.Errorf("reading archive index: %w", err)
+		}
+		if err := writePullIndex(flags.indexOut, newPullIndex(inputRef, pulled.digest, pulled.annotations, index)); err != nil {
+			return err
+		}
+	}
+
+	// 11. Build result
 	result := pullResult{
 		Ref:         inputRef,
 		Destination: destDir,
 		FileCount:   copyStats.FileCount,
 		TotalSize:   copyStats.TotalBytes,
-		Verified:    len(policies) > 0,
+		Verified:    pulled.verification.Verified,
+		Resumed:     resumed,
+		IndexOut:    flags.indexOut,
+	}
+	if pri != nil {
+		result.PriorityFiles = pri.files
 	}
 
 	if inputRef != resolvedRef {
@@ -160,12 +402,17 @@ func runPull(cmd *cobra.Command, args []string) error {
 
 	result.TotalSizeHuman = archive.FormatSize(result.TotalSize)
 
-	if len(policies) > 0 {
-		result.PoliciesCount = len(policies)
+	result.PoliciesCount = pulled.verification.PoliciesApplied
+
+	if files != nil {
+		result.Checksums = verifyChecksums(files.files)
 	}
 
-	// 11. Output result
-	return outputPullResult(cfg, &result)
+	// 12. Output result
+	if err := outputPullResult(cfg, &result); err != nil {
+		return err
+	}
+	return checksumError(result.Checksums)
 }
 
 // parsePullFlags extracts and validates flags from the command.
@@ -193,10 +440,206 @@ func parsePullFlags(cmd *cobra.Command) (pullFlags, error) {
 		return flags, fmt.Errorf("reading skip-cache flag: %w", err)
 	}
 
+	flags.validate, err = cmd.Flags().GetBool("validate")
+	if err != nil {
+		return flags, fmt.Errorf("reading validate flag: %w", err)
+	}
+
+	required, err := cmd.Flags().GetStringArray("require-annotation")
+	if err != nil {
+		return flags, fmt.Errorf("reading require-annotation flag: %w", err)
+	}
+	flags.requireAnnots, err = parseRequiredAnnotations(required)
+	if err != nil {
+		return flags, err
+	}
+
+	flags.manifestOnly, err = cmd.Flags().GetBool("manifest-only")
+	if err != nil {
+		return flags, fmt.Errorf("reading manifest-only flag: %w", err)
+	}
+
+	flags.indexOut, err = cmd.Flags().GetString("index-out")
+	if err != nil {
+		return flags, fmt.Errorf("reading index-out flag: %w", err)
+	}
+
+	flags.resume, err = cmd.Flags().GetBool("resume")
+	if err != nil {
+		return flags, fmt.Errorf("reading resume flag: %w", err)
+	}
+
+	prefix, err := cmd.Flags().GetString("prefix")
+	if err != nil {
+		return flags, fmt.Errorf("reading prefix flag: %w", err)
+	}
+	flags.prefix = blob.NormalizePath(prefix)
+	if !fs.ValidPath(flags.prefix) {
+		return flags, fmt.Errorf("invalid --prefix %q", prefix)
+	}
+
+	flags.strip, err = cmd.Flags().GetInt("strip-components")
+	if err != nil {
+		return flags, fmt.Errorf("reading strip-components flag: %w", err)
+	}
+	if flags.strip < 0 {
+		return flags, errors.New("--strip-components must not be negative")
+	}
+
+	flags.verifyChecksums, err = cmd.Flags().GetBool("verify-checksums")
+	if err != nil {
+		return flags, fmt.Errorf("reading verify-checksums flag: %w", err)
+	}
+
+	flags.noHooks, err = cmd.Flags().GetBool("no-hooks")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-hooks flag: %w", err)
+	}
+
+	flags.requireEmpty, err = cmd.Flags().GetBool("require-empty")
+	if err != nil {
+		return flags, fmt.Errorf("reading require-empty flag: %w", err)
+	}
+
 	return flags, nil
 }
 
+// parseRequiredAnnotations parses "key" and "key=value" arguments.
+func parseRequiredAnnotations(args []string) ([]requiredAnnotation, error) {
+	required := make([]requiredAnnotation, 0, len(args))
+	for _, arg := range args {
+		key, value, hasValue := strings.Cut(arg, "=")
+		if key == "" {
+			return nil, fmt.Errorf("invalid --require-annotation %q: must be key or key=value", arg)
+		}
+		required = append(required, requiredAnnotation{Key: key, Value: value, Any: !hasValue})
+	}
+	return required, nil
+}
+
+// fetchPullManifest fetches the manifest for ref, evaluating the client's
+// policies.
+func fetchPullManifest(ctx context.Context, client *blob.Client, ref string, skipCache bool) (*blob.Manifest, error) {
+	var fetchOpts []blob.FetchOption
+	if skipCache {
+		fetchOpts = append(fetchOpts, blob.FetchWithSkipCache())
+	}
+	manifest, err := client.Fetch(ctx, ref, fetchOpts...)
+	if err != nil {
+		if errors.Is(err, blob.ErrPolicyViolation) {
+			return nil, fmt.Errorf("verification failed: %w", err)
+		}
+		return nil, fmt.Errorf("fetching manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// checkPullAnnotations checks the annotations of manifest against the
+// required annotations.
+func checkPullAnnotations(annotations map[string]string, required []requiredAnnotation) error {
+	if problems := missingAnnotations(annotations, required); len(problems) > 0 {
+		return &ExitError{
+			Code: exitCodePolicyViolation,
+			Err:  fmt.Errorf("required annotations not satisfied: %s", strings.Join(problems, "; ")),
+		}
+	}
+	return nil
+}
+
+// pullIndexOnly fetches the index of the archive at pinnedRef without its
+// data and writes it to --index-out, or to stdout.
+func pullIndexOnly(ctx context.Context, cfg *internalcfg.Config, client *blob.Client, inputRef, pinnedRef string, manifest *blob.Manifest, flags pullFlags) error {
+	var inspectOpts []blob.InspectOption
+	if flags.skipCache {
+		inspectOpts = append(inspectOpts, blob.InspectWithSkipCache())
+	}
+	result, err := client.Inspect(ctx, pinnedRef, inspectOpts...)
+	if err != nil {
+		return fmt.Errorf("fetching archive index: %w", err)
+	}
+	index := newPullIndex(inputRef, manifest.Digest(), manifest.Annotations(), result.Index())
+
+	if flags.indexOut == "" || flags.indexOut == "-" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(index)
+	}
+	if err := writePullIndex(flags.indexOut, index); err != nil {
+		return err
+	}
+
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		return pullJSON(&pullResult{
+			Ref:            inputRef,
+			FileCount:      index.Files,
+			TotalSize:      index.TotalSize,
+			TotalSizeHuman: archive.FormatSize(index.TotalSize),
+			IndexOut:       flags.indexOut,
+			Transfer:       transferStats(),
+		})
+	}
+	fmt.Printf("Saved index of %s to %s\n", inputRef, flags.indexOut)
+	fmt.Printf("  Files: %d\n", index.Files)
+	fmt.Printf("  Size: %s\n", archive.FormatSize(index.TotalSize))
+	return nil
+}
+
+// newPullIndex describes index for the archive with manifest.
+func newPullIndex(ref, digest string, annotations map[string]string, index *blob.IndexView) *pullIndex {
+	return &pullIndex{
+		Ref:         ref,
+		Digest:      digest,
+		Annotations: annotations,
+		Index:       archive.NewIndex(index),
+	}
+}
+
+// writePullIndex writes index as JSON to path.
+func writePullIndex(path string, index *pullIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding archive index: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil { //nolint:gosec // the index is not secret
+		return fmt.Errorf("writing archive index: %w", err)
+	}
+	return nil
+}
+
+// missingAnnotations describes each requirement not met by annotations.
+func missingAnnotations(annotations map[string]string, required []requiredAnnotation) []string {
+	var problems []string
+	for _, r := range required {
+		got, ok := annotations[r.Key]
+		switch {
+		case !ok:
+			problems = append(problems, r.Key+" is missing")
+		case !r.Any && got != r.Value:
+			problems = append(problems, fmt.Sprintf("%s is %q, want %q", r.Key, got, r.Value))
+		}
+	}
+	return problems
+}
+
 // prepareDestination validates and prepares the destination directory.
+// requireEmptyDestination returns an error, for --require-empty, if
+// destDir exists and is not an empty directory.
+func requireEmptyDestination(destDir string) error {
+	entries, err := os.ReadDir(destDir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("checking destination: %w", err)
+	case len(entries) > 0:
+		return fmt.Errorf("destination %s is not empty (--require-empty): it contains %s", destDir, entries[0].Name())
+	}
+	return nil
+}
+
 func prepareDestination(destDir string) (string, error) {
 	// Convert to absolute path
 	absPath, err := filepath.Abs(destDir)
@@ -225,12 +668,123 @@ func prepareDestination(destDir string) (string, error) {
 	return absPath, nil
 }
 
+// extractPull extracts the archive, or the directory flags.prefix of it,
+// into destDir, recording each file in a resume journal. With flags.resume,
+// files an earlier attempt recorded that still match the archive are
+// skipped and counted in the returned resumed count; recorded files that no
+// longer match are overwritten. Journal errors only prevent resuming later,
+// so without resume they are not fatal. Files extracted with
+// --strip-components are not journaled. Extracted files are recorded in
+// files, if it is not nil; resumed files are not. Files pri lists are
+// extracted first, except with --strip-components.
+func extractPull(cfg *internalcfg.Config, blobArchive *blob.Archive, destDir string, flags pullFlags, files *extractedFiles, pri *pullPriority) (blob.CopyStats, int, error) {
+	copyOpts := []blob.CopyOption{
+		blob.CopyWithPreserveMode(true),
+		blob.CopyWithPreserveTimes(true),
+	}
+	if flags.strip > 0 {
+		stats, err := extractStripped(blobArchive, destDir, flags.prefix, flags.strip, copyOpts, files)
+		if err != nil {
+			return stats, 0, fmt.Errorf("extracting files: %w", err)
+		}
+		return stats, 0, nil
+	}
+
+	journal, err := openPullJournal(cfg, blobArchive, destDir)
+	if err != nil {
+		if flags.resume {
+			return blob.CopyStats{}, 0, err
+		}
+		if files != nil {
+			copyOpts = append(copyOpts, files.copyOption(blobArchive, destDir))
+		}
+		stats, err := copyPullDir(blobArchive, destDir, flags.prefix, copyOpts, pri)
+		if err != nil {
+			return stats, 0, fmt.Errorf("extracting files: %w", err)
+		}
+		return stats, 0, nil
+	}
+	copyOpts = append(copyOpts, blobcore.CopyWithProgress(func(ev blob.ProgressEvent) {
+		if entry, ok := blobArchive.Entry(ev.Path); ok && ev.Stage == blob.StageExtracting {
+			journal.Record(ev.Path, hex.EncodeToString(entry.HashBytes()))
+			files.add(blobArchive, ev.Path, filepath.Join(destDir, filepath.FromSlash(ev.Path)))
+		}
+	}))
+
+	var stats blob.CopyStats
+	var resumed int
+	if flags.resume && journal.Len() > 0 {
+		stats, resumed, err = resumePull(blobArchive, destDir, flags.prefix, journal, copyOpts, pri)
+	} else {
+		stats, err = copyPullDir(blobArchive, destDir, flags.prefix, copyOpts, pri)
+	}
+	if err != nil {
+		if closeErr := journal.Close(); closeErr != nil {
+			return stats, resumed, fmt.Errorf("extracting files: %w", errors.Join(err, closeErr))
+		}
+		return stats, resumed, fmt.Errorf("extracting files: %w (rerun with --resume to skip files already extracted)", err)
+	}
+	if err := journal.Remove(); err != nil {
+		warn.Printf("%v", err)
+	}
+	return stats, resumed, nil
+}
+
+// copyPullDir extracts the files of blobArchive under prefix into destDir,
+// leaving existing files alone, those pri lists first.
+func copyPullDir(blobArchive *blob.Archive, destDir, prefix string, copyOpts []blob.CopyOption, pri *pullPriority) (blob.CopyStats, error) {
+	if pri == nil {
+		return blobArchive.CopyDir(destDir, prefix, append(copyOpts, blob.CopyWithOverwrite(false))...)
+	}
+	return pri.extract(blobArchive, destDir, []copyBatch{{paths: pullFiles(blobArchive, prefix)}}, copyOpts)
+}
+
+// openPullJournal opens the resume journal for extracting blobArchive into
+// destDir.
+func openPullJournal(cfg *internalcfg.Config, blobArchive *blob.Archive, destDir string) (*resume.Journal, error) {
+	cacheDir, err := resolveCacheDir(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("resolving cache directory: %w", err)
+	}
+	return resume.Open(cacheDir, resume.ArchiveID(blobArchive.IndexData()), destDir)
+}
+
+// resumePull extracts the files of blobArchive under prefix that journal
+// does not record as extracted with matching content on disk, those pri
+// lists first.
+func resumePull(blobArchive *blob.Archive, destDir, prefix string, journal *resume.Journal, copyOpts []blob.CopyOption, pri *pullPriority) (blob.CopyStats, int, error) {
+	var redo, rest []string
+	var resumed int
+	for entry := range pullEntries(blobArchive, prefix) {
+		if entry.Mode().IsDir() {
+			continue
+		}
+		path := entry.Path()
+		hash := hex.EncodeToString(entry.HashBytes())
+		recorded, ok := journal.Completed(path)
+		switch {
+		case !ok:
+			rest = append(rest, path)
+		case recorded == hash && resume.Verify(filepath.Join(destDir, filepath.FromSlash(path)), entry.OriginalSize(), hash):
+			resumed++
+		default:
+			redo = append(redo, path)
+		}
+	}
+
+	// Files this pull wrote earlier are replaced; other existing files are
+	// left alone, as in a pull without --resume.
+	stats, err := pri.extract(blobArchive, destDir, []copyBatch{{paths: redo, overwrite: true}, {paths: rest}}, copyOpts)
+	return stats, resumed, err
+}
+
 // outputPullResult formats and outputs the pull result.
 func outputPullResult(cfg *internalcfg.Config, result *pullResult) error {
 	if cfg.Quiet {
 		return nil
 	}
-	if viper.GetString("output") == internalcfg.OutputJSON {
+	if cfg.Output == internalcfg.OutputJSON {
+		result.Transfer = transferStats()
 		return pullJSON(result)
 	}
 	return pullText(result)
@@ -249,11 +803,23 @@ func pullText(result *pullResult) error {
 	}
 	fmt.Printf("  Destination: %s\n", result.Destination)
 	fmt.Printf("  Files: %d\n", result.FileCount)
+	if result.Resumed > 0 {
+		fmt.Printf("  Resumed: %d already extracted\n", result.Resumed)
+	}
+	if result.PriorityFiles > 0 {
+		fmt.Printf("  Priority: %d extracted first\n", result.PriorityFiles)
+	}
 	fmt.Printf("  Size: %s\n", result.TotalSizeHuman)
+	if result.IndexOut != "" {
+		fmt.Printf("  Index: %s\n", result.IndexOut)
+	}
 
 	if result.Verified {
 		fmt.Printf("  Verified: %d policies applied\n", result.PoliciesCount)
 	}
+	if result.Checksums != nil {
+		printChecksumReport(result.Checksums)
+	}
 
 	return nil
 }
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/meigma/blob"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/hooks"
+	"github.com/meigma/blob-cli/internal/priority"
+	"github.com/meigma/blob-cli/internal/warn"
+)
+
+// pullPriority orders extraction by the priority patterns an archive
+// declares in its manifest.
+type pullPriority struct {
+	patterns []string
+
+	// ready is called once the files matching patterns are extracted,
+	// before the rest, with their count.
+	ready func(files int)
+
+	// files is the number of priority files extracted.
+	files int
+}
+
+// newPullPriority returns the extraction priority declared in annotations,
+// or nil if there is none. On reaching the end of the priority files it
+// announces them and runs the priority_extracted hooks, unless noHooks is
+// set. A malformed annotation is reported and ignored.
+func newPullPriority(ctx context.Context, cfg *internalcfg.Config, annotations map[string]string, ref, destDir string, noHooks bool) *pullPriority {
+	patterns, err := priority.FromAnnotations(annotations)
+	if err != nil {
+		warn.Printf("%v; extracting in the default order", err)
+		return nil
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	p := &pullPriority{patterns: patterns}
+	p.ready = func(files int) {
+		if !cfg.Quiet {
+			fmt.Fprintf(os.Stderr, "Extracted %s\n", pluralize(files, "priority file", "priority files"))
+		}
+		if noHooks || len(cfg.Hooks.PriorityExtracted) == 0 {
+			return
+		}
+		runner := &hooks.Runner{}
+		if err := runner.Run(ctx, "priority_extracted", cfg.Hooks.PriorityExtracted, map[string]string{
+			hooks.EnvPullDir: destDir,
+			hooks.EnvPullRef: ref,
+		}); err != nil {
+			warn.Printf("%v", err)
+		}
+	}
+	return p
+}
+
+// copyBatch is a set of files extracted in one copy.
+type copyBatch struct {
+	paths     []string
+	overwrite bool
+}
+
+// extract copies batches of files from blobArchive into destDir, the
+// files matching the priority patterns first, in pattern order, and calls
+// p.ready between them and the rest. A nil p copies the batches in order.
+func (p *pullPriority) extract(blobArchive *blob.Archive, destDir string, batches []copyBatch, opts []blob.CopyOption) (blob.CopyStats, error) {
+	if p == nil {
+		return copyBatches(blobArchive, destDir, batches, opts)
+	}
+
+	var first, rest []copyBatch
+	ranked := make([][]copyBatch, len(p.patterns))
+	for _, b := range batches {
+		ordered, others := priority.Order(p.patterns, b.paths)
+		for i, paths := range ordered {
+			ranked[i] = append(ranked[i], copyBatch{paths: paths, overwrite: b.overwrite})
+			p.files += len(paths)
+		}
+		rest = append(rest, copyBatch{paths: others, overwrite: b.overwrite})
+	}
+	for _, r := range ranked {
+		first = append(first, r...)
+	}
+
+	stats, err := copyBatches(blobArchive, destDir, first, opts)
+	if err != nil {
+		return stats, err
+	}
+	p.ready(p.files)
+	s, err := copyBatches(blobArchive, destDir, rest, opts)
+	stats.FileCount += s.FileCount
+	stats.TotalBytes += s.TotalBytes
+	stats.Skipped += s.Skipped
+	return stats, err
+}
+
+// copyBatches copies each batch of files from blobArchive into destDir,
+// in order.
+func copyBatches(blobArchive *blob.Archive, destDir string, batches []copyBatch, opts []blob.CopyOption) (blob.CopyStats, error) {
+	var stats blob.CopyStats
+	for _, b := range batches {
+		if len(b.paths) == 0 {
+			continue
+		}
+		s, err := blobArchive.CopyToWithOptions(destDir, b.paths, append(opts, blob.CopyWithOverwrite(b.overwrite))...)
+		stats.FileCount += s.FileCount
+		stats.TotalBytes += s.TotalBytes
+		stats.Skipped += s.Skipped
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// pullFiles returns the paths of the files of blobArchive under prefix.
+func pullFiles(blobArchive *blob.Archive, prefix string) []string {
+	var paths []string
+	for entry := range pullEntries(blobArchive, prefix) {
+		if !entry.Mode().IsDir() {
+			paths = append(paths, entry.Path())
+		}
+	}
+	return paths
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/priority"
+)
+
+func TestExtractPull_Priority(t *testing.T) {
+	arch := newTestArchive(t, map[string]string{
+		"data/big.bin":  "big",
+		"etc/app.conf":  "app",
+		"etc/db.yaml":   "db",
+		"certs/ca.pem":  "ca",
+		"docs/README":   "readme",
+		"etc/notes.txt": "notes",
+	})
+	cfg := &internalcfg.Config{Cache: internalcfg.CacheConfig{Dir: t.TempDir()}}
+	dest := t.TempDir()
+
+	var readyFiles int
+	pri := &pullPriority{
+		patterns: []string{"etc/*.conf", "certs", "etc/*.yaml"},
+		ready: func(files int) {
+			readyFiles = files
+			for _, name := range []string{"etc/app.conf", "etc/db.yaml", "certs/ca.pem"} {
+				assert.FileExists(t, filepath.Join(dest, filepath.FromSlash(name)))
+			}
+			for _, name := range []string{"data/big.bin", "docs/README", "etc/notes.txt"} {
+				assert.NoFileExists(t, filepath.Join(dest, filepath.FromSlash(name)), "extracted after the priority files")
+			}
+		},
+	}
+	stats, _, err := extractPull(cfg, arch, dest, pullFlags{prefix: "."}, nil, pri)
+	require.NoError(t, err)
+	assert.Equal(t, 3, readyFiles)
+	assert.Equal(t, 3, pri.files)
+	assert.Equal(t, 6, stats.FileCount)
+	assert.FileExists(t, filepath.Join(dest, "data", "big.bin"))
+}
+
+func TestNewPullPriority(t *testing.T) {
+	cfg := &internalcfg.Config{Quiet: true}
+	ctx := context.Background()
+	assert.Nil(t, newPullPriority(ctx, cfg, nil, "ghcr.io/acme/app:v1", t.TempDir(), false))
+	assert.Nil(t, newPullPriority(ctx, cfg, map[string]string{priority.Annotation: "["}, "ghcr.io/acme/app:v1", t.TempDir(), false),
+		"a malformed annotation is ignored")
+
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests use POSIX shell syntax")
+	}
+	dest := t.TempDir()
+	marker := filepath.Join(t.TempDir(), "ready")
+	cfg.Hooks.PriorityExtracted = []string{`echo "$BLOB_PULL_REF $BLOB_PULL_DIR" > ` + marker}
+	pri := newPullPriority(ctx, cfg, map[string]string{priority.Annotation: "etc"}, "ghcr.io/acme/app:v1", dest, false)
+	require.NotNil(t, pri)
+	assert.Equal(t, []string{"etc"}, pri.patterns)
+	pri.ready(1)
+	data, err := os.ReadFile(marker)
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/acme/app:v1 "+dest+"\n", string(data))
+
+	// --no-hooks skips them
+	require.NoError(t, os.Remove(marker))
+	newPullPriority(ctx, cfg, map[string]string{priority.Annotation: "etc"}, "ghcr.io/acme/app:v1", dest, true).ready(1)
+	assert.NoFileExists(t, marker)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/meigma/blob"
+)
+
+// pullEntries returns the entries of blobArchive under the directory
+// prefix, or every entry if prefix is "" or ".".
+func pullEntries(blobArchive *blob.Archive, prefix string) iter.Seq[blob.EntryView] {
+	if prefix == "" || prefix == "." {
+		return blobArchive.Entries()
+	}
+	return blobArchive.EntriesWithPrefix(prefix + "/")
+}
+
+// stripPath removes the first n components of the slash-separated path p.
+// It reports false if nothing is left.
+func stripPath(p string, n int) (string, bool) {
+	parts := strings.SplitN(p, "/", n+1)
+	if len(parts) <= n {
+		return "", false
+	}
+	return parts[n], true
+}
+
+// extractStripped extracts the files of blobArchive under prefix into
+// destDir with their first strip path components removed, as tar's
+// --strip-components does. Files with no more components than that are
+// left out. Existing files are left alone, as in a pull without
+// --strip-components, and of several files stripped to the same path only
+// the first is extracted.
+//
+// The files are extracted into a staging directory inside destDir, so they
+// are read in one batch, and then renamed into place, where they are
+// recorded in files if it is not nil.
+func extractStripped(blobArchive *blob.Archive, destDir, prefix string, strip int, copyOpts []blob.CopyOption, files *extractedFiles) (blob.CopyStats, error) {
+	var stats blob.CopyStats
+	var paths []string
+	targets := make(map[string]string)
+	claimed := make(map[string]bool)
+	for entry := range pullEntries(blobArchive, prefix) {
+		if entry.Mode().IsDir() {
+			continue
+		}
+		target, ok := stripPath(entry.Path(), strip)
+		if !ok {
+			continue
+		}
+		if claimed[target] {
+			stats.Skipped++
+			continue
+		}
+		claimed[target] = true
+		if _, err := os.Lstat(filepath.Join(destDir, filepath.FromSlash(target))); err == nil {
+			stats.Skipped++
+			continue
+		}
+		paths = append(paths, entry.Path())
+		targets[entry.Path()] = target
+	}
+	if len(paths) == 0 {
+		return stats, nil
+	}
+
+	stage, err := os.MkdirTemp(destDir, ".blob-pull-")
+	if err != nil {
+		return stats, fmt.Errorf("creating staging directory: %w", err)
+	}
+	defer os.RemoveAll(stage)
+
+	copied, err := blobArchive.CopyToWithOptions(stage, paths, copyOpts...)
+	if err != nil {
+		return stats, err
+	}
+	for _, p := range paths {
+		dest := filepath.Join(destDir, filepath.FromSlash(targets[p]))
+		if err := ensureDir(filepath.Dir(dest)); err != nil {
+			return stats, err
+		}
+		if err := os.Rename(filepath.Join(stage, filepath.FromSlash(p)), dest); err != nil {
+			return stats, err
+		}
+		files.add(blobArchive, p, dest)
+	}
+	stats.FileCount = copied.FileCount
+	stats.TotalBytes = copied.TotalBytes
+	return stats, nil
+}
@@ -3,18 +3,37 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 
-	"github.com/spf13/viper"
+	blobcore "github.com/meigma/blob/core"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
 )
 
+func TestRequireEmptyDestination(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, requireEmptyDestination(dir))
+	require.NoError(t, requireEmptyDestination(filepath.Join(dir, "missing")))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.conf"), []byte("x"), 0o644))
+	err := requireEmptyDestination(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not empty")
+	assert.Contains(t, err.Error(), "app.conf")
+
+	// A file is not a directory to extract into
+	err = requireEmptyDestination(filepath.Join(dir, "app.conf"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checking destination")
+}
+
 func TestPrepareDestination(t *testing.T) {
 	t.Run("existing directory", func(t *testing.T) {
 		dir := t.TempDir()
@@ -57,8 +76,6 @@ func TestPrepareDestination(t *testing.T) {
 }
 
 func TestPullCmd_NilConfig(t *testing.T) {
-	viper.Reset()
-
 	// Don't set config in context
 	ctx := context.Background()
 
@@ -70,8 +87,6 @@ func TestPullCmd_NilConfig(t *testing.T) {
 }
 
 func TestPullCmd_InvalidReference(t *testing.T) {
-	viper.Reset()
-
 	dir := t.TempDir()
 	cfg := &internalcfg.Config{}
 	ctx := internalcfg.WithConfig(context.Background(), cfg)
@@ -84,6 +99,21 @@ func TestPullCmd_InvalidReference(t *testing.T) {
 	assert.Contains(t, err.Error(), "pulling archive")
 }
 
+func TestPullCmd_StrictRequiresPolicy(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &internalcfg.Config{Strict: true}
+	ctx := internalcfg.WithConfig(context.Background(), cfg)
+
+	pullCmd.SetContext(ctx)
+	err := pullCmd.RunE(pullCmd, []string{"ghcr.io/acme/configs:v1", dir})
+
+	// Refused before anything is fetched
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, exitCodeStrict, exitErr.Code)
+	assert.Contains(t, err.Error(), "unverified pulls")
+}
+
 func TestPullText(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -223,8 +253,6 @@ func TestPullJSON(t *testing.T) {
 }
 
 func TestOutputPullResult_Quiet(t *testing.T) {
-	viper.Reset()
-
 	cfg := &internalcfg.Config{Quiet: true}
 	result := &pullResult{
 		Ref:         "ghcr.io/test:v1",
@@ -248,3 +276,266 @@ func TestOutputPullResult_Quiet(t *testing.T) {
 	require.NoError(t, err)
 	assert.Empty(t, buf.String(), "quiet mode should produce no output")
 }
+
+func TestParseRequiredAnnotations(t *testing.T) {
+	got, err := parseRequiredAnnotations([]string{"environment=production", "reviewed", "note="})
+	require.NoError(t, err)
+	assert.Equal(t, []requiredAnnotation{
+		{Key: "environment", Value: "production"},
+		{Key: "reviewed", Any: true},
+		{Key: "note", Value: ""},
+	}, got)
+
+	_, err = parseRequiredAnnotations([]string{"=value"})
+	require.Error(t, err)
+}
+
+func TestMissingAnnotations(t *testing.T) {
+	annotations := map[string]string{
+		"environment": "staging",
+		"reviewed":    "alice",
+	}
+
+	tests := []struct {
+		name     string
+		required []requiredAnnotation
+		want     []string
+	}{
+		{
+			name:     "all satisfied",
+			required: []requiredAnnotation{{Key: "environment", Value: "staging"}, {Key: "reviewed", Any: true}},
+		},
+		{
+			name:     "missing key",
+			required: []requiredAnnotation{{Key: "team", Any: true}},
+			want:     []string{"team is missing"},
+		},
+		{
+			name:     "wrong value",
+			required: []requiredAnnotation{{Key: "environment", Value: "production"}},
+			want:     []string{`environment is "staging", want "production"`},
+		},
+		{
+			name:     "reports every problem",
+			required: []requiredAnnotation{{Key: "environment", Value: "production"}, {Key: "team", Value: "platform"}},
+			want:     []string{`environment is "staging", want "production"`, "team is missing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, missingAnnotations(annotations, tt.required))
+		})
+	}
+}
+
+func TestExtractPull_Resume(t *testing.T) {
+	arch := newTestArchive(t, map[string]string{
+		"a.txt":     "alpha",
+		"b.txt":     "bravo",
+		"dir/c.txt": "charlie",
+	})
+	cfg := &internalcfg.Config{Cache: internalcfg.CacheConfig{Dir: t.TempDir()}}
+	dest := t.TempDir()
+
+	// Simulate an interrupted pull that extracted a.txt and b.txt, then
+	// b.txt was modified.
+	journal, err := openPullJournal(cfg, arch, dest)
+	require.NoError(t, err)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		entry, ok := arch.Entry(name)
+		require.True(t, ok)
+		data, err := arch.ReadFile(name)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dest, name), data, 0o644))
+		journal.Record(name, hex.EncodeToString(entry.HashBytes()))
+	}
+	require.NoError(t, journal.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dest, "b.txt"), []byte("tampered"), 0o644))
+
+	stats, resumed, err := extractPull(cfg, arch, dest, pullFlags{prefix: ".", resume: true}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resumed)
+	assert.Equal(t, 2, stats.FileCount)
+
+	data, err := os.ReadFile(filepath.Join(dest, "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "bravo", string(data))
+	data, err = os.ReadFile(filepath.Join(dest, "dir", "c.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "charlie", string(data))
+
+	// A completed pull removes its journal.
+	entries, err := os.ReadDir(filepath.Join(cfg.Cache.Dir, "resume"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestExtractPull_KeepsExistingFiles(t *testing.T) {
+	arch := newTestArchive(t, map[string]string{"a.txt": "alpha"})
+	cfg := &internalcfg.Config{Cache: internalcfg.CacheConfig{Dir: t.TempDir()}}
+	dest := t.TempDir()
+
+	// A file already in the destination that the pull did not write is
+	// left alone, with or without --resume.
+	require.NoError(t, os.WriteFile(filepath.Join(dest, "a.txt"), []byte("local"), 0o644))
+	stats, resumed, err := extractPull(cfg, arch, dest, pullFlags{prefix: ".", resume: true}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, resumed)
+	assert.Equal(t, 1, stats.Skipped)
+
+	data, err := os.ReadFile(filepath.Join(dest, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "local", string(data))
+}
+
+func TestStripPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		n      int
+		want   string
+		wantOK bool
+	}{
+		{"etc/nginx/nginx.conf", 0, "etc/nginx/nginx.conf", true},
+		{"etc/nginx/nginx.conf", 2, "nginx.conf", true},
+		{"etc/nginx/conf.d/app.conf", 2, "conf.d/app.conf", true},
+		{"etc/nginx", 2, "", false},
+		{"README.md", 1, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := stripPath(tt.path, tt.n)
+		assert.Equal(t, tt.wantOK, ok, tt.path)
+		assert.Equal(t, tt.want, got, tt.path)
+	}
+}
+
+func TestExtractPull_Prefix(t *testing.T) {
+	arch := newTestArchive(t, map[string]string{
+		"etc/nginx/nginx.conf":    "events {}",
+		"etc/nginx/conf.d/a.conf": "server {}",
+		"etc/nginxfoo/other.conf": "other",
+		"README.md":               "readme",
+	})
+	cfg := &internalcfg.Config{Cache: internalcfg.CacheConfig{Dir: t.TempDir()}}
+
+	t.Run("prefix only", func(t *testing.T) {
+		dest := t.TempDir()
+		stats, _, err := extractPull(cfg, arch, dest, pullFlags{prefix: "etc/nginx"}, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, stats.FileCount)
+		assert.FileExists(t, filepath.Join(dest, "etc", "nginx", "nginx.conf"))
+		assert.FileExists(t, filepath.Join(dest, "etc", "nginx", "conf.d", "a.conf"))
+		assert.NoFileExists(t, filepath.Join(dest, "etc", "nginxfoo", "other.conf"))
+		assert.NoFileExists(t, filepath.Join(dest, "README.md"))
+	})
+
+	t.Run("with strip-components", func(t *testing.T) {
+		dest := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dest, "nginx.conf"), []byte("local"), 0o644))
+
+		stats, _, err := extractPull(cfg, arch, dest, pullFlags{prefix: "etc/nginx", strip: 2}, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats.FileCount)
+		assert.Equal(t, 1, stats.Skipped)
+
+		data, err := os.ReadFile(filepath.Join(dest, "nginx.conf"))
+		require.NoError(t, err)
+		assert.Equal(t, "local", string(data), "existing files are left alone")
+		data, err = os.ReadFile(filepath.Join(dest, "conf.d", "a.conf"))
+		require.NoError(t, err)
+		assert.Equal(t, "server {}", string(data))
+
+		entries, err := os.ReadDir(dest)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2, "the staging directory is removed")
+	})
+
+	t.Run("strip-components alone", func(t *testing.T) {
+		dest := t.TempDir()
+		stats, _, err := extractPull(cfg, arch, dest, pullFlags{prefix: ".", strip: 1}, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 3, stats.FileCount)
+		assert.FileExists(t, filepath.Join(dest, "nginx", "nginx.conf"))
+		assert.FileExists(t, filepath.Join(dest, "nginxfoo", "other.conf"))
+		assert.NoFileExists(t, filepath.Join(dest, "README.md"))
+	})
+}
+
+func TestPullCmd_PrefixNotFound(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	reg.addArchive(t, "v1", map[string]string{"etc/app.conf": "a"})
+
+	t.Cleanup(func() {
+		pullCmd.Flags().Set("prefix", "") //nolint:errcheck // test cleanup
+	})
+	require.NoError(t, pullCmd.Flags().Set("prefix", "/etc/nginx"))
+
+	cfg := newRegistryTestConfig(t)
+	pullCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	dest := filepath.Join(t.TempDir(), "out")
+	err := pullCmd.RunE(pullCmd, []string{reg.repo + ":v1", dest})
+
+	requireExitCode(t, err, exitCodePathNotFound)
+	assert.Contains(t, err.Error(), "directory not found in archive: etc/nginx")
+	assert.NoDirExists(t, dest, "nothing is written")
+}
+
+func TestPullCmd_ManifestOnlyRejectsDestination(t *testing.T) {
+	t.Cleanup(func() {
+		pullCmd.Flags().Set("manifest-only", "false") //nolint:errcheck // test cleanup
+	})
+	require.NoError(t, pullCmd.Flags().Set("manifest-only", "true"))
+
+	ctx := internalcfg.WithConfig(context.Background(), &internalcfg.Config{})
+	pullCmd.SetContext(ctx)
+	err := pullCmd.RunE(pullCmd, []string{"ghcr.io/acme/configs:v1", t.TempDir()})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "omit the destination path")
+}
+
+func TestWritePullIndex(t *testing.T) {
+	arch := newTestArchive(t, map[string]string{
+		"etc/app.yaml": "app: true",
+		"README":       "hello",
+	})
+	view, err := blobcore.NewIndexView(arch.IndexData())
+	require.NoError(t, err)
+	index := &pullIndex{
+		Ref:    "ghcr.io/acme/configs:v1",
+		Digest: "sha256:abc",
+		Index:  archive.NewIndex(view),
+	}
+	path := filepath.Join(t.TempDir(), "index.json")
+
+	require.NoError(t, writePullIndex(path, index))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, "ghcr.io/acme/configs:v1", got["ref"])
+	assert.Equal(t, "sha256:abc", got["digest"])
+	assert.InDelta(t, 2, got["files"], 0)
+	entries, ok := got["entries"].([]any)
+	require.True(t, ok)
+	require.Len(t, entries, 2)
+	first, ok := entries[0].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "/README", first["path"])
+}
+
+func TestPullCmd_AliasDefaultDest(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	reg.addArchive(t, "v1", map[string]string{"etc/app.conf": "a"})
+
+	dest := filepath.Join(t.TempDir(), "configs")
+	cfg := newRegistryTestConfig(t)
+	cfg.Aliases = map[string]string{"configs": reg.repo + ":v1"}
+	cfg.AliasDefaults = map[string]internalcfg.AliasDefaults{"configs": {DefaultDest: dest}}
+	pullCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	require.NoError(t, pullCmd.RunE(pullCmd, []string{"configs"}))
+	data, err := os.ReadFile(filepath.Join(dest, "etc", "app.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+}
@@ -5,66 +5,233 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/meigma/blob"
-	"github.com/meigma/blob/policy/sigstore"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+	"golang.org/x/term"
 
+	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/hooks"
+	"github.com/meigma/blob-cli/internal/identity"
+	"github.com/meigma/blob-cli/internal/platform"
+	"github.com/meigma/blob-cli/internal/priority"
 )
 
 var pushCmd = &cobra.Command{
-	Use:   "push <ref> <path>",
+	Use:   "push <ref> [path]",
 	Short: "Push a directory to an OCI registry as a blob archive",
 	Long: `Push a directory to an OCI registry as a blob archive.
 
 The directory contents are archived and uploaded to the specified
 registry reference. Files are compressed individually using zstd
-by default for optimal random access performance.`,
+by default for optimal random access performance.
+
+Small files and already-compressed formats are stored uncompressed,
+which keeps range reads fast. The rules come from
+push.skip_compress_min_size and push.skip_compress_extensions in the
+config file; --no-skip-compressed compresses every file.
+--compression-exclude stores files matching the given glob patterns
+uncompressed regardless of these rules. A pattern containing "/" is
+matched against the path within the archive, others against the file
+name.
+
+Only zstd and none are supported: the blob archive format decompresses
+each file independently for range reads and has no gzip encoding.
+
+Commands listed under hooks.pre_push in the config file run before
+anything is uploaded, with BLOB_PUSH_DIR and BLOB_PUSH_REF set in
+their environment. A non-zero exit aborts the push.
+
+With -v, a breakdown of time spent in each phase is printed to
+stderr. Creating the archive (scanning, hashing, and compressing) is a
+single pass.
+
+With "-" as the path, a tar stream is read from stdin (gzip-compressed
+streams are detected) and unpacked to a temporary directory, which is
+pushed as if it had been given. Entries may not leave the archive root,
+and links and special files are rejected.
+
+A .blobignore file in the source directory lists paths to leave out of
+the archive, in gitignore syntax: "#" starts a comment, "!" re-includes
+a path, a trailing "/" matches only directories, and a pattern with a
+"/" elsewhere is relative to the source root. --exclude adds patterns
+after those in .blobignore. --include pushes only files matching one of
+its glob patterns; a pattern containing "/" is matched against the path,
+others against the file name, and exclusions win. The .blobignore file
+itself is never pushed. When filtering, the kept files are staged in a
+temporary directory (hard-linked where possible), which hooks and
+--validate see in place of the source.
+
+--dry-run builds the archive locally, applying the filters and
+compression rules above, and prints the files with their stored sizes,
+the total upload size, and the manifest that would be pushed. Nothing
+is sent to the registry and pre_push hooks are not run; --validate
+still applies. The manifest omits the creation time, which is set at
+upload, so the upload size is approximate by a few bytes.
+
+--platform records which platforms the archive serves in the
+io.meigma.blob.platforms annotation. Give os/arch[/variant] once to mark
+the whole archive, or os/arch=dir for each platform whose files live
+under dir. ls, cp, and inspect select a variant with their own
+--platform flag.
+
+--priority records glob patterns of files to extract first in the
+io.meigma.blob.priority annotation, most important first. A pattern is
+matched against each file's path and its parent directories, so a
+directory name covers everything under it. pull extracts the matching
+files in pattern order before the rest of the archive and runs the
+priority_extracted hooks as soon as they are in place.
+
+--source composes the archive from several directories instead of one
+path: each dir:/path places the contents of dir at /path in the
+archive, or at the root without a mount point. Each directory's own
+.blobignore applies to it, and --exclude and --include to the composed
+archive. Two sources may share a mount point, but not a file.
+
+--files-from pushes exactly the files named in a list, one per line,
+read from stdin for "-". A line of the form local=archive/path stores
+the file under another name; otherwise its path is kept. Relative local
+paths are read from the path argument, which defaults to the current
+directory here. Blank lines and lines starting with "#" are skipped.`,
 	Example: `  blob push ghcr.io/acme/configs:v1.0.0 ./config
   blob push --sign ghcr.io/acme/configs:latest ./config
-  blob push --compression none ghcr.io/acme/data:v1 ./data`,
-	Args: cobra.ExactArgs(2),
+  blob push --compression none ghcr.io/acme/data:v1 ./data
+  blob push --no-skip-compressed ghcr.io/acme/data:v1 ./data
+  blob push --compression-exclude "*.png,*.zip,vendor/*" ghcr.io/acme/site:v1 ./site
+  blob push --exclude ".git/,*.env" ghcr.io/acme/configs:v1.0.0 ./config
+  blob push --include "**/*.yaml" ghcr.io/acme/configs:v1.0.0 ./config
+  blob push --validate ghcr.io/acme/configs:v1.0.0 ./config
+  blob push --dry-run ghcr.io/acme/configs:v1.0.0 ./config
+  blob push -v --validate ghcr.io/acme/configs:v1.0.0 ./config
+  blob push --platform linux/amd64=linux-amd64 --platform darwin/arm64=darwin-arm64 ghcr.io/acme/tools:v2 ./dist
+  blob push --priority etc/app.conf,certs ghcr.io/acme/app-data:v3 ./data
+  blob push --source ./configs:/etc/app --source ./certs:/certs ghcr.io/acme/app-data:v3
+  blob push --files-from build/files.txt ghcr.io/acme/app-data:v3 ./out
+  git archive HEAD config | blob push ghcr.io/acme/configs:v1.0.0 -`,
+	Args: cobra.RangeArgs(1, 2),
 	RunE: runPush,
 }
 
 func init() {
 	pushCmd.Flags().StringP("compression", "c", "zstd", "compression type: none, zstd")
 	pushCmd.Flags().Bool("skip-compressed", true, "skip compressing already-compressed files")
+	pushCmd.Flags().Bool("no-skip-compressed", false, "compress every file, ignoring skip-compression rules")
+	pushCmd.Flags().StringSlice("compression-exclude", nil, "glob patterns of files to store uncompressed (comma-separated, repeatable)")
+	pushCmd.Flags().StringSlice("exclude", nil, "gitignore-style patterns of files to leave out (comma-separated, repeatable)")
+	pushCmd.Flags().StringSlice("include", nil, "glob patterns of the only files to push (comma-separated, repeatable)")
+	pushCmd.Flags().String("files-from", "", "push exactly the files listed in this file (\"-\" for stdin), one local[=archive/path] per line")
+	pushCmd.Flags().StringArray("source", nil, "directory to add at a path in the archive: dir[:/path] (repeatable, replaces the path argument)")
 	pushCmd.Flags().Bool("sign", false, "sign the archive after pushing")
 	pushCmd.Flags().StringArray("annotation", nil, "add annotation to manifest (k=v, repeatable)")
-
-	_ = viper.BindPFlag("compression", pushCmd.Flags().Lookup("compression"))
+	pushCmd.Flags().StringArray("platform", nil, "declare a platform: os/arch[/variant] or os/arch=dir (repeatable)")
+	pushCmd.Flags().StringSlice("priority", nil, "glob patterns of files pull extracts first, most important first (comma-separated, repeatable)")
+	pushCmd.Flags().Bool("validate", false, "validate files against schemas from config before pushing")
+	pushCmd.Flags().Bool("no-hooks", false, "skip pre_push hooks from config")
+	pushCmd.Flags().Bool("dry-run", false, "show what would be pushed without contacting the registry")
+	addIdentityFlags(pushCmd)
+
+	pushCmd.MarkFlagsMutuallyExclusive("dry-run", "sign")
 }
 
 // pushResult contains the result of a push operation.
 type pushResult struct {
 	Ref             string `json:"ref"`
 	Status          string `json:"status"`
+	Excluded        int    `json:"excluded,omitempty"`
 	Signed          bool   `json:"signed,omitempty"`
 	SignatureDigest string `json:"signature_digest,omitempty"`
 }
 
 // pushFlags holds the parsed command flags.
 type pushFlags struct {
-	compression    blob.Compression
-	skipCompressed bool
-	sign           bool
-	annotations    map[string]string
+	compression         blob.Compression
+	skipCompressed      bool
+	compressionExcludes []string
+	excludes            []string
+	includes            []string
+	sign                bool
+	annotations         map[string]string
+	platforms           []platform.Variant
+	priority            []string
+	validate            bool
+	noHooks             bool
+	dryRun              bool
+	identity            identity.Options
 }
 
 func runPush(cmd *cobra.Command, args []string) error {
 	ref := args[0]
-	srcPath := args[1]
 
 	cfg := internalcfg.FromContext(cmd.Context())
 	if cfg == nil {
 		return errors.New("configuration not loaded")
 	}
 
+	sources, err := cmd.Flags().GetStringArray("source")
+	if err != nil {
+		return fmt.Errorf("reading source flag: %w", err)
+	}
+	filesFrom, err := cmd.Flags().GetString("files-from")
+	if err != nil {
+		return fmt.Errorf("reading files-from flag: %w", err)
+	}
+	var srcPath string
+	excluded := 0
+	switch {
+	case filesFrom != "" && len(sources) > 0:
+		return errors.New("--files-from and --source cannot be combined")
+	case filesFrom != "":
+		base := "."
+		if len(args) > 1 {
+			base = args[1]
+		}
+		dir, err := stagePushFilesFrom(cmd.InOrStdin(), filesFrom, base)
+		if dir != "" {
+			defer os.RemoveAll(dir)
+		}
+		if err != nil {
+			return err
+		}
+		srcPath = dir
+	case len(sources) > 0 && len(args) > 1:
+		return errors.New("a path argument and --source cannot be combined")
+	case len(sources) > 0:
+		dir, n, err := composePushSources(sources)
+		if dir != "" {
+			defer os.RemoveAll(dir)
+		}
+		if err != nil {
+			return err
+		}
+		srcPath = dir
+		excluded = n
+	case len(args) < 2:
+		return errors.New("requires a path argument, --source, or --files-from")
+	default:
+		srcPath = args[1]
+	}
+
+	if srcPath == "-" {
+		dir, err := extractStdinTar(cmd.InOrStdin())
+		if dir != "" {
+			defer os.RemoveAll(dir)
+		}
+		if err != nil {
+			return err
+		}
+		srcPath = dir
+	}
+
 	if err := validateSourcePath(srcPath); err != nil {
 		return err
 	}
@@ -74,28 +241,83 @@ func runPush(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	timings := &pushTimings{start: time.Now()}
+
+	filter, err := loadPushFilter(srcPath, flags.excludes, flags.includes)
+	if err != nil {
+		return err
+	}
+	if filter != nil {
+		dir, n, err := stagePushDir(filter, srcPath)
+		excluded += n
+		if dir != "" {
+			defer os.RemoveAll(dir)
+		}
+		if err != nil {
+			return err
+		}
+		srcPath = dir
+		timings.Filter = timings.lap()
+	}
+
+	if err := validateSourceMetadata(srcPath); err != nil {
+		return err
+	}
+
+	if err := validatePlatformDirs(srcPath, flags.platforms); err != nil {
+		return err
+	}
+
+	if flags.validate {
+		if err := validateContent(cfg, os.DirFS(srcPath)); err != nil {
+			return err
+		}
+	}
+	timings.Validate = timings.lap()
+
+	if flags.dryRun {
+		return runPushDryRun(cmd.Context(), cfg, ref, srcPath, flags, excluded)
+	}
+
+	if !flags.noHooks {
+		if err := runPrePushHooks(cmd.Context(), cfg, ref, srcPath); err != nil {
+			return err
+		}
+	}
+	timings.Hooks = timings.lap()
+
 	client, err := newClient(cfg)
 	if err != nil {
 		return fmt.Errorf("creating client: %w", err)
 	}
 
-	pushOpts := buildPushOptions(flags)
+	pushOpts := buildPushOptions(flags, &cfg.Push)
+	if cfg.Verbose > 0 {
+		pushOpts = append(pushOpts, blob.PushWithProgress(timings.progress))
+	}
 
 	ctx := cmd.Context()
 	if err := client.Push(ctx, ref, srcPath, pushOpts...); err != nil {
 		return fmt.Errorf("pushing archive: %w", err)
 	}
+	timings.splitPush()
 
 	result := pushResult{
-		Ref:    ref,
-		Status: "success",
+		Ref:      ref,
+		Status:   "success",
+		Excluded: excluded,
 	}
 
 	if flags.sign {
-		if err := signArchive(ctx, client, ref, &result); err != nil {
+		if err := signArchive(ctx, client, ref, flags.identity, &result); err != nil {
 			return err
 		}
 	}
+	timings.Sign = timings.lap()
+
+	if cfg.Verbose > 0 && !cfg.Quiet {
+		timings.print(os.Stderr, flags)
+	}
 
 	return outputPushResult(cfg, result)
 }
@@ -116,11 +338,49 @@ func parsePushFlags(cmd *cobra.Command) (pushFlags, error) {
 		return flags, fmt.Errorf("reading skip-compressed flag: %w", err)
 	}
 
+	noSkipCompressed, err := cmd.Flags().GetBool("no-skip-compressed")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-skip-compressed flag: %w", err)
+	}
+	if noSkipCompressed {
+		flags.skipCompressed = false
+	}
+
+	flags.compressionExcludes, err = cmd.Flags().GetStringSlice("compression-exclude")
+	if err != nil {
+		return flags, fmt.Errorf("reading compression-exclude flag: %w", err)
+	}
+	for _, pattern := range flags.compressionExcludes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return flags, fmt.Errorf("invalid --compression-exclude pattern %q: %w", pattern, err)
+		}
+	}
+
+	flags.excludes, err = cmd.Flags().GetStringSlice("exclude")
+	if err != nil {
+		return flags, fmt.Errorf("reading exclude flag: %w", err)
+	}
+
+	flags.includes, err = cmd.Flags().GetStringSlice("include")
+	if err != nil {
+		return flags, fmt.Errorf("reading include flag: %w", err)
+	}
+	for _, pattern := range slices.Concat(flags.excludes, flags.includes) {
+		if _, err := path.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+			return flags, fmt.Errorf("invalid filter pattern %q: %w", pattern, err)
+		}
+	}
+
 	flags.sign, err = cmd.Flags().GetBool("sign")
 	if err != nil {
 		return flags, fmt.Errorf("reading sign flag: %w", err)
 	}
 
+	flags.identity, err = parseIdentityFlags(cmd)
+	if err != nil {
+		return flags, err
+	}
+
 	annotationStrs, err := cmd.Flags().GetStringArray("annotation")
 	if err != nil {
 		return flags, fmt.Errorf("reading annotation flag: %w", err)
@@ -131,16 +391,148 @@ func parsePushFlags(cmd *cobra.Command) (pushFlags, error) {
 		return flags, err
 	}
 
+	platformStrs, err := cmd.Flags().GetStringArray("platform")
+	if err != nil {
+		return flags, fmt.Errorf("reading platform flag: %w", err)
+	}
+	flags.platforms, err = parsePlatformFlags(platformStrs)
+	if err != nil {
+		return flags, err
+	}
+	if len(flags.platforms) > 0 {
+		if _, ok := flags.annotations[platform.Annotation]; ok {
+			return flags, fmt.Errorf("--platform and --annotation %s cannot be combined", platform.Annotation)
+		}
+		flags.annotations[platform.Annotation] = platform.Format(flags.platforms)
+	}
+
+	priorityStrs, err := cmd.Flags().GetStringSlice("priority")
+	if err != nil {
+		return flags, fmt.Errorf("reading priority flag: %w", err)
+	}
+	for _, s := range priorityStrs {
+		p, err := priority.ParsePattern(s)
+		if err != nil {
+			return flags, fmt.Errorf("invalid --priority: %w", err)
+		}
+		flags.priority = append(flags.priority, p)
+	}
+	if len(flags.priority) > 0 {
+		if _, ok := flags.annotations[priority.Annotation]; ok {
+			return flags, fmt.Errorf("--priority and --annotation %s cannot be combined", priority.Annotation)
+		}
+		flags.annotations[priority.Annotation] = priority.Format(flags.priority)
+	}
+
+	flags.validate, err = cmd.Flags().GetBool("validate")
+	if err != nil {
+		return flags, fmt.Errorf("reading validate flag: %w", err)
+	}
+
+	flags.noHooks, err = cmd.Flags().GetBool("no-hooks")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-hooks flag: %w", err)
+	}
+
+	flags.dryRun, err = cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return flags, fmt.Errorf("reading dry-run flag: %w", err)
+	}
+
 	return flags, nil
 }
 
-// buildPushOptions creates blob.PushOption slice from flags.
-func buildPushOptions(flags pushFlags) []blob.PushOption {
+// pushTimings records how long each phase of a push took.
+// Archive creation covers scanning, hashing, and compressing, which the
+// blob library performs in a single pass; it is split from the upload
+// using progress events.
+type pushTimings struct {
+	Filter   time.Duration
+	Validate time.Duration
+	Hooks    time.Duration
+	Archive  time.Duration
+	Upload   time.Duration
+	Sign     time.Duration
+
+	start time.Time
+	last  time.Time
+
+	mu       sync.Mutex
+	uploadAt time.Time
+	files    int
+}
+
+// lap returns the time since the previous lap (or start) and resets the lap clock.
+func (t *pushTimings) lap() time.Duration {
+	now := time.Now()
+	prev := t.last
+	if prev.IsZero() {
+		prev = t.start
+	}
+	t.last = now
+	return now.Sub(prev)
+}
+
+// progress is a blob.ProgressFunc noting when the upload begins.
+func (t *pushTimings) progress(ev blob.ProgressEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch ev.Stage {
+	case blob.StageCompressing:
+		t.files = ev.FilesDone
+	case blob.StagePushingIndex, blob.StagePushingData:
+		if t.uploadAt.IsZero() {
+			t.uploadAt = time.Now()
+		}
+	}
+}
+
+// splitPush divides the time since the last lap into archive and upload.
+func (t *pushTimings) splitPush() {
+	pushStart := t.last
+	total := t.lap()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.uploadAt.IsZero() {
+		t.Archive = total
+		return
+	}
+	t.Archive = t.uploadAt.Sub(pushStart)
+	t.Upload = total - t.Archive
+}
+
+// print writes the breakdown as an aligned table.
+func (t *pushTimings) print(w io.Writer, flags pushFlags) {
+	row := func(name string, d time.Duration, note string) {
+		fmt.Fprintf(w, "  %-9s %10s%s\n", name, d.Round(time.Millisecond), note)
+	}
+
+	fmt.Fprintln(w, "Push timing:")
+	if t.Filter > 0 {
+		row("filter", t.Filter, "")
+	}
+	if flags.validate {
+		row("validate", t.Validate, "")
+	}
+	if !flags.noHooks {
+		row("hooks", t.Hooks, "")
+	}
+	row("archive", t.Archive, fmt.Sprintf("  (scan, hash, compress; %s)", pluralize(t.files, "file", "files")))
+	row("upload", t.Upload, "")
+	if flags.sign {
+		row("sign", t.Sign, "")
+	}
+	row("total", t.last.Sub(t.start), "")
+}
+
+// buildPushOptions creates blob.PushOption slice from flags and push config.
+func buildPushOptions(flags pushFlags, pushCfg *internalcfg.PushConfig) []blob.PushOption {
 	opts := []blob.PushOption{
 		blob.PushWithCompression(flags.compression),
 	}
-	if flags.skipCompressed {
-		opts = append(opts, blob.PushWithSkipCompression(blob.DefaultSkipCompression(1024)))
+	if skip := skipCompressionFuncs(flags, pushCfg); len(skip) > 0 {
+		opts = append(opts, blob.PushWithSkipCompression(skip...))
 	}
 	if len(flags.annotations) > 0 {
 		opts = append(opts, blob.PushWithAnnotations(flags.annotations))
@@ -148,14 +540,63 @@ func buildPushOptions(flags pushFlags) []blob.PushOption {
 	return opts
 }
 
+// skipCompressionFuncs returns the predicates deciding which files are
+// stored uncompressed, if any.
+func skipCompressionFuncs(flags pushFlags, pushCfg *internalcfg.PushConfig) []blob.SkipCompressionFunc {
+	var fns []blob.SkipCompressionFunc
+	if flags.skipCompressed {
+		fns = append(fns, skipCompressionFunc(pushCfg))
+	}
+	if len(flags.compressionExcludes) > 0 {
+		fns = append(fns, excludeCompressionFunc(flags.compressionExcludes))
+	}
+	return fns
+}
+
+// skipCompressionFunc builds the predicate deciding which files are stored
+// uncompressed. Without configured extensions, the library's built-in list
+// of already-compressed formats is used.
+func skipCompressionFunc(pushCfg *internalcfg.PushConfig) blob.SkipCompressionFunc {
+	minSize := pushCfg.SkipCompressMinSize
+	if len(pushCfg.SkipCompressExtensions) == 0 {
+		return blob.DefaultSkipCompression(minSize)
+	}
+
+	exts := make(map[string]struct{}, len(pushCfg.SkipCompressExtensions))
+	for _, ext := range pushCfg.SkipCompressExtensions {
+		exts["."+strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))] = struct{}{}
+	}
+
+	return func(path string, info fs.FileInfo) bool {
+		if info != nil && minSize > 0 && info.Size() < minSize {
+			return true
+		}
+		_, ok := exts[strings.ToLower(filepath.Ext(path))]
+		return ok
+	}
+}
+
+// excludeCompressionFunc returns a predicate matching files that
+// --compression-exclude stores uncompressed. Patterns containing "/" match
+// the archive path; others match the file name.
+func excludeCompressionFunc(patterns []string) blob.SkipCompressionFunc {
+	return func(p string, _ fs.FileInfo) bool {
+		for _, pattern := range patterns {
+			name := path.Base(p)
+			if strings.Contains(pattern, "/") {
+				name = p
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 // signArchive signs the pushed archive using Sigstore keyless signing.
-func signArchive(ctx context.Context, client *blob.Client, ref string, result *pushResult) error {
-	signer, err := sigstore.NewSigner(
-		sigstore.WithEphemeralKey(),
-		sigstore.WithFulcio("https://fulcio.sigstore.dev"),
-		sigstore.WithRekor("https://rekor.sigstore.dev"),
-		sigstore.WithAmbientCredentials(),
-	)
+func signArchive(ctx context.Context, client *blob.Client, ref string, id identity.Options, result *pushResult) error {
+	signer, err := buildSigner(ctx, signFlags{identity: id})
 	if err != nil {
 		return fmt.Errorf("creating signer: %w", err)
 	}
@@ -175,7 +616,7 @@ func outputPushResult(cfg *internalcfg.Config, result pushResult) error {
 	if cfg.Quiet {
 		return nil
 	}
-	if viper.GetString("output") == internalcfg.OutputJSON {
+	if cfg.Output == internalcfg.OutputJSON {
 		return pushJSON(result)
 	}
 	return pushText(result)
@@ -189,12 +630,63 @@ func pushJSON(result pushResult) error {
 
 func pushText(result pushResult) error {
 	fmt.Printf("Pushed %s\n", result.Ref)
+	if result.Excluded > 0 {
+		fmt.Printf("Excluded: %s\n", pluralize(result.Excluded, "file", "files"))
+	}
 	if result.Signed {
 		fmt.Printf("Signed: %s\n", result.SignatureDigest)
 	}
 	return nil
 }
 
+// stagePushFilesFrom reads the --files-from list at listPath, or stdin for
+// "-", and stages the files it names, relative to base, in a temporary
+// directory. The caller removes the directory, which is returned even if
+// staging fails.
+func stagePushFilesFrom(stdin io.Reader, listPath, base string) (string, error) {
+	if base == "-" {
+		return "", errors.New(`--files-from cannot be combined with "-" as the path`)
+	}
+	if err := validateSourcePath(base); err != nil {
+		return "", err
+	}
+	list := stdin
+	if listPath != "-" {
+		f, err := os.Open(listPath) //nolint:gosec // listPath is the user's file list
+		if err != nil {
+			return "", fmt.Errorf("reading file list: %w", err)
+		}
+		defer f.Close()
+		list = f
+	}
+	entries, err := parseFilesFrom(list)
+	if err != nil {
+		return "", fmt.Errorf("--files-from %s: %w", listPath, err)
+	}
+	return stageFilesFrom(entries, base)
+}
+
+// extractStdinTar unpacks the tar stream in into a temporary directory and
+// returns its path. The caller removes the directory, which is returned
+// even if extraction fails.
+func extractStdinTar(in io.Reader) (string, error) {
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) { //nolint:gosec // file descriptors fit in an int
+		return "", errors.New(`expected a tar stream on stdin for "-"`)
+	}
+	dir, err := os.MkdirTemp("", "blob-push-")
+	if err != nil {
+		return "", fmt.Errorf("creating work directory: %w", err)
+	}
+	n, err := archive.ExtractTar(in, dir)
+	if err != nil {
+		return dir, fmt.Errorf("reading tar from stdin: %w", err)
+	}
+	if n == 0 {
+		return dir, errors.New("reading tar from stdin: no files in stream")
+	}
+	return dir, nil
+}
+
 // validateSourcePath checks that the path exists and is a directory.
 func validateSourcePath(path string) error {
 	info, err := os.Stat(path)
@@ -210,6 +702,34 @@ func validateSourcePath(path string) error {
 	return nil
 }
 
+// runPrePushHooks runs the configured pre_push hooks.
+// Any hook failure aborts the push.
+func runPrePushHooks(ctx context.Context, cfg *internalcfg.Config, ref, srcPath string) error {
+	if len(cfg.Hooks.PrePush) == 0 {
+		return nil
+	}
+
+	absPath, err := filepath.Abs(srcPath)
+	if err != nil {
+		return fmt.Errorf("resolving source path: %w", err)
+	}
+
+	runner := &hooks.Runner{}
+	return runner.Run(ctx, "pre_push", cfg.Hooks.PrePush, map[string]string{
+		hooks.EnvPushDir: absPath,
+		hooks.EnvPushRef: ref,
+	})
+}
+
+// validateSourceMetadata checks the archive metadata manifest, if present,
+// so that malformed metadata is rejected before anything is uploaded.
+func validateSourceMetadata(srcPath string) error {
+	if _, err := archive.ReadMetadataFile(srcPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("invalid archive metadata: %w", err)
+	}
+	return nil
+}
+
 // mapCompression converts a compression string to a blob.Compression value.
 func mapCompression(s string) (blob.Compression, error) {
 	switch s {
@@ -222,6 +742,36 @@ func mapCompression(s string) (blob.Compression, error) {
 	}
 }
 
+// parsePlatformFlags parses --platform values into variants.
+func parsePlatformFlags(values []string) ([]platform.Variant, error) {
+	variants := make([]platform.Variant, 0, len(values))
+	for _, v := range values {
+		variant, err := platform.ParseVariant(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --platform: %w", err)
+		}
+		variants = append(variants, variant)
+	}
+	if err := platform.Validate(variants); err != nil {
+		return nil, fmt.Errorf("invalid --platform: %w", err)
+	}
+	return variants, nil
+}
+
+// validatePlatformDirs checks that each platform directory exists in srcPath.
+func validatePlatformDirs(srcPath string, variants []platform.Variant) error {
+	for _, v := range variants {
+		if v.Dir == "" {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(srcPath, filepath.FromSlash(v.Dir)))
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("--platform %s: directory %s not found in %s", v.Platform, v.Dir, srcPath)
+		}
+	}
+	return nil
+}
+
 // parseAnnotations parses annotation strings in key=value format.
 // Returns an empty map (not nil) when annotations is empty.
 func parseAnnotations(annotations []string) (map[string]string, error) {
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/meigma/blob-cli/internal/archive"
+)
+
+// pushFilter decides which files of a source directory are pushed.
+type pushFilter struct {
+	ignore   *archive.IgnoreRules
+	includes []string
+}
+
+// loadPushFilter combines the source directory's .blobignore with the
+// --exclude and --include patterns. It returns nil if nothing would be
+// filtered out.
+func loadPushFilter(srcPath string, excludes, includes []string) (*pushFilter, error) {
+	ignore, err := archive.LoadIgnoreRules(srcPath, excludes)
+	if err != nil {
+		return nil, err
+	}
+	_, statErr := os.Lstat(filepath.Join(srcPath, archive.IgnoreFile))
+	if ignore.Empty() && len(includes) == 0 && statErr != nil {
+		return nil, nil
+	}
+	return &pushFilter{ignore: ignore, includes: includes}, nil
+}
+
+// skipDir reports whether the directory p, relative to the source root, is
+// left out along with everything below it. A nil filter keeps everything.
+func (f *pushFilter) skipDir(p string) bool {
+	return f != nil && f.ignore.Ignored(p, true)
+}
+
+// keep reports whether the file p, relative to the source root, is pushed.
+// Exclusions win over --include; the .blobignore file is never pushed.
+func (f *pushFilter) keep(p string) bool {
+	if f == nil {
+		return true
+	}
+	if p == archive.IgnoreFile || f.ignore.Ignored(p, false) {
+		return false
+	}
+	if len(f.includes) == 0 {
+		return true
+	}
+	for _, pattern := range f.includes {
+		name := path.Base(p)
+		if strings.Contains(pattern, "/") {
+			name = p
+		}
+		if archive.MatchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// stage links (or, across file systems, copies) the files of srcPath that
+// the filter keeps into dest under mount, a slash-separated path that may
+// be empty, returning how many were kept and excluded. Symlinks and special
+// files are skipped, as the archive builder does. A file already staged
+// from another source is an error.
+func (f *pushFilter) stage(srcPath, dest, mount string) (kept, excluded int, err error) {
+	err = filepath.WalkDir(srcPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcPath, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case d.IsDir():
+			if f.skipDir(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		case !d.Type().IsRegular():
+			return nil
+		case !f.keep(rel):
+			excluded++
+			return nil
+		}
+
+		archivePath := path.Join(mount, rel)
+		target := filepath.Join(dest, filepath.FromSlash(archivePath))
+		if _, err := os.Lstat(target); err == nil {
+			return fmt.Errorf("/%s is in more than one source", archivePath)
+		}
+		if err := ensureDir(filepath.Dir(target)); err != nil {
+			return err
+		}
+		if err := os.Link(p, target); err != nil {
+			if err := copyStagedFile(p, target); err != nil {
+				return err
+			}
+		}
+		kept++
+		return nil
+	})
+	if err != nil {
+		return kept, excluded, fmt.Errorf("staging source directory: %w", err)
+	}
+	return kept, excluded, nil
+}
+
+// copyStagedFile copies src to dest, keeping its permissions and
+// modification time.
+func copyStagedFile(src, dest string) error {
+	in, err := os.Open(src) //nolint:gosec // src is inside the source directory being pushed
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm()) //nolint:gosec // dest is inside the staging directory
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dest, info.ModTime(), info.ModTime())
+}
+
+// stagePushDir applies filter to srcPath and returns a temporary directory
+// holding the files to push. The caller removes the directory, which is
+// returned even if staging fails.
+func stagePushDir(filter *pushFilter, srcPath string) (dir string, excluded int, err error) {
+	dir, err = os.MkdirTemp("", "blob-push-")
+	if err != nil {
+		return "", 0, fmt.Errorf("creating work directory: %w", err)
+	}
+	kept, excluded, err := filter.stage(srcPath, dir, "")
+	if err != nil {
+		return dir, excluded, err
+	}
+	if kept == 0 {
+		return dir, excluded, errors.New("no files left to push after applying .blobignore, --include, and --exclude")
+	}
+	return dir, excluded, nil
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"text/tabwriter"
+
+	blobcore "github.com/meigma/blob/core"
+	"github.com/meigma/blob/registry"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// pushPlan describes what a push would upload, for --dry-run.
+type pushPlan struct {
+	Ref             string           `json:"ref"`
+	DryRun          bool             `json:"dry_run"`
+	Manifest        ocispec.Manifest `json:"manifest"`
+	Files           []pushPlanFile   `json:"files"`
+	FileCount       int              `json:"file_count"`
+	Excluded        int              `json:"excluded,omitempty"`
+	TotalSize       uint64           `json:"total_size"`
+	StoredSize      uint64           `json:"stored_size"`
+	UploadSize      uint64           `json:"upload_size"`
+	UploadSizeHuman string           `json:"upload_size_human"`
+}
+
+// pushPlanFile is a file as it would be stored in the archive.
+type pushPlanFile struct {
+	Path        string `json:"path"`
+	Size        uint64 `json:"size"`
+	StoredSize  uint64 `json:"stored_size"`
+	Compression string `json:"compression"`
+}
+
+// runPushDryRun builds the archive for srcPath locally, without contacting
+// the registry, and prints what pushing it would upload.
+func runPushDryRun(ctx context.Context, cfg *internalcfg.Config, ref, srcPath string, flags pushFlags, excluded int) error {
+	plan, err := planPush(ctx, srcPath, flags, &cfg.Push)
+	if err != nil {
+		return err
+	}
+	plan.Ref = ref
+	plan.Excluded = excluded
+	return outputPushPlan(cfg, plan)
+}
+
+// planPush creates the archive in memory, keeping only the index, and
+// returns the manifest and files a push would produce. The manifest's
+// created annotation is left out, since it is set at upload time.
+func planPush(ctx context.Context, srcPath string, flags pushFlags, pushCfg *internalcfg.PushConfig) (*pushPlan, error) {
+	createOpts := []blobcore.CreateOption{blobcore.CreateWithCompression(flags.compression)}
+	if skip := skipCompressionFuncs(flags, pushCfg); len(skip) > 0 {
+		createOpts = append(createOpts, blobcore.CreateWithSkipCompression(skip...))
+	}
+
+	var indexBuf bytes.Buffer
+	if err := blobcore.Create(ctx, srcPath, &indexBuf, io.Discard, createOpts...); err != nil {
+		return nil, fmt.Errorf("creating archive: %w", err)
+	}
+	index, err := blobcore.NewIndexView(indexBuf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("reading archive index: %w", err)
+	}
+	dataHash, ok := index.DataHash()
+	if !ok {
+		return nil, errors.New("archive index has no data hash")
+	}
+	dataSize, _ := index.DataSize()
+
+	config := []byte("{}")
+	manifest := ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: registry.ArtifactType,
+		Config: ocispec.Descriptor{
+			MediaType: ocispec.MediaTypeEmptyJSON,
+			Digest:    digest.FromBytes(config),
+			Size:      int64(len(config)),
+		},
+		Layers: []ocispec.Descriptor{
+			{
+				MediaType: registry.MediaTypeIndex,
+				Digest:    digest.FromBytes(indexBuf.Bytes()),
+				Size:      int64(indexBuf.Len()),
+			},
+			{
+				MediaType: registry.MediaTypeData,
+				Digest:    digest.NewDigestFromEncoded(digest.SHA256, hex.EncodeToString(dataHash)),
+				Size:      int64(dataSize), //nolint:gosec // archive sizes fit in an int64
+			},
+		},
+		Annotations: maps.Clone(flags.annotations),
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &pushPlan{DryRun: true, Manifest: manifest, Files: []pushPlanFile{}}
+	for e := range index.Entries() {
+		if !e.Mode().IsRegular() {
+			continue
+		}
+		plan.Files = append(plan.Files, pushPlanFile{
+			Path:        e.Path(),
+			Size:        e.OriginalSize(),
+			StoredSize:  e.DataSize(),
+			Compression: e.Compression().String(),
+		})
+		plan.TotalSize += e.OriginalSize()
+		plan.StoredSize += e.DataSize()
+	}
+	plan.FileCount = len(plan.Files)
+	plan.UploadSize = uint64(len(config)+indexBuf.Len()+len(manifestData)) + dataSize //nolint:gosec // lengths are non-negative
+	plan.UploadSizeHuman = archive.FormatSize(plan.UploadSize)
+	return plan, nil
+}
+
+// outputPushPlan formats and outputs the dry-run plan.
+func outputPushPlan(cfg *internalcfg.Config, plan *pushPlan) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+	return pushPlanText(plan)
+}
+
+func pushPlanText(plan *pushPlan) error {
+	fmt.Printf("Dry run: nothing was pushed to %s\n\n", plan.Ref)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SIZE\tSTORED\tCOMPRESSION\tPATH")
+	for _, f := range plan.Files {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", archive.FormatSize(f.Size), archive.FormatSize(f.StoredSize), f.Compression, f.Path)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("Files: %d (%s, stored as %s)\n", plan.FileCount, archive.FormatSize(plan.TotalSize), archive.FormatSize(plan.StoredSize))
+	if plan.Excluded > 0 {
+		fmt.Printf("Excluded: %s\n", pluralize(plan.Excluded, "file", "files"))
+	}
+	fmt.Printf("Upload: %s\n", plan.UploadSizeHuman)
+
+	data, err := json.MarshalIndent(plan.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	fmt.Println("Manifest:")
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// pushSource is a local directory mapped to a path in the archive.
+type pushSource struct {
+	dir   string
+	mount string // slash-separated, relative to the archive root; "" for the root
+}
+
+// parsePushSource parses a --source value: dir or dir:/mount. Without a
+// mount point the directory is placed at the root of the archive.
+func parsePushSource(value string) (pushSource, error) {
+	dir, mount := value, "/"
+	// Look for ":/" past a Windows drive letter such as C:/
+	if idx := strings.LastIndex(value, ":/"); idx >= 0 && idx != 1 {
+		dir, mount = value[:idx], value[idx+1:]
+	}
+	if dir == "" {
+		return pushSource{}, fmt.Errorf("invalid --source %q: expected dir or dir:/path", value)
+	}
+	return pushSource{dir: dir, mount: strings.TrimPrefix(path.Clean(mount), "/")}, nil
+}
+
+// composePushSources stages the files of each --source directory under its
+// mount point in a temporary directory, applying each directory's own
+// .blobignore, and returns the directory with the number of files left
+// out. The caller removes the directory, which is returned even if
+// staging fails. Two sources may share a mount point but not a file.
+func composePushSources(values []string) (dir string, excluded int, err error) {
+	sources := make([]pushSource, 0, len(values))
+	for _, v := range values {
+		src, err := parsePushSource(v)
+		if err != nil {
+			return "", 0, err
+		}
+		if err := validateSourcePath(src.dir); err != nil {
+			return "", 0, fmt.Errorf("--source %s: %w", v, err)
+		}
+		sources = append(sources, src)
+	}
+
+	dir, err = os.MkdirTemp("", "blob-push-")
+	if err != nil {
+		return "", 0, fmt.Errorf("creating work directory: %w", err)
+	}
+	total := 0
+	for i, src := range sources {
+		filter, err := loadPushFilter(src.dir, nil, nil)
+		if err != nil {
+			return dir, excluded, fmt.Errorf("--source %s: %w", values[i], err)
+		}
+		kept, skipped, err := filter.stage(src.dir, dir, src.mount)
+		total += kept
+		excluded += skipped
+		if err != nil {
+			return dir, excluded, fmt.Errorf("--source %s: %w", values[i], err)
+		}
+	}
+	if total == 0 {
+		return dir, excluded, errors.New("no files to push in the --source directories")
+	}
+	return dir, excluded, nil
+}
+
+// filesFromEntry is a line of a --files-from list: a local file and the
+// slash-separated path it is stored at in the archive.
+type filesFromEntry struct {
+	local       string
+	archivePath string
+}
+
+// parseFilesFrom reads a --files-from list: one file per line, as local or
+// local=archive/path to store it under another name. Blank lines and lines
+// starting with "#" are skipped. An archive path is relative to the archive
+// root, and a local path without one must be relative and stay within the
+// base directory, as it is used for both.
+func parseFilesFrom(r io.Reader) ([]filesFromEntry, error) {
+	var entries []filesFromEntry
+	seen := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		local, archivePath, renamed := strings.Cut(line, "=")
+		if !renamed {
+			if filepath.IsAbs(local) {
+				return nil, fmt.Errorf("line %d: absolute path %s needs an archive path (local=archive/path)", lineNo, local)
+			}
+			archivePath = filepath.ToSlash(local)
+		}
+		archivePath = strings.TrimPrefix(path.Clean("/"+archivePath), "/")
+		if local == "" || archivePath == "" {
+			return nil, fmt.Errorf("line %d: expected local or local=archive/path", lineNo)
+		}
+		if !renamed && !filepath.IsLocal(local) {
+			return nil, fmt.Errorf("line %d: %s is outside the base directory; give it an archive path (local=archive/path)", lineNo, local)
+		}
+		if prev, ok := seen[archivePath]; ok {
+			return nil, fmt.Errorf("line %d: /%s is already added on line %d", lineNo, archivePath, prev)
+		}
+		seen[archivePath] = lineNo
+		entries = append(entries, filesFromEntry{local: local, archivePath: archivePath})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading file list: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no files in the --files-from list")
+	}
+	return entries, nil
+}
+
+// stageFilesFrom links (or copies) each listed file, with relative local
+// paths resolved against base, into a temporary directory at its archive
+// path, and returns the directory. The caller removes the directory, which
+// is returned even if staging fails. Every entry must be a regular file.
+func stageFilesFrom(entries []filesFromEntry, base string) (string, error) {
+	dir, err := os.MkdirTemp("", "blob-push-")
+	if err != nil {
+		return "", fmt.Errorf("creating work directory: %w", err)
+	}
+	for _, e := range entries {
+		local := e.local
+		if !filepath.IsAbs(local) {
+			local = filepath.Join(base, local)
+		}
+		info, err := os.Stat(local)
+		if err != nil {
+			return dir, fmt.Errorf("--files-from: %w", err)
+		}
+		if !info.Mode().IsRegular() {
+			return dir, fmt.Errorf("--files-from: %s is not a regular file", local)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(e.archivePath))
+		if err := ensureDir(filepath.Dir(target)); err != nil {
+			return dir, fmt.Errorf("--files-from: /%s: %w", e.archivePath, err)
+		}
+		if err := os.Link(local, target); err != nil {
+			if err := copyStagedFile(local, target); err != nil {
+				return dir, fmt.Errorf("--files-from: /%s: %w", e.archivePath, err)
+			}
+		}
+	}
+	return dir, nil
+}
@@ -1,19 +1,27 @@
 package cmd
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/meigma/blob"
-	"github.com/spf13/viper"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/hooks"
+	"github.com/meigma/blob-cli/internal/platform"
+	"github.com/meigma/blob-cli/internal/priority"
+	"github.com/meigma/blob-cli/internal/schema"
 )
 
 func TestParseAnnotations(t *testing.T) {
@@ -163,8 +171,6 @@ func TestValidateSourcePath(t *testing.T) {
 }
 
 func TestPushCmd_NilConfig(t *testing.T) {
-	viper.Reset()
-
 	// Create a temp directory for source path
 	dir := t.TempDir()
 
@@ -179,8 +185,6 @@ func TestPushCmd_NilConfig(t *testing.T) {
 }
 
 func TestPushCmd_InvalidSourcePath(t *testing.T) {
-	viper.Reset()
-
 	cfg := &internalcfg.Config{}
 	ctx := internalcfg.WithConfig(context.Background(), cfg)
 
@@ -192,8 +196,6 @@ func TestPushCmd_InvalidSourcePath(t *testing.T) {
 }
 
 func TestPushCmd_SourcePathIsFile(t *testing.T) {
-	viper.Reset()
-
 	// Create a temp file
 	dir := t.TempDir()
 	file := filepath.Join(dir, "file.txt")
@@ -308,3 +310,484 @@ func TestPushJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestPushCmd_InvalidMetadata(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".blob"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".blob", "metadata.yaml"), []byte("schema_version: 99\n"), 0o644))
+
+	cfg := &internalcfg.Config{}
+	ctx := internalcfg.WithConfig(context.Background(), cfg)
+
+	pushCmd.SetContext(ctx)
+	err := pushCmd.RunE(pushCmd, []string{"ghcr.io/test:v1", dir})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid archive metadata")
+	assert.Contains(t, err.Error(), "unsupported schema_version 99")
+}
+
+func TestPushCmd_ValidateWithoutSchemas(t *testing.T) {
+	require.NoError(t, pushCmd.Flags().Set("validate", "true"))
+	t.Cleanup(func() { _ = pushCmd.Flags().Set("validate", "false") })
+
+	cfg := &internalcfg.Config{}
+	ctx := internalcfg.WithConfig(context.Background(), cfg)
+
+	pushCmd.SetContext(ctx)
+	err := pushCmd.RunE(pushCmd, []string{"ghcr.io/test:v1", t.TempDir()})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires at least one entry")
+}
+
+func TestPushCmd_ValidateFailure(t *testing.T) {
+	schemaDir := t.TempDir()
+	schemaPath := filepath.Join(schemaDir, "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type": "object", "required": ["port"]}`), 0o644))
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "app.config.json"), []byte(`{}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "ok.config.json"), []byte(`{"port": 1}`), 0o644))
+
+	require.NoError(t, pushCmd.Flags().Set("validate", "true"))
+	t.Cleanup(func() { _ = pushCmd.Flags().Set("validate", "false") })
+
+	cfg := &internalcfg.Config{
+		Schemas: []internalcfg.SchemaRule{{Match: "**/*.config.json", Schema: schemaPath}},
+	}
+	ctx := internalcfg.WithConfig(context.Background(), cfg)
+
+	pushCmd.SetContext(ctx)
+	err := pushCmd.RunE(pushCmd, []string{"ghcr.io/test:v1", srcDir})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, schema.ErrValidationFailed)
+	assert.Contains(t, err.Error(), "1 file invalid")
+}
+
+func TestPushCmd_PrePushHookFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook test uses POSIX shell syntax")
+	}
+
+	srcDir := t.TempDir()
+	marker := filepath.Join(t.TempDir(), "hook-ran")
+
+	cfg := &internalcfg.Config{
+		Hooks: internalcfg.HooksConfig{
+			PrePush: []string{
+				`echo "$BLOB_PUSH_REF" > ` + marker,
+				"exit 1",
+			},
+		},
+	}
+	ctx := internalcfg.WithConfig(context.Background(), cfg)
+
+	pushCmd.SetContext(ctx)
+	err := pushCmd.RunE(pushCmd, []string{"ghcr.io/test:v1", srcDir})
+
+	var hookErr *hooks.HookError
+	require.ErrorAs(t, err, &hookErr)
+	assert.Equal(t, "exit 1", hookErr.Command)
+
+	data, readErr := os.ReadFile(marker)
+	require.NoError(t, readErr)
+	assert.Equal(t, "ghcr.io/test:v1\n", string(data))
+}
+
+func TestSkipCompressionFunc(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, size int) os.FileInfo {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0o644))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return info
+	}
+	small := writeFile("small.txt", 100)
+	large := writeFile("large.txt", 4096)
+	largePNG := writeFile("image.png", 4096)
+	largeBin := writeFile("data.BIN", 4096)
+
+	tests := []struct {
+		name string
+		cfg  internalcfg.PushConfig
+		path string
+		info os.FileInfo
+		want bool
+	}{
+		{name: "default_small", cfg: internalcfg.PushConfig{SkipCompressMinSize: 1024}, path: "small.txt", info: small, want: true},
+		{name: "default_large_text", cfg: internalcfg.PushConfig{SkipCompressMinSize: 1024}, path: "large.txt", info: large, want: false},
+		{name: "default_builtin_ext", cfg: internalcfg.PushConfig{SkipCompressMinSize: 1024}, path: "image.png", info: largePNG, want: true},
+		{name: "min_size_disabled", cfg: internalcfg.PushConfig{}, path: "small.txt", info: small, want: false},
+		{
+			name: "custom_ext_matches",
+			cfg:  internalcfg.PushConfig{SkipCompressExtensions: []string{"bin"}},
+			path: "data.BIN",
+			info: largeBin,
+			want: true,
+		},
+		{
+			name: "custom_ext_replaces_builtin",
+			cfg:  internalcfg.PushConfig{SkipCompressExtensions: []string{".bin"}},
+			path: "image.png",
+			info: largePNG,
+			want: false,
+		},
+		{
+			name: "custom_ext_with_min_size",
+			cfg:  internalcfg.PushConfig{SkipCompressExtensions: []string{".bin"}, SkipCompressMinSize: 1024},
+			path: "small.txt",
+			info: small,
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := skipCompressionFunc(&tt.cfg)
+			assert.Equal(t, tt.want, fn(tt.path, tt.info))
+		})
+	}
+}
+
+func TestExcludeCompressionFunc(t *testing.T) {
+	fn := excludeCompressionFunc([]string{"*.png", "*.zip", "vendor/*"})
+
+	assert.True(t, fn("image.png", nil))
+	assert.True(t, fn("assets/icons/logo.png", nil))
+	assert.True(t, fn("dist/bundle.zip", nil))
+	assert.True(t, fn("vendor/lib.js", nil))
+	assert.False(t, fn("src/vendor/lib.js", nil))
+	assert.False(t, fn("config.yaml", nil))
+}
+
+func TestParsePushFlags_CompressionExclude(t *testing.T) {
+	t.Cleanup(func() {
+		flag := pushCmd.Flags().Lookup("compression-exclude")
+		_ = flag.Value.(pflag.SliceValue).Replace(nil)
+		flag.Changed = false
+	})
+
+	require.NoError(t, pushCmd.Flags().Set("compression-exclude", "*.png,*.zip"))
+	flags, err := parsePushFlags(pushCmd)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.png", "*.zip"}, flags.compressionExcludes)
+
+	require.NoError(t, pushCmd.Flags().Lookup("compression-exclude").Value.(pflag.SliceValue).Replace([]string{"[a-"}))
+	_, err = parsePushFlags(pushCmd)
+	require.ErrorContains(t, err, "invalid --compression-exclude pattern")
+}
+
+func TestParsePushFlags_Priority(t *testing.T) {
+	t.Cleanup(func() {
+		flag := pushCmd.Flags().Lookup("priority")
+		_ = flag.Value.(pflag.SliceValue).Replace(nil)
+		flag.Changed = false
+	})
+
+	require.NoError(t, pushCmd.Flags().Set("priority", "/etc/app.conf,certs/"))
+	flags, err := parsePushFlags(pushCmd)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"etc/app.conf", "certs"}, flags.priority)
+	assert.Equal(t, "etc/app.conf,certs", flags.annotations[priority.Annotation])
+
+	require.NoError(t, pushCmd.Flags().Lookup("priority").Value.(pflag.SliceValue).Replace([]string{"etc/["}))
+	_, err = parsePushFlags(pushCmd)
+	require.ErrorContains(t, err, "invalid --priority")
+}
+
+func TestParsePushFlags_NoSkipCompressed(t *testing.T) {
+	t.Cleanup(func() {
+		_ = pushCmd.Flags().Set("no-skip-compressed", "false")
+	})
+
+	flags, err := parsePushFlags(pushCmd)
+	require.NoError(t, err)
+	assert.True(t, flags.skipCompressed)
+
+	require.NoError(t, pushCmd.Flags().Set("no-skip-compressed", "true"))
+	flags, err = parsePushFlags(pushCmd)
+	require.NoError(t, err)
+	assert.False(t, flags.skipCompressed)
+}
+
+func TestParsePlatformFlags(t *testing.T) {
+	variants, err := parsePlatformFlags([]string{"linux/amd64=dist/linux-amd64", "Darwin/ARM64=dist/darwin-arm64"})
+	require.NoError(t, err)
+	assert.Equal(t, []platform.Variant{
+		{Platform: "linux/amd64", Dir: "dist/linux-amd64"},
+		{Platform: "darwin/arm64", Dir: "dist/darwin-arm64"},
+	}, variants)
+
+	_, err = parsePlatformFlags([]string{"linux/amd64", "darwin/arm64=dist"})
+	require.Error(t, err)
+
+	_, err = parsePlatformFlags([]string{"amd64"})
+	require.Error(t, err)
+}
+
+func TestValidatePlatformDirs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "dist", "linux-amd64"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("x"), 0o644))
+
+	require.NoError(t, validatePlatformDirs(dir, []platform.Variant{{Platform: "linux/amd64"}}))
+	require.NoError(t, validatePlatformDirs(dir, []platform.Variant{{Platform: "linux/amd64", Dir: "dist/linux-amd64"}}))
+
+	err := validatePlatformDirs(dir, []platform.Variant{{Platform: "darwin/arm64", Dir: "dist/darwin-arm64"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dist/darwin-arm64")
+
+	err = validatePlatformDirs(dir, []platform.Variant{{Platform: "linux/amd64", Dir: "README"}})
+	require.Error(t, err)
+}
+
+func TestPushCmd_FromStdin(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	ref := reg.repo + ":v1"
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	content := []byte(`{"key":"value"}`)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "etc/config.json", Mode: 0o644, Size: int64(len(content))}))
+	_, err := tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	cfg := newRegistryTestConfig(t)
+	pushCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	pushCmd.SetIn(&buf)
+	t.Cleanup(func() { pushCmd.SetIn(nil) })
+
+	require.NoError(t, pushCmd.RunE(pushCmd, []string{ref, "-"}))
+	pushed := reg.tag("v1")
+	assert.Equal(t, map[string]string{"etc/config.json": string(content)}, pulledFiles(t, cfg, ref))
+
+	// An empty stream is a mistake, not an empty archive
+	pushCmd.SetIn(bytes.NewReader(nil))
+	err = pushCmd.RunE(pushCmd, []string{ref, "-"})
+	require.ErrorContains(t, err, "no files in stream")
+	assert.Equal(t, pushed, reg.tag("v1"), "the tag is not moved")
+}
+
+func TestPushCmd_Filters(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	ref := reg.repo + ":v1"
+
+	src := t.TempDir()
+	for name, content := range map[string]string{
+		archive.IgnoreFile:   ".git/\n*.env\n",
+		"app.yaml":           "app",
+		"prod.env":           "SECRET=1",
+		".git/HEAD":          "ref: refs/heads/main",
+		"svc/db.yaml":        "db",
+		"svc/notes.txt":      "notes",
+		"svc/build/out.yaml": "out",
+	} {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	cfg := newRegistryTestConfig(t)
+	pushCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	require.NoError(t, pushCmd.Flags().Set("include", "*.yaml"))
+	require.NoError(t, pushCmd.Flags().Set("exclude", "build/"))
+	t.Cleanup(func() {
+		pushCmd.Flags().Lookup("include").Value.(pflag.SliceValue).Replace(nil) //nolint:errcheck // test cleanup
+		pushCmd.Flags().Lookup("exclude").Value.(pflag.SliceValue).Replace(nil) //nolint:errcheck // test cleanup
+	})
+
+	require.NoError(t, pushCmd.RunE(pushCmd, []string{ref, src}))
+	pushed := reg.tag("v1")
+	assert.Equal(t, map[string]string{"app.yaml": "app", "svc/db.yaml": "db"}, pulledFiles(t, cfg, ref))
+
+	// The source directory is left untouched
+	assert.FileExists(t, filepath.Join(src, "prod.env"))
+
+	require.NoError(t, pushCmd.Flags().Lookup("include").Value.(pflag.SliceValue).Replace([]string{"*.json"}))
+	err := pushCmd.RunE(pushCmd, []string{ref, src})
+	require.ErrorContains(t, err, "no files left to push")
+	assert.Equal(t, pushed, reg.tag("v1"), "the tag is not moved")
+}
+
+func TestPlanPush(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "small.txt"), []byte("hi"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "big.txt"), bytes.Repeat([]byte("a"), 64<<10), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "image.png"), bytes.Repeat([]byte("b"), 64<<10), 0o644))
+
+	flags := pushFlags{
+		compression:    blob.CompressionZstd,
+		skipCompressed: true,
+		annotations:    map[string]string{"team": "platform"},
+	}
+	plan, err := planPush(t.Context(), src, flags, &internalcfg.PushConfig{SkipCompressMinSize: 1024})
+	require.NoError(t, err)
+
+	files := make(map[string]pushPlanFile, len(plan.Files))
+	for _, f := range plan.Files {
+		files[f.Path] = f
+	}
+	require.Len(t, files, 3)
+	assert.Equal(t, "none", files["small.txt"].Compression)
+	assert.Equal(t, "none", files["image.png"].Compression)
+	assert.Equal(t, uint64(64<<10), files["image.png"].StoredSize)
+	assert.Equal(t, "zstd", files["big.txt"].Compression)
+	assert.Less(t, files["big.txt"].StoredSize, files["big.txt"].Size)
+
+	assert.Equal(t, 3, plan.FileCount)
+	assert.Equal(t, uint64(2+128<<10), plan.TotalSize)
+	require.Len(t, plan.Manifest.Layers, 2)
+	assert.Equal(t, int64(plan.StoredSize), plan.Manifest.Layers[1].Size)
+	assert.Greater(t, plan.UploadSize, plan.StoredSize)
+	assert.Equal(t, map[string]string{"team": "platform"}, plan.Manifest.Annotations)
+}
+
+func TestPushCmd_DryRun(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	ref := reg.repo + ":v1"
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "config.json"), []byte(`{}`), 0o644))
+
+	cfg := newRegistryTestConfig(t)
+	pushCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	require.NoError(t, pushCmd.Flags().Set("dry-run", "true"))
+	t.Cleanup(func() {
+		pushCmd.Flags().Set("dry-run", "false") //nolint:errcheck // test cleanup
+	})
+
+	require.NoError(t, pushCmd.RunE(pushCmd, []string{ref, src}))
+	assert.Empty(t, reg.tag("v1"))
+	manifests, blobs := reg.stored()
+	assert.Zero(t, manifests, "nothing is pushed")
+	assert.Zero(t, blobs, "nothing is uploaded")
+}
+
+func TestParsePushSource(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    pushSource
+		wantErr string
+	}{
+		{value: "./configs:/etc/app", want: pushSource{dir: "./configs", mount: "etc/app"}},
+		{value: "./certs:/certs/", want: pushSource{dir: "./certs", mount: "certs"}},
+		{value: "./data", want: pushSource{dir: "./data", mount: ""}},
+		{value: "./data:/", want: pushSource{dir: "./data", mount: ""}},
+		{value: "C:/data:/etc", want: pushSource{dir: "C:/data", mount: "etc"}},
+		{value: "C:/data", want: pushSource{dir: "C:/data", mount: ""}},
+		{value: ":/etc", wantErr: "expected dir or dir:/path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parsePushSource(tt.value)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPushCmd_Sources(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	ref := reg.repo + ":v1"
+
+	configs, certs := t.TempDir(), t.TempDir()
+	for name, content := range map[string]string{
+		filepath.Join(configs, archive.IgnoreFile): "*.bak\n",
+		filepath.Join(configs, "app.conf"):         "app",
+		filepath.Join(configs, "app.conf.bak"):     "old",
+		filepath.Join(certs, "ca.pem"):             "ca",
+	} {
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	cfg := newRegistryTestConfig(t)
+	pushCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	sources := pushCmd.Flags().Lookup("source").Value.(pflag.SliceValue)
+	t.Cleanup(func() {
+		sources.Replace(nil) //nolint:errcheck // test cleanup
+	})
+
+	require.NoError(t, sources.Replace([]string{configs + ":/etc/app", certs + ":/certs"}))
+	require.NoError(t, pushCmd.RunE(pushCmd, []string{ref}))
+	assert.Equal(t, map[string]string{"etc/app/app.conf": "app", "certs/ca.pem": "ca"}, pulledFiles(t, cfg, ref))
+	manifests, blobs := reg.stored()
+
+	err := pushCmd.RunE(pushCmd, []string{ref, configs})
+	require.ErrorContains(t, err, "cannot be combined")
+
+	require.NoError(t, sources.Replace([]string{configs + ":/etc", configs + ":/etc"}))
+	err = pushCmd.RunE(pushCmd, []string{ref})
+	require.ErrorContains(t, err, "/etc/app.conf is in more than one source")
+
+	require.NoError(t, sources.Replace(nil))
+	err = pushCmd.RunE(pushCmd, []string{ref})
+	require.ErrorContains(t, err, "requires a path argument, --source, or --files-from")
+
+	gotManifests, gotBlobs := reg.stored()
+	assert.Equal(t, manifests, gotManifests, "refused pushes push nothing")
+	assert.Equal(t, blobs, gotBlobs, "refused pushes upload nothing")
+}
+
+func TestParseFilesFrom(t *testing.T) {
+	entries, err := parseFilesFrom(strings.NewReader("# build outputs\nbin/tool\n\nconf/app.yaml=/etc/app/app.yaml\n/abs/ca.pem=certs/ca.pem\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []filesFromEntry{
+		{local: "bin/tool", archivePath: "bin/tool"},
+		{local: "conf/app.yaml", archivePath: "etc/app/app.yaml"},
+		{local: "/abs/ca.pem", archivePath: "certs/ca.pem"},
+	}, entries)
+
+	for list, wantErr := range map[string]string{
+		"":                     "no files",
+		"a.txt\nb.txt=a.txt\n": "already added on line 1",
+		"../secret":            "outside the base directory",
+		"/etc/passwd":          "needs an archive path",
+		"a.txt=":               "expected local or local=archive/path",
+	} {
+		_, err := parseFilesFrom(strings.NewReader(list))
+		require.ErrorContains(t, err, wantErr, list)
+	}
+}
+
+func TestPushCmd_FilesFrom(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	ref := reg.repo + ":v1"
+
+	base := t.TempDir()
+	for name, content := range map[string]string{
+		"bin/tool":      "tool",
+		"conf/app.yaml": "app",
+		"conf/skip.txt": "skip",
+	} {
+		path := filepath.Join(base, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	cfg := newRegistryTestConfig(t)
+	pushCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	pushCmd.SetIn(strings.NewReader("bin/tool\nconf/app.yaml=etc/app.yaml\n"))
+	require.NoError(t, pushCmd.Flags().Set("files-from", "-"))
+	t.Cleanup(func() {
+		pushCmd.SetIn(nil)
+		pushCmd.Flags().Set("files-from", "") //nolint:errcheck // test cleanup
+	})
+
+	require.NoError(t, pushCmd.RunE(pushCmd, []string{ref, base}))
+	pushed := reg.tag("v1")
+	assert.Equal(t, map[string]string{"bin/tool": "tool", "etc/app.yaml": "app"}, pulledFiles(t, cfg, ref))
+
+	list := filepath.Join(t.TempDir(), "files.txt")
+	require.NoError(t, os.WriteFile(list, []byte("conf/missing.yaml\n"), 0o644))
+	require.NoError(t, pushCmd.Flags().Set("files-from", list))
+	err := pushCmd.RunE(pushCmd, []string{ref, base})
+	require.ErrorContains(t, err, "missing.yaml")
+	assert.Equal(t, pushed, reg.tag("v1"), "the tag is not moved")
+}
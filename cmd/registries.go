@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// dockerConfigEnv overrides the Docker config directory.
+const dockerConfigEnv = "DOCKER_CONFIG"
+
+// dockerConfigDir returns the user's Docker config directory.
+func dockerConfigDir() (string, error) {
+	if dir := os.Getenv(dockerConfigEnv); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("finding Docker config: %w", err)
+	}
+	return filepath.Join(home, ".docker"), nil
+}
+
+// registryCredentialTransport returns base wrapped so requests to the
+// registries with credentials in the registries section are authenticated
+// with them, in place of any from the Docker config. The credentials are
+// only held in memory.
+func registryCredentialTransport(base http.RoundTripper, registries []internalcfg.RegistryAuth) (http.RoundTripper, error) {
+	clients := make(map[string]*auth.Client)
+	for _, r := range registries {
+		c, err := resolveRegistryCredential(r, os.Getenv)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			continue
+		}
+		client := newAuthClient(base, c.credential)
+		clients[r.Host] = client
+		if r.Host == "docker.io" {
+			clients[dockerHubHost] = client
+		}
+	}
+	if len(clients) == 0 {
+		return base, nil
+	}
+	return &credentialTransport{base: base, clients: clients}, nil
+}
+
+// newAuthClient returns a client that authenticates requests sent through
+// base with the credentials from cred. Redirects are returned rather than
+// followed, so they are left to the registry client.
+func newAuthClient(base http.RoundTripper, cred auth.CredentialFunc) *auth.Client {
+	return &auth.Client{
+		Client: &http.Client{
+			Transport: base,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		Cache:      auth.NewCache(),
+		Credential: cred,
+	}
+}
+
+// credentialTransport authenticates requests to the registries in clients
+// with their clients, and sends all other requests through base.
+type credentialTransport struct {
+	base    http.RoundTripper
+	clients map[string]*auth.Client
+}
+
+func (t *credentialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	client, ok := t.clients[req.URL.Host]
+	if !ok {
+		client, ok = t.clients[req.URL.Hostname()]
+	}
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	// The client only authenticates requests without credentials
+	req = req.Clone(req.Context())
+	req.Header.Del("Authorization")
+	return client.Do(req)
+}
+
+// registryCredential is where a registry's credentials come from.
+type registryCredential struct {
+	host          string
+	username      string
+	password      string
+	identityToken string
+	helper        string
+}
+
+// credential implements auth.CredentialFunc, taking credentials from the
+// configured credential helper if there is one.
+func (c *registryCredential) credential(ctx context.Context, _ string) (auth.Credential, error) {
+	if c.helper != "" {
+		cred, err := credentials.NewNativeStore(c.helper).Get(ctx, credentials.ServerAddressFromRegistry(c.host))
+		if err != nil {
+			return auth.EmptyCredential, fmt.Errorf("registries (%s): credential helper %s: %w", c.host, c.helper, err)
+		}
+		return cred, nil
+	}
+	return auth.Credential{Username: c.username, Password: c.password, RefreshToken: c.identityToken}, nil
+}
+
+// resolveRegistryCredential reads the credentials configured for r from
+// the environment. It returns nil if r configures none.
+func resolveRegistryCredential(r internalcfg.RegistryAuth, getenv func(string) string) (*registryCredential, error) {
+	c := &registryCredential{host: r.Host, helper: r.CredentialHelper, username: r.Username}
+	if c.username == "" && r.UsernameEnv != "" {
+		c.username = getenv(r.UsernameEnv)
+		if c.username == "" {
+			return nil, fmt.Errorf("registries (%s): $%s is not set", r.Host, r.UsernameEnv)
+		}
+	}
+	if r.PasswordEnv != "" {
+		c.password = getenv(r.PasswordEnv)
+		if c.password == "" {
+			return nil, fmt.Errorf("registries (%s): $%s is not set", r.Host, r.PasswordEnv)
+		}
+	}
+	if r.IdentityTokenEnv != "" {
+		c.identityToken = getenv(r.IdentityTokenEnv)
+		if c.identityToken == "" {
+			return nil, fmt.Errorf("registries (%s): $%s is not set", r.Host, r.IdentityTokenEnv)
+		}
+	}
+	if c.password == "" && c.identityToken == "" && c.helper == "" {
+		return nil, nil //nolint:nilnil // no credentials configured is not an error
+	}
+	return c, nil
+}
+
+// registryHostTransport returns the transport requests leave through,
+// derived from base: requests to the registries with connection settings
+// in the registries section are sent with them, and all others through
+// base.
+func registryHostTransport(base *http.Transport, registries []internalcfg.RegistryAuth) (http.RoundTripper, error) {
+	hosts := make(map[string]*registryTransport)
+	for _, r := range registries {
+		rt, err := newRegistryTransport(base, r)
+		if err != nil {
+			return nil, err
+		}
+		if rt != nil {
+			hosts[r.Host] = rt
+		}
+	}
+	if len(hosts) == 0 {
+		return base, nil
+	}
+	return &hostTransport{base: base, hosts: hosts}, nil
+}
+
+// registryTransport is how requests to one registry are sent.
+type registryTransport struct {
+	plainHTTP bool
+	transport http.RoundTripper
+}
+
+// newRegistryTransport returns the transport for r, derived from base.
+// It returns nil if r does not change how the registry is reached.
+func newRegistryTransport(base *http.Transport, r internalcfg.RegistryAuth) (*registryTransport, error) {
+	if r.PlainHTTP {
+		return &registryTransport{plainHTTP: true, transport: base}, nil
+	}
+	if r.CAFile == "" && !r.SkipTLSVerify {
+		return nil, nil //nolint:nilnil // the default transport applies
+	}
+
+	t := base.Clone()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{} //nolint:gosec // MinVersion is the Go default
+	}
+	if r.CAFile != "" {
+		data, err := os.ReadFile(r.CAFile) //nolint:gosec // path comes from the user's config
+		if err != nil {
+			return nil, fmt.Errorf("registries (%s): reading ca_file: %w", r.Host, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("registries (%s): ca_file %s contains no PEM certificates", r.Host, r.CAFile)
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	t.TLSClientConfig.InsecureSkipVerify = r.SkipTLSVerify //nolint:gosec // requested for this registry
+	return &registryTransport{transport: t}, nil
+}
+
+// hostTransport sends requests to the registries in hosts through their
+// own transport, and all other requests through base.
+type hostTransport struct {
+	base  http.RoundTripper
+	hosts map[string]*registryTransport
+}
+
+// registryFor returns the transport for the registry u points at, matching
+// the host with its port first. It returns nil if none is configured.
+func (t *hostTransport) registryFor(u *url.URL) *registryTransport {
+	if rt, ok := t.hosts[u.Host]; ok {
+		return rt
+	}
+	return t.hosts[u.Hostname()]
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := t.registryFor(req.URL)
+	if rt == nil {
+		return t.base.RoundTrip(req)
+	}
+	if rt.plainHTTP && req.URL.Scheme == "https" {
+		req = req.Clone(req.Context())
+		req.URL.Scheme = "http"
+	}
+	return rt.transport.RoundTrip(req)
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/v2/registry/remote/auth"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestResolveRegistryCredential(t *testing.T) {
+	env := map[string]string{"GHCR_USER": "octocat", "GHCR_TOKEN": "s3cret"}
+	getenv := func(name string) string { return env[name] }
+
+	c, err := resolveRegistryCredential(internalcfg.RegistryAuth{
+		Host: "ghcr.io", UsernameEnv: "GHCR_USER", PasswordEnv: "GHCR_TOKEN",
+	}, getenv)
+	require.NoError(t, err)
+	assert.Equal(t, &registryCredential{host: "ghcr.io", username: "octocat", password: "s3cret"}, c)
+
+	// Only TLS settings: nothing to add to the Docker config
+	c, err = resolveRegistryCredential(internalcfg.RegistryAuth{Host: "ghcr.io", SkipTLSVerify: true}, getenv)
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	_, err = resolveRegistryCredential(internalcfg.RegistryAuth{
+		Host: "ghcr.io", Username: "octocat", PasswordEnv: "MISSING",
+	}, getenv)
+	require.ErrorContains(t, err, "$MISSING is not set")
+}
+
+func TestRegistryCredentialTransport(t *testing.T) {
+	t.Setenv("GHCR_TOKEN", "s3cret")
+	t.Setenv(dockerConfigEnv, "/home/octocat/.docker")
+	var gotAuth []string
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		gotAuth = append(gotAuth, user+":"+pass)
+		if !ok || user != "octocat" || pass != "s3cret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(registry.Close)
+	var otherAuth string
+	other := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		otherAuth = r.Header.Get("Authorization")
+	}))
+	t.Cleanup(other.Close)
+
+	rt, err := registryCredentialTransport(http.DefaultTransport, []internalcfg.RegistryAuth{
+		{Host: registry.Listener.Addr().String(), Username: "octocat", PasswordEnv: "GHCR_TOKEN"},
+	})
+	require.NoError(t, err)
+	client := &http.Client{Transport: rt}
+	get := func(url, authorization string) int {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, http.NoBody)
+		require.NoError(t, err)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Configured credentials replace those from the Docker config
+	assert.Equal(t, http.StatusOK, get(registry.URL+"/v2/", "Basic ZG9ja2VyOmNyZWQ="))
+	assert.Equal(t, []string{":", "octocat:s3cret"}, gotAuth)
+
+	// Other registries are left to the Docker config
+	get(other.URL+"/v2/", "Bearer docker")
+	assert.Equal(t, "Bearer docker", otherAuth)
+
+	// Nothing is written or pointed elsewhere
+	assert.Equal(t, "/home/octocat/.docker", os.Getenv(dockerConfigEnv))
+}
+
+func TestRegistryCredential(t *testing.T) {
+	c := &registryCredential{host: "docker.io", identityToken: "refresh"}
+	cred, err := c.credential(context.Background(), "registry-1.docker.io")
+	require.NoError(t, err)
+	assert.Equal(t, auth.Credential{RefreshToken: "refresh"}, cred)
+
+	c = &registryCredential{host: "gcr.io", helper: "blob-test-missing-helper"}
+	_, err = c.credential(context.Background(), "gcr.io")
+	require.ErrorContains(t, err, "credential helper blob-test-missing-helper")
+}
+
+func TestRegistryTransports(t *testing.T) {
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"ok": "yes"}) //nolint:errcheck // test
+	}))
+	t.Cleanup(tlsSrv.Close)
+	plainSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(plainSrv.Close)
+	tlsHost := tlsSrv.Listener.Addr().String()
+	plainHost := plainSrv.Listener.Addr().String()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsSrv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, certPEM, 0o600))
+
+	get := func(registries []internalcfg.RegistryAuth, url string) error {
+		rt, err := buildRegistryTransport(&internalcfg.Config{Registries: registries}, "")
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, http.NoBody)
+		if err != nil {
+			return err
+		}
+		resp, err := (&http.Client{Transport: rt}).Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	require.Error(t, get(nil, tlsSrv.URL), "self-signed certificate should be rejected")
+	require.NoError(t, get([]internalcfg.RegistryAuth{{Host: tlsHost, CAFile: caFile}}, tlsSrv.URL))
+	require.NoError(t, get([]internalcfg.RegistryAuth{{Host: tlsHost, SkipTLSVerify: true}}, tlsSrv.URL))
+
+	// Settings for another registry do not apply
+	require.Error(t, get([]internalcfg.RegistryAuth{{Host: "ghcr.io", SkipTLSVerify: true}}, tlsSrv.URL))
+
+	// plain_http turns HTTPS requests to the registry into HTTP
+	require.Error(t, get(nil, "https://"+plainHost+"/v2/"))
+	require.NoError(t, get([]internalcfg.RegistryAuth{{Host: plainHost, PlainHTTP: true}}, "https://"+plainHost+"/v2/"))
+
+	notPEM := filepath.Join(t.TempDir(), "ca.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+	err := get([]internalcfg.RegistryAuth{{Host: tlsHost, CAFile: notPEM}}, tlsSrv.URL)
+	require.ErrorContains(t, err, "contains no PEM certificates")
+}
@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	blobcore "github.com/meigma/blob/core"
+	"github.com/meigma/blob/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/referrers"
+)
+
+// testRegistry serves manifests, blobs, tags, and the referrers API for
+// acme/configs, recording the digests it is asked to delete. It accepts
+// monolithic blob uploads and manifest pushes. Deleting a manifest
+// removes its tags; deleting a tag alone is refused unless tagDeletes is
+// set. Its state may be changed and read while commands use it.
+type testRegistry struct {
+	repo       string // host/acme/configs, for references
+	tagDeletes bool
+
+	mu        sync.Mutex
+	manifests map[string][]byte               // by digest
+	blobs     map[string][]byte               // by digest
+	tags      map[string]string               // tag to digest
+	referrers map[string][]ocispec.Descriptor // subject digest to referrers
+	deleted   []string
+}
+
+func newTestRegistry(t *testing.T) (*testRegistry, *httptest.Server) {
+	t.Helper()
+	reg := &testRegistry{
+		manifests: make(map[string][]byte),
+		blobs:     make(map[string][]byte),
+		tags:      make(map[string]string),
+		referrers: make(map[string][]ocispec.Descriptor),
+	}
+	srv := httptest.NewServer(reg)
+	t.Cleanup(srv.Close)
+	reg.repo = strings.TrimPrefix(srv.URL, "http://") + "/acme/configs"
+	return reg, srv
+}
+
+// newRegistryTestConfig returns a config for commands run against a
+// testRegistry, with and a cache of its own.
+func newRegistryTestConfig(t *testing.T) *internalcfg.Config {
+	t.Helper()
+	return &internalcfg.Config{PlainHTTP: true, Quiet: true, Cache: internalcfg.CacheConfig{Dir: t.TempDir()}}
+}
+
+// tag returns the digest tag points to, or "" if it is not tagged.
+func (reg *testRegistry) tag(tag string) string {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.tags[tag]
+}
+
+// hasManifest reports whether the manifest with digest is stored.
+func (reg *testRegistry) hasManifest(dgst string) bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	_, ok := reg.manifests[dgst]
+	return ok
+}
+
+// stored returns how many manifests and blobs are stored.
+func (reg *testRegistry) stored() (manifests, blobs int) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return len(reg.manifests), len(reg.blobs)
+}
+
+// manifestData returns the stored manifest a tag or digest names, or nil.
+func (reg *testRegistry) manifestData(reference string) []byte {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if dgst, ok := reg.tags[reference]; ok {
+		reference = dgst
+	}
+	return reg.manifests[reference]
+}
+
+// manifest returns the manifest a tag or digest names.
+func (reg *testRegistry) manifest(t *testing.T, reference string) ocispec.Manifest {
+	t.Helper()
+	data := reg.manifestData(reference)
+	require.NotNil(t, data, "no manifest %s", reference)
+	var manifest ocispec.Manifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	return manifest
+}
+
+// deletedDigests returns the digests deleted so far, in order.
+func (reg *testRegistry) deletedDigests() []string {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return slices.Clone(reg.deleted)
+}
+
+func (reg *testRegistry) add(t *testing.T, tag string, subject *ocispec.Descriptor, artifactType string) ocispec.Descriptor {
+	t.Helper()
+	return reg.addWithLayers(t, tag, subject, artifactType, ocispec.DescriptorEmptyJSON)
+}
+
+// addBlob stores data as a blob and returns its descriptor.
+func (reg *testRegistry) addBlob(mediaType string, data []byte) ocispec.Descriptor {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	desc := ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(data), Size: int64(len(data))}
+	reg.blobs[desc.Digest.String()] = data
+	return desc
+}
+
+// addArchive stores a blob archive of files and tags its manifest.
+func (reg *testRegistry) addArchive(t *testing.T, tag string, files map[string]string) ocispec.Descriptor {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	var indexBuf, dataBuf bytes.Buffer
+	require.NoError(t, blobcore.Create(t.Context(), dir, &indexBuf, &dataBuf))
+	return reg.addWithLayers(t, tag, nil, registry.ArtifactType,
+		reg.addBlob(registry.MediaTypeIndex, indexBuf.Bytes()),
+		reg.addBlob(registry.MediaTypeData, dataBuf.Bytes()))
+}
+
+func (reg *testRegistry) addWithLayers(t *testing.T, tag string, subject *ocispec.Descriptor, artifactType string, layers ...ocispec.Descriptor) ocispec.Descriptor {
+	t.Helper()
+	manifest := ocispec.Manifest{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Config:       ocispec.DescriptorEmptyJSON,
+		Layers:       layers,
+		Subject:      subject,
+	}
+	manifest.SchemaVersion = 2
+	data, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	desc := ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Digest:       digest.FromBytes(data),
+		Size:         int64(len(data)),
+	}
+	reg.manifests[desc.Digest.String()] = data
+	if tag != "" {
+		reg.tags[tag] = desc.Digest.String()
+	}
+	if subject != nil {
+		reg.referrers[subject.Digest.String()] = append(reg.referrers[subject.Digest.String()], desc)
+	}
+	return desc
+}
+
+// addTagReferrer stores a referrer of subject that the referrers API does
+// not list, indexing it under the referrers tag as oras does on registries
+// without the referrers API.
+func (reg *testRegistry) addTagReferrer(t *testing.T, subject ocispec.Descriptor, artifactType string) ocispec.Descriptor {
+	t.Helper()
+	desc := reg.add(t, "", &subject, artifactType)
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.referrers[subject.Digest.String()] = slices.DeleteFunc(reg.referrers[subject.Digest.String()], func(d ocispec.Descriptor) bool {
+		return d.Digest == desc.Digest
+	})
+
+	tag := referrers.Tag(subject.Digest)
+	var index ocispec.Index
+	if dgst, ok := reg.tags[tag]; ok {
+		require.NoError(t, json.Unmarshal(reg.manifests[dgst], &index))
+	}
+	index.SchemaVersion = 2
+	index.MediaType = ocispec.MediaTypeImageIndex
+	index.Manifests = append(index.Manifests, desc)
+	data, err := json.Marshal(index)
+	require.NoError(t, err)
+	reg.manifests[digest.FromBytes(data).String()] = data
+	reg.tags[tag] = digest.FromBytes(data).String()
+	return desc
+}
+
+func (reg *testRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	const prefix = "/v2/acme/configs/"
+	if r.URL.Path == "/v2/" {
+		return
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, prefix)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if subject, ok := strings.CutPrefix(rest, "referrers/"); ok {
+		index := ocispec.Index{
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: reg.referrers[subject],
+		}
+		index.SchemaVersion = 2
+		if index.Manifests == nil {
+			index.Manifests = []ocispec.Descriptor{}
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		//nolint:errcheck // test server
+		json.NewEncoder(w).Encode(index)
+		return
+	}
+
+	if rest == "tags/list" {
+		tags := make([]string, 0, len(reg.tags))
+		for tag := range reg.tags {
+			tags = append(tags, tag)
+		}
+		slices.Sort(tags) // registries list tags in lexical order
+		w.Header().Set("Content-Type", "application/json")
+		//nolint:errcheck // test server
+		json.NewEncoder(w).Encode(map[string]any{"name": "acme/configs", "tags": tags})
+		return
+	}
+
+	if upload, ok := strings.CutPrefix(rest, "blobs/uploads/"); ok {
+		reg.upload(w, r, upload)
+		return
+	}
+
+	if dgst, ok := strings.CutPrefix(rest, "blobs/"); ok {
+		data, ok := reg.blobs[dgst]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		// ServeContent answers the range requests archives are read with
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+		return
+	}
+
+	reference, ok := strings.CutPrefix(rest, "manifests/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method == http.MethodPut {
+		reg.putManifest(w, r, reference)
+		return
+	}
+	dgst := reference
+	if d, ok := reg.tags[reference]; ok {
+		dgst = d
+	}
+	data, ok := reg.manifests[dgst]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		if reference != dgst {
+			if !reg.tagDeletes {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			delete(reg.tags, reference)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		delete(reg.manifests, dgst)
+		for tag, d := range reg.tags {
+			if d == dgst {
+				delete(reg.tags, tag)
+			}
+		}
+		reg.deleted = append(reg.deleted, dgst)
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodHead, http.MethodGet:
+		var content struct {
+			MediaType string `json:"mediaType"`
+		}
+		json.Unmarshal(data, &content) //nolint:errcheck // test server
+		w.Header().Set("Content-Type", content.MediaType)
+		w.Header().Set("Docker-Content-Digest", dgst)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data) //nolint:errcheck // test server
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// upload starts an upload (POST) or completes it with the whole blob (PUT).
+func (reg *testRegistry) upload(w http.ResponseWriter, r *http.Request, id string) {
+	switch {
+	case r.Method == http.MethodPost && id == "":
+		w.Header().Set("Location", "/v2/acme/configs/blobs/uploads/"+strconv.Itoa(len(reg.blobs)))
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut && id != "":
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		dgst := digest.FromBytes(data)
+		if dgst.String() != r.URL.Query().Get("digest") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reg.blobs[dgst.String()] = data
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// putManifest stores a pushed manifest, tagging it unless reference is a digest.
+func (reg *testRegistry) putManifest(w http.ResponseWriter, r *http.Request, reference string) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	dgst := digest.FromBytes(data).String()
+	reg.manifests[dgst] = data
+	if !strings.HasPrefix(reference, "sha256:") {
+		reg.tags[reference] = dgst
+	}
+	w.Header().Set("Docker-Content-Digest", dgst)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// pulledFiles pulls the archive at ref and returns the content of each of
+// its files by path.
+func pulledFiles(t *testing.T, cfg *internalcfg.Config, ref string) map[string]string {
+	t.Helper()
+	client, err := newClient(cfg)
+	require.NoError(t, err)
+	pulled, err := client.Pull(t.Context(), ref)
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for e := range pulled.Entries() {
+		if !e.Mode().IsRegular() {
+			continue
+		}
+		data, err := pulled.ReadFile(e.Path())
+		require.NoError(t, err, e.Path())
+		files[e.Path()] = string(data)
+	}
+	return files
+}
+
+// requireExitCode checks that err exits blob with code.
+func requireExitCode(t *testing.T, err error, code int) {
+	t.Helper()
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, code, exitErr.Code, "exit code of: %v", err)
+}
+
+// captureStdout runs fn and returns what it wrote to stdout.
+func captureStdout(t *testing.T, fn func() error) ([]byte, error) {
+	t.Helper()
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+	done := make(chan []byte)
+	go func() {
+		var buf bytes.Buffer
+		buf.ReadFrom(r) //nolint:errcheck // the pipe is closed below
+		done <- buf.Bytes()
+	}()
+
+	err = fn()
+	w.Close()
+	os.Stdout = oldStdout
+	return <-done, err
+}
@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/semver"
+)
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve <ref>...",
+	Short: "Resolve references, including semver queries, to tags and digests",
+	Long: `Resolve references, including semver queries, to tags and digests.
+
+Expands aliases and, if the tag is a semantic version query, lists the
+repository's tags and picks the highest version that matches. Prints
+the resolved reference, or with --digest, the reference pinned by the
+manifest digest.
+
+Queries:
+  latest-stable     highest release version (no prereleases)
+  1.2.3, v1.2.3     exactly that version
+  1.2, 1.2.x        any 1.2.x version
+  ^1.2.3            compatible updates: >=1.2.3 <2.0.0
+  ~1.2.3            patch updates: >=1.2.3 <1.3.0
+  '>=1.2 <2'        comparators, all of which must match
+  '^1 || ^2'        alternatives
+
+Tags that are semantic versions, with or without a leading "v", are
+considered; other tags are ignored. Prerelease tags only match queries
+that name a prerelease. Tags that are not queries, such as "latest",
+are resolved as is.
+
+The global --semver flag applies the same resolution to references
+given to other commands.`,
+	Example: `  blob resolve 'ghcr.io/acme/configs:^1.2'
+  blob resolve --digest configs:latest-stable
+  blob resolve 'configs:>=1.2 <2' --output json
+  blob pull "$(blob resolve --digest 'configs:^1.2')" ./config
+  blob resolve --digest --copy configs:latest-stable
+  blob --semver pull 'configs:~1.4' ./config`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runResolve,
+}
+
+func init() {
+	resolveCmd.Flags().Bool("digest", false, "print references pinned by digest")
+	resolveCmd.Flags().Bool("copy", false, "copy the printed references to the clipboard")
+}
+
+// resolveResult contains the resolve output data for JSON format.
+type resolveResult struct {
+	Resolved []resolveEntry `json:"resolved"`
+}
+
+// resolveEntry describes a resolved reference.
+type resolveEntry struct {
+	Ref         string `json:"ref"`
+	ResolvedRef string `json:"resolved_ref"`
+	Query       string `json:"query,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	Digest      string `json:"digest"`
+}
+
+func runResolve(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	pinDigest, err := cmd.Flags().GetBool("digest")
+	if err != nil {
+		return fmt.Errorf("reading digest flag: %w", err)
+	}
+
+	ctx := cmd.Context()
+	result := resolveResult{Resolved: make([]resolveEntry, 0, len(args))}
+	for _, arg := range args {
+		entry, err := resolveEntryFor(ctx, cfg, arg)
+		if err != nil {
+			return err
+		}
+		result.Resolved = append(result.Resolved, entry)
+	}
+
+	lines := make([]string, 0, len(result.Resolved))
+	for _, e := range result.Resolved {
+		if pinDigest {
+			lines = append(lines, repositoryOf(e.ResolvedRef)+"@"+e.Digest)
+		} else {
+			lines = append(lines, e.ResolvedRef)
+		}
+	}
+	what := "reference"
+	if len(lines) > 1 {
+		what = "references"
+	}
+	if err := copyFlagValue(cmd, cfg, what, strings.Join(lines, "\n")); err != nil {
+		return err
+	}
+
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// resolveEntryFor resolves arg, including any semver query, and looks up
+// the manifest digest it points to.
+func resolveEntryFor(ctx context.Context, cfg *internalcfg.Config, arg string) (resolveEntry, error) {
+	entry := resolveEntry{Ref: arg}
+	aliased := cfg.ResolveAlias(arg)
+	if _, tag, ok := splitTag(aliased); ok {
+		if _, err := semver.Parse(tag); err == nil {
+			entry.Query = tag
+		}
+	}
+
+	resolved, err := resolveSemverRef(ctx, cfg, aliased)
+	if err != nil {
+		return entry, err
+	}
+	entry.ResolvedRef = resolved
+	if _, tag, ok := splitTag(resolved); ok {
+		entry.Tag = tag
+	}
+
+	dgst, err := resolveDigest(ctx, cfg, resolved)
+	if err != nil {
+		if errors.Is(err, errNoTagOrDigest) {
+			return entry, fmt.Errorf("invalid reference %q: %w", arg, err)
+		}
+		return entry, err
+	}
+	entry.Digest = dgst
+	return entry, nil
+}
+
+// errNoTagOrDigest is returned by resolveDigest for a reference with
+// neither a tag nor a digest.
+var errNoTagOrDigest = errors.New("a tag or digest is required")
+
+// resolveDigest looks up the manifest digest ref points to in the
+// registry, bypassing the ref cache.
+func resolveDigest(ctx context.Context, cfg *internalcfg.Config, ref string) (string, error) {
+	repository, reference, err := remoteReference(cfg, ref)
+	if err != nil {
+		return "", err
+	}
+	desc, err := repository.Resolve(ctx, reference)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// resolveManifest is resolveDigest, also returning the annotations of the
+// manifest. It fetches the manifest in place of the lookup, so it costs
+// the same single request.
+func resolveManifest(ctx context.Context, cfg *internalcfg.Config, ref string) (string, map[string]string, error) {
+	repository, reference, err := remoteReference(cfg, ref)
+	if err != nil {
+		return "", nil, err
+	}
+	desc, rc, err := repository.FetchReference(ctx, reference)
+	if err != nil {
+		return "", nil, fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	defer rc.Close()
+	data, err := content.ReadAll(rc, desc)
+	if err != nil {
+		return "", nil, fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", nil, fmt.Errorf("resolving %s: parsing manifest: %w", ref, err)
+	}
+	return desc.Digest.String(), manifest.Annotations, nil
+}
+
+// remoteReference returns the repository of ref and its tag or digest.
+func remoteReference(cfg *internalcfg.Config, ref string) (*remote.Repository, string, error) {
+	var reference string
+	if _, tag, ok := splitTag(ref); ok {
+		reference = tag
+	}
+	if idx := strings.LastIndex(ref, "@"); idx != -1 {
+		reference = ref[idx+1:]
+	}
+	if reference == "" {
+		return nil, "", errNoTagOrDigest
+	}
+	repository, err := newRemoteRepository(cfg, repositoryOf(ref))
+	if err != nil {
+		return nil, "", err
+	}
+	return repository, reference, nil
+}
+
+// resolveRef expands an alias and, with --semver, resolves a semver query
+// in the tag. Commands that read an archive call this instead of
+// ResolveAlias.
+func resolveRef(ctx context.Context, cfg *internalcfg.Config, ref string) (string, error) {
+	resolved := cfg.ResolveAlias(ref)
+	if !cfg.Semver {
+		return resolved, nil
+	}
+	return resolveSemverRef(ctx, cfg, resolved)
+}
+
+// defaultRefArgs wraps validate so that the reference argument may be
+// omitted, for default_ref (BLOB_DEFAULT_REF). Arguments are validated
+// before the config is loaded, so an empty list is let through and
+// withDefaultRef rejects it if no default is set.
+func defaultRefArgs(validate cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return nil
+		}
+		return validate(cmd, args)
+	}
+}
+
+// withDefaultRef returns args, or the default reference when no arguments
+// were given. It fails if neither is set.
+func withDefaultRef(cfg *internalcfg.Config, args []string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+	if cfg.DefaultRef == "" {
+		return nil, fmt.Errorf("requires a reference argument, or default_ref (%s) to be set", internalcfg.EnvDefaultRef)
+	}
+	return []string{cfg.DefaultRef}, nil
+}
+
+// resolveSemverRefs applies resolveSemverRef to each reference in place
+// when --semver is set, looking up each distinct reference once.
+func resolveSemverRefs(ctx context.Context, cfg *internalcfg.Config, refs ...*string) error {
+	if !cfg.Semver {
+		return nil
+	}
+	resolved := make(map[string]string, len(refs))
+	for _, ref := range refs {
+		r, ok := resolved[*ref]
+		if !ok {
+			var err error
+			r, err = resolveSemverRef(ctx, cfg, *ref)
+			if err != nil {
+				return err
+			}
+			resolved[*ref] = r
+		}
+		*ref = r
+	}
+	return nil
+}
+
+// resolveSemverRef replaces a tag that is a semver query with the highest
+// matching tag in the repository. References whose tag is not a query, and
+// digest references, are returned unchanged.
+func resolveSemverRef(ctx context.Context, cfg *internalcfg.Config, ref string) (string, error) {
+	repo, tag, ok := splitTag(ref)
+	if !ok {
+		return ref, nil
+	}
+	query, err := semver.Parse(tag)
+	if err != nil {
+		return ref, nil //nolint:nilerr // not a query; use the tag as is
+	}
+
+	tags, err := listTags(ctx, cfg, repo)
+	if err != nil {
+		return "", err
+	}
+	best, err := query.Best(tags)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	return repo + ":" + best, nil
+}
+
+// splitTag splits ref into its repository and tag. ok is false for digest
+// references and references without a tag.
+func splitTag(ref string) (repo, tag string, ok bool) {
+	if strings.Contains(ref, "@") {
+		return "", "", false
+	}
+	repo = repositoryOf(ref)
+	if repo == ref {
+		return "", "", false
+	}
+	return repo, ref[len(repo)+1:], true
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/semver"
+)
+
+func TestResolveRef_Semver(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	for _, tag := range []string{"v1.2.0", "v1.4.1", "v2.0.0", "latest"} {
+		reg.add(t, tag, nil, "")
+	}
+	repo := reg.repo
+	cfg := &internalcfg.Config{
+		PlainHTTP: true,
+		Aliases:   map[string]string{"configs": repo},
+	}
+
+	// Without --semver, references are only alias-expanded.
+	got, err := resolveRef(t.Context(), cfg, "configs:^1.2")
+	require.NoError(t, err)
+	assert.Equal(t, repo+":^1.2", got)
+
+	cfg.Semver = true
+	got, err = resolveRef(t.Context(), cfg, "configs:^1.2")
+	require.NoError(t, err)
+	assert.Equal(t, repo+":v1.4.1", got)
+
+	got, err = resolveRef(t.Context(), cfg, repo+":latest-stable")
+	require.NoError(t, err)
+	assert.Equal(t, repo+":v2.0.0", got)
+
+	// Tags that are not queries are used as is.
+	got, err = resolveRef(t.Context(), cfg, "configs")
+	require.NoError(t, err)
+	assert.Equal(t, repo+":latest", got)
+
+	_, err = resolveRef(t.Context(), cfg, "configs:^3")
+	require.ErrorIs(t, err, semver.ErrNoMatch)
+
+	refs := []string{repo + ":~1.2", repo + ":~1.2"}
+	require.NoError(t, resolveSemverRefs(t.Context(), cfg, &refs[0], &refs[1]))
+	assert.Equal(t, []string{repo + ":v1.2.0", repo + ":v1.2.0"}, refs)
+}
+
+func TestResolveEntryFor(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	reg.add(t, "v1.0.0", nil, "")
+	want := reg.add(t, "v1.1.0", nil, "application/vnd.test")
+	repo := reg.repo
+	cfg := &internalcfg.Config{PlainHTTP: true}
+
+	entry, err := resolveEntryFor(t.Context(), cfg, repo+":^1")
+	require.NoError(t, err)
+	assert.Equal(t, "^1", entry.Query)
+	assert.Equal(t, "v1.1.0", entry.Tag)
+	assert.Equal(t, repo+":v1.1.0", entry.ResolvedRef)
+	assert.Equal(t, want.Digest.String(), entry.Digest)
+}
+
+func TestSplitTag(t *testing.T) {
+	repo, tag, ok := splitTag("localhost:5000/acme/configs:>=1.2 <2")
+	require.True(t, ok)
+	assert.Equal(t, "localhost:5000/acme/configs", repo)
+	assert.Equal(t, ">=1.2 <2", tag)
+
+	_, _, ok = splitTag("localhost:5000/acme/configs")
+	assert.False(t, ok)
+	_, _, ok = splitTag("ghcr.io/acme/configs@sha256:abc")
+	assert.False(t, ok)
+}
+
+func TestResolveCmd_Copy(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	want := reg.add(t, "v1.0.0", nil, "")
+	repo := reg.repo
+
+	var copied string
+	oldCopy := clipboardCopy
+	clipboardCopy = func(text string) (string, error) {
+		copied = text
+		return "test", nil
+	}
+	t.Cleanup(func() {
+		clipboardCopy = oldCopy
+		resolveCmd.Flags().Set("copy", "false")   //nolint:errcheck // test cleanup
+		resolveCmd.Flags().Set("digest", "false") //nolint:errcheck // test cleanup
+	})
+	require.NoError(t, resolveCmd.Flags().Set("copy", "true"))
+	require.NoError(t, resolveCmd.Flags().Set("digest", "true"))
+
+	cfg := newRegistryTestConfig(t)
+	resolveCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	require.NoError(t, resolveCmd.RunE(resolveCmd, []string{repo + ":v1.0.0"}))
+	assert.Equal(t, repo+"@"+want.Digest.String(), copied)
+}
+
+func TestDefaultRefArgs(t *testing.T) {
+	validate := defaultRefArgs(cobra.RangeArgs(1, 2))
+	require.NoError(t, validate(pullCmd, nil), "checked once the config is loaded")
+	require.NoError(t, validate(pullCmd, []string{"configs:v2", "./out"}))
+	require.Error(t, validate(pullCmd, []string{"a", "b", "c"}))
+
+	cfg := &internalcfg.Config{DefaultRef: "configs:v1"}
+	args, err := withDefaultRef(cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"configs:v1"}, args)
+	args, err = withDefaultRef(cfg, []string{"configs:v2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"configs:v2"}, args)
+	_, err = withDefaultRef(&internalcfg.Config{}, nil)
+	require.ErrorContains(t, err, "requires a reference argument")
+}
@@ -0,0 +1,450 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/referrers"
+)
+
+var rmCmd = &cobra.Command{
+	Use:   "rm <ref>...",
+	Short: "Delete tags or manifests from a registry",
+	Long: `Delete tags or manifests from a registry.
+
+Deletes the manifest each reference points to. Registries delete
+manifests by digest, which removes every tag that points to the
+manifest. If other tags point to the manifest a tag names, rm refuses
+to delete it unless --force is set, and then deletes only the tag where
+the registry supports that, leaving the manifest and its referrers in
+place. Not all registries allow deletion; those that do not return an
+error.
+
+With --referrers, signatures, attestations, and other artifacts that
+refer to the manifest are deleted first, including artifacts that
+refer to them in turn. Without it, they are left in place.
+
+Prompts for confirmation unless --force is set. --force is required
+with --output json or --quiet.`,
+	Example: `  blob rm ghcr.io/acme/configs:v1.0.0
+  blob rm --force ghcr.io/acme/configs@sha256:abc...
+  blob rm --referrers --force configs:v0.9.0 configs:v0.9.1`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRm,
+}
+
+func init() {
+	rmCmd.Flags().Bool("force", false, "skip confirmation prompt")
+	rmCmd.Flags().Bool("referrers", false, "also delete signatures, attestations, and other referrers")
+}
+
+// rmFlags holds the parsed command flags.
+type rmFlags struct {
+	force     bool
+	referrers bool
+}
+
+// rmResult contains the rm output data for JSON format.
+type rmResult struct {
+	Deleted []rmEntry `json:"deleted"`
+}
+
+// rmEntry describes a deleted manifest.
+type rmEntry struct {
+	Ref       string       `json:"ref"`
+	Digest    string       `json:"digest"`
+	Referrers []rmReferrer `json:"referrers,omitempty"`
+	// ReferrersTag is the tag indexing the referrers on registries
+	// without the referrers API, deleted along with them.
+	ReferrersTag string `json:"referrers_tag,omitempty"`
+	// Untagged is set when only the tag was deleted, leaving the
+	// manifest in place under its other tags.
+	Untagged bool `json:"untagged,omitempty"`
+	// RemovedTags are the other tags that pointed to the deleted
+	// manifest, removed along with it.
+	RemovedTags []string `json:"removed_tags,omitempty"`
+}
+
+// rmReferrer describes a deleted referrer.
+type rmReferrer struct {
+	Digest       string `json:"digest"`
+	ArtifactType string `json:"artifact_type,omitempty"`
+}
+
+// rmTarget is a manifest to delete, with the referrers and referrers tag
+// index to delete before it.
+type rmTarget struct {
+	ref          string
+	tag          string // the tag ref names, or "" for a digest
+	otherTags    []string
+	repository   *remote.Repository
+	desc         ocispec.Descriptor
+	referrers    []ocispec.Descriptor
+	referrersTag *ocispec.Descriptor
+}
+
+// sharesManifest reports whether ref names a tag and other tags point
+// to the same manifest.
+func (t rmTarget) sharesManifest() bool {
+	return t.tag != "" && len(t.otherTags) > 0
+}
+
+func runRm(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	flags, err := parseRmFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	jsonOutput := cfg.Output == internalcfg.OutputJSON
+	if !flags.force {
+		if jsonOutput {
+			return errors.New("--force required when using --output json")
+		}
+		if cfg.Quiet {
+			return errors.New("--force required when using --quiet")
+		}
+	}
+
+	ctx := cmd.Context()
+	targets := make([]rmTarget, 0, len(args))
+	for _, arg := range args {
+		target, err := resolveRmTarget(ctx, cfg, cfg.ResolveAlias(arg), flags.referrers)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+
+	if !flags.force {
+		for _, target := range targets {
+			if target.sharesManifest() {
+				return fmt.Errorf("%s points to the same manifest as %s, which deleting it may remove too: use --force to delete it anyway",
+					target.ref, strings.Join(target.otherTags, ", "))
+			}
+		}
+		confirmed, err := promptRmConfirmation(targets, os.Stdin)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Fprintln(os.Stderr, "Canceled.")
+			return nil
+		}
+	}
+
+	result := rmResult{Deleted: make([]rmEntry, 0, len(targets))}
+	for _, target := range targets {
+		entry, err := deleteRmTarget(ctx, target)
+		if err != nil {
+			return err
+		}
+		result.Deleted = append(result.Deleted, entry)
+	}
+
+	if cfg.Quiet {
+		return nil
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	rmText(&result)
+	return nil
+}
+
+func parseRmFlags(cmd *cobra.Command) (rmFlags, error) {
+	var flags rmFlags
+	var err error
+
+	flags.force, err = cmd.Flags().GetBool("force")
+	if err != nil {
+		return flags, fmt.Errorf("reading force flag: %w", err)
+	}
+
+	flags.referrers, err = cmd.Flags().GetBool("referrers")
+	if err != nil {
+		return flags, fmt.Errorf("reading referrers flag: %w", err)
+	}
+
+	return flags, nil
+}
+
+// resolveRmTarget resolves ref to the manifest to delete and, if
+// withReferrers is set, collects its referrers.
+func resolveRmTarget(ctx context.Context, cfg *internalcfg.Config, ref string, withReferrers bool) (rmTarget, error) {
+	parsed, err := registry.ParseReference(ref)
+	if err != nil {
+		return rmTarget{}, fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	if parsed.Reference == "" {
+		return rmTarget{}, fmt.Errorf("invalid reference %q: a tag or digest is required", ref)
+	}
+
+	repository, err := newRemoteRepository(cfg, parsed.Registry+"/"+parsed.Repository)
+	if err != nil {
+		return rmTarget{}, err
+	}
+	desc, err := repository.Resolve(ctx, parsed.Reference)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return rmTarget{}, &ExitError{Code: exitCodeNotFound, Err: fmt.Errorf("resolving %s: %w", ref, err)}
+	}
+	if err != nil {
+		return rmTarget{}, fmt.Errorf("resolving %s: %w", ref, err)
+	}
+
+	target := rmTarget{ref: ref, repository: repository, desc: desc}
+	if _, err := parsed.Digest(); err != nil {
+		target.tag = parsed.Reference
+	}
+	tags, err := tagsOf(ctx, repository, desc)
+	if err != nil {
+		return rmTarget{}, err
+	}
+	for _, tag := range tags {
+		if tag != target.tag {
+			target.otherTags = append(target.otherTags, tag)
+		}
+	}
+
+	if withReferrers {
+		target.referrers, err = collectReferrers(ctx, repository, desc)
+		if err != nil {
+			return rmTarget{}, err
+		}
+		tagDesc, err := repository.Resolve(ctx, referrers.Tag(desc.Digest))
+		switch {
+		case err == nil:
+			target.referrersTag = &tagDesc
+		case !errors.Is(err, errdef.ErrNotFound):
+			return rmTarget{}, fmt.Errorf("resolving referrers tag of %s: %w", ref, err)
+		}
+	}
+	return target, nil
+}
+
+// tagsOf returns the tags in repository that point to desc, in the order
+// the registry lists them.
+func tagsOf(ctx context.Context, repository *remote.Repository, desc ocispec.Descriptor) ([]string, error) {
+	var all []string
+	err := repository.Tags(ctx, "", func(page []string) error {
+		for _, tag := range page {
+			if !referrers.IsTag(tag) {
+				all = append(all, tag)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing tags: %w", err)
+	}
+
+	matches := make([]bool, len(all))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(tagsInspectJobs)
+	for i, tag := range all {
+		g.Go(func() error {
+			got, err := repository.Resolve(ctx, tag)
+			if errors.Is(err, errdef.ErrNotFound) {
+				return nil // deleted since it was listed
+			}
+			if err != nil {
+				return fmt.Errorf("resolving tag %s: %w", tag, err)
+			}
+			matches[i] = got.Digest == desc.Digest
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for i, tag := range all {
+		if matches[i] {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// collectReferrers returns the referrers of subject and, recursively, their
+// referrers. Each referrer comes after its own referrers, so deleting in
+// order never leaves an artifact whose subject is gone.
+func collectReferrers(ctx context.Context, repository *remote.Repository, subject ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	direct, err := referrers.List(ctx, repository, subject, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var all []ocispec.Descriptor
+	for _, desc := range direct {
+		nested, err := collectReferrers(ctx, repository, desc)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, nested...)
+		all = append(all, desc)
+	}
+	return all, nil
+}
+
+// deleteRmTarget deletes target's referrers, then target itself. A tag
+// that shares its manifest with other tags is deleted alone if the
+// registry supports deleting tags.
+func deleteRmTarget(ctx context.Context, target rmTarget) (rmEntry, error) {
+	entry := rmEntry{Ref: target.ref, Digest: target.desc.Digest.String()}
+	if target.sharesManifest() {
+		err := deleteTag(ctx, target.repository, target.tag)
+		if err == nil {
+			entry.Untagged = true
+			return entry, nil
+		}
+		if !errors.Is(err, errTagDeleteUnsupported) {
+			return entry, err
+		}
+	}
+	for _, desc := range target.referrers {
+		// A referrer shared by several targets is already gone.
+		if err := deleteManifest(ctx, target.repository, desc); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+			return entry, err
+		}
+		entry.Referrers = append(entry.Referrers, rmReferrer{
+			Digest:       desc.Digest.String(),
+			ArtifactType: desc.ArtifactType,
+		})
+	}
+	if target.referrersTag != nil {
+		if err := deleteManifest(ctx, target.repository, *target.referrersTag); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+			return entry, err
+		}
+		entry.ReferrersTag = referrers.Tag(target.desc.Digest)
+	}
+	if err := deleteManifest(ctx, target.repository, target.desc); err != nil {
+		return entry, err
+	}
+	entry.RemovedTags = target.otherTags
+	return entry, nil
+}
+
+// errTagDeleteUnsupported reports a registry that deletes manifests only
+// by digest.
+var errTagDeleteUnsupported = errors.New("registry does not support deleting tags")
+
+// deleteTag deletes tag from repository, leaving the manifest it points
+// to. oras only deletes manifests by digest, so the request is made
+// directly, as the distribution spec allows.
+func deleteTag(ctx context.Context, repository *remote.Repository, tag string) error {
+	scheme := "https"
+	if repository.PlainHTTP {
+		scheme = "http"
+	}
+	ref := repository.Reference
+	url := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, ref.Host(), ref.Repository, tag)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := repository.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("deleting tag %s: %w", tag, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusBadRequest, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return errTagDeleteUnsupported
+	default:
+		return fmt.Errorf("deleting tag %s: %w", tag, &errcode.ErrorResponse{
+			Method:     req.Method,
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+		})
+	}
+}
+
+func deleteManifest(ctx context.Context, repository *remote.Repository, desc ocispec.Descriptor) error {
+	err := repository.Manifests().Delete(ctx, desc)
+	if err == nil {
+		return nil
+	}
+	var errResp *errcode.ErrorResponse
+	if errors.As(err, &errResp) && errResp.StatusCode == http.StatusMethodNotAllowed {
+		return fmt.Errorf("deleting %s: registry does not support deleting manifests: %w", desc.Digest, err)
+	}
+	return fmt.Errorf("deleting %s: %w", desc.Digest, err)
+}
+
+// promptRmConfirmation lists the targets and asks for confirmation.
+// Returns false (not confirmed) on EOF or non-interactive stdin.
+func promptRmConfirmation(targets []rmTarget, in io.Reader) (bool, error) {
+	for _, target := range targets {
+		line := fmt.Sprintf("  %s (%s)", target.ref, shortDigest(target.desc.Digest.String()))
+		if n := len(target.referrers); n > 0 {
+			line += " and " + pluralize(n, "referrer", "referrers")
+		}
+		if len(target.otherTags) > 0 {
+			line += ", removing tags " + strings.Join(target.otherTags, ", ")
+		}
+		fmt.Println(line)
+	}
+	fmt.Print("Delete from the registry? This cannot be undone. [y/N]: ")
+
+	response, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil {
+		// Treat EOF (non-interactive, piped stdin) as "no"
+		if errors.Is(err, io.EOF) {
+			fmt.Println() // newline since user didn't press enter
+			return false, nil
+		}
+		return false, fmt.Errorf("reading response: %w", err)
+	}
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "y" || response == "yes", nil
+}
+
+func rmText(result *rmResult) {
+	for _, entry := range result.Deleted {
+		for _, r := range entry.Referrers {
+			if r.ArtifactType != "" {
+				fmt.Printf("Deleted referrer %s (%s)\n", shortDigest(r.Digest), r.ArtifactType)
+			} else {
+				fmt.Printf("Deleted referrer %s\n", shortDigest(r.Digest))
+			}
+		}
+		if entry.ReferrersTag != "" {
+			fmt.Printf("Deleted referrers tag %s\n", entry.ReferrersTag)
+		}
+		switch {
+		case entry.Untagged:
+			fmt.Printf("Deleted tag %s (%s is still tagged)\n", entry.Ref, shortDigest(entry.Digest))
+		case len(entry.RemovedTags) > 0:
+			fmt.Printf("Deleted %s (%s), removing tags %s\n", entry.Ref, shortDigest(entry.Digest), strings.Join(entry.RemovedTags, ", "))
+		default:
+			fmt.Printf("Deleted %s (%s)\n", entry.Ref, shortDigest(entry.Digest))
+		}
+	}
+}
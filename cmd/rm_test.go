@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/referrers"
+)
+
+func runRmForTest(t *testing.T, cfg *internalcfg.Config, flags map[string]string, args ...string) error {
+	t.Helper()
+	t.Cleanup(func() {
+		rmCmd.Flags().Set("force", "false")     //nolint:errcheck // test cleanup
+		rmCmd.Flags().Set("referrers", "false") //nolint:errcheck // test cleanup
+	})
+	for name, value := range flags {
+		require.NoError(t, rmCmd.Flags().Set(name, value))
+	}
+	rmCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	return rmCmd.RunE(rmCmd, args)
+}
+
+func TestRmCmd_NilConfig(t *testing.T) {
+	rmCmd.SetContext(context.Background())
+	err := rmCmd.RunE(rmCmd, []string{"ghcr.io/test:v1"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration not loaded")
+}
+
+func TestRmCmd_RequiresForce(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	subject := reg.add(t, "v1", nil, "")
+
+	err := runRmForTest(t, newRegistryTestConfig(t), nil, reg.repo+":v1")
+	require.ErrorContains(t, err, "--force required")
+	assert.Empty(t, reg.deletedDigests())
+	assert.True(t, reg.hasManifest(subject.Digest.String()))
+}
+
+func TestRmCmd_DeletesManifest(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	subject := reg.add(t, "v1", nil, "")
+	sig := reg.add(t, "", &subject, "application/vnd.dev.sigstore.bundle.v0.3+json")
+
+	err := runRmForTest(t, newRegistryTestConfig(t), map[string]string{"force": "true"}, reg.repo+":v1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{subject.Digest.String()}, reg.deletedDigests())
+	assert.False(t, reg.hasManifest(subject.Digest.String()))
+	assert.True(t, reg.hasManifest(sig.Digest.String()), "referrers are kept without --referrers")
+
+	// The tag no longer resolves
+	err = runRmForTest(t, newRegistryTestConfig(t), map[string]string{"force": "true"}, reg.repo+":v1")
+	requireExitCode(t, err, exitCodeNotFound)
+}
+
+func TestRmCmd_SharedManifest(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	subject := reg.add(t, "v1", nil, "")
+	reg.add(t, "latest", nil, "")
+	require.Equal(t, subject.Digest.String(), reg.tag("latest"))
+
+	// Without --force, deleting v1 is refused, naming latest
+	cfg := newRegistryTestConfig(t)
+	cfg.Quiet = false
+	err := runRmForTest(t, cfg, nil, reg.repo+":v1")
+	require.ErrorContains(t, err, "latest")
+	assert.Equal(t, subject.Digest.String(), reg.tag("v1"))
+	assert.Empty(t, reg.deletedDigests())
+
+	// A registry that deletes tags deletes only v1
+	reg.tagDeletes = true
+	err = runRmForTest(t, newRegistryTestConfig(t), map[string]string{"force": "true"}, reg.repo+":v1")
+	require.NoError(t, err)
+	assert.Empty(t, reg.tag("v1"))
+	assert.Equal(t, subject.Digest.String(), reg.tag("latest"))
+	assert.True(t, reg.hasManifest(subject.Digest.String()))
+	assert.Empty(t, reg.deletedDigests())
+
+	// One that does not deletes the manifest, and latest with it
+	reg.tagDeletes = false
+	reg.add(t, "v1", nil, "")
+	err = runRmForTest(t, newRegistryTestConfig(t), map[string]string{"force": "true"}, reg.repo+":v1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{subject.Digest.String()}, reg.deletedDigests())
+	assert.Empty(t, reg.tag("latest"))
+}
+
+func TestRmCmd_Referrers(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	subject := reg.add(t, "v1", nil, "")
+	sig := reg.add(t, "", &subject, "application/vnd.dev.sigstore.bundle.v0.3+json")
+	att := reg.add(t, "", &subject, "application/vnd.in-toto+json")
+	attSig := reg.add(t, "", &att, "application/vnd.dev.sigstore.bundle.v0.3+json")
+
+	err := runRmForTest(t, newRegistryTestConfig(t), map[string]string{"force": "true", "referrers": "true"}, reg.repo+":v1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		sig.Digest.String(),
+		attSig.Digest.String(),
+		att.Digest.String(),
+		subject.Digest.String(),
+	}, reg.deletedDigests())
+	for _, desc := range []ocispec.Descriptor{subject, sig, att, attSig} {
+		assert.False(t, reg.hasManifest(desc.Digest.String()), desc.ArtifactType)
+	}
+}
+
+func TestRmCmd_ReferrersTag(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	subject := reg.add(t, "v1", nil, "")
+	sig := reg.addTagReferrer(t, subject, "application/vnd.dev.sigstore.bundle.v0.3+json")
+	index := reg.tag(referrers.Tag(subject.Digest))
+
+	err := runRmForTest(t, newRegistryTestConfig(t), map[string]string{"force": "true", "referrers": "true"}, reg.repo+":v1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{sig.Digest.String(), index, subject.Digest.String()}, reg.deletedDigests())
+	assert.False(t, reg.hasManifest(index), "the referrers tag index is deleted")
+}
+
+func TestRmCmd_MissingTag(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	reg.add(t, "v1", nil, "")
+	cfg := newRegistryTestConfig(t)
+
+	err := runRmForTest(t, cfg, map[string]string{"force": "true"}, reg.repo)
+	require.ErrorContains(t, err, "a tag or digest is required")
+
+	err = runRmForTest(t, cfg, map[string]string{"force": "true"}, reg.repo+":nope")
+	requireExitCode(t, err, exitCodeNotFound)
+	assert.Empty(t, reg.deletedDigests())
+}
+
+func TestRmText_SharedManifest(t *testing.T) {
+	out, err := captureStdout(t, func() error {
+		rmText(&rmResult{Deleted: []rmEntry{
+			{Ref: "ghcr.io/acme/configs:v1", Digest: "sha256:abc123", Untagged: true},
+			{Ref: "ghcr.io/acme/configs:v2", Digest: "sha256:def456", RemovedTags: []string{"latest", "stable"}},
+		}})
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "Deleted tag ghcr.io/acme/configs:v1")
+	assert.Contains(t, string(out), "removing tags latest, stable")
+}
+
+func TestPromptRmConfirmation(t *testing.T) {
+	targets := []rmTarget{{ref: "ghcr.io/acme/configs:v1"}}
+
+	ok, err := promptRmConfirmation(targets, strings.NewReader("y\n"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = promptRmConfirmation(targets, strings.NewReader(""))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Deleting by digest names the tags removed with the manifest
+	targets = []rmTarget{{ref: "ghcr.io/acme/configs@sha256:abc123", otherTags: []string{"v1", "latest"}}}
+	out, err := captureStdout(t, func() error {
+		_, err := promptRmConfirmation(targets, strings.NewReader("n\n"))
+		return err
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "removing tags v1, latest")
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/meigma/blob-cli/cmd/cache"
 	"github.com/meigma/blob-cli/cmd/config"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 var cfgFile string
@@ -34,17 +35,67 @@ retrieval of individual files without downloading the entire archive.`,
 			return fmt.Errorf("loading config: %w", err)
 		}
 
+		headers, err := cmd.Flags().GetStringArray("header")
+		if err != nil {
+			return fmt.Errorf("reading header flag: %w", err)
+		}
+		flagHeaders, err := parseHeaderFlags(headers)
+		if err != nil {
+			return err
+		}
+		if len(flagHeaders) > 0 && cfg.Registry.ExtraHeaders == nil {
+			cfg.Registry.ExtraHeaders = make(map[string]string, len(flagHeaders))
+		}
+		for name, value := range flagHeaders {
+			cfg.Registry.ExtraHeaders[name] = value
+		}
+		traceDest, err := cmd.Flags().GetString("trace")
+		if err != nil {
+			return fmt.Errorf("reading trace flag: %w", err)
+		}
+		transport, err := buildRegistryTransport(cfg, traceDest)
+		if err != nil {
+			return err
+		}
+		useRegistryTransport(transport)
+
+		warn.SetQuiet(cfg.Quiet)
+		if cfg.Verbose > 0 {
+			for _, note := range internalcfg.Lint(viper.GetViper(), cfg) {
+				fmt.Fprintf(os.Stderr, "Note: config: %s\n", note)
+			}
+			for _, name := range shadowedShortcuts(cmd.Root(), cfg) {
+				fmt.Fprintf(os.Stderr, "Note: config: shortcut %s is ignored because %s is a command\n", name, name)
+			}
+		}
+
+		if err := checkOutputFormat(cmd, cfg.Output); err != nil {
+			return err
+		}
+
 		// Attach config to context for use by subcommands
 		ctx := internalcfg.WithConfig(cmd.Context(), cfg)
 		cmd.SetContext(ctx)
 
 		return nil
 	},
+	PersistentPostRun: recordRecentRef,
 }
 
 func Execute() error {
+	defer flushCacheStats()
+	defer stopTrace()
+	args, err := expandShortcut(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	rootCmd.SetArgs(args)
 	ctx := context.Background()
-	return rootCmd.ExecuteContext(ctx)
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	if err != nil {
+		return err
+	}
+	return strictError(internalcfg.FromContext(cmd.Context()))
 }
 
 func init() {
@@ -52,11 +103,22 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: $XDG_CONFIG_HOME/blob/config.yaml)")
-	rootCmd.PersistentFlags().String("output", "text", "output format: text, json")
+	rootCmd.PersistentFlags().String("output", "text", "output format: text, json, csv, tsv (listings), junit (verify)")
 	rootCmd.PersistentFlags().CountP("verbose", "v", "increase verbosity (can be repeated: -vv, -vvv)")
 	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "suppress non-error output")
 	rootCmd.PersistentFlags().Bool("no-color", false, "disable colored output")
 	rootCmd.PersistentFlags().Bool("plain-http", false, "use plain HTTP instead of HTTPS for registries")
+	rootCmd.PersistentFlags().Bool("semver", false, "resolve tags such as ^1.2 or latest-stable as semver queries")
+	rootCmd.PersistentFlags().Bool("strict", false, "fail with exit code 6 if any warning is reported")
+	rootCmd.PersistentFlags().Bool("offline", false, "read only from local caches and pinned archives, never a registry")
+	rootCmd.PersistentFlags().String("user-agent", "", "User-Agent for registry requests (default: blob-cli/<version>)")
+	rootCmd.PersistentFlags().StringArray("header", nil, "add a header to registry requests (\"Name: value\", repeatable)")
+	rootCmd.PersistentFlags().String("trace", "", "log each registry HTTP request to a file (\"-\" or no value for stderr)")
+	rootCmd.PersistentFlags().Lookup("trace").NoOptDefVal = traceStderr
+	rootCmd.PersistentFlags().String("fulcio-url", "", "Fulcio instance for keyless signing (default: public Sigstore)")
+	rootCmd.PersistentFlags().String("rekor-url", "", "Rekor instance for signing and --rekor-search (default: public Sigstore)")
+	rootCmd.PersistentFlags().String("oidc-issuer", "", "OIDC issuer for interactive keyless signing login (default: public Sigstore)")
+	rootCmd.PersistentFlags().String("trust-root", "", "Sigstore trusted_root.json for keyless verification (default: public Sigstore)")
 
 	// Bind flags to Viper
 	// Note: "config" is NOT bound to Viper to avoid BLOB_CONFIG env var affecting
@@ -66,23 +128,55 @@ func init() {
 	viper.BindPFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet"))
 	viper.BindPFlag("no-color", rootCmd.PersistentFlags().Lookup("no-color"))
 	viper.BindPFlag("plain-http", rootCmd.PersistentFlags().Lookup("plain-http"))
+	viper.BindPFlag("semver", rootCmd.PersistentFlags().Lookup("semver"))
+	viper.BindPFlag("strict", rootCmd.PersistentFlags().Lookup("strict"))
+	viper.BindPFlag("offline", rootCmd.PersistentFlags().Lookup("offline"))
+	viper.BindPFlag("registry.user_agent", rootCmd.PersistentFlags().Lookup("user-agent"))
+	viper.BindPFlag("sigstore.fulcio_url", rootCmd.PersistentFlags().Lookup("fulcio-url"))
+	viper.BindPFlag("sigstore.rekor_url", rootCmd.PersistentFlags().Lookup("rekor-url"))
+	viper.BindPFlag("sigstore.oidc_issuer", rootCmd.PersistentFlags().Lookup("oidc-issuer"))
+	viper.BindPFlag("sigstore.trust_root", rootCmd.PersistentFlags().Lookup("trust-root"))
+	viper.BindPFlag("compression", pushCmd.Flags().Lookup("compression"))
 
 	// Add core commands
 	rootCmd.AddCommand(pushCmd)
 	rootCmd.AddCommand(pullCmd)
+	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(cpCmd)
 	rootCmd.AddCommand(catCmd)
 	rootCmd.AddCommand(lsCmd)
 	rootCmd.AddCommand(inspectCmd)
 	rootCmd.AddCommand(treeCmd)
+	rootCmd.AddCommand(diffCmd)
 	rootCmd.AddCommand(signCmd)
+	rootCmd.AddCommand(attestCmd)
+	rootCmd.AddCommand(attestationCmd)
 	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(verifyFileCmd)
+	rootCmd.AddCommand(policyCmd)
 	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(tagsCmd)
+	rootCmd.AddCommand(resolveCmd)
+	rootCmd.AddCommand(rmCmd)
+	rootCmd.AddCommand(promoteCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(copyCmd)
+	rootCmd.AddCommand(metaCmd)
+	rootCmd.AddCommand(storeCmd)
+	rootCmd.AddCommand(proxyCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(mountCmd)
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
 
 	// Add subcommand groups
 	rootCmd.AddCommand(cache.Cmd)
 	rootCmd.AddCommand(alias.Cmd)
 	rootCmd.AddCommand(config.Cmd)
+
+	setupCompletion()
 }
 
 func initConfig() {
@@ -100,7 +194,7 @@ func initConfig() {
 		if configHome == "" {
 			home, err := os.UserHomeDir()
 			if err != nil {
-				fmt.Fprintln(os.Stderr, "Warning: could not determine home directory:", err)
+				warn.Printf("could not determine home directory: %v", err)
 				return
 			}
 			configHome = filepath.Join(home, ".config")
@@ -118,8 +212,16 @@ func initConfig() {
 	viper.SetEnvPrefix("BLOB")
 	viper.AutomaticEnv()
 
-	// Bind cache.dir to BLOB_CACHE_DIR explicitly for nested key
-	viper.BindEnv("cache.dir", "BLOB_CACHE_DIR") //nolint:errcheck // best effort
+	// Bind cache.dir, store.dir, and registry.user_agent to their env vars explicitly for nested keys
+	viper.BindEnv("cache.dir", "BLOB_CACHE_DIR")              //nolint:errcheck // best effort
+	viper.BindEnv("store.dir", "BLOB_STORE_DIR")              //nolint:errcheck // best effort
+	viper.BindEnv("registry.user_agent", "BLOB_USER_AGENT")   //nolint:errcheck // best effort
+	viper.BindEnv("sigstore.fulcio_url", "BLOB_FULCIO_URL")   //nolint:errcheck // best effort
+	viper.BindEnv("sigstore.rekor_url", "BLOB_REKOR_URL")     //nolint:errcheck // best effort
+	viper.BindEnv("sigstore.oidc_issuer", "BLOB_OIDC_ISSUER") //nolint:errcheck // best effort
+	viper.BindEnv("sigstore.trust_root", "BLOB_TRUST_ROOT")   //nolint:errcheck // best effort
+	// default_ref is not set by default, so AutomaticEnv alone would not reach Unmarshal
+	viper.BindEnv("default_ref", internalcfg.EnvDefaultRef) //nolint:errcheck // best effort
 
 	// Config file is optional - don't fail if missing
 	viper.ReadInConfig() //nolint:errcheck // config file is optional
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/schema"
+)
+
+// newSchemaValidator builds a validator from the schema rules in config.
+// Relative schema paths are resolved against the config file's directory.
+func newSchemaValidator(cfg *internalcfg.Config) (*schema.Validator, error) {
+	if len(cfg.Schemas) == 0 {
+		return nil, errors.New("--validate requires at least one entry in the config 'schemas' list")
+	}
+
+	baseDir := "."
+	if path, err := cfg.FilePath(); err == nil {
+		baseDir = filepath.Dir(path)
+	}
+
+	v, err := schema.New(cfg.Schemas, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("loading schemas: %w", err)
+	}
+	return v, nil
+}
+
+// validateContent validates all matching files in fsys against the configured schemas.
+// Each failing file is reported on stderr; a single summary error is returned.
+func validateContent(cfg *internalcfg.Config, fsys fs.FS) error {
+	v, err := newSchemaValidator(cfg)
+	if err != nil {
+		return err
+	}
+
+	failures, err := v.ValidateFS(fsys)
+	if err != nil {
+		return fmt.Errorf("validating content: %w", err)
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	for _, f := range failures {
+		fmt.Fprintf(os.Stderr, "  %s (%s): %v\n", f.Path, f.Schema, f.Err)
+	}
+	return fmt.Errorf("%w: %s invalid", schema.ErrValidationFailed, pluralize(len(failures), "file", "files"))
+}
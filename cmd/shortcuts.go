@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/shortcut"
+)
+
+// expandShortcut returns args with a leading shortcut from the config file
+// replaced by its command line. The config is read before the command line
+// is parsed, so of the flags only a --config given in args is honored.
+func expandShortcut(args []string) ([]string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || isCommand(rootCmd, args[0]) {
+		return args, nil
+	}
+	if path := configFlag(args); path != "" {
+		cfgFile = path
+	}
+	initConfig()
+	cfg, err := internalcfg.LoadFromViper()
+	if err != nil {
+		return nil, err
+	}
+	expanded, _, err := shortcut.Expand(cfg.Shortcuts, args, func(name string) bool {
+		return isCommand(rootCmd, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expanded, nil
+}
+
+// isCommand reports whether name is a built-in command of root, which a
+// shortcut cannot replace.
+func isCommand(root *cobra.Command, name string) bool {
+	switch name {
+	case "help", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	cmd, _, err := root.Find([]string{name})
+	return err == nil && cmd != root
+}
+
+// shadowedShortcuts returns the shortcuts that are never expanded because
+// they are named after a built-in command of root, sorted.
+func shadowedShortcuts(root *cobra.Command, cfg *internalcfg.Config) []string {
+	var names []string
+	for name := range cfg.Shortcuts {
+		if isCommand(root, name) {
+			names = append(names, name)
+		}
+	}
+	slices.SortFunc(names, cmp.Compare)
+	return names
+}
+
+// configFlag returns the value of a --config flag in args, or "".
+func configFlag(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--config="); ok {
+			return value
+		}
+		if arg == "--config" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// completeShortcut completes the names of shortcuts in place of a command.
+func completeShortcut(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, err := completionConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for name, line := range cfg.Shortcuts {
+		if strings.HasPrefix(name, toComplete) && !isCommand(cmd.Root(), name) {
+			names = append(names, name+"\t"+line)
+		}
+	}
+	slices.SortFunc(names, cmp.Compare)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
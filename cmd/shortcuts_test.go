@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestExpandShortcut(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	saved := cfgFile
+	t.Cleanup(func() { cfgFile = saved })
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`shortcuts:
+  deploy-prod: pull prod-cfg:stable '/etc/my app' --verify-checksums
+  ls: pull x
+`), 0o600))
+
+	args, err := expandShortcut([]string{"deploy-prod", "--config", path, "--dry-run"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pull", "prod-cfg:stable", "/etc/my app", "--verify-checksums", "--config", path, "--dry-run"}, args)
+
+	// Built-in commands and flags are never expanded
+	for _, in := range [][]string{{"ls", "--config=" + path}, {"--config", path, "deploy-prod"}, {"help"}, nil} {
+		args, err = expandShortcut(in)
+		require.NoError(t, err)
+		assert.Equal(t, in, args)
+	}
+
+	assert.Equal(t, []string{"ls"}, shadowedShortcuts(rootCmd, &internalcfg.Config{
+		Shortcuts: map[string]string{"ls": "pull x", "deploy-prod": "pull y"},
+	}))
+
+	require.NoError(t, os.WriteFile(path, []byte("shortcuts:\n  bad: \"ls 'x\"\n"), 0o600))
+	_, err = expandShortcut([]string{"bad", "--config=" + path})
+	require.ErrorIs(t, err, internalcfg.ErrInvalidConfig)
+}
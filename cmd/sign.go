@@ -5,15 +5,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 
+	"github.com/meigma/blob"
 	"github.com/meigma/blob/policy/sigstore"
 	"github.com/meigma/blob/registry/oras"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/identity"
+	"github.com/meigma/blob-cli/internal/pkcs11key"
 )
 
 var signCmd = &cobra.Command{
@@ -23,17 +27,50 @@ var signCmd = &cobra.Command{
 
 Signs the specified archive reference using Sigstore. By default,
 uses keyless signing which authenticates via OIDC. A private key
-can be specified for key-based signing instead.`,
+can be specified for key-based signing instead.
+
+--key also accepts a cloud KMS key URI, so the private key never leaves
+the KMS: awskms:///alias/name (or a key ARN), gcpkms://projects/P/
+locations/L/keyRings/R/cryptoKeys/K/cryptoKeyVersions/V, or
+azurekms://vault.vault.azure.net/key. Credentials come from each
+cloud's usual environment, such as AWS_PROFILE,
+GOOGLE_APPLICATION_CREDENTIALS, or AZURE_CLIENT_ID.
+
+A PKCS#11 URI as --key signs with a key on a hardware token, such as an
+HSM, smart card, or YubiKey PIV slot, loading the token's module from
+module-path in the URI or BLOB_PKCS11_MODULE. The PIN comes from
+pin-value, BLOB_PKCS11_PIN, or a prompt on the terminal. PKCS#11 signing
+needs a blob built with cgo.
+
+Keyless signing needs an OIDC identity token. It is read from
+--identity-token-file, the SIGSTORE_ID_TOKEN environment variable, or the
+ambient credentials of GitHub Actions, GitLab CI, or Buildkite (detected
+automatically, or chosen with --identity-provider). Outside CI, on a
+terminal, you log in instead: a browser is opened at the Sigstore OIDC
+issuer, and its URL printed. --oidc-provider picks the account to log in
+with (github, google, or microsoft), and --identity-provider device logs
+in with a code entered on another device, for machines without a browser.
+
+Signing uses the public Sigstore Fulcio and Rekor services unless
+--fulcio-url and --rekor-url, or sigstore.fulcio_url and
+sigstore.rekor_url in the config, name a private deployment.`,
 	Example: `  blob sign ghcr.io/acme/configs:v1.0.0
   blob sign --key cosign.key ghcr.io/acme/configs:v1.0.0
-  blob sign --output-signature ghcr.io/acme/configs:v1.0.0 > sig.json`,
+  blob sign --key awskms:///alias/blob-signing ghcr.io/acme/configs:v1.0.0
+  blob sign --key 'pkcs11:token=YubiKey%20PIV%20%2312345678;id=%02?module-path=/usr/lib/libykcs11.so' ghcr.io/acme/configs:v1.0.0
+  blob sign --output-signature ghcr.io/acme/configs:v1.0.0 > sig.json
+  blob sign --identity-token-file /var/run/oidc/token ghcr.io/acme/configs:v1.0.0
+  blob sign --identity-provider buildkite ghcr.io/acme/configs:v1.0.0
+  blob sign --oidc-provider github ghcr.io/acme/configs:v1.0.0
+  blob sign --identity-provider device ghcr.io/acme/configs:v1.0.0`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSign,
 }
 
 func init() {
-	signCmd.Flags().String("key", "", "sign with a private key instead of keyless")
+	signCmd.Flags().String("key", "", "sign with a private key file, KMS key URI, or PKCS#11 URI instead of keyless")
 	signCmd.Flags().Bool("output-signature", false, "print signature to stdout instead of uploading")
+	addIdentityFlags(signCmd)
 }
 
 // signResult contains the result of a sign operation.
@@ -48,6 +85,7 @@ type signResult struct {
 type signFlags struct {
 	keyPath         string
 	outputSignature bool
+	identity        identity.Options
 }
 
 func runSign(cmd *cobra.Command, args []string) error {
@@ -67,16 +105,19 @@ func runSign(cmd *cobra.Command, args []string) error {
 	}
 
 	// 4. Resolve alias
-	resolvedRef := cfg.ResolveAlias(inputRef)
+	ctx := cmd.Context()
+	resolvedRef, err := resolveRef(ctx, cfg, inputRef)
+	if err != nil {
+		return err
+	}
 
 	// 5. Build signer
-	signer, err := buildSigner(flags)
+	signer, err := buildSigner(ctx, flags)
 	if err != nil {
 		return fmt.Errorf("creating signer: %w", err)
 	}
 
 	// 6. Handle two output modes
-	ctx := cmd.Context()
 	var result signResult
 	result.Ref = inputRef
 	if inputRef != resolvedRef {
@@ -85,7 +126,7 @@ func runSign(cmd *cobra.Command, args []string) error {
 
 	if flags.outputSignature {
 		// Output mode: sign and print to stdout
-		return signToStdout(ctx, resolvedRef, signer)
+		return signToStdout(ctx, resolvedRef, signer, userAgent(cfg))
 	}
 
 	// Normal mode: sign and upload
@@ -114,47 +155,111 @@ func parseSignFlags(cmd *cobra.Command) (signFlags, error) {
 	if err != nil {
 		return flags, fmt.Errorf("reading key flag: %w", err)
 	}
+	if pkcs11key.IsURI(flags.keyPath) && !pkcs11key.Supported {
+		return flags, fmt.Errorf("--key %s: %w", pkcs11key.Scheme, pkcs11key.ErrUnsupported)
+	}
 
 	flags.outputSignature, err = cmd.Flags().GetBool("output-signature")
 	if err != nil {
 		return flags, fmt.Errorf("reading output-signature flag: %w", err)
 	}
 
+	flags.identity, err = parseIdentityFlags(cmd)
+	if err != nil {
+		return flags, err
+	}
+
 	return flags, nil
 }
 
+// addIdentityFlags registers the flags selecting the keyless signing identity.
+func addIdentityFlags(cmd *cobra.Command) {
+	cmd.Flags().String("identity-provider", identity.ProviderAuto,
+		"OIDC identity provider for keyless signing ("+strings.Join(identity.Providers, ", ")+")")
+	cmd.Flags().String("identity-token-file", "", "read the OIDC identity token for keyless signing from a file")
+	cmd.Flags().String("oidc-provider", "",
+		"account to log in with for browser login ("+strings.Join(slices.Sorted(maps.Keys(identity.OIDCProviders)), ", ")+")")
+}
+
+// parseIdentityFlags reads and validates the identity flags.
+func parseIdentityFlags(cmd *cobra.Command) (identity.Options, error) {
+	var opts identity.Options
+	var err error
+
+	opts.Provider, err = cmd.Flags().GetString("identity-provider")
+	if err != nil {
+		return opts, fmt.Errorf("reading identity-provider flag: %w", err)
+	}
+	if err := identity.ValidateProvider(opts.Provider); err != nil {
+		return opts, err
+	}
+
+	opts.TokenFile, err = cmd.Flags().GetString("identity-token-file")
+	if err != nil {
+		return opts, fmt.Errorf("reading identity-token-file flag: %w", err)
+	}
+
+	opts.OIDCProvider, err = cmd.Flags().GetString("oidc-provider")
+	if err != nil {
+		return opts, fmt.Errorf("reading oidc-provider flag: %w", err)
+	}
+
+	return opts, nil
+}
+
 // buildSigner creates a signer based on the flags.
-func buildSigner(flags signFlags) (*sigstore.Signer, error) {
+// For keyless signing the identity token is obtained up front so a missing
+// identity is reported before anything is sent to Fulcio. A KMS key URI
+// as the key signs with the KMS key, and a PKCS#11 URI with the key on a
+// hardware token.
+func buildSigner(ctx context.Context, flags signFlags) (blob.ManifestSigner, error) {
+	if isKMSKey(flags.keyPath) {
+		return newKMSSigner(ctx, flags.keyPath)
+	}
+	if pkcs11key.IsURI(flags.keyPath) {
+		return newPKCS11Signer(ctx, flags.keyPath)
+	}
+	fulcioURL, rekorURL := sigstoreURLs(ctx)
 	if flags.keyPath != "" {
 		// Key-based signing
-		pemData, err := os.ReadFile(flags.keyPath)
+		pemData, password, err := readSigningKey(flags.keyPath)
 		if err != nil {
-			return nil, fmt.Errorf("reading key file: %w", err)
-		}
-
-		// Password from BLOB_KEY_PASSWORD env var (optional, for encrypted keys)
-		var password []byte
-		if pwd := os.Getenv("BLOB_KEY_PASSWORD"); pwd != "" {
-			password = []byte(pwd)
+			return nil, err
 		}
-
 		return sigstore.NewSigner(
 			sigstore.WithPrivateKeyPEM(pemData, password),
-			sigstore.WithRekor("https://rekor.sigstore.dev"),
+			sigstore.WithRekor(rekorURL),
 		)
 	}
 
 	// Keyless signing (default)
+	token, err := identity.Token(ctx, identityOptions(ctx, flags.identity))
+	if err != nil {
+		return nil, err
+	}
 	return sigstore.NewSigner(
 		sigstore.WithEphemeralKey(),
-		sigstore.WithFulcio("https://fulcio.sigstore.dev"),
-		sigstore.WithRekor("https://rekor.sigstore.dev"),
-		sigstore.WithAmbientCredentials(),
+		sigstore.WithFulcio(fulcioURL),
+		sigstore.WithRekor(rekorURL),
+		sigstore.WithIDToken(token),
 	)
 }
 
+// readSigningKey reads a PEM private key and its password, taken from
+// BLOB_KEY_PASSWORD for encrypted keys.
+func readSigningKey(path string) (pemData, password []byte, err error) {
+	pemData, err = os.ReadFile(path) //nolint:gosec // key path is user-provided
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading key file: %w", err)
+	}
+	if pwd := os.Getenv("BLOB_KEY_PASSWORD"); pwd != "" {
+		password = []byte(pwd)
+	}
+	return pemData, password, nil
+}
+
 // signToStdout fetches the manifest and signs it, writing the signature bundle to stdout.
-func signToStdout(ctx context.Context, ref string, signer *sigstore.Signer) error {
+func signToStdout(ctx context.Context, ref string, signer blob.ManifestSigner, ua string) error {
 	// Extract and validate the reference portion (tag or digest)
 	reference := extractReference(ref)
 	if reference == "" {
@@ -162,7 +267,7 @@ func signToStdout(ctx context.Context, ref string, signer *sigstore.Signer) erro
 	}
 
 	// Create OCI client to fetch raw manifest bytes
-	ociClient := oras.New(oras.WithDockerConfig())
+	ociClient := oras.New(oras.WithDockerConfig(), oras.WithUserAgent(ua))
 
 	// Resolve the reference to get the descriptor
 	desc, err := ociClient.Resolve(ctx, ref, reference)
@@ -177,13 +282,13 @@ func signToStdout(ctx context.Context, ref string, signer *sigstore.Signer) erro
 	}
 
 	// Sign the raw manifest bytes
-	sig, err := signer.Sign(ctx, rawManifest)
+	sig, _, err := signer.SignManifest(ctx, rawManifest)
 	if err != nil {
 		return fmt.Errorf("signing manifest: %w", err)
 	}
 
 	// Write signature bundle to stdout
-	_, err = os.Stdout.Write(sig.Data)
+	_, err = os.Stdout.Write(sig)
 	if err != nil {
 		return fmt.Errorf("writing signature: %w", err)
 	}
@@ -210,7 +315,7 @@ func outputSignResult(cfg *internalcfg.Config, result *signResult) error {
 	if cfg.Quiet {
 		return nil
 	}
-	if viper.GetString("output") == internalcfg.OutputJSON {
+	if cfg.Output == internalcfg.OutputJSON {
 		return signJSON(result)
 	}
 	return signText(result)
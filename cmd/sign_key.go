@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/meigma/blob/policy/sigstore"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// signDigestFunc signs a digest made with hf using a key held elsewhere.
+type signDigestFunc func(ctx context.Context, digest []byte, hf crypto.Hash) ([]byte, error)
+
+// externalSigner signs manifests with a key that never leaves where it is
+// held, such as a cloud KMS or a hardware token, recording each signature
+// in Rekor as signing with a key file does.
+type externalSigner struct {
+	keypair *externalKeypair
+	opts    sign.BundleOptions
+}
+
+// newExternalSigner returns a signer for the key with public key pub,
+// whose signatures are made by signDigest and recorded in the Rekor
+// instance configured in ctx.
+func newExternalSigner(ctx context.Context, pub crypto.PublicKey, signDigest signDigestFunc) (*externalSigner, error) {
+	algo, err := keyAlgorithm(pub)
+	if err != nil {
+		return nil, err
+	}
+	details, err := signature.GetAlgorithmDetails(algo)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	hint := sha256.Sum256(der)
+	_, rekorURL := sigstoreURLs(ctx)
+	return &externalSigner{
+		keypair: &externalKeypair{
+			pub:        pub,
+			details:    details,
+			hint:       []byte(base64.StdEncoding.EncodeToString(hint[:])),
+			signDigest: signDigest,
+		},
+		opts: sign.BundleOptions{
+			TransparencyLogs: []sign.Transparency{sign.NewRekor(&sign.RekorOptions{BaseURL: rekorURL})},
+		},
+	}, nil
+}
+
+// SignManifest implements blob.ManifestSigner.
+func (s *externalSigner) SignManifest(ctx context.Context, payload []byte) (data []byte, mediaType string, err error) {
+	opts := s.opts
+	opts.Context = ctx
+	bundle, err := sign.Bundle(&sign.PlainData{Data: payload}, s.keypair, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("sigstore sign: %w", err)
+	}
+	data, err = protojson.Marshal(bundle)
+	if err != nil {
+		return nil, "", fmt.Errorf("sigstore marshal bundle: %w", err)
+	}
+	return data, sigstore.SignatureArtifactType, nil
+}
+
+// keyAlgorithm returns the signing algorithm for a public key.
+func keyAlgorithm(pub crypto.PublicKey) (protocommon.PublicKeyDetails, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256, nil
+		case elliptic.P384():
+			return protocommon.PublicKeyDetails_PKIX_ECDSA_P384_SHA_384, nil
+		case elliptic.P521():
+			return protocommon.PublicKeyDetails_PKIX_ECDSA_P521_SHA_512, nil
+		}
+		return 0, fmt.Errorf("unsupported ECDSA curve %s", k.Curve.Params().Name)
+	case *rsa.PublicKey:
+		switch bits := k.N.BitLen(); {
+		case bits >= 4096:
+			return protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_4096_SHA256, nil
+		case bits >= 3072:
+			return protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_3072_SHA256, nil
+		case bits >= 2048:
+			return protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_2048_SHA256, nil
+		default:
+			return 0, fmt.Errorf("RSA key size %d bits is too small (minimum 2048)", bits)
+		}
+	}
+	return 0, fmt.Errorf("unsupported key type %T", pub)
+}
+
+// externalKeypair is a sign.Keypair whose signatures are made by
+// signDigest.
+type externalKeypair struct {
+	pub        crypto.PublicKey
+	details    signature.AlgorithmDetails
+	hint       []byte
+	signDigest signDigestFunc
+}
+
+func (k *externalKeypair) GetHashAlgorithm() protocommon.HashAlgorithm {
+	return k.details.GetProtoHashType()
+}
+
+func (k *externalKeypair) GetSigningAlgorithm() protocommon.PublicKeyDetails {
+	return k.details.GetSignatureAlgorithm()
+}
+
+func (k *externalKeypair) GetHint() []byte {
+	return k.hint
+}
+
+func (k *externalKeypair) GetKeyAlgorithm() string {
+	switch k.details.GetKeyType() {
+	case signature.ECDSA:
+		return "ECDSA"
+	case signature.RSA:
+		return "RSA"
+	default:
+		return ""
+	}
+}
+
+func (k *externalKeypair) GetPublicKey() crypto.PublicKey {
+	return k.pub
+}
+
+func (k *externalKeypair) GetPublicKeyPem() (string, error) {
+	pem, err := cryptoutils.MarshalPublicKeyToPEM(k.pub)
+	if err != nil {
+		return "", err
+	}
+	return string(pem), nil
+}
+
+// SignData hashes data and has the key sign the digest, returning the
+// signature and the digest.
+func (k *externalKeypair) SignData(ctx context.Context, data []byte) (sig, digest []byte, err error) {
+	hf := k.details.GetHashType()
+	hasher := hf.New()
+	hasher.Write(data)
+	digest = hasher.Sum(nil)
+	sig, err = k.signDigest(ctx, digest, hf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sig, digest, nil
+}
+
+// Ensure externalKeypair implements sign.Keypair.
+var _ sign.Keypair = (*externalKeypair)(nil)
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"fmt"
+	"strings"
+
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+
+	// Register the KMS providers accepted by --key
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/aws"
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/azure"
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/gcp"
+)
+
+// isKMSKey reports whether a --key value is a KMS key URI, such as
+// awskms:///alias/blob, rather than a key file.
+func isKMSKey(key string) bool {
+	for _, prefix := range kms.SupportedProviders() {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// newKMSSigner returns a signer for the KMS key at uri, which makes the
+// signatures itself. The key's public half is fetched up front, so a
+// missing key or denied access fails before anything is signed.
+func newKMSSigner(ctx context.Context, uri string) (*externalSigner, error) {
+	sv, err := kms.Get(ctx, uri, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("opening KMS key %s: %w", uri, err)
+	}
+	pub, err := sv.PublicKey(options.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("reading KMS key %s: %w", uri, err)
+	}
+	signer, err := newExternalSigner(ctx, pub, func(ctx context.Context, digest []byte, hf crypto.Hash) ([]byte, error) {
+		sig, err := sv.SignMessage(bytes.NewReader(nil),
+			options.WithContext(ctx),
+			options.WithDigest(digest),
+			options.WithCryptoSignerOpts(hf),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("KMS sign: %w", err)
+		}
+		return sig, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("KMS key %s: %w", uri, err)
+	}
+	return signer, nil
+}
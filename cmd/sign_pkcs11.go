@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+
+	"github.com/meigma/blob-cli/internal/pkcs11key"
+)
+
+// newPKCS11Signer returns a signer for the key on a hardware token named
+// by a PKCS#11 URI. The token stays logged in until blob exits. Builds
+// that cannot open the token fail before the PIN is asked for.
+func newPKCS11Signer(ctx context.Context, uri string) (*externalSigner, error) {
+	if !pkcs11key.Supported {
+		return nil, pkcs11key.ErrUnsupported
+	}
+	u, err := pkcs11key.ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	pin, err := pkcs11PIN(u)
+	if err != nil {
+		return nil, err
+	}
+	key, err := pkcs11key.Open(u, pin)
+	if err != nil {
+		return nil, err
+	}
+	signer := key.Signer()
+	ext, err := newExternalSigner(ctx, signer.Public(), func(_ context.Context, digest []byte, hf crypto.Hash) ([]byte, error) {
+		sig, err := signer.Sign(rand.Reader, digest, hf)
+		if err != nil {
+			return nil, fmt.Errorf("PKCS#11 sign: %w", err)
+		}
+		return sig, nil
+	})
+	if err != nil {
+		key.Close()
+		return nil, fmt.Errorf("PKCS#11 key: %w", err)
+	}
+	return ext, nil
+}
+
+// pkcs11PIN returns the token PIN: from the URI, then BLOB_PKCS11_PIN,
+// and otherwise prompted for on the terminal.
+func pkcs11PIN(u *pkcs11key.URI) (string, error) {
+	if u.PIN != "" {
+		return u.PIN, nil
+	}
+	if pin := os.Getenv("BLOB_PKCS11_PIN"); pin != "" {
+		return pin, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) { //nolint:gosec // file descriptors fit in an int
+		return "", errors.New("no token PIN given: set BLOB_PKCS11_PIN or pin-value when not running in a terminal")
+	}
+	label := u.Token
+	if label == "" {
+		label = "token"
+	}
+	fmt.Fprintf(os.Stderr, "PIN for %s: ", label)
+	data, err := term.ReadPassword(int(os.Stdin.Fd())) //nolint:gosec // file descriptors fit in an int
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("reading PIN: %w", err)
+	}
+	if len(data) == 0 {
+		return "", errors.New("PIN is required")
+	}
+	return string(data), nil
+}
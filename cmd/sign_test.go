@@ -3,13 +3,22 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
 	"encoding/json"
 	"os"
 	"testing"
 
 	"github.com/meigma/blob/policy/sigstore"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/sigstore/sigstore/pkg/signature/kms/fake"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/term"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/identity"
+	"github.com/meigma/blob-cli/internal/pkcs11key"
 )
 
 func TestExtractReference(t *testing.T) {
@@ -114,7 +123,7 @@ func TestSignToStdout_InvalidReference(t *testing.T) {
 	signer, err := sigstore.NewSigner(sigstore.WithEphemeralKey())
 	require.NoError(t, err)
 
-	err = signToStdout(ctx, "ghcr.io/acme/configs", signer)
+	err = signToStdout(ctx, "ghcr.io/acme/configs", signer, "blob-cli/test")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid reference")
 	assert.Contains(t, err.Error(), "must include a tag or digest")
@@ -172,3 +181,118 @@ func TestSignJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSignFlags_Identity(t *testing.T) {
+	t.Run("token file", func(t *testing.T) {
+		require.NoError(t, signCmd.Flags().Set("identity-token-file", "/var/run/oidc/token"))
+		t.Cleanup(func() { _ = signCmd.Flags().Set("identity-token-file", "") })
+
+		flags, err := parseSignFlags(signCmd)
+		require.NoError(t, err)
+		assert.Equal(t, "/var/run/oidc/token", flags.identity.TokenFile)
+		assert.Equal(t, "auto", flags.identity.Provider)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		require.NoError(t, signCmd.Flags().Set("identity-provider", "circleci"))
+		t.Cleanup(func() { _ = signCmd.Flags().Set("identity-provider", "auto") })
+
+		_, err := parseSignFlags(signCmd)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown identity provider")
+	})
+}
+
+func TestParseSignFlags_PKCS11(t *testing.T) {
+	require.NoError(t, signCmd.Flags().Set("key", "pkcs11:token=YubiKey;id=%02?module-path=/usr/lib/libykcs11.so"))
+	t.Cleanup(func() { _ = signCmd.Flags().Set("key", "") })
+
+	// Builds without cgo fail here, before a PIN is asked for
+	_, err := parseSignFlags(signCmd)
+	if pkcs11key.Supported {
+		require.NoError(t, err)
+	} else {
+		require.ErrorIs(t, err, pkcs11key.ErrUnsupported)
+	}
+}
+
+func TestIsKMSKey(t *testing.T) {
+	assert.True(t, isKMSKey("awskms:///alias/blob-signing"))
+	assert.True(t, isKMSKey("gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"))
+	assert.True(t, isKMSKey("azurekms://vault.vault.azure.net/key"))
+	assert.False(t, isKMSKey("cosign.key"))
+	assert.False(t, isKMSKey(""))
+}
+
+func TestKMSSigner(t *testing.T) {
+	signer, err := newKMSSigner(t.Context(), fake.ReferenceScheme+"key")
+	require.NoError(t, err)
+	kp := signer.keypair
+	assert.Equal(t, protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256, kp.GetSigningAlgorithm())
+	assert.Equal(t, protocommon.HashAlgorithm_SHA2_256, kp.GetHashAlgorithm())
+	assert.Equal(t, "ECDSA", kp.GetKeyAlgorithm())
+	assert.NotEmpty(t, kp.GetHint())
+
+	payload := []byte(`{"schemaVersion":2}`)
+	sig, digest, err := kp.SignData(t.Context(), payload)
+	require.NoError(t, err)
+	want := sha256.Sum256(payload)
+	assert.Equal(t, want[:], digest)
+	pub, ok := kp.GetPublicKey().(*ecdsa.PublicKey)
+	require.True(t, ok)
+	assert.True(t, ecdsa.VerifyASN1(pub, digest, sig), "signature made by the KMS key verifies")
+
+	_, err = newKMSSigner(t.Context(), "awskms:///")
+	require.Error(t, err)
+}
+
+func TestPKCS11PIN(t *testing.T) {
+	t.Setenv("BLOB_PKCS11_PIN", "")
+
+	pin, err := pkcs11PIN(&pkcs11key.URI{PIN: "from-uri"})
+	require.NoError(t, err)
+	assert.Equal(t, "from-uri", pin)
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) { //nolint:gosec // file descriptors fit in an int
+		_, err = pkcs11PIN(&pkcs11key.URI{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "BLOB_PKCS11_PIN")
+	}
+
+	t.Setenv("BLOB_PKCS11_PIN", "from-env")
+	pin, err = pkcs11PIN(&pkcs11key.URI{})
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", pin)
+}
+
+func TestSigstoreURLs(t *testing.T) {
+	fulcio, rekor := sigstoreURLs(t.Context())
+	assert.Equal(t, defaultFulcioURL, fulcio)
+	assert.Equal(t, defaultRekorURL, rekor)
+
+	cfg := &internalcfg.Config{Sigstore: internalcfg.SigstoreConfig{
+		FulcioURL: "https://fulcio.sigstore.internal",
+		RekorURL:  "https://rekor.sigstore.internal",
+	}}
+	fulcio, rekor = sigstoreURLs(internalcfg.WithConfig(t.Context(), cfg))
+	assert.Equal(t, "https://fulcio.sigstore.internal", fulcio)
+	assert.Equal(t, "https://rekor.sigstore.internal", rekor)
+
+	cfg.Sigstore.FulcioURL = ""
+	fulcio, _ = sigstoreURLs(internalcfg.WithConfig(t.Context(), cfg))
+	assert.Equal(t, defaultFulcioURL, fulcio)
+}
+
+func TestIdentityOptions(t *testing.T) {
+	cfg := &internalcfg.Config{Sigstore: internalcfg.SigstoreConfig{
+		OIDCIssuer:   "https://oauth2.sigstore.internal/auth",
+		OIDCClientID: "blob",
+	}}
+	opts := identityOptions(internalcfg.WithConfig(t.Context(), cfg), identity.Options{OIDCProvider: "github"})
+	assert.Equal(t, "https://oauth2.sigstore.internal/auth", opts.Issuer)
+	assert.Equal(t, "blob", opts.ClientID)
+	assert.Equal(t, "github", opts.OIDCProvider)
+
+	opts = identityOptions(t.Context(), identity.Options{})
+	assert.Empty(t, opts.Issuer)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/identity"
+	"github.com/meigma/blob-cli/internal/rekor"
+)
+
+// Public Sigstore services, used for signing unless the config names
+// others.
+const (
+	defaultFulcioURL = "https://fulcio.sigstore.dev"
+	defaultRekorURL  = rekor.DefaultURL
+)
+
+// sigstoreURLs returns the Fulcio and Rekor URLs to sign with: those set
+// by sigstore.fulcio_url and sigstore.rekor_url in the config in ctx, or
+// the public Sigstore services.
+func sigstoreURLs(ctx context.Context) (fulcioURL, rekorURL string) {
+	fulcioURL, rekorURL = defaultFulcioURL, defaultRekorURL
+	if cfg := internalcfg.FromContext(ctx); cfg != nil {
+		if cfg.Sigstore.FulcioURL != "" {
+			fulcioURL = cfg.Sigstore.FulcioURL
+		}
+		if cfg.Sigstore.RekorURL != "" {
+			rekorURL = cfg.Sigstore.RekorURL
+		}
+	}
+	return fulcioURL, rekorURL
+}
+
+// identityOptions completes opts for an interactive login: outside CI,
+// keyless signing logs in with the OIDC issuer in the config in ctx when
+// run from a terminal.
+func identityOptions(ctx context.Context, opts identity.Options) identity.Options {
+	if cfg := internalcfg.FromContext(ctx); cfg != nil {
+		opts.Issuer = cfg.Sigstore.OIDCIssuer
+		opts.ClientID = cfg.Sigstore.OIDCClientID
+	}
+	opts.Interactive = stdinIsTerminal() && stderrIsTerminal()
+	return opts
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/meigma/blob"
+	"github.com/spf13/cobra"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/pullstate"
+)
+
+// Remote statuses reported by blob status.
+const (
+	remoteCurrent     = "current"
+	remoteMoved       = "moved"
+	remotePinned      = "pinned"
+	remoteUnreachable = "unreachable"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [dir]",
+	Short: "Show where a pulled directory came from and whether it changed",
+	Long: `Show where a pulled directory came from and whether it changed.
+
+blob pull records the reference, the manifest digest it resolved to, and
+the digest of every extracted file in a .blob-state file in the
+destination. This command reads it and reports:
+
+  - the reference and digest the directory was pulled from, and when
+  - whether the tag now points to a different digest in the registry
+    (a directory pulled by digest is pinned and never moves)
+  - which of the extracted files were modified or removed since
+
+Files added to the directory after the pull are not reported. The
+directory defaults to the current directory.`,
+	Example: `  blob status ./configs
+  blob status --output json ./configs`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runStatus,
+}
+
+// statusRemote describes the reference as it is in the registry now.
+type statusRemote struct {
+	Status string `json:"status"`
+	Digest string `json:"digest,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// statusChange is an extracted file that no longer matches the archive.
+type statusChange struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+// statusResult contains the status output data.
+type statusResult struct {
+	Dir         string         `json:"dir"`
+	Ref         string         `json:"ref"`
+	ResolvedRef string         `json:"resolved_ref"`
+	Digest      string         `json:"digest"`
+	PulledAt    time.Time      `json:"pulled_at"`
+	Remote      statusRemote   `json:"remote"`
+	Files       int            `json:"files"`
+	Modified    int            `json:"modified"`
+	Missing     int            `json:"missing"`
+	Changes     []statusChange `json:"changes,omitempty"`
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolving path: %w", err)
+	}
+
+	state, err := pullstate.Read(dir)
+	if errors.Is(err, pullstate.ErrNoState) {
+		return fmt.Errorf("%s was not pulled by blob: %w", dir, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	result := &statusResult{
+		Dir:         dir,
+		Ref:         state.Ref,
+		ResolvedRef: state.ResolvedRef,
+		Digest:      state.Digest,
+		PulledAt:    state.PulledAt,
+		Remote:      remoteStatus(cmd.Context(), cfg, state),
+	}
+	addFileChanges(result, dir, state.Files)
+
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	return statusText(result)
+}
+
+// remoteStatus resolves the reference state was pulled from and compares
+// it with the digest that was extracted.
+func remoteStatus(ctx context.Context, cfg *internalcfg.Config, state *pullstate.State) statusRemote {
+	if strings.Contains(state.ResolvedRef, "@") {
+		return statusRemote{Status: remotePinned}
+	}
+	dgst, err := resolveDigest(ctx, cfg, state.ResolvedRef)
+	if err != nil {
+		return statusRemote{Status: remoteUnreachable, Error: err.Error()}
+	}
+	if dgst != state.Digest {
+		return statusRemote{Status: remoteMoved, Digest: dgst}
+	}
+	return statusRemote{Status: remoteCurrent, Digest: dgst}
+}
+
+// addFileChanges checks the files recorded in the state of dir against
+// the disk and adds those that changed to result.
+func addFileChanges(result *statusResult, dir string, files []pullstate.File) {
+	checks := make([]checksumFile, 0, len(files))
+	for _, f := range files {
+		checks = append(checks, checksumFile{
+			Path:        f.Path,
+			Destination: filepath.Join(dir, filepath.FromSlash(f.Path)),
+			Digest:      f.Digest,
+		})
+	}
+	report := verifyChecksums(checks)
+	result.Files = report.Checked
+	for _, r := range report.Files {
+		switch r.Status {
+		case checksumMismatch:
+			result.Modified++
+			result.Changes = append(result.Changes, statusChange{Path: r.Path, Status: "modified"})
+		case checksumMissing:
+			result.Missing++
+			result.Changes = append(result.Changes, statusChange{Path: r.Path, Status: "missing"})
+		}
+	}
+}
+
+// newPullState describes a pull of blobArchive, with the manifest digest
+// dgst, for blob status. Its files are those under flags.prefix, with
+// flags.strip components removed, whether or not this pull wrote them.
+func newPullState(blobArchive *blob.Archive, inputRef, resolvedRef, dgst string, flags pullFlags) *pullstate.State {
+	state := &pullstate.State{
+		Ref:             inputRef,
+		ResolvedRef:     resolvedRef,
+		Digest:          dgst,
+		PulledAt:        time.Now().UTC(),
+		StripComponents: flags.strip,
+		Files:           []pullstate.File{},
+	}
+	if flags.prefix != "." {
+		state.Prefix = flags.prefix
+	}
+	claimed := make(map[string]bool)
+	for entry := range pullEntries(blobArchive, flags.prefix) {
+		if entry.Mode().IsDir() {
+			continue
+		}
+		target, ok := stripPath(entry.Path(), flags.strip)
+		if !ok || claimed[target] {
+			continue
+		}
+		claimed[target] = true
+		state.Files = append(state.Files, pullstate.File{
+			Path:   target,
+			Digest: "sha256:" + hex.EncodeToString(entry.HashBytes()),
+		})
+	}
+	return state
+}
+
+func statusText(result *statusResult) error {
+	fmt.Printf("Directory: %s\n", result.Dir)
+	fmt.Printf("  Ref: %s\n", result.Ref)
+	if result.ResolvedRef != result.Ref {
+		fmt.Printf("  Resolved: %s\n", result.ResolvedRef)
+	}
+	fmt.Printf("  Digest: %s\n", result.Digest)
+	fmt.Printf("  Pulled: %s\n", result.PulledAt.Local().Format(time.DateTime))
+
+	switch result.Remote.Status {
+	case remoteCurrent:
+		fmt.Println("  Remote: up to date")
+	case remoteMoved:
+		fmt.Printf("  Remote: moved to %s\n", result.Remote.Digest)
+	case remotePinned:
+		fmt.Println("  Remote: pinned by digest")
+	default:
+		fmt.Printf("  Remote: unknown (%s)\n", result.Remote.Error)
+	}
+
+	if len(result.Changes) == 0 {
+		fmt.Printf("  Files: %s, unchanged\n", pluralize(result.Files, "file", "files"))
+		return nil
+	}
+	fmt.Printf("  Files: %s, %d modified, %d missing\n", pluralize(result.Files, "file", "files"), result.Modified, result.Missing)
+	for _, c := range result.Changes {
+		fmt.Printf("    %-8s %s\n", c.Status, c.Path)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/pullstate"
+)
+
+func TestStatus(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	repo := reg.repo
+	v1 := reg.addArchive(t, "v1", map[string]string{
+		"etc/app.conf": "app",
+		"etc/db.conf":  "db",
+		"README":       "readme",
+	})
+
+	cfg := newRegistryTestConfig(t)
+	ctx := internalcfg.WithConfig(context.Background(), cfg)
+	dest := t.TempDir()
+	pullCmd.SetContext(ctx)
+	require.NoError(t, pullCmd.RunE(pullCmd, []string{repo + ":v1", dest}))
+
+	state, err := pullstate.Read(dest)
+	require.NoError(t, err)
+	assert.Equal(t, repo+":v1", state.Ref)
+	assert.Equal(t, v1.Digest.String(), state.Digest)
+	require.Len(t, state.Files, 3)
+
+	result := statusFor(t, cfg, dest)
+	assert.Equal(t, remoteCurrent, result.Remote.Status)
+	assert.Equal(t, 3, result.Files)
+	assert.Empty(t, result.Changes)
+
+	// Change the directory and move the tag
+	require.NoError(t, os.WriteFile(filepath.Join(dest, "etc", "app.conf"), []byte("edited"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(dest, "README")))
+	v2 := reg.addArchive(t, "v1", map[string]string{"etc/app.conf": "app2"})
+
+	result = statusFor(t, cfg, dest)
+	assert.Equal(t, statusRemote{Status: remoteMoved, Digest: v2.Digest.String()}, result.Remote)
+	assert.Equal(t, 1, result.Modified)
+	assert.Equal(t, 1, result.Missing)
+	assert.Equal(t, []statusChange{
+		{Path: "README", Status: "missing"},
+		{Path: "etc/app.conf", Status: "modified"},
+	}, result.Changes)
+}
+
+func TestStatus_PinnedAndStripped(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	repo := reg.repo
+	v1 := reg.addArchive(t, "v1", map[string]string{"etc/nginx/nginx.conf": "events {}"})
+
+	cfg := newRegistryTestConfig(t)
+	dest := t.TempDir()
+	pullCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	t.Cleanup(func() {
+		pullCmd.Flags().Set("strip-components", "0") //nolint:errcheck // test cleanup
+	})
+	require.NoError(t, pullCmd.Flags().Set("strip-components", "2"))
+	require.NoError(t, pullCmd.RunE(pullCmd, []string{repo + "@" + v1.Digest.String(), dest}))
+	assert.FileExists(t, filepath.Join(dest, "nginx.conf"))
+
+	result := statusFor(t, cfg, dest)
+	assert.Equal(t, remotePinned, result.Remote.Status)
+	assert.Equal(t, 1, result.Files)
+	assert.Empty(t, result.Changes)
+}
+
+func TestStatus_NotPulled(t *testing.T) {
+	cfg := &internalcfg.Config{Quiet: true}
+	statusCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	err := statusCmd.RunE(statusCmd, []string{t.TempDir()})
+	require.ErrorIs(t, err, pullstate.ErrNoState)
+	require.ErrorContains(t, err, "was not pulled by blob")
+}
+
+// statusFor returns the status of dir.
+func statusFor(t *testing.T, cfg *internalcfg.Config, dir string) *statusResult {
+	t.Helper()
+	state, err := pullstate.Read(dir)
+	require.NoError(t, err)
+	result := &statusResult{Digest: state.Digest, Remote: remoteStatus(t.Context(), cfg, state)}
+	addFileChanges(result, dir, state.Files)
+	return result
+}
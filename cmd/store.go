@@ -0,0 +1,465 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/meigma/blob"
+	"github.com/spf13/cobra"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/policy"
+	"github.com/meigma/blob-cli/internal/store"
+)
+
+var storeCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Manage the local content-addressed store",
+	Long: `Manage the local content-addressed store.
+
+The store keeps archive files on disk once, addressed by their SHA-256,
+and records which files belong to each archive by manifest digest.
+"store get" populates a working directory with hard links into the
+store, so several directories holding the same or overlapping archives
+share their bytes instead of duplicating them.
+
+Linked files are read-only because they share the store's copy. Replace
+a file rather than editing it in place. If a hard link cannot be made
+(e.g., the destination is on another file system), the file is copied.
+
+Store location: $XDG_DATA_HOME/blob/store or ~/.local/share/blob/store.
+Override with store.dir in config file or BLOB_STORE_DIR environment variable.`,
+}
+
+var storeAddCmd = &cobra.Command{
+	Use:   "add <ref>...",
+	Short: "Add archives to the store",
+	Long: `Add archives to the store.
+
+Fetches each archive's files that are not already stored. Verification
+policies from config and flags are applied as for pull.`,
+	Example: `  blob store add ghcr.io/acme/configs:v1.0.0
+  blob store add foo:v1 foo:v2                     # Using alias
+  blob store add --policy policy.yaml ghcr.io/acme/configs:v1.0.0`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runStoreAdd,
+}
+
+var storeGetCmd = &cobra.Command{
+	Use:   "get <ref|digest> [path]",
+	Short: "Link a stored archive into a directory",
+	Long: `Link a stored archive into a directory.
+
+The archive is identified by manifest digest or by a reference it was
+added under. No registry access is needed. Existing files in the
+destination are not overwritten.`,
+	Example: `  blob store get ghcr.io/acme/configs:v1.0.0 ./work
+  blob store get sha256:4f1c... ./work`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runStoreGet,
+}
+
+var storeLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List stored archives",
+	Example: `  blob store ls
+  blob store ls --output json`,
+	Args: cobra.NoArgs,
+	RunE: runStoreLs,
+}
+
+var storeRmCmd = &cobra.Command{
+	Use:   "rm <ref|digest>...",
+	Short: "Remove archives from the store",
+	Long: `Remove archives from the store.
+
+Files no longer used by any stored archive are deleted from the store.
+Directories populated with "store get" keep their copies.`,
+	Example: `  blob store rm ghcr.io/acme/configs:v1.0.0
+  blob store rm sha256:4f1c...`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runStoreRm,
+}
+
+func init() {
+	storeAddCmd.Flags().StringArray("policy", nil, "policy file for verification (repeatable)")
+	storeAddCmd.Flags().String("policy-rego", "", "OPA Rego policy file")
+	storeAddCmd.Flags().Bool("no-default-policy", false, "skip policies from config file")
+
+	storeCmd.AddCommand(storeAddCmd)
+	storeCmd.AddCommand(storeGetCmd)
+	storeCmd.AddCommand(storeLsCmd)
+	storeCmd.AddCommand(storeRmCmd)
+}
+
+// storeAddResult describes one archive added to the store.
+type storeAddResult struct {
+	Ref             string `json:"ref"`
+	ResolvedRef     string `json:"resolved_ref,omitempty"`
+	Digest          string `json:"digest"`
+	FileCount       int    `json:"file_count"`
+	NewObjects      int    `json:"new_objects"`
+	AddedBytes      uint64 `json:"added_bytes"`
+	AddedBytesHuman string `json:"added_bytes_human"`
+}
+
+// storeGetResult describes a directory populated from the store.
+type storeGetResult struct {
+	Digest         string `json:"digest"`
+	Destination    string `json:"destination"`
+	FileCount      int    `json:"file_count"`
+	Linked         int    `json:"linked"`
+	Copied         int    `json:"copied"`
+	TotalSize      uint64 `json:"total_size"`
+	TotalSizeHuman string `json:"total_size_human"`
+}
+
+// storeLsEntry describes a stored archive.
+type storeLsEntry struct {
+	Digest    string    `json:"digest"`
+	Refs      []string  `json:"refs,omitempty"`
+	Added     time.Time `json:"added"`
+	FileCount int       `json:"file_count"`
+	Size      uint64    `json:"size"`
+	SizeHuman string    `json:"size_human"`
+}
+
+// storeRmResult describes removed archives and the space reclaimed.
+type storeRmResult struct {
+	Removed         []string `json:"removed"`
+	FreedObjects    int      `json:"freed_objects"`
+	FreedBytes      int64    `json:"freed_bytes"`
+	FreedBytesHuman string   `json:"freed_bytes_human"`
+}
+
+func runStoreAdd(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	policyFiles, err := cmd.Flags().GetStringArray("policy")
+	if err != nil {
+		return fmt.Errorf("reading policy flag: %w", err)
+	}
+	policyRego, err := cmd.Flags().GetString("policy-rego")
+	if err != nil {
+		return fmt.Errorf("reading policy-rego flag: %w", err)
+	}
+	noDefaultPolicy, err := cmd.Flags().GetBool("no-default-policy")
+	if err != nil {
+		return fmt.Errorf("reading no-default-policy flag: %w", err)
+	}
+
+	s, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	results := make([]storeAddResult, 0, len(args))
+	for _, inputRef := range args {
+		resolvedRef, err := resolveRef(cmd.Context(), cfg, inputRef)
+		if err != nil {
+			return err
+		}
+
+		policies, err := policy.BuildPolicies(cfg, resolvedRef, policyFiles, policyRego, noDefaultPolicy)
+		if err != nil {
+			return fmt.Errorf("building policies: %w", err)
+		}
+		policies = withTagReferrers(cfg, policies)
+		policyOpts := make([]blob.Option, 0, len(policies))
+		for _, p := range policies {
+			policyOpts = append(policyOpts, blob.WithPolicy(p))
+		}
+
+		client, err := newClient(cfg, policyOpts...)
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+
+		result, err := addToStore(cmd.Context(), client, s, resolvedRef)
+		if err != nil {
+			return err
+		}
+		result.Ref = inputRef
+		if inputRef != resolvedRef {
+			result.ResolvedRef = resolvedRef
+		}
+		results = append(results, *result)
+	}
+
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		return storeJSON(map[string]any{"root": s.Root(), "added": results})
+	}
+	for i := range results {
+		r := &results[i]
+		fmt.Printf("Stored %s\n", r.Ref)
+		if r.ResolvedRef != "" {
+			fmt.Printf("  Resolved: %s\n", r.ResolvedRef)
+		}
+		fmt.Printf("  Digest: %s\n", r.Digest)
+		fmt.Printf("  Files: %d (%d new, %s)\n", r.FileCount, r.NewObjects, r.AddedBytesHuman)
+	}
+	return nil
+}
+
+// addToStore pulls the archive at ref by digest and stores files missing
+// from the store.
+func addToStore(ctx context.Context, client *blob.Client, s *store.Store, ref string) (*storeAddResult, error) {
+	// Resolve the tag once so the record matches the files that are stored.
+	manifest, err := client.Fetch(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("fetching manifest: %w", err)
+	}
+	digest := manifest.Digest()
+
+	blobArchive, err := client.Pull(ctx, repositoryOf(ref)+"@"+digest)
+	if err != nil {
+		if errors.Is(err, blob.ErrPolicyViolation) {
+			return nil, fmt.Errorf("verification failed: %w", err)
+		}
+		return nil, fmt.Errorf("pulling archive: %w", err)
+	}
+
+	record := &store.Archive{
+		Digest: digest,
+		Refs:   []string{ref},
+		Added:  time.Now().UTC(),
+	}
+	result := &storeAddResult{Digest: digest}
+
+	for entry := range blobArchive.Entries() {
+		file := store.File{
+			Path: entry.Path(),
+			Hash: hex.EncodeToString(entry.HashBytes()),
+			Size: entry.OriginalSize(),
+			Mode: entry.Mode(),
+		}
+		record.Files = append(record.Files, file)
+
+		if s.HasObject(file.Hash, file.Mode) {
+			continue
+		}
+		if err := storeEntry(blobArchive, s, file); err != nil {
+			return nil, err
+		}
+		result.NewObjects++
+		result.AddedBytes += file.Size
+	}
+
+	if err := s.Put(record); err != nil {
+		return nil, err
+	}
+
+	result.FileCount = len(record.Files)
+	result.AddedBytesHuman = archive.FormatSize(result.AddedBytes)
+	return result, nil
+}
+
+// storeEntry copies a single archive file into the store.
+func storeEntry(blobArchive *blob.Archive, s *store.Store, file store.File) error {
+	f, err := blobArchive.Open(file.Path)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", file.Path, err)
+	}
+	defer f.Close()
+
+	if err := s.AddObject(file.Hash, file.Mode, f); err != nil {
+		return fmt.Errorf("storing %s: %w", file.Path, err)
+	}
+	return nil
+}
+
+func runStoreGet(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	destDir := "."
+	if len(args) > 1 {
+		destDir = args[1]
+	}
+
+	s, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	record, err := s.Find(cfg.ResolveAlias(args[0]))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("%w (add it with: blob store add %s)", err, args[0])
+		}
+		return err
+	}
+
+	destDir, err = prepareDestination(destDir)
+	if err != nil {
+		return err
+	}
+
+	stats, err := s.Link(record, destDir)
+	if err != nil {
+		return fmt.Errorf("populating %s: %w", destDir, err)
+	}
+
+	result := storeGetResult{
+		Digest:         record.Digest,
+		Destination:    destDir,
+		FileCount:      stats.Files,
+		Linked:         stats.Linked,
+		Copied:         stats.Copied,
+		TotalSize:      stats.Bytes,
+		TotalSizeHuman: archive.FormatSize(stats.Bytes),
+	}
+
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		return storeJSON(result)
+	}
+	fmt.Printf("Linked %s\n", result.Digest)
+	fmt.Printf("  Destination: %s\n", result.Destination)
+	fmt.Printf("  Files: %d (%d linked, %d copied)\n", result.FileCount, result.Linked, result.Copied)
+	fmt.Printf("  Size: %s\n", result.TotalSizeHuman)
+	return nil
+}
+
+func runStoreLs(cmd *cobra.Command, _ []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	s, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	archives, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	entries := make([]storeLsEntry, 0, len(archives))
+	for _, a := range archives {
+		entries = append(entries, storeLsEntry{
+			Digest:    a.Digest,
+			Refs:      a.Refs,
+			Added:     a.Added,
+			FileCount: len(a.Files),
+			Size:      a.Size(),
+			SizeHuman: archive.FormatSize(a.Size()),
+		})
+	}
+
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		return storeJSON(map[string]any{"root": s.Root(), "archives": entries})
+	}
+	return storeLsText(entries)
+}
+
+func storeLsText(entries []storeLsEntry) error {
+	if len(entries) == 0 {
+		fmt.Println("No archives in store.")
+		return nil
+	}
+
+	fmt.Printf("%-19s  %6s  %8s  %-16s  %s\n", "DIGEST", "FILES", "SIZE", "ADDED", "REFS")
+	for i := range entries {
+		e := &entries[i]
+		fmt.Printf("%-19s  %6d  %8s  %-16s  %s\n",
+			shortDigest(e.Digest), e.FileCount, e.SizeHuman,
+			e.Added.Local().Format("2006-01-02 15:04"), strings.Join(e.Refs, ", "))
+	}
+	return nil
+}
+
+// shortDigest abbreviates a digest to its algorithm and first 12 hex characters.
+func shortDigest(digest string) string {
+	algo, hash, ok := strings.Cut(digest, ":")
+	if !ok || len(hash) <= 12 {
+		return digest
+	}
+	return algo + ":" + hash[:12]
+}
+
+func runStoreRm(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	s, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	result := storeRmResult{Removed: make([]string, 0, len(args))}
+	for _, arg := range args {
+		record, err := s.Find(cfg.ResolveAlias(arg))
+		if err != nil {
+			return err
+		}
+		if err := s.Remove(record.Digest); err != nil {
+			return err
+		}
+		result.Removed = append(result.Removed, record.Digest)
+	}
+
+	stats, err := s.GC()
+	if err != nil {
+		return err
+	}
+	result.FreedObjects = stats.Objects
+	result.FreedBytes = stats.Bytes
+	result.FreedBytesHuman = archive.FormatSize(uint64(max(0, stats.Bytes))) //nolint:gosec // bytes freed are non-negative
+
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		return storeJSON(result)
+	}
+	for _, digest := range result.Removed {
+		fmt.Printf("Removed %s\n", digest)
+	}
+	fmt.Printf("Freed %s (%s)\n", result.FreedBytesHuman, pluralize(result.FreedObjects, "file", "files"))
+	return nil
+}
+
+// openStore returns the store configured for cfg.
+// Priority: config file > XDG default.
+func openStore(cfg *internalcfg.Config) (*store.Store, error) {
+	if cfg.Store.Dir != "" {
+		return store.New(cfg.Store.Dir), nil
+	}
+	dataDir, err := internalcfg.DataDir()
+	if err != nil {
+		return nil, fmt.Errorf("determining store directory: %w", err)
+	}
+	return store.New(filepath.Join(dataDir, "store")), nil
+}
+
+func storeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
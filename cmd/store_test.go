@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/store"
+)
+
+// seedStore adds an archive with the given files to the store in dir.
+func seedStore(t *testing.T, dir, ref string, files map[string]string) *store.Archive {
+	t.Helper()
+	s := store.New(dir)
+	var all strings.Builder
+	a := &store.Archive{Refs: []string{ref}, Added: time.Now().UTC()}
+	for path, content := range files {
+		sum := sha256.Sum256([]byte(content))
+		hash := hex.EncodeToString(sum[:])
+		require.NoError(t, s.AddObject(hash, 0o644, strings.NewReader(content)))
+		a.Files = append(a.Files, store.File{Path: path, Hash: hash, Size: uint64(len(content)), Mode: 0o644})
+		all.WriteString(path + hash)
+	}
+	sum := sha256.Sum256([]byte(all.String()))
+	a.Digest = "sha256:" + hex.EncodeToString(sum[:])
+	require.NoError(t, s.Put(a))
+	return a
+}
+
+func storeTestContext(t *testing.T) (context.Context, string) {
+	t.Helper()
+	cfg := internalcfg.Default()
+	cfg.Quiet = true
+	cfg.Store.Dir = t.TempDir()
+	cfg.Aliases = map[string]string{"app": "ghcr.io/acme/app"}
+	return internalcfg.WithConfig(context.Background(), cfg), cfg.Store.Dir
+}
+
+func TestStoreGet(t *testing.T) {
+	ctx, dir := storeTestContext(t)
+	seedStore(t, dir, "ghcr.io/acme/app:v1", map[string]string{"conf/a.yaml": "a: 1\n"})
+
+	dest := filepath.Join(t.TempDir(), "work")
+	storeGetCmd.SetContext(ctx)
+	require.NoError(t, storeGetCmd.RunE(storeGetCmd, []string{"app:v1", dest}))
+
+	data, err := os.ReadFile(filepath.Join(dest, "conf", "a.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(data))
+
+	err = storeGetCmd.RunE(storeGetCmd, []string{"app:v2", dest})
+	require.ErrorIs(t, err, store.ErrNotFound)
+	assert.Contains(t, err.Error(), "blob store add app:v2")
+}
+
+func TestStoreRm(t *testing.T) {
+	ctx, dir := storeTestContext(t)
+	v1 := seedStore(t, dir, "ghcr.io/acme/app:v1", map[string]string{"shared": "same", "one": "1"})
+	seedStore(t, dir, "ghcr.io/acme/app:v2", map[string]string{"shared": "same", "two": "2"})
+
+	storeRmCmd.SetContext(ctx)
+	require.NoError(t, storeRmCmd.RunE(storeRmCmd, []string{v1.Digest}))
+
+	s := store.New(dir)
+	archives, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, archives, 1)
+	assert.Equal(t, []string{"ghcr.io/acme/app:v2"}, archives[0].Refs)
+	for _, f := range archives[0].Files {
+		assert.True(t, s.HasObject(f.Hash, f.Mode), f.Path)
+	}
+	one := slices.IndexFunc(v1.Files, func(f store.File) bool { return f.Path == "one" })
+	assert.False(t, s.HasObject(v1.Files[one].Hash, 0o644))
+
+	require.ErrorIs(t, storeRmCmd.RunE(storeRmCmd, []string{v1.Digest}), store.ErrNotFound)
+}
+
+func TestShortDigest(t *testing.T) {
+	assert.Equal(t, "sha256:0123456789ab", shortDigest("sha256:0123456789abcdef0123"))
+	assert.Equal(t, "sha256:abc", shortDigest("sha256:abc"))
+	assert.Equal(t, "nodigest", shortDigest("nodigest"))
+}
@@ -7,7 +7,6 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	internalcfg "github.com/meigma/blob-cli/internal/config"
 )
@@ -45,7 +44,10 @@ func runTag(cmd *cobra.Command, args []string) error {
 	srcRef := args[0]
 	dstRef := args[1]
 
-	resolvedSrcRef := cfg.ResolveAlias(srcRef)
+	resolvedSrcRef, err := resolveRef(cmd.Context(), cfg, srcRef)
+	if err != nil {
+		return err
+	}
 	resolvedDstRef := cfg.ResolveAlias(dstRef)
 
 	client, err := newClient(cfg)
@@ -88,7 +90,7 @@ func outputTagResult(cfg *internalcfg.Config, result *tagResult) error {
 	if cfg.Quiet {
 		return nil
 	}
-	if viper.GetString("output") == internalcfg.OutputJSON {
+	if cfg.Output == internalcfg.OutputJSON {
 		return tagJSON(result)
 	}
 	return tagText(result)
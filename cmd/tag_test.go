@@ -7,16 +7,11 @@ import (
 	"os"
 	"testing"
 
-	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTagCmd_NilConfig(t *testing.T) {
-	// Reset viper and restore after test to avoid affecting other tests.
-	viper.Reset()
-	t.Cleanup(viper.Reset)
-
 	ctx := context.Background()
 
 	tagCmd.SetContext(ctx)
@@ -0,0 +1,380 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/meigma/blob"
+	blobregistry "github.com/meigma/blob/registry/oras"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+	"oras.land/oras-go/v2/registry/remote"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/referrers"
+	"github.com/meigma/blob-cli/internal/semver"
+)
+
+// tagsInspectJobs bounds concurrent manifest and index fetches.
+const tagsInspectJobs = 8
+
+// Tag sort orders accepted by tags --sort.
+const (
+	tagsSortName   = "name"
+	tagsSortSemver = "semver"
+	tagsSortDate   = "date"
+)
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags <repo>",
+	Short: "List tags in a repository",
+	Long: `List tags in a repository.
+
+Shows each tag with its manifest digest, creation date, file count,
+and size. Details are read from each archive's manifest and index;
+tags that do not point to a blob archive (for example signature
+tags) are listed without them. Use --names-only to skip the details
+and only list the tag names.
+
+Tags are listed in the order the registry returns them unless --sort
+is given:
+  name     alphabetically
+  semver   highest version first; tags that are not versions follow,
+           sorted by name
+  date     newest first, by the creation date in the manifest; tags
+           without one follow, sorted by name
+
+--match keeps only tags matched by a semver query (see blob resolve),
+and --exclude-prereleases drops prerelease versions such as v1.2.0-rc.1.
+Together they give the candidate lists for retention and promotion
+scripts, for example every 1.x release older than the newest three.
+
+A tag or digest on the reference (or alias) is ignored.`,
+	Example: `  blob tags ghcr.io/acme/configs
+  blob tags --names-only ghcr.io/acme/configs
+  blob tags --sort semver --exclude-prereleases configs
+  blob tags --sort date --names-only configs | tail -n +4
+  blob tags --match '^1' --sort semver --names-only configs
+  blob tags --output json configs`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTags,
+}
+
+func init() {
+	tagsCmd.Flags().Bool("names-only", false, "list tag names without fetching details")
+	tagsCmd.Flags().Bool("skip-cache", false, "bypass registry caches for this operation")
+	tagsCmd.Flags().String("sort", "", "sort tags by name, semver, or date (default: registry order)")
+	tagsCmd.Flags().String("match", "", "only list tags matched by a semver query")
+	tagsCmd.Flags().Bool("exclude-prereleases", false, "omit prerelease versions")
+}
+
+// tagsFlags holds the parsed command flags.
+type tagsFlags struct {
+	namesOnly          bool
+	skipCache          bool
+	sort               string
+	match              *semver.Query
+	excludePrereleases bool
+}
+
+// tagsResult contains the tags output data for JSON format.
+type tagsResult struct {
+	Repository string      `json:"repository"`
+	Tags       []tagsEntry `json:"tags"`
+}
+
+// tagsEntry describes a single tag.
+type tagsEntry struct {
+	Tag       string `json:"tag"`
+	Digest    string `json:"digest,omitempty"`
+	Created   string `json:"created,omitempty"`
+	Files     int    `json:"files,omitempty"`
+	Size      uint64 `json:"size,omitempty"`
+	SizeHuman string `json:"size_human,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func runTags(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	flags, err := parseTagsFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	repo := repositoryOf(cfg.ResolveAlias(args[0]))
+	ctx := cmd.Context()
+
+	tags, err := listTags(ctx, cfg, repo)
+	if err != nil {
+		return err
+	}
+
+	tags = filterTags(tags, flags.match, flags.excludePrereleases)
+	entries := make([]tagsEntry, len(tags))
+	for i, tag := range tags {
+		entries[i].Tag = tag
+	}
+	// Sorting by date needs the creation dates even if they are not shown.
+	if !flags.namesOnly || flags.sort == tagsSortDate {
+		if err := inspectTags(ctx, cfg, repo, entries, flags.skipCache); err != nil {
+			return err
+		}
+	}
+	sortTags(entries, flags.sort)
+
+	if cfg.Quiet {
+		return nil
+	}
+	result := tagsResult{Repository: repo, Tags: entries}
+	if cfg.Output == internalcfg.OutputJSON {
+		return tagsJSON(&result)
+	}
+	return tagsText(&result, flags)
+}
+
+func parseTagsFlags(cmd *cobra.Command) (tagsFlags, error) {
+	var flags tagsFlags
+	var err error
+
+	flags.namesOnly, err = cmd.Flags().GetBool("names-only")
+	if err != nil {
+		return flags, fmt.Errorf("reading names-only flag: %w", err)
+	}
+
+	flags.skipCache, err = cmd.Flags().GetBool("skip-cache")
+	if err != nil {
+		return flags, fmt.Errorf("reading skip-cache flag: %w", err)
+	}
+
+	flags.sort, err = cmd.Flags().GetString("sort")
+	if err != nil {
+		return flags, fmt.Errorf("reading sort flag: %w", err)
+	}
+	switch flags.sort {
+	case "", tagsSortName, tagsSortSemver, tagsSortDate:
+	default:
+		return flags, fmt.Errorf("invalid --sort %q: must be name, semver, or date", flags.sort)
+	}
+
+	match, err := cmd.Flags().GetString("match")
+	if err != nil {
+		return flags, fmt.Errorf("reading match flag: %w", err)
+	}
+	if match != "" {
+		flags.match, err = semver.Parse(match)
+		if err != nil {
+			return flags, fmt.Errorf("invalid --match: %w", err)
+		}
+	}
+
+	flags.excludePrereleases, err = cmd.Flags().GetBool("exclude-prereleases")
+	if err != nil {
+		return flags, fmt.Errorf("reading exclude-prereleases flag: %w", err)
+	}
+
+	return flags, nil
+}
+
+// filterTags returns the tags matched by query, if any, dropping prerelease
+// versions if excludePrereleases is set.
+func filterTags(tags []string, query *semver.Query, excludePrereleases bool) []string {
+	if query == nil && !excludePrereleases {
+		return tags
+	}
+	filtered := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if query != nil && !query.Match(tag) {
+			continue
+		}
+		if v, ok := semver.ParseTag(tag); ok && excludePrereleases && len(v.Pre) > 0 {
+			continue
+		}
+		filtered = append(filtered, tag)
+	}
+	return filtered
+}
+
+// sortTags sorts entries in place by the given order. Versions and dates
+// sort newest first; tags without a version or date follow, by name. An
+// empty order keeps the registry's order.
+func sortTags(entries []tagsEntry, order string) {
+	switch order {
+	case tagsSortName:
+		slices.SortStableFunc(entries, func(a, b tagsEntry) int {
+			return strings.Compare(a.Tag, b.Tag)
+		})
+	case tagsSortSemver:
+		slices.SortStableFunc(entries, func(a, b tagsEntry) int {
+			va, okA := semver.ParseTag(a.Tag)
+			vb, okB := semver.ParseTag(b.Tag)
+			switch {
+			case okA && okB:
+				if c := vb.Compare(va); c != 0 {
+					return c
+				}
+			case okA:
+				return -1
+			case okB:
+				return 1
+			}
+			return strings.Compare(a.Tag, b.Tag)
+		})
+	case tagsSortDate:
+		slices.SortStableFunc(entries, func(a, b tagsEntry) int {
+			ta, errA := time.Parse(time.RFC3339, a.Created)
+			tb, errB := time.Parse(time.RFC3339, b.Created)
+			switch {
+			case errA == nil && errB == nil:
+				if c := tb.Compare(ta); c != 0 {
+					return c
+				}
+			case errA == nil:
+				return -1
+			case errB == nil:
+				return 1
+			}
+			return strings.Compare(a.Tag, b.Tag)
+		})
+	}
+}
+
+// newRemoteRepository returns an authenticated client for the registry API
+// of repo, for operations the blob client does not expose.
+func newRemoteRepository(cfg *internalcfg.Config, repo string) (*remote.Repository, error) {
+	ociClient := blobregistry.New(
+		blobregistry.WithDockerConfig(),
+		blobregistry.WithPlainHTTP(cfg.PlainHTTP),
+		blobregistry.WithUserAgent(userAgent(cfg)),
+	)
+	httpClient, err := ociClient.AuthClient(repo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository %q: %w", repo, err)
+	}
+
+	repository, err := remote.NewRepository(repo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository %q: %w", repo, err)
+	}
+	repository.Client = httpClient
+	repository.PlainHTTP = cfg.PlainHTTP
+	return repository, nil
+}
+
+// listTags returns the tags in repo, in the order the registry lists them.
+// Referrers tags, which index signatures and attestations on registries
+// without the referrers API, are left out.
+func listTags(ctx context.Context, cfg *internalcfg.Config, repo string) ([]string, error) {
+	repository, err := newRemoteRepository(cfg, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	err = repository.Tags(ctx, "", func(page []string) error {
+		for _, tag := range page {
+			if !referrers.IsTag(tag) {
+				tags = append(tags, tag)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing tags for %s: %w", repo, err)
+	}
+	return tags, nil
+}
+
+// inspectTags fills in the details of each entry. Tags that cannot be
+// inspected as archives record the error instead of failing the command.
+func inspectTags(ctx context.Context, cfg *internalcfg.Config, repo string, entries []tagsEntry, skipCache bool) error {
+	var client *blob.Client
+	var err error
+	if skipCache {
+		client, err = blob.NewClient(clientOptsNoCache(cfg)...)
+	} else {
+		client, err = newClient(cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	var inspectOpts []blob.InspectOption
+	if skipCache {
+		inspectOpts = append(inspectOpts, blob.InspectWithSkipCache())
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(tagsInspectJobs)
+	for i := range entries {
+		e := &entries[i]
+		g.Go(func() error {
+			result, err := client.Inspect(ctx, repo+":"+e.Tag, inspectOpts...)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				e.Error = err.Error()
+				return nil
+			}
+			e.Digest = result.Digest()
+			if created := result.Created(); !created.IsZero() {
+				e.Created = created.Format(time.RFC3339)
+			}
+			e.Files = result.FileCount()
+			e.Size = result.TotalUncompressedSize()
+			e.SizeHuman = archive.FormatSize(e.Size)
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+func tagsJSON(result *tagsResult) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+func tagsText(result *tagsResult, flags tagsFlags) error {
+	if len(result.Tags) == 0 {
+		fmt.Printf("No tags in %s.\n", result.Repository)
+		return nil
+	}
+
+	if flags.namesOnly {
+		for _, e := range result.Tags {
+			fmt.Println(e.Tag)
+		}
+		return nil
+	}
+
+	tagWidth := len("TAG")
+	for _, e := range result.Tags {
+		tagWidth = max(tagWidth, len(e.Tag))
+	}
+
+	fmt.Printf("%-*s  %-19s  %-16s  %6s  %8s\n", tagWidth, "TAG", "DIGEST", "CREATED", "FILES", "SIZE")
+	for _, e := range result.Tags {
+		if e.Error != "" {
+			fmt.Printf("%-*s  %-19s  %-16s  %6s  %8s\n", tagWidth, e.Tag, "-", "-", "-", "-")
+			continue
+		}
+		created := "-"
+		if t, err := time.Parse(time.RFC3339, e.Created); err == nil {
+			created = t.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%-*s  %-19s  %-16s  %6d  %8s\n",
+			tagWidth, e.Tag, shortDigest(e.Digest), created, e.Files, e.SizeHuman)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/semver"
+)
+
+func TestListTags(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/acme/configs/tags/list" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		//nolint:errcheck // test server
+		json.NewEncoder(w).Encode(map[string]any{
+			"name": "acme/configs",
+			"tags": []string{"v1.0.0", "v1.1.0", "latest", "sha256-" + strings.Repeat("ab", 32)},
+		})
+	}))
+	defer srv.Close()
+
+	cfg := &internalcfg.Config{PlainHTTP: true}
+	repo := strings.TrimPrefix(srv.URL, "http://") + "/acme/configs"
+
+	tags, err := listTags(t.Context(), cfg, repo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0", "latest"}, tags)
+
+	_, err = listTags(t.Context(), cfg, strings.TrimPrefix(srv.URL, "http://")+"/acme/missing")
+	require.Error(t, err)
+}
+
+func TestTagsText(t *testing.T) {
+	result := &tagsResult{
+		Repository: "ghcr.io/acme/configs",
+		Tags: []tagsEntry{
+			{
+				Tag:       "v1.0.0",
+				Digest:    "sha256:4f1c2a9b8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f0e9d8c7b6a5f4e3d2c1b",
+				Files:     12,
+				SizeHuman: "4.2K",
+			},
+			{Tag: "sha256-abc.sig", Error: "not a blob archive"},
+		},
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := tagsText(result, tagsFlags{})
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	require.NoError(t, err)
+	output := buf.String()
+	assert.Contains(t, output, "TAG")
+	assert.Contains(t, output, "sha256:4f1c2a9b8e7d")
+	assert.Contains(t, output, "4.2K")
+	assert.Contains(t, output, "sha256-abc.sig")
+}
+
+func TestFilterTags(t *testing.T) {
+	tags := []string{"v1.0.0", "v1.1.0-rc.1", "v1.1.0", "v2.0.0", "latest"}
+
+	assert.Equal(t, tags, filterTags(tags, nil, false))
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0", "v2.0.0", "latest"}, filterTags(tags, nil, true))
+
+	query, err := semver.Parse("^1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, filterTags(tags, query, false))
+
+	query, err = semver.Parse(">=1.1.0-rc.1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.1.0-rc.1", "v1.1.0", "v2.0.0"}, filterTags(tags, query, false))
+	assert.Equal(t, []string{"v1.1.0", "v2.0.0"}, filterTags(tags, query, true))
+}
+
+func TestSortTags(t *testing.T) {
+	entries := func() []tagsEntry {
+		return []tagsEntry{
+			{Tag: "v1.10.0", Created: "2025-03-01T00:00:00Z"},
+			{Tag: "latest", Created: "2025-04-01T00:00:00+02:00"},
+			{Tag: "v1.2.0", Created: "2025-01-01T00:00:00Z"},
+			{Tag: "sha256-abc.sig"},
+			{Tag: "v1.10.0-rc.1", Created: "2025-02-01T00:00:00Z"},
+		}
+	}
+	tagNames := func(entries []tagsEntry) []string {
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.Tag
+		}
+		return names
+	}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"", []string{"v1.10.0", "latest", "v1.2.0", "sha256-abc.sig", "v1.10.0-rc.1"}},
+		{tagsSortName, []string{"latest", "sha256-abc.sig", "v1.10.0", "v1.10.0-rc.1", "v1.2.0"}},
+		{tagsSortSemver, []string{"v1.10.0", "v1.10.0-rc.1", "v1.2.0", "latest", "sha256-abc.sig"}},
+		{tagsSortDate, []string{"latest", "v1.10.0", "v1.10.0-rc.1", "v1.2.0", "sha256-abc.sig"}},
+	}
+	for _, tt := range tests {
+		t.Run("sort "+tt.order, func(t *testing.T) {
+			got := entries()
+			sortTags(got, tt.order)
+			assert.Equal(t, tt.want, tagNames(got))
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+// stdoutIsTerminal reports whether stdout is a terminal.
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd())) //nolint:gosec // file descriptors fit in an int
+}
+
+// stdinIsTerminal reports whether stdin is a terminal.
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) //nolint:gosec // file descriptors fit in an int
+}
+
+// stderrIsTerminal reports whether stderr is a terminal.
+func stderrIsTerminal() bool {
+	return term.IsTerminal(int(os.Stderr.Fd())) //nolint:gosec // file descriptors fit in an int
+}
+
+// terminalWidth returns the width of stdout in columns, or 0 if unknown.
+// $COLUMNS takes precedence, as in most listing tools.
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	width, _, err := term.GetSize(int(os.Stdout.Fd())) //nolint:gosec // file descriptors fit in an int
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// useColor reports whether output should be colored for a color mode
+// ("auto", "always", or "never"). --no-color and $NO_COLOR always win;
+// "auto" colors only when stdout is a terminal.
+func useColor(cfg *internalcfg.Config, mode string, tty bool) bool {
+	if cfg.NoColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	switch mode {
+	case internalcfg.ColorAlways:
+		return true
+	case internalcfg.ColorNever:
+		return false
+	default:
+		return tty
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/meigma/blob-cli/internal/trace"
+)
+
+// traceStderr is the --trace value that writes to stderr.
+const traceStderr = "-"
+
+// tracer holds the active request trace, if --trace was given.
+var tracer struct {
+	transport *trace.Transport
+	w         io.Writer
+	file      *os.File
+}
+
+// startTrace returns base wrapped so each request is logged to dest ("-"
+// for stderr).
+func startTrace(base http.RoundTripper, dest string) (http.RoundTripper, error) {
+	w := io.Writer(os.Stderr)
+	if dest != traceStderr {
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("opening trace file: %w", err)
+		}
+		tracer.file = f
+		w = f
+	}
+	tracer.w = w
+	tracer.transport = trace.NewTransport(base, w)
+	return tracer.transport, nil
+}
+
+// stopTrace writes the trace summary and closes the trace file.
+func stopTrace() {
+	if tracer.transport == nil {
+		return
+	}
+	fmt.Fprintf(tracer.w, "trace: %s\n", tracer.transport.Summary())
+	if tracer.file != nil {
+		_ = tracer.file.Close() //nolint:errcheck // tracing is best-effort
+	}
+}
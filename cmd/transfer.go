@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/meigma/blob-cli/internal/transfer"
+)
+
+// transferCounter counts the registry traffic of this process.
+var transferCounter *transfer.Transport
+
+// countTransfers returns base wrapped so the traffic sent through it is
+// counted for transferStats.
+func countTransfers(base http.RoundTripper) http.RoundTripper {
+	transferCounter = transfer.NewTransport(base)
+	return transferCounter
+}
+
+// transferStats returns the registry traffic and cache hits of this
+// process so far, for the transfer block of JSON results.
+func transferStats() *transfer.Stats {
+	var s transfer.Stats
+	if transferCounter != nil {
+		s = transferCounter.Stats()
+	}
+	cacheStats.mu.Lock()
+	rec := cacheStats.rec
+	cacheStats.mu.Unlock()
+	if rec != nil {
+		for _, c := range rec.Totals() {
+			s.CacheHits += c.Hits
+		}
+	}
+	return &s
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,7 +9,6 @@ import (
 
 	"github.com/meigma/blob"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
@@ -20,24 +20,47 @@ var treeCmd = &cobra.Command{
 	Long: `Display directory structure as a tree.
 
 Shows the hierarchical structure of files and directories in an
-archive, similar to the tree command.`,
+archive, similar to the tree command.
+
+Entries whose name starts with "." are hidden, along with everything
+below hidden directories, unless -a is set or ls.show_hidden is
+enabled in the config file.
+
+For very large archives, --ndjson streams one JSON object per entry
+(with its depth) instead of a nested document; --limit and --offset
+page through the streamed entries.`,
 	Example: `  blob tree ghcr.io/acme/configs:v1.0.0
-  blob tree -L 2 ghcr.io/acme/configs:v1.0.0 /etc`,
-	Args: cobra.RangeArgs(1, 2),
+  blob tree -L 2 ghcr.io/acme/configs:v1.0.0 /etc
+  blob tree -d ghcr.io/acme/configs:v1.0.0
+  blob tree -a ghcr.io/acme/configs:v1.0.0
+  blob tree --ndjson --limit 1000 ghcr.io/acme/data:v1`,
+	Args: defaultRefArgs(cobra.RangeArgs(1, 2)),
 	RunE: runTree,
 }
 
 func init() {
 	treeCmd.Flags().IntP("level", "L", 0, "descend only n levels deep (0 = unlimited)")
 	treeCmd.Flags().Bool("dirsfirst", false, "list directories before files")
+	treeCmd.Flags().BoolP("dirs-only", "d", false, "list directories only")
+	treeCmd.Flags().BoolP("all", "a", false, "include entries whose name starts with \".\"")
+	treeCmd.Flags().Bool("ndjson", false, "stream entries as newline-delimited JSON")
+	treeCmd.Flags().Int("limit", 0, "with --ndjson, show at most n entries (0 = unlimited)")
+	treeCmd.Flags().Int("offset", 0, "with --ndjson, skip the first n entries")
 	treeCmd.Flags().Bool("skip-cache", false, "bypass registry caches for this operation")
+	addNoVerifyFlag(treeCmd)
 }
 
 // treeFlags holds the parsed command flags.
 type treeFlags struct {
 	level     int
 	dirsFirst bool
+	dirsOnly  bool
+	all       bool
+	ndjson    bool
+	limit     int
+	offset    int
 	skipCache bool
+	noVerify  bool
 }
 
 // treeResult contains the tree output data for JSON format.
@@ -47,6 +70,15 @@ type treeResult struct {
 	Root      *treeNode `json:"root"`
 	DirCount  int       `json:"directory_count"`
 	FileCount int       `json:"file_count"`
+	verificationStatus
+}
+
+// treeLine represents a single entry in NDJSON output.
+type treeLine struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	IsDir bool   `json:"is_dir"`
+	Depth int    `json:"depth"`
 }
 
 // treeNode represents a single node in the JSON tree.
@@ -62,8 +94,15 @@ func runTree(cmd *cobra.Command, args []string) error {
 	if cfg == nil {
 		return errors.New("configuration not loaded")
 	}
+	args, err := withDefaultRef(cfg, args)
+	if err != nil {
+		return err
+	}
 
-	ref := cfg.ResolveAlias(args[0])
+	ref, err := resolveRef(cmd.Context(), cfg, args[0])
+	if err != nil {
+		return err
+	}
 	dirPath := "/"
 	if len(args) > 1 {
 		dirPath = args[1]
@@ -73,7 +112,14 @@ func runTree(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if !cmd.Flags().Changed("all") {
+		flags.all = cfg.Ls.ShowHidden
+	}
 
+	policyOpts, verification, err := readPolicyOpts(cfg, ref, flags.noVerify)
+	if err != nil {
+		return err
+	}
 	var opts archive.InspectOptions
 	if flags.skipCache {
 		opts.ClientOpts = clientOptsNoCache(cfg)
@@ -81,23 +127,33 @@ func runTree(cmd *cobra.Command, args []string) error {
 	} else {
 		opts.ClientOpts = clientOpts(cfg)
 	}
+	opts.ClientOpts = append(opts.ClientOpts, policyOpts...)
 
 	result, err := archive.InspectWithOptions(cmd.Context(), ref, opts)
 	if err != nil {
-		return err
+		return archiveError(err)
 	}
 
 	root, err := archive.BuildTree(result.Index(), dirPath, flags.level)
 	if err != nil {
 		return err
 	}
+	if !flags.all {
+		archive.PruneHidden(root)
+	}
+	if flags.dirsOnly {
+		archive.PruneFiles(root)
+	}
 
 	if cfg.Quiet {
 		return nil
 	}
 
-	if viper.GetString("output") == internalcfg.OutputJSON {
-		return treeJSON(ref, dirPath, root, flags)
+	if flags.ndjson {
+		return treeNDJSON(root, flags)
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		return treeJSON(ref, dirPath, root, flags, verification)
 	}
 	return treeText(root, flags)
 }
@@ -116,23 +172,52 @@ func parseTreeFlags(cmd *cobra.Command) (treeFlags, error) {
 		return flags, fmt.Errorf("reading dirsfirst flag: %w", err)
 	}
 
+	flags.dirsOnly, err = cmd.Flags().GetBool("dirs-only")
+	if err != nil {
+		return flags, fmt.Errorf("reading dirs-only flag: %w", err)
+	}
+
+	flags.all, err = cmd.Flags().GetBool("all")
+	if err != nil {
+		return flags, fmt.Errorf("reading all flag: %w", err)
+	}
+
+	flags.ndjson, err = cmd.Flags().GetBool("ndjson")
+	if err != nil {
+		return flags, fmt.Errorf("reading ndjson flag: %w", err)
+	}
+
+	flags.limit, flags.offset, err = parsePageFlags(cmd)
+	if err != nil {
+		return flags, err
+	}
+	if (flags.limit > 0 || flags.offset > 0) && !flags.ndjson {
+		return flags, errors.New("--limit and --offset require --ndjson")
+	}
+
 	flags.skipCache, err = cmd.Flags().GetBool("skip-cache")
 	if err != nil {
 		return flags, fmt.Errorf("reading skip-cache flag: %w", err)
 	}
 
+	flags.noVerify, err = cmd.Flags().GetBool("no-verify")
+	if err != nil {
+		return flags, fmt.Errorf("reading no-verify flag: %w", err)
+	}
+
 	return flags, nil
 }
 
-func treeJSON(ref, dirPath string, root *archive.DirEntry, flags treeFlags) error {
+func treeJSON(ref, dirPath string, root *archive.DirEntry, flags treeFlags, verification verificationStatus) error {
 	dirs, files := archive.Counts(root)
 
 	result := treeResult{
-		Ref:       ref,
-		Path:      dirPath,
-		Root:      convertToTreeNode(root, flags.dirsFirst),
-		DirCount:  dirs,
-		FileCount: files,
+		Ref:                ref,
+		Path:               dirPath,
+		Root:               convertToTreeNode(root, flags.dirsFirst),
+		DirCount:           dirs,
+		FileCount:          files,
+		verificationStatus: verification,
 	}
 
 	enc := json.NewEncoder(os.Stdout)
@@ -140,6 +225,30 @@ func treeJSON(ref, dirPath string, root *archive.DirEntry, flags treeFlags) erro
 	return enc.Encode(result)
 }
 
+// treeNDJSON writes one JSON object per entry in depth-first order.
+func treeNDJSON(root *archive.DirEntry, flags treeFlags) error {
+	w := bufio.NewWriter(os.Stdout)
+	enc := json.NewEncoder(w)
+
+	var index, written int
+	for entry, depth := range archive.Walk(root, flags.dirsFirst) {
+		index++
+		if index <= flags.offset {
+			continue
+		}
+		if flags.limit > 0 && written >= flags.limit {
+			break
+		}
+		line := treeLine{Name: entry.Name, Path: entry.Path, IsDir: entry.IsDir, Depth: depth}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+		written++
+	}
+
+	return w.Flush()
+}
+
 func convertToTreeNode(entry *archive.DirEntry, dirsFirst bool) *treeNode {
 	node := &treeNode{
 		Name:  entry.Name,
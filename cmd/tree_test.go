@@ -7,7 +7,6 @@ import (
 	"os"
 	"testing"
 
-	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -15,8 +14,6 @@ import (
 )
 
 func TestTreeCmd_NilConfig(t *testing.T) {
-	viper.Reset()
-
 	ctx := context.Background()
 
 	treeCmd.SetContext(ctx)
@@ -157,7 +154,7 @@ func TestTreeJSON(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err := treeJSON("ghcr.io/test:v1", "/", root, flags)
+	err := treeJSON("ghcr.io/test:v1", "/", root, flags, verificationStatus{})
 
 	w.Close()
 	os.Stdout = oldStdout
@@ -251,3 +248,55 @@ func TestConvertToTreeNode_DirsFirst(t *testing.T) {
 	assert.Equal(t, "file.txt", node.Children[1].Name)
 	assert.False(t, node.Children[1].IsDir)
 }
+
+func TestTreeNDJSON(t *testing.T) {
+	root := &archive.DirEntry{
+		Name:  ".",
+		IsDir: true,
+		Children: []*archive.DirEntry{
+			{
+				Name:  "config",
+				Path:  "config",
+				IsDir: true,
+				Children: []*archive.DirEntry{
+					{Name: "app.yaml", Path: "config/app.yaml"},
+				},
+			},
+			{Name: "README.md", Path: "README.md"},
+		},
+	}
+	flags := treeFlags{ndjson: true, offset: 1, limit: 1}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := treeNDJSON(root, flags)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 1)
+
+	var got treeLine
+	require.NoError(t, json.Unmarshal(lines[0], &got))
+	assert.Equal(t, "config/app.yaml", got.Path)
+	assert.Equal(t, 2, got.Depth)
+	assert.False(t, got.IsDir)
+}
+
+func TestParseTreeFlags_PageRequiresNDJSON(t *testing.T) {
+	t.Cleanup(func() {
+		_ = treeCmd.Flags().Set("limit", "0")
+	})
+
+	require.NoError(t, treeCmd.Flags().Set("limit", "5"))
+	_, err := parseTreeFlags(treeCmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "require --ndjson")
+}
@@ -6,14 +6,19 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/meigma/blob"
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/meigma/blob-cli/internal/archive"
 	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/evidence"
 	"github.com/meigma/blob-cli/internal/policy"
+	"github.com/meigma/blob-cli/internal/rekor"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 const (
@@ -31,13 +36,63 @@ for signatures and attestations. Policies can be specified as
 YAML files or OPA Rego policies.
 
 If no policies are specified (via flags or config), verification
-succeeds with a warning that no verification was performed.`,
+succeeds with a warning that no verification was performed.
+
+With --save-evidence, everything used for the decision is written to
+a directory for long-term audit storage: the manifest, signature and
+attestation bundles (including certificates and Rekor proofs), the
+Sigstore trusted root, the policy documents, and the decision itself.
+The evidence is saved whether verification passes or fails.
+
+With --from-evidence, verification is repeated entirely from a saved
+evidence directory, without contacting the registry or Sigstore
+services. The recorded policies are re-evaluated against the recorded
+material; additional --policy files are evaluated too, and
+--policy-rego replaces a recorded Rego policy. The reference argument
+is optional and, if given, must match the recorded reference or digest.
+
+Tags are mutable: a result for a tag says nothing about what the tag
+points to once it moves. Verifying a tag prints a warning; pin the
+reference by digest (ref@sha256:...) to verify exactly one archive, or
+use --require-digest to reject tag references.
+
+With --output junit, the result is written as a JUnit XML report with
+one test case per policy, for CI systems that display test results.
+Every policy is evaluated and reported, not only the first failure;
+the exit code is the same as for text output.
+
+With --all-tags, the argument names a repository and every tag in it
+is verified against the policies, for periodic compliance sweeps. The
+results are reported together, one line per tag (or one JUnit test
+suite per tag), and the command exits with code 5 if any tag fails.
+Tags that are not blob archives, such as signature tags, are skipped.
+
+With --rekor-search, signatures are also looked up in the Rekor
+transparency log when the registry does not support the referrers API
+or has no signatures for the archive. Entries recording the manifest
+digest are turned into Sigstore bundles and checked by the policies as
+if they had been attached, so the same identity requirements apply.
+Only keyless signatures are found this way; --rekor-url (or
+sigstore.rekor_url in the config) selects a private Rekor instance.
+
+Requests to Sigstore services, for the trusted root and Rekor searches,
+are retried with backoff. If a service stays unavailable, verify exits
+with code 9 and, with --output json, status "infrastructure_error",
+rather than reporting a policy failure, so CI can retry the job.`,
 	Example: `  blob verify ghcr.io/acme/configs:v1.0.0
+  blob verify ghcr.io/acme/configs@sha256:4f1c...
+  blob verify --require-digest --policy policy.yaml ghcr.io/acme/configs@sha256:4f1c...
   blob verify --policy policy.yaml ghcr.io/acme/configs:v1.0.0
   blob verify --policy-rego custom.rego ghcr.io/acme/configs:v1.0.0
-  blob verify --no-default-policy --policy policy.yaml ghcr.io/acme/configs:v1.0.0`,
-	Args: cobra.ExactArgs(1),
-	RunE: runVerify,
+  blob verify --no-default-policy --policy policy.yaml ghcr.io/acme/configs:v1.0.0
+  blob verify --save-evidence ./evidence/configs-v1.0.0 ghcr.io/acme/configs:v1.0.0
+  blob verify --from-evidence ./evidence/configs-v1.0.0
+  blob verify --output junit ghcr.io/acme/configs:v1.0.0 > verify.xml
+  blob verify --all-tags --policy policy.yaml ghcr.io/acme/configs --output json
+  blob verify --rekor-search --policy policy.yaml registry.example.com/acme/configs@sha256:4f1c...`,
+	Args:        cobra.RangeArgs(0, 1),
+	Annotations: outputFormats(internalcfg.OutputJUnit),
+	RunE:        runVerify,
 }
 
 func init() {
@@ -45,6 +100,16 @@ func init() {
 	verifyCmd.Flags().String("policy-rego", "", "OPA Rego policy file")
 	verifyCmd.Flags().Bool("no-default-policy", false, "skip policies from config file")
 	verifyCmd.Flags().Bool("skip-cache", false, "bypass registry caches for this operation")
+	verifyCmd.Flags().String("save-evidence", "", "write verification evidence to this directory")
+	verifyCmd.Flags().String("from-evidence", "", "verify offline from a saved evidence directory")
+	verifyCmd.Flags().Bool("require-digest", false, "fail unless the reference is pinned by digest")
+	verifyCmd.Flags().Bool("all-tags", false, "verify every tag in the repository")
+	verifyCmd.Flags().Bool("rekor-search", false, "search Rekor for signatures the registry does not have")
+	verifyCmd.MarkFlagsMutuallyExclusive("save-evidence", "from-evidence")
+	verifyCmd.MarkFlagsMutuallyExclusive("all-tags", "save-evidence")
+	verifyCmd.MarkFlagsMutuallyExclusive("all-tags", "from-evidence")
+	verifyCmd.MarkFlagsMutuallyExclusive("all-tags", "require-digest")
+	verifyCmd.MarkFlagsMutuallyExclusive("rekor-search", "from-evidence")
 }
 
 // verifyResult contains the result of a verify operation.
@@ -53,10 +118,21 @@ type verifyResult struct {
 	ResolvedRef     string         `json:"resolved_ref,omitempty"`
 	Digest          string         `json:"digest"`
 	Verified        bool           `json:"verified"`
-	Status          string         `json:"status"` // "verified", "no_policies"
+	Status          string         `json:"status"` // "verified", "no_policies", "infrastructure_error"
+	Error           string         `json:"error,omitempty"`
 	PoliciesApplied int            `json:"policies_applied"`
 	Signatures      []referrerInfo `json:"signatures,omitempty"`
 	Attestations    []referrerInfo `json:"attestations,omitempty"`
+	RekorEntries    []string       `json:"rekor_entries,omitempty"`
+	Evidence        string         `json:"evidence,omitempty"`
+	Offline         bool           `json:"offline,omitempty"`
+	MutableRef      bool           `json:"mutable_ref,omitempty"`
+
+	// outcomes and started are recorded for --output junit, and skipped
+	// explains a reference that was not verified.
+	outcomes []policyOutcome
+	started  time.Time
+	skipped  string
 }
 
 // verifyFlags holds the parsed command flags.
@@ -65,6 +141,11 @@ type verifyFlags struct {
 	policyRego      string
 	noDefaultPolicy bool
 	skipCache       bool
+	saveEvidence    string
+	fromEvidence    string
+	requireDigest   bool
+	allTags         bool
+	rekorSearch     bool
 }
 
 func runVerify(cmd *cobra.Command, args []string) error {
@@ -74,34 +155,85 @@ func runVerify(cmd *cobra.Command, args []string) error {
 		return errors.New("configuration not loaded")
 	}
 
-	// 2. Parse arguments
-	inputRef := args[0]
-
-	// 3. Parse flags
+	// 2. Parse flags
 	flags, err := parseVerifyFlags(cmd)
 	if err != nil {
 		return err
 	}
 
+	junitOutput := cfg.Output == internalcfg.OutputJUnit
+
+	// 3. Parse arguments (offline verification needs no reference)
+	if flags.fromEvidence != "" {
+		if junitOutput {
+			return errors.New("--output junit is not supported with --from-evidence")
+		}
+		return runVerifyFromEvidence(cmd, cfg, args, &flags)
+	}
+	if flags.allTags {
+		return runVerifyAllTags(cmd, cfg, args, &flags)
+	}
+	if len(args) == 0 && cfg.DefaultRef == "" {
+		return errors.New("requires a reference argument (or --from-evidence)")
+	}
+	args, err = withDefaultRef(cfg, args)
+	if err != nil {
+		return err
+	}
+	inputRef := args[0]
+
 	// 4. Resolve alias
-	resolvedRef := cfg.ResolveAlias(inputRef)
+	resolvedRef, err := resolveRef(cmd.Context(), cfg, inputRef)
+	if err != nil {
+		return err
+	}
+
+	// A tag can move after verification; only a digest names one archive.
+	mutable := !isDigestRef(resolvedRef)
+	if mutable {
+		if flags.requireDigest {
+			return &ExitError{
+				Code: exitCodePolicyViolation,
+				Err:  fmt.Errorf("--require-digest: %s is not pinned by digest (use ref@sha256:...)", resolvedRef),
+			}
+		}
+		warn.Printf("%s is a mutable tag; the result no longer applies if the tag moves. Verify by digest to pin it.", resolvedRef)
+	}
 
 	// 5. Build policies from config + flags
-	policies, err := policy.BuildPolicies(
+	var buildOpts []policy.BuildOption
+	var trustedRoot []byte
+	if flags.saveEvidence != "" {
+		buildOpts, trustedRoot = evidenceTrustedRoot(cfg)
+	}
+	policies, err := policy.BuildNamedPolicies(
 		cfg,
 		resolvedRef,
 		flags.policyFiles,
 		flags.policyRego,
 		flags.noDefaultPolicy,
+		buildOpts...,
 	)
 	if err != nil {
+		if isInfrastructureError(err) {
+			result := verifyResult{Ref: inputRef}
+			if inputRef != resolvedRef {
+				result.ResolvedRef = resolvedRef
+			}
+			return verifyInfrastructureError(cfg, &result, err)
+		}
 		return fmt.Errorf("building policies: %w", err)
 	}
+	if flags.saveEvidence != "" && len(policies) == 0 {
+		return errors.New("--save-evidence requires at least one policy")
+	}
 
 	// 6. Build result
 	result := verifyResult{
 		Ref:             inputRef,
 		PoliciesApplied: len(policies),
+		MutableRef:      mutable,
+		started:         time.Now(),
 	}
 	if inputRef != resolvedRef {
 		result.ResolvedRef = resolvedRef
@@ -113,8 +245,31 @@ func runVerify(cmd *cobra.Command, args []string) error {
 	}
 
 	// 8. Create client with policies for verification
+	var recorder *evidence.Recorder
+	if flags.saveEvidence != "" {
+		recorder = evidence.NewRecorder()
+	}
+	var outcomes *policyOutcomes
+	if junitOutput {
+		outcomes = &policyOutcomes{}
+	}
+	fallback := rekorFallback(cfg, &flags)
+	tagged := tagReferrers(cfg)
+	infra := &infrastructureErrors{}
 	policyOpts := make([]blob.Option, 0, len(policies))
-	for _, p := range policies {
+	for _, np := range policies {
+		np.Policy = infra.wrap(np.Policy)
+		p := np.Policy
+		if outcomes != nil {
+			p = outcomes.wrap(np)
+		}
+		if recorder != nil {
+			p = recorder.Wrap(p)
+		}
+		if fallback != nil {
+			p = fallback.Wrap(p)
+		}
+		p = tagged.Wrap(p)
 		policyOpts = append(policyOpts, blob.WithPolicy(p))
 	}
 
@@ -136,8 +291,46 @@ func runVerify(cmd *cobra.Command, args []string) error {
 		inspectOpts = append(inspectOpts, blob.InspectWithSkipCache())
 	}
 	inspectResult, err := client.Inspect(ctx, resolvedRef, inspectOpts...)
+	result.RekorEntries = reportRekorSearch(fallback)
+	if infraErr := infra.first(); infraErr != nil {
+		return verifyInfrastructureError(cfg, &result, infraErr)
+	}
+	if outcomes != nil {
+		result.outcomes = outcomes.outcomes
+		if err == nil {
+			if failed := outcomes.failed(); failed != nil {
+				err = fmt.Errorf("%w: %w", blob.ErrPolicyViolation, failed)
+			}
+		}
+	}
+	if recorder != nil {
+		decision := evidence.Decision{
+			Verified:        err == nil,
+			Status:          "verified",
+			PoliciesApplied: len(policies),
+		}
+		if err != nil {
+			decision.Status = "failed"
+			decision.Error = err.Error()
+		}
+		src := evidenceSources(cfg, resolvedRef, &flags, trustedRoot)
+		if _, saveErr := recorder.Save(flags.saveEvidence, src, decision); saveErr != nil {
+			return fmt.Errorf("saving evidence: %w", saveErr)
+		}
+		result.Evidence = flags.saveEvidence
+	}
 	if err != nil {
 		if errors.Is(err, blob.ErrPolicyViolation) {
+			if junitOutput {
+				// Policies are recorded rather than enforced in junit
+				// mode, so the archive was still inspected.
+				if inspectResult != nil {
+					result.Digest = inspectResult.Digest()
+				}
+				if outErr := outputVerifyResult(cfg, &result); outErr != nil {
+					return outErr
+				}
+			}
 			return &ExitError{
 				Code: exitCodePolicyViolation,
 				Err:  fmt.Errorf("verification failed: %w", err),
@@ -152,11 +345,29 @@ func runVerify(cmd *cobra.Command, args []string) error {
 	result.Status = "verified"
 
 	// Fetch referrers for signatures/attestations
-	populateReferrers(ctx, inspectResult, &result)
+	populateReferrers(ctx, cfg, resolvedRef, inspectResult, &result)
 
 	return outputVerifyResult(cfg, &result)
 }
 
+// verifyInfrastructureError reports verification that could not be
+// completed because a Sigstore service was unavailable. It exits with its
+// own code and, in JSON, status "infrastructure_error", so CI can retry
+// the job instead of treating it as a policy failure.
+func verifyInfrastructureError(cfg *internalcfg.Config, result *verifyResult, err error) error {
+	result.Status = verifyStatusInfrastructure
+	result.Error = err.Error()
+	if cfg.Output == internalcfg.OutputJSON && !cfg.Quiet {
+		if outErr := verifyJSON(result); outErr != nil {
+			return outErr
+		}
+	}
+	return &ExitError{
+		Code: exitCodeInfrastructure,
+		Err:  fmt.Errorf("verification incomplete, Sigstore is unavailable: %w", err),
+	}
+}
+
 // parseVerifyFlags extracts and validates flags from the command.
 func parseVerifyFlags(cmd *cobra.Command) (verifyFlags, error) {
 	var flags verifyFlags
@@ -182,9 +393,70 @@ func parseVerifyFlags(cmd *cobra.Command) (verifyFlags, error) {
 		return flags, fmt.Errorf("reading skip-cache flag: %w", err)
 	}
 
+	flags.saveEvidence, err = cmd.Flags().GetString("save-evidence")
+	if err != nil {
+		return flags, fmt.Errorf("reading save-evidence flag: %w", err)
+	}
+
+	flags.fromEvidence, err = cmd.Flags().GetString("from-evidence")
+	if err != nil {
+		return flags, fmt.Errorf("reading from-evidence flag: %w", err)
+	}
+	if flags.saveEvidence != "" && flags.fromEvidence != "" {
+		return flags, errors.New("--save-evidence and --from-evidence are mutually exclusive")
+	}
+
+	flags.requireDigest, err = cmd.Flags().GetBool("require-digest")
+	if err != nil {
+		return flags, fmt.Errorf("reading require-digest flag: %w", err)
+	}
+
+	flags.allTags, err = cmd.Flags().GetBool("all-tags")
+	if err != nil {
+		return flags, fmt.Errorf("reading all-tags flag: %w", err)
+	}
+
+	flags.rekorSearch, err = cmd.Flags().GetBool("rekor-search")
+	if err != nil {
+		return flags, fmt.Errorf("reading rekor-search flag: %w", err)
+	}
+
 	return flags, nil
 }
 
+// rekorFallback returns the Rekor fallback for --rekor-search, or nil.
+// It searches the configured Rekor instance.
+func rekorFallback(cfg *internalcfg.Config, flags *verifyFlags) *rekor.Fallback {
+	if !flags.rekorSearch {
+		return nil
+	}
+	return rekor.NewFallback(&rekor.Client{BaseURL: cfg.Sigstore.RekorURL})
+}
+
+// reportRekorSearch returns the Rekor entries offered to policies. If
+// entries were found but none could be used, it warns why.
+func reportRekorSearch(fallback *rekor.Fallback) []string {
+	if fallback == nil {
+		return nil
+	}
+	entries := fallback.Entries()
+	if len(entries) == 0 {
+		for _, err := range fallback.Skipped() {
+			warn.Printf("rekor: %v", err)
+		}
+	}
+	return entries
+}
+
+// isDigestRef reports whether ref is pinned by a valid digest.
+func isDigestRef(ref string) bool {
+	idx := strings.LastIndex(ref, "@")
+	if idx == -1 {
+		return false
+	}
+	return digest.Digest(ref[idx+1:]).Validate() == nil
+}
+
 // handleNoPolicies handles the case where no policies are specified.
 func handleNoPolicies(cmd *cobra.Command, cfg *internalcfg.Config, resolvedRef string, result *verifyResult, skipCache bool) error {
 	var opts archive.InspectOptions
@@ -204,29 +476,27 @@ func handleNoPolicies(cmd *cobra.Command, cfg *internalcfg.Config, resolvedRef s
 	result.Verified = false
 	result.Status = "no_policies"
 
-	populateReferrers(cmd.Context(), inspectResult, result)
+	populateReferrers(cmd.Context(), cfg, resolvedRef, inspectResult, result)
 
-	if !cfg.Quiet && viper.GetString("output") != internalcfg.OutputJSON {
-		fmt.Fprintln(os.Stderr, "Warning: No policies applied - archive not verified")
-	}
+	warn.Printf("No policies applied - archive not verified")
 
 	return outputVerifyResult(cfg, result)
 }
 
 // populateReferrers fetches signatures and attestations and adds them to the result.
-func populateReferrers(ctx context.Context, inspectResult *blob.InspectResult, result *verifyResult) {
-	signatures, sigErr := inspectResult.Referrers(ctx, sigstoreArtifactType)
+func populateReferrers(ctx context.Context, cfg *internalcfg.Config, ref string, inspectResult *blob.InspectResult, result *verifyResult) {
+	signatures, sigErr := inspectReferrers(ctx, cfg, ref, inspectResult, sigstoreArtifactType)
 	if sigErr == nil {
 		result.Signatures = convertBlobReferrers(signatures)
 	} else if !errors.Is(sigErr, blob.ErrReferrersUnsupported) {
-		fmt.Fprintf(os.Stderr, "Warning: failed to fetch signatures: %v\n", sigErr)
+		warn.Printf("failed to fetch signatures: %v", sigErr)
 	}
 
-	attestations, attErr := inspectResult.Referrers(ctx, inTotoArtifactType)
+	attestations, attErr := inspectReferrers(ctx, cfg, ref, inspectResult, inTotoArtifactType)
 	if attErr == nil {
 		result.Attestations = convertBlobReferrers(attestations)
 	} else if !errors.Is(attErr, blob.ErrReferrersUnsupported) {
-		fmt.Fprintf(os.Stderr, "Warning: failed to fetch attestations: %v\n", attErr)
+		warn.Printf("failed to fetch attestations: %v", attErr)
 	}
 }
 
@@ -251,10 +521,14 @@ func outputVerifyResult(cfg *internalcfg.Config, result *verifyResult) error {
 	if cfg.Quiet {
 		return nil
 	}
-	if viper.GetString("output") == internalcfg.OutputJSON {
+	switch cfg.Output {
+	case internalcfg.OutputJSON:
 		return verifyJSON(result)
+	case internalcfg.OutputJUnit:
+		return writeJUnit(os.Stdout, newJUnitSuite(result))
+	default:
+		return verifyText(result)
 	}
-	return verifyText(result)
 }
 
 func verifyJSON(result *verifyResult) error {
@@ -287,6 +561,14 @@ func verifyText(result *verifyResult) error {
 		}
 	}
 
+	if len(result.RekorEntries) > 0 {
+		fmt.Println()
+		fmt.Println("Rekor entries:")
+		for _, uuid := range result.RekorEntries {
+			fmt.Printf("  %s\n", uuid)
+		}
+	}
+
 	if len(result.Attestations) > 0 {
 		fmt.Println()
 		fmt.Println("Attestations:")
@@ -295,5 +577,14 @@ func verifyText(result *verifyResult) error {
 		}
 	}
 
+	switch {
+	case result.Offline:
+		fmt.Println()
+		fmt.Printf("Verified offline from evidence in %s\n", result.Evidence)
+	case result.Evidence != "":
+		fmt.Println()
+		fmt.Printf("Evidence saved to %s\n", result.Evidence)
+	}
+
 	return nil
 }
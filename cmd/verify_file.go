@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/meigma/blob"
+	"github.com/spf13/cobra"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+var verifyFileCmd = &cobra.Command{
+	Use:   "verify-file <ref>:<path> <file>",
+	Short: "Check a local file against its digest in an archive",
+	Long: `Check a local file against its digest in an archive.
+
+Hashes the local file and compares its SHA-256 digest with the one the
+archive index records for the path, without downloading any file data.
+The manifest is checked against the config policies first, as pull
+does, so a match means the file is what the signed archive holds;
+--no-verify skips them.
+
+Exits with code 0 if the file matches, 8 if it does not, 7 if the path
+is not a file in the archive, and 5 if the archive fails the policies.
+A pinned archive (blob cache pin) is checked without the registry.`,
+	Example: `  blob verify-file ghcr.io/acme/configs:v1.0.0:/etc/app.conf ./app.conf
+  blob verify-file foo:v1:/etc/app.conf /etc/app.conf      # Using alias
+  blob verify-file --output json foo:v1:/bin/tool ./tool`,
+	Args: cobra.ExactArgs(2),
+	RunE: runVerifyFile,
+}
+
+func init() {
+	verifyFileCmd.Flags().Bool("skip-cache", false, "bypass registry caches for this operation")
+	addNoVerifyFlag(verifyFileCmd)
+}
+
+// verifyFileResult contains the verify-file output data.
+type verifyFileResult struct {
+	Ref         string `json:"ref"`
+	ResolvedRef string `json:"resolved_ref,omitempty"`
+	Digest      string `json:"digest"`
+	Path        string `json:"path"`
+	File        string `json:"file"`
+	Status      string `json:"status"` // "ok" or "mismatch"
+	Expected    string `json:"expected"`
+	Actual      string `json:"actual"`
+	verificationStatus
+}
+
+func runVerifyFile(cmd *cobra.Command, args []string) error {
+	cfg := internalcfg.FromContext(cmd.Context())
+	if cfg == nil {
+		return errors.New("configuration not loaded")
+	}
+
+	skipCache, err := cmd.Flags().GetBool("skip-cache")
+	if err != nil {
+		return fmt.Errorf("reading skip-cache flag: %w", err)
+	}
+	noVerify, err := cmd.Flags().GetBool("no-verify")
+	if err != nil {
+		return fmt.Errorf("reading no-verify flag: %w", err)
+	}
+
+	src, err := parseSourceArg(args[0], cfg)
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	if err := resolveSemverRefs(ctx, cfg, &src.ref); err != nil {
+		return err
+	}
+
+	// Hash the local file first: it is the cheapest thing to get wrong
+	local := args[1]
+	if info, err := os.Stat(local); err != nil {
+		return fmt.Errorf("reading %s: %w", local, err)
+	} else if info.IsDir() {
+		return fmt.Errorf("%s is a directory", local)
+	}
+	actual, err := fileDigest(local)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", local, err)
+	}
+
+	index, digest, verification, err := verifyFileIndex(ctx, cfg, src.ref, skipCache, noVerify)
+	if err != nil {
+		return err
+	}
+	archivePath := blob.NormalizePath(src.path)
+	entry, ok := index.Entry(archivePath)
+	if !ok || entry.Mode().IsDir() {
+		return pathNotFoundError(fmt.Errorf("file not found in archive: %s", src.path))
+	}
+
+	result := verifyFileResult{
+		Ref:                src.inputRef,
+		Digest:             digest,
+		Path:               "/" + archivePath,
+		File:               local,
+		Status:             checksumOK,
+		Expected:           "sha256:" + hex.EncodeToString(entry.HashBytes()),
+		Actual:             actual,
+		verificationStatus: verification,
+	}
+	if src.ref != src.inputRef {
+		result.ResolvedRef = src.ref
+	}
+	if result.Actual != result.Expected {
+		result.Status = checksumMismatch
+	}
+
+	if err := outputVerifyFileResult(cfg, &result); err != nil {
+		return err
+	}
+	if result.Status != checksumOK {
+		return &ExitError{
+			Code: exitCodeChecksum,
+			Err:  fmt.Errorf("%s does not match %s in %s", local, result.Path, src.inputRef),
+		}
+	}
+	return nil
+}
+
+// verifyFileIndex returns the index and manifest digest of the archive at
+// ref, from a pinned archive if there is one. Otherwise the manifest is
+// checked against the policies for ref and the index is fetched by digest,
+// so it is the one that was checked.
+func verifyFileIndex(ctx context.Context, cfg *internalcfg.Config, ref string, skipCache, noVerify bool) (*blob.IndexView, string, verificationStatus, error) {
+	if !skipCache {
+		if index, pin, ok := pinnedIndex(cfg, ref); ok {
+			return index, pin.Digest, verificationStatus{Verified: pin.Verified}, nil
+		}
+	}
+
+	policyOpts, verification, err := readPolicyOpts(cfg, ref, noVerify)
+	if err != nil {
+		return nil, "", verificationStatus{}, err
+	}
+	var client *blob.Client
+	if skipCache {
+		client, err = blob.NewClient(append(clientOptsNoCache(cfg), policyOpts...)...)
+	} else {
+		client, err = newClient(cfg, policyOpts...)
+	}
+	if err != nil {
+		return nil, "", verificationStatus{}, fmt.Errorf("creating client: %w", err)
+	}
+	manifest, err := fetchPullManifest(ctx, client, ref, skipCache)
+	if err != nil {
+		return nil, "", verificationStatus{}, archiveError(err)
+	}
+
+	var opts archive.InspectOptions
+	if skipCache {
+		opts.ClientOpts = clientOptsNoCache(cfg)
+		opts.InspectOpts = []blob.InspectOption{blob.InspectWithSkipCache()}
+	} else {
+		opts.ClientOpts = clientOpts(cfg)
+	}
+	result, err := archive.InspectWithOptions(ctx, repositoryOf(ref)+"@"+manifest.Digest(), opts)
+	if err != nil {
+		return nil, "", verificationStatus{}, archiveError(err)
+	}
+	return result.Index(), manifest.Digest(), verification, nil
+}
+
+// outputVerifyFileResult formats and outputs the verify-file result.
+func outputVerifyFileResult(cfg *internalcfg.Config, result *verifyFileResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	if cfg.Output == internalcfg.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	if result.Status == checksumOK {
+		fmt.Printf("OK %s matches %s\n", result.File, result.Path)
+	} else {
+		fmt.Printf("MISMATCH %s does not match %s\n", result.File, result.Path)
+	}
+	fmt.Printf("  Archive: %s\n", result.Ref)
+	if result.ResolvedRef != "" {
+		fmt.Printf("  Resolved: %s\n", result.ResolvedRef)
+	}
+	fmt.Printf("  Manifest: %s\n", result.Digest)
+	fmt.Printf("  Expected: %s\n", result.Expected)
+	if result.Status != checksumOK {
+		fmt.Printf("  Actual: %s\n", result.Actual)
+	}
+	if result.Verified {
+		fmt.Printf("  Verified: %d policies applied\n", result.PoliciesApplied)
+	} else {
+		fmt.Println("  Verified: no policies applied")
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestVerifyFile(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	repo := reg.repo
+	reg.addArchive(t, "v1", map[string]string{"etc/app.conf": "app"})
+
+	cfg := newRegistryTestConfig(t)
+	verifyFileCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	local := filepath.Join(t.TempDir(), "app.conf")
+
+	require.NoError(t, os.WriteFile(local, []byte("app"), 0o644))
+	require.NoError(t, verifyFileCmd.RunE(verifyFileCmd, []string{repo + ":v1:/etc/app.conf", local}))
+
+	require.NoError(t, os.WriteFile(local, []byte("edited"), 0o644))
+	err := verifyFileCmd.RunE(verifyFileCmd, []string{repo + ":v1:/etc/app.conf", local})
+	requireExitCode(t, err, exitCodeChecksum)
+	data, err := os.ReadFile(local)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", string(data), "the local file is only read")
+
+	for _, path := range []string{"/etc/missing.conf", "/etc"} {
+		err = verifyFileCmd.RunE(verifyFileCmd, []string{repo + ":v1:" + path, local})
+		requireExitCode(t, err, exitCodePathNotFound)
+	}
+
+	err = verifyFileCmd.RunE(verifyFileCmd, []string{repo + ":v1:/etc/app.conf", filepath.Join(t.TempDir(), "missing")})
+	require.ErrorContains(t, err, "reading")
+
+	err = verifyFileCmd.RunE(verifyFileCmd, []string{repo + ":v1", local})
+	require.ErrorContains(t, err, "expected <ref>:<path>")
+}
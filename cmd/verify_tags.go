@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/meigma/blob"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/policy"
+)
+
+// Statuses reported per tag by verify --all-tags, in addition to
+// "verified" and "no_policies". verify reports verifyStatusInfrastructure
+// too.
+const (
+	verifyStatusFailed         = "failed"
+	verifyStatusSkipped        = "skipped"
+	verifyStatusError          = "error"
+	verifyStatusInfrastructure = "infrastructure_error"
+)
+
+// verifyTagsResult contains the aggregated result of verify --all-tags.
+type verifyTagsResult struct {
+	Repository string            `json:"repository"`
+	Total      int               `json:"total"`
+	Verified   int               `json:"verified"`
+	Failed     int               `json:"failed"`
+	NoPolicies int               `json:"no_policies"`
+	Skipped    int               `json:"skipped"`
+	Errors     int               `json:"errors"`
+	Tags       []verifyTagResult `json:"tags"`
+}
+
+// verifyTagResult is the verification result for one tag.
+type verifyTagResult struct {
+	Tag             string               `json:"tag"`
+	Digest          string               `json:"digest,omitempty"`
+	Status          string               `json:"status"` // "verified", "failed", "no_policies", "skipped", "error", "infrastructure_error"
+	PoliciesApplied int                  `json:"policies_applied"`
+	Policies        []verifyPolicyResult `json:"policies,omitempty"`
+	Error           string               `json:"error,omitempty"`
+
+	outcomes []policyOutcome
+	started  time.Time
+}
+
+// verifyPolicyResult is the outcome of one policy for a tag.
+type verifyPolicyResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// runVerifyAllTags verifies every tag in the repository of args[0] and
+// reports the results together. Tags that are not blob archives, such as
+// signature tags, are skipped.
+func runVerifyAllTags(cmd *cobra.Command, cfg *internalcfg.Config, args []string, flags *verifyFlags) error {
+	if len(args) == 0 {
+		return errors.New("--all-tags requires a repository argument")
+	}
+	ctx := cmd.Context()
+	repo := repositoryOf(cfg.ResolveAlias(args[0]))
+
+	tags, err := listTags(ctx, cfg, repo)
+	if err != nil {
+		return err
+	}
+
+	result := verifyTagsResult{Repository: repo, Tags: make([]verifyTagResult, len(tags))}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(tagsInspectJobs)
+	for i, tag := range tags {
+		g.Go(func() error {
+			r, err := verifyTag(gctx, cfg, repo, tag, flags)
+			result.Tags[i] = r
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		if isInfrastructureError(err) {
+			return &ExitError{
+				Code: exitCodeInfrastructure,
+				Err:  fmt.Errorf("verification incomplete, Sigstore is unavailable: %w", err),
+			}
+		}
+		return err
+	}
+
+	infrastructure := 0
+	for _, r := range result.Tags {
+		result.Total++
+		if r.Status == verifyStatusInfrastructure {
+			infrastructure++
+		}
+		switch r.Status {
+		case "verified":
+			result.Verified++
+		case "no_policies":
+			result.NoPolicies++
+		case verifyStatusFailed:
+			result.Failed++
+		case verifyStatusSkipped:
+			result.Skipped++
+		default:
+			result.Errors++
+		}
+	}
+
+	if err := outputVerifyTagsResult(cfg, &result); err != nil {
+		return err
+	}
+	if result.Failed > 0 {
+		return &ExitError{
+			Code: exitCodePolicyViolation,
+			Err:  fmt.Errorf("verification failed for %s in %s", pluralize(result.Failed, "tag", "tags"), repo),
+		}
+	}
+	if infrastructure > 0 {
+		return &ExitError{
+			Code: exitCodeInfrastructure,
+			Err:  fmt.Errorf("could not verify %s in %s: Sigstore is unavailable", pluralize(infrastructure, "tag", "tags"), repo),
+		}
+	}
+	if result.Errors > 0 {
+		return fmt.Errorf("could not verify %s in %s", pluralize(result.Errors, "tag", "tags"), repo)
+	}
+	return nil
+}
+
+// verifyTag verifies one tag, recording the outcome of every policy. Only
+// errors that apply to every tag, such as an unreadable policy file, are
+// returned; everything else is recorded in the result.
+func verifyTag(ctx context.Context, cfg *internalcfg.Config, repo, tag string, flags *verifyFlags) (verifyTagResult, error) {
+	ref := repo + ":" + tag
+	r := verifyTagResult{Tag: tag, started: time.Now()}
+
+	policies, err := policy.BuildNamedPolicies(cfg, ref, flags.policyFiles, flags.policyRego, flags.noDefaultPolicy)
+	if err != nil {
+		return r, fmt.Errorf("building policies: %w", err)
+	}
+	r.PoliciesApplied = len(policies)
+
+	outcomes := &policyOutcomes{}
+	fallback := rekorFallback(cfg, flags)
+	tagged := tagReferrers(cfg)
+	policyOpts := make([]blob.Option, 0, len(policies))
+	for _, np := range policies {
+		p := outcomes.wrap(np)
+		if fallback != nil {
+			p = fallback.Wrap(p)
+		}
+		p = tagged.Wrap(p)
+		policyOpts = append(policyOpts, blob.WithPolicy(p))
+	}
+	var client *blob.Client
+	if flags.skipCache {
+		client, err = blob.NewClient(append(clientOptsNoCache(cfg), policyOpts...)...)
+	} else {
+		client, err = newClient(cfg, policyOpts...)
+	}
+	if err != nil {
+		return r, fmt.Errorf("creating client: %w", err)
+	}
+
+	var inspectOpts []blob.InspectOption
+	if flags.skipCache {
+		inspectOpts = append(inspectOpts, blob.InspectWithSkipCache())
+	}
+	inspectResult, err := client.Inspect(ctx, ref, inspectOpts...)
+	reportRekorSearch(fallback)
+	r.outcomes = outcomes.outcomes
+	switch {
+	case ctx.Err() != nil:
+		return r, ctx.Err()
+	case errors.Is(err, blob.ErrInvalidManifest):
+		r.Status = verifyStatusSkipped
+		r.Error = "not a blob archive"
+		return r, nil
+	case err != nil:
+		r.Status = verifyStatusError
+		r.Error = err.Error()
+		return r, nil
+	}
+	r.Digest = inspectResult.Digest()
+
+	for _, o := range r.outcomes {
+		pr := verifyPolicyResult{Name: o.name, Passed: o.err == nil}
+		if o.err != nil {
+			pr.Error = o.err.Error()
+		}
+		r.Policies = append(r.Policies, pr)
+	}
+	switch failed := outcomes.failed(); {
+	case len(policies) == 0:
+		r.Status = "no_policies"
+	case isInfrastructureError(failed):
+		r.Status = verifyStatusInfrastructure
+		r.Error = failed.Error()
+	case failed != nil:
+		r.Status = verifyStatusFailed
+		r.Error = failed.Error()
+	default:
+		r.Status = "verified"
+	}
+	return r, nil
+}
+
+// outputVerifyTagsResult formats and outputs the verify --all-tags result.
+func outputVerifyTagsResult(cfg *internalcfg.Config, result *verifyTagsResult) error {
+	if cfg.Quiet {
+		return nil
+	}
+	switch cfg.Output {
+	case internalcfg.OutputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	case internalcfg.OutputJUnit:
+		suites := make([]junitTestSuite, 0, len(result.Tags))
+		for _, r := range result.Tags {
+			vr := verifyResult{
+				Ref:      result.Repository + ":" + r.Tag,
+				Digest:   r.Digest,
+				outcomes: r.outcomes,
+				started:  r.started,
+			}
+			switch r.Status {
+			case verifyStatusSkipped:
+				vr.skipped = r.Error
+			case verifyStatusError:
+				vr.outcomes = []policyOutcome{{name: "inspect archive", err: errors.New(r.Error)}}
+			}
+			suites = append(suites, newJUnitSuite(&vr))
+		}
+		return writeJUnit(os.Stdout, suites...)
+	default:
+		return verifyTagsText(result)
+	}
+}
+
+func verifyTagsText(result *verifyTagsResult) error {
+	if len(result.Tags) == 0 {
+		fmt.Printf("No tags in %s.\n", result.Repository)
+		return nil
+	}
+
+	tagWidth := len("TAG")
+	for _, r := range result.Tags {
+		tagWidth = max(tagWidth, len(r.Tag))
+	}
+	fmt.Printf("%-*s  %-11s  %-19s  %s\n", tagWidth, "TAG", "STATUS", "DIGEST", "DETAILS")
+	for _, r := range result.Tags {
+		digest := "-"
+		if r.Digest != "" {
+			digest = shortDigest(r.Digest)
+		}
+		details := r.Error
+		if r.Status == "verified" {
+			details = pluralize(r.PoliciesApplied, "policy", "policies") + " passed"
+		}
+		fmt.Printf("%-*s  %-11s  %-19s  %s\n", tagWidth, r.Tag, r.Status, digest, details)
+	}
+
+	fmt.Println()
+	fmt.Printf("%s: %d verified, %d failed, %d without policies, %d skipped, %d errors\n",
+		pluralize(result.Total, "tag", "tags"), result.Verified, result.Failed, result.NoPolicies, result.Skipped, result.Errors)
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
+)
+
+func TestVerifyCmd_AllTags(t *testing.T) {
+	reg, _ := newTestRegistry(t)
+	reg.add(t, "v1", nil, "")
+	reg.add(t, "v2", nil, "")
+	v3 := reg.addArchive(t, "v3", map[string]string{"etc/app.conf": "app"})
+	repo := reg.repo
+
+	cfg := newRegistryTestConfig(t)
+	cfg.Quiet = false
+	cfg.Output = internalcfg.OutputJSON
+	verifyCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	require.NoError(t, verifyCmd.Flags().Set("all-tags", "true"))
+	t.Cleanup(func() {
+		verifyCmd.Flags().Set("all-tags", "false") //nolint:errcheck // test cleanup
+	})
+
+	out, err := captureStdout(t, func() error { return verifyCmd.RunE(verifyCmd, []string{repo + ":ignored"}) })
+
+	// v1 and v2 are not blob archives; v3 is, with no policies for it
+	require.NoError(t, err)
+	var result verifyTagsResult
+	require.NoError(t, json.Unmarshal(out, &result))
+	assert.Equal(t, repo, result.Repository)
+	assert.Equal(t, 3, result.Total)
+	assert.Equal(t, 2, result.Skipped)
+	assert.Equal(t, 1, result.NoPolicies)
+	require.Len(t, result.Tags, 3)
+	assert.Equal(t, "v1", result.Tags[0].Tag)
+	assert.Equal(t, verifyStatusSkipped, result.Tags[0].Status)
+	assert.Equal(t, "not a blob archive", result.Tags[0].Error)
+	assert.Equal(t, "v3", result.Tags[2].Tag)
+	assert.Equal(t, "no_policies", result.Tags[2].Status)
+	assert.Equal(t, v3.Digest.String(), result.Tags[2].Digest)
+}
+
+func TestVerifyTagsText(t *testing.T) {
+	result := verifyTagsResult{
+		Repository: "ghcr.io/acme/configs",
+		Total:      3,
+		Verified:   1,
+		Failed:     1,
+		Skipped:    1,
+		Tags: []verifyTagResult{
+			{Tag: "v1.0.0", Digest: "sha256:4f1c8d2a9e7b3c5d6f0a1b2c3d4e5f60", Status: "verified", PoliciesApplied: 2},
+			{Tag: "v1.1.0", Digest: "sha256:9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d", Status: verifyStatusFailed, Error: "policy-1: signature not found"},
+			{Tag: "sha256-4f1c.sig", Status: verifyStatusSkipped, Error: "not a blob archive"},
+		},
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := verifyTagsText(&result)
+
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	require.NoError(t, err)
+	output := buf.String()
+	assert.Contains(t, output, "2 policies passed")
+	assert.Contains(t, output, "policy-1: signature not found")
+	assert.Contains(t, output, "not a blob archive")
+	assert.Contains(t, output, "3 tags: 1 verified, 1 failed, 0 without policies, 1 skipped, 0 errors")
+}
+
+func TestWriteJUnit_SkippedTag(t *testing.T) {
+	suite := newJUnitSuite(&verifyResult{Ref: "ghcr.io/acme/configs:sig", skipped: "not a blob archive"})
+
+	var buf bytes.Buffer
+	require.NoError(t, writeJUnit(&buf, suite))
+	assert.Contains(t, buf.String(), `<testcase name="skipped"`)
+	assert.Contains(t, buf.String(), `<skipped message="not a blob archive">`)
+}
@@ -2,13 +2,17 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	internalcfg "github.com/meigma/blob-cli/internal/config"
 )
 
 func TestExitError(t *testing.T) {
@@ -59,6 +63,21 @@ func TestVerifyText(t *testing.T) {
 				"Policies: 2 applied",
 			},
 		},
+		{
+			name: "with evidence",
+			result: verifyResult{
+				Ref:             "ghcr.io/test:v1",
+				Digest:          "sha256:abc123",
+				Verified:        true,
+				Status:          "verified",
+				PoliciesApplied: 1,
+				Evidence:        "./evidence",
+			},
+			wantContain: []string{
+				"Verified ghcr.io/test:v1",
+				"Evidence saved to ./evidence",
+			},
+		},
 		{
 			name: "unverified - no policies",
 			result: verifyResult{
@@ -292,3 +311,39 @@ func TestConvertBlobReferrers(t *testing.T) {
 		assert.Nil(t, result)
 	})
 }
+
+func TestIsDigestRef(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want bool
+	}{
+		{"ghcr.io/acme/configs:v1.0.0", false},
+		{"ghcr.io/acme/configs", false},
+		{"localhost:5000/configs:latest", false},
+		{"ghcr.io/acme/configs@sha256:" + strings.Repeat("a", 64), true},
+		{"ghcr.io/acme/configs:v1@sha256:" + strings.Repeat("a", 64), true},
+		{"ghcr.io/acme/configs@sha256:abc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			assert.Equal(t, tt.want, isDigestRef(tt.ref))
+		})
+	}
+}
+
+func TestVerifyCmd_RequireDigest(t *testing.T) {
+	cfg := &internalcfg.Config{}
+	verifyCmd.SetContext(internalcfg.WithConfig(context.Background(), cfg))
+	require.NoError(t, verifyCmd.Flags().Set("require-digest", "true"))
+	t.Cleanup(func() {
+		verifyCmd.Flags().Set("require-digest", "false") //nolint:errcheck // test cleanup
+	})
+
+	err := verifyCmd.RunE(verifyCmd, []string{"ghcr.io/acme/configs:v1.0.0"})
+	require.Error(t, err)
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, exitCodePolicyViolation, exitErr.Code)
+	assert.Contains(t, err.Error(), "not pinned by digest")
+}
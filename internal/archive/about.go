@@ -0,0 +1,127 @@
+package archive
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/meigma/blob"
+)
+
+// Archive-level documentation lives under a reserved directory so it can
+// travel with the content it describes.
+const (
+	// MetadataDir is the reserved directory for archive-level files.
+	MetadataDir = ".blob"
+	// ReadmePath is the path of the archive README.
+	ReadmePath = MetadataDir + "/README.md"
+	// MetadataPath is the path of the archive metadata manifest.
+	MetadataPath = MetadataDir + "/metadata.yaml"
+)
+
+// About describes an archive using its README and metadata manifest.
+type About struct {
+	Metadata *Metadata // Parsed metadata manifest (nil if absent)
+	Readme   string    // Raw README contents (empty if absent)
+}
+
+// FileReader reads files from an archive.
+// *blob.Archive satisfies this interface.
+type FileReader interface {
+	ReadFile(name string) ([]byte, error)
+}
+
+// HasAbout reports whether the archive contains a README or metadata manifest.
+func HasAbout(index *blob.IndexView) bool {
+	_, hasReadme := index.Entry(ReadmePath)
+	_, hasMetadata := index.Entry(MetadataPath)
+	return hasReadme || hasMetadata
+}
+
+// LoadAbout reads the archive README and metadata manifest.
+// Callers should check HasAbout first; an archive with neither file
+// yields an empty About.
+func LoadAbout(index *blob.IndexView, r FileReader) (*About, error) {
+	var readme, metadata []byte
+	var err error
+
+	if _, ok := index.Entry(ReadmePath); ok {
+		readme, err = r.ReadFile(ReadmePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", ReadmePath, err)
+		}
+	}
+
+	if _, ok := index.Entry(MetadataPath); ok {
+		metadata, err = r.ReadFile(MetadataPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", MetadataPath, err)
+		}
+	}
+
+	return ParseAbout(readme, metadata)
+}
+
+// ParseAbout builds an About from raw README and metadata contents.
+// Either argument may be nil.
+func ParseAbout(readme, metadata []byte) (*About, error) {
+	about := &About{Readme: string(readme)}
+	if metadata != nil {
+		m, err := ParseMetadata(metadata)
+		if err != nil {
+			return nil, err
+		}
+		about.Metadata = m
+	}
+
+	return about, nil
+}
+
+// Description returns the archive description.
+// The metadata description takes precedence; otherwise the first
+// paragraph of the README (skipping headings) is used.
+func (a *About) Description() string {
+	if a.Metadata != nil && a.Metadata.Description != "" {
+		return strings.TrimSpace(a.Metadata.Description)
+	}
+	return firstParagraph(a.Readme)
+}
+
+// Summary renders the description, owners, and links as plain text lines.
+func (a *About) Summary() []string {
+	var lines []string
+	if desc := a.Description(); desc != "" {
+		lines = append(lines, desc)
+	}
+	if a.Metadata == nil {
+		return lines
+	}
+	if len(a.Metadata.Owners) > 0 {
+		lines = append(lines, "Owners: "+strings.Join(a.Metadata.Owners, ", "))
+	}
+	for _, link := range a.Metadata.Links {
+		if link.Name != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", link.Name, link.URL))
+		} else {
+			lines = append(lines, link.URL)
+		}
+	}
+	return lines
+}
+
+// firstParagraph returns the first non-heading paragraph of a Markdown document,
+// joined onto a single line.
+func firstParagraph(markdown string) string {
+	var para []string
+	for line := range strings.Lines(markdown) {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", strings.HasPrefix(line, "#"):
+			if len(para) > 0 {
+				return strings.Join(para, " ")
+			}
+		default:
+			para = append(para, line)
+		}
+	}
+	return strings.Join(para, " ")
+}
@@ -0,0 +1,98 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAbout(t *testing.T) {
+	t.Parallel()
+
+	metadata := []byte(`description: Shared service configs
+owners:
+  - platform-team
+  - alice@example.com
+links:
+  - name: Docs
+    url: https://example.com/docs
+`)
+
+	about, err := ParseAbout([]byte("# Configs\n"), metadata)
+	require.NoError(t, err)
+	require.NotNil(t, about.Metadata)
+	assert.Equal(t, "Shared service configs", about.Metadata.Description)
+	assert.Equal(t, []string{"platform-team", "alice@example.com"}, about.Metadata.Owners)
+	require.Len(t, about.Metadata.Links, 1)
+	assert.Equal(t, "https://example.com/docs", about.Metadata.Links[0].URL)
+	assert.Equal(t, "# Configs\n", about.Readme)
+}
+
+func TestParseAbout_InvalidMetadata(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseAbout(nil, []byte("owners: [unterminated"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), MetadataPath)
+}
+
+func TestAbout_Description(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		about About
+		want  string
+	}{
+		{
+			name:  "metadata wins",
+			about: About{Metadata: &Metadata{Description: "From metadata"}, Readme: "From readme"},
+			want:  "From metadata",
+		},
+		{
+			name:  "readme first paragraph",
+			about: About{Readme: "# Title\n\nFirst line\ncontinued.\n\nSecond paragraph.\n"},
+			want:  "First line continued.",
+		},
+		{
+			name:  "readme paragraph ends at heading",
+			about: About{Readme: "Intro text\n## Usage\nMore\n"},
+			want:  "Intro text",
+		},
+		{
+			name:  "empty",
+			about: About{},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.about.Description())
+		})
+	}
+}
+
+func TestAbout_Summary(t *testing.T) {
+	t.Parallel()
+
+	about := &About{
+		Metadata: &Metadata{
+			Description: "Configs",
+			Owners:      []string{"a", "b"},
+			Links: []Link{
+				{Name: "Docs", URL: "https://example.com/docs"},
+				{URL: "https://example.com"},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"Configs",
+		"Owners: a, b",
+		"Docs: https://example.com/docs",
+		"https://example.com",
+	}, about.Summary())
+}
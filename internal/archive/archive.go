@@ -99,19 +99,7 @@ func ListDir(index *blob.IndexView, dirPath string) ([]*DirEntry, error) {
 
 		if slashIdx == -1 {
 			// This is a file (no more path components)
-			hashBytes := entry.HashBytes()
-			hash := make([]byte, len(hashBytes))
-			copy(hash, hashBytes)
-
-			seen[name] = &DirEntry{
-				Name:    name,
-				Path:    entryPath,
-				IsDir:   false,
-				Mode:    entry.Mode(),
-				Size:    entry.OriginalSize(),
-				ModTime: entry.ModTime(),
-				Hash:    hash,
-			}
+			seen[name] = fileEntry(name, &entry)
 		} else {
 			// This is a directory (synthesized)
 			childPath := dirPath + "/" + name
@@ -139,6 +127,78 @@ func ListDir(index *blob.IndexView, dirPath string) ([]*DirEntry, error) {
 	return entries, nil
 }
 
+// fileEntry returns the DirEntry for a file in the index.
+func fileEntry(name string, entry *blob.EntryView) *DirEntry {
+	hashBytes := entry.HashBytes()
+	hash := make([]byte, len(hashBytes))
+	copy(hash, hashBytes)
+
+	return &DirEntry{
+		Name:    name,
+		Path:    entry.Path(),
+		IsDir:   false,
+		Mode:    entry.Mode(),
+		Size:    entry.OriginalSize(),
+		ModTime: entry.ModTime(),
+		Hash:    hash,
+	}
+}
+
+// ListOptions controls filtering and depth for ListDirWithOptions.
+type ListOptions struct {
+	// DirsOnly limits results to directories.
+	DirsOnly bool
+
+	// FilesOnly limits results to files.
+	FilesOnly bool
+
+	// MaxDepth is the number of directory levels to include below dirPath.
+	// Values <= 1 list only the immediate children, like ListDir.
+	MaxDepth int
+
+	// HideDotfiles omits entries whose name starts with "." and does not
+	// descend into hidden directories.
+	HideDotfiles bool
+}
+
+// ListDirWithOptions lists entries below dirPath, descending up to
+// opts.MaxDepth levels and applying the type filters.
+// Nested entries are named by their path relative to dirPath
+// (e.g., "sub/file.txt") and are listed directly after their parent directory.
+func ListDirWithOptions(index *blob.IndexView, dirPath string, opts ListOptions) ([]*DirEntry, error) {
+	var result []*DirEntry
+	if err := listRecursive(index, dirPath, "", 1, opts, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func listRecursive(index *blob.IndexView, dirPath, namePrefix string, depth int, opts ListOptions, result *[]*DirEntry) error {
+	entries, err := ListDir(index, dirPath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if opts.HideDotfiles && IsHidden(entry.Name) {
+			continue
+		}
+		entry.Name = namePrefix + entry.Name
+
+		if (entry.IsDir && !opts.FilesOnly) || (!entry.IsDir && !opts.DirsOnly) {
+			*result = append(*result, entry)
+		}
+
+		if entry.IsDir && depth < opts.MaxDepth {
+			if err := listRecursive(index, entry.Path, entry.Name+"/", depth+1, opts, result); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // BuildTree builds a hierarchical tree structure rooted at dirPath.
 // If maxDepth is 0, the tree depth is unlimited.
 // If maxDepth is > 0, the tree is limited to that many levels.
@@ -189,6 +249,45 @@ func buildTreeRecursive(index *blob.IndexView, parent *DirEntry, dirPath string,
 	return nil
 }
 
+// Page returns the items in the window [offset, offset+limit).
+// A limit <= 0 means no limit; an offset past the end yields an empty slice.
+func Page[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[max(offset, 0):]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// PruneFiles removes all file entries from a tree, leaving only directories.
+func PruneFiles(root *DirEntry) {
+	root.Children = slices.DeleteFunc(root.Children, func(e *DirEntry) bool {
+		return !e.IsDir
+	})
+	for _, child := range root.Children {
+		PruneFiles(child)
+	}
+}
+
+// PruneHidden removes entries whose name starts with "." from a tree,
+// along with everything below hidden directories.
+func PruneHidden(root *DirEntry) {
+	root.Children = slices.DeleteFunc(root.Children, func(e *DirEntry) bool {
+		return IsHidden(e.Name)
+	})
+	for _, child := range root.Children {
+		PruneHidden(child)
+	}
+}
+
+// IsHidden reports whether name is a dotfile.
+func IsHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
 // SortDirsFirst sorts entries with directories first, then files.
 // Within each group, entries are sorted alphabetically.
 func SortDirsFirst(entries []*DirEntry) {
@@ -1,11 +1,191 @@
 package archive
 
 import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/meigma/blob"
+	blobcore "github.com/meigma/blob/core"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// buildTestIndex creates an archive index from the given files.
+func buildTestIndex(t *testing.T, files map[string]string, opts ...blobcore.CreateOption) *blob.IndexView {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	var indexBuf, dataBuf bytes.Buffer
+	require.NoError(t, blobcore.Create(context.Background(), dir, &indexBuf, &dataBuf, opts...))
+
+	index, err := blobcore.NewIndexView(indexBuf.Bytes())
+	require.NoError(t, err)
+	return index
+}
+
+func entryNames(entries []*DirEntry) []string {
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+func TestListDirWithOptions(t *testing.T) {
+	t.Parallel()
+
+	index := buildTestIndex(t, map[string]string{
+		"README.md":          "readme",
+		"etc/app.yaml":       "app",
+		"etc/nginx/site.cfg": "site",
+		"lib/util.go":        "util",
+	})
+
+	tests := []struct {
+		name string
+		dir  string
+		opts ListOptions
+		want []string
+	}{
+		{name: "default", dir: "/", want: []string{"README.md", "etc", "lib"}},
+		{name: "dirs_only", dir: "/", opts: ListOptions{DirsOnly: true}, want: []string{"etc", "lib"}},
+		{name: "files_only", dir: "/", opts: ListOptions{FilesOnly: true}, want: []string{"README.md"}},
+		{
+			name: "max_depth_2",
+			dir:  "/",
+			opts: ListOptions{MaxDepth: 2},
+			want: []string{"README.md", "etc", "etc/app.yaml", "etc/nginx", "lib", "lib/util.go"},
+		},
+		{
+			name: "files_only_deep",
+			dir:  "/",
+			opts: ListOptions{FilesOnly: true, MaxDepth: 3},
+			want: []string{"README.md", "etc/app.yaml", "etc/nginx/site.cfg", "lib/util.go"},
+		},
+		{
+			name: "dirs_only_deep",
+			dir:  "/etc",
+			opts: ListOptions{DirsOnly: true, MaxDepth: 5},
+			want: []string{"nginx"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			entries, err := ListDirWithOptions(index, tt.dir, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, entryNames(entries))
+		})
+	}
+}
+
+func TestListDirWithOptions_HideDotfiles(t *testing.T) {
+	t.Parallel()
+
+	index := buildTestIndex(t, map[string]string{
+		".env":             "secret",
+		".github/ci.yaml":  "ci",
+		"etc/.keep":        "",
+		"etc/app.yaml":     "app",
+		"etc/.d/extra.cfg": "extra",
+	})
+
+	entries, err := ListDirWithOptions(index, "/", ListOptions{MaxDepth: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".env", ".github", ".github/ci.yaml", "etc", "etc/.d", "etc/.d/extra.cfg", "etc/.keep", "etc/app.yaml"}, entryNames(entries))
+
+	entries, err = ListDirWithOptions(index, "/", ListOptions{MaxDepth: 3, HideDotfiles: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"etc", "etc/app.yaml"}, entryNames(entries))
+
+	// Listing a hidden directory by name shows its visible contents.
+	entries, err = ListDirWithOptions(index, "/.github", ListOptions{HideDotfiles: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ci.yaml"}, entryNames(entries))
+}
+
+func TestPage(t *testing.T) {
+	t.Parallel()
+
+	items := []int{0, 1, 2, 3, 4}
+
+	tests := []struct {
+		name          string
+		offset, limit int
+		want          []int
+	}{
+		{name: "all", want: []int{0, 1, 2, 3, 4}},
+		{name: "limit", limit: 2, want: []int{0, 1}},
+		{name: "offset", offset: 3, want: []int{3, 4}},
+		{name: "window", offset: 1, limit: 3, want: []int{1, 2, 3}},
+		{name: "limit_past_end", offset: 4, limit: 10, want: []int{4}},
+		{name: "offset_past_end", offset: 9, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, Page(items, tt.offset, tt.limit))
+		})
+	}
+}
+
+func TestPruneFiles(t *testing.T) {
+	t.Parallel()
+
+	root := &DirEntry{
+		Name:  "/",
+		IsDir: true,
+		Children: []*DirEntry{
+			{Name: "a.txt"},
+			{Name: "etc", IsDir: true, Children: []*DirEntry{
+				{Name: "b.txt"},
+				{Name: "nginx", IsDir: true},
+			}},
+		},
+	}
+
+	PruneFiles(root)
+
+	require.Len(t, root.Children, 1)
+	assert.Equal(t, "etc", root.Children[0].Name)
+	require.Len(t, root.Children[0].Children, 1)
+	assert.Equal(t, "nginx", root.Children[0].Children[0].Name)
+}
+
+func TestPruneHidden(t *testing.T) {
+	t.Parallel()
+
+	root := &DirEntry{
+		Name:  ".",
+		IsDir: true,
+		Children: []*DirEntry{
+			{Name: ".env"},
+			{Name: ".git", IsDir: true, Children: []*DirEntry{{Name: "HEAD"}}},
+			{Name: "etc", IsDir: true, Children: []*DirEntry{
+				{Name: ".keep"},
+				{Name: "app.yaml"},
+			}},
+		},
+	}
+
+	PruneHidden(root)
+
+	require.Len(t, root.Children, 1)
+	assert.Equal(t, "etc", root.Children[0].Name)
+	assert.Equal(t, []string{"app.yaml"}, entryNames(root.Children[0].Children))
+}
+
 func TestNormalizePath(t *testing.T) {
 	t.Parallel()
 
@@ -47,14 +47,25 @@ func FormatDigest(hash []byte) string {
 }
 
 // FormatMode returns a Unix-style file mode string.
-// Examples: "-rw-r--r--", "drwxr-xr-x"
+// Examples: "-rw-r--r--", "drwxr-xr-x", "lrwxrwxrwx"
 func FormatMode(mode fs.FileMode, isDir bool) string {
 	var buf [10]byte
 
 	// File type indicator
-	if isDir {
+	switch {
+	case isDir:
 		buf[0] = 'd'
-	} else {
+	case mode&fs.ModeSymlink != 0:
+		buf[0] = 'l'
+	case mode&fs.ModeNamedPipe != 0:
+		buf[0] = 'p'
+	case mode&fs.ModeSocket != 0:
+		buf[0] = 's'
+	case mode&fs.ModeCharDevice != 0:
+		buf[0] = 'c'
+	case mode&fs.ModeDevice != 0:
+		buf[0] = 'b'
+	default:
 		buf[0] = '-'
 	}
 
@@ -85,6 +85,9 @@ func TestFormatMode(t *testing.T) {
 		{name: "directory_700", mode: 0o700, isDir: true, want: "drwx------"},
 		{name: "no_permissions", mode: 0o000, isDir: false, want: "----------"},
 		{name: "all_permissions", mode: 0o777, isDir: false, want: "-rwxrwxrwx"},
+		{name: "symlink", mode: fs.ModeSymlink | 0o777, isDir: false, want: "lrwxrwxrwx"},
+		{name: "named_pipe", mode: fs.ModeNamedPipe | 0o644, isDir: false, want: "prw-r--r--"},
+		{name: "char_device", mode: fs.ModeDevice | fs.ModeCharDevice | 0o666, isDir: false, want: "crw-rw-rw-"},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,40 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.json", "a.json", true},
+		{"*.json", "dir/a.json", false},
+		{"**/*.json", "a.json", true},
+		{"**/*.json", "dir/sub/a.json", true},
+		{"**/*.config.json", "svc/app.config.json", true},
+		{"**/*.config.json", "svc/app.json", false},
+		{"config/**", "config/a/b.yaml", true},
+		{"config/**", "other/a.yaml", false},
+		{"config/**/*.yaml", "config/a.yaml", true},
+		{"config/**/*.yaml", "config/x/y/a.yaml", true},
+		{"/etc/*.conf", "etc/app.conf", true},
+		{"etc/*.conf", "/etc/app.conf", true},
+		{"**", "anything/at/all", true},
+		{"a/**/**/b", "a/b", true},
+		{"[", "[", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"|"+tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, MatchGlob(tt.pattern, tt.name))
+		})
+	}
+}
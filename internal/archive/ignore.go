@@ -0,0 +1,133 @@
+package archive
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile is the file in a source directory listing paths to leave out
+// of the archive, in gitignore syntax.
+const IgnoreFile = ".blobignore"
+
+// IgnoreRules is a list of gitignore-style patterns. The last pattern
+// matching a path decides whether it is ignored, and a "!" pattern
+// re-includes a path an earlier pattern ignored.
+type IgnoreRules struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	pattern string // glob for MatchGlob, relative to the root
+	negate  bool
+	dirOnly bool
+}
+
+// ParseIgnore reads gitignore-style patterns from r, one per line.
+// Blank lines and lines starting with "#" are skipped; "\#" and "\!"
+// escape a leading "#" or "!".
+func ParseIgnore(r io.Reader) (*IgnoreRules, error) {
+	var rules IgnoreRules
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		rules.Add(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// ReadIgnoreFile reads the IgnoreFile in dir. If there is none, the error
+// wraps fs.ErrNotExist.
+func ReadIgnoreFile(dir string) (*IgnoreRules, error) {
+	f, err := os.Open(filepath.Join(dir, IgnoreFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rules, err := ParseIgnore(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", IgnoreFile, err)
+	}
+	return rules, nil
+}
+
+// Add appends a pattern line. Lines that hold no pattern are ignored.
+func (r *IgnoreRules) Add(line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	var rule ignoreRule
+	switch {
+	case strings.HasPrefix(line, "!"):
+		rule.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\#`), strings.HasPrefix(line, `\!`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	// A pattern with a slash other than at the end is relative to the
+	// root; otherwise it matches at any depth.
+	if strings.Contains(line, "/") {
+		rule.pattern = strings.TrimPrefix(line, "/")
+	} else {
+		rule.pattern = "**/" + line
+	}
+	r.rules = append(r.rules, rule)
+}
+
+// Ignored reports whether the slash-separated path, relative to the root,
+// is ignored. Callers walking a tree should not descend into ignored
+// directories: as in git, a file cannot be re-included if a directory
+// above it is ignored.
+func (r *IgnoreRules) Ignored(p string, isDir bool) bool {
+	if r == nil {
+		return false
+	}
+	ignored := false
+	for _, rule := range r.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if MatchGlob(rule.pattern, p) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// Empty reports whether there are no rules.
+func (r *IgnoreRules) Empty() bool {
+	return r == nil || len(r.rules) == 0
+}
+
+// LoadIgnoreRules returns the rules of the IgnoreFile in dir followed by
+// extra, or empty rules if neither has any.
+func LoadIgnoreRules(dir string, extra []string) (*IgnoreRules, error) {
+	rules, err := ReadIgnoreFile(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		rules, err = &IgnoreRules{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range extra {
+		rules.Add(line)
+	}
+	return rules, nil
+}
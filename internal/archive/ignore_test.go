@@ -0,0 +1,80 @@
+package archive
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreRules_Ignored(t *testing.T) {
+	t.Parallel()
+
+	rules, err := ParseIgnore(strings.NewReader(`# secrets and build output
+*.env
+!example.env
+build/
+/TODO
+docs/*.draft
+\#notes
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"prod.env", false, true},
+		{"svc/prod.env", false, true},
+		{"example.env", false, false},
+		{"svc/example.env", false, false},
+		{"build", true, true},
+		{"svc/build", true, true},
+		{"build", false, false},
+		{"TODO", false, true},
+		{"svc/TODO", false, false},
+		{"docs/a.draft", false, true},
+		{"docs/sub/a.draft", false, false},
+		{"#notes", false, true},
+		{"config.yaml", false, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, rules.Ignored(tt.path, tt.isDir), "%s (dir %v)", tt.path, tt.isDir)
+	}
+}
+
+func TestIgnoreRules_Empty(t *testing.T) {
+	t.Parallel()
+
+	var nilRules *IgnoreRules
+	assert.True(t, nilRules.Empty())
+	assert.False(t, nilRules.Ignored("a", false))
+
+	rules, err := ParseIgnore(strings.NewReader("# only a comment\n\n"))
+	require.NoError(t, err)
+	assert.True(t, rules.Empty())
+}
+
+func TestLoadIgnoreRules(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, err := ReadIgnoreFile(dir)
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	rules, err := LoadIgnoreRules(dir, []string{"*.log"})
+	require.NoError(t, err)
+	assert.True(t, rules.Ignored("app.log", false))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, IgnoreFile), []byte("*.log\n"), 0o644))
+	rules, err = LoadIgnoreRules(dir, []string{"!keep.log"})
+	require.NoError(t, err)
+	assert.True(t, rules.Ignored("app.log", false))
+	assert.False(t, rules.Ignored("keep.log", false))
+}
@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/meigma/blob"
+)
+
+// Index describes every file in an archive index, for tooling that plans
+// or diffs downloads without fetching file data.
+type Index struct {
+	Files     int          `json:"files"`
+	TotalSize uint64       `json:"total_size"`
+	DataSize  uint64       `json:"data_size,omitempty"`
+	Entries   []IndexEntry `json:"entries"`
+}
+
+// IndexEntry describes one file of an archive index.
+type IndexEntry struct {
+	Path        string `json:"path"`
+	Size        uint64 `json:"size"`
+	StoredSize  uint64 `json:"stored_size"`
+	Compression string `json:"compression"`
+	Digest      string `json:"digest"`
+	Mode        string `json:"mode"`
+	ModTime     string `json:"mod_time,omitempty"`
+}
+
+// NewIndex returns the entries of index in path order. Digests are
+// complete, unlike those from FormatDigest.
+func NewIndex(index *blob.IndexView) *Index {
+	out := &Index{Entries: make([]IndexEntry, 0, index.Len())}
+	if size, ok := index.DataSize(); ok {
+		out.DataSize = size
+	}
+	for entry := range index.Entries() {
+		e := IndexEntry{
+			Path:        "/" + entry.Path(),
+			Size:        entry.OriginalSize(),
+			StoredSize:  entry.DataSize(),
+			Compression: entry.Compression().String(),
+			Digest:      "sha256:" + hex.EncodeToString(entry.HashBytes()),
+			Mode:        fmt.Sprintf("%04o", entry.Mode().Perm()),
+		}
+		if mt := entry.ModTime(); !mt.IsZero() {
+			e.ModTime = mt.UTC().Format(time.RFC3339)
+		}
+		out.Entries = append(out.Entries, e)
+		out.TotalSize += e.Size
+	}
+	out.Files = len(out.Entries)
+	return out
+}
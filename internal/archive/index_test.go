@@ -0,0 +1,27 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIndex(t *testing.T) {
+	index := buildTestIndex(t, map[string]string{
+		"b.txt":        "bravo",
+		"a/config.yml": "alpha: 1",
+	})
+
+	got := NewIndex(index)
+	assert.Equal(t, 2, got.Files)
+	assert.Equal(t, uint64(len("bravo")+len("alpha: 1")), got.TotalSize)
+	require.Len(t, got.Entries, 2)
+
+	first := got.Entries[0]
+	assert.Equal(t, "/a/config.yml", first.Path)
+	assert.Equal(t, uint64(len("alpha: 1")), first.Size)
+	assert.Equal(t, "none", first.Compression)
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, first.Digest)
+	assert.Equal(t, "/b.txt", got.Entries[1].Path)
+}
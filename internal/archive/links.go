@@ -0,0 +1,53 @@
+package archive
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/meigma/blob"
+)
+
+// IsSymlink reports whether the entry is a symbolic link. blob only
+// archives regular files, but the index keeps the full mode bits, so
+// archives written by other tools may record links. As in tar and git, the
+// content of a link entry is its target.
+func (e *DirEntry) IsSymlink() bool {
+	return !e.IsDir && e.Mode&fs.ModeSymlink != 0
+}
+
+// IsSpecial reports whether the entry is a device, named pipe, socket, or
+// other non-regular file that is not a symbolic link.
+func (e *DirEntry) IsSpecial() bool {
+	return !e.IsDir && e.Mode.Type()&^fs.ModeSymlink != 0
+}
+
+// ResolveLink returns the entry the link at linkPath with the given target
+// points to, or false if the target is absolute, leaves the archive, or
+// does not exist in it. Only the link itself is resolved; a target that is
+// another link is returned as is.
+func ResolveLink(index *blob.IndexView, linkPath, target string) (*DirEntry, bool) {
+	if target == "" || path.IsAbs(target) {
+		return nil, false
+	}
+	resolved := path.Clean(path.Join(path.Dir(normalizePath(linkPath)), target))
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return nil, false
+	}
+	if resolved == "." {
+		return &DirEntry{Name: "/", Path: "", IsDir: true, Mode: fs.ModeDir | 0o755}, true
+	}
+
+	if entry, ok := index.Entry(resolved); ok {
+		return fileEntry(path.Base(resolved), &entry), true
+	}
+	for range index.EntriesWithPrefix(resolved + "/") {
+		return &DirEntry{
+			Name:  path.Base(resolved),
+			Path:  resolved,
+			IsDir: true,
+			Mode:  fs.ModeDir | 0o755,
+		}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,62 @@
+package archive
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveLink(t *testing.T) {
+	index := buildTestIndex(t, map[string]string{
+		"etc/app.yaml":        "a",
+		"etc/conf.d/db.yaml":  "b",
+		"usr/share/doc/READ":  "c",
+		"usr/local/bin/tool":  "d",
+		"usr/local/lib/x.txt": "e",
+	})
+
+	tests := []struct {
+		name     string
+		linkPath string
+		target   string
+		wantPath string
+		wantDir  bool
+		wantOK   bool
+	}{
+		{name: "sibling file", linkPath: "etc/current.yaml", target: "app.yaml", wantPath: "etc/app.yaml", wantOK: true},
+		{name: "parent directory", linkPath: "usr/local/bin/doc", target: "../../share/doc", wantPath: "usr/share/doc", wantDir: true, wantOK: true},
+		{name: "nested file", linkPath: "db.yaml", target: "etc/conf.d/db.yaml", wantPath: "etc/conf.d/db.yaml", wantOK: true},
+		{name: "archive root", linkPath: "etc/root", target: "..", wantPath: "", wantDir: true, wantOK: true},
+		{name: "missing target", linkPath: "etc/old.yaml", target: "gone.yaml"},
+		{name: "absolute target", linkPath: "etc/passwd", target: "/etc/passwd"},
+		{name: "outside archive", linkPath: "etc/escape", target: "../../etc"},
+		{name: "directory prefix only", linkPath: "usr/l", target: "local/li"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := ResolveLink(index, tt.linkPath, tt.target)
+			require.Equal(t, tt.wantOK, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tt.wantPath, entry.Path)
+			assert.Equal(t, tt.wantDir, entry.IsDir)
+		})
+	}
+}
+
+func TestDirEntry_Kinds(t *testing.T) {
+	link := &DirEntry{Mode: fs.ModeSymlink | 0o777}
+	pipe := &DirEntry{Mode: fs.ModeNamedPipe | 0o644}
+	file := &DirEntry{Mode: 0o644}
+	dir := &DirEntry{IsDir: true, Mode: fs.ModeDir | 0o755}
+
+	assert.True(t, link.IsSymlink())
+	assert.False(t, link.IsSpecial())
+	assert.True(t, pipe.IsSpecial())
+	assert.False(t, pipe.IsSymlink())
+	assert.False(t, file.IsSymlink() || file.IsSpecial())
+	assert.False(t, dir.IsSymlink() || dir.IsSpecial())
+}
@@ -0,0 +1,174 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MetadataSchemaVersion is the metadata manifest schema version written by
+// this version of the CLI. Manifests with a newer version are rejected.
+const MetadataSchemaVersion = 1
+
+// linkFieldPrefix is the field prefix used to address links by name.
+const linkFieldPrefix = "links."
+
+// Metadata is the archive metadata manifest stored at MetadataPath.
+type Metadata struct {
+	SchemaVersion int      `yaml:"schema_version,omitempty" json:"schema_version,omitempty"`
+	Description   string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Owners        []string `yaml:"owners,omitempty" json:"owners,omitempty"`
+	Links         []Link   `yaml:"links,omitempty" json:"links,omitempty"`
+}
+
+// Link is a named URL in the archive metadata.
+type Link struct {
+	Name string `yaml:"name" json:"name"`
+	URL  string `yaml:"url" json:"url"`
+}
+
+// ParseMetadata parses and validates a metadata manifest.
+func ParseMetadata(data []byte) (*Metadata, error) {
+	var m Metadata
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", MetadataPath, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Validate checks that the manifest uses a supported schema version
+// and that every link has a URL.
+func (m *Metadata) Validate() error {
+	if m.SchemaVersion < 0 || m.SchemaVersion > MetadataSchemaVersion {
+		return fmt.Errorf("%s: unsupported schema_version %d (supported: %d)",
+			MetadataPath, m.SchemaVersion, MetadataSchemaVersion)
+	}
+	for i, link := range m.Links {
+		if link.URL == "" {
+			return fmt.Errorf("%s: links[%d] is missing a url", MetadataPath, i)
+		}
+	}
+	return nil
+}
+
+// ReadMetadataFile reads the metadata manifest from a local source directory.
+// Returns an error wrapping fs.ErrNotExist if the directory has no manifest.
+func ReadMetadataFile(dir string) (*Metadata, error) {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(MetadataPath)))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", MetadataPath, err)
+	}
+	return ParseMetadata(data)
+}
+
+// WriteMetadataFile writes the metadata manifest into a local source directory,
+// creating the metadata directory if needed. The schema version is stamped
+// if unset.
+func WriteMetadataFile(dir string, m *Metadata) error {
+	if m.SchemaVersion == 0 {
+		m.SchemaVersion = MetadataSchemaVersion
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("encoding metadata: %w", err)
+	}
+
+	metaDir := filepath.Join(dir, MetadataDir)
+	if err := os.MkdirAll(metaDir, 0o750); err != nil {
+		return fmt.Errorf("creating %s: %w", MetadataDir, err)
+	}
+
+	//nolint:gosec // G306: metadata is archived content, not a secret
+	if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(MetadataPath)), data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", MetadataPath, err)
+	}
+	return nil
+}
+
+// MetadataFields returns the names of the fields addressable by Get and Set.
+func MetadataFields() []string {
+	return []string{"schema_version", "description", "owners", linkFieldPrefix + "<name>"}
+}
+
+// Get returns a single field as a string.
+// Owners are returned comma-separated; links are addressed as "links.<name>".
+func (m *Metadata) Get(field string) (string, error) {
+	switch {
+	case field == "schema_version":
+		return strconv.Itoa(m.SchemaVersion), nil
+	case field == "description":
+		return m.Description, nil
+	case field == "owners":
+		return strings.Join(m.Owners, ","), nil
+	case strings.HasPrefix(field, linkFieldPrefix):
+		name := strings.TrimPrefix(field, linkFieldPrefix)
+		for _, link := range m.Links {
+			if link.Name == name {
+				return link.URL, nil
+			}
+		}
+		return "", fmt.Errorf("link %q not found", name)
+	default:
+		return "", unknownFieldError(field)
+	}
+}
+
+// Set updates a single field from a string value.
+// Owners are given comma-separated. An empty value clears the field
+// (or removes the link).
+func (m *Metadata) Set(field, value string) error {
+	switch {
+	case field == "schema_version":
+		return errors.New("schema_version is managed automatically")
+	case field == "description":
+		m.Description = value
+	case field == "owners":
+		m.Owners = nil
+		for owner := range strings.SplitSeq(value, ",") {
+			if owner = strings.TrimSpace(owner); owner != "" {
+				m.Owners = append(m.Owners, owner)
+			}
+		}
+	case strings.HasPrefix(field, linkFieldPrefix):
+		name := strings.TrimPrefix(field, linkFieldPrefix)
+		if name == "" {
+			return errors.New("link name is required (links.<name>)")
+		}
+		m.setLink(name, value)
+	default:
+		return unknownFieldError(field)
+	}
+	return nil
+}
+
+// setLink adds, updates, or (for an empty url) removes a named link.
+func (m *Metadata) setLink(name, url string) {
+	idx := slices.IndexFunc(m.Links, func(l Link) bool { return l.Name == name })
+	switch {
+	case url == "" && idx >= 0:
+		m.Links = slices.Delete(m.Links, idx, idx+1)
+	case url == "":
+		// Nothing to remove
+	case idx >= 0:
+		m.Links[idx].URL = url
+	default:
+		m.Links = append(m.Links, Link{Name: name, URL: url})
+	}
+}
+
+func unknownFieldError(field string) error {
+	return fmt.Errorf("unknown metadata field %q (valid: %s)", field, strings.Join(MetadataFields(), ", "))
+}
@@ -0,0 +1,92 @@
+package archive
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetadata_Validation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "minimal", data: "description: x\n"},
+		{name: "current version", data: "schema_version: 1\n"},
+		{name: "future version", data: "schema_version: 2\n", wantErr: "unsupported schema_version 2"},
+		{name: "link without url", data: "links:\n  - name: Docs\n", wantErr: "links[0] is missing a url"},
+		{name: "malformed", data: "owners: [", wantErr: "parsing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseMetadata([]byte(tt.data))
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestMetadata_GetSet(t *testing.T) {
+	t.Parallel()
+
+	var m Metadata
+	require.NoError(t, m.Set("description", "Configs"))
+	require.NoError(t, m.Set("owners", " a, ,b "))
+	require.NoError(t, m.Set("links.Docs", "https://example.com/docs"))
+	require.NoError(t, m.Set("links.Home", "https://example.com"))
+
+	got, err := m.Get("description")
+	require.NoError(t, err)
+	assert.Equal(t, "Configs", got)
+
+	got, err = m.Get("owners")
+	require.NoError(t, err)
+	assert.Equal(t, "a,b", got)
+
+	got, err = m.Get("links.Home")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", got)
+
+	// Update then remove a link
+	require.NoError(t, m.Set("links.Docs", "https://docs.example.com"))
+	assert.Equal(t, "https://docs.example.com", m.Links[0].URL)
+	require.NoError(t, m.Set("links.Docs", ""))
+	assert.Equal(t, []Link{{Name: "Home", URL: "https://example.com"}}, m.Links)
+
+	_, err = m.Get("links.Docs")
+	require.Error(t, err)
+
+	require.Error(t, m.Set("schema_version", "2"))
+	require.Error(t, m.Set("links.", "x"))
+	_, err = m.Get("color")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown metadata field")
+}
+
+func TestMetadataFile_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	_, err := ReadMetadataFile(dir)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	m := &Metadata{Description: "Configs", Owners: []string{"a"}}
+	require.NoError(t, WriteMetadataFile(dir, m))
+	assert.Equal(t, MetadataSchemaVersion, m.SchemaVersion)
+
+	got, err := ReadMetadataFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, m, got)
+}
@@ -0,0 +1,45 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/meigma/blob"
+)
+
+// OpenRange returns a reader for bytes [start, end) of the file at
+// filePath, with end clamped to the file size. Uncompressed files are read
+// with range requests for just those bytes; compressed files are
+// decompressed from the start and the bytes before start discarded.
+func OpenRange(a *blob.Archive, filePath string, start, end int64) (io.ReadCloser, error) {
+	entry, ok := a.Entry(filePath)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: filePath, Err: fs.ErrNotExist}
+	}
+	size := int64(entry.OriginalSize()) //nolint:gosec // archive sizes fit in int64
+	if end < 0 || end > size {
+		end = size
+	}
+	end = max(end, start)
+
+	f, err := a.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", filePath, err)
+	}
+
+	if ra, ok := f.(io.ReaderAt); ok && entry.Compression() == blob.CompressionNone {
+		return rangeReader{Reader: io.NewSectionReader(ra, start, end-start), Closer: f}, nil
+	}
+	if _, err := io.CopyN(io.Discard, f, start); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading %s: %w", filePath, err)
+	}
+	return rangeReader{Reader: io.LimitReader(f, end-start), Closer: f}, nil
+}
+
+// rangeReader reads a range of a file and closes the file.
+type rangeReader struct {
+	io.Reader
+	io.Closer
+}
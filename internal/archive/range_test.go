@@ -0,0 +1,60 @@
+package archive
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/meigma/blob"
+	blobcore "github.com/meigma/blob/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memSource serves archive data from memory.
+type memSource struct {
+	*bytes.Reader
+}
+
+func (memSource) SourceID() string { return "test" }
+
+func TestOpenRange(t *testing.T) {
+	content := strings.Repeat("0123456789", 100)
+
+	for _, compression := range []blob.Compression{blob.CompressionNone, blob.CompressionZstd} {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "data.bin"), []byte(content), 0o644))
+		var indexBuf, dataBuf bytes.Buffer
+		require.NoError(t, blobcore.Create(t.Context(), dir, &indexBuf, &dataBuf,
+			blobcore.CreateWithCompression(compression),
+			blobcore.CreateWithSkipCompression()))
+		b, err := blobcore.New(indexBuf.Bytes(), memSource{bytes.NewReader(dataBuf.Bytes())})
+		require.NoError(t, err)
+		a := &blob.Archive{Blob: b}
+
+		tests := []struct {
+			start, end int64
+			want       string
+		}{
+			{start: 0, end: 10, want: content[:10]},
+			{start: 995, end: 2000, want: content[995:]},
+			{start: 500, end: -1, want: content[500:]},
+			{start: 1000, end: 1000, want: ""},
+		}
+		for _, tt := range tests {
+			r, err := OpenRange(a, "data.bin", tt.start, tt.end)
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			assert.Equal(t, tt.want, string(got), "compression %v, range %d:%d", compression, tt.start, tt.end)
+		}
+
+		_, err = OpenRange(a, "missing.bin", 0, 10)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	}
+}
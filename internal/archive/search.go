@@ -0,0 +1,86 @@
+package archive
+
+import (
+	"cmp"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/meigma/blob"
+)
+
+// Search returns the files and directories anywhere below dirPath that
+// match query, sorted by path. Entries are named by their path relative to
+// dirPath, as in ListDirWithOptions.
+//
+// A query containing glob characters (*, ?, [) is matched with MatchGlob
+// against the relative path if it contains a slash, or against the base
+// name otherwise, so "*.yaml" finds YAML files at any depth. Any other
+// query matches relative paths containing it, ignoring case. An empty
+// query matches nothing.
+func Search(index *blob.IndexView, dirPath, query string) []*DirEntry {
+	if query == "" {
+		return nil
+	}
+	dirPath = normalizePath(dirPath)
+	prefix := ""
+	if dirPath != "" {
+		prefix = dirPath + "/"
+	}
+	match := queryMatcher(query)
+
+	var results []*DirEntry
+	seenDirs := make(map[string]bool)
+	for entry := range index.EntriesWithPrefix(prefix) {
+		rel := strings.TrimPrefix(entry.Path(), prefix)
+		if rel == "" {
+			continue
+		}
+
+		// Directories are synthesized from the paths of the files below them.
+		for i, c := range rel {
+			if c != '/' || seenDirs[rel[:i]] {
+				continue
+			}
+			dir := rel[:i]
+			seenDirs[dir] = true
+			if match(dir) {
+				results = append(results, &DirEntry{
+					Name:  dir,
+					Path:  prefix + dir,
+					IsDir: true,
+					Mode:  fs.ModeDir | 0o755,
+				})
+			}
+		}
+
+		if match(rel) {
+			results = append(results, fileEntry(rel, &entry))
+		}
+	}
+
+	slices.SortFunc(results, func(a, b *DirEntry) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return results
+}
+
+// queryMatcher returns the function Search uses to match relative paths.
+func queryMatcher(query string) func(rel string) bool {
+	if !strings.ContainsAny(query, "*?[") {
+		lower := strings.ToLower(query)
+		return func(rel string) bool {
+			return strings.Contains(strings.ToLower(rel), lower)
+		}
+	}
+	if strings.Contains(query, "/") {
+		return func(rel string) bool {
+			return MatchGlob(query, rel)
+		}
+	}
+	return func(rel string) bool {
+		ok, err := path.Match(query, path.Base(rel))
+		return err == nil && ok
+	}
+}
@@ -0,0 +1,87 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func entryPaths(entries []*DirEntry) []string {
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	return paths
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	index := buildTestIndex(t, map[string]string{
+		"README.md":             "readme",
+		"config/app.yaml":       "app",
+		"config/db.yaml":        "db",
+		"config/prod/app.yaml":  "prod app",
+		"config/prod/notes.txt": "notes",
+		"scripts/deploy.sh":     "deploy",
+	})
+
+	tests := []struct {
+		name  string
+		dir   string
+		query string
+		want  []string
+	}{
+		{
+			name:  "substring matches files and directories",
+			query: "prod",
+			want:  []string{"config/prod", "config/prod/app.yaml", "config/prod/notes.txt"},
+		},
+		{
+			name:  "substring ignores case",
+			query: "readme",
+			want:  []string{"README.md"},
+		},
+		{
+			name:  "glob without slash matches base names at any depth",
+			query: "*.yaml",
+			want:  []string{"config/app.yaml", "config/db.yaml", "config/prod/app.yaml"},
+		},
+		{
+			name:  "glob with slash matches relative paths",
+			query: "config/*/app.yaml",
+			want:  []string{"config/prod/app.yaml"},
+		},
+		{
+			name:  "search is limited to the directory",
+			dir:   "config/prod",
+			query: "app",
+			want:  []string{"config/prod/app.yaml"},
+		},
+		{
+			name:  "empty query matches nothing",
+			query: "",
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, entryPaths(Search(index, tt.dir, tt.query)))
+		})
+	}
+}
+
+func TestSearch_NamesRelativeToDir(t *testing.T) {
+	t.Parallel()
+
+	index := buildTestIndex(t, map[string]string{"config/prod/app.yaml": "app"})
+
+	results := Search(index, "config", "app")
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "prod/app.yaml", results[0].Name)
+		assert.Equal(t, "config/prod/app.yaml", results[0].Path)
+		assert.False(t, results[0].IsDir)
+	}
+}
@@ -0,0 +1,130 @@
+package archive
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/meigma/blob"
+)
+
+// NoExtension is the extension key used for files without an extension.
+const NoExtension = "(none)"
+
+// Compression effectiveness classes, from worst to best.
+const (
+	EffectivenessStored   = "stored"   // Stored without compression
+	EffectivenessPoor     = "poor"     // Saved less than 10%
+	EffectivenessModerate = "moderate" // Saved 10-50%
+	EffectivenessGood     = "good"     // Saved 50% or more
+)
+
+// Effectiveness thresholds as stored/original size ratios.
+const (
+	poorRatio     = 0.9
+	moderateRatio = 0.5
+)
+
+// GroupStats summarizes a group of entries.
+type GroupStats struct {
+	Name            string  // Extension or effectiveness class
+	Files           int     // Number of files in the group
+	CompressedFiles int     // Number of files stored compressed
+	Size            uint64  // Total original (uncompressed) size
+	StoredSize      uint64  // Total size as stored in the archive
+	Ratio           float64 // StoredSize / Size (1.0 for empty groups)
+}
+
+// Stats breaks down archive contents by file extension and by
+// compression effectiveness.
+type Stats struct {
+	ByExtension     []GroupStats // Sorted by original size, largest first
+	ByEffectiveness []GroupStats // Ordered stored, poor, moderate, good; empty classes omitted
+}
+
+// ComputeStats computes per-extension and per-effectiveness statistics.
+func ComputeStats(index *blob.IndexView) *Stats {
+	byExt := make(map[string]*GroupStats)
+	byEff := make(map[string]*GroupStats)
+
+	for entry := range index.Entries() {
+		size := entry.OriginalSize()
+		stored := entry.DataSize()
+		compressed := entry.Compression() != blob.CompressionNone
+
+		addToGroup(byExt, Extension(entry.Path()), size, stored, compressed)
+		addToGroup(byEff, Effectiveness(size, stored, compressed), size, stored, compressed)
+	}
+
+	stats := &Stats{
+		ByExtension:     make([]GroupStats, 0, len(byExt)),
+		ByEffectiveness: make([]GroupStats, 0, len(byEff)),
+	}
+	for _, g := range byExt {
+		stats.ByExtension = append(stats.ByExtension, finishGroup(g))
+	}
+	slices.SortFunc(stats.ByExtension, func(a, b GroupStats) int {
+		if c := cmp.Compare(b.Size, a.Size); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	for _, name := range []string{EffectivenessStored, EffectivenessPoor, EffectivenessModerate, EffectivenessGood} {
+		if g, ok := byEff[name]; ok {
+			stats.ByEffectiveness = append(stats.ByEffectiveness, finishGroup(g))
+		}
+	}
+
+	return stats
+}
+
+// Extension returns the lower-cased extension of a path (e.g., ".json"),
+// or NoExtension if it has none. Dotfiles such as ".gitignore" have no extension.
+func Extension(name string) string {
+	base := path.Base(name)
+	ext := path.Ext(base)
+	if ext == "" || ext == base {
+		return NoExtension
+	}
+	return strings.ToLower(ext)
+}
+
+// Effectiveness classifies how well a single entry compressed.
+func Effectiveness(size, stored uint64, compressed bool) string {
+	if !compressed || size == 0 {
+		return EffectivenessStored
+	}
+	ratio := float64(stored) / float64(size)
+	switch {
+	case ratio > poorRatio:
+		return EffectivenessPoor
+	case ratio > moderateRatio:
+		return EffectivenessModerate
+	default:
+		return EffectivenessGood
+	}
+}
+
+func addToGroup(groups map[string]*GroupStats, name string, size, stored uint64, compressed bool) {
+	g, ok := groups[name]
+	if !ok {
+		g = &GroupStats{Name: name}
+		groups[name] = g
+	}
+	g.Files++
+	g.Size += size
+	g.StoredSize += stored
+	if compressed {
+		g.CompressedFiles++
+	}
+}
+
+func finishGroup(g *GroupStats) GroupStats {
+	g.Ratio = 1.0
+	if g.Size > 0 {
+		g.Ratio = float64(g.StoredSize) / float64(g.Size)
+	}
+	return *g
+}
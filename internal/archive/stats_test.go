@@ -0,0 +1,89 @@
+package archive
+
+import (
+	"strings"
+	"testing"
+
+	blobcore "github.com/meigma/blob/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtension(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "config.json", want: ".json"},
+		{name: "etc/App.YAML", want: ".yaml"},
+		{name: "archive.tar.gz", want: ".gz"},
+		{name: "Makefile", want: NoExtension},
+		{name: ".gitignore", want: NoExtension},
+		{name: "dir.d/file", want: NoExtension},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, Extension(tt.name))
+		})
+	}
+}
+
+func TestEffectiveness(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		size       uint64
+		stored     uint64
+		compressed bool
+		want       string
+	}{
+		{name: "uncompressed", size: 100, stored: 100, compressed: false, want: EffectivenessStored},
+		{name: "empty", size: 0, stored: 0, compressed: true, want: EffectivenessStored},
+		{name: "poor", size: 100, stored: 95, compressed: true, want: EffectivenessPoor},
+		{name: "moderate", size: 100, stored: 70, compressed: true, want: EffectivenessModerate},
+		{name: "good", size: 100, stored: 20, compressed: true, want: EffectivenessGood},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, Effectiveness(tt.size, tt.stored, tt.compressed))
+		})
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	t.Parallel()
+
+	index := buildTestIndex(t, map[string]string{
+		"a.json":   strings.Repeat("{}", 4096),
+		"b.json":   strings.Repeat("[]", 4096),
+		"README":   "hello",
+		"notes.md": strings.Repeat("# notes\n", 512),
+	}, blobcore.CreateWithCompression(blobcore.CompressionZstd))
+
+	stats := ComputeStats(index)
+
+	require.Len(t, stats.ByExtension, 3)
+	jsonStats := stats.ByExtension[0]
+	assert.Equal(t, ".json", jsonStats.Name)
+	assert.Equal(t, 2, jsonStats.Files)
+	assert.Equal(t, 2, jsonStats.CompressedFiles)
+	assert.Equal(t, uint64(16384), jsonStats.Size)
+	assert.Less(t, jsonStats.StoredSize, jsonStats.Size)
+	assert.Less(t, jsonStats.Ratio, 0.5)
+	assert.Equal(t, ".md", stats.ByExtension[1].Name)
+	assert.Equal(t, NoExtension, stats.ByExtension[2].Name)
+
+	var total int
+	for _, g := range stats.ByEffectiveness {
+		total += g.Files
+	}
+	assert.Equal(t, 4, total)
+	assert.Equal(t, EffectivenessGood, stats.ByEffectiveness[len(stats.ByEffectiveness)-1].Name)
+}
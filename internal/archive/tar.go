@@ -0,0 +1,159 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractTar unpacks the tar stream r into dir, which must exist, and
+// returns the number of files written. A gzip-compressed stream is
+// detected and decompressed. Entries are confined to dir; links and
+// special files are rejected because an archive cannot hold them.
+func ExtractTar(r io.Reader, dir string) (int, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, fmt.Errorf("reading gzip stream: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return 0, err
+	}
+	defer root.Close()
+
+	tr := tar.NewReader(r)
+	files := 0
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return files, fmt.Errorf("reading tar stream: %w", err)
+		}
+
+		name, ok := tarEntryName(hdr.Name)
+		switch {
+		case hdr.Typeflag == tar.TypeXGlobalHeader:
+			// Stream-wide metadata, such as the commit id from git archive
+			continue
+		case !ok:
+			return files, fmt.Errorf("tar entry %q: path escapes the archive root", hdr.Name)
+		case name == ".":
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := root.MkdirAll(name, 0o755); err != nil {
+				return files, fmt.Errorf("tar entry %s: %w", hdr.Name, err)
+			}
+		case tar.TypeReg:
+			if err := extractTarFile(root, name, hdr, tr); err != nil {
+				return files, fmt.Errorf("tar entry %s: %w", hdr.Name, err)
+			}
+			files++
+		case tar.TypeSymlink, tar.TypeLink:
+			return files, fmt.Errorf("tar entry %s: links are not supported in archives", hdr.Name)
+		default:
+			return files, fmt.Errorf("tar entry %s: unsupported type %q", hdr.Name, hdr.Typeflag)
+		}
+	}
+}
+
+// tarEntryName cleans a tar entry name to a slash-separated path relative
+// to the extraction root. It reports false for names that leave the root.
+func tarEntryName(name string) (string, bool) {
+	if path.IsAbs(name) || strings.HasPrefix(name, `\`) {
+		return "", false
+	}
+	clean := path.Clean(name)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", false
+	}
+	return clean, true
+}
+
+// extractTarFile writes the current entry of tr to name in root with the
+// entry's permissions and modification time.
+func extractTarFile(root *os.Root, name string, hdr *tar.Header, tr io.Reader) error {
+	if err := root.MkdirAll(path.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := root.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, hdr.FileInfo().Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, tr); err != nil { //nolint:gosec // the stream is written to disk as given, as tar would
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return root.Chtimes(name, hdr.ModTime, hdr.ModTime)
+}
+
+// WriteTar writes the files under dir to w as an uncompressed tar stream,
+// with paths relative to dir. Directories are written before their
+// contents; links and special files are rejected.
+func WriteTar(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return fmt.Errorf("%s: unsupported file type", p)
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(p) //nolint:gosec // p is within dir
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("writing tar stream: %w", err)
+	}
+	return tw.Close()
+}
@@ -0,0 +1,116 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tarStream builds a tar stream from headers, writing content for regular files.
+func tarStream(t *testing.T, entries ...*tar.Header) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range entries {
+		content := []byte("content of " + hdr.Name)
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(content))
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		if hdr.Typeflag == tar.TypeReg {
+			_, err := tw.Write(content)
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func TestExtractTar(t *testing.T) {
+	mtime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	stream := tarStream(t,
+		&tar.Header{Typeflag: tar.TypeXGlobalHeader, Name: "pax_global_header", PAXRecords: map[string]string{"comment": "abc123"}},
+		&tar.Header{Typeflag: tar.TypeDir, Name: "./", Mode: 0o755},
+		&tar.Header{Typeflag: tar.TypeDir, Name: "./etc/", Mode: 0o755},
+		&tar.Header{Typeflag: tar.TypeReg, Name: "./etc/app.conf", Mode: 0o600, ModTime: mtime},
+		&tar.Header{Typeflag: tar.TypeReg, Name: "bin/tool", Mode: 0o755, ModTime: mtime},
+	)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err := zw.Write(stream)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	for name, data := range map[string][]byte{"tar": stream, "tar.gz": gz.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			n, err := ExtractTar(bytes.NewReader(data), dir)
+			require.NoError(t, err)
+			assert.Equal(t, 2, n)
+
+			content, err := os.ReadFile(filepath.Join(dir, "etc", "app.conf"))
+			require.NoError(t, err)
+			assert.Equal(t, "content of ./etc/app.conf", string(content))
+
+			info, err := os.Stat(filepath.Join(dir, "bin", "tool"))
+			require.NoError(t, err)
+			assert.True(t, info.ModTime().Equal(mtime))
+			if os.PathSeparator == '/' {
+				assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestExtractTar_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		hdr     *tar.Header
+		wantErr string
+	}{
+		{"parent path", &tar.Header{Typeflag: tar.TypeReg, Name: "../evil", Mode: 0o644}, "escapes the archive root"},
+		{"nested parent path", &tar.Header{Typeflag: tar.TypeReg, Name: "a/../../evil", Mode: 0o644}, "escapes the archive root"},
+		{"absolute path", &tar.Header{Typeflag: tar.TypeReg, Name: "/etc/passwd", Mode: 0o644}, "escapes the archive root"},
+		{"symlink", &tar.Header{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "/etc/passwd"}, "links are not supported"},
+		{"hard link", &tar.Header{Typeflag: tar.TypeLink, Name: "link", Linkname: "file"}, "links are not supported"},
+		{"fifo", &tar.Header{Typeflag: tar.TypeFifo, Name: "pipe"}, "unsupported type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			_, err := ExtractTar(bytes.NewReader(tarStream(t, tt.hdr)), dir)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestExtractTar_NotTar(t *testing.T) {
+	_, err := ExtractTar(bytes.NewReader(bytes.Repeat([]byte("not a tar stream"), 64)), t.TempDir())
+	require.ErrorContains(t, err, "reading tar stream")
+}
+
+func TestWriteTar(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "blobs", "sha256"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "index.json"), []byte("{}"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "blobs", "sha256", "abc"), []byte("data"), 0o644))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTar(&buf, src))
+
+	dest := t.TempDir()
+	files, err := ExtractTar(&buf, dest)
+	require.NoError(t, err)
+	assert.Equal(t, 2, files)
+	content, err := os.ReadFile(filepath.Join(dest, "blobs", "sha256", "abc"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(content))
+}
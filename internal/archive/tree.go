@@ -3,6 +3,7 @@ package archive
 import (
 	"fmt"
 	"io"
+	"iter"
 )
 
 // Tree drawing characters (Unicode box-drawing).
@@ -71,6 +72,30 @@ func (p *TreePrinter) printChildren(children []*DirEntry, prefix string) {
 	}
 }
 
+// Walk yields every entry below root in depth-first order, together with
+// its depth (1 for the root's children). The root itself is not yielded.
+// Stopping the iteration early avoids visiting the rest of the tree.
+func Walk(root *DirEntry, dirsFirst bool) iter.Seq2[*DirEntry, int] {
+	return func(yield func(*DirEntry, int) bool) {
+		walkRecursive(root, 1, dirsFirst, yield)
+	}
+}
+
+func walkRecursive(entry *DirEntry, depth int, dirsFirst bool, yield func(*DirEntry, int) bool) bool {
+	if dirsFirst {
+		SortDirsFirst(entry.Children)
+	}
+	for _, child := range entry.Children {
+		if !yield(child, depth) {
+			return false
+		}
+		if !walkRecursive(child, depth+1, dirsFirst, yield) {
+			return false
+		}
+	}
+	return true
+}
+
 // Counts returns the number of directories and files in a tree.
 // The root directory is not counted.
 func Counts(root *DirEntry) (dirs, files int) {
@@ -207,3 +207,50 @@ func TestCounts_OnlyDirs(t *testing.T) {
 	assert.Equal(t, 2, dirs)
 	assert.Equal(t, 0, files)
 }
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+
+	root := &DirEntry{
+		Name:  ".",
+		IsDir: true,
+		Children: []*DirEntry{
+			{Name: "a.txt"},
+			{Name: "etc", IsDir: true, Children: []*DirEntry{
+				{Name: "app.yaml"},
+			}},
+		},
+	}
+
+	var names []string
+	var depths []int
+	for entry, depth := range Walk(root, true) {
+		names = append(names, entry.Name)
+		depths = append(depths, depth)
+	}
+
+	assert.Equal(t, []string{"etc", "app.yaml", "a.txt"}, names)
+	assert.Equal(t, []int{1, 2, 1}, depths)
+}
+
+func TestWalk_StopEarly(t *testing.T) {
+	t.Parallel()
+
+	root := &DirEntry{
+		Name:  ".",
+		IsDir: true,
+		Children: []*DirEntry{
+			{Name: "a", IsDir: true, Children: []*DirEntry{{Name: "b.txt"}}},
+			{Name: "c.txt"},
+		},
+	}
+
+	var count int
+	for range Walk(root, false) {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,190 @@
+// Package audit keeps a local journal of changes blob commands make to the
+// configuration file, so changes to a shared workstation or CI config can
+// be traced.
+//
+// The journal sits next to the config file (config.yaml is journaled in
+// config.audit.jsonl) and holds one JSON line per changed key, with the
+// old and new values, the time, the user, and the command that made it.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Entry records one changed configuration key. An empty Old means the key
+// was added; an empty New means it was removed.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Command string    `json:"command"`
+	Key     string    `json:"key"`
+	Old     string    `json:"old,omitempty"`
+	New     string    `json:"new,omitempty"`
+}
+
+// Path returns the journal path for the config file at configPath.
+func Path(configPath string) string {
+	return strings.TrimSuffix(configPath, filepath.Ext(configPath)) + ".audit.jsonl"
+}
+
+// Record appends entries to the journal of the config file at configPath.
+// Entries without a time or user get the current ones.
+func Record(configPath string, entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	path := Path(configPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating audit directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600) //nolint:gosec // path is derived from the config path
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+
+	now := time.Now().UTC()
+	name := currentUser()
+	enc := json.NewEncoder(f)
+	for _, e := range entries {
+		if e.Time.IsZero() {
+			e.Time = now
+		}
+		if e.User == "" {
+			e.User = name
+		}
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return fmt.Errorf("writing audit log: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries in the journal of the config file at
+// configPath, oldest first. A missing journal has no entries.
+func Read(configPath string) ([]Entry, error) {
+	f, err := os.Open(Path(configPath)) //nolint:gosec // path is derived from the config path
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("reading audit log: line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Changes compares two versions of a YAML config file and returns an entry
+// for each key whose value differs, in key order. Keys are dotted paths
+// such as "aliases.configs" or "cache.enabled".
+func Changes(command string, before, after []byte) ([]Entry, error) {
+	oldValues, err := flatten(before)
+	if err != nil {
+		return nil, fmt.Errorf("parsing previous config: %w", err)
+	}
+	newValues, err := flatten(after)
+	if err != nil {
+		return nil, fmt.Errorf("parsing new config: %w", err)
+	}
+
+	keys := slices.Collect(maps.Keys(oldValues))
+	for k := range newValues {
+		if _, ok := oldValues[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	var entries []Entry
+	for _, k := range keys {
+		if oldValues[k] != newValues[k] {
+			entries = append(entries, Entry{Command: command, Key: k, Old: oldValues[k], New: newValues[k]})
+		}
+	}
+	return entries, nil
+}
+
+// flatten maps each scalar in a YAML document to its dotted key. Lists
+// are recorded whole, as JSON, under the key of the list.
+func flatten(data []byte) (map[string]string, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	var walk func(prefix string, v any) error
+	walk = func(prefix string, v any) error {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if err := walk(join(prefix, k), child); err != nil {
+					return err
+				}
+			}
+		case []any:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			values[prefix] = string(b)
+		case nil:
+		default:
+			values[prefix] = fmt.Sprint(v)
+		}
+		return nil
+	}
+	if err := walk("", doc); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// currentUser returns the name of the user running blob.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPath(t *testing.T) {
+	assert.Equal(t, "/home/u/.config/blob/config.audit.jsonl", Path("/home/u/.config/blob/config.yaml"))
+	assert.Equal(t, "/etc/blob.audit.jsonl", Path("/etc/blob"))
+}
+
+func TestRecordRead(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "blob", "config.yaml")
+
+	entries, err := Read(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, Record(configPath,
+		Entry{Time: at, User: "alice", Command: "alias set", Key: "aliases.prod", New: "ghcr.io/acme/app:v1"},
+	))
+	require.NoError(t, Record(configPath,
+		Entry{Command: "alias remove", Key: "aliases.prod", Old: "ghcr.io/acme/app:v1"},
+	))
+
+	entries, err = Read(configPath)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, Entry{Time: at, User: "alice", Command: "alias set", Key: "aliases.prod", New: "ghcr.io/acme/app:v1"}, entries[0])
+	assert.Equal(t, "alias remove", entries[1].Command)
+	assert.False(t, entries[1].Time.IsZero())
+	assert.NotEmpty(t, entries[1].User)
+
+	info, err := os.Stat(Path(configPath))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestChanges(t *testing.T) {
+	before := []byte(`output: text
+cache:
+  enabled: true
+aliases:
+  prod: ghcr.io/acme/app:v1
+  dev: ghcr.io/acme/app:dev
+policies:
+  - match: ghcr\.io/acme/.*
+`)
+	after := []byte(`output: json
+cache:
+  enabled: true
+aliases:
+  prod: ghcr.io/acme/app:v2
+  staging: ghcr.io/acme/app:rc
+policies: []
+`)
+
+	entries, err := Changes("config edit", before, after)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Command: "config edit", Key: "aliases.dev", Old: "ghcr.io/acme/app:dev"},
+		{Command: "config edit", Key: "aliases.prod", Old: "ghcr.io/acme/app:v1", New: "ghcr.io/acme/app:v2"},
+		{Command: "config edit", Key: "aliases.staging", New: "ghcr.io/acme/app:rc"},
+		{Command: "config edit", Key: "output", Old: "text", New: "json"},
+		{Command: "config edit", Key: "policies", Old: `[{"match":"ghcr\\.io/acme/.*"}]`, New: "[]"},
+	}, entries)
+
+	_, err = Changes("config edit", before, []byte("output: [unclosed"))
+	require.Error(t, err)
+}
@@ -0,0 +1,16 @@
+package cachegc
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// accessTime returns the access time of the file described by info.
+func accessTime(info fs.FileInfo) (time.Time, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(st.Atimespec.Unix()), true
+}
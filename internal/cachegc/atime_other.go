@@ -0,0 +1,14 @@
+//go:build !(darwin || linux || windows)
+
+package cachegc
+
+import (
+	"io/fs"
+	"time"
+)
+
+// accessTime reports no access time on platforms where it is not read;
+// entries are ordered by modification time alone.
+func accessTime(fs.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
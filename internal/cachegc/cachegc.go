@@ -0,0 +1,137 @@
+// Package cachegc keeps the caches under a size limit by evicting the
+// least recently used entries.
+//
+// Each cache also limits its own size, but only while a process has it
+// open and only for that cache. Collect enforces cache.max_size over all
+// of them together. The caches do not record hits, so an entry's last use
+// is the later of its access and modification times; on file systems
+// mounted without access times, or where the platform does not expose
+// them, this is when the entry was written.
+package cachegc
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/meigma/blob-cli/internal/cachestats"
+)
+
+// Caches lists the cache subdirectories that are collected.
+var Caches = []string{"content", "blocks", "refs", "manifests", "indexes"}
+
+// Entry is a cached file.
+type Entry struct {
+	Cache    string    `json:"cache"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// Result describes a collection.
+type Result struct {
+	MaxSize      int64   `json:"max_size"`
+	SizeBefore   int64   `json:"size_before"`
+	SizeAfter    int64   `json:"size_after"`
+	EvictedBytes int64   `json:"evicted_bytes"`
+	DryRun       bool    `json:"dry_run,omitempty"`
+	Evicted      []Entry `json:"evicted"`
+}
+
+// Options configures Collect.
+type Options struct {
+	// DryRun reports the entries that would be evicted without removing
+	// them.
+	DryRun bool
+
+	// Stats, if not nil, records each eviction.
+	Stats *cachestats.Recorder
+}
+
+// Collect evicts the least recently used entries of the caches in
+// cacheDir until their combined size is at most maxSize. A maxSize of 0 or
+// less evicts nothing. Entries that cannot be removed, typically because
+// another process removed them first, are skipped.
+func Collect(cacheDir string, maxSize int64, opts Options) (*Result, error) {
+	entries, err := scan(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{MaxSize: maxSize, DryRun: opts.DryRun, Evicted: []Entry{}}
+	for _, e := range entries {
+		result.SizeBefore += e.Size
+	}
+	result.SizeAfter = result.SizeBefore
+	if maxSize <= 0 || result.SizeBefore <= maxSize {
+		return result, nil
+	}
+
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return cmp.Or(a.LastUsed.Compare(b.LastUsed), cmp.Compare(a.Path, b.Path))
+	})
+	for _, e := range entries {
+		if result.SizeAfter <= maxSize {
+			break
+		}
+		if !opts.DryRun {
+			if err := os.Remove(e.Path); err != nil {
+				continue
+			}
+			if opts.Stats != nil {
+				opts.Stats.Evict(e.Cache, e.Size)
+			}
+		}
+		result.Evicted = append(result.Evicted, e)
+		result.EvictedBytes += e.Size
+		result.SizeAfter -= e.Size
+	}
+	return result, nil
+}
+
+// scan returns the files in the caches under cacheDir. Missing caches
+// have no entries.
+func scan(cacheDir string) ([]Entry, error) {
+	var entries []Entry
+	for _, name := range Caches {
+		err := filepath.WalkDir(filepath.Join(cacheDir, name), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil //nolint:nilerr // removed since it was listed
+			}
+			entries = append(entries, Entry{
+				Cache:    name,
+				Path:     path,
+				Size:     info.Size(),
+				LastUsed: lastUsed(info),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// lastUsed returns the later of the access and modification times of the
+// file described by info.
+func lastUsed(info fs.FileInfo) time.Time {
+	mtime := info.ModTime()
+	if atime, ok := accessTime(info); ok && atime.After(mtime) {
+		return atime
+	}
+	return mtime
+}
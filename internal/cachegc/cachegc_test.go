@@ -0,0 +1,96 @@
+package cachegc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/meigma/blob-cli/internal/cachestats"
+)
+
+// writeEntry writes a cache file of size bytes last used at used.
+func writeEntry(t *testing.T, cacheDir, cache, name string, size int, used time.Time) string {
+	t.Helper()
+	path := filepath.Join(cacheDir, cache, name[:2], name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o600))
+	require.NoError(t, os.Chtimes(path, used, used))
+	return path
+}
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	oldest := writeEntry(t, dir, "content", "aa01", 40, now.Add(-3*time.Hour))
+	older := writeEntry(t, dir, "manifests", "bb01", 30, now.Add(-2*time.Hour))
+	recent := writeEntry(t, dir, "blocks", "cc01", 20, now.Add(-time.Hour))
+	newest := writeEntry(t, dir, "content", "dd01", 10, now)
+	// Other files in the cache directory are left alone
+	other := filepath.Join(dir, "completion", "refs.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(other), 0o750))
+	require.NoError(t, os.WriteFile(other, []byte(strings.Repeat("x", 100)), 0o600))
+
+	dry, err := Collect(dir, 35, Options{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), dry.SizeBefore)
+	assert.Equal(t, int64(30), dry.SizeAfter)
+	assert.Equal(t, int64(70), dry.EvictedBytes)
+	require.Len(t, dry.Evicted, 2)
+	assert.Equal(t, oldest, dry.Evicted[0].Path)
+	assert.Equal(t, older, dry.Evicted[1].Path)
+	assert.FileExists(t, oldest, "a dry run removes nothing")
+
+	rec := cachestats.NewRecorder(dir, 0)
+	result, err := Collect(dir, 35, Options{Stats: rec})
+	require.NoError(t, err)
+	require.NoError(t, rec.Flush())
+	assert.Equal(t, int64(30), result.SizeAfter)
+	assert.NoFileExists(t, oldest)
+	assert.NoFileExists(t, older)
+	assert.FileExists(t, recent)
+	assert.FileExists(t, newest)
+	assert.FileExists(t, other)
+
+	stats, err := cachestats.Read(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats["content"].Evictions)
+	assert.Equal(t, int64(40), stats["content"].EvictedBytes)
+	assert.Equal(t, int64(30), stats["manifests"].EvictedBytes)
+}
+
+func TestCollect_UnderLimit(t *testing.T) {
+	dir := t.TempDir()
+	writeEntry(t, dir, "refs", "aa01", 10, time.Now())
+
+	for _, maxSize := range []int64{0, 10, 100} {
+		result, err := Collect(dir, maxSize, Options{})
+		require.NoError(t, err)
+		assert.Empty(t, result.Evicted)
+		assert.Equal(t, int64(10), result.SizeAfter)
+	}
+}
+
+func TestCollect_AccessTime(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	written := writeEntry(t, dir, "content", "aa01", 10, now.Add(-2*time.Hour))
+	read := writeEntry(t, dir, "content", "bb01", 10, now.Add(-3*time.Hour))
+	// Read recently, though written earlier
+	require.NoError(t, os.Chtimes(read, now, now.Add(-3*time.Hour)))
+
+	info, err := os.Stat(read)
+	require.NoError(t, err)
+	if _, ok := accessTime(info); !ok {
+		t.Skip("access times are not available on this platform")
+	}
+
+	result, err := Collect(dir, 10, Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Evicted, 1)
+	assert.Equal(t, written, result.Evicted[0].Path)
+}
@@ -0,0 +1,181 @@
+// Package cachepin keeps pinned archives in the cache directory, so they
+// can be read without the registry.
+//
+// A pinned archive is kept whole, apart from the caches that blob cache gc
+// and blob cache clear manage, so it is never evicted. The pins directory
+// has the following layout:
+//
+//	pins/pins.json             the pinned references
+//	pins/<algo>-<hex>/index    archive index, by manifest digest
+//	pins/<algo>-<hex>/data     archive data
+//
+// Several references may pin the same archive; its files are removed with
+// the last of them.
+package cachepin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Dir is the name of the pins directory within the cache directory.
+const Dir = "pins"
+
+// fileName is the name of the list of pins within Dir.
+const fileName = "pins.json"
+
+// ErrNotPinned is returned by Remove for a reference that is not pinned.
+var ErrNotPinned = errors.New("not pinned")
+
+// Pin is a pinned reference.
+type Pin struct {
+	Ref         string            `json:"ref"`
+	Repository  string            `json:"repository"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Size        int64             `json:"size"`
+	Verified    bool              `json:"verified"`
+	PinnedAt    time.Time         `json:"pinned_at"`
+}
+
+// pinsFile is the JSON form of the list of pins.
+type pinsFile struct {
+	Pins []Pin `json:"pins"`
+}
+
+// ArchiveDir returns the directory holding the pinned archive with the
+// given manifest digest.
+func ArchiveDir(cacheDir, digest string) string {
+	return filepath.Join(cacheDir, Dir, strings.Replace(digest, ":", "-", 1))
+}
+
+// IndexPath returns the path of the index of a pinned archive.
+func IndexPath(cacheDir, digest string) string {
+	return filepath.Join(ArchiveDir(cacheDir, digest), "index")
+}
+
+// DataPath returns the path of the data of a pinned archive.
+func DataPath(cacheDir, digest string) string {
+	return filepath.Join(ArchiveDir(cacheDir, digest), "data")
+}
+
+// List returns the pins in cacheDir, sorted by reference.
+func List(cacheDir string) ([]Pin, error) {
+	data, err := os.ReadFile(filepath.Join(cacheDir, Dir, fileName)) //nolint:gosec // path is derived from the cache directory
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading pins: %w", err)
+	}
+	var f pinsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing pins: %w", err)
+	}
+	return f.Pins, nil
+}
+
+// Lookup returns the pin for ref. A reference by digest finds a pin of
+// that digest in the same repository.
+func Lookup(cacheDir, ref string) (Pin, bool) {
+	pins, err := List(cacheDir)
+	if err != nil {
+		return Pin{}, false
+	}
+	for _, p := range pins {
+		if p.Ref == ref || p.Repository+"@"+p.Digest == ref {
+			return p, true
+		}
+	}
+	return Pin{}, false
+}
+
+// Add records pin, replacing any pin of the same reference. The archive
+// must already be in place at IndexPath and DataPath. An archive the
+// reference pinned before is removed if nothing else pins it.
+func Add(cacheDir string, pin Pin) error {
+	pins, err := List(cacheDir)
+	if err != nil {
+		return err
+	}
+	var replaced []Pin
+	pins = slices.DeleteFunc(pins, func(p Pin) bool {
+		if p.Ref == pin.Ref {
+			replaced = append(replaced, p)
+			return true
+		}
+		return false
+	})
+	pins = append(pins, pin)
+	slices.SortFunc(pins, func(a, b Pin) int { return strings.Compare(a.Ref, b.Ref) })
+	if err := write(cacheDir, pins); err != nil {
+		return err
+	}
+	return removeUnused(cacheDir, pins, replaced)
+}
+
+// Remove removes the pin of ref and, if nothing else pins it, its archive.
+// It returns ErrNotPinned if ref is not pinned.
+func Remove(cacheDir, ref string) (Pin, error) {
+	pins, err := List(cacheDir)
+	if err != nil {
+		return Pin{}, err
+	}
+	i := slices.IndexFunc(pins, func(p Pin) bool { return p.Ref == ref })
+	if i < 0 {
+		return Pin{}, fmt.Errorf("%s is %w", ref, ErrNotPinned)
+	}
+	removed := pins[i]
+	pins = slices.Delete(pins, i, i+1)
+	if err := write(cacheDir, pins); err != nil {
+		return Pin{}, err
+	}
+	return removed, removeUnused(cacheDir, pins, []Pin{removed})
+}
+
+// removeUnused removes the archives of removed that none of pins use.
+func removeUnused(cacheDir string, pins, removed []Pin) error {
+	for _, r := range removed {
+		if slices.ContainsFunc(pins, func(p Pin) bool { return p.Digest == r.Digest }) {
+			continue
+		}
+		if err := os.RemoveAll(ArchiveDir(cacheDir, r.Digest)); err != nil {
+			return fmt.Errorf("removing pinned archive: %w", err)
+		}
+	}
+	return nil
+}
+
+// write replaces the list of pins atomically.
+func write(cacheDir string, pins []Pin) error {
+	dir := filepath.Join(cacheDir, Dir)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("creating pins directory: %w", err)
+	}
+	data, err := json.MarshalIndent(pinsFile{Pins: pins}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding pins: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, fileName+"-*")
+	if err != nil {
+		return fmt.Errorf("writing pins: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // gone after a successful rename
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing pins: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing pins: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, fileName)); err != nil {
+		return fmt.Errorf("writing pins: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package cachepin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addPin writes a placeholder archive for digest and pins ref to it.
+func addPin(t *testing.T, cacheDir, ref, digest string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(ArchiveDir(cacheDir, digest), 0o750))
+	require.NoError(t, os.WriteFile(IndexPath(cacheDir, digest), []byte("index"), 0o600))
+	require.NoError(t, os.WriteFile(DataPath(cacheDir, digest), []byte("data"), 0o600))
+	require.NoError(t, Add(cacheDir, Pin{Ref: ref, Repository: "ghcr.io/acme/app", Digest: digest}))
+}
+
+func TestPins(t *testing.T) {
+	dir := t.TempDir()
+	pins, err := List(dir)
+	require.NoError(t, err)
+	assert.Empty(t, pins)
+
+	addPin(t, dir, "ghcr.io/acme/app:v2", "sha256:bbb")
+	addPin(t, dir, "ghcr.io/acme/app:v1", "sha256:aaa")
+	addPin(t, dir, "ghcr.io/acme/app:stable", "sha256:aaa")
+
+	pins, err = List(dir)
+	require.NoError(t, err)
+	require.Len(t, pins, 3)
+	assert.Equal(t, "ghcr.io/acme/app:stable", pins[0].Ref, "sorted by reference")
+
+	p, ok := Lookup(dir, "ghcr.io/acme/app:v1")
+	require.True(t, ok)
+	assert.Equal(t, "sha256:aaa", p.Digest)
+	p, ok = Lookup(dir, "ghcr.io/acme/app@sha256:bbb")
+	require.True(t, ok)
+	assert.Equal(t, "ghcr.io/acme/app:v2", p.Ref)
+	_, ok = Lookup(dir, "ghcr.io/acme/other@sha256:bbb")
+	assert.False(t, ok)
+
+	// The archive stays while another reference pins it
+	_, err = Remove(dir, "ghcr.io/acme/app:v1")
+	require.NoError(t, err)
+	assert.DirExists(t, ArchiveDir(dir, "sha256:aaa"))
+	_, err = Remove(dir, "ghcr.io/acme/app:stable")
+	require.NoError(t, err)
+	assert.NoDirExists(t, ArchiveDir(dir, "sha256:aaa"))
+
+	_, err = Remove(dir, "ghcr.io/acme/app:v1")
+	require.ErrorIs(t, err, ErrNotPinned)
+}
+
+func TestAdd_Repin(t *testing.T) {
+	dir := t.TempDir()
+	addPin(t, dir, "ghcr.io/acme/app:v1", "sha256:aaa")
+	addPin(t, dir, "ghcr.io/acme/app:v1", "sha256:bbb")
+
+	pins, err := List(dir)
+	require.NoError(t, err)
+	require.Len(t, pins, 1)
+	assert.Equal(t, "sha256:bbb", pins[0].Digest)
+	assert.NoDirExists(t, ArchiveDir(dir, "sha256:aaa"), "the archive pinned before is removed")
+	assert.FileExists(t, filepath.Join(dir, Dir, "sha256-bbb", "data"))
+}
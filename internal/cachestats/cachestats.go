@@ -0,0 +1,209 @@
+// Package cachestats counts cache hits, misses, and evictions across blob
+// processes.
+//
+// Each process records events with a Recorder, which periodically appends
+// its counters to a shared log in the cache directory. Read sums the log,
+// so a watcher sees the combined activity of every process using the cache.
+package cachestats
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the name of the stats log within the cache directory.
+const FileName = "stats.ndjson"
+
+// DefaultFlushInterval is how often a Recorder appends pending counters.
+const DefaultFlushInterval = time.Second
+
+// Counters holds event counts for a single cache type.
+type Counters struct {
+	Hits         int64 `json:"hits"`
+	Misses       int64 `json:"misses"`
+	Evictions    int64 `json:"evictions"`
+	EvictedBytes int64 `json:"evicted_bytes"`
+}
+
+// Add returns the sum of c and o.
+func (c Counters) Add(o Counters) Counters {
+	return Counters{
+		Hits:         c.Hits + o.Hits,
+		Misses:       c.Misses + o.Misses,
+		Evictions:    c.Evictions + o.Evictions,
+		EvictedBytes: c.EvictedBytes + o.EvictedBytes,
+	}
+}
+
+// Sub returns c minus o.
+func (c Counters) Sub(o Counters) Counters {
+	return Counters{
+		Hits:         c.Hits - o.Hits,
+		Misses:       c.Misses - o.Misses,
+		Evictions:    c.Evictions - o.Evictions,
+		EvictedBytes: c.EvictedBytes - o.EvictedBytes,
+	}
+}
+
+// HitRate returns hits / (hits + misses), or 0 if there were no lookups.
+func (c Counters) HitRate() float64 {
+	total := c.Hits + c.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(total)
+}
+
+// Snapshot maps cache type names to counters.
+type Snapshot map[string]Counters
+
+// entry is a single line of the stats log.
+type entry struct {
+	Time   time.Time `json:"time"`
+	PID    int       `json:"pid"`
+	Caches Snapshot  `json:"caches"`
+}
+
+// Recorder accumulates events for one process and appends them to the
+// stats log. It is safe for concurrent use.
+type Recorder struct {
+	mu        sync.Mutex
+	path      string
+	interval  time.Duration
+	pending   Snapshot
+	totals    Snapshot
+	lastFlush time.Time
+	now       func() time.Time
+}
+
+// NewRecorder creates a recorder that writes to the stats log in cacheDir.
+// Pending counters are appended at most once per interval while events
+// arrive; call Flush before exiting to write the remainder.
+func NewRecorder(cacheDir string, interval time.Duration) *Recorder {
+	return &Recorder{
+		path:      filepath.Join(cacheDir, FileName),
+		interval:  interval,
+		pending:   make(Snapshot),
+		totals:    make(Snapshot),
+		lastFlush: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Hit records a cache hit.
+func (r *Recorder) Hit(name string) {
+	r.record(name, Counters{Hits: 1})
+}
+
+// Miss records a cache miss.
+func (r *Recorder) Miss(name string) {
+	r.record(name, Counters{Misses: 1})
+}
+
+// Evict records an eviction that freed the given number of bytes.
+func (r *Recorder) Evict(name string, bytes int64) {
+	r.record(name, Counters{Evictions: 1, EvictedBytes: bytes})
+}
+
+func (r *Recorder) record(name string, delta Counters) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending[name] = r.pending[name].Add(delta)
+	r.totals[name] = r.totals[name].Add(delta)
+	if r.interval > 0 && r.now().Sub(r.lastFlush) >= r.interval {
+		_ = r.flushLocked() //nolint:errcheck // stats are best-effort; the next flush retries
+	}
+}
+
+// Totals returns every event recorded by this recorder, flushed or not.
+func (r *Recorder) Totals() Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.totals)
+}
+
+// Flush appends pending counters to the stats log.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.flushLocked()
+}
+
+func (r *Recorder) flushLocked() error {
+	r.lastFlush = r.now()
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	line, err := json.Marshal(entry{Time: r.lastFlush.UTC(), PID: os.Getpid(), Caches: r.pending})
+	if err != nil {
+		return fmt.Errorf("encoding cache stats: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o750); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	// A single O_APPEND write keeps lines from concurrent processes intact.
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening cache stats: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("writing cache stats: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing cache stats: %w", err)
+	}
+
+	r.pending = make(Snapshot)
+	return nil
+}
+
+// Read sums the stats log in cacheDir. A missing log yields an empty
+// snapshot. Lines that cannot be parsed (e.g., a partial write) are skipped.
+func Read(cacheDir string) (Snapshot, error) {
+	total := make(Snapshot)
+
+	f, err := os.Open(filepath.Join(cacheDir, FileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return total, nil
+		}
+		return nil, fmt.Errorf("opening cache stats: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		for name, c := range e.Caches {
+			total[name] = total[name].Add(c)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading cache stats: %w", err)
+	}
+
+	return total, nil
+}
+
+// Reset removes the stats log in cacheDir.
+func Reset(cacheDir string) error {
+	err := os.Remove(filepath.Join(cacheDir, FileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing cache stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package cachestats
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	coredisk "github.com/meigma/blob/core/cache/disk"
+	registrydisk "github.com/meigma/blob/registry/cache/disk"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_FlushAndRead(t *testing.T) {
+	dir := t.TempDir()
+
+	a := NewRecorder(dir, 0)
+	a.Hit("content")
+	a.Hit("content")
+	a.Miss("content")
+	require.NoError(t, a.Flush())
+
+	b := NewRecorder(dir, 0)
+	b.Miss("refs")
+	b.Evict("content", 100)
+	require.NoError(t, b.Flush())
+
+	// Flushing with nothing pending writes nothing.
+	require.NoError(t, b.Flush())
+
+	// Totals cover the recorder's own events, flushed or not.
+	a.Hit("refs")
+	assert.Equal(t, Snapshot{
+		"content": {Hits: 2, Misses: 1},
+		"refs":    {Hits: 1},
+	}, a.Totals())
+
+	snap, err := Read(dir)
+	require.NoError(t, err)
+	assert.Equal(t, Counters{Hits: 2, Misses: 1, Evictions: 1, EvictedBytes: 100}, snap["content"])
+	assert.Equal(t, Counters{Misses: 1}, snap["refs"])
+
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(data, []byte("\n")))
+}
+
+func TestRecorder_PeriodicFlush(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	r := NewRecorder(dir, time.Second)
+	r.now = func() time.Time { return now }
+	r.lastFlush = now
+
+	r.Hit("blocks")
+	snap, err := Read(dir)
+	require.NoError(t, err)
+	assert.Empty(t, snap, "nothing flushed before the interval")
+
+	now = now.Add(time.Second)
+	r.Hit("blocks")
+	snap, err = Read(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), snap["blocks"].Hits)
+}
+
+func TestRead(t *testing.T) {
+	t.Run("missing log", func(t *testing.T) {
+		snap, err := Read(t.TempDir())
+		require.NoError(t, err)
+		assert.Empty(t, snap)
+	})
+
+	t.Run("skips partial lines", func(t *testing.T) {
+		dir := t.TempDir()
+		log := `{"time":"2026-01-01T00:00:00Z","pid":1,"caches":{"indexes":{"hits":3}}}` + "\n" + `{"time":"2026-01-`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(log), 0o600))
+
+		snap, err := Read(dir)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), snap["indexes"].Hits)
+	})
+}
+
+func TestReset(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRecorder(dir, 0)
+	r.Hit("content")
+	require.NoError(t, r.Flush())
+
+	require.NoError(t, Reset(dir))
+	assert.NoFileExists(t, filepath.Join(dir, FileName))
+	require.NoError(t, Reset(dir), "resetting twice is not an error")
+}
+
+func TestCounters_HitRate(t *testing.T) {
+	assert.InDelta(t, 0.0, Counters{}.HitRate(), 0.001)
+	assert.InDelta(t, 0.75, Counters{Hits: 3, Misses: 1}.HitRate(), 0.001)
+}
+
+func TestLogger_ContentCache(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRecorder(dir, 0)
+
+	cache, err := coredisk.New(filepath.Join(dir, "content"), coredisk.WithLogger(r.Logger("content")))
+	require.NoError(t, err)
+
+	hash := []byte{0xab, 0xcd}
+	_, ok := cache.Get(hash)
+	require.False(t, ok)
+
+	src := filepath.Join(t.TempDir(), "src")
+	require.NoError(t, os.WriteFile(src, []byte("hello"), 0o600))
+	f, err := os.Open(src)
+	require.NoError(t, err)
+	require.NoError(t, cache.Put(hash, f))
+	require.NoError(t, f.Close())
+
+	got, ok := cache.Get(hash)
+	require.True(t, ok)
+	require.NoError(t, got.Close())
+
+	_, err = cache.Prune(0)
+	require.NoError(t, err)
+
+	require.NoError(t, r.Flush())
+	snap, err := Read(dir)
+	require.NoError(t, err)
+	assert.Equal(t, Counters{Hits: 1, Misses: 1, Evictions: 1, EvictedBytes: 5}, snap["content"])
+}
+
+func TestWrapManifestCache_Evictions(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRecorder(dir, 0)
+
+	manifest := func(n int) (string, []byte) {
+		raw := []byte(`{"schemaVersion":2,"n":` + string(rune('0'+n)) + `}`)
+		return digest.FromBytes(raw).String(), raw
+	}
+
+	_, first := manifest(1)
+	inner, err := registrydisk.NewManifestCache(filepath.Join(dir, "manifests"),
+		registrydisk.WithMaxBytes(int64(len(first))))
+	require.NoError(t, err)
+	cache := r.WrapManifestCache("manifests", inner)
+
+	d1, raw1 := manifest(1)
+	require.NoError(t, cache.PutManifest(d1, raw1))
+	// A second entry only fits by evicting the first.
+	d2, raw2 := manifest(2)
+	require.NoError(t, cache.PutManifest(d2, raw2))
+
+	require.NoError(t, r.Flush())
+	snap, err := Read(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), snap["manifests"].Evictions)
+	assert.Equal(t, int64(len(raw1)), snap["manifests"].EvictedBytes)
+}
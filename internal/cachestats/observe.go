@@ -0,0 +1,127 @@
+package cachestats
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	registrycache "github.com/meigma/blob/registry/cache"
+)
+
+// Logger returns a logger to pass to a disk cache. It counts the cache's
+// hit, miss, and prune messages as events for the named cache type and
+// discards everything else.
+func (r *Recorder) Logger(name string) *slog.Logger {
+	return slog.New(&countingHandler{rec: r, name: name})
+}
+
+// countingHandler turns disk cache log records into counter events.
+type countingHandler struct {
+	rec  *Recorder
+	name string
+}
+
+func (h *countingHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *countingHandler) Handle(_ context.Context, record slog.Record) error {
+	msg := record.Message
+	switch {
+	case strings.HasSuffix(msg, " hit"):
+		h.rec.Hit(h.name)
+	case strings.HasSuffix(msg, " miss"), strings.HasSuffix(msg, " expired"):
+		h.rec.Miss(h.name)
+	case strings.HasSuffix(msg, " pruned"):
+		var freed int64
+		record.Attrs(func(a slog.Attr) bool {
+			if a.Key == "bytes_freed" {
+				freed = a.Value.Int64()
+				return false
+			}
+			return true
+		})
+		h.rec.Evict(h.name, freed)
+	}
+	return nil
+}
+
+func (h *countingHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *countingHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// Registry disk caches do not log evictions, so their puts are wrapped and
+// evictions are inferred from the cache size. A put makes room only when the
+// new entry does not fit; the bytes freed are then the difference between
+// the expected and the actual size afterwards. Re-putting an entry that is
+// already cached while the cache is full is miscounted as an eviction, which
+// the client avoids by only putting after a miss.
+
+// WrapRefCache records evictions caused by PutDigest.
+func (r *Recorder) WrapRefCache(name string, c registrycache.RefCache) registrycache.RefCache {
+	return &refCache{RefCache: c, rec: r, name: name}
+}
+
+// WrapManifestCache records evictions caused by PutManifest.
+func (r *Recorder) WrapManifestCache(name string, c registrycache.ManifestCache) registrycache.ManifestCache {
+	return &manifestCache{ManifestCache: c, rec: r, name: name}
+}
+
+// WrapIndexCache records evictions caused by PutIndex.
+func (r *Recorder) WrapIndexCache(name string, c registrycache.IndexCache) registrycache.IndexCache {
+	return &indexCache{IndexCache: c, rec: r, name: name}
+}
+
+type refCache struct {
+	registrycache.RefCache
+	rec  *Recorder
+	name string
+}
+
+func (c *refCache) PutDigest(ref, dgst string) error {
+	before := c.SizeBytes()
+	err := c.RefCache.PutDigest(ref, dgst)
+	c.rec.observePut(c.name, c.MaxBytes(), before, int64(len(dgst)), c.SizeBytes())
+	return err
+}
+
+type manifestCache struct {
+	registrycache.ManifestCache
+	rec  *Recorder
+	name string
+}
+
+func (c *manifestCache) PutManifest(dgst string, raw []byte) error {
+	before := c.SizeBytes()
+	err := c.ManifestCache.PutManifest(dgst, raw)
+	c.rec.observePut(c.name, c.MaxBytes(), before, int64(len(raw)), c.SizeBytes())
+	return err
+}
+
+type indexCache struct {
+	registrycache.IndexCache
+	rec  *Recorder
+	name string
+}
+
+func (c *indexCache) PutIndex(dgst string, raw []byte) error {
+	before := c.SizeBytes()
+	err := c.IndexCache.PutIndex(dgst, raw)
+	c.rec.observePut(c.name, c.MaxBytes(), before, int64(len(raw)), c.SizeBytes())
+	return err
+}
+
+// observePut records an eviction if a put of added bytes into a cache
+// limited to maxBytes had to make room.
+func (r *Recorder) observePut(name string, maxBytes, before, added, after int64) {
+	if maxBytes <= 0 || added > maxBytes || before+added <= maxBytes {
+		return // No limit, entry too large to cache, or it fit
+	}
+	if freed := before + added - after; freed > 0 {
+		r.Evict(name, freed)
+	}
+}
@@ -0,0 +1,118 @@
+// Package clipboard places text on the system clipboard.
+//
+// Locally, the platform's clipboard tool is used (pbcopy, wl-copy, xclip,
+// xsel, or clip.exe). Over SSH, or when no tool is available, the text is
+// sent to the terminal in an OSC 52 escape sequence, which most terminal
+// emulators apply to the clipboard of the machine they run on.
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrUnavailable is returned when there is neither a clipboard tool nor a
+// terminal to send OSC 52 to.
+var ErrUnavailable = errors.New("no clipboard available")
+
+// tool is a command that reads text for the clipboard from stdin.
+type tool struct {
+	name string
+	args []string
+}
+
+// Copy places text on the clipboard and returns how it was placed: the
+// name of the clipboard tool, or "osc52".
+func Copy(text string) (string, error) {
+	if !remote(os.Getenv) {
+		for _, t := range tools(runtime.GOOS, os.Getenv) {
+			path, err := exec.LookPath(t.name)
+			if err != nil {
+				continue
+			}
+			cmd := exec.Command(path, t.args...) //nolint:gosec // fixed clipboard tools
+			cmd.Stdin = strings.NewReader(text)
+			if err := cmd.Run(); err == nil {
+				return t.name, nil
+			}
+		}
+	}
+
+	tty, err := openTerminal()
+	if err != nil {
+		return "", err
+	}
+	defer tty.Close()
+	if _, err := io.WriteString(tty, OSC52(text, os.Getenv("TMUX") != "")); err != nil {
+		return "", fmt.Errorf("writing to terminal: %w", err)
+	}
+	return "osc52", nil
+}
+
+// OSC52 returns the escape sequence that sets the clipboard to text. Inside
+// tmux, the sequence is wrapped so tmux passes it to the outer terminal.
+func OSC52(text string, tmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+	if !tmux {
+		return seq
+	}
+	return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
+
+// remote reports whether the process runs in an SSH session, where local
+// clipboard tools would set the clipboard of the wrong machine.
+func remote(getenv func(string) string) bool {
+	return getenv("SSH_TTY") != "" || getenv("SSH_CONNECTION") != ""
+}
+
+// tools returns the clipboard tools to try, in order of preference.
+func tools(goos string, getenv func(string) string) []tool {
+	switch goos {
+	case "darwin":
+		return []tool{{name: "pbcopy"}}
+	case "windows":
+		return []tool{{name: "clip.exe"}}
+	}
+
+	var ts []tool
+	if getenv("WAYLAND_DISPLAY") != "" {
+		ts = append(ts, tool{name: "wl-copy"})
+	}
+	if getenv("DISPLAY") != "" {
+		ts = append(ts,
+			tool{name: "xclip", args: []string{"-selection", "clipboard"}},
+			tool{name: "xsel", args: []string{"--clipboard", "--input"}},
+		)
+	}
+	if getenv("WSL_DISTRO_NAME") != "" {
+		ts = append(ts, tool{name: "clip.exe"})
+	}
+	return ts
+}
+
+// openTerminal opens the controlling terminal, falling back to stderr if it
+// is a terminal.
+func openTerminal() (io.WriteCloser, error) {
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		return tty, nil
+	}
+	if term.IsTerminal(int(os.Stderr.Fd())) { //nolint:gosec // file descriptors fit in an int
+		return nopCloser{os.Stderr}, nil
+	}
+	return nil, ErrUnavailable
+}
+
+// nopCloser keeps stderr open when the terminal writer is closed.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,42 @@
+package clipboard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOSC52(t *testing.T) {
+	assert.Equal(t, "\x1b]52;c;c2hhMjU2OmFiYw==\x07", OSC52("sha256:abc", false))
+	assert.Equal(t, "\x1bPtmux;\x1b\x1b]52;c;c2hhMjU2OmFiYw==\x07\x1b\\", OSC52("sha256:abc", true))
+}
+
+func TestTools(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+	names := func(ts []tool) []string {
+		var out []string
+		for _, t := range ts {
+			out = append(out, t.name)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"pbcopy"}, names(tools("darwin", env(nil))))
+	assert.Equal(t, []string{"clip.exe"}, names(tools("windows", env(nil))))
+	assert.Equal(t, []string{"wl-copy", "xclip", "xsel"},
+		names(tools("linux", env(map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}))))
+	assert.Equal(t, []string{"clip.exe"}, names(tools("linux", env(map[string]string{"WSL_DISTRO_NAME": "Ubuntu"}))))
+	assert.Empty(t, tools("linux", env(nil)))
+}
+
+func TestRemote(t *testing.T) {
+	assert.True(t, remote(func(key string) string {
+		if key == "SSH_TTY" {
+			return "/dev/pts/0"
+		}
+		return ""
+	}))
+	assert.False(t, remote(func(string) string { return "" }))
+}
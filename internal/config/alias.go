@@ -1,8 +1,15 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"maps"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
 )
 
 // ResolveAlias expands an alias to a full reference.
@@ -44,6 +51,14 @@ func (c *Config) ResolveAlias(name string) string {
 	return ref + ":latest"
 }
 
+// DefaultsFor returns the settings of the alias ref names, if ref is an
+// alias written as a mapping. A tag or digest on ref is ignored.
+func (c *Config) DefaultsFor(ref string) (AliasDefaults, bool) {
+	name, _, _ := parseRef(ref)
+	d, ok := c.AliasDefaults[name]
+	return d, ok
+}
+
 // SetAlias returns a new Config with the alias added or updated.
 // The original Config is not modified.
 func (c *Config) SetAlias(name, ref string) *Config {
@@ -61,9 +76,150 @@ func (c *Config) SetAlias(name, ref string) *Config {
 func (c *Config) RemoveAlias(name string) *Config {
 	newCfg := c.clone()
 	delete(newCfg.Aliases, name)
+	delete(newCfg.AliasDefaults, name)
 	return newCfg
 }
 
+// ErrAliasNotFound is returned by RemoveAliasFromFile for an alias the
+// config file does not define.
+var ErrAliasNotFound = errors.New("alias not found")
+
+// SetAliasInFile adds or updates an alias in the config file at path,
+// leaving the rest of the file as it is. It returns the reference the alias
+// had before, if it existed.
+func SetAliasInFile(path, name, ref string) (previous string, existed bool, err error) {
+	err = Update(path, func(doc map[string]any) error {
+		aliases, err := fileAliases(doc)
+		if err != nil {
+			return err
+		}
+		previous, existed = aliasValue(aliases, name)
+		if entry, ok := aliases[name].(map[string]any); ok {
+			// Keep the settings of an alias written as a mapping
+			entry["ref"] = ref
+		} else {
+			aliases[name] = ref
+		}
+		doc["aliases"] = aliases
+		return nil
+	})
+	return previous, existed, err
+}
+
+// RemoveAliasFromFile removes an alias from the config file at path,
+// leaving the rest of the file as it is, and returns the reference it had.
+func RemoveAliasFromFile(path, name string) (previous string, err error) {
+	err = Update(path, func(doc map[string]any) error {
+		aliases, err := fileAliases(doc)
+		if err != nil {
+			return err
+		}
+		var existed bool
+		if previous, existed = aliasValue(aliases, name); !existed {
+			return ErrAliasNotFound
+		}
+		delete(aliases, name)
+		doc["aliases"] = aliases
+		return nil
+	})
+	return previous, err
+}
+
+// fileAliases returns the aliases mapping of a config file document.
+func fileAliases(doc map[string]any) (map[string]any, error) {
+	switch aliases := doc["aliases"].(type) {
+	case nil:
+		return make(map[string]any), nil
+	case map[string]any:
+		return aliases, nil
+	default:
+		return nil, fmt.Errorf("aliases in config file must be a mapping, got %T", aliases)
+	}
+}
+
+func aliasValue(aliases map[string]any, name string) (string, bool) {
+	v, ok := aliases[name]
+	if !ok {
+		return "", false
+	}
+	if entry, ok := v.(map[string]any); ok {
+		v = entry["ref"]
+	}
+	return fmt.Sprint(v), true
+}
+
+// aliasEntry is an alias written as a mapping.
+type aliasEntry struct {
+	Ref           string `mapstructure:"ref"`
+	AliasDefaults `mapstructure:",squash"`
+}
+
+// decodeAliasRefs is a decode hook reducing aliases written as a mapping
+// to their reference, so both forms decode into an AliasMap.
+func decodeAliasRefs(_, to reflect.Type, data any) (any, error) {
+	entries, ok := data.(map[string]any)
+	if to != reflect.TypeFor[AliasMap]() || !ok {
+		return data, nil
+	}
+	refs := make(map[string]any, len(entries))
+	for name, v := range entries {
+		if entry, ok := v.(map[string]any); ok {
+			v = entry["ref"]
+		}
+		refs[name] = v
+	}
+	return refs, nil
+}
+
+// withAliasRefs adds decodeAliasRefs to viper's decode hooks.
+func withAliasRefs(c *mapstructure.DecoderConfig) {
+	c.DecodeHook = mapstructure.ComposeDecodeHookFunc(decodeAliasRefs, c.DecodeHook)
+}
+
+// decodeAliasDefaults returns the settings of the aliases written as a
+// mapping in raw, the aliases value of the config.
+func decodeAliasDefaults(raw any) (map[string]AliasDefaults, error) {
+	entries, _ := raw.(map[string]any)
+	var defaults map[string]AliasDefaults
+	for name, v := range entries {
+		if _, ok := v.(map[string]any); !ok {
+			continue
+		}
+		var entry aliasEntry
+		if err := mapstructure.Decode(v, &entry); err != nil {
+			return nil, fmt.Errorf("%w: aliases.%s: %v", ErrInvalidConfig, name, err)
+		}
+		if entry.Ref == "" {
+			return nil, fmt.Errorf("%w: aliases.%s.ref cannot be empty", ErrInvalidConfig, name)
+		}
+		if defaults == nil {
+			defaults = make(map[string]AliasDefaults)
+		}
+		defaults[name] = entry.AliasDefaults
+	}
+	return defaults, nil
+}
+
+// aliasPolicyRules returns a policy rule for each alias with a policy,
+// matching every reference to the alias's repository.
+func (c *Config) aliasPolicyRules() []PolicyRule {
+	names := slices.Sorted(maps.Keys(c.AliasDefaults))
+	var rules []PolicyRule
+	for _, name := range names {
+		d := c.AliasDefaults[name]
+		if d.Policy == nil {
+			continue
+		}
+		repo, _, _ := parseRef(c.Aliases[name])
+		rules = append(rules, PolicyRule{
+			Match:  "^" + regexp.QuoteMeta(repo) + "([:@].*)?$",
+			Policy: *d.Policy,
+			Alias:  name,
+		})
+	}
+	return rules
+}
+
 // clone creates a shallow copy of the Config with a deep copy of maps/slices.
 func (c *Config) clone() *Config {
 	newCfg := *c
@@ -72,6 +228,9 @@ func (c *Config) clone() *Config {
 	if c.Aliases != nil {
 		newCfg.Aliases = maps.Clone(c.Aliases)
 	}
+	if c.AliasDefaults != nil {
+		newCfg.AliasDefaults = maps.Clone(c.AliasDefaults)
+	}
 
 	// Deep copy policies slice
 	if c.Policies != nil {
@@ -82,6 +241,12 @@ func (c *Config) clone() *Config {
 	return &newCfg
 }
 
+// SplitRef splits a reference into its repository and its tag or digest,
+// including the separator. ok is false if ref has neither.
+func SplitRef(ref string) (repo, tagOrDigest string, ok bool) {
+	return parseRef(ref)
+}
+
 // parseRef splits a reference into base and tag/digest components.
 // Returns: (base, tagOrDigest including separator, hasTagOrDigest)
 //
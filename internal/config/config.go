@@ -2,11 +2,12 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"reflect"
 
 	"github.com/spf13/viper"
-	"gopkg.in/yaml.v3"
 )
 
 // Load reads configuration from the provided Viper instance and returns a typed Config.
@@ -14,7 +15,7 @@ import (
 // (flags, env, file) have been loaded.
 func Load(v *viper.Viper) (*Config, error) {
 	cfg := &Config{}
-	if err := v.Unmarshal(cfg); err != nil {
+	if err := v.Unmarshal(cfg, withAliasRefs); err != nil {
 		return nil, fmt.Errorf("unmarshaling config: %w", err)
 	}
 
@@ -23,6 +24,28 @@ func Load(v *viper.Viper) (*Config, error) {
 		cfg.Aliases = make(map[string]string)
 	}
 
+	// Aliases written as a mapping may carry a policy
+	defaults, err := decodeAliasDefaults(v.Get("aliases"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.AliasDefaults = defaults
+	cfg.File = v.GetString("internal.config_path")
+	cfg.Policies = append(cfg.Policies, cfg.aliasPolicyRules()...)
+
+	// Environment policies apply in addition to those in the config file
+	envRules, err := envPolicyRules(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Policies = append(cfg.Policies, envRules...)
+
+	// Mirrors from the environment replace those in the config file
+	cfg.Registries, err = applyEnvHosts(cfg.Registries, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := validate(cfg); err != nil {
 		return nil, err
 	}
@@ -37,25 +60,16 @@ func LoadFromViper() (*Config, error) {
 
 // Save writes the config to the specified path as YAML.
 // Creates parent directories if they don't exist.
+//
+// The file is edited in place as with Update: comments and key order are
+// kept, as are keys the config does not define, such as ones from newer
+// blob versions.
 func Save(cfg *Config, path string) error {
-	// Ensure parent directory exists
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o700); err != nil {
-		return fmt.Errorf("creating config directory: %w", err)
-	}
-
-	// Marshal to YAML
-	data, err := yaml.Marshal(cfg)
-	if err != nil {
-		return fmt.Errorf("marshaling config: %w", err)
-	}
-
-	// Write file with appropriate permissions
-	if err := os.WriteFile(path, data, 0o600); err != nil {
-		return fmt.Errorf("writing config file: %w", err)
-	}
-
-	return nil
+	values, _ := encodeConfig(reflect.ValueOf(cfg)).(map[string]any)
+	return Update(path, func(doc map[string]any) error {
+		maps.Copy(doc, values)
+		return nil
+	})
 }
 
 // SaveDefault creates a config file at path with default values.
@@ -74,6 +88,9 @@ output: text
 # Default compression for push: none, zstd
 compression: zstd
 
+# Read only from the local caches and pinned archives, never a registry
+# offline: false
+
 # Cache settings
 cache:
   enabled: true
@@ -93,6 +110,48 @@ cache:
   # indexes:
   #   enabled: false
 
+# Push settings
+push:
+  # Files smaller than this many bytes are stored uncompressed (0 disables)
+  skip_compress_min_size: 1024
+  # Extensions stored uncompressed (default: common already-compressed formats)
+  # skip_compress_extensions: [".png", ".jpg", ".gz", ".zip"]
+
+# Registry request settings
+# registry:
+#   user_agent: acme-deploy/1.0     # default: blob-cli/<version>
+#   extra_headers:                  # added to registry API (/v2/) requests
+#     X-Gateway-Tenant: acme
+
+# Per-registry authentication and connection, on top of ~/.docker/config.json
+# registries:
+#   - host: ghcr.io
+#     username: octocat
+#     password_env: GHCR_TOKEN        # secrets are read from the environment
+#   - host: 123456789012.dkr.ecr.us-east-1.amazonaws.com
+#     credential_helper: ecr-login    # runs docker-credential-ecr-login
+#   - host: registry.internal:5000
+#     ca_file: /etc/ssl/internal-ca.pem  # trusted in addition to system roots
+#   - host: localhost:5000
+#     plain_http: true                # no TLS (also skip_tls_verify: true)
+#   - host: docker.io
+#     mirrors: [mirror.internal:5000] # tried first for reads (also BLOB_HOSTS)
+
+# Private or air-gapped Sigstore deployment (default: public Sigstore)
+# sigstore:
+#   fulcio_url: https://fulcio.sigstore.internal  # keyless signing CA
+#   rekor_url: https://rekor.sigstore.internal    # transparency log
+#   trust_root: /etc/blob/trusted_root.json       # keyless verification root
+#   oidc_issuer: https://oauth2.sigstore.internal/auth  # interactive login
+#   oidc_client_id: sigstore
+
+# ls text output
+ls:
+  color: auto        # auto (only on a terminal), always, never
+  dirs_first: true   # list directories before files
+  # icons: true      # file type icons (requires a Nerd Font)
+  # show_hidden: true  # list dotfiles in ls and tree without -a
+
 # Aliases for frequently used references
 # Usage: blob pull foo:v1 → ghcr.io/acme/repo/foo:v1
 aliases: {}
@@ -109,6 +168,25 @@ policies: []
   #       keyless:
   #         issuer: https://token.actions.githubusercontent.com
   #         identity: https://github.com/acme/*/.github/workflows/*
+  #   mode: enforce                   # or warn: report failures as warnings
+
+# JSON Schemas for content validation (push --validate, pull --validate)
+# Globs are matched against archive paths; ** matches any number of directories
+# Relative schema paths are resolved against this file's directory
+schemas: []
+  # - match: "**/*.config.json"
+  #   schema: ./schemas/config.schema.json
+
+# Hook commands (run through the shell; a non-zero exit aborts the operation)
+# pre_push hooks receive BLOB_PUSH_DIR and BLOB_PUSH_REF in the environment
+# priority_extracted hooks run during pull once the files the archive lists
+# for priority extraction are in place; they receive BLOB_PULL_DIR and
+# BLOB_PULL_REF, and a failure only warns
+hooks:
+  pre_push: []
+  # - gitleaks detect --no-git --source "$BLOB_PUSH_DIR"
+  priority_extracted: []
+  # - systemctl start app
 `
 
 // SaveDefaultWithComments creates a config file at path with default values
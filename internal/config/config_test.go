@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/viper"
@@ -59,6 +60,55 @@ func TestLoad_AliasesInitialized(t *testing.T) {
 	assert.NotNil(t, cfg.Aliases)
 }
 
+func TestLoad_StructuredAliases(t *testing.T) {
+	v := viper.New()
+	SetDefaults(v)
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(strings.NewReader(`
+aliases:
+  plain: ghcr.io/acme/plain:v1
+  configs:
+    ref: ghcr.io/acme/configs:stable
+    default_dest: ./configs
+    policy:
+      signature:
+        keyless:
+          issuer: https://token.actions.githubusercontent.com
+          identity: https://github.com/acme/*
+`)))
+
+	cfg, err := Load(v)
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/acme/plain:v1", cfg.Aliases["plain"])
+	assert.Equal(t, "ghcr.io/acme/configs:stable", cfg.Aliases["configs"])
+	assert.Equal(t, "ghcr.io/acme/configs:v2", cfg.ResolveAlias("configs:v2"))
+
+	d, ok := cfg.DefaultsFor("configs:v2")
+	require.True(t, ok)
+	assert.Equal(t, "./configs", d.DefaultDest)
+	_, ok = cfg.DefaultsFor("plain")
+	assert.False(t, ok)
+
+	// The policy applies to the repository however it is referenced
+	for _, ref := range []string{"ghcr.io/acme/configs:v1", "ghcr.io/acme/configs@sha256:abc"} {
+		rules := cfg.MatchedPolicyRules(ref)
+		require.Len(t, rules, 1, ref)
+		assert.Equal(t, "configs", rules[0].Alias)
+		assert.Equal(t, "https://github.com/acme/*", rules[0].Policy.Signature.Keyless.Identity)
+	}
+	assert.Empty(t, cfg.MatchedPolicyRules("ghcr.io/acme/configs-extra:v1"))
+}
+
+func TestLoad_StructuredAliasWithoutRef(t *testing.T) {
+	v := viper.New()
+	SetDefaults(v)
+	v.Set("aliases", map[string]any{"configs": map[string]any{"default_dest": "./configs"}})
+
+	_, err := Load(v)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "aliases.configs.ref cannot be empty")
+}
+
 func TestSave(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "subdir", "config.yaml")
@@ -6,6 +6,11 @@ import "github.com/spf13/viper"
 const (
 	OutputText = "text"
 	OutputJSON = "json"
+
+	// Formats supported only by some commands.
+	OutputCSV   = "csv"
+	OutputTSV   = "tsv"
+	OutputJUnit = "junit"
 )
 
 // Default compression values.
@@ -14,6 +19,26 @@ const (
 	CompressionZstd = "zstd"
 )
 
+// Color modes for ls output.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// Policy modes.
+const (
+	// PolicyModeEnforce fails a command when the policy fails.
+	PolicyModeEnforce = "enforce"
+
+	// PolicyModeWarn reports a failed policy as a warning.
+	PolicyModeWarn = "warn"
+)
+
+// DefaultSkipCompressMinSize is the default size (in bytes) below which
+// files are stored uncompressed.
+const DefaultSkipCompressMinSize int64 = 1024
+
 // Default returns a new Config with default values.
 func Default() *Config {
 	return &Config{
@@ -27,6 +52,13 @@ func Default() *Config {
 			Enabled: true,
 			MaxSize: "5GB",
 		},
+		Push: PushConfig{
+			SkipCompressMinSize: DefaultSkipCompressMinSize,
+		},
+		Ls: LsConfig{
+			Color:     ColorAuto,
+			DirsFirst: true,
+		},
 		Aliases:  make(map[string]string),
 		Policies: nil,
 	}
@@ -40,8 +72,14 @@ func SetDefaults(v *viper.Viper) {
 	v.SetDefault("quiet", false)
 	v.SetDefault("no-color", false)
 	v.SetDefault("plain-http", false)
+	v.SetDefault("semver", false)
+	v.SetDefault("strict", false)
+	v.SetDefault("offline", false)
 	v.SetDefault("compression", CompressionZstd)
 	v.SetDefault("cache.enabled", true)
 	v.SetDefault("cache.max_size", "5GB")
 	v.SetDefault("cache.ref_ttl", "5m")
+	v.SetDefault("push.skip_compress_min_size", DefaultSkipCompressMinSize)
+	v.SetDefault("ls.color", ColorAuto)
+	v.SetDefault("ls.dirs_first", true)
 }
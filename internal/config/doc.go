@@ -11,6 +11,14 @@
 //  3. Config file ($XDG_CONFIG_HOME/blob/config.yaml)
 //  4. Built-in defaults
 //
+// For containers without a config file, two settings can come from the
+// environment alone. BLOB_DEFAULT_REF sets default_ref like any other
+// key. BLOB_POLICY_FILE (a policy file path) or BLOB_POLICY_B64 (the
+// file's contents, base64-encoded) adds a policy that matches every
+// reference. Setting both is an error. The environment policy is
+// appended to the config file's policies instead of overriding them, so
+// it can only add requirements.
+//
 // # Context Integration
 //
 // The configuration is passed to commands via context.Context:
@@ -25,6 +33,11 @@
 //
 //	cfg := config.FromContext(ctx)
 //
+// Every resolved setting, including the output format and the config file
+// in use, is in the Config. Only root command initialization touches the
+// global Viper instance; commands read the Config alone, so they can run
+// in parallel tests or be embedded with a Config of their own.
+//
 // # Alias Resolution
 //
 // Aliases map short names to full OCI references:
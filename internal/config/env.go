@@ -0,0 +1,122 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Environment variables for configuring blob without a config file, as in
+// minimal containers.
+const (
+	// EnvPolicyB64 holds a base64-encoded YAML policy file applied to
+	// every reference.
+	EnvPolicyB64 = "BLOB_POLICY_B64"
+
+	// EnvPolicyFile names a YAML policy file applied to every reference.
+	EnvPolicyFile = "BLOB_POLICY_FILE"
+
+	// EnvDefaultRef sets default_ref, the reference used by commands run
+	// without one.
+	EnvDefaultRef = "BLOB_DEFAULT_REF"
+
+	// EnvHosts sets the mirrors of registries, replacing those in the
+	// config file: "ghcr.io=mirror.internal:5000,ghcr.io;docker.io=...".
+	// Each registry's hosts are tried in the order given.
+	EnvHosts = "BLOB_HOSTS"
+)
+
+// envPolicyMatch matches every reference.
+const envPolicyMatch = ".*"
+
+// envPolicyRules returns the policy rules set by EnvPolicyB64 and
+// EnvPolicyFile. Both use the format of "blob verify --policy" files.
+func envPolicyRules(getenv func(string) string) ([]PolicyRule, error) {
+	b64, path := getenv(EnvPolicyB64), getenv(EnvPolicyFile)
+	if b64 != "" && path != "" {
+		return nil, fmt.Errorf("%w: %s and %s cannot both be set", ErrInvalidConfig, EnvPolicyB64, EnvPolicyFile)
+	}
+
+	var data []byte
+	var source string
+	switch {
+	case b64 != "":
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b64))
+		if err != nil {
+			return nil, fmt.Errorf("%w: decoding %s: %v", ErrInvalidConfig, EnvPolicyB64, err)
+		}
+		data, source = decoded, EnvPolicyB64
+	case path != "":
+		//nolint:gosec // path is intentionally user-provided for policy loading
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading %s: %v", ErrInvalidConfig, EnvPolicyFile, err)
+		}
+		data, source = contents, EnvPolicyFile+" "+path
+	default:
+		return nil, nil
+	}
+
+	p, err := parsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, source, err)
+	}
+	return []PolicyRule{{Match: envPolicyMatch, Policy: p, Source: source}}, nil
+}
+
+// applyEnvHosts sets the mirrors of registries from EnvHosts, adding a
+// registries entry for a registry the config does not list.
+func applyEnvHosts(registries []RegistryAuth, getenv func(string) string) ([]RegistryAuth, error) {
+	value := strings.TrimSpace(getenv(EnvHosts))
+	if value == "" {
+		return registries, nil
+	}
+	for entry := range strings.SplitSeq(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		host, list, ok := strings.Cut(entry, "=")
+		host = strings.TrimSpace(host)
+		if !ok || host == "" {
+			return nil, fmt.Errorf("%w: %s: entry %q must be registry=host,host", ErrInvalidConfig, EnvHosts, entry)
+		}
+		var mirrors []string
+		for m := range strings.SplitSeq(list, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				mirrors = append(mirrors, m)
+			}
+		}
+		i := slices.IndexFunc(registries, func(r RegistryAuth) bool { return r.Host == host })
+		if i < 0 {
+			registries = append(registries, RegistryAuth{Host: host})
+			i = len(registries) - 1
+		}
+		registries[i].Mirrors = mirrors
+	}
+	return registries, nil
+}
+
+// parsePolicy decodes a YAML policy document with the same rules as the
+// policies in the config file.
+func parsePolicy(data []byte) (Policy, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return Policy{}, fmt.Errorf("parsing policy: %w", err)
+	}
+	var p Policy
+	if err := v.Unmarshal(&p); err != nil {
+		return Policy{}, fmt.Errorf("parsing policy: %w", err)
+	}
+	if p.Signature == nil && p.Provenance == nil {
+		return Policy{}, errors.New("policy has no signature or provenance requirements")
+	}
+	return p, nil
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const envTestPolicy = `signature:
+  keyless:
+    issuer: https://token.actions.githubusercontent.com
+    identity: https://github.com/acme/*
+`
+
+func TestLoad_EnvPolicy(t *testing.T) {
+	t.Run("base64", func(t *testing.T) {
+		t.Setenv(EnvPolicyB64, base64.StdEncoding.EncodeToString([]byte(envTestPolicy)))
+
+		v := viper.New()
+		SetDefaults(v)
+		v.Set("policies", []map[string]any{{
+			"match":  "ghcr.io/acme/.*",
+			"policy": map[string]any{"provenance": map[string]any{"slsa": map[string]any{"repository": "acme/configs"}}},
+		}})
+		cfg, err := Load(v)
+		require.NoError(t, err)
+
+		// Appended after the config file's policies, matching every ref
+		require.Len(t, cfg.Policies, 2)
+		assert.Empty(t, cfg.Policies[0].Source)
+		rule := cfg.Policies[1]
+		assert.Equal(t, EnvPolicyB64, rule.Source)
+		require.NotNil(t, rule.Policy.Signature)
+		require.NotNil(t, rule.Policy.Signature.Keyless)
+		assert.Equal(t, "https://github.com/acme/*", rule.Policy.Signature.Keyless.Identity)
+
+		assert.Len(t, cfg.GetPoliciesForRef("ghcr.io/acme/configs:v1"), 2)
+		matched := cfg.MatchedPolicyRules("docker.io/other/repo:v1")
+		require.Len(t, matched, 1)
+		assert.Equal(t, EnvPolicyB64, matched[0].Source)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(envTestPolicy), 0o600))
+		t.Setenv(EnvPolicyFile, path)
+
+		v := viper.New()
+		SetDefaults(v)
+		cfg, err := Load(v)
+		require.NoError(t, err)
+		require.Len(t, cfg.Policies, 1)
+		assert.Equal(t, EnvPolicyFile+" "+path, cfg.Policies[0].Source)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			b64  string
+			file string
+		}{
+			{name: "both set", b64: base64.StdEncoding.EncodeToString([]byte(envTestPolicy)), file: "policy.yaml"},
+			{name: "invalid base64", b64: "not base64!"},
+			{name: "missing file", file: filepath.Join(t.TempDir(), "missing.yaml")},
+			{name: "empty policy", b64: base64.StdEncoding.EncodeToString([]byte("other: true\n"))},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Setenv(EnvPolicyB64, tt.b64)
+				t.Setenv(EnvPolicyFile, tt.file)
+
+				v := viper.New()
+				SetDefaults(v)
+				_, err := Load(v)
+				require.ErrorIs(t, err, ErrInvalidConfig)
+			})
+		}
+	})
+}
+
+func TestLoad_DefaultRef(t *testing.T) {
+	t.Setenv(EnvDefaultRef, "ghcr.io/acme/configs:v1")
+
+	v := viper.New()
+	SetDefaults(v)
+	require.NoError(t, v.BindEnv("default_ref", EnvDefaultRef))
+	cfg, err := Load(v)
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/acme/configs:v1", cfg.DefaultRef)
+}
+
+func TestLoad_EnvHosts(t *testing.T) {
+	t.Setenv(EnvHosts, "ghcr.io=mirror-a.internal, ghcr.io ; docker.io=mirror-b.internal:5000")
+
+	v := viper.New()
+	SetDefaults(v)
+	v.Set("registries", []map[string]any{{
+		"host":         "ghcr.io",
+		"password_env": "GHCR_TOKEN",
+		"username":     "octocat",
+		"mirrors":      []string{"old-mirror.internal"},
+	}})
+	cfg, err := Load(v)
+	require.NoError(t, err)
+
+	// The environment replaces the config file's mirrors and adds entries
+	require.Len(t, cfg.Registries, 2)
+	assert.Equal(t, "GHCR_TOKEN", cfg.Registries[0].PasswordEnv)
+	assert.Equal(t, []string{"mirror-a.internal", "ghcr.io"}, cfg.Registries[0].Mirrors)
+	assert.Equal(t, "docker.io", cfg.Registries[1].Host)
+	assert.Equal(t, []string{"mirror-b.internal:5000"}, cfg.Registries[1].Mirrors)
+
+	t.Setenv(EnvHosts, "mirror.internal")
+	_, err = Load(v)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), EnvHosts)
+
+	t.Setenv(EnvHosts, "ghcr.io=https://mirror.internal")
+	_, err = Load(v)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "mirrors[0]")
+}
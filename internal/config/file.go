@@ -0,0 +1,271 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Update applies fn to the contents of the config file at path and writes
+// the result back. An exclusive lock is held from the read to the write, so
+// concurrent updates from other blob processes are not lost, and the file
+// is replaced atomically, so readers never see a partial write.
+//
+// fn receives the file's top-level keys, including keys blob does not know.
+// Its changes are applied to the file's YAML tree rather than by writing
+// the document out again, so comments, key order, and every value fn
+// leaves alone survive. A missing file is treated as empty and created.
+func Update(path string, fn func(doc map[string]any) error) error {
+	unlock, err := lockConfig(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path) //nolint:gosec // path is the user's config file
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	// Decode twice: once to hand to fn and once to see what it changed.
+	var before, doc map[string]any
+	if err := yaml.Unmarshal(data, &before); err != nil {
+		return fmt.Errorf("parsing config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing config file: %w", err)
+	}
+	if doc == nil {
+		doc = make(map[string]any)
+	}
+	if err := fn(doc); err != nil {
+		return err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("parsing config file: %w", err)
+	}
+	if err := mergeDocument(&root, before, doc); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// mergeDocument applies the changes between before and after to the
+// document root, editing the YAML tree in place so that everything the
+// changes do not touch, comments and key order included, is kept.
+func mergeDocument(root *yaml.Node, before, after map[string]any) error {
+	if root.Kind == 0 {
+		root.Kind = yaml.DocumentNode
+	}
+	if len(root.Content) == 0 {
+		root.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	if root.Content[0].Kind != yaml.MappingNode {
+		return errors.New("config file is not a YAML mapping")
+	}
+	return mergeMapping(root.Content[0], before, after)
+}
+
+// mergeMapping edits a mapping node from before to after: changed values
+// are merged, removed keys are dropped, and added keys are appended in
+// sorted order.
+func mergeMapping(mapping *yaml.Node, before, after map[string]any) error {
+	seen := make(map[string]bool, len(after))
+	content := make([]*yaml.Node, 0, len(mapping.Content))
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		newValue, ok := after[key.Value]
+		if !ok {
+			continue
+		}
+		seen[key.Value] = true
+		value, err := mergeValue(value, before[key.Value], newValue)
+		if err != nil {
+			return err
+		}
+		content = append(content, key, value)
+	}
+
+	var added []string
+	for k := range after {
+		if !seen[k] {
+			added = append(added, k)
+		}
+	}
+	slices.Sort(added)
+	for _, k := range added {
+		value, err := encodeNode(after[k])
+		if err != nil {
+			return err
+		}
+		content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, value)
+	}
+
+	// An empty flow mapping such as "aliases: {}" gets block style once
+	// it has entries.
+	if len(mapping.Content) == 0 && len(content) > 0 {
+		mapping.Style &^= yaml.FlowStyle
+	}
+	mapping.Content = content
+	return nil
+}
+
+// mergeValue returns the node for a value changed from before to after.
+// Mappings are edited in place; other values are replaced, keeping the
+// comments of the node they replace.
+func mergeValue(node *yaml.Node, before, after any) (*yaml.Node, error) {
+	if reflect.DeepEqual(before, after) {
+		return node, nil
+	}
+	beforeMap, wasMap := before.(map[string]any)
+	afterMap, isMap := after.(map[string]any)
+	if node.Kind == yaml.MappingNode && wasMap && isMap {
+		if err := mergeMapping(node, beforeMap, afterMap); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+
+	value, err := encodeNode(after)
+	if err != nil {
+		return nil, err
+	}
+	value.HeadComment = node.HeadComment
+	value.LineComment = node.LineComment
+	value.FootComment = node.FootComment
+	return value, nil
+}
+
+func encodeNode(v any) (*yaml.Node, error) {
+	var n yaml.Node
+	if err := n.Encode(v); err != nil {
+		return nil, fmt.Errorf("marshaling config: %w", err)
+	}
+	return &n, nil
+}
+
+// lockConfig takes an exclusive advisory lock on the config file at path
+// and returns a function that releases it. The lock is held on a sibling
+// ".lock" file because the config file itself is replaced on every write.
+func lockConfig(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("creating config directory: %w", err)
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o600) //nolint:gosec // path is the user's config file
+	if err != nil {
+		return nil, fmt.Errorf("opening config lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("locking config file: %w", err)
+	}
+	return func() {
+		unlockFile(f) //nolint:errcheck // closing the file releases the lock too
+		f.Close()
+	}, nil
+}
+
+// writeFileAtomic replaces the file at path with data by writing a
+// temporary file in the same directory and renaming it into place. A
+// symlink at path is followed, so the file it points to is replaced, and
+// the mode of the file replaced is kept.
+func writeFileAtomic(path string, data []byte) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // gone after a successful rename
+
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	return nil
+}
+
+// encodeConfig converts a config value to the plain maps, slices, and
+// scalars it is read from, keyed by the mapstructure names used in the
+// config file.
+func encodeConfig(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return encodeConfig(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]any)
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			m[name] = encodeConfig(v.Field(i))
+		}
+		return m
+	case reflect.Map:
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = encodeConfig(iter.Value())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		s := make([]any, v.Len())
+		for i := range v.Len() {
+			s[i] = encodeConfig(v.Index(i))
+		}
+		return s
+	default:
+		return v.Interface()
+	}
+}
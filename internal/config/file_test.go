@@ -0,0 +1,234 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const handWrittenConfig = `# Team config, see wiki
+output: json # machine readable
+
+# Future setting not known to this version
+telemetry:
+  endpoint: https://telemetry.acme.dev
+
+aliases:
+  prod: ghcr.io/acme/app:stable
+`
+
+func TestUpdate_KeepsUnknownKeysAndComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(handWrittenConfig), 0o600))
+
+	require.NoError(t, Update(path, func(doc map[string]any) error {
+		doc["compression"] = "none"
+		return nil
+	}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "# Team config, see wiki")
+	assert.Contains(t, content, "output: json # machine readable")
+	assert.Contains(t, content, "# Future setting not known to this version")
+	assert.Contains(t, content, "endpoint: https://telemetry.acme.dev")
+	assert.Contains(t, content, "compression: none")
+}
+
+func TestUpdate_EditsInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	original := `# Team config
+compression: none
+aliases:
+  # production services
+  prod: ghcr.io/acme/app:stable # pinned by release
+  # staging
+  staging: ghcr.io/acme/app:main
+output: json # for scripts
+`
+	require.NoError(t, os.WriteFile(path, []byte(original), 0o600))
+
+	_, _, err := SetAliasInFile(path, "prod", "ghcr.io/acme/app:v2")
+	require.NoError(t, err)
+	_, _, err = SetAliasInFile(path, "dev", "ghcr.io/acme/app:dev")
+	require.NoError(t, err)
+	_, err = RemoveAliasFromFile(path, "staging")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `# Team config
+compression: none
+aliases:
+  # production services
+  prod: ghcr.io/acme/app:v2 # pinned by release
+  dev: ghcr.io/acme/app:dev
+output: json # for scripts
+`, string(data))
+}
+
+func TestSetAliasInFile_KeepsSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`aliases:
+  configs:
+    ref: ghcr.io/acme/configs:v1
+    default_dest: ./configs
+`), 0o600))
+
+	previous, existed, err := SetAliasInFile(path, "configs", "ghcr.io/acme/configs:v2")
+	require.NoError(t, err)
+	assert.True(t, existed)
+	assert.Equal(t, "ghcr.io/acme/configs:v1", previous)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `aliases:
+  configs:
+    ref: ghcr.io/acme/configs:v2
+    default_dest: ./configs
+`, string(data))
+}
+
+func TestUpdate_FlowMappingBecomesBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("aliases: {}\n"), 0o600))
+
+	_, _, err := SetAliasInFile(path, "prod", "ghcr.io/acme/app")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "aliases:\n  prod: ghcr.io/acme/app\n", string(data))
+}
+
+func TestUpdate_FnErrorLeavesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(handWrittenConfig), 0o600))
+
+	err := Update(path, func(doc map[string]any) error {
+		doc["output"] = "text"
+		return assert.AnError
+	})
+	require.ErrorIs(t, err, assert.AnError)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, handWrittenConfig, string(data))
+}
+
+func TestUpdate_NotAMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("- a\n- b\n"), 0o600))
+
+	err := Update(path, func(map[string]any) error { return nil })
+	require.Error(t, err)
+}
+
+func TestSave_KeepsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(handWrittenConfig), 0o600))
+
+	cfg := Default()
+	cfg.Cache.RefTTL = "10m"
+	require.NoError(t, Save(cfg, path))
+
+	var doc map[string]any
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	assert.Equal(t, map[string]any{"endpoint": "https://telemetry.acme.dev"}, doc["telemetry"])
+	assert.Equal(t, "text", doc["output"])
+	cache, ok := doc["cache"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "10m", cache["ref_ttl"])
+	assert.Contains(t, string(data), "# Future setting not known to this version")
+}
+
+func TestUpdate_FollowsSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on windows")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "blob.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(target), 0o755))
+	require.NoError(t, os.WriteFile(target, []byte(handWrittenConfig), 0o644))
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.Symlink(target, path))
+
+	_, _, err := SetAliasInFile(path, "dev", "ghcr.io/acme/app:dev")
+	require.NoError(t, err)
+
+	// The link still points at the file, which was edited in place of
+	// the link and kept its mode
+	link, err := os.Readlink(path)
+	require.NoError(t, err)
+	assert.Equal(t, target, link)
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "dev: ghcr.io/acme/app:dev")
+	info, err := os.Stat(target)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+}
+
+func TestSetAliasInFile_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(handWrittenConfig), 0o600))
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			_, _, err := SetAliasInFile(path, fmt.Sprintf("app%d", i), fmt.Sprintf("ghcr.io/acme/app%d", i))
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+
+	var doc struct {
+		Aliases map[string]string `yaml:"aliases"`
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	assert.Len(t, doc.Aliases, n+1, "no alias should be lost")
+	assert.Equal(t, "ghcr.io/acme/app:stable", doc.Aliases["prod"])
+}
+
+func TestSetAliasInFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	previous, existed, err := SetAliasInFile(path, "prod", "ghcr.io/acme/app:v1")
+	require.NoError(t, err)
+	assert.False(t, existed)
+	assert.Empty(t, previous)
+
+	previous, existed, err = SetAliasInFile(path, "prod", "ghcr.io/acme/app:v2")
+	require.NoError(t, err)
+	assert.True(t, existed)
+	assert.Equal(t, "ghcr.io/acme/app:v1", previous)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestRemoveAliasFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(handWrittenConfig), 0o600))
+
+	previous, err := RemoveAliasFromFile(path, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/acme/app:stable", previous)
+
+	_, err = RemoveAliasFromFile(path, "prod")
+	require.ErrorIs(t, err, ErrAliasNotFound)
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Lint reports settings that are accepted but have no effect or fall back
+// to a default, such as unknown keys in the config file or a ref_ttl for a
+// disabled refs cache. Unlike validation errors they do not stop a
+// command; the CLI prints them with -v.
+func Lint(v *viper.Viper, cfg *Config) []string {
+	var notes []string
+	for _, key := range v.AllKeys() {
+		if v.InConfig(key) && !knownKey(reflect.TypeFor[Config](), strings.Split(key, ".")) {
+			notes = append(notes, fmt.Sprintf("unknown key %s is ignored", key))
+		}
+	}
+	sort.Strings(notes)
+
+	notes = append(notes, lintCache(v, &cfg.Cache)...)
+
+	if cfg.Offline && !cfg.Cache.Enabled {
+		notes = append(notes, "offline is set but caching is disabled, so only pinned archives can be read")
+	}
+	if v.InConfig("push.skip_compress_extensions") && len(cfg.Push.SkipCompressExtensions) == 0 {
+		notes = append(notes, "push.skip_compress_extensions is empty, so the built-in list is used")
+	}
+	if v.InConfig("registry.user_agent") && cfg.Registry.UserAgent == "" {
+		notes = append(notes, "registry.user_agent is empty, so blob-cli/<version> is used")
+	}
+	for i, rule := range cfg.Policies {
+		if rule.Policy.Signature == nil && rule.Policy.Provenance == nil {
+			notes = append(notes, fmt.Sprintf("policies[%d] (match %s) has no signature or provenance requirements and verifies nothing", i, rule.Match))
+		}
+	}
+	for i, r := range cfg.Registries {
+		if r.PasswordEnv == "" && r.IdentityTokenEnv == "" && r.CredentialHelper == "" && !r.connects() {
+			if r.Username != "" || r.UsernameEnv != "" {
+				notes = append(notes, fmt.Sprintf("registries[%d] (%s) has a username but no password_env, so it is ignored", i, r.Host))
+			} else {
+				notes = append(notes, fmt.Sprintf("registries[%d] (%s) sets nothing and is ignored", i, r.Host))
+			}
+		}
+	}
+	return notes
+}
+
+// lintCache reports cache settings that have no effect.
+func lintCache(v *viper.Viper, cache *CacheConfig) []string {
+	var notes []string
+	if v.InConfig("cache.max_size") && !cache.Enabled {
+		notes = append(notes, "cache.max_size is ignored because caching is disabled")
+	}
+	if v.IsSet("cache.ref_ttl") && !cache.RefsEnabled() && (v.InConfig("cache.ref_ttl") || cache.Enabled) {
+		notes = append(notes, "cache.ref_ttl is ignored because the refs cache is disabled")
+	}
+	if ttl, err := time.ParseDuration(cache.RefTTL); err == nil && ttl == 0 && cache.RefsEnabled() {
+		notes = append(notes, "cache.ref_ttl is 0, so cached tags never expire")
+	}
+	if !cache.Enabled {
+		individual := map[string]*IndividualCacheConfig{
+			"content": cache.Content, "blocks": cache.Blocks, "refs": cache.Refs,
+			"manifests": cache.Manifests, "indexes": cache.Indexes,
+		}
+		names := make([]string, 0, len(individual))
+		for name, c := range individual {
+			if c != nil && c.Enabled != nil && *c.Enabled {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			notes = append(notes, fmt.Sprintf("cache.%s.enabled is ignored because cache.enabled is false", name))
+		}
+	}
+	return notes
+}
+
+// knownKey reports whether the dotted key path names a setting of t.
+// Maps accept any key below them; slices and scalars end the path.
+func knownKey(t reflect.Type, path []string) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if len(path) == 0 {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Struct:
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if strings.EqualFold(name, path[0]) {
+				return knownKey(f.Type, path[1:])
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// connects reports whether r changes how the registry is reached.
+func (r *RegistryAuth) connects() bool {
+	return r.PlainHTTP || r.CAFile != "" || r.SkipTLSVerify || len(r.Mirrors) > 0
+}
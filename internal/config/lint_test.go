@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lintFile(t *testing.T, content string) []string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	v := viper.New()
+	SetDefaults(v)
+	v.SetConfigFile(path)
+	require.NoError(t, v.ReadInConfig())
+	cfg, err := Load(v)
+	require.NoError(t, err)
+	return Lint(v, cfg)
+}
+
+func TestLint_Clean(t *testing.T) {
+	notes := lintFile(t, `output: json
+aliases:
+  foo: ghcr.io/acme/foo:v1
+registry:
+  extra_headers:
+    X-Team: platform
+cache:
+  ref_ttl: 10m
+  refs:
+    enabled: true
+`)
+	assert.Empty(t, notes)
+}
+
+func TestLint_Findings(t *testing.T) {
+	notes := lintFile(t, `outptu: json
+offline: true
+cache:
+  enabled: false
+  max_size: 1GB
+  ref_ttl: 10m
+  refs:
+    enabled: true
+  contnet:
+    enabled: true
+push:
+  skip_compress_extensions: []
+registries:
+  - host: ghcr.io
+policies:
+  - match: ghcr.io/.*
+    policy: {}
+`)
+	assert.Equal(t, []string{
+		"unknown key cache.contnet.enabled is ignored",
+		"unknown key outptu is ignored",
+		"cache.max_size is ignored because caching is disabled",
+		"cache.ref_ttl is ignored because the refs cache is disabled",
+		"cache.refs.enabled is ignored because cache.enabled is false",
+		"offline is set but caching is disabled, so only pinned archives can be read",
+		"push.skip_compress_extensions is empty, so the built-in list is used",
+		"policies[0] (match ghcr.io/.*) has no signature or provenance requirements and verifies nothing",
+		"registries[0] (ghcr.io) sets nothing and is ignored",
+	}, notes)
+}
+
+func TestLint_ZeroRefTTL(t *testing.T) {
+	notes := lintFile(t, "cache:\n  ref_ttl: 0s\n")
+	assert.Equal(t, []string{"cache.ref_ttl is 0, so cached tags never expire"}, notes)
+}
@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || solaris || windows)
+
+package config
+
+import "os"
+
+// lockFile is a no-op on platforms without advisory file locks; config
+// writes are still atomic there, but concurrent updates may be lost.
+func lockFile(*os.File) error { return nil }
+
+func unlockFile(*os.File) error { return nil }
@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"github.com/spf13/viper"
 )
 
 const (
@@ -48,19 +46,13 @@ func ConfigPath() (string, error) {
 	return filepath.Join(dir, configFileName+"."+configFileExt), nil
 }
 
-// ConfigPathUsed returns the config file path that is actually in use.
+// FilePath returns the config file path that is actually in use.
 // If --config flag was specified, returns that path.
 // Otherwise returns the default XDG path.
-//
-//nolint:revive // stuttering is acceptable for clarity
-func ConfigPathUsed() (string, error) {
-	// Check if the effective path was set by initConfig()
-	// This is stored in an internal key to avoid BLOB_CONFIG env var interference
-	if path := viper.GetString("internal.config_path"); path != "" {
-		return path, nil
+func (c *Config) FilePath() (string, error) {
+	if c.File != "" {
+		return c.File, nil
 	}
-
-	// Fall back to default XDG path (for cases where initConfig wasn't called)
 	return ConfigPath()
 }
 
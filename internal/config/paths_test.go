@@ -5,7 +5,6 @@ import (
 	"path/filepath"
 	"testing"
 
-	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -92,25 +91,17 @@ func TestDataDir(t *testing.T) {
 	})
 }
 
-func TestConfigPathUsed(t *testing.T) {
-	origXDG := os.Getenv("XDG_CONFIG_HOME")
-	t.Cleanup(func() {
-		os.Setenv("XDG_CONFIG_HOME", origXDG)
-		viper.Reset()
-	})
-
-	t.Run("with internal config path set", func(t *testing.T) {
-		viper.Reset()
-		viper.Set("internal.config_path", "/custom/path/config.yaml")
-		path, err := ConfigPathUsed()
+func TestFilePath(t *testing.T) {
+	t.Run("with --config", func(t *testing.T) {
+		cfg := &Config{File: "/custom/path/config.yaml"}
+		path, err := cfg.FilePath()
 		require.NoError(t, err)
 		assert.Equal(t, "/custom/path/config.yaml", path)
 	})
 
-	t.Run("without internal config path (fallback to XDG)", func(t *testing.T) {
-		viper.Reset()
-		os.Setenv("XDG_CONFIG_HOME", "/xdg/config")
-		path, err := ConfigPathUsed()
+	t.Run("without --config (fallback to XDG)", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+		path, err := (&Config{}).FilePath()
 		require.NoError(t, err)
 		assert.Equal(t, "/xdg/config/blob/config.yaml", path)
 	})
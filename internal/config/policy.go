@@ -44,6 +44,15 @@ type MatchedPolicyRule struct {
 
 	// Policy is the policy configuration.
 	Policy Policy
+
+	// Source is the environment variable the rule came from, if any.
+	Source string
+
+	// Alias is the alias whose policy the rule enforces, if any.
+	Alias string
+
+	// Warn is set for rules in warn mode, whose failures are warnings.
+	Warn bool
 }
 
 // MatchedPolicyRules returns the policy rules that match the reference,
@@ -64,6 +73,9 @@ func (c *Config) MatchedPolicyRules(ref string) []MatchedPolicyRule {
 			matched = append(matched, MatchedPolicyRule{
 				Pattern: rule.Match,
 				Policy:  rule.Policy,
+				Source:  rule.Source,
+				Alias:   rule.Alias,
+				Warn:    rule.Mode == PolicyModeWarn,
 			})
 		}
 	}
@@ -17,17 +17,228 @@ type Config struct {
 	// PlainHTTP enables plain HTTP (no TLS) for registries.
 	PlainHTTP bool `mapstructure:"plain-http" json:"plain_http"`
 
+	// Semver resolves tags that are semantic version queries (such as
+	// "^1.2") to the highest matching tag in the repository.
+	Semver bool `mapstructure:"semver" json:"semver"`
+
+	// Strict fails a command with exit code 6 if it reports any warning,
+	// and refuses pulls that no policy verifies.
+	Strict bool `mapstructure:"strict" json:"strict"`
+
+	// Offline reads only from the local caches and pinned archives and
+	// fails any request that would reach a registry.
+	Offline bool `mapstructure:"offline" json:"offline"`
+
 	// Compression type for push: "none" or "zstd".
 	Compression string `mapstructure:"compression" json:"compression"`
 
 	// Cache settings.
 	Cache CacheConfig `mapstructure:"cache" json:"cache"`
 
-	// Aliases map short names to full OCI references.
-	Aliases map[string]string `mapstructure:"aliases" json:"aliases"`
+	// Push settings.
+	Push PushConfig `mapstructure:"push" json:"push"`
+
+	// Store settings.
+	Store StoreConfig `mapstructure:"store" json:"store"`
+
+	// Registry request settings.
+	Registry RegistryConfig `mapstructure:"registry" json:"registry"`
+
+	// Sigstore settings for signing and keyless verification.
+	Sigstore SigstoreConfig `mapstructure:"sigstore" json:"sigstore"`
+
+	// Registries configures authentication and TLS for individual
+	// registries, on top of the Docker config.
+	Registries []RegistryAuth `mapstructure:"registries" json:"registries,omitempty"`
+
+	// Ls display settings.
+	Ls LsConfig `mapstructure:"ls" json:"ls"`
+
+	// DefaultRef is the reference used by commands that read an archive
+	// when none is given, such as "blob pull" in a container. It may be
+	// an alias.
+	DefaultRef string `mapstructure:"default_ref" json:"default_ref,omitempty"`
+
+	// Aliases map short names to full OCI references. In the config file
+	// an alias may also be a mapping, with the reference under "ref" and
+	// the settings in AliasDefaults.
+	Aliases AliasMap `mapstructure:"aliases" json:"aliases"`
+
+	// AliasDefaults holds the settings of aliases written as a mapping,
+	// by alias name.
+	AliasDefaults map[string]AliasDefaults `mapstructure:"-" json:"alias_defaults,omitempty"`
+
+	// Shortcuts map names to full command lines, run as "blob <name>".
+	// A shortcut cannot replace a built-in command.
+	Shortcuts map[string]string `mapstructure:"shortcuts" json:"shortcuts,omitempty"`
 
 	// Policies define verification requirements by reference pattern.
 	Policies []PolicyRule `mapstructure:"policies" json:"policies,omitempty"`
+
+	// Schemas associate JSON Schemas with archive paths for content validation.
+	Schemas []SchemaRule `mapstructure:"schemas" json:"schemas,omitempty"`
+
+	// Hooks configures commands run at points in the CLI workflow.
+	Hooks HooksConfig `mapstructure:"hooks" json:"hooks"`
+
+	// File is the config file given with --config, if any. Use FilePath
+	// for the file in use.
+	File string `mapstructure:"-" json:"-"`
+}
+
+// AliasMap maps alias names to references. It is a distinct type so the
+// mapping form of an alias can be decoded into its reference.
+type AliasMap map[string]string
+
+// AliasDefaults are the settings an alias written as a mapping applies
+// besides its reference:
+//
+//	aliases:
+//	  configs:
+//	    ref: ghcr.io/acme/configs
+//	    default_dest: ./configs
+//	    policy:
+//	      signature:
+//	        keyless: {issuer: ..., identity: ...}
+type AliasDefaults struct {
+	// Policy is enforced for every reference to the alias's repository,
+	// with or without the alias, like a policies entry matching it.
+	Policy *Policy `mapstructure:"policy" json:"policy,omitempty"`
+
+	// DefaultDest is the directory "blob pull" extracts the alias into
+	// when no path is given.
+	DefaultDest string `mapstructure:"default_dest" json:"default_dest,omitempty"`
+}
+
+// PushConfig holds push-related settings.
+type PushConfig struct {
+	// SkipCompressExtensions lists file extensions (e.g., ".png") that are
+	// stored uncompressed. When empty, a built-in list of already-compressed
+	// formats is used.
+	SkipCompressExtensions []string `mapstructure:"skip_compress_extensions" json:"skip_compress_extensions,omitempty"`
+
+	// SkipCompressMinSize stores files smaller than this many bytes uncompressed.
+	// Zero disables the size rule. Default: 1024.
+	SkipCompressMinSize int64 `mapstructure:"skip_compress_min_size" json:"skip_compress_min_size"`
+}
+
+// StoreConfig holds local content-addressed store settings.
+type StoreConfig struct {
+	// Dir overrides the store directory path.
+	// If empty, uses $XDG_DATA_HOME/blob/store or ~/.local/share/blob/store.
+	Dir string `mapstructure:"dir" json:"dir,omitempty"`
+}
+
+// RegistryConfig holds settings applied to registry API (/v2/) requests.
+type RegistryConfig struct {
+	// UserAgent overrides the User-Agent header.
+	// If empty, uses blob-cli/<version>.
+	UserAgent string `mapstructure:"user_agent" json:"user_agent,omitempty"`
+
+	// ExtraHeaders are added to registry API requests, for gateways that
+	// route or rate limit by header. They never replace headers set by the
+	// client, such as Authorization.
+	ExtraHeaders map[string]string `mapstructure:"extra_headers" json:"extra_headers,omitempty"`
+}
+
+// SigstoreConfig selects the Sigstore services used for signing and the
+// trust root used for keyless verification, for private or air-gapped
+// Sigstore deployments. Empty values use the public Sigstore instance.
+type SigstoreConfig struct {
+	// FulcioURL is the Fulcio certificate authority for keyless signing
+	// (e.g., "https://fulcio.sigstore.internal").
+	FulcioURL string `mapstructure:"fulcio_url" json:"fulcio_url,omitempty"`
+
+	// RekorURL is the Rekor transparency log signatures are recorded in,
+	// and searched by "blob verify --rekor-search".
+	RekorURL string `mapstructure:"rekor_url" json:"rekor_url,omitempty"`
+
+	// TrustRoot is a Sigstore trusted_root.json file that keyless
+	// signatures are verified against instead of the public-good root
+	// fetched over TUF.
+	TrustRoot string `mapstructure:"trust_root" json:"trust_root,omitempty"`
+
+	// OIDCIssuer is the OIDC issuer users log in with for keyless signing
+	// outside CI (e.g., "https://oauth2.sigstore.internal/auth").
+	OIDCIssuer string `mapstructure:"oidc_issuer" json:"oidc_issuer,omitempty"`
+
+	// OIDCClientID is the client ID registered with OIDCIssuer.
+	OIDCClientID string `mapstructure:"oidc_client_id" json:"oidc_client_id,omitempty"`
+}
+
+// RegistryAuth configures access to one registry: its credentials and
+// how to connect. Secrets are never written in the config file: they are
+// named by environment variable. At most one of PasswordEnv,
+// IdentityTokenEnv, and CredentialHelper may be set; with none, the
+// Docker config is used as usual.
+type RegistryAuth struct {
+	// Host is the registry host, with a port if not the default
+	// (e.g., "ghcr.io" or "registry.internal:5000").
+	Host string `mapstructure:"host" json:"host"`
+
+	// Username for password authentication.
+	Username string `mapstructure:"username" json:"username,omitempty"`
+
+	// UsernameEnv names an environment variable holding the username,
+	// used when Username is empty.
+	UsernameEnv string `mapstructure:"username_env" json:"username_env,omitempty"`
+
+	// PasswordEnv names an environment variable holding the password or
+	// access token.
+	PasswordEnv string `mapstructure:"password_env" json:"password_env,omitempty"`
+
+	// IdentityTokenEnv names an environment variable holding an OAuth2
+	// refresh token, exchanged for access tokens by the registry.
+	IdentityTokenEnv string `mapstructure:"identity_token_env" json:"identity_token_env,omitempty"`
+
+	// CredentialHelper is a Docker credential helper, run as
+	// docker-credential-<name> (e.g., "ecr-login" or "gcloud").
+	CredentialHelper string `mapstructure:"credential_helper" json:"credential_helper,omitempty"`
+
+	// PlainHTTP connects to the registry over HTTP instead of HTTPS,
+	// as --plain-http does for every registry.
+	PlainHTTP bool `mapstructure:"plain_http" json:"plain_http,omitempty"`
+
+	// CAFile is a PEM file of CA certificates trusted for the registry,
+	// in addition to the system roots.
+	CAFile string `mapstructure:"ca_file" json:"ca_file,omitempty"`
+
+	// SkipTLSVerify skips TLS certificate verification for the registry.
+	SkipTLSVerify bool `mapstructure:"skip_tls_verify" json:"skip_tls_verify,omitempty"`
+
+	// Mirrors are hosts serving the same content as the registry, such as
+	// pull-through caches, tried in order for reads. A mirror that fails
+	// is skipped for a while and the next one, or the registry itself, is
+	// used; list Host among them to try the registry before a mirror.
+	Mirrors []string `mapstructure:"mirrors" json:"mirrors,omitempty"`
+}
+
+// LsConfig holds display settings for ls and tree output.
+type LsConfig struct {
+	// Color controls colored output: "auto" (only when stdout is a
+	// terminal), "always", or "never". Default: auto.
+	Color string `mapstructure:"color" json:"color,omitempty"`
+
+	// Icons shows a file type icon before each name. Requires a Nerd Font.
+	Icons bool `mapstructure:"icons" json:"icons"`
+
+	// DirsFirst lists directories before files. Default: true.
+	DirsFirst bool `mapstructure:"dirs_first" json:"dirs_first"`
+
+	// ShowHidden lists dotfiles in ls and tree without -a.
+	ShowHidden bool `mapstructure:"show_hidden" json:"show_hidden"`
+}
+
+// HooksConfig holds user-defined hook commands.
+type HooksConfig struct {
+	// PrePush commands run before a push, through the platform shell.
+	// A non-zero exit aborts the push.
+	PrePush []string `mapstructure:"pre_push" json:"pre_push,omitempty"`
+
+	// PriorityExtracted commands run during a pull once the files the
+	// archive lists for priority extraction are in place, while the rest
+	// is still being extracted. A non-zero exit is reported as a warning.
+	PriorityExtracted []string `mapstructure:"priority_extracted" json:"priority_extracted,omitempty"`
 }
 
 // CacheConfig holds cache-related settings.
@@ -35,8 +246,10 @@ type CacheConfig struct {
 	// Enabled controls whether caching is active globally.
 	Enabled bool `mapstructure:"enabled" json:"enabled"`
 
-	// MaxSize is deprecated. Use per-cache settings instead.
-	// Kept for backward compatibility.
+	// MaxSize caps the combined size of the caches (e.g., "5GB"). blob
+	// cache gc, and every pull and cp, evict the least recently used
+	// entries until the caches fit. Empty means no cap beyond each
+	// cache's built-in limit.
 	MaxSize string `mapstructure:"max_size" json:"max_size,omitempty"`
 
 	// Dir overrides the cache directory path.
@@ -63,6 +276,11 @@ type IndividualCacheConfig struct {
 	Enabled *bool `mapstructure:"enabled" json:"enabled,omitempty"`
 }
 
+// MaxSizeBytes returns MaxSize in bytes, or 0 if it is not set.
+func (c *CacheConfig) MaxSizeBytes() (int64, error) {
+	return parseCacheSize(c.MaxSize)
+}
+
 // ContentEnabled returns whether the content cache is enabled.
 func (c *CacheConfig) ContentEnabled() bool {
 	if !c.Enabled {
@@ -118,6 +336,16 @@ func (c *CacheConfig) IndexesEnabled() bool {
 	return *c.Indexes.Enabled
 }
 
+// SchemaRule maps an archive path pattern to a JSON Schema.
+type SchemaRule struct {
+	// Match is a glob matched against archive paths (e.g., "**/*.config.json").
+	Match string `mapstructure:"match" json:"match"`
+
+	// Schema is the path to a JSON Schema file.
+	// Relative paths are resolved against the config file's directory.
+	Schema string `mapstructure:"schema" json:"schema"`
+}
+
 // PolicyRule maps a reference pattern to verification policies.
 type PolicyRule struct {
 	// Match is a regex pattern matched against fully-expanded references.
@@ -125,6 +353,17 @@ type PolicyRule struct {
 
 	// Policy defines the verification requirements.
 	Policy Policy `mapstructure:"policy" json:"policy"`
+
+	// Mode is "enforce" (the default) or "warn". A failed policy in warn
+	// mode is reported as a warning instead of failing the command.
+	Mode string `mapstructure:"mode" json:"mode,omitempty"`
+
+	// Source names the environment variable a rule came from. It is empty
+	// for rules from the config file.
+	Source string `mapstructure:"-" json:"source,omitempty"`
+
+	// Alias names the alias whose policy the rule enforces, if any.
+	Alias string `mapstructure:"-" json:"alias,omitempty"`
 }
 
 // Policy defines verification requirements for an archive.
@@ -3,11 +3,18 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"path"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/meigma/blob-cli/internal/shortcut"
 )
 
 // ErrInvalidConfig is returned when configuration validation fails.
@@ -24,7 +31,31 @@ func validate(cfg *Config) error {
 	if err := validateCache(&cfg.Cache); err != nil {
 		return err
 	}
-	return validatePolicies(cfg.Policies)
+	if err := validatePush(&cfg.Push); err != nil {
+		return err
+	}
+	if err := validatePolicies(cfg.Policies); err != nil {
+		return err
+	}
+	if err := validateSchemas(cfg.Schemas); err != nil {
+		return err
+	}
+	if err := validateRegistry(&cfg.Registry); err != nil {
+		return err
+	}
+	if err := validateRegistries(cfg.Registries); err != nil {
+		return err
+	}
+	if err := validateSigstore(&cfg.Sigstore); err != nil {
+		return err
+	}
+	if err := validateLs(&cfg.Ls); err != nil {
+		return err
+	}
+	if err := validateShortcuts(cfg.Shortcuts); err != nil {
+		return err
+	}
+	return validateHooks(&cfg.Hooks)
 }
 
 // validateCache validates cache configuration.
@@ -35,19 +66,126 @@ func validateCache(cache *CacheConfig) error {
 		}
 	}
 	if cache.RefTTL != "" {
-		if _, err := time.ParseDuration(cache.RefTTL); err != nil {
+		ttl, err := time.ParseDuration(cache.RefTTL)
+		if err != nil {
 			return fmt.Errorf("%w: cache.ref_ttl must be a valid duration (e.g., 5m, 1h), got %q", ErrInvalidConfig, cache.RefTTL)
 		}
+		if ttl < 0 {
+			return fmt.Errorf("%w: cache.ref_ttl cannot be negative, got %q", ErrInvalidConfig, cache.RefTTL)
+		}
+	}
+	return nil
+}
+
+// validatePush validates push configuration.
+func validatePush(push *PushConfig) error {
+	if push.SkipCompressMinSize < 0 {
+		return fmt.Errorf("%w: push.skip_compress_min_size cannot be negative, got %d", ErrInvalidConfig, push.SkipCompressMinSize)
+	}
+	for i, ext := range push.SkipCompressExtensions {
+		ext = strings.TrimPrefix(strings.TrimSpace(ext), ".")
+		if ext == "" || strings.ContainsAny(ext, "/\\") {
+			return fmt.Errorf("%w: push.skip_compress_extensions[%d] is invalid: %q", ErrInvalidConfig, i, push.SkipCompressExtensions[i])
+		}
+	}
+	return nil
+}
+
+// validateLs validates ls display settings.
+func validateLs(ls *LsConfig) error {
+	switch ls.Color {
+	case "", ColorAuto, ColorAlways, ColorNever:
+		return nil
+	default:
+		return fmt.Errorf("%w: ls.color must be %q, %q, or %q, got %q", ErrInvalidConfig, ColorAuto, ColorAlways, ColorNever, ls.Color)
+	}
+}
+
+// validateRegistry validates registry request settings.
+func validateRegistry(registry *RegistryConfig) error {
+	if !httpguts.ValidHeaderFieldValue(registry.UserAgent) {
+		return fmt.Errorf("%w: registry.user_agent is not a valid header value: %q", ErrInvalidConfig, registry.UserAgent)
+	}
+	for name, value := range registry.ExtraHeaders {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("%w: registry.extra_headers has an invalid header name: %q", ErrInvalidConfig, name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("%w: registry.extra_headers.%s is not a valid header value: %q", ErrInvalidConfig, name, value)
+		}
+	}
+	return nil
+}
+
+// validateSigstore validates the Sigstore service and OIDC issuer URLs.
+func validateSigstore(sigstore *SigstoreConfig) error {
+	for _, setting := range []struct{ key, value string }{
+		{"sigstore.fulcio_url", sigstore.FulcioURL},
+		{"sigstore.rekor_url", sigstore.RekorURL},
+		{"sigstore.oidc_issuer", sigstore.OIDCIssuer},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		u, err := url.Parse(setting.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: %s must be an http or https URL, got %q", ErrInvalidConfig, setting.key, setting.value)
+		}
+	}
+	return nil
+}
+
+func validateRegistries(registries []RegistryAuth) error {
+	seen := make(map[string]bool, len(registries))
+	for i, r := range registries {
+		if r.Host == "" || strings.ContainsAny(r.Host, "/ ") {
+			return fmt.Errorf("%w: registries[%d].host must be a registry host, got %q", ErrInvalidConfig, i, r.Host)
+		}
+		if seen[r.Host] {
+			return fmt.Errorf("%w: registries[%d].host %q is listed more than once", ErrInvalidConfig, i, r.Host)
+		}
+		seen[r.Host] = true
+
+		sources := 0
+		for _, set := range []bool{r.PasswordEnv != "", r.IdentityTokenEnv != "", r.CredentialHelper != ""} {
+			if set {
+				sources++
+			}
+		}
+		if sources > 1 {
+			return fmt.Errorf("%w: registries[%d] (%s): set only one of password_env, identity_token_env, and credential_helper",
+				ErrInvalidConfig, i, r.Host)
+		}
+		if r.PasswordEnv != "" && r.Username == "" && r.UsernameEnv == "" {
+			return fmt.Errorf("%w: registries[%d] (%s): password_env needs username or username_env", ErrInvalidConfig, i, r.Host)
+		}
+		if r.PlainHTTP && (r.CAFile != "" || r.SkipTLSVerify) {
+			return fmt.Errorf("%w: registries[%d] (%s): ca_file and skip_tls_verify do not apply with plain_http",
+				ErrInvalidConfig, i, r.Host)
+		}
+		for j, m := range r.Mirrors {
+			if m == "" || strings.ContainsAny(m, "/ ") {
+				return fmt.Errorf("%w: registries[%d].mirrors[%d] must be a registry host, got %q", ErrInvalidConfig, i, j, m)
+			}
+			if slices.Contains(r.Mirrors[:j], m) {
+				return fmt.Errorf("%w: registries[%d] (%s): mirror %q is listed more than once", ErrInvalidConfig, i, r.Host, m)
+			}
+		}
+		if strings.ContainsAny(r.CredentialHelper, `/\ `) {
+			return fmt.Errorf("%w: registries[%d] (%s): credential_helper must be a helper name such as \"ecr-login\", got %q",
+				ErrInvalidConfig, i, r.Host, r.CredentialHelper)
+		}
 	}
 	return nil
 }
 
 func validateOutput(v string) error {
 	switch v {
-	case OutputText, OutputJSON:
+	case OutputText, OutputJSON, OutputCSV, OutputTSV, OutputJUnit:
 		return nil
 	default:
-		return fmt.Errorf("%w: output must be %q or %q, got %q", ErrInvalidConfig, OutputText, OutputJSON, v)
+		return fmt.Errorf("%w: output must be one of %q, %q, %q, %q, or %q, got %q",
+			ErrInvalidConfig, OutputText, OutputJSON, OutputCSV, OutputTSV, OutputJUnit, v)
 	}
 }
 
@@ -62,14 +200,31 @@ func validateCompression(v string) error {
 
 // validateCacheSize validates a size string like "5GB", "500MB", "1TB".
 func validateCacheSize(v string) error {
+	_, err := parseCacheSize(v)
+	return err
+}
+
+// cacheSizeUnits maps size units to their size in bytes.
+var cacheSizeUnits = map[string]float64{
+	"":   1, // bytes
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+	"TB": 1 << 40,
+}
+
+// parseCacheSize parses a size string like "5GB" into bytes. Units are
+// binary, so 1KB is 1024 bytes. An empty string is 0.
+func parseCacheSize(v string) (int64, error) {
 	if v == "" {
-		return nil
+		return 0, nil
 	}
 
 	// Parse the numeric portion and unit
 	v = strings.TrimSpace(v)
 	if v == "" {
-		return fmt.Errorf("%w: cache.max_size cannot be empty", ErrInvalidConfig)
+		return 0, fmt.Errorf("%w: cache.max_size cannot be empty", ErrInvalidConfig)
 	}
 
 	// Find where the number ends
@@ -83,7 +238,7 @@ func validateCacheSize(v string) error {
 	}
 
 	if numEnd == 0 {
-		return fmt.Errorf("%w: cache.max_size must start with a number, got %q", ErrInvalidConfig, v)
+		return 0, fmt.Errorf("%w: cache.max_size must start with a number, got %q", ErrInvalidConfig, v)
 	}
 
 	numStr := v[:numEnd]
@@ -92,23 +247,16 @@ func validateCacheSize(v string) error {
 	// Validate number
 	num, err := strconv.ParseFloat(numStr, 64)
 	if err != nil || num < 0 {
-		return fmt.Errorf("%w: cache.max_size has invalid number %q", ErrInvalidConfig, numStr)
+		return 0, fmt.Errorf("%w: cache.max_size has invalid number %q", ErrInvalidConfig, numStr)
 	}
 
 	// Validate unit
-	validUnits := map[string]bool{
-		"":   true, // bytes
-		"B":  true,
-		"KB": true,
-		"MB": true,
-		"GB": true,
-		"TB": true,
-	}
-	if !validUnits[unit] {
-		return fmt.Errorf("%w: cache.max_size has invalid unit %q (valid: B, KB, MB, GB, TB)", ErrInvalidConfig, unit)
+	size, ok := cacheSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("%w: cache.max_size has invalid unit %q (valid: B, KB, MB, GB, TB)", ErrInvalidConfig, unit)
 	}
 
-	return nil
+	return int64(num * size), nil
 }
 
 func validatePolicies(policies []PolicyRule) error {
@@ -121,6 +269,72 @@ func validatePolicies(policies []PolicyRule) error {
 		if _, err := regexp.Compile(rule.Match); err != nil {
 			return fmt.Errorf("%w: policies[%d].match is invalid regex %q: %v", ErrInvalidConfig, i, rule.Match, err)
 		}
+
+		switch rule.Mode {
+		case "", PolicyModeEnforce, PolicyModeWarn:
+		default:
+			return fmt.Errorf("%w: policies[%d].mode must be %q or %q, got %q",
+				ErrInvalidConfig, i, PolicyModeEnforce, PolicyModeWarn, rule.Mode)
+		}
+	}
+	return nil
+}
+
+func validateSchemas(schemas []SchemaRule) error {
+	for i, rule := range schemas {
+		if rule.Match == "" {
+			return fmt.Errorf("%w: schemas[%d].match cannot be empty", ErrInvalidConfig, i)
+		}
+		if !validGlob(rule.Match) {
+			return fmt.Errorf("%w: schemas[%d].match is invalid glob %q", ErrInvalidConfig, i, rule.Match)
+		}
+		if rule.Schema == "" {
+			return fmt.Errorf("%w: schemas[%d].schema cannot be empty", ErrInvalidConfig, i)
+		}
+	}
+	return nil
+}
+
+func validateShortcuts(shortcuts map[string]string) error {
+	for name, line := range shortcuts {
+		if !shortcut.ValidName(name) {
+			return fmt.Errorf("%w: shortcut name %q must be one word not starting with \"-\"", ErrInvalidConfig, name)
+		}
+		args, err := shortcut.Split(line)
+		if err != nil {
+			return fmt.Errorf("%w: shortcuts.%s: %w", ErrInvalidConfig, name, err)
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("%w: shortcuts.%s cannot be empty", ErrInvalidConfig, name)
+		}
 	}
 	return nil
 }
+
+func validateHooks(hooks *HooksConfig) error {
+	for i, command := range hooks.PrePush {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("%w: hooks.pre_push[%d] cannot be empty", ErrInvalidConfig, i)
+		}
+	}
+	for i, command := range hooks.PriorityExtracted {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("%w: hooks.priority_extracted[%d] cannot be empty", ErrInvalidConfig, i)
+		}
+	}
+	return nil
+}
+
+// validGlob reports whether each segment of a slash-separated glob is valid.
+// "**" segments (any number of directories) are always valid.
+func validGlob(pattern string) bool {
+	for seg := range strings.SplitSeq(pattern, "/") {
+		if seg == "**" {
+			continue
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
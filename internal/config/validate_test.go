@@ -15,6 +15,9 @@ func TestValidateOutput(t *testing.T) {
 	}{
 		{"text", false},
 		{"json", false},
+		{"csv", false},
+		{"tsv", false},
+		{"junit", false},
 		{"xml", true},
 		{"", true},
 		{"TEXT", true}, // case sensitive
@@ -87,6 +90,22 @@ func TestValidateCacheSize(t *testing.T) {
 	}
 }
 
+func TestCacheConfig_MaxSizeBytes(t *testing.T) {
+	tests := map[string]int64{
+		"":      0,
+		"1024":  1024,
+		"100KB": 100 << 10,
+		"1.5GB": 3 << 29,
+		"5 gb":  5 << 30,
+	}
+	for value, want := range tests {
+		c := CacheConfig{MaxSize: value}
+		got, err := c.MaxSizeBytes()
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+}
+
 func TestValidatePolicies(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -112,6 +131,20 @@ func TestValidatePolicies(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "warn mode",
+			policies: []PolicyRule{
+				{Match: `ghcr\.io/acme/.*`, Mode: PolicyModeWarn},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown mode",
+			policies: []PolicyRule{
+				{Match: `ghcr\.io/acme/.*`, Mode: "audit"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "empty match",
 			policies: []PolicyRule{
@@ -133,6 +166,189 @@ func TestValidatePolicies(t *testing.T) {
 	}
 }
 
+func TestValidateSchemas(t *testing.T) {
+	tests := []struct {
+		name    string
+		schemas []SchemaRule
+		wantErr bool
+	}{
+		{
+			name:    "empty schemas",
+			schemas: nil,
+			wantErr: false,
+		},
+		{
+			name: "valid rule",
+			schemas: []SchemaRule{
+				{Match: "**/*.config.json", Schema: "./schema.json"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid glob",
+			schemas: []SchemaRule{
+				{Match: "config/[.json", Schema: "./schema.json"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty match",
+			schemas: []SchemaRule{
+				{Schema: "./schema.json"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty schema",
+			schemas: []SchemaRule{
+				{Match: "*.json"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSchemas(tt.schemas)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateHooks(t *testing.T) {
+	require.NoError(t, validateHooks(&HooksConfig{}))
+	require.NoError(t, validateHooks(&HooksConfig{PrePush: []string{"make lint"}}))
+
+	err := validateHooks(&HooksConfig{PrePush: []string{"make lint", "  "}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "hooks.pre_push[1]")
+
+	err = validateHooks(&HooksConfig{PriorityExtracted: []string{""}})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "hooks.priority_extracted[0]")
+}
+
+func TestValidateShortcuts(t *testing.T) {
+	require.NoError(t, validateShortcuts(nil))
+	require.NoError(t, validateShortcuts(map[string]string{"deploy-prod": "pull prod-cfg:stable /etc/app --verify-checksums"}))
+
+	for name, shortcuts := range map[string]map[string]string{
+		"-x":            {"-x": "ls"},
+		"shortcuts.bad": {"bad": `pull "configs`},
+		"shortcuts.nil": {"nil": "  "},
+	} {
+		err := validateShortcuts(shortcuts)
+		require.ErrorIs(t, err, ErrInvalidConfig)
+		assert.Contains(t, err.Error(), name)
+	}
+}
+
+func TestValidatePush(t *testing.T) {
+	require.NoError(t, validatePush(&PushConfig{}))
+	require.NoError(t, validatePush(&PushConfig{
+		SkipCompressMinSize:    4096,
+		SkipCompressExtensions: []string{".png", "JPG"},
+	}))
+
+	err := validatePush(&PushConfig{SkipCompressMinSize: -1})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+
+	err = validatePush(&PushConfig{SkipCompressExtensions: []string{".png", "."}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "push.skip_compress_extensions[1]")
+}
+
+func TestValidateRegistry(t *testing.T) {
+	require.NoError(t, validateRegistry(&RegistryConfig{}))
+	require.NoError(t, validateRegistry(&RegistryConfig{
+		UserAgent:    "acme-deploy/2.0 (+https://acme.example)",
+		ExtraHeaders: map[string]string{"x-gateway-tenant": "acme"},
+	}))
+
+	err := validateRegistry(&RegistryConfig{UserAgent: "bad\r\nInjected: 1"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "registry.user_agent")
+
+	err = validateRegistry(&RegistryConfig{ExtraHeaders: map[string]string{"x tenant": "acme"}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+
+	err = validateRegistry(&RegistryConfig{ExtraHeaders: map[string]string{"x-tenant": "a\nb"}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "registry.extra_headers.x-tenant")
+}
+
+func TestValidateRegistries(t *testing.T) {
+	require.NoError(t, validateRegistries(nil))
+	require.NoError(t, validateRegistries([]RegistryAuth{
+		{Host: "ghcr.io", Username: "octocat", PasswordEnv: "GHCR_TOKEN"},
+		{Host: "123456789012.dkr.ecr.us-east-1.amazonaws.com", CredentialHelper: "ecr-login"},
+		{Host: "registry.internal:5000", SkipTLSVerify: true},
+		{Host: "localhost:5000", PlainHTTP: true},
+	}))
+
+	tests := []struct {
+		name       string
+		registries []RegistryAuth
+		want       string
+	}{
+		{name: "missing host", registries: []RegistryAuth{{SkipTLSVerify: true}}, want: "registries[0].host"},
+		{name: "host with path", registries: []RegistryAuth{{Host: "ghcr.io/acme"}}, want: "registries[0].host"},
+		{
+			name:       "duplicate host",
+			registries: []RegistryAuth{{Host: "ghcr.io"}, {Host: "ghcr.io"}},
+			want:       "more than once",
+		},
+		{
+			name:       "two credential sources",
+			registries: []RegistryAuth{{Host: "ghcr.io", Username: "u", PasswordEnv: "P", CredentialHelper: "gcloud"}},
+			want:       "set only one",
+		},
+		{
+			name:       "password without username",
+			registries: []RegistryAuth{{Host: "ghcr.io", PasswordEnv: "P"}},
+			want:       "needs username",
+		},
+		{
+			name:       "helper path",
+			registries: []RegistryAuth{{Host: "ghcr.io", CredentialHelper: "/usr/bin/docker-credential-gcloud"}},
+			want:       "credential_helper",
+		},
+		{
+			name:       "plain HTTP with TLS settings",
+			registries: []RegistryAuth{{Host: "localhost:5000", PlainHTTP: true, CAFile: "ca.pem"}},
+			want:       "do not apply with plain_http",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRegistries(tt.registries)
+			require.ErrorIs(t, err, ErrInvalidConfig)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestValidateLs(t *testing.T) {
+	require.NoError(t, validateLs(&LsConfig{}))
+	require.NoError(t, validateLs(&LsConfig{Color: ColorAlways, Icons: true}))
+
+	err := validateLs(&LsConfig{Color: "sometimes"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "ls.color")
+}
+
 func TestValidateCache(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -164,6 +380,11 @@ func TestValidateCache(t *testing.T) {
 			cache:   CacheConfig{RefTTL: "5"},
 			wantErr: true,
 		},
+		{
+			name:    "negative ref_ttl",
+			cache:   CacheConfig{RefTTL: "-5m"},
+			wantErr: true,
+		},
 		{
 			name:    "valid max_size",
 			cache:   CacheConfig{MaxSize: "5GB"},
@@ -232,3 +453,26 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSigstore(t *testing.T) {
+	require.NoError(t, validateSigstore(&SigstoreConfig{}))
+	require.NoError(t, validateSigstore(&SigstoreConfig{
+		FulcioURL: "https://fulcio.sigstore.internal",
+		RekorURL:  "http://rekor.internal:3000",
+		TrustRoot: "/etc/blob/trusted_root.json",
+	}))
+
+	err := validateSigstore(&SigstoreConfig{FulcioURL: "fulcio.sigstore.internal"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "sigstore.fulcio_url")
+
+	err = validateSigstore(&SigstoreConfig{RekorURL: "ftp://rekor.internal"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "sigstore.rekor_url")
+
+	err = validateSigstore(&SigstoreConfig{OIDCIssuer: "oauth2.sigstore.internal"})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "sigstore.oidc_issuer")
+}
@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// dialTimeout bounds how long the CLI waits for a daemon before falling
+// back to doing the work itself.
+const dialTimeout = 200 * time.Millisecond
+
+// ErrUnavailable is returned by Connect when no daemon answers on the socket.
+var ErrUnavailable = errors.New("daemon not available")
+
+// Client talks to a daemon over its Unix socket.
+type Client struct {
+	http *http.Client
+}
+
+// Connect returns a client for the daemon listening on socket, after
+// checking that it responds. It returns ErrUnavailable if the socket does
+// not exist or nothing answers.
+func Connect(ctx context.Context, socket string) (*Client, error) {
+	if _, err := os.Stat(socket); err != nil {
+		return nil, ErrUnavailable
+	}
+
+	c := &Client{http: &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}}
+
+	pingCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	if _, err := c.Ping(pingCtx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return c, nil
+}
+
+// Ping returns the daemon's identity.
+func (c *Client) Ping(ctx context.Context) (*PingResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://daemon/v1/ping", http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ping: unexpected status %d", resp.StatusCode)
+	}
+	var ping PingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ping); err != nil {
+		return nil, fmt.Errorf("decoding ping: %w", err)
+	}
+	return &ping, nil
+}
+
+// Cat validates paths in the archive at ref and, unless req.ValidateOnly is
+// set, writes their concatenated content to w. An invalid path is reported
+// as a *ValidationError before anything is written, and a missing archive
+// as ErrNotFound.
+func (c *Client) Cat(ctx context.Context, req CatRequest, w io.Writer) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://daemon/v1/cat", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("daemon request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+			return fmt.Errorf("daemon returned status %d", resp.StatusCode)
+		}
+		if errResp.Validation != nil {
+			return errResp.Validation
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s", ErrNotFound, errResp.Error)
+		}
+		return errors.New(errResp.Error)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("reading from daemon: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Package daemon implements a long-running blob process that keeps registry
+// connections, parsed indexes, and caches warm, and a client the CLI uses
+// to delegate work to it over a Unix socket.
+//
+// The protocol is JSON over HTTP on the socket:
+//
+//	GET  /v1/ping   daemon identity
+//	POST /v1/cat    validate files and stream their concatenated content
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// SocketName is the socket file name within the cache directory.
+const SocketName = "daemon.sock"
+
+// SocketPath returns the default socket path for a cache directory.
+func SocketPath(cacheDir string) string {
+	return filepath.Join(cacheDir, SocketName)
+}
+
+// Archive is an opened archive the daemon can serve.
+type Archive interface {
+	fs.FS
+	// ValidateFiles checks that all paths are regular files and returns
+	// them normalized, or a *ValidationError for the first invalid path.
+	ValidateFiles(paths ...string) ([]string, error)
+}
+
+// ErrNotFound reports that no archive exists at a reference. An OpenFunc
+// wraps it so the client can tell a missing archive from other failures.
+var ErrNotFound = errors.New("archive not found")
+
+// OpenFunc opens the archive at a resolved reference.
+type OpenFunc func(ctx context.Context, ref string) (Archive, error)
+
+// ValidationError describes a path that cannot be read from an archive.
+// Reason is one of the archive's validation reasons (e.g., "not found").
+type ValidationError struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Reason
+}
+
+// PingResponse identifies a running daemon.
+type PingResponse struct {
+	PID     int    `json:"pid"`
+	Version string `json:"version"`
+}
+
+// CatRequest asks the daemon to print files from an archive.
+type CatRequest struct {
+	Ref          string   `json:"ref"`
+	Paths        []string `json:"paths"`
+	ValidateOnly bool     `json:"validate_only,omitempty"`
+}
+
+// errorResponse is the body of a failed request.
+type errorResponse struct {
+	Error      string           `json:"error"`
+	Validation *ValidationError `json:"validation,omitempty"`
+}
+
+// NewHandler returns the daemon's HTTP handler. Requests are logged to
+// logger at debug level; a nil logger discards them.
+func NewHandler(open OpenFunc, version string, logger *slog.Logger) http.Handler {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	h := &handler{open: open, version: version, log: logger}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/ping", h.ping)
+	mux.HandleFunc("POST /v1/cat", h.cat)
+	return mux
+}
+
+type handler struct {
+	open    OpenFunc
+	version string
+	log     *slog.Logger
+}
+
+func (h *handler) ping(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, PingResponse{PID: os.Getpid(), Version: h.version})
+}
+
+func (h *handler) cat(w http.ResponseWriter, r *http.Request) {
+	var req CatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("decoding request: %v", err)})
+		return
+	}
+	h.log.Debug("daemon cat", "ref", req.Ref, "paths", req.Paths)
+
+	archive, err := h.open(r.Context(), req.Ref)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeJSON(w, status, errorResponse{Error: err.Error()})
+		return
+	}
+
+	paths, err := archive.ValidateFiles(req.Paths...)
+	if err != nil {
+		resp := errorResponse{Error: err.Error()}
+		var ve *ValidationError
+		if errors.As(err, &ve) {
+			resp.Validation = ve
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	if req.ValidateOnly {
+		return
+	}
+
+	// Once streaming has started the status cannot change, so failures
+	// abort the connection and the client reports a truncated response.
+	for _, name := range paths {
+		if err := copyFile(w, archive, name); err != nil {
+			h.log.Warn("daemon cat failed", "ref", req.Ref, "path", name, "error", err)
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+// copyFile streams one file to w. Content is verified on close.
+func copyFile(w io.Writer, fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	//nolint:errcheck // the client may have gone away
+	json.NewEncoder(w).Encode(v)
+}
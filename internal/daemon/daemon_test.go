@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapArchive implements Archive over a MapFS.
+type mapArchive struct {
+	fstest.MapFS
+}
+
+func (a mapArchive) ValidateFiles(paths ...string) ([]string, error) {
+	for _, p := range paths {
+		info, err := fs.Stat(a.MapFS, p)
+		switch {
+		case err != nil:
+			return nil, &ValidationError{Path: p, Reason: "not found"}
+		case info.IsDir():
+			return nil, &ValidationError{Path: p, Reason: "is a directory"}
+		}
+	}
+	return paths, nil
+}
+
+// startDaemon serves a test archive on a socket and returns the socket path.
+func startDaemon(t *testing.T) string {
+	t.Helper()
+	archive := mapArchive{fstest.MapFS{
+		"a.txt":     {Data: []byte("alpha\n")},
+		"dir/b.txt": {Data: []byte("beta\n")},
+	}}
+	open := func(_ context.Context, ref string) (Archive, error) {
+		switch ref {
+		case "ghcr.io/acme/app:v1":
+		case "ghcr.io/acme/missing:v1":
+			return nil, fmt.Errorf("accessing archive: %w", ErrNotFound)
+		default:
+			return nil, errors.New("accessing archive: connection refused")
+		}
+		return archive, nil
+	}
+
+	socket := SocketPath(t.TempDir())
+	var lc net.ListenConfig
+	ln, err := lc.Listen(t.Context(), "unix", socket)
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: NewHandler(open, "test", nil)} //nolint:gosec // test server
+	go srv.Serve(ln)                                            //nolint:errcheck // closed in cleanup
+	t.Cleanup(func() { srv.Close() })
+	return socket
+}
+
+func TestConnect_NoSocket(t *testing.T) {
+	_, err := Connect(t.Context(), filepath.Join(t.TempDir(), SocketName))
+	require.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestCat(t *testing.T) {
+	client, err := Connect(t.Context(), startDaemon(t))
+	require.NoError(t, err)
+
+	ping, err := client.Ping(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "test", ping.Version)
+
+	var out bytes.Buffer
+	require.NoError(t, client.Cat(t.Context(), CatRequest{Ref: "ghcr.io/acme/app:v1", Paths: []string{"a.txt", "dir/b.txt"}}, &out))
+	assert.Equal(t, "alpha\nbeta\n", out.String())
+
+	out.Reset()
+	require.NoError(t, client.Cat(t.Context(), CatRequest{Ref: "ghcr.io/acme/app:v1", Paths: []string{"a.txt"}, ValidateOnly: true}, &out))
+	assert.Empty(t, out.String())
+}
+
+func TestCat_Errors(t *testing.T) {
+	client, err := Connect(t.Context(), startDaemon(t))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	err = client.Cat(t.Context(), CatRequest{Ref: "ghcr.io/acme/app:v1", Paths: []string{"a.txt", "dir"}}, &out)
+	var ve *ValidationError
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, "dir", ve.Path)
+	assert.Equal(t, "is a directory", ve.Reason)
+	assert.Empty(t, out.String(), "nothing is written when validation fails")
+
+	err = client.Cat(t.Context(), CatRequest{Ref: "ghcr.io/acme/other:v1", Paths: []string{"a.txt"}}, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection refused")
+	require.NotErrorIs(t, err, ErrNotFound)
+
+	err = client.Cat(t.Context(), CatRequest{Ref: "ghcr.io/acme/missing:v1", Paths: []string{"a.txt"}}, &out)
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,134 @@
+package evidence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/meigma/blob/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ErrNotRecorded is returned when a policy asks for material that is not
+// part of the evidence.
+var ErrNotRecorded = errors.New("not recorded in evidence")
+
+// Bundle is a saved evidence directory.
+// It implements registry.PolicyClient by serving recorded material,
+// so policies can be re-evaluated without network access.
+type Bundle struct {
+	Dir    string
+	Record *Record
+}
+
+// Load reads an evidence directory written by Recorder.Save.
+func Load(dir string) (*Bundle, error) {
+	data, err := os.ReadFile(filepath.Join(dir, RecordFile)) //nolint:gosec // evidence path is user-provided
+	if err != nil {
+		return nil, fmt.Errorf("reading evidence record: %w", err)
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("parsing evidence record: %w", err)
+	}
+	if record.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported evidence version %d (expected %d)", record.Version, FormatVersion)
+	}
+	if err := record.Subject.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid evidence subject: %w", err)
+	}
+	names := append([]string{record.Policies.Rego, record.TrustedRoot}, record.Policies.Files...)
+	for _, name := range names {
+		if name != "" && !filepath.IsLocal(filepath.FromSlash(name)) {
+			return nil, fmt.Errorf("invalid evidence record: path %q is outside the evidence directory", name)
+		}
+	}
+
+	return &Bundle{Dir: dir, Record: &record}, nil
+}
+
+// Request returns a policy request for the recorded subject, served by b.
+func (b *Bundle) Request() registry.PolicyRequest {
+	return registry.PolicyRequest{
+		Ref:     b.Record.Ref,
+		Digest:  b.Record.Digest,
+		Subject: b.Record.Subject,
+		Client:  b,
+	}
+}
+
+// PolicyFiles returns the paths of the recorded YAML policy files.
+func (b *Bundle) PolicyFiles() []string {
+	paths := make([]string, 0, len(b.Record.Policies.Files))
+	for _, name := range b.Record.Policies.Files {
+		paths = append(paths, b.path(name))
+	}
+	return paths
+}
+
+// PolicyRego returns the path of the recorded Rego policy, or "".
+func (b *Bundle) PolicyRego() string {
+	if b.Record.Policies.Rego == "" {
+		return ""
+	}
+	return b.path(b.Record.Policies.Rego)
+}
+
+// TrustedRootPath returns the path of the recorded trusted root, or "".
+func (b *Bundle) TrustedRootPath() string {
+	if b.Record.TrustedRoot == "" {
+		return ""
+	}
+	return b.path(b.Record.TrustedRoot)
+}
+
+// Referrers implements registry.PolicyClient using the recorded listings.
+func (b *Bundle) Referrers(
+	_ context.Context,
+	_ string,
+	subject ocispec.Descriptor,
+	artifactType string,
+) ([]ocispec.Descriptor, error) {
+	if subject.Digest != b.Record.Subject.Digest {
+		return nil, fmt.Errorf("referrers of %s: %w", subject.Digest, ErrNotRecorded)
+	}
+	for _, set := range b.Record.Referrers {
+		if set.ArtifactType != artifactType {
+			continue
+		}
+		if set.Error != "" {
+			return nil, fmt.Errorf("recorded referrers error: %s", set.Error)
+		}
+		return set.Descriptors, nil
+	}
+	return nil, fmt.Errorf("referrers of type %s: %w", artifactType, ErrNotRecorded)
+}
+
+// FetchDescriptor implements registry.PolicyClient by reading recorded blobs.
+// Content is checked against the descriptor digest.
+func (b *Bundle) FetchDescriptor(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid descriptor digest: %w", err)
+	}
+
+	data, err := os.ReadFile(b.path(blobPath(desc.Digest)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("blob %s: %w", desc.Digest, ErrNotRecorded)
+		}
+		return nil, fmt.Errorf("reading blob %s: %w", desc.Digest, err)
+	}
+	if actual := desc.Digest.Algorithm().FromBytes(data); actual != desc.Digest {
+		return nil, fmt.Errorf("blob %s is corrupt: content digest is %s", desc.Digest, actual)
+	}
+
+	return data, nil
+}
+
+func (b *Bundle) path(name string) string {
+	return filepath.Join(b.Dir, filepath.FromSlash(name))
+}
@@ -0,0 +1,128 @@
+package evidence
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// saveTestEvidence records readingPolicy against a test request and saves it.
+func saveTestEvidence(t *testing.T) string {
+	t.Helper()
+
+	req, _ := newTestRequest()
+	rec := NewRecorder()
+	require.NoError(t, rec.Wrap(readingPolicy).Evaluate(context.Background(), req))
+
+	dir := filepath.Join(t.TempDir(), "evidence")
+	_, err := rec.Save(dir, &Sources{TrustedRoot: []byte(`{}`)}, Decision{Verified: true, Status: "verified"})
+	require.NoError(t, err)
+	return dir
+}
+
+func TestLoad_Replay(t *testing.T) {
+	dir := saveTestEvidence(t)
+
+	bundle, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/acme/configs:v1", bundle.Record.Ref)
+	assert.Equal(t, filepath.Join(dir, TrustedRootFile), bundle.TrustedRootPath())
+	assert.Empty(t, bundle.PolicyRego())
+
+	// The recorded policy evaluates offline against the bundle.
+	require.NoError(t, readingPolicy.Evaluate(context.Background(), bundle.Request()))
+
+	// Referrers of other types were never recorded.
+	req := bundle.Request()
+	_, err = bundle.Referrers(context.Background(), req.Ref, req.Subject, "application/vnd.in-toto+json")
+	require.ErrorIs(t, err, ErrNotRecorded)
+}
+
+func TestBundle_FetchDescriptor(t *testing.T) {
+	dir := saveTestEvidence(t)
+	bundle, err := Load(dir)
+	require.NoError(t, err)
+	req := bundle.Request()
+
+	t.Run("recorded blob", func(t *testing.T) {
+		data, err := bundle.FetchDescriptor(context.Background(), req.Ref, req.Subject)
+		require.NoError(t, err)
+		assert.Equal(t, `{"schemaVersion":2}`, string(data))
+	})
+
+	t.Run("missing blob", func(t *testing.T) {
+		desc := req.Subject
+		desc.Digest = "sha256:" + "0000000000000000000000000000000000000000000000000000000000000000"
+		_, err := bundle.FetchDescriptor(context.Background(), req.Ref, desc)
+		require.ErrorIs(t, err, ErrNotRecorded)
+	})
+
+	t.Run("corrupt blob", func(t *testing.T) {
+		path := filepath.Join(dir, "blobs", "sha256", req.Subject.Digest.Encoded())
+		require.NoError(t, os.WriteFile(path, []byte("tampered"), 0o600))
+
+		_, err := bundle.FetchDescriptor(context.Background(), req.Ref, req.Subject)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "corrupt")
+	})
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Record)
+		wantErr string
+	}{
+		{
+			name:    "unsupported version",
+			modify:  func(r *Record) { r.Version = FormatVersion + 1 },
+			wantErr: "unsupported evidence version",
+		},
+		{
+			name:    "invalid subject",
+			modify:  func(r *Record) { r.Subject.Digest = "sha256:bad" },
+			wantErr: "invalid evidence subject",
+		},
+		{
+			name:    "policy outside directory",
+			modify:  func(r *Record) { r.Policies.Files = []string{"../policy.yaml"} },
+			wantErr: "outside the evidence directory",
+		},
+		{
+			name:    "absolute trusted root",
+			modify:  func(r *Record) { r.TrustedRoot = "/etc/trusted_root.json" },
+			wantErr: "outside the evidence directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := saveTestEvidence(t)
+			path := filepath.Join(dir, RecordFile)
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			var record Record
+			require.NoError(t, json.Unmarshal(data, &record))
+			tt.modify(&record)
+			data, err = json.Marshal(&record)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, data, 0o600))
+
+			_, err = Load(dir)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	_, err := Load(t.TempDir())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reading evidence record")
+}
@@ -0,0 +1,66 @@
+// Package evidence records the material used to verify an archive so it can
+// be stored for audit and re-verified later without network access.
+//
+// An evidence directory has the following layout:
+//
+//	evidence.json          verification record (reference, subject, decision)
+//	trusted_root.json      Sigstore trusted root (if available)
+//	blobs/sha256/<hex>     every manifest, bundle, and attestation fetched
+//	policies/              policy documents that were evaluated
+package evidence
+
+import (
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// File and directory names within an evidence directory.
+const (
+	// RecordFile is the verification record.
+	RecordFile = "evidence.json"
+	// TrustedRootFile holds the Sigstore trusted root used for verification.
+	TrustedRootFile = "trusted_root.json"
+	// BlobsDir holds fetched content, addressed by digest.
+	BlobsDir = "blobs"
+	// PoliciesDir holds the evaluated policy documents.
+	PoliciesDir = "policies"
+)
+
+// FormatVersion is the evidence directory format version.
+const FormatVersion = 1
+
+// Record describes a single verification and the material it used.
+type Record struct {
+	Version     int                `json:"version"`
+	Ref         string             `json:"ref"`
+	Digest      string             `json:"digest"`
+	Subject     ocispec.Descriptor `json:"subject"`
+	RecordedAt  time.Time          `json:"recorded_at"`
+	Referrers   []ReferrerSet      `json:"referrers,omitempty"`
+	Policies    PolicySet          `json:"policies"`
+	TrustedRoot string             `json:"trusted_root,omitempty"`
+	Decision    Decision           `json:"decision"`
+}
+
+// ReferrerSet is a recorded referrers listing for one artifact type.
+type ReferrerSet struct {
+	ArtifactType string               `json:"artifact_type"`
+	Descriptors  []ocispec.Descriptor `json:"descriptors"`
+	Error        string               `json:"error,omitempty"`
+}
+
+// PolicySet lists the policy documents in the evidence directory.
+// Paths are relative to the evidence directory.
+type PolicySet struct {
+	Files []string `json:"files,omitempty"`
+	Rego  string   `json:"rego,omitempty"`
+}
+
+// Decision is the outcome of the recorded verification.
+type Decision struct {
+	Verified        bool   `json:"verified"`
+	Status          string `json:"status"`
+	PoliciesApplied int    `json:"policies_applied"`
+	Error           string `json:"error,omitempty"`
+}
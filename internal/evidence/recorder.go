@@ -0,0 +1,228 @@
+package evidence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/meigma/blob/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/policy"
+)
+
+// Recorder captures everything policies read from the registry.
+// Wrap each policy with Recorder.Wrap before creating the client.
+type Recorder struct {
+	mu        sync.Mutex
+	ref       string
+	digest    string
+	subject   ocispec.Descriptor
+	seen      bool
+	referrers []ReferrerSet
+	blobs     map[digest.Digest][]byte
+}
+
+// Sources lists the policy documents and trust material used for verification.
+type Sources struct {
+	ConfigPolicies []config.Policy // Policies matched from the config file
+	PolicyFiles    []string        // Policy files given on the command line
+	PolicyRego     string          // OPA Rego policy file
+	TrustedRoot    []byte          // Sigstore trusted root JSON (optional)
+}
+
+// NewRecorder creates an empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{blobs: make(map[digest.Digest][]byte)}
+}
+
+// Wrap returns a policy that evaluates p against a recording client.
+// The subject manifest is always recorded, even if p does not read it.
+func (r *Recorder) Wrap(p registry.Policy) registry.Policy {
+	return registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+		client := &recordingClient{inner: req.Client, rec: r}
+		r.setSubject(req)
+		if _, err := client.FetchDescriptor(ctx, req.Ref, req.Subject); err != nil {
+			return fmt.Errorf("recording manifest: %w", err)
+		}
+		req.Client = client
+		return p.Evaluate(ctx, req)
+	})
+}
+
+func (r *Recorder) setSubject(req registry.PolicyRequest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen {
+		return
+	}
+	r.ref = req.Ref
+	r.digest = req.Digest
+	r.subject = req.Subject
+	r.seen = true
+}
+
+func (r *Recorder) addReferrers(set ReferrerSet) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.referrers {
+		if r.referrers[i].ArtifactType == set.ArtifactType {
+			r.referrers[i] = set
+			return
+		}
+	}
+	r.referrers = append(r.referrers, set)
+}
+
+func (r *Recorder) addBlob(d digest.Digest, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.blobs[d] = data
+}
+
+// Save writes the recorded material, policy documents, and decision to dir.
+// The directory is created if needed; existing evidence files are overwritten.
+func (r *Recorder) Save(dir string, src *Sources, decision Decision) (*Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.seen {
+		return nil, errors.New("no verification material was recorded")
+	}
+
+	record := &Record{
+		Version:    FormatVersion,
+		Ref:        r.ref,
+		Digest:     r.digest,
+		Subject:    r.subject,
+		RecordedAt: time.Now().UTC(),
+		Referrers:  slices.Clone(r.referrers),
+		Decision:   decision,
+	}
+
+	for d, data := range r.blobs {
+		if err := writeFile(dir, blobPath(d), data); err != nil {
+			return nil, err
+		}
+	}
+
+	policies, err := savePolicies(dir, src)
+	if err != nil {
+		return nil, err
+	}
+	record.Policies = policies
+
+	if len(src.TrustedRoot) > 0 {
+		if err := writeFile(dir, TrustedRootFile, src.TrustedRoot); err != nil {
+			return nil, err
+		}
+		record.TrustedRoot = TrustedRootFile
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding evidence record: %w", err)
+	}
+	if err := writeFile(dir, RecordFile, append(data, '\n')); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// savePolicies copies policy documents into the evidence directory.
+func savePolicies(dir string, src *Sources) (PolicySet, error) {
+	var set PolicySet
+
+	for i := range src.ConfigPolicies {
+		data, err := policy.MarshalFile(&src.ConfigPolicies[i])
+		if err != nil {
+			return set, err
+		}
+		name := filepath.ToSlash(filepath.Join(PoliciesDir, fmt.Sprintf("config-%d.yaml", i)))
+		if err := writeFile(dir, name, data); err != nil {
+			return set, err
+		}
+		set.Files = append(set.Files, name)
+	}
+
+	for i, path := range src.PolicyFiles {
+		name := filepath.ToSlash(filepath.Join(PoliciesDir, fmt.Sprintf("file-%d-%s", i, filepath.Base(path))))
+		if err := copyFile(dir, name, path); err != nil {
+			return set, err
+		}
+		set.Files = append(set.Files, name)
+	}
+
+	if src.PolicyRego != "" {
+		name := filepath.ToSlash(filepath.Join(PoliciesDir, filepath.Base(src.PolicyRego)))
+		if err := copyFile(dir, name, src.PolicyRego); err != nil {
+			return set, err
+		}
+		set.Rego = name
+	}
+
+	return set, nil
+}
+
+// blobPath returns the slash-separated path of a blob within the evidence directory.
+func blobPath(d digest.Digest) string {
+	return BlobsDir + "/" + d.Algorithm().String() + "/" + d.Encoded()
+}
+
+func copyFile(dir, name, src string) error {
+	data, err := os.ReadFile(src) //nolint:gosec // policy paths are user-provided
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", src, err)
+	}
+	return writeFile(dir, name, data)
+}
+
+func writeFile(dir, name string, data []byte) error {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating evidence directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return nil
+}
+
+// recordingClient forwards to the registry and records every response.
+type recordingClient struct {
+	inner registry.PolicyClient
+	rec   *Recorder
+}
+
+func (c *recordingClient) Referrers(
+	ctx context.Context,
+	ref string,
+	subject ocispec.Descriptor,
+	artifactType string,
+) ([]ocispec.Descriptor, error) {
+	descs, err := c.inner.Referrers(ctx, ref, subject, artifactType)
+	set := ReferrerSet{ArtifactType: artifactType, Descriptors: descs}
+	if err != nil {
+		set.Error = err.Error()
+	}
+	c.rec.addReferrers(set)
+	return descs, err
+}
+
+func (c *recordingClient) FetchDescriptor(ctx context.Context, ref string, desc ocispec.Descriptor) ([]byte, error) {
+	data, err := c.inner.FetchDescriptor(ctx, ref, desc)
+	if err != nil {
+		return nil, err
+	}
+	c.rec.addBlob(desc.Digest, data)
+	return data, nil
+}
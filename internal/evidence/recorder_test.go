@@ -0,0 +1,136 @@
+package evidence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/meigma/blob/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/meigma/blob-cli/internal/config"
+)
+
+const testArtifactType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+
+// fakeClient serves fixed referrers and blobs.
+type fakeClient struct {
+	referrers []ocispec.Descriptor
+	blobs     map[digest.Digest][]byte
+}
+
+func (c *fakeClient) Referrers(context.Context, string, ocispec.Descriptor, string) ([]ocispec.Descriptor, error) {
+	return c.referrers, nil
+}
+
+func (c *fakeClient) FetchDescriptor(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	data, ok := c.blobs[desc.Digest]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+// newTestRequest builds a policy request whose client serves one signature bundle.
+func newTestRequest() (registry.PolicyRequest, ocispec.Descriptor) {
+	manifest := []byte(`{"schemaVersion":2}`)
+	bundle := []byte(`{"bundle":true}`)
+
+	subject := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	bundleDesc := ocispec.Descriptor{
+		ArtifactType: testArtifactType,
+		Digest:       digest.FromBytes(bundle),
+		Size:         int64(len(bundle)),
+	}
+
+	client := &fakeClient{
+		referrers: []ocispec.Descriptor{bundleDesc},
+		blobs: map[digest.Digest][]byte{
+			subject.Digest:    manifest,
+			bundleDesc.Digest: bundle,
+		},
+	}
+
+	req := registry.PolicyRequest{
+		Ref:     "ghcr.io/acme/configs:v1",
+		Digest:  subject.Digest.String(),
+		Subject: subject,
+		Client:  client,
+	}
+	return req, bundleDesc
+}
+
+// readingPolicy lists signature referrers and fetches each one.
+var readingPolicy = registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+	descs, err := req.Client.Referrers(ctx, req.Ref, req.Subject, testArtifactType)
+	if err != nil {
+		return err
+	}
+	for _, d := range descs {
+		if _, err := req.Client.FetchDescriptor(ctx, req.Ref, d); err != nil {
+			return err
+		}
+	}
+	return nil
+})
+
+func TestRecorder_Save(t *testing.T) {
+	req, bundleDesc := newTestRequest()
+
+	rec := NewRecorder()
+	require.NoError(t, rec.Wrap(readingPolicy).Evaluate(context.Background(), req))
+
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(policyFile, []byte("signature: {}\n"), 0o644))
+
+	dir := filepath.Join(t.TempDir(), "evidence")
+	src := &Sources{
+		ConfigPolicies: []config.Policy{{
+			Signature: &config.SignaturePolicy{
+				Keyless: &config.KeylessConfig{Issuer: "https://issuer", Identity: "me"},
+			},
+		}},
+		PolicyFiles: []string{policyFile},
+		TrustedRoot: []byte(`{"mediaType":"root"}`),
+	}
+	record, err := rec.Save(dir, src, Decision{Verified: true, Status: "verified", PoliciesApplied: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, req.Ref, record.Ref)
+	assert.Equal(t, req.Digest, record.Digest)
+	require.Len(t, record.Referrers, 1)
+	assert.Equal(t, []ocispec.Descriptor{bundleDesc}, record.Referrers[0].Descriptors)
+	assert.Equal(t, []string{"policies/config-0.yaml", "policies/file-0-policy.yaml"}, record.Policies.Files)
+	assert.Equal(t, TrustedRootFile, record.TrustedRoot)
+
+	// Manifest and bundle are stored by digest.
+	assert.FileExists(t, filepath.Join(dir, "blobs", "sha256", req.Subject.Digest.Encoded()))
+	assert.FileExists(t, filepath.Join(dir, "blobs", "sha256", bundleDesc.Digest.Encoded()))
+	assert.FileExists(t, filepath.Join(dir, TrustedRootFile))
+
+	configPolicy, err := os.ReadFile(filepath.Join(dir, "policies", "config-0.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(configPolicy), "issuer: https://issuer")
+
+	data, err := os.ReadFile(filepath.Join(dir, RecordFile))
+	require.NoError(t, err)
+	var saved Record
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, FormatVersion, saved.Version)
+	assert.True(t, saved.Decision.Verified)
+}
+
+func TestRecorder_SaveWithoutEvaluation(t *testing.T) {
+	_, err := NewRecorder().Save(t.TempDir(), &Sources{}, Decision{})
+	require.Error(t, err)
+}
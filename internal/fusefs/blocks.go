@@ -0,0 +1,168 @@
+package fusefs
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"sync"
+
+	"github.com/meigma/blob"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/meigma/blob-cli/internal/archive"
+)
+
+// Default block and cache sizes.
+const (
+	DefaultBlockSize = 1 << 20   // 1 MiB per range request
+	DefaultCacheSize = 256 << 20 // 256 MiB of cached blocks
+)
+
+// blockKey identifies a cached block.
+type blockKey struct {
+	path  string
+	index int64
+}
+
+// block is a cached block of file data.
+type block struct {
+	key  blockKey
+	data []byte
+}
+
+// BlockReader reads file data in fixed-size blocks. Each block is fetched
+// with one range request and kept in an LRU cache bounded by size, so
+// repeated and sequential reads of nearby bytes do not reach the registry.
+//
+// Compressed files cannot be read from an offset without decompressing
+// everything before it, so they are fetched whole as a single block.
+type BlockReader struct {
+	archive   *blob.Archive
+	blockSize int64
+	cacheSize int64
+
+	mu     sync.Mutex
+	lru    *list.List // of *block, most recently used first
+	blocks map[blockKey]*list.Element
+	cached int64 // bytes held by blocks
+
+	fetches singleflight.Group
+}
+
+// NewBlockReader returns a reader for the files of a. Non-positive sizes
+// select the defaults.
+func NewBlockReader(a *blob.Archive, blockSize, cacheSize int64) *BlockReader {
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	if cacheSize <= 0 {
+		cacheSize = DefaultCacheSize
+	}
+	return &BlockReader{
+		archive:   a,
+		blockSize: blockSize,
+		cacheSize: cacheSize,
+		lru:       list.New(),
+		blocks:    make(map[blockKey]*list.Element),
+	}
+}
+
+// ReadAt reads len(p) bytes of the file at filePath starting at off. It
+// returns io.EOF when fewer bytes remain.
+func (r *BlockReader) ReadAt(filePath string, p []byte, off int64) (int, error) {
+	entry, ok := r.archive.Entry(filePath)
+	if !ok {
+		return 0, &fs.PathError{Op: "read", Path: filePath, Err: fs.ErrNotExist}
+	}
+	size := int64(entry.OriginalSize()) //nolint:gosec // archive sizes fit in int64
+	blockSize := r.blockSize
+	if entry.Compression() != blob.CompressionNone {
+		blockSize = max(size, 1)
+	}
+
+	n := 0
+	for n < len(p) && off < size {
+		index := off / blockSize
+		data, err := r.block(filePath, index, blockSize, size)
+		if err != nil {
+			return n, err
+		}
+		copied := copy(p[n:], data[off-index*blockSize:])
+		n += copied
+		off += int64(copied)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// block returns block index of the file, fetching it if it is not cached.
+func (r *BlockReader) block(filePath string, index, blockSize, fileSize int64) ([]byte, error) {
+	key := blockKey{path: filePath, index: index}
+	if data, ok := r.cachedBlock(key); ok {
+		return data, nil
+	}
+
+	// Concurrent reads of the same block share one fetch
+	v, err, _ := r.fetches.Do(filePath+"\x00"+strconv.FormatInt(index, 10), func() (any, error) {
+		start := index * blockSize
+		rc, err := archive.OpenRange(r.archive, filePath, start, min(start+blockSize, fileSize))
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", filePath, err)
+		}
+		r.store(key, data)
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil //nolint:errcheck // the fetch always returns a []byte
+}
+
+// cachedBlock returns the cached block for key and marks it recently used.
+func (r *BlockReader) cachedBlock(key blockKey) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	el, ok := r.blocks[key]
+	if !ok {
+		return nil, false
+	}
+	r.lru.MoveToFront(el)
+	return el.Value.(*block).data, true //nolint:errcheck // the list only holds blocks
+}
+
+// store caches data for key, evicting the least recently used blocks to
+// stay within the cache size. The newest block is always kept, even if it
+// alone exceeds the cache size.
+func (r *BlockReader) store(key blockKey, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.blocks[key]; ok {
+		return
+	}
+	r.blocks[key] = r.lru.PushFront(&block{key: key, data: data})
+	r.cached += int64(len(data))
+
+	for r.cached > r.cacheSize && r.lru.Len() > 1 {
+		oldest := r.lru.Back()
+		b := oldest.Value.(*block) //nolint:errcheck // the list only holds blocks
+		r.lru.Remove(oldest)
+		delete(r.blocks, b.key)
+		r.cached -= int64(len(b.data))
+	}
+}
+
+// CachedBytes returns the number of bytes held in the block cache.
+func (r *BlockReader) CachedBytes() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cached
+}
@@ -0,0 +1,124 @@
+package fusefs
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/meigma/blob"
+	blobcore "github.com/meigma/blob/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingSource serves archive data from memory and counts reads.
+type countingSource struct {
+	*bytes.Reader
+	reads atomic.Int64
+}
+
+func (s *countingSource) ReadAt(p []byte, off int64) (int, error) {
+	s.reads.Add(1)
+	return s.Reader.ReadAt(p, off)
+}
+
+func (*countingSource) SourceID() string { return "test" }
+
+// newTestArchive builds an archive of files in memory.
+func newTestArchive(t *testing.T, files map[string]string, opts ...blobcore.CreateOption) (*blob.Archive, *countingSource) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	var indexBuf, dataBuf bytes.Buffer
+	require.NoError(t, blobcore.Create(t.Context(), dir, &indexBuf, &dataBuf, opts...))
+	src := &countingSource{Reader: bytes.NewReader(dataBuf.Bytes())}
+	b, err := blobcore.New(indexBuf.Bytes(), src)
+	require.NoError(t, err)
+	return &blob.Archive{Blob: b}, src
+}
+
+func TestBlockReader_ReadAt(t *testing.T) {
+	content := strings.Repeat("0123456789", 100)
+	a, _ := newTestArchive(t, map[string]string{"data.bin": content})
+	r := NewBlockReader(a, 64, 1024)
+
+	tests := []struct {
+		name    string
+		off     int64
+		n       int
+		want    string
+		wantEOF bool
+	}{
+		{name: "within a block", off: 3, n: 10, want: content[3:13]},
+		{name: "across blocks", off: 60, n: 100, want: content[60:160]},
+		{name: "at the end", off: 990, n: 20, want: content[990:], wantEOF: true},
+		{name: "past the end", off: 2000, n: 10, want: "", wantEOF: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := make([]byte, tt.n)
+			n, err := r.ReadAt("data.bin", p, tt.off)
+			if tt.wantEOF {
+				require.ErrorIs(t, err, io.EOF)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, string(p[:n]))
+		})
+	}
+
+	_, err := r.ReadAt("missing.bin", make([]byte, 1), 0)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestBlockReader_Caches(t *testing.T) {
+	content := strings.Repeat("abcdefgh", 64)
+	a, src := newTestArchive(t, map[string]string{"data.bin": content})
+	r := NewBlockReader(a, 128, 256)
+
+	p := make([]byte, 16)
+	_, err := r.ReadAt("data.bin", p, 0)
+	require.NoError(t, err)
+	reads := src.reads.Load()
+
+	// Same block: served from the cache
+	_, err = r.ReadAt("data.bin", p, 100)
+	require.NoError(t, err)
+	assert.Equal(t, reads, src.reads.Load())
+
+	// Two more blocks evict the first, keeping the cache within 256 bytes
+	_, err = r.ReadAt("data.bin", p, 200)
+	require.NoError(t, err)
+	_, err = r.ReadAt("data.bin", p, 300)
+	require.NoError(t, err)
+	assert.Equal(t, int64(256), r.CachedBytes())
+
+	reads = src.reads.Load()
+	_, err = r.ReadAt("data.bin", p, 0)
+	require.NoError(t, err)
+	assert.Greater(t, src.reads.Load(), reads)
+}
+
+func TestBlockReader_Compressed(t *testing.T) {
+	content := strings.Repeat("compressible ", 200)
+	a, _ := newTestArchive(t, map[string]string{"data.txt": content},
+		blobcore.CreateWithCompression(blobcore.CompressionZstd))
+	r := NewBlockReader(a, 64, 1<<20)
+
+	p := make([]byte, 100)
+	n, err := r.ReadAt("data.txt", p, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, content[1000:1100], string(p[:n]))
+	assert.Equal(t, int64(len(content)), r.CachedBytes(), "compressed files are cached whole")
+}
@@ -0,0 +1,43 @@
+// Package fusefs exposes a blob archive as a read-only FUSE filesystem.
+//
+// Directories are synthesized from file paths, as in "blob ls". File reads
+// are translated into range requests for fixed-size blocks, which are
+// cached in memory (see BlockReader), so only the parts of files that are
+// read are downloaded.
+package fusefs
+
+import "errors"
+
+// ErrUnsupported is returned by Mount on platforms without FUSE support.
+var ErrUnsupported = errors.New("FUSE mounts are not supported on this platform")
+
+// Options configures a mount.
+type Options struct {
+	// Name is shown as the filesystem source in mount tables, typically
+	// the archive reference.
+	Name string
+
+	// BlockSize is the size of each range request; 0 selects
+	// DefaultBlockSize.
+	BlockSize int64
+
+	// CacheSize bounds the memory used for cached blocks; 0 selects
+	// DefaultCacheSize.
+	CacheSize int64
+
+	// AllowOther lets users other than the one mounting access the files.
+	// It requires user_allow_other in /etc/fuse.conf.
+	AllowOther bool
+
+	// Debug logs every FUSE request to stderr.
+	Debug bool
+}
+
+// Server is a mounted archive.
+type Server interface {
+	// Wait blocks until the filesystem is unmounted.
+	Wait()
+
+	// Unmount unmounts the filesystem.
+	Unmount() error
+}
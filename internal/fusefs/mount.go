@@ -0,0 +1,187 @@
+//go:build linux || darwin
+
+package fusefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	gofs "github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/meigma/blob"
+)
+
+// entryTimeout is how long the kernel may cache names and attributes.
+// Archives are immutable, so it is long.
+const entryTimeout = time.Hour
+
+// Mount mounts the archive read-only at mountpoint. Regular files and
+// symbolic links are exposed; other special files are omitted.
+func Mount(a *blob.Archive, index *blob.IndexView, mountpoint string, opts Options) (Server, error) {
+	reader := NewBlockReader(a, opts.BlockSize, opts.CacheSize)
+	root := &dirNode{}
+	timeout := entryTimeout
+
+	fsOpts := &gofs.Options{
+		MountOptions: fuse.MountOptions{
+			FsName:      opts.Name,
+			Name:        "blob",
+			AllowOther:  opts.AllowOther,
+			Debug:       opts.Debug,
+			DirectMount: true, // fall back to fusermount when not permitted
+			Options:     []string{"ro"},
+		},
+		EntryTimeout:    &timeout,
+		AttrTimeout:     &timeout,
+		NegativeTimeout: &timeout,
+		UID:             uint32(os.Getuid()), //nolint:gosec // user IDs fit in uint32
+		GID:             uint32(os.Getgid()), //nolint:gosec // group IDs fit in uint32
+		OnAdd: func(ctx context.Context) {
+			addEntries(ctx, &root.Inode, index, reader)
+		},
+	}
+
+	srv, err := gofs.Mount(mountpoint, root, fsOpts)
+	if err != nil {
+		return nil, fmt.Errorf("mounting %s: %w", mountpoint, err)
+	}
+	return srv, nil
+}
+
+// addEntries adds a node for every file in index below root, creating the
+// directories on their paths.
+func addEntries(ctx context.Context, root *gofs.Inode, index *blob.IndexView, reader *BlockReader) {
+	for entry := range index.Entries() {
+		mode := entry.Mode()
+		if mode.Type()&^fs.ModeSymlink != 0 {
+			continue
+		}
+
+		parts := strings.Split(entry.Path(), "/")
+		parent := directory(ctx, root, parts[:len(parts)-1])
+		if parent == nil {
+			continue
+		}
+
+		file := &fileNode{
+			reader: reader,
+			path:   entry.Path(),
+			size:   entry.OriginalSize(),
+			mode:   mode,
+			mtime:  entry.ModTime(),
+		}
+		attr := gofs.StableAttr{Mode: fuse.S_IFREG}
+		var node gofs.InodeEmbedder = file
+		if mode&fs.ModeSymlink != 0 {
+			attr.Mode = fuse.S_IFLNK
+			node = &linkNode{fileNode: file}
+		}
+		parent.AddChild(parts[len(parts)-1], parent.NewPersistentInode(ctx, node, attr), false)
+	}
+}
+
+// directory returns the directory at path below root, creating it and its
+// parents as needed. It returns nil if a file is on the path.
+func directory(ctx context.Context, root *gofs.Inode, path []string) *gofs.Inode {
+	dir := root
+	for _, name := range path {
+		child := dir.GetChild(name)
+		if child == nil {
+			child = dir.NewPersistentInode(ctx, &dirNode{}, gofs.StableAttr{Mode: fuse.S_IFDIR})
+			dir.AddChild(name, child, false)
+		}
+		if !child.IsDir() {
+			return nil
+		}
+		dir = child
+	}
+	return dir
+}
+
+// dirNode is a synthesized directory.
+type dirNode struct {
+	gofs.Inode
+}
+
+var _ gofs.NodeGetattrer = (*dirNode)(nil)
+
+// Getattr reports a read-only directory.
+func (n *dirNode) Getattr(_ context.Context, _ gofs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	out.Mode = fuse.S_IFDIR | 0o555
+	return 0
+}
+
+// fileNode is a regular file read through the block cache.
+type fileNode struct {
+	gofs.Inode
+	reader *BlockReader
+	path   string
+	size   uint64
+	mode   fs.FileMode
+	mtime  time.Time
+}
+
+var (
+	_ gofs.NodeGetattrer = (*fileNode)(nil)
+	_ gofs.NodeOpener    = (*fileNode)(nil)
+	_ gofs.NodeReader    = (*fileNode)(nil)
+)
+
+// Getattr reports the file's size, time, and mode without write bits.
+func (n *fileNode) Getattr(_ context.Context, _ gofs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	out.Mode = fuse.S_IFREG | uint32(n.mode.Perm()&^0o222)
+	out.Size = n.size
+	out.Blocks = (n.size + 511) / 512
+	out.SetTimes(nil, &n.mtime, &n.mtime)
+	return 0
+}
+
+// Open refuses writes. Contents never change, so the kernel may keep its
+// page cache between opens.
+func (n *fileNode) Open(_ context.Context, flags uint32) (gofs.FileHandle, uint32, syscall.Errno) {
+	if flags&syscall.O_ACCMODE != syscall.O_RDONLY {
+		return nil, 0, syscall.EROFS
+	}
+	return nil, fuse.FOPEN_KEEP_CACHE, 0
+}
+
+// Read reads from the block cache.
+func (n *fileNode) Read(_ context.Context, _ gofs.FileHandle, dest []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	nr, err := n.reader.ReadAt(n.path, dest, off)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, syscall.EIO
+	}
+	return fuse.ReadResultData(dest[:nr]), 0
+}
+
+// linkNode is a symbolic link whose target is the entry's content.
+type linkNode struct {
+	*fileNode
+}
+
+var _ gofs.NodeReadlinker = (*linkNode)(nil)
+
+// Getattr reports a symbolic link.
+func (n *linkNode) Getattr(_ context.Context, _ gofs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	out.Mode = fuse.S_IFLNK | 0o777
+	out.Size = n.size
+	out.SetTimes(nil, &n.mtime, &n.mtime)
+	return 0
+}
+
+// Readlink returns the link target.
+func (n *linkNode) Readlink(context.Context) ([]byte, syscall.Errno) {
+	target := make([]byte, n.size)
+	nr, err := n.reader.ReadAt(n.path, target, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, syscall.EIO
+	}
+	return target[:nr], 0
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin)
+
+package fusefs
+
+import "github.com/meigma/blob"
+
+// Mount is not supported on this platform.
+func Mount(*blob.Archive, *blob.IndexView, string, Options) (Server, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,54 @@
+//go:build linux || darwin
+
+package fusefs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	blobcore "github.com/meigma/blob/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMount(t *testing.T) {
+	a, _ := newTestArchive(t, map[string]string{
+		"etc/app.yaml":       "app: true",
+		"etc/conf.d/db.yaml": "db: pg",
+		"README":             "hello",
+	})
+	index, err := blobcore.NewIndexView(a.IndexData())
+	require.NoError(t, err)
+
+	mountpoint := t.TempDir()
+	srv, err := Mount(a, index, mountpoint, Options{Name: "test"})
+	if err != nil {
+		t.Skipf("FUSE unavailable: %v", err)
+	}
+	t.Cleanup(func() { srv.Unmount() }) //nolint:errcheck // test cleanup
+
+	names := func(dir string) []string {
+		entries, err := os.ReadDir(filepath.Join(mountpoint, dir))
+		require.NoError(t, err)
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Name())
+		}
+		return out
+	}
+	assert.Equal(t, []string{"README", "etc"}, names("."))
+	assert.Equal(t, []string{"app.yaml", "conf.d"}, names("etc"))
+
+	data, err := os.ReadFile(filepath.Join(mountpoint, "etc", "conf.d", "db.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "db: pg", string(data))
+
+	info, err := os.Stat(filepath.Join(mountpoint, "README"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("hello")), info.Size())
+	assert.Zero(t, info.Mode().Perm()&0o222, "files are read-only")
+
+	err = os.WriteFile(filepath.Join(mountpoint, "README"), []byte("x"), 0o644)
+	require.Error(t, err)
+}
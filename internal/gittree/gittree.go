@@ -0,0 +1,191 @@
+// Package gittree reads the files of a git tree-ish through the git CLI,
+// hashing their content with SHA-256 so they can be compared with the
+// file digests recorded in a blob archive.
+package gittree
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Git modes of the entries a tree can hold.
+const (
+	ModeFile       = "100644"
+	ModeExecutable = "100755"
+	ModeSymlink    = "120000"
+	ModeSubmodule  = "160000"
+)
+
+// File is a regular file in a git tree.
+type File struct {
+	// Path is slash-separated and relative to the root of the tree-ish.
+	Path string
+	// Mode is the git mode, ModeFile or ModeExecutable.
+	Mode string
+	// Digest is the SHA-256 of the file content.
+	Digest []byte
+	// Size is the content size in bytes.
+	Size int64
+}
+
+// Executable reports whether git records the file as executable.
+func (f *File) Executable() bool {
+	return f.Mode == ModeExecutable
+}
+
+// Tree is the content of a tree-ish.
+type Tree struct {
+	// ID is the object id the tree-ish names, a commit or a tree.
+	ID string
+	// Files are the regular files, in tree order.
+	Files []File
+	// Skipped counts symlinks and submodules, which have no file content.
+	Skipped int
+}
+
+// Read lists and hashes the files of treeish in the repository containing
+// dir, or the current directory if dir is empty. A tree-ish such as
+// "HEAD:config" reads a subdirectory, with paths relative to it.
+func Read(ctx context.Context, dir, treeish string) (*Tree, error) {
+	if strings.HasPrefix(treeish, "-") {
+		return nil, fmt.Errorf("invalid tree-ish %q", treeish)
+	}
+
+	id, err := run(ctx, dir, "rev-parse", "--verify", "--end-of-options", treeish)
+	if err != nil {
+		return nil, err
+	}
+	tree := &Tree{ID: strings.TrimSpace(string(id))}
+
+	listing, err := run(ctx, dir, "ls-tree", "-r", "-z", "--full-tree", tree.ID)
+	if err != nil {
+		return nil, err
+	}
+	var objects []string
+	for record := range strings.SplitSeq(strings.TrimSuffix(string(listing), "\x00"), "\x00") {
+		if record == "" {
+			continue
+		}
+		// <mode> SP <type> SP <object> TAB <path>
+		meta, path, ok := strings.Cut(record, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected ls-tree output %q", record)
+		}
+		switch fields[0] {
+		case ModeFile, ModeExecutable:
+			tree.Files = append(tree.Files, File{Path: path, Mode: fields[0]})
+			objects = append(objects, fields[2])
+		default:
+			tree.Skipped++
+		}
+	}
+
+	if err := hashObjects(ctx, dir, objects, tree.Files); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// hashObjects reads the blobs named by objects with git cat-file --batch
+// and records each one's digest and size in the file at the same index.
+func hashObjects(ctx context.Context, dir string, objects []string, files []File) error {
+	if len(objects) == 0 {
+		return nil
+	}
+	input := strings.Join(objects, "\n") + "\n"
+
+	cmd := gitCommand(ctx, dir, "cat-file", "--batch")
+	cmd.Stdin = strings.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return gitError(err, nil)
+	}
+
+	readErr := readBatch(bufio.NewReader(stdout), files)
+	if readErr != nil {
+		// Stop git rather than wait for it to fill a pipe nobody reads
+		_ = cmd.Process.Kill() //nolint:errcheck // the read error is reported
+	}
+	waitErr := cmd.Wait()
+	if readErr != nil {
+		return readErr
+	}
+	if waitErr != nil {
+		return gitError(waitErr, stderr.Bytes())
+	}
+	return nil
+}
+
+// readBatch parses git cat-file --batch output for files, in order.
+func readBatch(r *bufio.Reader, files []File) error {
+	for i := range files {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("reading git objects: %w", err)
+		}
+		// <object> SP <type> SP <size> LF, or <object> SP missing LF
+		fields := strings.Fields(header)
+		if len(fields) != 3 || fields[1] != "blob" {
+			return fmt.Errorf("reading %s from git: unexpected object %q", files[i].Path, strings.TrimSpace(header))
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("reading %s from git: invalid size %q", files[i].Path, fields[2])
+		}
+
+		h := sha256.New()
+		if _, err := io.CopyN(h, r, size); err != nil {
+			return fmt.Errorf("reading %s from git: %w", files[i].Path, err)
+		}
+		if _, err := r.Discard(1); err != nil { // trailing LF
+			return fmt.Errorf("reading %s from git: %w", files[i].Path, err)
+		}
+		files[i].Digest = h.Sum(nil)
+		files[i].Size = size
+	}
+	return nil
+}
+
+// run runs git with args and returns its output.
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := gitCommand(ctx, dir, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, gitError(err, stderr.Bytes())
+	}
+	return out, nil
+}
+
+// gitCommand returns a git command run in dir.
+func gitCommand(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	return cmd
+}
+
+// gitError describes a failed git command, using its stderr if any.
+func gitError(err error, stderr []byte) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return errors.New("git not found in PATH")
+	}
+	if msg := strings.TrimSpace(string(stderr)); msg != "" {
+		return fmt.Errorf("git: %s", msg)
+	}
+	return fmt.Errorf("running git: %w", err)
+}
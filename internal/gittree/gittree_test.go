@@ -0,0 +1,81 @@
+package gittree
+
+import (
+	"crypto/sha256"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitRepo creates a repository in a temporary directory with files
+// committed, and returns its path.
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"update-index", "--chmod=+x", "bin/tool"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	return dir
+}
+
+func TestRead(t *testing.T) {
+	dir := gitRepo(t, map[string]string{
+		"README.md":       "readme",
+		"config/app.conf": "key=value",
+		"bin/tool":        "#!/bin/sh",
+	})
+
+	tree, err := Read(t.Context(), dir, "HEAD")
+	require.NoError(t, err)
+	assert.Len(t, tree.ID, 40)
+	assert.Zero(t, tree.Skipped)
+
+	byPath := make(map[string]File)
+	for _, f := range tree.Files {
+		byPath[f.Path] = f
+	}
+	require.Len(t, byPath, 3)
+	want := sha256.Sum256([]byte("key=value"))
+	assert.Equal(t, want[:], byPath["config/app.conf"].Digest)
+	assert.Equal(t, int64(len("key=value")), byPath["config/app.conf"].Size)
+	tool := byPath["bin/tool"]
+	assert.True(t, tool.Executable())
+	readme := byPath["README.md"]
+	assert.False(t, readme.Executable())
+
+	// A subdirectory tree-ish has paths relative to it
+	tree, err = Read(t.Context(), dir, "HEAD:config")
+	require.NoError(t, err)
+	require.Len(t, tree.Files, 1)
+	assert.Equal(t, "app.conf", tree.Files[0].Path)
+}
+
+func TestRead_Errors(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"bin/tool": "#!/bin/sh"})
+
+	_, err := Read(t.Context(), dir, "no-such-branch")
+	require.ErrorContains(t, err, "git:")
+
+	_, err = Read(t.Context(), dir, "--output=/tmp/x")
+	require.ErrorContains(t, err, "invalid tree-ish")
+}
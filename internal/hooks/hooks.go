@@ -0,0 +1,86 @@
+// Package hooks runs user-configured shell commands at points in the CLI workflow.
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// Environment variables passed to hooks.
+const (
+	// EnvPushDir is the absolute path of the directory being pushed.
+	EnvPushDir = "BLOB_PUSH_DIR"
+	// EnvPushRef is the reference being pushed to.
+	EnvPushRef = "BLOB_PUSH_REF"
+	// EnvPullDir is the absolute path of the directory being pulled into.
+	EnvPullDir = "BLOB_PULL_DIR"
+	// EnvPullRef is the reference being pulled.
+	EnvPullRef = "BLOB_PULL_REF"
+	// EnvHook is the name of the hook being run (e.g., "pre_push").
+	EnvHook = "BLOB_HOOK"
+)
+
+// HookError is returned when a hook command fails.
+type HookError struct {
+	Hook    string // Hook name (e.g., "pre_push")
+	Command string // Command that failed
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s hook %q failed: %v", e.Hook, e.Command, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// Runner runs hook commands through the platform shell.
+type Runner struct {
+	// Output receives the combined stdout/stderr of hook commands.
+	// Defaults to os.Stderr so that command output does not corrupt JSON on stdout.
+	Output io.Writer
+}
+
+// Run executes each command in order with env added to the process environment.
+// Execution stops at the first command that fails or exits non-zero.
+func (r *Runner) Run(ctx context.Context, hook string, commands []string, env map[string]string) error {
+	out := r.Output
+	if out == nil {
+		out = os.Stderr
+	}
+
+	environ := os.Environ()
+	environ = append(environ, EnvHook+"="+hook)
+	for k, v := range env {
+		environ = append(environ, k+"="+v)
+	}
+
+	for _, command := range commands {
+		c := shellCommand(ctx, command)
+		c.Env = environ
+		c.Stdout = out
+		c.Stderr = out
+		c.Stdin = nil
+		if err := c.Run(); err != nil {
+			return &HookError{Hook: hook, Command: command, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// shellCommand builds a command that runs through the platform shell.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	//nolint:gosec // G204: hook commands come from the user's own config file
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,46 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests use POSIX shell syntax")
+	}
+
+	var out bytes.Buffer
+	r := &Runner{Output: &out}
+
+	err := r.Run(context.Background(), "pre_push",
+		[]string{`echo "$BLOB_HOOK $BLOB_PUSH_REF $BLOB_PUSH_DIR"`},
+		map[string]string{EnvPushRef: "ghcr.io/acme/cfg:v1", EnvPushDir: "/src"},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "pre_push ghcr.io/acme/cfg:v1 /src\n", out.String())
+}
+
+func TestRunner_StopsOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests use POSIX shell syntax")
+	}
+
+	var out bytes.Buffer
+	r := &Runner{Output: &out}
+
+	err := r.Run(context.Background(), "pre_push", []string{"echo first", "exit 3", "echo never"}, nil)
+
+	var hookErr *HookError
+	require.ErrorAs(t, err, &hookErr)
+	assert.Equal(t, "pre_push", hookErr.Hook)
+	assert.Equal(t, "exit 3", hookErr.Command)
+	assert.Contains(t, err.Error(), "exit status 3")
+	assert.Equal(t, "first\n", out.String())
+}
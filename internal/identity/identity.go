@@ -0,0 +1,246 @@
+// Package identity obtains OIDC identity tokens for Sigstore keyless signing.
+//
+// Tokens are taken, in order of precedence, from an explicit token file,
+// the SIGSTORE_ID_TOKEN environment variable, the ambient credentials of a
+// supported CI provider (GitHub Actions, GitLab CI, Buildkite), or, outside
+// CI, an interactive login with the Sigstore OIDC issuer.
+package identity
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Audience is the token audience expected by Fulcio.
+const Audience = "sigstore"
+
+// TokenEnv is the environment variable holding a pre-issued identity token.
+// GitLab CI jobs expose ID tokens under the name chosen in `id_tokens`.
+const TokenEnv = "SIGSTORE_ID_TOKEN"
+
+// Identity providers.
+const (
+	ProviderAuto          = "auto"           // Detect from the environment
+	ProviderGitHubActions = "github-actions" // GitHub Actions OIDC endpoint
+	ProviderGitLab        = "gitlab"         // GitLab CI id_tokens
+	ProviderBuildkite     = "buildkite"      // buildkite-agent oidc request-token
+	ProviderBrowser       = "browser"        // Interactive login in a web browser
+	ProviderDevice        = "device"         // Device code login, for machines without a browser
+)
+
+// Providers lists the accepted provider names.
+var Providers = []string{
+	ProviderAuto, ProviderGitHubActions, ProviderGitLab, ProviderBuildkite, ProviderBrowser, ProviderDevice,
+}
+
+// ErrNoIdentity is returned when no identity token source is available.
+var ErrNoIdentity = errors.New("no OIDC identity available for keyless signing")
+
+// Options selects where the identity token comes from.
+type Options struct {
+	Provider  string // One of Providers; empty means ProviderAuto
+	TokenFile string // Read the token from this file instead of a provider
+
+	// Interactive login settings.
+	Interactive  bool      // With ProviderAuto, log in in a browser when not in CI
+	OIDCProvider string    // Account to log in with, such as "github"; empty lets the user choose
+	Issuer       string    // OIDC issuer; empty means DefaultIssuer
+	ClientID     string    // OIDC client ID; empty means DefaultClientID
+	Prompt       io.Writer // Where login instructions are written; nil means stderr
+}
+
+// runCommand runs an external command and returns its standard output.
+// It is a variable so tests can replace it.
+var runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// ValidateProvider reports whether name is a supported provider.
+func ValidateProvider(name string) error {
+	if name == "" || slices.Contains(Providers, name) {
+		return nil
+	}
+	return fmt.Errorf("unknown identity provider %q (expected one of: %s)", name, strings.Join(Providers, ", "))
+}
+
+// Token obtains an identity token and checks that it has not expired.
+func Token(ctx context.Context, opts Options) (string, error) {
+	token, err := fetch(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := checkToken(token, time.Now()); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func fetch(ctx context.Context, opts Options) (string, error) {
+	if opts.TokenFile != "" {
+		data, err := os.ReadFile(opts.TokenFile) //nolint:gosec // token path is user-provided
+		if err != nil {
+			return "", fmt.Errorf("reading identity token file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	provider := opts.Provider
+	if provider == "" || provider == ProviderAuto {
+		if token := os.Getenv(TokenEnv); token != "" {
+			return token, nil
+		}
+		provider = Detect()
+		if provider == "" && opts.Interactive {
+			provider = ProviderBrowser
+		}
+	}
+
+	switch provider {
+	case ProviderGitHubActions:
+		return gitHubActionsToken(ctx)
+	case ProviderGitLab:
+		return gitLabToken()
+	case ProviderBuildkite:
+		return buildkiteToken(ctx)
+	case ProviderBrowser, ProviderDevice:
+		return loginToken(provider, opts)
+	case "":
+		return "", fmt.Errorf("%w: not running in GitHub Actions, GitLab CI, or Buildkite; "+
+			"set %s, use --identity-token-file, or log in with --identity-provider browser or device",
+			ErrNoIdentity, TokenEnv)
+	default:
+		return "", ValidateProvider(provider)
+	}
+}
+
+// Detect returns the CI provider of the current environment, or "" if none
+// is recognized.
+func Detect() string {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return ProviderGitHubActions
+	case os.Getenv("GITLAB_CI") == "true":
+		return ProviderGitLab
+	case os.Getenv("BUILDKITE") == "true":
+		return ProviderBuildkite
+	default:
+		return ""
+	}
+}
+
+// gitHubActionsToken requests a token from the GitHub Actions OIDC endpoint.
+func gitHubActionsToken(ctx context.Context) (string, error) {
+	requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if requestURL == "" || requestToken == "" {
+		return "", fmt.Errorf("%w: GitHub Actions OIDC is not enabled for this job "+
+			"(grant the workflow `permissions: id-token: write`)", ErrNoIdentity)
+	}
+
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing ACTIONS_ID_TOKEN_REQUEST_URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("audience", Audience)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("creating GitHub Actions token request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req) //nolint:gosec // URL is provided by the GitHub Actions runner
+	if err != nil {
+		return "", fmt.Errorf("requesting GitHub Actions token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("GitHub Actions token request failed with status %d: %s",
+			resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tokenResp struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("decoding GitHub Actions token response: %w", err)
+	}
+	if tokenResp.Value == "" {
+		return "", errors.New("GitHub Actions returned an empty token")
+	}
+	return tokenResp.Value, nil
+}
+
+// gitLabToken reads the ID token GitLab CI exposes through the job's id_tokens.
+func gitLabToken() (string, error) {
+	if token := os.Getenv(TokenEnv); token != "" {
+		return token, nil
+	}
+	return "", fmt.Errorf("%w: %s is not set; add it to the job's id_tokens with `aud: %s`",
+		ErrNoIdentity, TokenEnv, Audience)
+}
+
+// buildkiteToken requests a token from the Buildkite agent.
+func buildkiteToken(ctx context.Context) (string, error) {
+	out, err := runCommand(ctx, "buildkite-agent", "oidc", "request-token", "--audience", Audience)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("buildkite-agent oidc request-token: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("%w: running buildkite-agent: %w", ErrNoIdentity, err)
+	}
+
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", errors.New("buildkite-agent returned an empty token")
+	}
+	return token, nil
+}
+
+// checkToken verifies that token looks like a JWT and has not expired.
+// The signature is not verified; Fulcio does that.
+func checkToken(token string, now time.Time) error {
+	if token == "" {
+		return fmt.Errorf("%w: identity token is empty", ErrNoIdentity)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("identity token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return fmt.Errorf("decoding identity token: %w", err)
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return fmt.Errorf("decoding identity token claims: %w", err)
+	}
+	if claims.Exp != 0 {
+		if exp := time.Unix(claims.Exp, 0); !now.Before(exp) {
+			return fmt.Errorf("identity token expired at %s", exp.UTC().Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
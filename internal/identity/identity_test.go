@@ -0,0 +1,214 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/oauthflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testToken builds an unsigned JWT with the given expiry.
+func testToken(exp time.Time) string {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := enc.EncodeToString([]byte(fmt.Sprintf(`{"sub":"test","exp":%d}`, exp.Unix())))
+	return header + "." + payload + ".sig"
+}
+
+// clearEnv unsets every variable used for provider detection.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		TokenEnv, "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE",
+		"ACTIONS_ID_TOKEN_REQUEST_URL", "ACTIONS_ID_TOKEN_REQUEST_TOKEN",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestToken(t *testing.T) {
+	valid := testToken(time.Now().Add(time.Hour))
+
+	t.Run("token file", func(t *testing.T) {
+		clearEnv(t)
+		path := filepath.Join(t.TempDir(), "token")
+		require.NoError(t, os.WriteFile(path, []byte(valid+"\n"), 0o600))
+
+		token, err := Token(context.Background(), Options{TokenFile: path})
+		require.NoError(t, err)
+		assert.Equal(t, valid, token)
+	})
+
+	t.Run("environment variable", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv(TokenEnv, valid)
+
+		token, err := Token(context.Background(), Options{})
+		require.NoError(t, err)
+		assert.Equal(t, valid, token)
+	})
+
+	t.Run("no ambient identity", func(t *testing.T) {
+		clearEnv(t)
+
+		_, err := Token(context.Background(), Options{Provider: ProviderAuto})
+		require.ErrorIs(t, err, ErrNoIdentity)
+		assert.Contains(t, err.Error(), "--identity-token-file")
+	})
+
+	t.Run("gitlab without id token", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("GITLAB_CI", "true")
+
+		_, err := Token(context.Background(), Options{})
+		require.ErrorIs(t, err, ErrNoIdentity)
+		assert.Contains(t, err.Error(), "id_tokens")
+	})
+
+	t.Run("github actions without id-token permission", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("GITHUB_ACTIONS", "true")
+
+		_, err := Token(context.Background(), Options{})
+		require.ErrorIs(t, err, ErrNoIdentity)
+		assert.Contains(t, err.Error(), "id-token: write")
+	})
+
+	t.Run("github actions", func(t *testing.T) {
+		clearEnv(t)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer request-token", r.Header.Get("Authorization"))
+			assert.Equal(t, Audience, r.URL.Query().Get("audience"))
+			fmt.Fprintf(w, `{"value":%q}`, valid)
+		}))
+		defer srv.Close()
+		t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", srv.URL+"/token?api-version=2.0")
+		t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+
+		token, err := Token(context.Background(), Options{Provider: ProviderGitHubActions})
+		require.NoError(t, err)
+		assert.Equal(t, valid, token)
+	})
+
+	t.Run("buildkite", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("BUILDKITE", "true")
+		orig := runCommand
+		t.Cleanup(func() { runCommand = orig })
+		var gotArgs []string
+		runCommand = func(_ context.Context, name string, args ...string) ([]byte, error) {
+			gotArgs = append([]string{name}, args...)
+			return []byte(valid + "\n"), nil
+		}
+
+		token, err := Token(context.Background(), Options{})
+		require.NoError(t, err)
+		assert.Equal(t, valid, token)
+		assert.Equal(t, []string{"buildkite-agent", "oidc", "request-token", "--audience", Audience}, gotArgs)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		clearEnv(t)
+		_, err := Token(context.Background(), Options{Provider: "circleci"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown identity provider")
+	})
+}
+
+func TestCheckToken(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{name: "valid", token: testToken(now.Add(time.Minute))},
+		{name: "expired", token: testToken(now.Add(-time.Minute)), wantErr: "expired"},
+		{name: "empty", token: "", wantErr: "empty"},
+		{name: "not a jwt", token: "opaque-token", wantErr: "not a JWT"},
+		{name: "bad payload", token: "a.!!!.c", wantErr: "decoding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkToken(tt.token, now)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoginToken(t *testing.T) {
+	valid := testToken(time.Now().Add(time.Hour))
+	var gotIssuer, gotClientID string
+	var gotGetter oauthflow.TokenGetter
+	orig := connect
+	t.Cleanup(func() { connect = orig })
+	connect = func(issuer, id, _, _ string, tg oauthflow.TokenGetter) (*oauthflow.OIDCIDToken, error) {
+		gotIssuer, gotClientID, gotGetter = issuer, id, tg
+		return &oauthflow.OIDCIDToken{RawString: valid}, nil
+	}
+
+	t.Run("auto falls back to browser login", func(t *testing.T) {
+		clearEnv(t)
+		token, err := Token(context.Background(), Options{Interactive: true, OIDCProvider: "github"})
+		require.NoError(t, err)
+		assert.Equal(t, valid, token)
+		assert.Equal(t, DefaultIssuer, gotIssuer)
+		assert.Equal(t, DefaultClientID, gotClientID)
+		browser, ok := gotGetter.(*oauthflow.InteractiveIDTokenGetter)
+		require.True(t, ok)
+		assert.Len(t, browser.ExtraAuthURLParams, 1)
+	})
+
+	t.Run("device login with custom issuer", func(t *testing.T) {
+		clearEnv(t)
+		var prompt bytes.Buffer
+		_, err := Token(context.Background(), Options{
+			Provider: ProviderDevice,
+			Issuer:   "https://oauth2.sigstore.internal/auth",
+			ClientID: "blob",
+			Prompt:   &prompt,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "https://oauth2.sigstore.internal/auth", gotIssuer)
+		assert.Equal(t, "blob", gotClientID)
+		device, ok := gotGetter.(*oauthflow.DeviceFlowTokenGetter)
+		require.True(t, ok)
+		device.MessagePrinter("Enter the verification code")
+		assert.Equal(t, "Enter the verification code\n", prompt.String())
+	})
+
+	t.Run("device login cannot pick an account provider", func(t *testing.T) {
+		clearEnv(t)
+		_, err := Token(context.Background(), Options{Provider: ProviderDevice, OIDCProvider: "google"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--oidc-provider")
+	})
+
+	t.Run("login failure", func(t *testing.T) {
+		clearEnv(t)
+		connect = func(string, string, string, string, oauthflow.TokenGetter) (*oauthflow.OIDCIDToken, error) {
+			return nil, errors.New("access_denied")
+		}
+		_, err := Token(context.Background(), Options{Provider: ProviderBrowser})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "logging in with "+DefaultIssuer)
+	})
+}
@@ -0,0 +1,80 @@
+package identity
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sigstore/sigstore/pkg/oauthflow"
+	"golang.org/x/oauth2"
+)
+
+// Public Sigstore OIDC issuer, used for interactive login unless another
+// is configured.
+const (
+	DefaultIssuer   = "https://oauth2.sigstore.dev/auth"
+	DefaultClientID = "sigstore"
+)
+
+// OIDCProviders maps the account providers offered by the public Sigstore
+// issuer to the connector IDs it knows them by. Other values of
+// Options.OIDCProvider are passed to the issuer as connector IDs unchanged.
+var OIDCProviders = map[string]string{
+	"github":    oauthflow.PublicInstanceGithubAuthSubURL,
+	"google":    oauthflow.PublicInstanceGoogleAuthSubURL,
+	"microsoft": oauthflow.PublicInstanceMicrosoftAuthSubURL,
+}
+
+// connect runs an OIDC login with an issuer. It is a variable so tests can
+// replace it.
+var connect = oauthflow.OIDConnect
+
+// loginToken obtains a token by having the user log in with the issuer,
+// in a browser opened for them or, with ProviderDevice, by entering a code
+// at a printed URL on any device.
+func loginToken(provider string, opts Options) (string, error) {
+	issuer := opts.Issuer
+	if issuer == "" {
+		issuer = DefaultIssuer
+	}
+	clientID := opts.ClientID
+	if clientID == "" {
+		clientID = DefaultClientID
+	}
+	out := opts.Prompt
+	if out == nil {
+		out = os.Stderr
+	}
+
+	var getter oauthflow.TokenGetter
+	switch provider {
+	case ProviderDevice:
+		// The device code request has no way to name a connector.
+		if opts.OIDCProvider != "" {
+			return "", errors.New("--oidc-provider needs the browser login (--identity-provider browser)")
+		}
+		device := oauthflow.NewDeviceFlowTokenGetterForIssuer(issuer)
+		device.MessagePrinter = func(msg string) { fmt.Fprintln(out, msg) }
+		getter = device
+	default:
+		browser := &oauthflow.InteractiveIDTokenGetter{
+			HTMLPage: oauthflow.DefaultIDTokenGetter.HTMLPage,
+			Input:    os.Stdin,
+			Output:   out,
+		}
+		if opts.OIDCProvider != "" {
+			connector, ok := OIDCProviders[opts.OIDCProvider]
+			if !ok {
+				connector = opts.OIDCProvider
+			}
+			browser.ExtraAuthURLParams = []oauth2.AuthCodeOption{oauthflow.ConnectorIDOpt(connector)}
+		}
+		getter = browser
+	}
+
+	token, err := connect(issuer, clientID, "", "", getter)
+	if err != nil {
+		return "", fmt.Errorf("logging in with %s: %w", issuer, err)
+	}
+	return token.RawString, nil
+}
@@ -0,0 +1,60 @@
+//go:build cgo
+
+package pkcs11key
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+
+	"github.com/ThalesIgnite/crypto11"
+)
+
+// Supported reports whether this build can sign with keys on tokens.
+const Supported = true
+
+// Key is a private key on a token. Close it to log out of the token.
+type Key struct {
+	ctx    *crypto11.Context
+	signer crypto.Signer
+}
+
+// Open loads the module of u, logs in to its token with pin, and finds
+// the key.
+func Open(u *URI, pin string) (*Key, error) {
+	cfg := &crypto11.Config{Path: u.ModulePath, Pin: pin}
+	if u.Slot != nil {
+		cfg.SlotNumber = u.Slot
+	} else {
+		cfg.TokenLabel = u.Token
+	}
+	ctx, err := crypto11.Configure(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("opening PKCS#11 token: %w", err)
+	}
+
+	var label []byte
+	if u.Object != "" {
+		label = []byte(u.Object)
+	}
+	signer, err := ctx.FindKeyPair(u.ID, label)
+	if err == nil && signer == nil {
+		err = errors.New("no such key on the token")
+	}
+	if err != nil {
+		ctx.Close()
+		return nil, fmt.Errorf("finding PKCS#11 key: %w", err)
+	}
+	return &Key{ctx: ctx, signer: signer}, nil
+}
+
+// Signer returns the key as a crypto.Signer. Its Sign method takes a
+// digest, as crypto.Signer does.
+func (k *Key) Signer() crypto.Signer {
+	return k.signer
+}
+
+// Close logs out of the token and unloads the module.
+func (k *Key) Close() error {
+	return k.ctx.Close()
+}
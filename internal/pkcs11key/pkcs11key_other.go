@@ -0,0 +1,26 @@
+//go:build !cgo
+
+package pkcs11key
+
+import "crypto"
+
+// Supported reports whether this build can sign with keys on tokens.
+const Supported = false
+
+// Key is a private key on a token. Close it to log out of the token.
+type Key struct{}
+
+// Open returns ErrUnsupported, as loading a PKCS#11 module needs cgo.
+func Open(*URI, string) (*Key, error) {
+	return nil, ErrUnsupported
+}
+
+// Signer returns nil.
+func (*Key) Signer() crypto.Signer {
+	return nil
+}
+
+// Close does nothing.
+func (*Key) Close() error {
+	return nil
+}
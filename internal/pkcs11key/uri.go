@@ -0,0 +1,135 @@
+// Package pkcs11key signs with private keys held in hardware tokens, such
+// as HSMs, smart cards, and YubiKeys, through their PKCS#11 module, so the
+// keys never touch disk.
+//
+// Keys are named by PKCS#11 URIs (RFC 7512):
+//
+//	pkcs11:token=YubiKey%20PIV;id=%02?module-path=/usr/lib/libykcs11.so
+//
+// Signing needs cgo to load the module; builds without cgo report
+// ErrUnsupported from Open, and Supported tells them apart beforehand.
+package pkcs11key
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Scheme prefixes PKCS#11 URIs.
+const Scheme = "pkcs11:"
+
+// ModuleEnv names the environment variable with the module path used when
+// a URI has no module-path.
+const ModuleEnv = "BLOB_PKCS11_MODULE"
+
+// ErrUnsupported is returned by Open in builds without cgo.
+var ErrUnsupported = errors.New("PKCS#11 signing is not supported by this build of blob (it needs cgo)")
+
+// URI identifies a key on a token.
+type URI struct {
+	// ModulePath is the PKCS#11 module to load.
+	ModulePath string
+	// Token is the label of the token, and Slot its slot number; one of
+	// them picks the token.
+	Token string
+	Slot  *int
+	// Object is the label of the key, and ID its identifier; one of them
+	// picks the key.
+	Object string
+	ID     []byte
+	// PIN is the user PIN from pin-value or pin-source, if given.
+	PIN string
+}
+
+// IsURI reports whether s is a PKCS#11 URI.
+func IsURI(s string) bool {
+	return strings.HasPrefix(s, Scheme)
+}
+
+// ParseURI parses a PKCS#11 URI. The path attributes token, slot-id,
+// object, and id, and the query attributes module-path, pin-value, and
+// pin-source are understood; others are ignored.
+func ParseURI(s string) (*URI, error) {
+	if !IsURI(s) {
+		return nil, fmt.Errorf("invalid PKCS#11 URI %q: must start with %s", s, Scheme)
+	}
+	path, query, _ := strings.Cut(strings.TrimPrefix(s, Scheme), "?")
+	u := &URI{ModulePath: os.Getenv(ModuleEnv)}
+
+	for _, attr := range splitAttrs(path, ";") {
+		name, value, err := attrValue(attr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PKCS#11 URI %q: %w", s, err)
+		}
+		switch name {
+		case "token":
+			u.Token = value
+		case "object":
+			u.Object = value
+		case "id":
+			u.ID = []byte(value)
+		case "slot-id":
+			slot, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PKCS#11 URI %q: slot-id %q is not a number", s, value)
+			}
+			u.Slot = &slot
+		}
+	}
+	for _, attr := range splitAttrs(query, "&") {
+		name, value, err := attrValue(attr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PKCS#11 URI %q: %w", s, err)
+		}
+		switch name {
+		case "module-path":
+			u.ModulePath = value
+		case "pin-value":
+			u.PIN = value
+		case "pin-source":
+			pin, err := os.ReadFile(strings.TrimPrefix(value, "file:")) //nolint:gosec // path comes from the user's key URI
+			if err != nil {
+				return nil, fmt.Errorf("reading pin-source: %w", err)
+			}
+			u.PIN = strings.TrimRight(string(pin), "\r\n")
+		}
+	}
+
+	switch {
+	case u.ModulePath == "":
+		return nil, fmt.Errorf("invalid PKCS#11 URI %q: no module-path (or %s) given", s, ModuleEnv)
+	case u.Token == "" && u.Slot == nil:
+		return nil, fmt.Errorf("invalid PKCS#11 URI %q: token or slot-id is required", s)
+	case u.Object == "" && u.ID == nil:
+		return nil, fmt.Errorf("invalid PKCS#11 URI %q: object or id is required", s)
+	}
+	return u, nil
+}
+
+// splitAttrs splits a URI component into its non-empty attributes.
+func splitAttrs(s, sep string) []string {
+	var attrs []string
+	for attr := range strings.SplitSeq(s, sep) {
+		if attr != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// attrValue splits a name=value attribute and unescapes the value.
+func attrValue(attr string) (name, value string, err error) {
+	name, raw, ok := strings.Cut(attr, "=")
+	if !ok {
+		return "", "", fmt.Errorf("attribute %q has no value", attr)
+	}
+	value, err = url.PathUnescape(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("attribute %s: %w", name, err)
+	}
+	return name, value, nil
+}
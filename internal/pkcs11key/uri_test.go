@@ -0,0 +1,79 @@
+package pkcs11key
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsURI(t *testing.T) {
+	assert.True(t, IsURI("pkcs11:token=a;id=%01"))
+	assert.False(t, IsURI("cosign.key"))
+	assert.False(t, IsURI("awskms:///alias/blob"))
+}
+
+func TestParseURI(t *testing.T) {
+	t.Setenv(ModuleEnv, "")
+
+	u, err := ParseURI("pkcs11:token=YubiKey%20PIV%20%2312345678;id=%02?module-path=/usr/lib/libykcs11.so&pin-value=123456")
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/lib/libykcs11.so", u.ModulePath)
+	assert.Equal(t, "YubiKey PIV #12345678", u.Token)
+	assert.Equal(t, []byte{0x02}, u.ID)
+	assert.Empty(t, u.Object)
+	assert.Nil(t, u.Slot)
+	assert.Equal(t, "123456", u.PIN)
+
+	u, err = ParseURI("pkcs11:slot-id=3;object=signing;type=private?module-path=/lib/softhsm2.so")
+	require.NoError(t, err)
+	require.NotNil(t, u.Slot)
+	assert.Equal(t, 3, *u.Slot)
+	assert.Equal(t, "signing", u.Object)
+	assert.Nil(t, u.ID)
+}
+
+func TestParseURIModuleEnv(t *testing.T) {
+	t.Setenv(ModuleEnv, "/env/module.so")
+
+	u, err := ParseURI("pkcs11:token=t;object=k")
+	require.NoError(t, err)
+	assert.Equal(t, "/env/module.so", u.ModulePath)
+
+	u, err = ParseURI("pkcs11:token=t;object=k?module-path=/uri/module.so")
+	require.NoError(t, err)
+	assert.Equal(t, "/uri/module.so", u.ModulePath)
+}
+
+func TestParseURIPinSource(t *testing.T) {
+	t.Setenv(ModuleEnv, "/lib/module.so")
+	path := filepath.Join(t.TempDir(), "pin")
+	require.NoError(t, os.WriteFile(path, []byte("4321\n"), 0o600))
+
+	u, err := ParseURI("pkcs11:token=t;object=k?pin-source=file:" + path)
+	require.NoError(t, err)
+	assert.Equal(t, "4321", u.PIN)
+}
+
+func TestParseURIErrors(t *testing.T) {
+	t.Setenv(ModuleEnv, "")
+
+	for _, tc := range []struct {
+		uri  string
+		want string
+	}{
+		{"token=t;object=k", "must start with pkcs11:"},
+		{"pkcs11:token=t;object=k", "no module-path"},
+		{"pkcs11:object=k?module-path=/m.so", "token or slot-id is required"},
+		{"pkcs11:token=t?module-path=/m.so", "object or id is required"},
+		{"pkcs11:slot-id=x;object=k?module-path=/m.so", "slot-id \"x\" is not a number"},
+		{"pkcs11:token;object=k?module-path=/m.so", "has no value"},
+		{"pkcs11:token=%zz;object=k?module-path=/m.so", "attribute token"},
+	} {
+		_, err := ParseURI(tc.uri)
+		require.Error(t, err, tc.uri)
+		assert.Contains(t, err.Error(), tc.want, tc.uri)
+	}
+}
@@ -0,0 +1,188 @@
+// Package platform implements the annotation convention for marking which
+// platforms an archive serves.
+//
+// An archive records its platforms in the manifest annotation
+// io.meigma.blob.platforms as a comma-separated list. Each entry is either
+// a platform (os/arch or os/arch/variant), meaning the whole archive is for
+// that platform, or platform=dir, meaning the files for that platform are
+// under dir:
+//
+//	io.meigma.blob.platforms: linux/amd64
+//	io.meigma.blob.platforms: linux/amd64=bin/linux-amd64,darwin/arm64=bin/darwin-arm64
+//
+// This is deliberately lighter than an OCI image index: all variants share
+// one archive, and commands that read files select a variant by treating
+// its directory as the archive root.
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Annotation is the manifest annotation holding an archive's platforms.
+const Annotation = "io.meigma.blob.platforms"
+
+var (
+	// ErrNotAnnotated is returned by Select when the archive declares no platforms.
+	ErrNotAnnotated = errors.New("archive does not declare any platforms")
+
+	// ErrNoVariant is returned by Select when no variant matches the platform.
+	ErrNoVariant = errors.New("no variant for platform")
+)
+
+// Variant is one platform an archive serves.
+type Variant struct {
+	// Platform is the normalized os/arch[/variant].
+	Platform string `json:"platform"`
+
+	// Dir is the archive directory holding the platform's files, without
+	// leading or trailing slashes. Empty means the whole archive.
+	Dir string `json:"dir,omitempty"`
+}
+
+// Normalize validates a platform string and returns it in canonical form:
+// lowercase os/arch or os/arch/variant.
+func Normalize(s string) (string, error) {
+	p := strings.ToLower(strings.TrimSpace(s))
+	parts := strings.Split(p, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", fmt.Errorf("invalid platform %q: expected os/arch[/variant]", s)
+	}
+	for _, part := range parts {
+		if part == "" || strings.ContainsAny(part, " ,=") {
+			return "", fmt.Errorf("invalid platform %q: expected os/arch[/variant]", s)
+		}
+	}
+	return p, nil
+}
+
+// ParseVariant parses a single "platform" or "platform=dir" entry.
+func ParseVariant(s string) (Variant, error) {
+	p, dir, hasDir := strings.Cut(s, "=")
+	platform, err := Normalize(p)
+	if err != nil {
+		return Variant{}, err
+	}
+	v := Variant{Platform: platform}
+	if hasDir {
+		v.Dir = strings.Trim(path.Clean("/"+strings.TrimSpace(dir)), "/")
+		if v.Dir == "" || strings.Contains(dir, ",") {
+			return Variant{}, fmt.Errorf("invalid platform directory in %q", s)
+		}
+	}
+	return v, nil
+}
+
+// Parse parses an annotation value. Variants are returned sorted by platform.
+func Parse(value string) ([]Variant, error) {
+	var variants []Variant
+	for entry := range strings.SplitSeq(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		v, err := ParseVariant(entry)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	if err := Validate(variants); err != nil {
+		return nil, err
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].Platform < variants[j].Platform })
+	return variants, nil
+}
+
+// Validate checks that platforms are unique and that whole-archive entries
+// are not mixed with directory variants.
+func Validate(variants []Variant) error {
+	seen := make(map[string]bool, len(variants))
+	var whole, dirs int
+	for _, v := range variants {
+		if seen[v.Platform] {
+			return fmt.Errorf("platform %s listed more than once", v.Platform)
+		}
+		seen[v.Platform] = true
+		if v.Dir == "" {
+			whole++
+		} else {
+			dirs++
+		}
+	}
+	if whole > 0 && dirs > 0 {
+		return errors.New("platforms must either all name a directory or none")
+	}
+	return nil
+}
+
+// Format returns the annotation value for variants.
+func Format(variants []Variant) string {
+	entries := make([]string, len(variants))
+	for i, v := range variants {
+		entries[i] = v.Platform
+		if v.Dir != "" {
+			entries[i] += "=" + v.Dir
+		}
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// FromAnnotations returns the variants declared in manifest annotations,
+// or nil if there are none.
+func FromAnnotations(annotations map[string]string) ([]Variant, error) {
+	value, ok := annotations[Annotation]
+	if !ok {
+		return nil, nil
+	}
+	variants, err := Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("annotation %s: %w", Annotation, err)
+	}
+	return variants, nil
+}
+
+// Select returns the variant for platform. A variant without a CPU variant
+// component (linux/arm64) matches a request that has one (linux/arm64/v8),
+// and the reverse.
+func Select(variants []Variant, platform string) (Variant, error) {
+	want, err := Normalize(platform)
+	if err != nil {
+		return Variant{}, err
+	}
+	if len(variants) == 0 {
+		return Variant{}, ErrNotAnnotated
+	}
+	for _, v := range variants {
+		if v.Platform == want {
+			return v, nil
+		}
+	}
+	for _, v := range variants {
+		if osArch(v.Platform) == osArch(want) && (v.Platform == osArch(v.Platform) || want == osArch(want)) {
+			return v, nil
+		}
+	}
+	available := make([]string, len(variants))
+	for i, v := range variants {
+		available[i] = v.Platform
+	}
+	return Variant{}, fmt.Errorf("%w %s (available: %s)", ErrNoVariant, want, strings.Join(available, ", "))
+}
+
+// Path returns the archive path for name within the variant's directory.
+func (v Variant) Path(name string) string {
+	if v.Dir == "" {
+		return name
+	}
+	return "/" + path.Join(v.Dir, strings.TrimPrefix(name, "/"))
+}
+
+func osArch(platform string) string {
+	parts := strings.SplitN(platform, "/", 3)
+	return parts[0] + "/" + parts[1]
+}
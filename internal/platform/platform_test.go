@@ -0,0 +1,141 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "linux/amd64", want: "linux/amd64"},
+		{in: " Linux/ARM64/v8 ", want: "linux/arm64/v8"},
+		{in: "linux", wantErr: true},
+		{in: "linux/", wantErr: true},
+		{in: "linux/arm/v7/extra", wantErr: true},
+		{in: "linux/amd 64", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Normalize(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []Variant
+		wantErr bool
+	}{
+		{
+			name:  "whole archive",
+			value: "linux/amd64",
+			want:  []Variant{{Platform: "linux/amd64"}},
+		},
+		{
+			name:  "directory variants sorted",
+			value: "linux/arm64=/bin/linux-arm64/, darwin/arm64=bin/darwin-arm64",
+			want: []Variant{
+				{Platform: "darwin/arm64", Dir: "bin/darwin-arm64"},
+				{Platform: "linux/arm64", Dir: "bin/linux-arm64"},
+			},
+		},
+		{
+			name:    "duplicate platform",
+			value:   "linux/amd64=a,linux/amd64=b",
+			wantErr: true,
+		},
+		{
+			name:    "mixed whole and directory",
+			value:   "linux/amd64,darwin/arm64=darwin",
+			wantErr: true,
+		},
+		{
+			name:    "empty directory",
+			value:   "linux/amd64=/",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	variants := []Variant{
+		{Platform: "linux/arm64", Dir: "linux-arm64"},
+		{Platform: "darwin/arm64", Dir: "darwin-arm64"},
+	}
+	value := Format(variants)
+	assert.Equal(t, "darwin/arm64=darwin-arm64,linux/arm64=linux-arm64", value)
+
+	parsed, err := Parse(value)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, variants, parsed)
+}
+
+func TestFromAnnotations(t *testing.T) {
+	variants, err := FromAnnotations(map[string]string{"other": "x"})
+	require.NoError(t, err)
+	assert.Nil(t, variants)
+
+	_, err = FromAnnotations(map[string]string{Annotation: "bogus"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), Annotation)
+}
+
+func TestSelect(t *testing.T) {
+	variants := []Variant{
+		{Platform: "linux/amd64", Dir: "amd64"},
+		{Platform: "linux/arm/v7", Dir: "armv7"},
+		{Platform: "linux/arm64", Dir: "arm64"},
+	}
+
+	v, err := Select(variants, "Linux/AMD64")
+	require.NoError(t, err)
+	assert.Equal(t, "amd64", v.Dir)
+
+	v, err = Select(variants, "linux/arm64/v8")
+	require.NoError(t, err)
+	assert.Equal(t, "arm64", v.Dir)
+
+	v, err = Select(variants, "linux/arm")
+	require.NoError(t, err)
+	assert.Equal(t, "armv7", v.Dir)
+
+	_, err = Select(variants, "linux/arm/v6")
+	require.ErrorIs(t, err, ErrNoVariant)
+	assert.Contains(t, err.Error(), "available: linux/amd64, linux/arm/v7, linux/arm64")
+
+	_, err = Select(nil, "linux/amd64")
+	require.ErrorIs(t, err, ErrNotAnnotated)
+}
+
+func TestVariantPath(t *testing.T) {
+	assert.Equal(t, "/etc/app.conf", Variant{Platform: "linux/amd64"}.Path("/etc/app.conf"))
+	assert.Equal(t, "/amd64/etc/app.conf", Variant{Platform: "linux/amd64", Dir: "amd64"}.Path("/etc/app.conf"))
+	assert.Equal(t, "/amd64", Variant{Platform: "linux/amd64", Dir: "amd64"}.Path("/"))
+}
@@ -0,0 +1,180 @@
+package policy
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/meigma/blob/policy/slsa"
+	"github.com/meigma/blob/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ErrNotLocal is returned when a policy asks a local attestation for
+// material it does not hold, such as the manifest of the archive.
+var ErrNotLocal = errors.New("not available from a local attestation")
+
+// LocalAttestation serves an attestation file to policies as the only
+// referrer of the archive it is about, so policies can be tested against
+// it without pushing anything.
+type LocalAttestation struct {
+	data    []byte
+	desc    ocispec.Descriptor
+	subject ocispec.Descriptor
+}
+
+// localBundle holds the parts of a Sigstore bundle, DSSE envelope, or
+// in-toto statement needed to tell which it is and what it is about.
+type localBundle struct {
+	MediaType        string         `json:"mediaType"`
+	DSSEEnvelope     *localEnvelope `json:"dsseEnvelope"`
+	MessageSignature *struct {
+		MessageDigest struct {
+			Algorithm string `json:"algorithm"`
+			Digest    string `json:"digest"`
+		} `json:"messageDigest"`
+	} `json:"messageSignature"`
+
+	localEnvelope
+
+	Type    string `json:"_type"`
+	Subject []struct {
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+}
+
+// localEnvelope is a DSSE envelope.
+type localEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+}
+
+// LoadAttestation reads an attestation file: a Sigstore bundle, a DSSE
+// envelope, or a bare in-toto statement, which is served in an unsigned
+// envelope. The archive it is about is the statement's subject, or the
+// digest signed by a bundle holding a plain signature.
+func LoadAttestation(path string) (*LocalAttestation, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // attestation path is user-provided
+	if err != nil {
+		return nil, fmt.Errorf("reading attestation: %w", err)
+	}
+	var b localBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parsing attestation %s: %w", path, err)
+	}
+
+	a := &LocalAttestation{data: data}
+	var subject digest.Digest
+	switch {
+	case strings.HasPrefix(b.MediaType, "application/vnd.dev.sigstore.bundle"):
+		a.desc.ArtifactType = slsa.SigstoreBundleArtifactType
+		switch {
+		case b.DSSEEnvelope != nil:
+			subject, err = b.DSSEEnvelope.subject()
+		case b.MessageSignature != nil && b.MessageSignature.MessageDigest.Algorithm == "SHA2_256":
+			var sum []byte
+			sum, err = base64.StdEncoding.DecodeString(b.MessageSignature.MessageDigest.Digest)
+			subject = digest.NewDigestFromBytes(digest.SHA256, sum)
+		default:
+			err = errors.New("bundle holds neither a DSSE envelope nor a SHA-256 message signature")
+		}
+	case b.Payload != "":
+		a.desc.ArtifactType = slsa.InTotoArtifactType
+		subject, err = b.subject()
+	case b.Type != "":
+		a.desc.ArtifactType = slsa.InTotoArtifactType
+		subject, err = statementSubject(data)
+		if err == nil {
+			a.data, err = json.Marshal(localEnvelope{
+				PayloadType: slsa.DSSEPayloadType,
+				Payload:     base64.StdEncoding.EncodeToString(data),
+			})
+		}
+	default:
+		err = errors.New("not a Sigstore bundle, DSSE envelope, or in-toto statement")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("attestation %s: %w", path, err)
+	}
+	if err := subject.Validate(); err != nil {
+		return nil, fmt.Errorf("attestation %s: invalid subject digest: %w", path, err)
+	}
+
+	a.desc.MediaType = "application/json"
+	a.desc.Digest = digest.FromBytes(a.data)
+	a.desc.Size = int64(len(a.data))
+	a.subject = ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: subject}
+	return a, nil
+}
+
+// subject returns the subject of the statement in the envelope.
+func (e *localEnvelope) subject() (digest.Digest, error) {
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return "", fmt.Errorf("decoding DSSE payload: %w", err)
+	}
+	return statementSubject(payload)
+}
+
+// statementSubject returns the SHA-256 digest of the first subject of an
+// in-toto statement.
+func statementSubject(data []byte) (digest.Digest, error) {
+	var statement localBundle
+	if err := json.Unmarshal(data, &statement); err != nil {
+		return "", fmt.Errorf("parsing in-toto statement: %w", err)
+	}
+	for _, s := range statement.Subject {
+		if hex, ok := s.Digest["sha256"]; ok {
+			return digest.NewDigestFromEncoded(digest.SHA256, hex), nil
+		}
+	}
+	return "", errors.New("in-toto statement has no sha256 subject")
+}
+
+// Subject returns the digest of the archive the attestation is about.
+func (a *LocalAttestation) Subject() string {
+	return a.subject.Digest.String()
+}
+
+// Request returns a policy request for the attestation's subject, served
+// by a.
+func (a *LocalAttestation) Request() registry.PolicyRequest {
+	return registry.PolicyRequest{
+		Digest:  a.subject.Digest.String(),
+		Subject: a.subject,
+		Client:  a,
+	}
+}
+
+// Referrers implements registry.PolicyClient. The attestation is the only
+// referrer of its subject. Referrers of other artifact types are not
+// known, rather than absent, so a policy looking for them, such as for
+// signatures beside an attestation, fails with ErrNotLocal.
+func (a *LocalAttestation) Referrers(
+	_ context.Context,
+	_ string,
+	subject ocispec.Descriptor,
+	artifactType string,
+) ([]ocispec.Descriptor, error) {
+	if subject.Digest != a.subject.Digest || artifactType != a.desc.ArtifactType {
+		return nil, fmt.Errorf("%s referrers of %s: %w", artifactType, subject.Digest, ErrNotLocal)
+	}
+	return []ocispec.Descriptor{a.desc}, nil
+}
+
+// FetchDescriptor implements registry.PolicyClient. Only the attestation
+// itself can be fetched.
+func (a *LocalAttestation) FetchDescriptor(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	if desc.Digest != a.desc.Digest {
+		return nil, fmt.Errorf("%s: %w", desc.Digest, ErrNotLocal)
+	}
+	return a.data, nil
+}
+
+// Ensure LocalAttestation implements registry.PolicyClient.
+var _ registry.PolicyClient = (*LocalAttestation)(nil)
@@ -0,0 +1,96 @@
+package policy
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/meigma/blob/policy/slsa"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSubject is the archive digest the test statements are about.
+var testSubject = digest.FromString("manifest")
+
+// testStatement is an SLSA v1 provenance statement built by builder.
+func testStatement(builder string) string {
+	return `{"_type":"https://in-toto.io/Statement/v1",` +
+		`"subject":[{"name":"archive","digest":{"sha256":"` + testSubject.Encoded() + `"}}],` +
+		`"predicateType":"https://slsa.dev/provenance/v1",` +
+		`"predicate":{"runDetails":{"builder":{"id":"` + builder + `"}}}}`
+}
+
+func writeAttestation(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "attestation.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadAttestation(t *testing.T) {
+	statement := testStatement("https://github.com/actions/runner")
+	envelope := `{"payloadType":"application/vnd.in-toto+json","payload":"` +
+		base64.StdEncoding.EncodeToString([]byte(statement)) + `"}`
+
+	tests := []struct {
+		name         string
+		content      string
+		artifactType string
+		wantErr      string
+	}{
+		{name: "bare statement", content: statement, artifactType: slsa.InTotoArtifactType},
+		{name: "dsse envelope", content: envelope, artifactType: slsa.InTotoArtifactType},
+		{
+			name:         "sigstore bundle",
+			content:      `{"mediaType":"application/vnd.dev.sigstore.bundle.v0.3+json","dsseEnvelope":` + envelope + `}`,
+			artifactType: slsa.SigstoreBundleArtifactType,
+		},
+		{name: "not an attestation", content: `{"name":"x"}`, wantErr: "not a Sigstore bundle"},
+		{name: "no subject", content: `{"_type":"https://in-toto.io/Statement/v1"}`, wantErr: "no sha256 subject"},
+		{name: "invalid json", content: `{`, wantErr: "parsing attestation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			att, err := LoadAttestation(writeAttestation(t, tt.content))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testSubject.String(), att.Subject())
+
+			req := att.Request()
+			descs, err := req.Client.Referrers(t.Context(), "", req.Subject, tt.artifactType)
+			require.NoError(t, err)
+			require.Len(t, descs, 1)
+			data, err := req.Client.FetchDescriptor(t.Context(), "", descs[0])
+			require.NoError(t, err)
+			prov, err := slsa.ParseProvenance(data)
+			require.NoError(t, err)
+			assert.Equal(t, "https://github.com/actions/runner", prov.BuilderID)
+		})
+	}
+}
+
+func TestLocalAttestation_Evaluate(t *testing.T) {
+	att, err := LoadAttestation(writeAttestation(t, testStatement("https://github.com/actions/runner")))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, slsa.RequireBuilder("https://github.com/actions/runner").Evaluate(ctx, att.Request()))
+
+	err = slsa.RequireBuilder("https://gitlab.com/runner").Evaluate(ctx, att.Request())
+	require.ErrorIs(t, err, slsa.ErrBuilderMismatch)
+
+	// Neither are other referrers nor the manifest part of an attestation
+	_, err = att.Referrers(ctx, "", att.Request().Subject, slsa.SigstoreBundleArtifactType)
+	require.ErrorIs(t, err, ErrNotLocal)
+	_, err = att.FetchDescriptor(ctx, "", ocispec.Descriptor{Digest: testSubject})
+	require.ErrorIs(t, err, ErrNotLocal)
+}
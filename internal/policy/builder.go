@@ -1,41 +1,171 @@
 package policy
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/meigma/blob/policy"
 	"github.com/meigma/blob/policy/opa"
 	"github.com/meigma/blob/policy/sigstore"
 	"github.com/meigma/blob/policy/slsa"
 	"github.com/meigma/blob/registry"
+	"github.com/sigstore/sigstore-go/pkg/root"
 
 	"github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/retry"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
+// ErrTrustedRootUnavailable is returned when the public-good Sigstore
+// trusted root cannot be fetched, or may not be fetched offline, so
+// keyless policies cannot verify anything. It reports an outage rather
+// than a failed policy.
+var ErrTrustedRootUnavailable = errors.New("sigstore trusted root unavailable")
+
+// fetchTrustedRoot fetches the public-good trusted root, retried with
+// fetchRetry. Tests replace both.
+var (
+	fetchTrustedRoot = root.FetchTrustedRoot
+	fetchRetry       = retry.Default
+)
+
+// BuildOption configures how policies are built.
+type BuildOption func(*buildOptions)
+
+type buildOptions struct {
+	trustedRoot     root.TrustedMaterial
+	trustedRootFile string
+	offline         bool
+}
+
+// WithTrustedRoot verifies signatures against the given Sigstore trusted root
+// instead of fetching the public-good root.
+func WithTrustedRoot(tr root.TrustedMaterial) BuildOption {
+	return func(o *buildOptions) {
+		o.trustedRoot = tr
+	}
+}
+
+// WithTrustedRootFile verifies signatures against the Sigstore trusted
+// root in a trusted_root.json file, as for a private Sigstore deployment.
+// A root given with WithTrustedRoot takes precedence.
+func WithTrustedRootFile(path string) BuildOption {
+	return func(o *buildOptions) {
+		o.trustedRootFile = path
+	}
+}
+
+// WithOffline keeps keyless policies from fetching the public-good
+// trusted root: evaluating one fails with ErrTrustedRootUnavailable
+// unless a root is given or configured.
+func WithOffline() BuildOption {
+	return func(o *buildOptions) {
+		o.offline = true
+	}
+}
+
+// configOptions prepends the options set by cfg to opts: its trust root
+// and offline mode.
+func configOptions(cfg *config.Config, opts []BuildOption) []BuildOption {
+	if cfg == nil {
+		return opts
+	}
+	var fromConfig []BuildOption
+	if cfg.Sigstore.TrustRoot != "" {
+		fromConfig = append(fromConfig, WithTrustedRootFile(cfg.Sigstore.TrustRoot))
+	}
+	if cfg.Offline {
+		fromConfig = append(fromConfig, WithOffline())
+	}
+	return append(fromConfig, opts...)
+}
+
+// NamedPolicy is a policy with a description of where it came from.
+type NamedPolicy struct {
+	// Name identifies the policy source, e.g. "policy file policy.yaml".
+	Name string
+
+	// Policy is the built policy.
+	Policy registry.Policy
+
+	// Warn is set for config policies in warn mode, whose failures are
+	// reported rather than enforced.
+	Warn bool
+}
+
 // BuildPolicies constructs registry.Policy instances from config and command flags.
 // It combines policies from the config file (unless noDefaultPolicy is true)
-// with policies from policy files and OPA rego files.
+// with policies from policy files and OPA rego files. Config policies in
+// warn mode report a failure as a warning and pass.
 func BuildPolicies(
 	cfg *config.Config,
 	ref string,
 	policyFiles []string,
 	policyRego string,
 	noDefaultPolicy bool,
+	opts ...BuildOption,
 ) ([]registry.Policy, error) {
+	named, err := BuildNamedPolicies(cfg, ref, policyFiles, policyRego, noDefaultPolicy, opts...)
+	if err != nil {
+		return nil, err
+	}
 	var policies []registry.Policy
+	for _, np := range named {
+		p := np.Policy
+		if np.Warn {
+			p = warnOnFailure(np, ref)
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
 
-	// 1. Config policies (unless skipped)
+// warnOnFailure returns a policy that reports a failure of np as a
+// warning instead of failing.
+func warnOnFailure(np NamedPolicy, ref string) registry.Policy {
+	return registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+		if err := np.Policy.Evaluate(ctx, req); err != nil {
+			warn.Printf("%s failed for %s (warn mode): %v", np.Name, ref, err)
+		}
+		return nil
+	})
+}
+
+// BuildNamedPolicies is like BuildPolicies, but also names each policy by
+// its source, for reporting results per policy.
+func BuildNamedPolicies(
+	cfg *config.Config,
+	ref string,
+	policyFiles []string,
+	policyRego string,
+	noDefaultPolicy bool,
+	opts ...BuildOption,
+) ([]NamedPolicy, error) {
+	var policies []NamedPolicy
+
+	// A configured trust root replaces the public-good one
+	opts = configOptions(cfg, opts)
+
+	// 1. Config and environment policies (unless skipped)
 	if !noDefaultPolicy && cfg != nil {
-		configPolicies := cfg.GetPoliciesForRef(ref)
-		for i, cfgPolicy := range configPolicies {
-			regPolicy, err := ConvertConfigPolicy(cfgPolicy)
+		for i, rule := range cfg.MatchedPolicyRules(ref) {
+			regPolicy, err := ConvertConfigPolicy(rule.Policy, opts...)
 			if err != nil {
 				return nil, fmt.Errorf("config policy %d: %w", i, err)
 			}
-			if regPolicy != nil {
-				policies = append(policies, regPolicy)
+			if regPolicy == nil {
+				continue
+			}
+			name := fmt.Sprintf("config policy (match %s)", rule.Pattern)
+			switch {
+			case rule.Source != "":
+				name = "environment policy " + rule.Source
+			case rule.Alias != "":
+				name = "policy of alias " + rule.Alias
 			}
+			policies = append(policies, NamedPolicy{Name: name, Policy: regPolicy, Warn: rule.Warn})
 		}
 	}
 
@@ -45,12 +175,12 @@ func BuildPolicies(
 		if err != nil {
 			return nil, fmt.Errorf("loading policy %s: %w", path, err)
 		}
-		regPolicy, err := ConvertConfigPolicy(*cfgPolicy)
+		regPolicy, err := ConvertConfigPolicy(*cfgPolicy, opts...)
 		if err != nil {
 			return nil, fmt.Errorf("policy %s: %w", path, err)
 		}
 		if regPolicy != nil {
-			policies = append(policies, regPolicy)
+			policies = append(policies, NamedPolicy{Name: "policy file " + path, Policy: regPolicy})
 		}
 	}
 
@@ -60,19 +190,67 @@ func BuildPolicies(
 		if err != nil {
 			return nil, fmt.Errorf("loading rego policy %s: %w", policyRego, err)
 		}
-		policies = append(policies, p)
+		policies = append(policies, NamedPolicy{Name: "rego policy " + policyRego, Policy: p})
 	}
 
 	return policies, nil
 }
 
+// BuildRules builds the rules of policy files and a Rego policy
+// separately, so each can be reported on its own: the signature and
+// provenance requirements of each file, and the Rego policy as a whole.
+func BuildRules(cfg *config.Config, policyFiles []string, policyRego string, opts ...BuildOption) ([]NamedPolicy, error) {
+	opts = configOptions(cfg, opts)
+	var o buildOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var rules []NamedPolicy
+	for _, path := range policyFiles {
+		cfgPolicy, err := LoadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading policy %s: %w", path, err)
+		}
+		if cfgPolicy.Signature != nil {
+			p, err := buildSignaturePolicy(cfgPolicy.Signature, &o)
+			if err != nil {
+				return nil, fmt.Errorf("policy %s: signature policy: %w", path, err)
+			}
+			rules = append(rules, NamedPolicy{Name: path + ": signature", Policy: p})
+		}
+		if cfgPolicy.Provenance != nil {
+			p, err := buildProvenancePolicy(cfgPolicy.Provenance)
+			if err != nil {
+				return nil, fmt.Errorf("policy %s: provenance policy: %w", path, err)
+			}
+			rules = append(rules, NamedPolicy{Name: path + ": provenance", Policy: p})
+		}
+	}
+
+	if policyRego != "" {
+		p, err := opa.NewPolicy(opa.WithPolicyFile(policyRego))
+		if err != nil {
+			return nil, fmt.Errorf("loading rego policy %s: %w", policyRego, err)
+		}
+		rules = append(rules, NamedPolicy{Name: policyRego, Policy: p})
+	}
+
+	return rules, nil
+}
+
 // ConvertConfigPolicy converts a config.Policy to a registry.Policy.
-func ConvertConfigPolicy(cfgPolicy config.Policy) (registry.Policy, error) {
+func ConvertConfigPolicy(cfgPolicy config.Policy, opts ...BuildOption) (registry.Policy, error) {
+	var o buildOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var policies []registry.Policy
 
 	// Handle signature policy
 	if cfgPolicy.Signature != nil {
-		sigPolicy, err := buildSignaturePolicy(cfgPolicy.Signature)
+		sigPolicy, err := buildSignaturePolicy(cfgPolicy.Signature, &o)
 		if err != nil {
 			return nil, fmt.Errorf("signature policy: %w", err)
 		}
@@ -102,39 +280,132 @@ func ConvertConfigPolicy(cfgPolicy config.Policy) (registry.Policy, error) {
 }
 
 // buildSignaturePolicy creates a sigstore policy from config.
-func buildSignaturePolicy(sig *config.SignaturePolicy) (registry.Policy, error) {
+func buildSignaturePolicy(sig *config.SignaturePolicy, o *buildOptions) (registry.Policy, error) {
+	if _, err := checkSignaturePolicy(sig); err != nil {
+		return nil, err
+	}
+
+	// The public-good root is fetched when a signature is first verified,
+	// so building a policy needs no network
+	if o.trustedRoot == nil && o.trustedRootFile == "" {
+		return lazyPolicy(func(ctx context.Context) (registry.Policy, error) {
+			tr, err := o.fetchRoot(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return newKeylessPolicy(sig.Keyless, tr)
+		}), nil
+	}
+	trustedRoot, err := o.sigstoreRoot()
+	if err != nil {
+		return nil, err
+	}
+	return newKeylessPolicy(sig.Keyless, trustedRoot)
+}
+
+// newKeylessPolicy returns a policy requiring a keyless signature by
+// issuer and identity, verified against trustedRoot.
+func newKeylessPolicy(keyless *config.KeylessConfig, trustedRoot root.TrustedMaterial) (registry.Policy, error) {
+	sigPolicy, err := sigstore.NewPolicy(
+		sigstore.WithIdentity(keyless.Issuer, keyless.Identity),
+		sigstore.WithTrustedRoot(trustedRoot),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Signatures made by cosign pipelines are accepted too
+	cosign, err := newCosignPolicy(trustedRoot, keyless.Issuer, keyless.Identity)
+	if err != nil {
+		return nil, err
+	}
+	return orCosign(sigPolicy, cosign), nil
+}
+
+// lazyPolicy returns a policy built by build when it is first evaluated.
+// A failed build fails every evaluation with the same error.
+func lazyPolicy(build func(context.Context) (registry.Policy, error)) registry.Policy {
+	var (
+		once sync.Once
+		p    registry.Policy
+		err  error
+	)
+	return registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+		once.Do(func() { p, err = build(ctx) })
+		if err != nil {
+			return err
+		}
+		return p.Evaluate(ctx, req)
+	})
+}
+
+// checkSignaturePolicy reports why a signature policy cannot be built,
+// and the field at fault, such as "signature.keyless". Only keyless
+// policies can be built.
+func checkSignaturePolicy(sig *config.SignaturePolicy) (string, error) {
 	// Error if both keyless and key are specified to avoid ambiguity
 	if sig.Keyless != nil && sig.Key != nil {
-		return nil, errors.New("signature policy cannot specify both keyless and key")
+		return "signature", errors.New("signature policy cannot specify both keyless and key")
 	}
 
 	if sig.Keyless != nil {
 		if sig.Keyless.Issuer == "" {
-			return nil, errors.New("keyless issuer is required")
+			return "signature.keyless", errors.New("keyless issuer is required")
 		}
 		if sig.Keyless.Identity == "" {
-			return nil, errors.New("keyless identity is required")
+			return "signature.keyless", errors.New("keyless identity is required")
 		}
-		return sigstore.NewPolicy(
-			sigstore.WithIdentity(sig.Keyless.Issuer, sig.Keyless.Identity),
-		)
+		return "", nil
 	}
 	if sig.Key != nil {
 		if sig.Key.Path != "" {
-			return nil, errors.New("key-based signature verification not yet implemented")
+			return "signature.key.path", errors.New("key-based signature verification not yet implemented")
 		}
 		if sig.Key.URL != "" {
-			return nil, errors.New("key URL signature verification not yet implemented")
+			return "signature.key.url", errors.New("key URL signature verification not yet implemented")
 		}
-		return nil, errors.New("signature key must specify path or url")
+		return "signature.key", errors.New("signature key must specify path or url")
 	}
-	return nil, errors.New("signature policy must specify keyless or key")
+	return "signature", errors.New("signature policy must specify keyless or key")
+}
+
+// sigstoreRoot returns the trusted root keyless signatures are verified
+// against: the one given, the one in the trusted root file, or else the
+// public-good root.
+func (o *buildOptions) sigstoreRoot() (root.TrustedMaterial, error) {
+	if o.trustedRoot != nil {
+		return o.trustedRoot, nil
+	}
+	if o.trustedRootFile != "" {
+		tr, err := root.NewTrustedRootFromPath(o.trustedRootFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading sigstore trust root %s: %w", o.trustedRootFile, err)
+		}
+		return tr, nil
+	}
+	return o.fetchRoot(context.Background())
+}
+
+// fetchRoot fetches the public-good trusted root, unless offline.
+func (o *buildOptions) fetchRoot(ctx context.Context) (root.TrustedMaterial, error) {
+	if o.offline {
+		return nil, fmt.Errorf("%w: not fetched offline; configure sigstore.trust_root to verify keyless signatures offline", ErrTrustedRootUnavailable)
+	}
+	var tr *root.TrustedRoot
+	err := fetchRetry.Do(ctx, func() error {
+		var err error
+		tr, err = fetchTrustedRoot()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTrustedRootUnavailable, err)
+	}
+	return tr, nil
 }
 
 // buildProvenancePolicy creates an SLSA policy from config.
 func buildProvenancePolicy(prov *config.ProvenancePolicy) (registry.Policy, error) {
-	if prov.SLSA == nil {
-		return nil, errors.New("provenance policy must specify slsa")
+	if _, err := checkProvenancePolicy(prov); err != nil {
+		return nil, err
 	}
 
 	// Repository is required for GitHubActionsWorkflow
@@ -152,9 +423,17 @@ func buildProvenancePolicy(prov *config.ProvenancePolicy) (registry.Policy, erro
 	}
 
 	// Fallback to basic builder requirement
-	if prov.SLSA.Builder != "" {
-		return slsa.RequireBuilder(prov.SLSA.Builder), nil
-	}
+	return slsa.RequireBuilder(prov.SLSA.Builder), nil
+}
 
-	return nil, errors.New("slsa policy must specify repository or builder")
+// checkProvenancePolicy reports why a provenance policy cannot be built,
+// and the field at fault, such as "provenance.slsa".
+func checkProvenancePolicy(prov *config.ProvenancePolicy) (string, error) {
+	if prov.SLSA == nil {
+		return "provenance", errors.New("provenance policy must specify slsa")
+	}
+	if prov.SLSA.Repository == "" && prov.SLSA.Builder == "" {
+		return "provenance.slsa", errors.New("slsa policy must specify repository or builder")
+	}
+	return "", nil
 }
@@ -0,0 +1,236 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/meigma/blob/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+
+	"github.com/meigma/blob-cli/internal/referrers"
+)
+
+// Media type and annotations of the layers of a cosign signature manifest.
+// Each layer is a simple signing payload naming the signed manifest, with
+// the signature, signing certificate, and Rekor entry in its annotations.
+const (
+	cosignPayloadMediaType    = "application/vnd.dev.cosign.simplesigning.v1+json"
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	cosignCertAnnotation      = "dev.sigstore.cosign/certificate"
+	cosignBundleAnnotation    = "dev.sigstore.cosign/bundle"
+)
+
+// errNoCosignSignatures means a manifest has no cosign signatures at all.
+var errNoCosignSignatures = errors.New("cosign: no signatures found for manifest")
+
+// cosignPolicy verifies keyless signatures made by cosign, under its
+// ".sig" tag or as referrers of its signature artifact type, against a
+// trusted root and signer identity. Signatures stored as sigstore bundle
+// referrers, as newer cosign versions write them, are verified by the
+// sigstore policy instead.
+type cosignPolicy struct {
+	trustedRoot root.TrustedMaterial
+	identity    verify.CertificateIdentity
+}
+
+// newCosignPolicy returns a cosign policy requiring signatures by identity
+// with a certificate from issuer.
+func newCosignPolicy(trustedRoot root.TrustedMaterial, issuer, identity string) (*cosignPolicy, error) {
+	id, err := verify.NewShortCertificateIdentity(issuer, "", identity, "")
+	if err != nil {
+		return nil, err
+	}
+	return &cosignPolicy{trustedRoot: trustedRoot, identity: id}, nil
+}
+
+// orCosign returns a policy that passes if p does or, failing that, if
+// cosign does. When there are no cosign signatures, p's error is returned
+// as it was.
+func orCosign(p registry.Policy, cosign *cosignPolicy) registry.Policy {
+	return registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+		err := p.Evaluate(ctx, req)
+		if err == nil {
+			return nil
+		}
+		cosignErr := cosign.Evaluate(ctx, req)
+		switch {
+		case cosignErr == nil:
+			return nil
+		case errors.Is(cosignErr, errNoCosignSignatures):
+			return err
+		}
+		return fmt.Errorf("%w; %w", err, cosignErr)
+	})
+}
+
+// Evaluate implements registry.Policy.
+//
+//nolint:gocritic // req passed by value per registry.Policy interface contract
+func (p *cosignPolicy) Evaluate(ctx context.Context, req registry.PolicyRequest) error {
+	descs, err := req.Client.Referrers(ctx, req.Ref, req.Subject, referrers.CosignSignatureArtifactType)
+	if err != nil && !errors.Is(err, registry.ErrReferrersUnsupported) {
+		return fmt.Errorf("cosign: list signatures: %w", err)
+	}
+	if len(descs) == 0 {
+		return errNoCosignSignatures
+	}
+
+	lastErr := errNoCosignSignatures
+	for _, desc := range descs {
+		data, err := req.Client.FetchDescriptor(ctx, req.Ref, desc)
+		if err != nil {
+			lastErr = fmt.Errorf("cosign: fetch signature manifest: %w", err)
+			continue
+		}
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			lastErr = fmt.Errorf("cosign: parse signature manifest: %w", err)
+			continue
+		}
+		for _, layer := range manifest.Layers {
+			if layer.MediaType != cosignPayloadMediaType {
+				continue
+			}
+			if err := p.verifyLayer(ctx, req, layer); err != nil {
+				lastErr = fmt.Errorf("cosign: %w", err)
+				continue
+			}
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// verifyLayer verifies the signature in one layer of a cosign signature
+// manifest: its payload must name the manifest being evaluated, and its
+// signature, certificate, and Rekor entry must verify.
+//
+//nolint:gocritic // req passed by value to match Evaluate call chain
+func (p *cosignPolicy) verifyLayer(ctx context.Context, req registry.PolicyRequest, layer ocispec.Descriptor) error {
+	payload, err := req.Client.FetchDescriptor(ctx, req.Ref, layer)
+	if err != nil {
+		return fmt.Errorf("fetch payload: %w", err)
+	}
+	var simpleSigning struct {
+		Critical struct {
+			Image struct {
+				DockerManifestDigest string `json:"docker-manifest-digest"`
+			} `json:"image"`
+		} `json:"critical"`
+	}
+	if err := json.Unmarshal(payload, &simpleSigning); err != nil {
+		return fmt.Errorf("parse payload: %w", err)
+	}
+	if signed := simpleSigning.Critical.Image.DockerManifestDigest; signed != req.Digest {
+		return fmt.Errorf("signature is for %s, not %s", signed, req.Digest)
+	}
+
+	b, err := cosignBundle(layer.Annotations, payload)
+	if err != nil {
+		return err
+	}
+	verifier, err := verify.NewVerifier(
+		p.trustedRoot,
+		verify.WithObserverTimestamps(1),
+		verify.WithTransparencyLog(1),
+	)
+	if err != nil {
+		return fmt.Errorf("create verifier: %w", err)
+	}
+	policy := verify.NewPolicy(
+		verify.WithArtifact(bytes.NewReader(payload)),
+		verify.WithCertificateIdentity(p.identity),
+	)
+	if _, err := verifier.Verify(b, policy); err != nil {
+		return fmt.Errorf("signature invalid: %w", err)
+	}
+	return nil
+}
+
+// cosignBundle converts the annotations of a cosign signature layer into
+// a sigstore bundle over payload, of the v0.1 kind that carries a Rekor
+// inclusion promise rather than a proof, as cosign records.
+func cosignBundle(annotations map[string]string, payload []byte) (*bundle.Bundle, error) {
+	sig, err := base64.StdEncoding.DecodeString(annotations[cosignSignatureAnnotation])
+	if err != nil || len(sig) == 0 {
+		return nil, errors.New("missing or malformed signature annotation")
+	}
+	block, _ := pem.Decode([]byte(annotations[cosignCertAnnotation]))
+	if block == nil {
+		return nil, errors.New("missing certificate annotation; only keyless signatures are supported")
+	}
+
+	var rekorBundle struct {
+		SignedEntryTimestamp []byte
+		Payload              struct {
+			Body           []byte `json:"body"`
+			IntegratedTime int64  `json:"integratedTime"`
+			LogIndex       int64  `json:"logIndex"`
+			LogID          string `json:"logID"`
+		}
+	}
+	if err := json.Unmarshal([]byte(annotations[cosignBundleAnnotation]), &rekorBundle); err != nil {
+		return nil, errors.New("missing or malformed Rekor bundle annotation")
+	}
+	logID, err := hex.DecodeString(rekorBundle.Payload.LogID)
+	if err != nil {
+		return nil, fmt.Errorf("malformed Rekor log ID: %w", err)
+	}
+	var entry struct {
+		Kind       string `json:"kind"`
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal(rekorBundle.Payload.Body, &entry); err != nil {
+		return nil, fmt.Errorf("malformed Rekor entry: %w", err)
+	}
+
+	digest := sha256.Sum256(payload)
+	pb := &protobundle.Bundle{
+		MediaType: "application/vnd.dev.sigstore.bundle+json;version=0.1",
+		VerificationMaterial: &protobundle.VerificationMaterial{
+			Content: &protobundle.VerificationMaterial_X509CertificateChain{
+				X509CertificateChain: &protocommon.X509CertificateChain{
+					Certificates: []*protocommon.X509Certificate{{RawBytes: block.Bytes}},
+				},
+			},
+			TlogEntries: []*protorekor.TransparencyLogEntry{{
+				LogIndex:          rekorBundle.Payload.LogIndex,
+				LogId:             &protocommon.LogId{KeyId: logID},
+				KindVersion:       &protorekor.KindVersion{Kind: entry.Kind, Version: entry.APIVersion},
+				IntegratedTime:    rekorBundle.Payload.IntegratedTime,
+				InclusionPromise:  &protorekor.InclusionPromise{SignedEntryTimestamp: rekorBundle.SignedEntryTimestamp},
+				CanonicalizedBody: rekorBundle.Payload.Body,
+			}},
+		},
+		Content: &protobundle.Bundle_MessageSignature{
+			MessageSignature: &protocommon.MessageSignature{
+				MessageDigest: &protocommon.HashOutput{
+					Algorithm: protocommon.HashAlgorithm_SHA2_256,
+					Digest:    digest[:],
+				},
+				Signature: sig,
+			},
+		},
+	}
+	b, err := bundle.NewBundle(pb)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	return b, nil
+}
+
+// Ensure cosignPolicy implements registry.Policy.
+var _ registry.Policy = (*cosignPolicy)(nil)
@@ -0,0 +1,332 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"gopkg.in/yaml.v3"
+
+	"github.com/meigma/blob-cli/internal/config"
+)
+
+// regoPackage is the package a Rego policy must define its rules in.
+const regoPackage = "data.blob.policy"
+
+// Problem is something wrong with a policy file, found by LintFile.
+type Problem struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"` // 0 if the problem has no line
+	Message string `json:"message"`
+}
+
+// String formats the problem as "file:line: message".
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.File + ": " + p.Message
+	}
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// policyFields lists the fields of each part of a YAML policy, by its
+// dotted path.
+var policyFields = map[string][]string{
+	"":                  {"signature", "provenance"},
+	"signature":         {"keyless", "key"},
+	"signature.keyless": {"issuer", "identity"},
+	"signature.key":     {"path", "url"},
+	"provenance":        {"slsa"},
+	"provenance.slsa":   {"builder", "repository", "branch", "tag"},
+}
+
+// ruleFields lists the fields of a config policy rule.
+var ruleFields = []string{"match", "policy", "mode"}
+
+// yamlLine matches the line number yaml.v3 puts in its errors.
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// LintFiles returns the policy files to lint for path: path itself, or
+// the *.yaml, *.yml, and *.rego files anywhere under a directory.
+func LintFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch filepath.Ext(p) {
+		case ".yaml", ".yml", ".rego":
+			if d.Type().IsRegular() {
+				files = append(files, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// LintFile checks a policy file and returns every problem found. A .rego
+// file must parse and compile, and define allow or deny rules in package
+// blob.policy. Any other file is YAML: either a policy file, which must
+// have only known fields and pass the checks made when the policy is
+// built, or a config file, whose policies are checked the same way along
+// with each rule's match pattern and mode. An error is returned only if
+// the file cannot be read.
+func LintFile(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // policy path is user-provided
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) == ".rego" {
+		return lintRego(path, data), nil
+	}
+	return lintYAML(path, data), nil
+}
+
+// lintYAML checks a YAML policy file or config file.
+func lintYAML(file string, data []byte) []Problem {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return yamlProblems(file, err)
+	}
+	if len(doc.Content) == 0 {
+		return []Problem{{File: file, Message: "policy is empty"}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return []Problem{{File: file, Line: root.Line, Message: "policy must be a mapping"}}
+	}
+	if rules := mappingValue(root, "policies"); rules != nil {
+		return lintConfigPolicies(file, rules)
+	}
+	return lintPolicy(file, root, "")
+}
+
+// lintConfigPolicies checks the policies of a config file.
+func lintConfigPolicies(file string, rules *yaml.Node) []Problem {
+	if rules.Kind != yaml.SequenceNode {
+		return []Problem{{File: file, Line: rules.Line, Message: "policies must be a list"}}
+	}
+
+	var problems []Problem
+	for i, rule := range rules.Content {
+		name := fmt.Sprintf("policies[%d]", i)
+		if rule.Kind != yaml.MappingNode {
+			problems = append(problems, Problem{File: file, Line: rule.Line, Message: name + " must be a mapping"})
+			continue
+		}
+		for j := 0; j+1 < len(rule.Content); j += 2 {
+			if key := rule.Content[j]; !slices.Contains(ruleFields, key.Value) {
+				problems = append(problems, Problem{File: file, Line: key.Line, Message: "unknown field " + name + "." + key.Value})
+			}
+		}
+
+		match := mappingValue(rule, "match")
+		if match == nil || match.Value == "" {
+			problems = append(problems, Problem{File: file, Line: rule.Line, Message: name + ".match cannot be empty"})
+		} else if _, err := regexp.Compile(match.Value); err != nil {
+			problems = append(problems, Problem{
+				File:    file,
+				Line:    match.Line,
+				Message: fmt.Sprintf("%s.match is invalid regex %q: %v", name, match.Value, err),
+			})
+		}
+
+		if mode := mappingValue(rule, "mode"); mode != nil {
+			switch mode.Value {
+			case config.PolicyModeEnforce, config.PolicyModeWarn:
+			default:
+				problems = append(problems, Problem{
+					File:    file,
+					Line:    mode.Line,
+					Message: fmt.Sprintf("%s.mode must be %q or %q, got %q", name, config.PolicyModeEnforce, config.PolicyModeWarn, mode.Value),
+				})
+			}
+		}
+
+		p := mappingValue(rule, "policy")
+		switch {
+		case p == nil:
+			problems = append(problems, Problem{File: file, Line: rule.Line, Message: name + ".policy cannot be empty"})
+		case p.Kind != yaml.MappingNode:
+			problems = append(problems, Problem{File: file, Line: p.Line, Message: name + ".policy must be a mapping"})
+		default:
+			problems = append(problems, lintPolicy(file, p, name+".policy.")...)
+		}
+	}
+	return problems
+}
+
+// lintPolicy checks a policy mapping, naming its fields with prefix.
+func lintPolicy(file string, node *yaml.Node, prefix string) []Problem {
+	problems := unknownFields(file, node, "", prefix)
+
+	var pf File
+	if err := node.Decode(&pf); err != nil {
+		return append(problems, yamlProblems(file, err)...)
+	}
+	p := convertFileToConfig(&pf)
+	if p.Signature == nil && p.Provenance == nil {
+		return append(problems, Problem{File: file, Line: node.Line, Message: "policy has no signature or provenance requirements"})
+	}
+
+	if p.Signature != nil {
+		if field, err := checkSignaturePolicy(p.Signature); err != nil {
+			problems = append(problems, Problem{File: file, Line: fieldLine(node, field), Message: prefix + field + ": " + err.Error()})
+		}
+	}
+	if p.Provenance != nil {
+		if field, err := checkProvenancePolicy(p.Provenance); err != nil {
+			problems = append(problems, Problem{File: file, Line: fieldLine(node, field), Message: prefix + field + ": " + err.Error()})
+		}
+	}
+	return problems
+}
+
+// unknownFields reports the fields of node, the part of a policy at path,
+// that policies do not have.
+func unknownFields(file string, node *yaml.Node, path, prefix string) []Problem {
+	if node.Kind != yaml.MappingNode {
+		return nil // reported when the policy is decoded
+	}
+	var problems []Problem
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		field := key.Value
+		if path != "" {
+			field = path + "." + key.Value
+		}
+		if !slices.Contains(policyFields[path], key.Value) {
+			problems = append(problems, Problem{File: file, Line: key.Line, Message: "unknown field " + prefix + field})
+			continue
+		}
+		if _, ok := policyFields[field]; ok {
+			problems = append(problems, unknownFields(file, node.Content[i+1], field, prefix)...)
+		}
+	}
+	return problems
+}
+
+// fieldLine returns the line of the field at a dotted path under node, or
+// of the deepest part of the path present.
+func fieldLine(node *yaml.Node, path string) int {
+	line := node.Line
+	for name := range strings.SplitSeq(path, ".") {
+		if node.Kind != yaml.MappingNode {
+			break
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				line = node.Content[i].Line
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return line
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlProblems converts a YAML parse or decode error into problems,
+// taking their lines from the messages.
+func yamlProblems(file string, err error) []Problem {
+	messages := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	problems := make([]Problem, 0, len(messages))
+	for _, msg := range messages {
+		p := Problem{File: file, Message: msg}
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			p.Line, _ = strconv.Atoi(m[1])
+			p.Message = m[2]
+		}
+		problems = append(problems, p)
+	}
+	return problems
+}
+
+// lintRego checks a Rego policy.
+func lintRego(file string, data []byte) []Problem {
+	module, err := ast.ParseModule(file, string(data))
+	if err != nil {
+		return regoProblems(file, err)
+	}
+	if module == nil {
+		return []Problem{{File: file, Message: "policy is empty"}}
+	}
+
+	var problems []Problem
+	if pkg := module.Package.Path.String(); pkg != regoPackage {
+		problems = append(problems, Problem{
+			File:    file,
+			Line:    module.Package.Location.Row,
+			Message: fmt.Sprintf("package must be %s, got %s", strings.TrimPrefix(regoPackage, "data."), strings.TrimPrefix(pkg, "data.")),
+		})
+	} else if !slices.ContainsFunc(module.Rules, func(r *ast.Rule) bool {
+		name := r.Head.Ref().String()
+		return name == "allow" || name == "deny"
+	}) {
+		problems = append(problems, Problem{
+			File:    file,
+			Line:    module.Package.Location.Row,
+			Message: "policy must define allow or deny rules",
+		})
+	}
+
+	compiler := ast.NewCompiler()
+	if compiler.Compile(map[string]*ast.Module{file: module}); compiler.Failed() {
+		problems = append(problems, regoProblems(file, compiler.Errors)...)
+	}
+	return problems
+}
+
+// regoProblems converts Rego parse or compile errors into problems.
+func regoProblems(file string, err error) []Problem {
+	var astErrs ast.Errors
+	if !errors.As(err, &astErrs) {
+		return []Problem{{File: file, Message: err.Error()}}
+	}
+	problems := make([]Problem, 0, len(astErrs))
+	for _, e := range astErrs {
+		p := Problem{File: file, Message: e.Message}
+		if e.Location != nil {
+			p.Line = e.Location.Row
+		}
+		problems = append(problems, p)
+	}
+	return problems
+}
@@ -0,0 +1,135 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []Problem
+	}{
+		{
+			name:    "valid policy",
+			file:    "policy.yaml",
+			content: "provenance:\n  slsa:\n    builder: https://github.com/actions/runner\n",
+		},
+		{
+			name: "unknown field and missing identity",
+			file: "policy.yaml",
+			content: "signature:\n  keyless:\n    issuer: https://token.actions.githubusercontent.com\n" +
+				"    identiy: https://github.com/acme/*\n",
+			want: []Problem{
+				{Line: 4, Message: "unknown field signature.keyless.identiy"},
+				{Line: 2, Message: "signature.keyless: keyless identity is required"},
+			},
+		},
+		{
+			name:    "provenance without slsa requirements",
+			file:    "policy.yaml",
+			content: "provenance:\n  slsa:\n    branch: main\n",
+			want:    []Problem{{Line: 2, Message: "provenance.slsa: slsa policy must specify repository or builder"}},
+		},
+		{
+			name:    "empty policy",
+			file:    "policy.yaml",
+			content: "{}\n",
+			want:    []Problem{{Line: 1, Message: "policy has no signature or provenance requirements"}},
+		},
+		{
+			name:    "syntax error",
+			file:    "policy.yaml",
+			content: "signature:\n  keyless: [\n",
+			want:    []Problem{{Line: 2, Message: "did not find expected node content"}},
+		},
+		{
+			name: "config policies",
+			file: "config.yaml",
+			content: "output: text\npolicies:\n  - match: \"ghcr.io/(acme\"\n    mode: audit\n    policy:\n" +
+				"      signature:\n        key:\n          path: cosign.pub\n  - match: ghcr.io/\n",
+			want: []Problem{
+				{Line: 3, Message: "policies[0].match is invalid regex \"ghcr.io/(acme\": error parsing regexp: missing closing ): `ghcr.io/(acme`"},
+				{Line: 4, Message: "policies[0].mode must be \"enforce\" or \"warn\", got \"audit\""},
+				{Line: 8, Message: "policies[0].policy.signature.key.path: key-based signature verification not yet implemented"},
+				{Line: 9, Message: "policies[1].policy cannot be empty"},
+			},
+		},
+		{
+			name:    "valid rego",
+			file:    "policy.rego",
+			content: "package blob.policy\n\nimport rego.v1\n\ndefault allow := false\n\nallow if input.manifest\n",
+		},
+		{
+			name:    "rego in another package",
+			file:    "policy.rego",
+			content: "package acme\n\nallow := true\n",
+			want:    []Problem{{Line: 1, Message: "package must be blob.policy, got acme"}},
+		},
+		{
+			name:    "rego without allow or deny",
+			file:    "policy.rego",
+			content: "package blob.policy\n\npermit := true\n",
+			want:    []Problem{{Line: 1, Message: "policy must define allow or deny rules"}},
+		},
+		{
+			name:    "rego compile error",
+			file:    "policy.rego",
+			content: "package blob.policy\n\nallow if {\n\tinput.owner == owner\n}\n",
+			want:    []Problem{{Line: 4, Message: "var owner is unsafe"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			problems, err := LintFile(path)
+			require.NoError(t, err)
+			for i := range tt.want {
+				tt.want[i].File = path
+			}
+			if tt.want == nil {
+				assert.Empty(t, problems)
+			} else {
+				assert.Equal(t, tt.want, problems)
+			}
+		})
+	}
+}
+
+func TestLintFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "rego"), 0o755))
+	for _, name := range []string{"a.yaml", "b.yml", "rego/c.rego", "README.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+
+	files, err := LintFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a.yaml"),
+		filepath.Join(dir, "b.yml"),
+		filepath.Join(dir, "rego", "c.rego"),
+	}, files)
+
+	files, err = LintFiles(filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "README.md")}, files)
+
+	_, err = LintFiles(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
+
+func TestProblem_String(t *testing.T) {
+	assert.Equal(t, "policy.yaml:3: keyless issuer is required",
+		Problem{File: "policy.yaml", Line: 3, Message: "keyless issuer is required"}.String())
+	assert.Equal(t, "policy.yaml: policy is empty", Problem{File: "policy.yaml", Message: "policy is empty"}.String())
+}
@@ -13,39 +13,39 @@ import (
 // File represents a YAML policy file structure.
 // This matches the format described in DESIGN.md.
 type File struct {
-	Signature  *SignatureFile  `yaml:"signature"`
-	Provenance *ProvenanceFile `yaml:"provenance"`
+	Signature  *SignatureFile  `yaml:"signature,omitempty"`
+	Provenance *ProvenanceFile `yaml:"provenance,omitempty"`
 }
 
 // SignatureFile defines signature verification in a policy file.
 type SignatureFile struct {
-	Keyless *KeylessFile `yaml:"keyless"`
-	Key     *KeyFile     `yaml:"key"`
+	Keyless *KeylessFile `yaml:"keyless,omitempty"`
+	Key     *KeyFile     `yaml:"key,omitempty"`
 }
 
 // KeylessFile defines Sigstore keyless verification.
 type KeylessFile struct {
-	Issuer   string `yaml:"issuer"`
-	Identity string `yaml:"identity"`
+	Issuer   string `yaml:"issuer,omitempty"`
+	Identity string `yaml:"identity,omitempty"`
 }
 
 // KeyFile defines key-based signature verification.
 type KeyFile struct {
-	Path string `yaml:"path"`
-	URL  string `yaml:"url"`
+	Path string `yaml:"path,omitempty"`
+	URL  string `yaml:"url,omitempty"`
 }
 
 // ProvenanceFile defines provenance verification in a policy file.
 type ProvenanceFile struct {
-	SLSA *SLSAFile `yaml:"slsa"`
+	SLSA *SLSAFile `yaml:"slsa,omitempty"`
 }
 
 // SLSAFile defines SLSA provenance requirements.
 type SLSAFile struct {
-	Builder    string `yaml:"builder"`
-	Repository string `yaml:"repository"`
-	Branch     string `yaml:"branch"`
-	Tag        string `yaml:"tag"`
+	Builder    string `yaml:"builder,omitempty"`
+	Repository string `yaml:"repository,omitempty"`
+	Branch     string `yaml:"branch,omitempty"`
+	Tag        string `yaml:"tag,omitempty"`
 }
 
 // LoadFile loads and parses a YAML policy file.
@@ -64,6 +64,51 @@ func LoadFile(path string) (*config.Policy, error) {
 	return convertFileToConfig(&pf), nil
 }
 
+// MarshalFile encodes a config.Policy in the YAML policy file format,
+// so that it can be loaded again with LoadFile.
+func MarshalFile(p *config.Policy) ([]byte, error) {
+	data, err := yaml.Marshal(convertConfigToFile(p))
+	if err != nil {
+		return nil, fmt.Errorf("encoding policy file: %w", err)
+	}
+	return data, nil
+}
+
+// convertConfigToFile converts a config.Policy to a policy file.
+func convertConfigToFile(p *config.Policy) *File {
+	pf := &File{}
+
+	if p.Signature != nil {
+		pf.Signature = &SignatureFile{}
+		if p.Signature.Keyless != nil {
+			pf.Signature.Keyless = &KeylessFile{
+				Issuer:   p.Signature.Keyless.Issuer,
+				Identity: p.Signature.Keyless.Identity,
+			}
+		}
+		if p.Signature.Key != nil {
+			pf.Signature.Key = &KeyFile{
+				Path: p.Signature.Key.Path,
+				URL:  p.Signature.Key.URL,
+			}
+		}
+	}
+
+	if p.Provenance != nil {
+		pf.Provenance = &ProvenanceFile{}
+		if p.Provenance.SLSA != nil {
+			pf.Provenance.SLSA = &SLSAFile{
+				Builder:    p.Provenance.SLSA.Builder,
+				Repository: p.Provenance.SLSA.Repository,
+				Branch:     p.Provenance.SLSA.Branch,
+				Tag:        p.Provenance.SLSA.Tag,
+			}
+		}
+	}
+
+	return pf
+}
+
 // convertFileToConfig converts a policy file to config.Policy.
 func convertFileToConfig(pf *File) *config.Policy {
 	if pf == nil {
@@ -1,14 +1,31 @@
 package policy
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/meigma/blob/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/meigma/blob-cli/internal/config"
+	"github.com/meigma/blob-cli/internal/referrers"
+	"github.com/meigma/blob-cli/internal/retry"
+	"github.com/meigma/blob-cli/internal/warn"
 )
 
 func TestLoadFile(t *testing.T) {
@@ -290,3 +307,384 @@ provenance:
 		assert.Empty(t, policies)
 	})
 }
+
+func TestBuildNamedPolicies(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.yaml")
+	content := `
+provenance:
+  slsa:
+    repository: acme/configs
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	cfg := &config.Config{
+		Policies: []config.PolicyRule{
+			{
+				Match: "ghcr\\.io/test/.*",
+				Policy: config.Policy{
+					Provenance: &config.ProvenancePolicy{
+						SLSA: &config.SLSAConfig{Repository: "test/repo"},
+					},
+				},
+			},
+		},
+	}
+
+	policies, err := BuildNamedPolicies(cfg, "ghcr.io/test/app:v1", []string{path}, "", false)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	assert.Equal(t, "config policy (match ghcr\\.io/test/.*)", policies[0].Name)
+	assert.Equal(t, "policy file "+path, policies[1].Name)
+	assert.NotNil(t, policies[0].Policy)
+}
+
+func TestBuildNamedPolicies_TrustRoot(t *testing.T) {
+	tr, err := root.NewTrustedRoot(root.TrustedRootMediaType01, nil, nil, nil, nil)
+	require.NoError(t, err)
+	data, err := tr.MarshalJSON()
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "trusted_root.json")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	cfg := &config.Config{
+		Sigstore: config.SigstoreConfig{TrustRoot: path},
+		Policies: []config.PolicyRule{{
+			Match: ".*",
+			Policy: config.Policy{Signature: &config.SignaturePolicy{
+				Keyless: &config.KeylessConfig{Issuer: "https://issuer.internal", Identity: "ci@acme.internal"},
+			}},
+		}},
+	}
+
+	// The configured root is used, so nothing is fetched
+	policies, err := BuildNamedPolicies(cfg, "registry.internal/app:v1", nil, "", false)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+
+	cfg.Sigstore.TrustRoot = filepath.Join(t.TempDir(), "missing.json")
+	_, err = BuildNamedPolicies(cfg, "registry.internal/app:v1", nil, "", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "loading sigstore trust root")
+
+	// A root given by the caller takes precedence
+	policies, err = BuildNamedPolicies(cfg, "registry.internal/app:v1", nil, "", false, WithTrustedRoot(tr))
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+}
+
+func TestBuildNamedPolicies_TrustedRootUnavailable(t *testing.T) {
+	oldFetch, oldRetry := fetchTrustedRoot, fetchRetry
+	t.Cleanup(func() { fetchTrustedRoot, fetchRetry = oldFetch, oldRetry })
+	calls := 0
+	fetchTrustedRoot = func() (*root.TrustedRoot, error) {
+		calls++
+		return nil, errors.New("tuf-repo-cdn.sigstore.dev: connection refused")
+	}
+	fetchRetry = retry.Policy{Attempts: 3, Delay: time.Millisecond}
+
+	cfg := &config.Config{Policies: []config.PolicyRule{{
+		Match: ".*",
+		Policy: config.Policy{Signature: &config.SignaturePolicy{
+			Keyless: &config.KeylessConfig{Issuer: "https://issuer", Identity: "ci@acme"},
+		}},
+	}}}
+	// The root is fetched when the policy is first evaluated, not built
+	policies, err := BuildNamedPolicies(cfg, "ghcr.io/acme/app:v1", nil, "", false)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, 0, calls)
+
+	err = policies[0].Policy.Evaluate(context.Background(), registry.PolicyRequest{})
+	require.ErrorIs(t, err, ErrTrustedRootUnavailable)
+	assert.Equal(t, 3, calls)
+
+	// The failed fetch is not retried by later evaluations
+	err = policies[0].Policy.Evaluate(context.Background(), registry.PolicyRequest{})
+	require.ErrorIs(t, err, ErrTrustedRootUnavailable)
+	assert.Equal(t, 3, calls)
+}
+
+func TestBuildNamedPolicies_Offline(t *testing.T) {
+	oldFetch := fetchTrustedRoot
+	t.Cleanup(func() { fetchTrustedRoot = oldFetch })
+	fetchTrustedRoot = func() (*root.TrustedRoot, error) {
+		t.Fatal("trusted root fetched offline")
+		return nil, nil
+	}
+
+	cfg := &config.Config{
+		Offline: true,
+		Policies: []config.PolicyRule{{
+			Match: ".*",
+			Policy: config.Policy{Signature: &config.SignaturePolicy{
+				Keyless: &config.KeylessConfig{Issuer: "https://issuer", Identity: "ci@acme"},
+			}},
+		}},
+	}
+	policies, err := BuildNamedPolicies(cfg, "ghcr.io/acme/app:v1", nil, "", false)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+
+	err = policies[0].Policy.Evaluate(context.Background(), registry.PolicyRequest{})
+	require.ErrorIs(t, err, ErrTrustedRootUnavailable)
+	assert.Contains(t, err.Error(), "sigstore.trust_root")
+}
+
+func TestBuildNamedPolicies_EnvironmentPolicy(t *testing.T) {
+	cfg := &config.Config{
+		Policies: []config.PolicyRule{
+			{
+				Match:  ".*",
+				Source: config.EnvPolicyB64,
+				Policy: config.Policy{
+					Provenance: &config.ProvenancePolicy{
+						SLSA: &config.SLSAConfig{Repository: "acme/configs"},
+					},
+				},
+			},
+		},
+	}
+
+	policies, err := BuildNamedPolicies(cfg, "ghcr.io/acme/configs:v1", nil, "", false)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, "environment policy "+config.EnvPolicyB64, policies[0].Name)
+
+	policies, err = BuildNamedPolicies(cfg, "ghcr.io/acme/configs:v1", nil, "", true)
+	require.NoError(t, err)
+	assert.Empty(t, policies)
+}
+
+func TestMarshalFile_RoundTrip(t *testing.T) {
+	original := &config.Policy{
+		Signature: &config.SignaturePolicy{
+			Keyless: &config.KeylessConfig{
+				Issuer:   "https://token.actions.githubusercontent.com",
+				Identity: "https://github.com/acme/*",
+			},
+		},
+		Provenance: &config.ProvenancePolicy{
+			SLSA: &config.SLSAConfig{Repository: "acme/configs", Branch: "main"},
+		},
+	}
+
+	data, err := MarshalFile(original)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	loaded, err := LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, loaded)
+}
+
+func TestBuildNamedPolicies_WarnMode(t *testing.T) {
+	cfg := &config.Config{
+		Policies: []config.PolicyRule{
+			{
+				Match: ".*",
+				Mode:  config.PolicyModeWarn,
+				Policy: config.Policy{
+					Provenance: &config.ProvenancePolicy{
+						SLSA: &config.SLSAConfig{Repository: "acme/configs"},
+					},
+				},
+			},
+		},
+	}
+
+	policies, err := BuildNamedPolicies(cfg, "ghcr.io/acme/configs:v1", nil, "", false)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.True(t, policies[0].Warn)
+}
+
+func TestWarnOnFailure(t *testing.T) {
+	warn.Reset()
+	warn.SetQuiet(true)
+	t.Cleanup(warn.Reset)
+
+	failing := NamedPolicy{
+		Name: "config policy (match .*)",
+		Policy: registry.PolicyFunc(func(context.Context, registry.PolicyRequest) error {
+			return errors.New("no provenance")
+		}),
+		Warn: true,
+	}
+	p := warnOnFailure(failing, "ghcr.io/acme/configs:v1")
+	require.NoError(t, p.Evaluate(context.Background(), registry.PolicyRequest{}))
+	assert.Equal(t, 1, warn.Count())
+}
+
+// cosignClient serves one cosign signature manifest as a referrer.
+type cosignClient struct {
+	sig   ocispec.Descriptor
+	blobs map[digest.Digest][]byte
+}
+
+func (c *cosignClient) Referrers(_ context.Context, _ string, _ ocispec.Descriptor, artifactType string) ([]ocispec.Descriptor, error) {
+	if artifactType != referrers.CosignSignatureArtifactType || c.sig.Digest == "" {
+		return nil, nil
+	}
+	return []ocispec.Descriptor{c.sig}, nil
+}
+
+func (c *cosignClient) FetchDescriptor(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	data, ok := c.blobs[desc.Digest]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+// signCosign signs subject the way cosign does for keyless signing and
+// returns a client serving the signature manifest.
+func signCosign(t *testing.T, vs *ca.VirtualSigstore, identity, issuer string, subject digest.Digest) *cosignClient {
+	t.Helper()
+	payload := []byte(`{"critical":{"identity":{"docker-reference":"reg.io/acme/configs"},` +
+		`"image":{"docker-manifest-digest":"` + subject.String() + `"},"type":"cosign container image signature"},"optional":null}`)
+	entity, err := vs.Sign(identity, issuer, payload)
+	require.NoError(t, err)
+
+	content, err := entity.VerificationContent()
+	require.NoError(t, err)
+	certPEM, err := cryptoutils.MarshalCertificateToPEM(content.(*bundle.Certificate).Certificate())
+	require.NoError(t, err)
+	sigContent, err := entity.SignatureContent()
+	require.NoError(t, err)
+	entries, err := entity.TlogEntries()
+	require.NoError(t, err)
+	tle := entries[0].TransparencyLogEntry()
+	rekorPayload := tlog.RekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(tle.GetCanonicalizedBody()),
+		IntegratedTime: tle.GetIntegratedTime(),
+		LogIndex:       tle.GetLogIndex(),
+		LogID:          hex.EncodeToString(tle.GetLogId().GetKeyId()),
+	}
+	set, err := vs.RekorSignPayload(rekorPayload)
+	require.NoError(t, err)
+	rekorBundle, err := json.Marshal(map[string]any{"SignedEntryTimestamp": set, "Payload": rekorPayload})
+	require.NoError(t, err)
+
+	layer := ocispec.Descriptor{
+		MediaType: cosignPayloadMediaType,
+		Digest:    digest.FromBytes(payload),
+		Size:      int64(len(payload)),
+		Annotations: map[string]string{
+			cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sigContent.MessageSignatureContent().Signature()),
+			cosignCertAnnotation:      string(certPEM),
+			cosignBundleAnnotation:    string(rekorBundle),
+		},
+	}
+	manifest, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.DescriptorEmptyJSON,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	require.NoError(t, err)
+	return &cosignClient{
+		sig: ocispec.Descriptor{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			ArtifactType: referrers.CosignSignatureArtifactType,
+			Digest:       digest.FromBytes(manifest),
+			Size:         int64(len(manifest)),
+		},
+		blobs: map[digest.Digest][]byte{
+			digest.FromBytes(manifest): manifest,
+			layer.Digest:               payload,
+		},
+	}
+}
+
+func TestCosignPolicy(t *testing.T) {
+	const (
+		issuer   = "https://token.actions.githubusercontent.com"
+		identity = "https://github.com/acme/configs/.github/workflows/release.yml@refs/heads/main"
+	)
+	vs, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	subject := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("archive"), Size: 7}
+	request := func(client registry.PolicyClient) registry.PolicyRequest {
+		return registry.PolicyRequest{
+			Ref:     "reg.io/acme/configs:v1",
+			Digest:  subject.Digest.String(),
+			Subject: subject,
+			Client:  client,
+		}
+	}
+
+	p, err := newCosignPolicy(vs, issuer, identity)
+	require.NoError(t, err)
+
+	t.Run("valid signature", func(t *testing.T) {
+		client := signCosign(t, vs, identity, issuer, subject.Digest)
+		require.NoError(t, p.Evaluate(t.Context(), request(client)))
+	})
+
+	t.Run("wrong identity", func(t *testing.T) {
+		client := signCosign(t, vs, "https://github.com/evil/repo/.github/workflows/x.yml@refs/heads/main", issuer, subject.Digest)
+		require.ErrorContains(t, p.Evaluate(t.Context(), request(client)), "cosign: signature invalid")
+	})
+
+	t.Run("signature for another manifest", func(t *testing.T) {
+		client := signCosign(t, vs, identity, issuer, digest.FromString("other"))
+		require.ErrorContains(t, p.Evaluate(t.Context(), request(client)), "signature is for")
+	})
+
+	t.Run("untrusted signer", func(t *testing.T) {
+		other, err := ca.NewVirtualSigstore()
+		require.NoError(t, err)
+		client := signCosign(t, other, identity, issuer, subject.Digest)
+		require.ErrorContains(t, p.Evaluate(t.Context(), request(client)), "cosign: signature invalid")
+	})
+
+	t.Run("no signatures", func(t *testing.T) {
+		require.ErrorIs(t, p.Evaluate(t.Context(), request(&cosignClient{})), errNoCosignSignatures)
+	})
+
+	t.Run("either kind of signature passes", func(t *testing.T) {
+		failing := registry.PolicyFunc(func(context.Context, registry.PolicyRequest) error {
+			return errors.New("sigstore: no signatures found for manifest")
+		})
+		client := signCosign(t, vs, identity, issuer, subject.Digest)
+		require.NoError(t, orCosign(failing, p).Evaluate(t.Context(), request(client)))
+
+		// Without cosign signatures the bundle policy's error stands alone
+		err := orCosign(failing, p).Evaluate(t.Context(), request(&cosignClient{}))
+		require.EqualError(t, err, "sigstore: no signatures found for manifest")
+	})
+}
+
+func TestBuildRules(t *testing.T) {
+	tr, err := root.NewTrustedRoot(root.TrustedRootMediaType01, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	both := filepath.Join(dir, "both.yaml")
+	require.NoError(t, os.WriteFile(both, []byte(`signature:
+  keyless:
+    issuer: https://token.actions.githubusercontent.com
+    identity: https://github.com/acme/configs/.github/workflows/release.yml@refs/heads/main
+provenance:
+  slsa:
+    builder: https://github.com/actions/runner
+`), 0o644))
+	provenance := filepath.Join(dir, "provenance.yaml")
+	require.NoError(t, os.WriteFile(provenance, []byte("provenance:\n  slsa:\n    builder: https://github.com/actions/runner\n"), 0o644))
+
+	rules, err := BuildRules(nil, []string{both, provenance}, "", WithTrustedRoot(tr))
+	require.NoError(t, err)
+	names := make([]string, len(rules))
+	for i, r := range rules {
+		names[i] = r.Name
+	}
+	assert.Equal(t, []string{both + ": signature", both + ": provenance", provenance + ": provenance"}, names)
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("provenance:\n  slsa: {}\n"), 0o644))
+	_, err = BuildRules(nil, []string{invalid}, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "provenance policy")
+}
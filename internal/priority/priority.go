@@ -0,0 +1,103 @@
+// Package priority implements the annotation convention for declaring
+// which files of an archive to extract first.
+//
+// An archive records its extraction priority in the manifest annotation
+// io.meigma.blob.priority as a comma-separated list of glob patterns, most
+// important first:
+//
+//	io.meigma.blob.priority: etc/app.conf,etc/*.yaml,certs
+//
+// A pattern is matched against the path of a file within the archive and
+// against each of its parent directories, so a directory name covers
+// everything under it. pull extracts the files matching each pattern in
+// the order the patterns are listed, before any other file, so a service
+// can start once its configuration is in place while large auxiliary data
+// is still being extracted.
+package priority
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Annotation is the manifest annotation holding an archive's extraction
+// priority.
+const Annotation = "io.meigma.blob.priority"
+
+// ParsePattern validates a pattern and returns it in canonical form,
+// without leading or trailing slashes.
+func ParsePattern(s string) (string, error) {
+	p := strings.Trim(strings.TrimSpace(s), "/")
+	if p == "" || strings.Contains(p, ",") {
+		return "", fmt.Errorf("invalid priority pattern %q", s)
+	}
+	if _, err := path.Match(p, ""); err != nil {
+		return "", fmt.Errorf("invalid priority pattern %q: %w", s, err)
+	}
+	return p, nil
+}
+
+// Parse parses an annotation value. Patterns keep their order.
+func Parse(value string) ([]string, error) {
+	var patterns []string
+	for entry := range strings.SplitSeq(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		p, err := ParsePattern(entry)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// Format returns the annotation value for patterns.
+func Format(patterns []string) string {
+	return strings.Join(patterns, ",")
+}
+
+// FromAnnotations returns the patterns declared in manifest annotations,
+// or nil if there are none.
+func FromAnnotations(annotations map[string]string) ([]string, error) {
+	value, ok := annotations[Annotation]
+	if !ok {
+		return nil, nil
+	}
+	patterns, err := Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("annotation %s: %w", Annotation, err)
+	}
+	return patterns, nil
+}
+
+// Rank returns the index of the first pattern matching name, a path within
+// the archive, or -1 if none does.
+func Rank(patterns []string, name string) int {
+	name = strings.TrimPrefix(name, "/")
+	for i, p := range patterns {
+		for dir := name; dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if ok, _ := path.Match(p, dir); ok { //nolint:errcheck // patterns are validated by Parse
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Order splits names into one batch per pattern, in pattern order, and
+// the names no pattern matches. A name goes in the batch of the first
+// pattern it matches; batches keep the order of names and may be empty.
+func Order(patterns, names []string) (batches [][]string, rest []string) {
+	batches = make([][]string, len(patterns))
+	for _, name := range names {
+		if i := Rank(patterns, name); i >= 0 {
+			batches[i] = append(batches[i], name)
+		} else {
+			rest = append(rest, name)
+		}
+	}
+	return batches, rest
+}
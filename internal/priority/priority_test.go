@@ -0,0 +1,73 @@
+package priority
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "order kept", value: "etc/app.conf, /certs/ ,etc/*.yaml", want: []string{"etc/app.conf", "certs", "etc/*.yaml"}},
+		{name: "empty entries skipped", value: "etc,,", want: []string{"etc"}},
+		{name: "bad pattern", value: "etc/[", wantErr: true},
+		{name: "only slashes", value: "/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, got, mustParse(t, Format(got)), "round trip")
+		})
+	}
+}
+
+func TestFromAnnotations(t *testing.T) {
+	patterns, err := FromAnnotations(map[string]string{"other": "x"})
+	require.NoError(t, err)
+	assert.Nil(t, patterns)
+
+	patterns, err = FromAnnotations(map[string]string{Annotation: "etc"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"etc"}, patterns)
+
+	_, err = FromAnnotations(map[string]string{Annotation: "["})
+	require.ErrorContains(t, err, Annotation)
+}
+
+func TestRank(t *testing.T) {
+	patterns := []string{"etc/app.conf", "etc/*.yaml", "certs"}
+	assert.Equal(t, 0, Rank(patterns, "/etc/app.conf"))
+	assert.Equal(t, 1, Rank(patterns, "etc/db.yaml"))
+	assert.Equal(t, 2, Rank(patterns, "certs/ca/root.pem"), "a directory covers its files")
+	assert.Equal(t, -1, Rank(patterns, "etc/nested/db.yaml"))
+	assert.Equal(t, -1, Rank(patterns, "data/blob.bin"))
+}
+
+func TestOrder(t *testing.T) {
+	batches, rest := Order(
+		[]string{"etc/*", "etc/app.conf", "missing"},
+		[]string{"data/a.bin", "etc/app.conf", "etc/db.conf", "data/b.bin"},
+	)
+	assert.Equal(t, [][]string{{"etc/app.conf", "etc/db.conf"}, nil, nil}, batches, "the first matching pattern wins")
+	assert.Equal(t, []string{"data/a.bin", "data/b.bin"}, rest)
+}
+
+func mustParse(t *testing.T, value string) []string {
+	t.Helper()
+	patterns, err := Parse(value)
+	require.NoError(t, err)
+	return patterns
+}
@@ -0,0 +1,144 @@
+// Package proxy serves archive files over a small local HTTP API so that
+// applications without an OCI client can read individual files.
+//
+// Files are served at:
+//
+//	GET /v1/archives/{ref}/files/{path...}
+//
+// The reference must be a single path segment, so slashes in it are escaped
+// as %2F (aliases usually avoid this). When the archive reports content
+// hashes, the response carries the file's SHA-256 as its ETag and honors
+// If-None-Match.
+package proxy
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// Errors an OpenFunc wraps to select the response status.
+var (
+	// ErrNotFound indicates the archive does not exist (404).
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden indicates the archive failed verification (403).
+	ErrForbidden = errors.New("forbidden")
+	// ErrBadRequest indicates a malformed reference or path (400).
+	ErrBadRequest = errors.New("bad request")
+)
+
+// OpenFunc opens the archive at ref.
+type OpenFunc func(ctx context.Context, ref string) (fs.FS, error)
+
+// Hasher is implemented by archives that can report a file's SHA-256
+// without reading it. It is used for the ETag header.
+type Hasher interface {
+	FileHash(name string) ([]byte, bool)
+}
+
+// NewHandler returns the HTTP handler for the proxy API. Requests are
+// logged to logger at debug level; a nil logger discards them.
+func NewHandler(open OpenFunc, logger *slog.Logger) http.Handler {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	h := &handler{open: open, log: logger}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/archives/{ref}/files/{path...}", h.serveFile)
+	return mux
+}
+
+type handler struct {
+	open OpenFunc
+	log  *slog.Logger
+}
+
+func (h *handler) serveFile(w http.ResponseWriter, r *http.Request) {
+	ref := r.PathValue("ref")
+	name := r.PathValue("path")
+	h.log.Debug("proxy request", "ref", ref, "path", name)
+
+	if !fs.ValidPath(name) || name == "." {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid file path %q", name))
+		return
+	}
+
+	fsys, err := h.open(r.Context(), ref)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		f.Close()
+		if err == nil {
+			err = fmt.Errorf("%w: %s is a directory", ErrBadRequest, name)
+		}
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	header := w.Header()
+	if hasher, ok := fsys.(Hasher); ok {
+		if hash, ok := hasher.FileHash(name); ok {
+			etag := `"sha256:` + hex.EncodeToString(hash) + `"`
+			header.Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				f.Close()
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+	}
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+
+	if _, err := io.Copy(w, f); err != nil {
+		f.Close()
+		h.log.Warn("proxy copy failed", "ref", ref, "path", name, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+	// Content is verified on close. The status has already been sent, so a
+	// mismatch aborts the connection to keep the client from accepting a
+	// corrupt body as complete.
+	if err := f.Close(); err != nil {
+		h.log.Warn("proxy verification failed", "ref", ref, "path", name, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// statusFor maps an open error to a response status.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrBadRequest), errors.Is(err, fs.ErrInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	//nolint:errcheck // the client may have gone away
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
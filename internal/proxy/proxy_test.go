@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hashedFS adds content hashes to a MapFS.
+type hashedFS struct {
+	fstest.MapFS
+}
+
+func (h hashedFS) FileHash(name string) ([]byte, bool) {
+	f, ok := h.MapFS[name]
+	if !ok {
+		return nil, false
+	}
+	sum := sha256.Sum256(f.Data)
+	return sum[:], true
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	archives := map[string]fs.FS{
+		"ghcr.io/acme/configs:v1": hashedFS{fstest.MapFS{
+			"app/config.yaml": {Data: []byte("port: 8080\n")},
+		}},
+	}
+	open := func(_ context.Context, ref string) (fs.FS, error) {
+		switch ref {
+		case "denied:v1":
+			return nil, fmt.Errorf("%w: signature missing", ErrForbidden)
+		case "bad ref":
+			return nil, fmt.Errorf("%w: invalid reference", ErrBadRequest)
+		}
+		fsys, ok := archives[ref]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
+		}
+		return fsys, nil
+	}
+	srv := httptest.NewServer(NewHandler(open, nil))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// get requests path from srv with optional If-None-Match.
+func get(t *testing.T, srv *httptest.Server, path, ifNoneMatch string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL+path, http.NoBody)
+	require.NoError(t, err)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServeFile(t *testing.T) {
+	srv := newTestServer(t)
+	const path = "/v1/archives/ghcr.io%2Facme%2Fconfigs:v1/files/app/config.yaml"
+
+	resp := get(t, srv, path, "")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	sum := sha256.Sum256([]byte("port: 8080\n"))
+	etag := fmt.Sprintf(`"sha256:%x"`, sum)
+	assert.Equal(t, etag, resp.Header.Get("ETag"))
+	assert.Equal(t, "port: 8080\n", string(body))
+
+	cached := get(t, srv, path, etag)
+	assert.Equal(t, http.StatusNotModified, cached.StatusCode)
+}
+
+func TestServeFile_Errors(t *testing.T) {
+	srv := newTestServer(t)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"missing archive", "/v1/archives/ghcr.io%2Facme%2Fother:v1/files/a.txt", http.StatusNotFound},
+		{"missing file", "/v1/archives/ghcr.io%2Facme%2Fconfigs:v1/files/nope.txt", http.StatusNotFound},
+		{"directory", "/v1/archives/ghcr.io%2Facme%2Fconfigs:v1/files/app", http.StatusBadRequest},
+		{"policy violation", "/v1/archives/denied:v1/files/a.txt", http.StatusForbidden},
+		{"bad reference", "/v1/archives/bad%20ref/files/a.txt", http.StatusBadRequest},
+		{"unknown route", "/v1/archives/denied:v1", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := get(t, srv, tt.path, "")
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+}
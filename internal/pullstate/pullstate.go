@@ -0,0 +1,87 @@
+// Package pullstate records where a directory written by blob pull came
+// from, so blob status can later compare it with the registry and with
+// the files on disk.
+//
+// The state is kept in a JSON file named .blob-state at the top of the
+// destination directory. It holds the reference pulled, the manifest
+// digest it resolved to, when the pull ran, and the path and SHA-256 of
+// every file the archive placed in the directory. A later pull into the
+// same directory replaces it.
+package pullstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the name of the state file within a pulled directory.
+const FileName = ".blob-state"
+
+// ErrNoState is returned by Read when a directory has no state file.
+var ErrNoState = errors.New("no " + FileName + " file")
+
+// File is a file the archive placed in the directory.
+type File struct {
+	Path   string `json:"path"`   // Slash-separated, relative to the directory
+	Digest string `json:"digest"` // SHA-256 of the content, as sha256:<hex>
+}
+
+// State describes the pull that wrote a directory.
+type State struct {
+	Ref             string    `json:"ref"`
+	ResolvedRef     string    `json:"resolved_ref"`
+	Digest          string    `json:"digest"`
+	PulledAt        time.Time `json:"pulled_at"`
+	Prefix          string    `json:"prefix,omitempty"`
+	StripComponents int       `json:"strip_components,omitempty"`
+	Files           []File    `json:"files"`
+}
+
+// Path returns the path of the state file of dir.
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Write writes state to the state file of dir, replacing it atomically.
+func Write(dir string, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding pull state: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, FileName+"-*")
+	if err != nil {
+		return fmt.Errorf("writing pull state: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // gone after a successful rename
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing pull state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing pull state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), Path(dir)); err != nil {
+		return fmt.Errorf("writing pull state: %w", err)
+	}
+	return nil
+}
+
+// Read reads the state file of dir. It returns ErrNoState if there is none.
+func Read(dir string) (*State, error) {
+	data, err := os.ReadFile(Path(dir)) //nolint:gosec // dir is given by the user
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoState
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading pull state: %w", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", Path(dir), err)
+	}
+	return &state, nil
+}
@@ -0,0 +1,46 @@
+package pullstate
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRead(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Read(dir)
+	require.ErrorIs(t, err, ErrNoState)
+
+	state := &State{
+		Ref:         "foo:v1",
+		ResolvedRef: "ghcr.io/acme/foo:v1",
+		Digest:      "sha256:abc",
+		PulledAt:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Files:       []File{{Path: "etc/app.conf", Digest: "sha256:def"}},
+	}
+	require.NoError(t, Write(dir, state))
+	got, err := Read(dir)
+	require.NoError(t, err)
+	assert.Equal(t, state, got)
+
+	// A second pull replaces the state and leaves no temporary files
+	state.Digest = "sha256:123"
+	require.NoError(t, Write(dir, state))
+	got, err = Read(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:123", got.Digest)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestRead_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(Path(dir), []byte("{"), 0o600))
+	_, err := Read(dir)
+	require.ErrorContains(t, err, "parsing")
+}
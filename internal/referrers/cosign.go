@@ -0,0 +1,41 @@
+package referrers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// CosignSignatureArtifactType is the artifact type of cosign signatures
+// stored as referrers, which Fallback also gives the signatures cosign
+// stores under a ".sig" tag.
+const CosignSignatureArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
+
+// CosignTag returns the tag under which cosign stores the signatures of
+// subject when it does not use referrers.
+func CosignTag(subject digest.Digest) string {
+	return Tag(subject) + ".sig"
+}
+
+// CosignSignatures returns the manifest tagged with the cosign signatures
+// of subject in repo, as a referrer of CosignSignatureArtifactType. A
+// missing tag means no signatures.
+func CosignSignatures(ctx context.Context, repo *remote.Repository, subject digest.Digest) ([]ocispec.Descriptor, error) {
+	if err := subject.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid subject digest: %w", err)
+	}
+	desc, err := repo.Resolve(ctx, CosignTag(subject))
+	if errors.Is(err, errdef.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolving cosign signature tag %s: %w", CosignTag(subject), err)
+	}
+	desc.ArtifactType = CosignSignatureArtifactType
+	return []ocispec.Descriptor{desc}, nil
+}
@@ -0,0 +1,106 @@
+package referrers
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/meigma/blob/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// RepositoryFunc opens the repository of an archive reference.
+type RepositoryFunc func(ref string) (*remote.Repository, error)
+
+// Fallback adds referrers from the tag index to the referrers policies
+// see, so signatures and attestations published on registries without
+// the referrers API are found, along with signatures under cosign's
+// ".sig" tag. Wrap each policy with Fallback.Wrap before creating the
+// client.
+type Fallback struct {
+	open RepositoryFunc
+
+	mu     sync.Mutex
+	tagged map[string][]ocispec.Descriptor
+}
+
+// NewFallback returns a fallback that reads tag indexes from the
+// repositories open returns.
+func NewFallback(open RepositoryFunc) *Fallback {
+	return &Fallback{open: open, tagged: make(map[string][]ocispec.Descriptor)}
+}
+
+// Wrap returns a policy that evaluates p against a client that also lists
+// referrers from the tag index.
+func (f *Fallback) Wrap(p registry.Policy) registry.Policy {
+	return registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+		req.Client = &fallbackClient{inner: req.Client, f: f}
+		return p.Evaluate(ctx, req)
+	})
+}
+
+// byTag returns every referrer in the tag index of subject and its cosign
+// signature tag, reading them once per repository and subject.
+func (f *Fallback) byTag(ctx context.Context, ref string, subject ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	repo, err := f.open(ref)
+	if err != nil {
+		return nil, err
+	}
+	key := repo.Reference.Registry + "/" + repo.Reference.Repository + "@" + subject.Digest.String()
+	if descs, ok := f.tagged[key]; ok {
+		return descs, nil
+	}
+	descs, err := ByTag(ctx, repo, subject.Digest, "")
+	if err != nil {
+		return nil, err
+	}
+	sigs, err := CosignSignatures(ctx, repo, subject.Digest)
+	if err != nil {
+		return nil, err
+	}
+	descs = Merge(descs, sigs)
+	f.tagged[key] = descs
+	return descs, nil
+}
+
+// fallbackClient forwards to the registry, adding referrers from the tag
+// index to the referrers API's answer.
+type fallbackClient struct {
+	inner registry.PolicyClient
+	f     *Fallback
+}
+
+func (c *fallbackClient) Referrers(
+	ctx context.Context,
+	ref string,
+	subject ocispec.Descriptor,
+	artifactType string,
+) ([]ocispec.Descriptor, error) {
+	descs, err := c.inner.Referrers(ctx, ref, subject, artifactType)
+	if err != nil && !errors.Is(err, registry.ErrReferrersUnsupported) {
+		return descs, err
+	}
+	tagged, tagErr := c.f.byTag(ctx, ref, subject)
+	if tagErr != nil {
+		if err != nil {
+			return descs, err
+		}
+		return descs, tagErr
+	}
+	for _, d := range tagged {
+		if artifactType == "" || d.ArtifactType == artifactType {
+			descs = Merge(descs, []ocispec.Descriptor{d})
+		}
+	}
+	if err != nil && len(descs) == 0 {
+		return nil, err
+	}
+	return descs, nil
+}
+
+func (c *fallbackClient) FetchDescriptor(ctx context.Context, ref string, desc ocispec.Descriptor) ([]byte, error) {
+	return c.inner.FetchDescriptor(ctx, ref, desc)
+}
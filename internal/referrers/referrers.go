@@ -0,0 +1,110 @@
+// Package referrers finds OCI referrers indexed under the referrers tag
+// schema, the fallback for registries without the referrers API: the
+// referrers of a manifest are listed in an image index tagged
+// "<alg>-<hex>" after the manifest's digest.
+//
+// Publishing needs no help, since oras-go maintains the tag index when a
+// registry does not acknowledge a pushed subject. Discovery does: a
+// registry may answer the referrers API without indexing those pushes, so
+// the API and the tag index are both consulted and merged.
+package referrers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// maxIndexSize bounds the tag index read for one subject.
+const maxIndexSize = 4 << 20
+
+var tagPattern = regexp.MustCompile(`^(sha256-[0-9a-f]{64}|sha512-[0-9a-f]{128})$`)
+
+// Tag returns the tag under which the referrers of subject are indexed.
+func Tag(subject digest.Digest) string {
+	return subject.Algorithm().String() + "-" + subject.Encoded()
+}
+
+// IsTag reports whether tag has the form of a referrers tag rather than a
+// tag someone chose for an archive.
+func IsTag(tag string) bool {
+	return tagPattern.MatchString(tag)
+}
+
+// ByTag returns the referrers of subject listed in its tag index in repo,
+// keeping those of artifactType if it is not empty. A missing tag index
+// means no referrers.
+func ByTag(ctx context.Context, repo *remote.Repository, subject digest.Digest, artifactType string) ([]ocispec.Descriptor, error) {
+	if err := subject.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid subject digest: %w", err)
+	}
+	desc, rc, err := repo.Manifests().FetchReference(ctx, Tag(subject))
+	if errors.Is(err, errdef.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching referrers tag %s: %w", Tag(subject), err)
+	}
+	defer rc.Close()
+
+	if desc.MediaType != ocispec.MediaTypeImageIndex {
+		return nil, fmt.Errorf("referrers tag %s is a %s, not an image index", Tag(subject), desc.MediaType)
+	}
+	if desc.Size > maxIndexSize {
+		return nil, fmt.Errorf("referrers tag %s is too large (%d bytes)", Tag(subject), desc.Size)
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, maxIndexSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading referrers tag %s: %w", Tag(subject), err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("parsing referrers tag %s: %w", Tag(subject), err)
+	}
+
+	var descs []ocispec.Descriptor
+	for _, m := range index.Manifests {
+		if artifactType == "" || m.ArtifactType == artifactType {
+			descs = append(descs, m)
+		}
+	}
+	return descs, nil
+}
+
+// List returns the referrers of subject in repo from both the referrers
+// API and the tag index, without duplicates. A registry rejecting the
+// referrers API is not an error.
+func List(ctx context.Context, repo *remote.Repository, subject ocispec.Descriptor, artifactType string) ([]ocispec.Descriptor, error) {
+	var descs []ocispec.Descriptor
+	err := repo.Referrers(ctx, subject, artifactType, func(page []ocispec.Descriptor) error {
+		descs = append(descs, page...)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errdef.ErrUnsupported) {
+		return nil, fmt.Errorf("listing referrers of %s: %w", subject.Digest, err)
+	}
+	tagged, err := ByTag(ctx, repo, subject.Digest, artifactType)
+	if err != nil {
+		return nil, err
+	}
+	return Merge(descs, tagged), nil
+}
+
+// Merge appends the descriptors of extra not already in descs.
+func Merge(descs, extra []ocispec.Descriptor) []ocispec.Descriptor {
+	for _, d := range extra {
+		if !slices.ContainsFunc(descs, func(e ocispec.Descriptor) bool { return e.Digest == d.Digest }) {
+			descs = append(descs, d)
+		}
+	}
+	return descs
+}
@@ -0,0 +1,221 @@
+package referrers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/meigma/blob/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+const (
+	sigType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+	attType = "application/vnd.in-toto+json"
+)
+
+var subject = ocispec.Descriptor{
+	MediaType: ocispec.MediaTypeImageManifest,
+	Digest:    digest.FromString("subject"),
+	Size:      7,
+}
+
+func referrer(name, artifactType string) ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Digest:       digest.FromString(name),
+		Size:         int64(len(name)),
+	}
+}
+
+// newTestRepository serves acme/configs with the given referrers API
+// answer (nil for a registry without the API) and tag index.
+func newTestRepository(t *testing.T, api, tagged []ocispec.Descriptor) *remote.Repository {
+	t.Helper()
+	writeIndex := func(w http.ResponseWriter, descs []ocispec.Descriptor) {
+		index := ocispec.Index{MediaType: ocispec.MediaTypeImageIndex, Manifests: descs}
+		index.SchemaVersion = 2
+		data, err := json.Marshal(index)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(data).String())
+		w.Write(data) //nolint:errcheck // test server
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/acme/configs/referrers/" + subject.Digest.String():
+			if api == nil {
+				http.NotFound(w, r)
+				return
+			}
+			writeIndex(w, api)
+		case "/v2/acme/configs/manifests/" + Tag(subject.Digest):
+			if tagged == nil {
+				http.NotFound(w, r)
+				return
+			}
+			writeIndex(w, tagged)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	repo, err := remote.NewRepository(strings.TrimPrefix(srv.URL, "http://") + "/acme/configs")
+	require.NoError(t, err)
+	repo.PlainHTTP = true
+	return repo
+}
+
+func TestTag(t *testing.T) {
+	d := digest.FromString("subject")
+	assert.Equal(t, "sha256-"+d.Encoded(), Tag(d))
+	assert.True(t, IsTag(Tag(d)))
+
+	assert.False(t, IsTag("v1.0.0"))
+	assert.False(t, IsTag("sha256-abc"))
+	assert.False(t, IsTag("sha256-"+d.Encoded()+".sig"))
+}
+
+func TestByTag(t *testing.T) {
+	sig := referrer("sig", sigType)
+	att := referrer("att", attType)
+	repo := newTestRepository(t, nil, []ocispec.Descriptor{sig, att})
+
+	descs, err := ByTag(t.Context(), repo, subject.Digest, "")
+	require.NoError(t, err)
+	assert.Equal(t, []ocispec.Descriptor{sig, att}, descs)
+
+	descs, err = ByTag(t.Context(), repo, subject.Digest, attType)
+	require.NoError(t, err)
+	assert.Equal(t, []ocispec.Descriptor{att}, descs)
+
+	descs, err = ByTag(t.Context(), newTestRepository(t, nil, nil), subject.Digest, "")
+	require.NoError(t, err)
+	assert.Empty(t, descs)
+}
+
+func TestList(t *testing.T) {
+	sig := referrer("sig", sigType)
+	att := referrer("att", attType)
+
+	t.Run("merges API and tag index", func(t *testing.T) {
+		repo := newTestRepository(t, []ocispec.Descriptor{sig}, []ocispec.Descriptor{sig, att})
+		descs, err := List(t.Context(), repo, subject, "")
+		require.NoError(t, err)
+		assert.Equal(t, []ocispec.Descriptor{sig, att}, descs)
+	})
+
+	t.Run("registry without the API", func(t *testing.T) {
+		repo := newTestRepository(t, nil, []ocispec.Descriptor{sig, att})
+		descs, err := List(t.Context(), repo, subject, sigType)
+		require.NoError(t, err)
+		assert.Equal(t, []ocispec.Descriptor{sig}, descs)
+	})
+}
+
+// stubClient answers referrer listings with fixed results.
+type stubClient struct {
+	descs []ocispec.Descriptor
+	err   error
+}
+
+func (c *stubClient) Referrers(context.Context, string, ocispec.Descriptor, string) ([]ocispec.Descriptor, error) {
+	return c.descs, c.err
+}
+
+func (c *stubClient) FetchDescriptor(context.Context, string, ocispec.Descriptor) ([]byte, error) {
+	return nil, errors.New("not found")
+}
+
+func TestFallback(t *testing.T) {
+	sig := referrer("sig", sigType)
+	att := referrer("att", attType)
+	repo := newTestRepository(t, nil, []ocispec.Descriptor{sig, att})
+	fallback := NewFallback(func(string) (*remote.Repository, error) {
+		return repo, nil
+	})
+
+	evaluate := func(inner registry.PolicyClient) ([]ocispec.Descriptor, error) {
+		var descs []ocispec.Descriptor
+		var err error
+		p := fallback.Wrap(registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+			descs, err = req.Client.Referrers(ctx, req.Ref, req.Subject, sigType)
+			return nil
+		}))
+		require.NoError(t, p.Evaluate(t.Context(), registry.PolicyRequest{Ref: "acme/configs:v1", Subject: subject, Client: inner}))
+		return descs, err
+	}
+
+	t.Run("unsupported API", func(t *testing.T) {
+		descs, err := evaluate(&stubClient{err: registry.ErrReferrersUnsupported})
+		require.NoError(t, err)
+		assert.Equal(t, []ocispec.Descriptor{sig}, descs)
+	})
+
+	t.Run("merges with API results", func(t *testing.T) {
+		other := referrer("other", sigType)
+		descs, err := evaluate(&stubClient{descs: []ocispec.Descriptor{other, sig}})
+		require.NoError(t, err)
+		assert.Equal(t, []ocispec.Descriptor{other, sig}, descs)
+	})
+
+	t.Run("other errors pass through", func(t *testing.T) {
+		_, err := evaluate(&stubClient{err: errors.New("boom")})
+		require.EqualError(t, err, "boom")
+	})
+}
+
+func TestCosignSignatures(t *testing.T) {
+	sigManifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","layers":[]}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/acme/configs/manifests/"+CosignTag(subject.Digest) {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(sigManifest).String())
+		w.Write(sigManifest) //nolint:errcheck // test server
+	}))
+	t.Cleanup(srv.Close)
+	repo, err := remote.NewRepository(strings.TrimPrefix(srv.URL, "http://") + "/acme/configs")
+	require.NoError(t, err)
+	repo.PlainHTTP = true
+
+	assert.Equal(t, "sha256-"+subject.Digest.Encoded()+".sig", CosignTag(subject.Digest))
+
+	descs, err := CosignSignatures(t.Context(), repo, subject.Digest)
+	require.NoError(t, err)
+	require.Len(t, descs, 1)
+	assert.Equal(t, digest.FromBytes(sigManifest), descs[0].Digest)
+	assert.Equal(t, CosignSignatureArtifactType, descs[0].ArtifactType)
+
+	descs, err = CosignSignatures(t.Context(), repo, digest.FromString("unsigned"))
+	require.NoError(t, err)
+	assert.Empty(t, descs)
+
+	// Fallback offers the signature tag to policies asking for cosign signatures
+	fallback := NewFallback(func(string) (*remote.Repository, error) {
+		return repo, nil
+	})
+	p := fallback.Wrap(registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+		descs, err = req.Client.Referrers(ctx, req.Ref, req.Subject, CosignSignatureArtifactType)
+		return err
+	}))
+	require.NoError(t, p.Evaluate(t.Context(), registry.PolicyRequest{
+		Ref:     "acme/configs:v1",
+		Subject: subject,
+		Client:  &stubClient{err: registry.ErrReferrersUnsupported},
+	}))
+	require.Len(t, descs, 1)
+	assert.Equal(t, digest.FromBytes(sigManifest), descs[0].Digest)
+}
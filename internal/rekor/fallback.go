@@ -0,0 +1,150 @@
+package rekor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/meigma/blob/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// AnnotationUUID records the Rekor entry a fallback referrer came from.
+const AnnotationUUID = "dev.sigstore.rekor.uuid"
+
+// maxEntries bounds how many entries are fetched for one digest. Anyone
+// can add entries to the log, so a digest may have many unrelated ones.
+const maxEntries = 20
+
+// Fallback serves signatures found in Rekor to policies when the registry
+// has none for a subject. Wrap each policy with Fallback.Wrap before
+// creating the client.
+type Fallback struct {
+	client *Client
+
+	mu       sync.Mutex
+	searched map[digest.Digest][]ocispec.Descriptor
+	bundles  map[digest.Digest][]byte
+	skipped  []error
+}
+
+// NewFallback returns a fallback that searches the Rekor instance c.
+func NewFallback(c *Client) *Fallback {
+	return &Fallback{
+		client:   c,
+		searched: make(map[digest.Digest][]ocispec.Descriptor),
+		bundles:  make(map[digest.Digest][]byte),
+	}
+}
+
+// Wrap returns a policy that evaluates p against a client falling back to
+// Rekor for Sigstore signatures.
+func (f *Fallback) Wrap(p registry.Policy) registry.Policy {
+	return registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+		req.Client = &fallbackClient{inner: req.Client, f: f}
+		return p.Evaluate(ctx, req)
+	})
+}
+
+// Entries returns the UUIDs of the Rekor entries offered to policies.
+func (f *Fallback) Entries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var uuids []string
+	for _, descs := range f.searched {
+		for _, d := range descs {
+			uuids = append(uuids, d.Annotations[AnnotationUUID])
+		}
+	}
+	slices.Sort(uuids)
+	return uuids
+}
+
+// Skipped returns why entries found in Rekor could not be used.
+func (f *Fallback) Skipped() []error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.skipped)
+}
+
+// search returns referrer descriptors for the Rekor entries recording
+// subject, fetching and converting them on first use.
+func (f *Fallback) search(ctx context.Context, subject digest.Digest) ([]ocispec.Descriptor, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if descs, ok := f.searched[subject]; ok {
+		return descs, nil
+	}
+
+	uuids, err := f.client.Search(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	if len(uuids) > maxEntries {
+		f.skipped = append(f.skipped, fmt.Errorf("%d entries found for %s; using the first %d", len(uuids), subject, maxEntries))
+		uuids = uuids[:maxEntries]
+	}
+
+	descs := []ocispec.Descriptor{}
+	for _, uuid := range uuids {
+		entry, err := f.client.Entry(ctx, uuid)
+		if err != nil {
+			return nil, err
+		}
+		data, err := entry.Bundle()
+		if err != nil {
+			f.skipped = append(f.skipped, fmt.Errorf("entry %s: %w", uuid, err))
+			continue
+		}
+		d := digest.FromBytes(data)
+		f.bundles[d] = data
+		descs = append(descs, ocispec.Descriptor{
+			MediaType:    BundleMediaType,
+			ArtifactType: BundleMediaType,
+			Digest:       d,
+			Size:         int64(len(data)),
+			Annotations:  map[string]string{AnnotationUUID: entry.UUID},
+		})
+	}
+	f.searched[subject] = descs
+	return descs, nil
+}
+
+// fallbackClient forwards to the registry, answering Sigstore referrer
+// listings from Rekor when the registry has none.
+type fallbackClient struct {
+	inner registry.PolicyClient
+	f     *Fallback
+}
+
+func (c *fallbackClient) Referrers(
+	ctx context.Context,
+	ref string,
+	subject ocispec.Descriptor,
+	artifactType string,
+) ([]ocispec.Descriptor, error) {
+	descs, err := c.inner.Referrers(ctx, ref, subject, artifactType)
+	if artifactType != BundleMediaType {
+		return descs, err
+	}
+	if err != nil && !errors.Is(err, registry.ErrReferrersUnsupported) {
+		return descs, err
+	}
+	if err == nil && len(descs) > 0 {
+		return descs, nil
+	}
+	return c.f.search(ctx, subject.Digest)
+}
+
+func (c *fallbackClient) FetchDescriptor(ctx context.Context, ref string, desc ocispec.Descriptor) ([]byte, error) {
+	c.f.mu.Lock()
+	data, ok := c.f.bundles[desc.Digest]
+	c.f.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+	return c.inner.FetchDescriptor(ctx, ref, desc)
+}
@@ -0,0 +1,274 @@
+// Package rekor searches a Rekor transparency log for signatures of a
+// manifest digest and turns the entries into Sigstore bundles, so
+// signatures can be verified on registries without the referrers API.
+package rekor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/meigma/blob-cli/internal/retry"
+)
+
+// DefaultURL is the public Sigstore Rekor instance.
+const DefaultURL = "https://rekor.sigstore.dev"
+
+// BundleMediaType is the media type of the bundles Entry.Bundle builds.
+const BundleMediaType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+
+// maxResponseSize bounds what is read from a Rekor response.
+const maxResponseSize = 16 << 20
+
+// ErrUnavailable is returned when Rekor cannot be reached or keeps
+// failing, as opposed to answering that there is nothing to find.
+var ErrUnavailable = errors.New("rekor unavailable")
+
+// ErrUnsupportedEntry is returned by Entry.Bundle for entries that cannot
+// be expressed as a keyless message-signature bundle.
+var ErrUnsupportedEntry = errors.New("unsupported rekor entry")
+
+// Client queries a Rekor instance.
+type Client struct {
+	// BaseURL is the Rekor server, DefaultURL if empty.
+	BaseURL string
+	// HTTPClient is used for requests, a client with a 30s timeout if nil.
+	HTTPClient *http.Client
+	// Retry is how requests that fail with a network or server error are
+	// retried, retry.Default if zero.
+	Retry retry.Policy
+}
+
+// Entry is a Rekor log entry as returned by the API.
+type Entry struct {
+	UUID           string `json:"-"`
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+	Verification   struct {
+		InclusionProof *struct {
+			Checkpoint string   `json:"checkpoint"`
+			Hashes     []string `json:"hashes"`
+			LogIndex   int64    `json:"logIndex"`
+			RootHash   string   `json:"rootHash"`
+			TreeSize   int64    `json:"treeSize"`
+		} `json:"inclusionProof"`
+		SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+	} `json:"verification"`
+}
+
+// hashedRekord is the body of a hashedrekord v0.0.1 entry.
+type hashedRekord struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+	Spec       struct {
+		Signature struct {
+			Content   []byte `json:"content"`
+			PublicKey struct {
+				Content []byte `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+	} `json:"spec"`
+}
+
+// Search returns the UUIDs of log entries recording the digest d.
+func (c *Client) Search(ctx context.Context, d digest.Digest) ([]string, error) {
+	if err := d.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid digest: %w", err)
+	}
+	body, err := json.Marshal(map[string]string{"hash": d.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	var uuids []string
+	if err := c.do(ctx, http.MethodPost, "/api/v1/index/retrieve", body, &uuids); err != nil {
+		return nil, fmt.Errorf("searching rekor: %w", err)
+	}
+	return uuids, nil
+}
+
+// Entry fetches the log entry with uuid.
+func (c *Client) Entry(ctx context.Context, uuid string) (*Entry, error) {
+	var entries map[string]*Entry
+	if err := c.do(ctx, http.MethodGet, "/api/v1/log/entries/"+url.PathEscape(uuid), nil, &entries); err != nil {
+		return nil, fmt.Errorf("fetching rekor entry %s: %w", uuid, err)
+	}
+	for id, e := range entries {
+		if e != nil {
+			e.UUID = id
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("fetching rekor entry %s: empty response", uuid)
+}
+
+// do sends a JSON request and decodes the JSON response into out,
+// retrying network and server errors. If they persist the error wraps
+// ErrUnavailable.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out any) error {
+	policy := c.Retry
+	if policy.Attempts == 0 {
+		policy = retry.Default
+	}
+	var transient bool
+	err := policy.Do(ctx, func() error {
+		var err error
+		transient, err = c.send(ctx, method, path, body, out)
+		if err != nil && !transient {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	if err != nil && transient && ctx.Err() == nil {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// send makes one request for do, reporting whether a failure is worth
+// retrying.
+func (c *Client) send(ctx context.Context, method, path string, body []byte, out any) (transient bool, err error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultURL
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(base, "/")+path, reader)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return true, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		transient = resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return transient, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return false, json.Unmarshal(data, out)
+}
+
+// Bundle builds a Sigstore bundle from a hashedrekord entry signed with a
+// certificate, as produced by keyless signing. Other entry kinds and
+// entries without an inclusion proof return ErrUnsupportedEntry.
+func (e *Entry) Bundle() ([]byte, error) {
+	body, err := base64.StdEncoding.DecodeString(e.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decoding entry body: %w", err)
+	}
+	var rekord hashedRekord
+	if err := json.Unmarshal(body, &rekord); err != nil {
+		return nil, fmt.Errorf("parsing entry body: %w", err)
+	}
+	if rekord.Kind != "hashedrekord" || rekord.APIVersion != "0.0.1" {
+		return nil, fmt.Errorf("%w: kind %s %s", ErrUnsupportedEntry, rekord.Kind, rekord.APIVersion)
+	}
+	if rekord.Spec.Data.Hash.Algorithm != "sha256" {
+		return nil, fmt.Errorf("%w: hash algorithm %s", ErrUnsupportedEntry, rekord.Spec.Data.Hash.Algorithm)
+	}
+	block, _ := pem.Decode(rekord.Spec.Signature.PublicKey.Content)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%w: signed with a public key, not a certificate", ErrUnsupportedEntry)
+	}
+	proof := e.Verification.InclusionProof
+	if proof == nil {
+		return nil, fmt.Errorf("%w: no inclusion proof", ErrUnsupportedEntry)
+	}
+
+	hash, err := hex.DecodeString(rekord.Spec.Data.Hash.Value)
+	if err != nil {
+		return nil, fmt.Errorf("decoding entry hash: %w", err)
+	}
+	logID, err := hex.DecodeString(e.LogID)
+	if err != nil {
+		return nil, fmt.Errorf("decoding log id: %w", err)
+	}
+	rootHash, err := hex.DecodeString(proof.RootHash)
+	if err != nil {
+		return nil, fmt.Errorf("decoding root hash: %w", err)
+	}
+	hashes := make([][]byte, len(proof.Hashes))
+	for i, h := range proof.Hashes {
+		if hashes[i], err = hex.DecodeString(h); err != nil {
+			return nil, fmt.Errorf("decoding inclusion proof: %w", err)
+		}
+	}
+
+	b := &protobundle.Bundle{
+		MediaType: BundleMediaType,
+		VerificationMaterial: &protobundle.VerificationMaterial{
+			Content: &protobundle.VerificationMaterial_Certificate{
+				Certificate: &protocommon.X509Certificate{RawBytes: block.Bytes},
+			},
+			TlogEntries: []*protorekor.TransparencyLogEntry{{
+				LogIndex:       e.LogIndex,
+				LogId:          &protocommon.LogId{KeyId: logID},
+				KindVersion:    &protorekor.KindVersion{Kind: rekord.Kind, Version: rekord.APIVersion},
+				IntegratedTime: e.IntegratedTime,
+				InclusionPromise: &protorekor.InclusionPromise{
+					SignedEntryTimestamp: e.Verification.SignedEntryTimestamp,
+				},
+				InclusionProof: &protorekor.InclusionProof{
+					LogIndex:   proof.LogIndex,
+					RootHash:   rootHash,
+					TreeSize:   proof.TreeSize,
+					Hashes:     hashes,
+					Checkpoint: &protorekor.Checkpoint{Envelope: proof.Checkpoint},
+				},
+				CanonicalizedBody: body,
+			}},
+		},
+		Content: &protobundle.Bundle_MessageSignature{
+			MessageSignature: &protocommon.MessageSignature{
+				MessageDigest: &protocommon.HashOutput{
+					Algorithm: protocommon.HashAlgorithm_SHA2_256,
+					Digest:    hash,
+				},
+				Signature: rekord.Spec.Signature.Content,
+			},
+		},
+	}
+	return protojson.Marshal(b)
+}
@@ -0,0 +1,301 @@
+package rekor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/meigma/blob/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/meigma/blob-cli/internal/retry"
+)
+
+// testKey signs test entries.
+var testKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+// testCertPEM returns a self-signed certificate for testKey in PEM form.
+func testCertPEM(t *testing.T) []byte {
+	t.Helper()
+	key := testKey
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// testEntry returns a hashedrekord entry for d signed with publicKey.
+func testEntry(t *testing.T, d digest.Digest, publicKey []byte) *Entry {
+	t.Helper()
+	var body hashedRekord
+	body.Kind = "hashedrekord"
+	body.APIVersion = "0.0.1"
+	sig, err := ecdsa.SignASN1(rand.Reader, testKey, hashBytes(t, d))
+	require.NoError(t, err)
+	body.Spec.Signature.Content = sig
+	body.Spec.Signature.PublicKey.Content = publicKey
+	body.Spec.Data.Hash.Algorithm = "sha256"
+	body.Spec.Data.Hash.Value = d.Encoded()
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	var e Entry
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"integratedTime": 1700000000,
+		"logID": "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+		"logIndex": 42,
+		"verification": {
+			"inclusionProof": {
+				"checkpoint": "rekor.sigstore.dev - 1\n43\nAAAA\n",
+				"hashes": ["00ff"],
+				"logIndex": 42,
+				"rootHash": "abcd",
+				"treeSize": 43
+			},
+			"signedEntryTimestamp": "c2V0"
+		}
+	}`), &e))
+	e.Body = base64.StdEncoding.EncodeToString(data)
+	return &e
+}
+
+// hashBytes returns the raw hash of d.
+func hashBytes(t *testing.T, d digest.Digest) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(d.Encoded())
+	require.NoError(t, err)
+	return b
+}
+
+// newTestRekor serves entries, indexed by the digest of their body's hash.
+func newTestRekor(t *testing.T, entries map[string]*Entry) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/index/retrieve", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Hash string `json:"hash"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		uuids := []string{}
+		for uuid, e := range entries {
+			body, _ := base64.StdEncoding.DecodeString(e.Body)
+			var rekord hashedRekord
+			_ = json.Unmarshal(body, &rekord)
+			if "sha256:"+rekord.Spec.Data.Hash.Value == req.Hash {
+				uuids = append(uuids, uuid)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(uuids)
+	})
+	mux.HandleFunc("GET /api/v1/log/entries/{uuid}", func(w http.ResponseWriter, r *http.Request) {
+		e, ok := entries[r.PathValue("uuid")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]*Entry{r.PathValue("uuid"): e})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return &Client{BaseURL: srv.URL}
+}
+
+func TestClient_SearchAndEntry(t *testing.T) {
+	d := digest.FromString("manifest")
+	client := newTestRekor(t, map[string]*Entry{"uuid-1": testEntry(t, d, testCertPEM(t))})
+
+	uuids, err := client.Search(t.Context(), d)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"uuid-1"}, uuids)
+
+	uuids, err = client.Search(t.Context(), digest.FromString("other"))
+	require.NoError(t, err)
+	assert.Empty(t, uuids)
+
+	e, err := client.Entry(t.Context(), "uuid-1")
+	require.NoError(t, err)
+	assert.Equal(t, "uuid-1", e.UUID)
+	assert.Equal(t, int64(42), e.LogIndex)
+
+	_, err = client.Entry(t.Context(), "missing")
+	require.ErrorContains(t, err, "404")
+}
+
+func TestClient_Retry(t *testing.T) {
+	d := digest.FromString("manifest")
+	var calls, failures atomic.Int64
+	failures.Store(2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) <= failures.Load() {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]string{"uuid-1"})
+	}))
+	t.Cleanup(srv.Close)
+	client := &Client{BaseURL: srv.URL, Retry: retry.Policy{Attempts: 3, Delay: time.Millisecond}}
+
+	// Server errors are retried
+	uuids, err := client.Search(t.Context(), d)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"uuid-1"}, uuids)
+	assert.Equal(t, int64(3), calls.Load())
+
+	// An outage that outlasts the retries is reported as one
+	calls.Store(0)
+	failures.Store(3)
+	_, err = client.Search(t.Context(), d)
+	require.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, int64(3), calls.Load())
+
+	// So is a server that cannot be reached
+	srv.Close()
+	_, err = client.Search(t.Context(), d)
+	require.ErrorIs(t, err, ErrUnavailable)
+
+	// Requests Rekor rejects are neither retried nor an outage
+	calls.Store(0)
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	t.Cleanup(rejecting.Close)
+	client.BaseURL = rejecting.URL
+	_, err = client.Search(t.Context(), d)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, int64(1), calls.Load())
+}
+
+func TestEntry_Bundle(t *testing.T) {
+	d := digest.FromString("manifest")
+	data, err := testEntry(t, d, testCertPEM(t)).Bundle()
+	require.NoError(t, err)
+
+	var b bundle.Bundle
+	require.NoError(t, b.UnmarshalJSON(data))
+	assert.Equal(t, BundleMediaType, b.GetMediaType())
+	sig := b.GetMessageSignature()
+	require.NotNil(t, sig)
+	assert.True(t, ecdsa.VerifyASN1(&testKey.PublicKey, hashBytes(t, d), sig.GetSignature()))
+	assert.Equal(t, hashBytes(t, d), sig.GetMessageDigest().GetDigest())
+	entries := b.GetVerificationMaterial().GetTlogEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(42), entries[0].GetLogIndex())
+	assert.Equal(t, int64(43), entries[0].GetInclusionProof().GetTreeSize())
+}
+
+func TestEntry_BundleUnsupported(t *testing.T) {
+	d := digest.FromString("manifest")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("key")})
+	_, err := testEntry(t, d, keyPEM).Bundle()
+	require.ErrorIs(t, err, ErrUnsupportedEntry)
+
+	e := testEntry(t, d, testCertPEM(t))
+	e.Verification.InclusionProof = nil
+	_, err = e.Bundle()
+	require.ErrorIs(t, err, ErrUnsupportedEntry)
+
+	e.Body = base64.StdEncoding.EncodeToString([]byte(`{"kind":"intoto","apiVersion":"0.0.2"}`))
+	_, err = e.Bundle()
+	require.ErrorIs(t, err, ErrUnsupportedEntry)
+}
+
+// stubClient is a registry.PolicyClient with fixed referrers.
+type stubClient struct {
+	referrers []ocispec.Descriptor
+	err       error
+}
+
+func (c *stubClient) Referrers(context.Context, string, ocispec.Descriptor, string) ([]ocispec.Descriptor, error) {
+	return c.referrers, c.err
+}
+
+func (c *stubClient) FetchDescriptor(_ context.Context, _ string, desc ocispec.Descriptor) ([]byte, error) {
+	return nil, errors.New("not found: " + desc.Digest.String())
+}
+
+func TestFallback(t *testing.T) {
+	d := digest.FromString("manifest")
+	client := newTestRekor(t, map[string]*Entry{
+		"keyless": testEntry(t, d, testCertPEM(t)),
+		"keyed":   testEntry(t, d, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("key")})),
+	})
+
+	// listBundles is a policy returning the bundles its client offers.
+	var got [][]byte
+	listBundles := registry.PolicyFunc(func(ctx context.Context, req registry.PolicyRequest) error {
+		got = nil
+		descs, err := req.Client.Referrers(ctx, req.Ref, req.Subject, BundleMediaType)
+		if err != nil {
+			return err
+		}
+		for _, desc := range descs {
+			data, err := req.Client.FetchDescriptor(ctx, req.Ref, desc)
+			if err != nil {
+				return err
+			}
+			got = append(got, data)
+		}
+		return nil
+	})
+	req := registry.PolicyRequest{Ref: "example.com/acme/configs:v1", Subject: ocispec.Descriptor{Digest: d}}
+
+	t.Run("referrers unsupported", func(t *testing.T) {
+		fallback := NewFallback(client)
+		req.Client = &stubClient{err: registry.ErrReferrersUnsupported}
+		require.NoError(t, fallback.Wrap(listBundles).Evaluate(t.Context(), req))
+		require.Len(t, got, 1)
+		assert.Equal(t, []string{"keyless"}, fallback.Entries())
+		require.Len(t, fallback.Skipped(), 1)
+		assert.ErrorContains(t, fallback.Skipped()[0], "keyed")
+	})
+
+	t.Run("no signatures attached", func(t *testing.T) {
+		fallback := NewFallback(client)
+		req.Client = &stubClient{}
+		require.NoError(t, fallback.Wrap(listBundles).Evaluate(t.Context(), req))
+		assert.Len(t, got, 1)
+	})
+
+	t.Run("registry signatures win", func(t *testing.T) {
+		fallback := NewFallback(client)
+		attached := ocispec.Descriptor{Digest: digest.FromString("attached")}
+		req.Client = &stubClient{referrers: []ocispec.Descriptor{attached}}
+		err := fallback.Wrap(listBundles).Evaluate(t.Context(), req)
+		require.ErrorContains(t, err, attached.Digest.String())
+		assert.Empty(t, fallback.Entries())
+	})
+
+	t.Run("other errors are kept", func(t *testing.T) {
+		fallback := NewFallback(client)
+		req.Client = &stubClient{err: errors.New("registry down")}
+		require.ErrorContains(t, fallback.Wrap(listBundles).Evaluate(t.Context(), req), "registry down")
+	})
+}
@@ -0,0 +1,181 @@
+// Package resume records the files a pull has extracted, so an interrupted
+// pull can skip them when it is retried.
+//
+// Each pull of an archive into a destination keeps a journal under the
+// cache directory, named by the archive ID and the destination. The
+// journal holds one JSON line per extracted file with its path and SHA-256,
+// appended as each file is written, so it survives the process being
+// killed. A completed pull removes its journal.
+package resume
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Dir is the name of the journal directory within the cache directory.
+const Dir = "resume"
+
+// record is one journal line.
+type record struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"` // Hex-encoded SHA-256 of the content
+}
+
+// Journal records the files extracted by one pull of an archive into a
+// destination. It is safe for concurrent use.
+type Journal struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+	done map[string]string
+	err  error
+}
+
+// ArchiveID identifies an archive by the SHA-256 of its index, which
+// covers every file's path and content hash.
+func ArchiveID(index []byte) string {
+	sum := sha256.Sum256(index)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// JournalPath returns the path of the journal for extracting the archive
+// with the given ID into dest.
+func JournalPath(cacheDir, archiveID, dest string) string {
+	sum := sha256.Sum256([]byte(archiveID + "\n" + dest))
+	return filepath.Join(cacheDir, Dir, hex.EncodeToString(sum[:])+".jsonl")
+}
+
+// Open opens the journal for extracting the archive with the given ID into
+// dest, loading the files recorded by earlier attempts. The journal is
+// created if needed.
+func Open(cacheDir, archiveID, dest string) (*Journal, error) {
+	path := JournalPath(cacheDir, archiveID, dest)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("creating resume directory: %w", err)
+	}
+
+	done, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600) //nolint:gosec // path is derived from the cache directory
+	if err != nil {
+		return nil, fmt.Errorf("opening resume journal: %w", err)
+	}
+	return &Journal{path: path, file: f, enc: json.NewEncoder(f), done: done}, nil
+}
+
+// load reads the records in the journal at path. A truncated final line,
+// left by a process killed mid-write, ends the journal.
+func load(path string) (map[string]string, error) {
+	done := make(map[string]string)
+	f, err := os.Open(path) //nolint:gosec // path is derived from the cache directory
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading resume journal: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var r record
+		if json.Unmarshal(scanner.Bytes(), &r) != nil {
+			break
+		}
+		done[r.Path] = r.Hash
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+		return nil, fmt.Errorf("reading resume journal: %w", err)
+	}
+	return done, nil
+}
+
+// Len returns the number of files recorded, including by earlier attempts.
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.done)
+}
+
+// Completed returns the recorded hash of path, if it was extracted.
+func (j *Journal) Completed(path string) (string, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	hash, ok := j.done[path]
+	return hash, ok
+}
+
+// Record marks path as extracted with the given content hash. Write errors
+// are kept and returned by Close, so extraction is not interrupted by
+// bookkeeping.
+func (j *Journal) Record(path, hash string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.done[path] = hash
+	if j.err != nil {
+		return
+	}
+	if err := j.enc.Encode(record{Path: path, Hash: hash}); err != nil {
+		j.err = fmt.Errorf("writing resume journal: %w", err)
+	}
+}
+
+// Close closes the journal file, keeping it for a later attempt.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	closeErr := j.file.Close()
+	if j.err != nil {
+		return j.err
+	}
+	if closeErr != nil {
+		return fmt.Errorf("closing resume journal: %w", closeErr)
+	}
+	return nil
+}
+
+// Remove closes and deletes the journal after a completed pull.
+func (j *Journal) Remove() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_ = j.file.Close()
+	if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing resume journal: %w", err)
+	}
+	return nil
+}
+
+// Verify reports whether the file at path has the given size and content
+// hash.
+func Verify(path string, size uint64, hash string) bool {
+	f, err := os.Open(path) //nolint:gosec // path is within the pull destination
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() || uint64(info.Size()) != size { //nolint:gosec // file sizes are non-negative
+		return false
+	}
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return false
+	}
+	return hex.EncodeToString(hasher.Sum(nil)) == hash
+}
@@ -0,0 +1,62 @@
+package resume
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	cacheDir := t.TempDir()
+	id := ArchiveID([]byte("index"))
+
+	j, err := Open(cacheDir, id, "/dest")
+	require.NoError(t, err)
+	assert.Equal(t, 0, j.Len())
+	j.Record("a.txt", "aa")
+	j.Record("dir/b.txt", "bb")
+	require.NoError(t, j.Close())
+
+	// Simulate a process killed mid-write.
+	f, err := os.OpenFile(JournalPath(cacheDir, id, "/dest"), os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"path":"c.t`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	j, err = Open(cacheDir, id, "/dest")
+	require.NoError(t, err)
+	assert.Equal(t, 2, j.Len())
+	hash, ok := j.Completed("dir/b.txt")
+	assert.True(t, ok)
+	assert.Equal(t, "bb", hash)
+	_, ok = j.Completed("c.txt")
+	assert.False(t, ok)
+
+	// Journals are per archive and destination.
+	other, err := Open(cacheDir, id, "/elsewhere")
+	require.NoError(t, err)
+	assert.Equal(t, 0, other.Len())
+	require.NoError(t, other.Close())
+
+	require.NoError(t, j.Remove())
+	_, err = os.Stat(JournalPath(cacheDir, id, "/dest"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0o600))
+	sum := sha256.Sum256([]byte("hello"))
+	hash := hex.EncodeToString(sum[:])
+
+	assert.True(t, Verify(path, 5, hash))
+	assert.False(t, Verify(path, 4, hash))
+	assert.False(t, Verify(path, 5, hex.EncodeToString(make([]byte, 32))))
+	assert.False(t, Verify(filepath.Join(t.TempDir(), "missing"), 5, hash))
+}
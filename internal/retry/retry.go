@@ -0,0 +1,64 @@
+// Package retry retries calls to network services, such as Rekor and the
+// Sigstore TUF repository, that may fail for a moment during an outage.
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Policy is how often and how long to retry.
+type Policy struct {
+	// Attempts is the number of calls made in all, at least one.
+	Attempts int
+	// Delay is the wait before the second call, doubled before each
+	// later one.
+	Delay time.Duration
+}
+
+// Default retries three times over about seven seconds.
+var Default = Policy{Attempts: 4, Delay: time.Second}
+
+// permanentError is an error not worth retrying.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, such as a rejected request.
+// Do returns err itself.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do calls fn until it succeeds, returns a Permanent error, or the
+// attempts are used up, and returns its last error. Waiting between
+// attempts stops early if ctx is done.
+func (p Policy) Do(ctx context.Context, fn func() error) error {
+	delay := p.Delay
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if err == nil || attempt >= p.Attempts {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,62 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	p := Policy{Attempts: 3, Delay: time.Millisecond}
+	errDown := errors.New("service unavailable")
+
+	t.Run("succeeds after failures", func(t *testing.T) {
+		calls := 0
+		err := p.Do(t.Context(), func() error {
+			calls++
+			if calls < 3 {
+				return errDown
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("returns the last error", func(t *testing.T) {
+		calls := 0
+		err := p.Do(t.Context(), func() error {
+			calls++
+			return errDown
+		})
+		require.ErrorIs(t, err, errDown)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		errRejected := errors.New("rejected")
+		calls := 0
+		err := p.Do(t.Context(), func() error {
+			calls++
+			return Permanent(errRejected)
+		})
+		assert.Equal(t, errRejected, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		calls := 0
+		err := Policy{Attempts: 3, Delay: time.Hour}.Do(ctx, func() error {
+			calls++
+			return errDown
+		})
+		require.ErrorIs(t, err, errDown)
+		assert.Equal(t, 1, calls)
+	})
+}
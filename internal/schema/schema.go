@@ -0,0 +1,177 @@
+// Package schema validates archive content against JSON Schemas
+// configured by path pattern.
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+
+	"github.com/meigma/blob-cli/internal/archive"
+	"github.com/meigma/blob-cli/internal/config"
+)
+
+// ErrValidationFailed is returned when one or more files fail validation.
+var ErrValidationFailed = errors.New("schema validation failed")
+
+// rule is a compiled schema rule.
+type rule struct {
+	match  string
+	source string
+	schema *jsonschema.Schema
+}
+
+// Validator validates files against the schemas whose patterns match them.
+type Validator struct {
+	rules []rule
+}
+
+// FileError describes a validation failure for a single file.
+type FileError struct {
+	Path   string // Archive path of the file
+	Schema string // Schema file the content was validated against
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// New compiles the configured schema rules.
+// Relative schema paths are resolved against baseDir.
+func New(rules []config.SchemaRule, baseDir string) (*Validator, error) {
+	compiler := jsonschema.NewCompiler()
+	v := &Validator{rules: make([]rule, 0, len(rules))}
+
+	for i, r := range rules {
+		schemaPath := r.Schema
+		if !filepath.IsAbs(schemaPath) {
+			schemaPath = filepath.Join(baseDir, schemaPath)
+		}
+		compiled, err := compiler.Compile(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("compiling schemas[%d] (%s): %w", i, r.Schema, err)
+		}
+		v.rules = append(v.rules, rule{match: r.Match, source: r.Schema, schema: compiled})
+	}
+
+	return v, nil
+}
+
+// Len returns the number of schema rules.
+func (v *Validator) Len() int {
+	return len(v.rules)
+}
+
+// Matches reports whether any schema rule applies to the archive path.
+func (v *Validator) Matches(name string) bool {
+	for _, r := range v.rules {
+		if archive.MatchGlob(r.match, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks file content against every schema whose pattern matches name.
+// JSON and YAML content are supported; the format is chosen by file extension.
+// Returns a *FileError for the first failing schema, or nil.
+func (v *Validator) Validate(name string, data []byte) error {
+	var doc any
+	decoded := false
+
+	for _, r := range v.rules {
+		if !archive.MatchGlob(r.match, name) {
+			continue
+		}
+		if !decoded {
+			var err error
+			doc, err = decode(name, data)
+			if err != nil {
+				return &FileError{Path: name, Schema: r.source, Err: err}
+			}
+			decoded = true
+		}
+		if err := r.schema.Validate(doc); err != nil {
+			return &FileError{Path: name, Schema: r.source, Err: summarize(err)}
+		}
+	}
+
+	return nil
+}
+
+// ValidateFS validates every matching file in fsys and returns all failures.
+// The returned error is non-nil only if walking or reading fails.
+func (v *Validator) ValidateFS(fsys fs.FS) ([]*FileError, error) {
+	var failures []*FileError
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !v.Matches(name) {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+
+		var fileErr *FileError
+		if errors.As(v.Validate(name, data), &fileErr) {
+			failures = append(failures, fileErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return failures, nil
+}
+
+// decode parses JSON or YAML content into a value suitable for validation.
+func decode(name string, data []byte) (any, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml":
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("parsing YAML: %w", err)
+		}
+		return doc, nil
+	default:
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("parsing JSON: %w", err)
+		}
+		return doc, nil
+	}
+}
+
+// summarize drops the schema URL header from validation errors,
+// keeping only the per-location causes.
+func summarize(err error) error {
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	msg := verr.Error()
+	if _, causes, ok := strings.Cut(msg, "\n"); ok {
+		msg = strings.TrimSpace(causes)
+	}
+	return errors.New(strings.ReplaceAll(msg, "\n", "; "))
+}
@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/meigma/blob-cli/internal/config"
+)
+
+const portSchema = `{
+  "type": "object",
+  "required": ["port"],
+  "properties": {
+    "port": {"type": "integer"}
+  }
+}`
+
+// newTestValidator writes the schema into a temp dir and compiles a single rule.
+func newTestValidator(t *testing.T, match string) *Validator {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "schema.json"), []byte(portSchema), 0o644))
+
+	v, err := New([]config.SchemaRule{{Match: match, Schema: "schema.json"}}, dir)
+	require.NoError(t, err)
+	return v
+}
+
+func TestNew_MissingSchema(t *testing.T) {
+	_, err := New([]config.SchemaRule{{Match: "*.json", Schema: "missing.json"}}, t.TempDir())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "schemas[0]")
+}
+
+func TestValidate(t *testing.T) {
+	v := newTestValidator(t, "**/*.config.json")
+	require.Equal(t, 1, v.Len())
+
+	tests := []struct {
+		name    string
+		path    string
+		data    string
+		wantErr string
+	}{
+		{name: "valid", path: "svc/app.config.json", data: `{"port": 8080}`},
+		{name: "not matched", path: "svc/app.json", data: `{"port": "nope"}`},
+		{name: "wrong type", path: "app.config.json", data: `{"port": "8080"}`, wantErr: "/port"},
+		{name: "missing property", path: "app.config.json", data: `{}`, wantErr: "port"},
+		{name: "malformed", path: "app.config.json", data: `{`, wantErr: "parsing JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.path, []byte(tt.data))
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			var fileErr *FileError
+			require.ErrorAs(t, err, &fileErr)
+			assert.Equal(t, tt.path, fileErr.Path)
+			assert.Equal(t, "schema.json", fileErr.Schema)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidate_YAML(t *testing.T) {
+	v := newTestValidator(t, "*.yaml")
+
+	require.NoError(t, v.Validate("app.yaml", []byte("port: 8080\n")))
+	require.Error(t, v.Validate("app.yaml", []byte("port: http\n")))
+}
+
+func TestValidateFS(t *testing.T) {
+	v := newTestValidator(t, "**/*.config.json")
+
+	fsys := fstest.MapFS{
+		"a.config.json":       {Data: []byte(`{"port": 1}`)},
+		"svc/b.config.json":   {Data: []byte(`{"port": "x"}`)},
+		"svc/c/d.config.json": {Data: []byte(`{}`)},
+		"svc/readme.md":       {Data: []byte(`not json`)},
+	}
+
+	failures, err := v.ValidateFS(fsys)
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+	assert.Equal(t, "svc/b.config.json", failures[0].Path)
+	assert.Equal(t, "svc/c/d.config.json", failures[1].Path)
+}
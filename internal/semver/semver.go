@@ -0,0 +1,283 @@
+// Package semver selects tags by semantic version queries.
+//
+// A query is one of:
+//
+//	latest-stable     the highest release (non-prerelease) version
+//	1.2.3, v1.2.3     exactly that version
+//	1.2, 1.2.x, 1     any version with that prefix (1.2.x, 1.x.x)
+//	^1.2.3            compatible with 1.2.3: >=1.2.3 <2.0.0 (<0.3.0 for ^0.2.3)
+//	~1.2.3            patch updates of 1.2.3: >=1.2.3 <1.3.0
+//	>=1.2 <2          comparators (>, >=, <, <=, =), all of which must match
+//	^1.2 || ^2        alternatives, any of which may match
+//
+// Tags are matched if they are valid semantic versions, with or without a
+// leading "v". Prerelease tags only match a query that itself names a
+// prerelease version. The highest matching version wins.
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	bsemver "github.com/blang/semver"
+)
+
+// LatestStable is the query for the highest release version.
+const LatestStable = "latest-stable"
+
+// ErrNoMatch is returned when no tag matches a query.
+var ErrNoMatch = errors.New("no tag matches")
+
+// Query is a parsed semantic version query.
+type Query struct {
+	raw        string
+	alts       [][]comparator
+	prerelease bool
+}
+
+type comparator struct {
+	op string
+	v  bsemver.Version
+}
+
+// Parse parses a query. It returns an error if q is not a valid query, so
+// callers can fall back to treating q as a literal tag.
+func Parse(q string) (*Query, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, errors.New("empty semver query")
+	}
+	query := &Query{raw: q}
+	if q == LatestStable {
+		query.alts = [][]comparator{nil}
+		return query, nil
+	}
+
+	for alt := range strings.SplitSeq(q, "||") {
+		fields := strings.Fields(alt)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid semver query %q: empty alternative", q)
+		}
+		var cmps []comparator
+		for _, field := range fields {
+			c, err := parseTerm(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid semver query %q: %w", q, err)
+			}
+			for _, cmp := range c {
+				if len(cmp.v.Pre) > 0 {
+					query.prerelease = true
+				}
+			}
+			cmps = append(cmps, c...)
+		}
+		query.alts = append(query.alts, cmps)
+	}
+	return query, nil
+}
+
+// String returns the query as written.
+func (q *Query) String() string {
+	return q.raw
+}
+
+// Match reports whether tag is a version matched by the query.
+func (q *Query) Match(tag string) bool {
+	v, ok := ParseTag(tag)
+	if !ok {
+		return false
+	}
+	if len(v.Pre) > 0 && !q.prerelease {
+		return false
+	}
+	for _, cmps := range q.alts {
+		if matchAll(v, cmps) {
+			return true
+		}
+	}
+	return false
+}
+
+// Best returns the tag with the highest version matched by the query.
+// Among tags naming the same version, the first in sorted order wins.
+func (q *Query) Best(tags []string) (string, error) {
+	var best string
+	var bestV bsemver.Version
+	sorted := slices.Sorted(slices.Values(tags))
+	for _, tag := range sorted {
+		if !q.Match(tag) {
+			continue
+		}
+		v, _ := ParseTag(tag)
+		if best == "" || v.GT(bestV) {
+			best, bestV = tag, v
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("%w %q", ErrNoMatch, q.raw)
+	}
+	return best, nil
+}
+
+// ParseTag parses a tag as a semantic version, with or without a leading
+// "v". Only complete versions (major.minor.patch) are accepted.
+func ParseTag(tag string) (bsemver.Version, bool) {
+	v, err := bsemver.Parse(strings.TrimPrefix(tag, "v"))
+	if err != nil {
+		return bsemver.Version{}, false
+	}
+	return v, true
+}
+
+func matchAll(v bsemver.Version, cmps []comparator) bool {
+	for _, c := range cmps {
+		cmp := v.Compare(c.v)
+		var ok bool
+		switch c.op {
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTerm expands one query term into comparators.
+func parseTerm(term string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, prefix) {
+			op = prefix
+			break
+		}
+	}
+	parts, pre, err := parsePartial(term[len(op):])
+	if err != nil {
+		return nil, err
+	}
+	lower := version(parts, pre)
+
+	switch op {
+	case "^":
+		// The first non-zero component may not change.
+		var upper bsemver.Version
+		switch {
+		case parts[0] > 0 || len(parts) == 1:
+			upper = bsemver.Version{Major: lower.Major + 1}
+		case lower.Minor > 0 || len(parts) == 2:
+			upper = bsemver.Version{Minor: lower.Minor + 1}
+		default:
+			upper = bsemver.Version{Minor: lower.Minor, Patch: lower.Patch + 1}
+		}
+		return []comparator{{">=", lower}, {"<", upper}}, nil
+	case "~":
+		upper := bsemver.Version{Major: lower.Major, Minor: lower.Minor + 1}
+		if len(parts) == 1 {
+			upper = bsemver.Version{Major: lower.Major + 1}
+		}
+		return []comparator{{">=", lower}, {"<", upper}}, nil
+	case "", "=":
+		if len(parts) == 3 {
+			return []comparator{{"=", lower}}, nil
+		}
+		// A partial version matches every version with that prefix.
+		upper := bsemver.Version{Major: lower.Major + 1}
+		if len(parts) == 2 {
+			upper = bsemver.Version{Major: lower.Major, Minor: lower.Minor + 1}
+		}
+		return []comparator{{">=", lower}, {"<", upper}}, nil
+	case ">":
+		// ">1.2" means above every 1.2.x version.
+		if len(parts) < 3 {
+			return []comparator{{">=", bump(lower, len(parts))}}, nil
+		}
+		return []comparator{{op, lower}}, nil
+	case "<=":
+		// "<=1.2" includes every 1.2.x version.
+		if len(parts) < 3 {
+			return []comparator{{"<", bump(lower, len(parts))}}, nil
+		}
+		return []comparator{{op, lower}}, nil
+	default:
+		return []comparator{{op, lower}}, nil
+	}
+}
+
+// parsePartial parses a version that may omit trailing components, written
+// as "1", "1.2", "1.2.x", or "v1.2.3-rc.1". It returns the numeric
+// components present and the prerelease identifiers.
+func parsePartial(s string) ([]uint64, []bsemver.PRVersion, error) {
+	s = strings.TrimPrefix(s, "v")
+	if s == "" {
+		return nil, nil, errors.New("missing version")
+	}
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(s, "-")
+	fields := strings.Split(core, ".")
+	if len(fields) > 3 {
+		return nil, nil, fmt.Errorf("invalid version %q", s)
+	}
+	var parts []uint64
+	for _, f := range fields {
+		if f == "x" || f == "X" || f == "*" {
+			break
+		}
+		n, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid version %q", s)
+		}
+		parts = append(parts, n)
+	}
+	if len(parts) == 0 {
+		return nil, nil, fmt.Errorf("invalid version %q", s)
+	}
+	if !hasPre {
+		return parts, nil, nil
+	}
+	if len(parts) < 3 {
+		return nil, nil, fmt.Errorf("invalid version %q: prerelease requires major.minor.patch", s)
+	}
+	var prerelease []bsemver.PRVersion
+	for p := range strings.SplitSeq(pre, ".") {
+		prv, err := bsemver.NewPRVersion(p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+		prerelease = append(prerelease, prv)
+	}
+	return parts, prerelease, nil
+}
+
+// version builds a version from partial components, filling in zeros.
+func version(parts []uint64, pre []bsemver.PRVersion) bsemver.Version {
+	v := bsemver.Version{Major: parts[0], Pre: pre}
+	if len(parts) > 1 {
+		v.Minor = parts[1]
+	}
+	if len(parts) > 2 {
+		v.Patch = parts[2]
+	}
+	return v
+}
+
+// bump returns the first version after every version sharing the first n
+// components of v.
+func bump(v bsemver.Version, n int) bsemver.Version {
+	if n == 1 {
+		return bsemver.Version{Major: v.Major + 1}
+	}
+	return bsemver.Version{Major: v.Major, Minor: v.Minor + 1}
+}
@@ -0,0 +1,83 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTags = []string{
+	"latest", "v0.9.0", "1.0.0", "v1.2.0", "v1.2.5", "1.3.0-rc.1", "v1.4.1",
+	"v2.0.0", "2.1.0-beta", "0.2.3", "0.2.9", "0.3.0", "sha256-abc.sig",
+}
+
+func TestBest(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"latest-stable", "v2.0.0"},
+		{"^1.2", "v1.4.1"},
+		{"^1.2.5", "v1.4.1"},
+		{"~1.2", "v1.2.5"},
+		{"~1", "v1.4.1"},
+		{"1.2", "v1.2.5"},
+		{"1.2.x", "v1.2.5"},
+		{"1", "v1.4.1"},
+		{"1.2.0", "v1.2.0"},
+		{"v1.0.0", "1.0.0"},
+		{">=1.2 <1.4", "v1.2.5"},
+		{">1.2", "v2.0.0"},
+		{"<=1.2", "v1.2.5"},
+		{"<1", "v0.9.0"},
+		{"<0.3", "0.2.9"},
+		{"^0.2.3", "0.2.9"},
+		{"^0.2 || ^1.0.0-0", "v1.4.1"},
+		{"^1.3.0-rc.1", "v1.4.1"},
+		{"1.3.0-rc.1", "1.3.0-rc.1"},
+		{">=2.1.0-alpha", "2.1.0-beta"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			require.NoError(t, err)
+			got, err := q.Best(testTags)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBest_NoMatch(t *testing.T) {
+	q, err := Parse("^3")
+	require.NoError(t, err)
+	_, err = q.Best(testTags)
+	require.ErrorIs(t, err, ErrNoMatch)
+
+	// Prereleases are skipped unless the query names one.
+	q, err = Parse(">=2.1")
+	require.NoError(t, err)
+	_, err = q.Best(testTags)
+	require.ErrorIs(t, err, ErrNoMatch)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, q := range []string{"", "latest", "main", "stable", "^", "1.2.3.4", ">=1.x-rc", "1.2-rc.1", "^1 ||", "v"} {
+		t.Run(q, func(t *testing.T) {
+			_, err := Parse(q)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	v, ok := ParseTag("v1.2.3")
+	require.True(t, ok)
+	assert.Equal(t, "1.2.3", v.String())
+
+	_, ok = ParseTag("1.2")
+	assert.False(t, ok)
+	_, ok = ParseTag("latest")
+	assert.False(t, ok)
+}
@@ -0,0 +1,106 @@
+// Package shortcut expands user-defined shortcuts: names that stand for a
+// full blob command line, configured under "shortcuts" in the config file.
+//
+//	shortcuts:
+//	  deploy-prod: pull prod-cfg:stable /etc/app --verify-checksums
+//
+// Running "blob deploy-prod --resume" runs
+// "blob pull prod-cfg:stable /etc/app --verify-checksums --resume". A
+// command line is split into arguments the way a POSIX shell would split
+// it, with single and double quotes and backslash escapes, but nothing
+// else of the shell: no variables, globs, or pipes.
+package shortcut
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ValidName reports whether name can be used as a shortcut: it must be a
+// single word that does not look like a flag.
+func ValidName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "-") && !strings.ContainsFunc(name, unicode.IsSpace)
+}
+
+// Split splits a command line into arguments.
+func Split(line string) ([]string, error) {
+	var (
+		args    []string
+		arg     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			// In double quotes a backslash only escapes what the shell
+			// would treat specially there
+			if quote == '"' && !strings.ContainsRune(`"\$`+"`", r) {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inArg = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escaped {
+		return nil, errors.New("unfinished escape at end of command line")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed %c quote", quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// Expand returns args with a leading shortcut replaced by its command
+// line, and whether there was one. The remaining arguments follow the
+// expansion. A name for which isCommand returns true is never expanded,
+// so a shortcut cannot hide a built-in command, and an expansion is not
+// expanded again.
+func Expand(shortcuts map[string]string, args []string, isCommand func(name string) bool) ([]string, bool, error) {
+	if len(args) == 0 || isCommand(args[0]) {
+		return args, false, nil
+	}
+	line, ok := shortcuts[args[0]]
+	if !ok {
+		return args, false, nil
+	}
+	expanded, err := Split(line)
+	if err != nil {
+		return nil, false, fmt.Errorf("shortcut %s: %w", args[0], err)
+	}
+	if len(expanded) == 0 {
+		return nil, false, fmt.Errorf("shortcut %s: empty command line", args[0])
+	}
+	return append(expanded, args[1:]...), true, nil
+}
@@ -0,0 +1,72 @@
+package shortcut
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "words", line: "  pull prod-cfg:stable\t/etc/app --verify-checksums ", want: []string{"pull", "prod-cfg:stable", "/etc/app", "--verify-checksums"}},
+		{name: "single quotes", line: `pull 'configs:>=1.2 <2' './my dir'`, want: []string{"pull", "configs:>=1.2 <2", "./my dir"}},
+		{name: "double quotes", line: `cat "a \"b\" \c" x""y`, want: []string{"cat", `a "b" \c`, "xy"}},
+		{name: "escapes", line: `ls my\ dir \'`, want: []string{"ls", "my dir", "'"}},
+		{name: "empty quotes", line: `pull '' ""`, want: []string{"pull", "", ""}},
+		{name: "empty", line: "  ", want: nil},
+		{name: "unclosed quote", line: `pull "configs`, wantErr: true},
+		{name: "trailing backslash", line: `pull \`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Split(tt.line)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	shortcuts := map[string]string{
+		"deploy-prod": "pull prod-cfg:stable /etc/app --verify-checksums",
+		"pull":        "ls x",
+		"broken":      `cat "x`,
+		"blank":       " ",
+	}
+	isCommand := func(name string) bool { return name == "pull" || name == "ls" }
+
+	args, ok, err := Expand(shortcuts, []string{"deploy-prod", "--dry-run"}, isCommand)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"pull", "prod-cfg:stable", "/etc/app", "--verify-checksums", "--dry-run"}, args)
+
+	for _, in := range [][]string{nil, {"pull", "x"}, {"unknown"}, {"--verbose", "deploy-prod"}} {
+		args, ok, err = Expand(shortcuts, in, isCommand)
+		require.NoError(t, err)
+		assert.False(t, ok, in)
+		assert.Equal(t, in, args)
+	}
+
+	_, _, err = Expand(shortcuts, []string{"broken"}, isCommand)
+	require.ErrorContains(t, err, "shortcut broken")
+	_, _, err = Expand(shortcuts, []string{"blank"}, isCommand)
+	require.ErrorContains(t, err, "empty command line")
+}
+
+func TestValidName(t *testing.T) {
+	assert.True(t, ValidName("deploy-prod"))
+	assert.False(t, ValidName(""))
+	assert.False(t, ValidName("-x"))
+	assert.False(t, ValidName("deploy prod"))
+}
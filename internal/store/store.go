@@ -0,0 +1,409 @@
+// Package store implements a local content-addressed store for archive files.
+//
+// Files are stored once by SHA-256 and shared by every archive that contains
+// them. Working directories are populated with hard links into the store, so
+// several checkouts of the same or overlapping archives do not duplicate
+// bytes on disk.
+//
+// A store directory has the following layout:
+//
+//	objects/<hh>/<hex>     file content (read-only); "-x" suffix if executable
+//	archives/<algo>-<hex>.json  record of an archive's files, by manifest digest
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Directory names within a store.
+const (
+	// ObjectsDir holds file content, addressed by hash.
+	ObjectsDir = "objects"
+	// ArchivesDir holds archive records, addressed by manifest digest.
+	ArchivesDir = "archives"
+)
+
+// ErrNotFound is returned when an archive is not in the store.
+var ErrNotFound = errors.New("archive not in store")
+
+// Archive records the files of a stored archive.
+type Archive struct {
+	Digest string    `json:"digest"`
+	Refs   []string  `json:"refs,omitempty"`
+	Added  time.Time `json:"added"`
+	Files  []File    `json:"files"`
+}
+
+// File is a single archive entry.
+type File struct {
+	Path string      `json:"path"`
+	Hash string      `json:"hash"` // Hex-encoded SHA-256 of the content
+	Size uint64      `json:"size"`
+	Mode fs.FileMode `json:"mode"`
+}
+
+// Size returns the total size of the archive's files.
+func (a *Archive) Size() uint64 {
+	var total uint64
+	for _, f := range a.Files {
+		total += f.Size
+	}
+	return total
+}
+
+// Store is a content-addressed store rooted at a directory.
+type Store struct {
+	root string
+}
+
+// New returns a store rooted at dir. The directory is created on first write.
+func New(dir string) *Store {
+	return &Store{root: dir}
+}
+
+// Root returns the store directory.
+func (s *Store) Root() string {
+	return s.root
+}
+
+// objectPath returns the path of the object for a file's content and mode.
+// Executable and non-executable copies are stored separately because hard
+// links share permissions.
+func (s *Store) objectPath(hash string, mode fs.FileMode) string {
+	name := hash
+	if mode&0o111 != 0 {
+		name += "-x"
+	}
+	return filepath.Join(s.root, ObjectsDir, hash[:2], name)
+}
+
+// HasObject reports whether content with the given hash and mode is stored.
+func (s *Store) HasObject(hash string, mode fs.FileMode) bool {
+	if validHash(hash) != nil {
+		return false
+	}
+	_, err := os.Stat(s.objectPath(hash, mode))
+	return err == nil
+}
+
+// AddObject stores the content read from r under hash. The content is
+// verified against the hash before it becomes visible in the store.
+func (s *Store) AddObject(hash string, mode fs.FileMode, r io.Reader) error {
+	if err := validHash(hash); err != nil {
+		return err
+	}
+	path := s.objectPath(hash, mode)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating store directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating object: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hasher), r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing object %s: %w", hash, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing object %s: %w", hash, err)
+	}
+	if got := hex.EncodeToString(hasher.Sum(nil)); got != hash {
+		return fmt.Errorf("object %s: content hash mismatch (got %s)", hash, got)
+	}
+
+	perm := fs.FileMode(0o444)
+	if mode&0o111 != 0 {
+		perm = 0o555
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("setting object permissions: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("storing object %s: %w", hash, err)
+	}
+	return nil
+}
+
+// recordPath returns the path of the record for a manifest digest.
+func (s *Store) recordPath(digest string) string {
+	return filepath.Join(s.root, ArchivesDir, strings.Replace(digest, ":", "-", 1)+".json")
+}
+
+// Put saves an archive record. If the archive is already stored, its
+// references are merged and the original add time is kept.
+func (s *Store) Put(a *Archive) error {
+	if err := validDigest(a.Digest); err != nil {
+		return err
+	}
+	if existing, err := s.Get(a.Digest); err == nil {
+		for _, ref := range existing.Refs {
+			if !slices.Contains(a.Refs, ref) {
+				a.Refs = append(a.Refs, ref)
+			}
+		}
+		a.Added = existing.Added
+	}
+	slices.Sort(a.Refs)
+
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding archive record: %w", err)
+	}
+	path := s.recordPath(a.Digest)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating store directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing archive record: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing archive record: %w", err)
+	}
+	return nil
+}
+
+// Get returns the record for a manifest digest.
+func (s *Store) Get(digest string) (*Archive, error) {
+	if err := validDigest(digest); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(s.recordPath(digest))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, digest)
+		}
+		return nil, fmt.Errorf("reading archive record: %w", err)
+	}
+	var a Archive
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("decoding archive record %s: %w", digest, err)
+	}
+	return &a, nil
+}
+
+// Find returns the archive identified by a manifest digest or by a
+// reference it was added under. If several stored archives were added
+// under the same reference, the most recently added one is returned.
+func (s *Store) Find(refOrDigest string) (*Archive, error) {
+	if validDigest(refOrDigest) == nil {
+		return s.Get(refOrDigest)
+	}
+
+	archives, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var found *Archive
+	for _, a := range archives {
+		if slices.Contains(a.Refs, refOrDigest) && (found == nil || a.Added.After(found.Added)) {
+			found = a
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, refOrDigest)
+	}
+	return found, nil
+}
+
+// List returns all stored archives, most recently added first.
+func (s *Store) List() ([]*Archive, error) {
+	entries, err := os.ReadDir(filepath.Join(s.root, ArchivesDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading store: %w", err)
+	}
+
+	var archives []*Archive
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		a, err := s.Get(strings.Replace(name, "-", ":", 1))
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, a)
+	}
+	slices.SortFunc(archives, func(a, b *Archive) int {
+		return b.Added.Compare(a.Added)
+	})
+	return archives, nil
+}
+
+// Remove deletes an archive record. Its objects are kept until GC finds
+// them unreferenced.
+func (s *Store) Remove(digest string) error {
+	if err := validDigest(digest); err != nil {
+		return err
+	}
+	err := os.Remove(s.recordPath(digest))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, digest)
+	}
+	if err != nil {
+		return fmt.Errorf("removing archive record: %w", err)
+	}
+	return nil
+}
+
+// GCStats summarizes a garbage collection.
+type GCStats struct {
+	Objects int   // Objects removed
+	Bytes   int64 // Bytes freed
+}
+
+// GC removes objects not referenced by any stored archive. Objects that
+// are still hard-linked from a working directory are removed from the
+// store; the linked copies are unaffected.
+func (s *Store) GC() (GCStats, error) {
+	var stats GCStats
+
+	archives, err := s.List()
+	if err != nil {
+		return stats, err
+	}
+	live := make(map[string]bool)
+	for _, a := range archives {
+		for _, f := range a.Files {
+			live[s.objectPath(f.Hash, f.Mode)] = true
+		}
+	}
+
+	objectsDir := filepath.Join(s.root, ObjectsDir)
+	err = filepath.WalkDir(objectsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || live[path] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		stats.Objects++
+		stats.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("collecting unreferenced objects: %w", err)
+	}
+	return stats, nil
+}
+
+// LinkStats summarizes a checkout.
+type LinkStats struct {
+	Files  int    // Files written
+	Linked int    // Files hard-linked to the store
+	Copied int    // Files copied because linking failed (e.g., across file systems)
+	Bytes  uint64 // Total size of the files
+}
+
+// Link populates dest with the archive's files, hard-linking them to the
+// store where possible. Linked files are read-only because they share the
+// store's copy; replace them rather than editing in place. Existing files
+// in dest are not overwritten.
+func (s *Store) Link(a *Archive, dest string) (LinkStats, error) {
+	var stats LinkStats
+
+	for _, f := range a.Files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Path)) {
+			return stats, fmt.Errorf("invalid path in archive record: %s", f.Path)
+		}
+		if err := validHash(f.Hash); err != nil {
+			return stats, err
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+			return stats, fmt.Errorf("creating directory: %w", err)
+		}
+
+		object := s.objectPath(f.Hash, f.Mode)
+		err := os.Link(object, target)
+		switch {
+		case err == nil:
+			stats.Linked++
+		case errors.Is(err, os.ErrExist):
+			return stats, fmt.Errorf("%s already exists", target)
+		case errors.Is(err, os.ErrNotExist):
+			return stats, fmt.Errorf("object for %s missing from store (re-add the archive)", f.Path)
+		default:
+			if err := copyObject(object, target, f.Mode); err != nil {
+				return stats, err
+			}
+			stats.Copied++
+		}
+		stats.Files++
+		stats.Bytes += f.Size
+	}
+	return stats, nil
+}
+
+// copyObject copies an object to target with the archive entry's permissions.
+func copyObject(object, target string, mode fs.FileMode) error {
+	src, err := os.Open(object) //nolint:gosec // object path is derived from a validated hash
+	if err != nil {
+		return fmt.Errorf("opening object: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm()) //nolint:gosec // target is validated to be inside dest
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", target, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("writing %s: %w", target, err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", target, err)
+	}
+	return nil
+}
+
+// validHash checks that hash is a hex-encoded SHA-256.
+func validHash(hash string) error {
+	if len(hash) != sha256.Size*2 {
+		return fmt.Errorf("invalid content hash %q", hash)
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return fmt.Errorf("invalid content hash %q", hash)
+	}
+	return nil
+}
+
+// validDigest checks that digest is a sha256 manifest digest.
+func validDigest(digest string) error {
+	hash, ok := strings.CutPrefix(digest, "sha256:")
+	if !ok || validHash(hash) != nil {
+		return fmt.Errorf("invalid digest %q", digest)
+	}
+	return nil
+}
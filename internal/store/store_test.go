@@ -0,0 +1,170 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hashOf(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func digestOf(s string) string {
+	return "sha256:" + hashOf(s)
+}
+
+// addArchive stores files (path -> content) as an archive and returns its record.
+func addArchive(t *testing.T, s *Store, digest, ref string, added time.Time, files map[string]string) *Archive {
+	t.Helper()
+	a := &Archive{Digest: digest, Refs: []string{ref}, Added: added}
+	for path, content := range files {
+		hash := hashOf(content)
+		if !s.HasObject(hash, 0o644) {
+			require.NoError(t, s.AddObject(hash, 0o644, strings.NewReader(content)))
+		}
+		a.Files = append(a.Files, File{Path: path, Hash: hash, Size: uint64(len(content)), Mode: 0o644})
+	}
+	require.NoError(t, s.Put(a))
+	return a
+}
+
+func TestAddObject(t *testing.T) {
+	s := New(t.TempDir())
+	hash := hashOf("hello")
+
+	assert.False(t, s.HasObject(hash, 0o644))
+	require.NoError(t, s.AddObject(hash, 0o644, strings.NewReader("hello")))
+	assert.True(t, s.HasObject(hash, 0o644))
+	assert.False(t, s.HasObject(hash, 0o755), "executable copy is stored separately")
+
+	info, err := os.Stat(s.objectPath(hash, 0o644))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o444), info.Mode().Perm())
+
+	err = s.AddObject(hash, 0o644, strings.NewReader("tampered"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hash mismatch")
+
+	require.Error(t, s.AddObject("../../etc/passwd", 0o644, strings.NewReader("")))
+}
+
+func TestPutGetFind(t *testing.T) {
+	s := New(t.TempDir())
+	now := time.Now().UTC().Truncate(time.Second)
+
+	older := addArchive(t, s, digestOf("v1"), "ghcr.io/acme/app:latest", now.Add(-time.Hour), map[string]string{"a.txt": "a"})
+	newer := addArchive(t, s, digestOf("v2"), "ghcr.io/acme/app:latest", now, map[string]string{"a.txt": "b"})
+
+	got, err := s.Find(older.Digest)
+	require.NoError(t, err)
+	assert.Equal(t, older.Files, got.Files)
+
+	got, err = s.Find("ghcr.io/acme/app:latest")
+	require.NoError(t, err)
+	assert.Equal(t, newer.Digest, got.Digest)
+
+	_, err = s.Find("ghcr.io/acme/other:v1")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Re-adding under another reference merges refs and keeps the add time.
+	again := &Archive{Digest: older.Digest, Refs: []string{"ghcr.io/acme/app:v1"}, Added: now, Files: older.Files}
+	require.NoError(t, s.Put(again))
+	got, err = s.Get(older.Digest)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ghcr.io/acme/app:latest", "ghcr.io/acme/app:v1"}, got.Refs)
+	assert.True(t, got.Added.Equal(older.Added))
+
+	list, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, newer.Digest, list[0].Digest)
+}
+
+func TestLink(t *testing.T) {
+	s := New(t.TempDir())
+	a := addArchive(t, s, digestOf("v1"), "app:v1", time.Now(), map[string]string{
+		"config/app.yaml": "port: 80\n",
+		"README.md":       "hi\n",
+	})
+
+	dest := t.TempDir()
+	stats, err := s.Link(a, dest)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Files)
+	assert.Equal(t, 2, stats.Linked+stats.Copied)
+	assert.Equal(t, uint64(len("port: 80\n")+len("hi\n")), stats.Bytes)
+
+	data, err := os.ReadFile(filepath.Join(dest, "config", "app.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "port: 80\n", string(data))
+
+	if stats.Linked == 2 {
+		linked, err := os.Stat(filepath.Join(dest, "README.md"))
+		require.NoError(t, err)
+		object, err := os.Stat(s.objectPath(hashOf("hi\n"), 0o644))
+		require.NoError(t, err)
+		assert.True(t, os.SameFile(linked, object))
+	}
+
+	// Existing files are not overwritten.
+	_, err = s.Link(a, dest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+func TestLink_InvalidPath(t *testing.T) {
+	s := New(t.TempDir())
+	a := &Archive{
+		Digest: digestOf("bad"),
+		Files:  []File{{Path: "../escape.txt", Hash: hashOf("x"), Size: 1, Mode: 0o644}},
+	}
+
+	_, err := s.Link(a, t.TempDir())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid path")
+}
+
+func TestRemoveAndGC(t *testing.T) {
+	s := New(t.TempDir())
+	now := time.Now()
+	a := addArchive(t, s, digestOf("v1"), "app:v1", now, map[string]string{"shared": "same", "only-v1": "one"})
+	b := addArchive(t, s, digestOf("v2"), "app:v2", now, map[string]string{"shared": "same", "only-v2": "two"})
+
+	require.NoError(t, s.Remove(a.Digest))
+	require.ErrorIs(t, s.Remove(a.Digest), ErrNotFound)
+
+	stats, err := s.GC()
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Objects)
+	assert.Equal(t, int64(len("one")), stats.Bytes)
+
+	assert.True(t, s.HasObject(hashOf("same"), 0o644))
+	assert.True(t, s.HasObject(hashOf("two"), 0o644))
+	assert.False(t, s.HasObject(hashOf("one"), 0o644))
+
+	require.NoError(t, s.Remove(b.Digest))
+	stats, err = s.GC()
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Objects)
+}
+
+func TestGC_EmptyStore(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "missing"))
+
+	stats, err := s.GC()
+	require.NoError(t, err)
+	assert.Zero(t, stats.Objects)
+
+	list, err := s.List()
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
@@ -0,0 +1,151 @@
+// Package trace logs HTTP requests for diagnosing registry traffic.
+//
+// Each completed request is written as one line:
+//
+//	GET https://ghcr.io/v2/acme/app/blobs/sha256:… range=bytes=0-65535 206 65536B 41ms
+//
+// The line is written when the response body is fully read or closed, so
+// bytes and duration cover the whole transfer. URL credentials and
+// sensitive query parameters (signatures, tokens) are redacted.
+package trace
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces sensitive values in traced URLs.
+const redacted = "REDACTED"
+
+// sensitiveParams are substrings of query parameter names whose values are
+// redacted (e.g., X-Amz-Signature, token, sig).
+var sensitiveParams = []string{"sig", "token", "key", "credential", "secret", "password", "auth"}
+
+// Transport is an http.RoundTripper that writes a line per request to W.
+// It is safe for concurrent use.
+type Transport struct {
+	Base http.RoundTripper
+
+	mu       sync.Mutex
+	w        io.Writer
+	requests int
+	bytes    int64
+	errors   int
+	elapsed  time.Duration
+	now      func() time.Time
+}
+
+// NewTransport returns a tracing transport wrapping base.
+func NewTransport(base http.RoundTripper, w io.Writer) *Transport {
+	return &Transport{Base: base, w: w, now: time.Now}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := t.now()
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		t.record(req, 0, 0, t.now().Sub(start), err)
+		return nil, err
+	}
+	resp.Body = &tracedBody{ReadCloser: resp.Body, t: t, req: req, status: resp.StatusCode, start: start}
+	return resp, nil
+}
+
+// Summary returns a one-line total of the traced requests.
+func (t *Transport) Summary() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := fmt.Sprintf("%d requests, %dB, %s total request time", t.requests, t.bytes, t.elapsed.Round(time.Millisecond))
+	if t.errors > 0 {
+		s += fmt.Sprintf(", %d failed", t.errors)
+	}
+	return s
+}
+
+func (t *Transport) record(req *http.Request, status int, n int64, d time.Duration, err error) {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte(' ')
+	b.WriteString(RedactURL(req.URL))
+	if r := req.Header.Get("Range"); r != "" {
+		b.WriteString(" range=")
+		b.WriteString(r)
+	}
+	if err != nil {
+		fmt.Fprintf(&b, " error=%q", err.Error())
+	} else {
+		fmt.Fprintf(&b, " %d %dB", status, n)
+	}
+	fmt.Fprintf(&b, " %s\n", d.Round(time.Millisecond))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests++
+	t.bytes += n
+	t.elapsed += d
+	if err != nil || status >= http.StatusBadRequest {
+		t.errors++
+	}
+	io.WriteString(t.w, b.String()) //nolint:errcheck // tracing is best-effort
+}
+
+// tracedBody records the request when the body is exhausted or closed.
+type tracedBody struct {
+	io.ReadCloser
+	t      *Transport
+	req    *http.Request
+	status int
+	start  time.Time
+	n      int64
+	once   sync.Once
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err == io.EOF {
+		b.done()
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+func (b *tracedBody) done() {
+	b.once.Do(func() {
+		b.t.record(b.req, b.status, b.n, b.t.now().Sub(b.start), nil)
+	})
+}
+
+// RedactURL returns u as a string with credentials and sensitive query
+// parameter values replaced.
+func RedactURL(u *url.URL) string {
+	redactedURL := *u
+	if u.User != nil {
+		redactedURL.User = url.User(redacted)
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		for name := range q {
+			lower := strings.ToLower(name)
+			for _, s := range sensitiveParams {
+				if strings.Contains(lower, s) {
+					q.Set(name, redacted)
+					break
+				}
+			}
+		}
+		redactedURL.RawQuery = q.Encode()
+	}
+	return redactedURL.String()
+}
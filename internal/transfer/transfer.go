@@ -0,0 +1,69 @@
+// Package transfer counts the registry traffic of a command, so its JSON
+// result can report how many requests it made and how many bytes it moved.
+package transfer
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// Stats is the registry traffic of a command.
+type Stats struct {
+	Requests  int64 `json:"requests"`
+	BytesDown int64 `json:"bytes_down"`
+	BytesUp   int64 `json:"bytes_up"`
+	CacheHits int64 `json:"cache_hits"`
+}
+
+// Transport is an http.RoundTripper that counts requests and the bytes of
+// request and response bodies. It is safe for concurrent use.
+type Transport struct {
+	Base http.RoundTripper
+
+	requests  atomic.Int64
+	bytesDown atomic.Int64
+	bytesUp   atomic.Int64
+}
+
+// NewTransport returns a counting transport wrapping base.
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// RoundTrip implements http.RoundTripper. A request counts once it is
+// sent, whether or not it succeeds; request bodies count by their
+// declared length, response bodies as they are read.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	if req.ContentLength > 0 {
+		t.bytesUp.Add(req.ContentLength)
+	}
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &countedBody{ReadCloser: resp.Body, n: &t.bytesDown}
+	return resp, nil
+}
+
+// Stats returns the traffic counted so far. CacheHits is left to the caller.
+func (t *Transport) Stats() Stats {
+	return Stats{
+		Requests:  t.requests.Load(),
+		BytesDown: t.bytesDown.Load(),
+		BytesUp:   t.bytesUp.Load(),
+	}
+}
+
+// countedBody adds the bytes read from a response body to n.
+type countedBody struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (b *countedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,39 @@
+package transfer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body) //nolint:errcheck // test server
+		io.WriteString(w, "hello")  //nolint:errcheck // test server
+	}))
+	t.Cleanup(srv.Close)
+
+	tr := NewTransport(http.DefaultTransport)
+	client := &http.Client{Transport: tr}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = client.Post(srv.URL, "text/plain", strings.NewReader("uploaded"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// Failed requests count too
+	_, err = client.Get("http://127.0.0.1:0/")
+	require.Error(t, err)
+
+	assert.Equal(t, Stats{Requests: 3, BytesDown: 5, BytesUp: 8}, tr.Stats())
+}
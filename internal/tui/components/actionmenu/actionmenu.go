@@ -0,0 +1,169 @@
+// Package actionmenu provides a popup menu listing the actions available
+// for the selected entry.
+package actionmenu
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Action identifies a menu item.
+type Action int
+
+// Actions offered by the menu.
+const (
+	ActionCopy Action = iota
+	ActionExtract
+	ActionViewRaw
+	ActionViewHex
+	ActionCopyPath
+	ActionCopyDigest
+	ActionOpenDir
+)
+
+// Item is one entry of the menu.
+type Item struct {
+	Action Action
+	Key    string // shortcut within the menu
+	Label  string
+}
+
+// Model represents the action menu state.
+type Model struct {
+	title   string
+	items   []Item
+	cursor  int
+	visible bool
+	width   int
+	height  int
+}
+
+// New creates a new action menu.
+func New() Model {
+	return Model{}
+}
+
+// Show opens the menu with the given items.
+func (m *Model) Show(title string, items []Item) {
+	m.title = title
+	m.items = items
+	m.cursor = 0
+	m.visible = true
+}
+
+// Hide closes the menu.
+func (m *Model) Hide() {
+	m.visible = false
+}
+
+// Visible returns whether the menu is shown.
+func (m *Model) Visible() bool {
+	return m.visible
+}
+
+// CursorUp moves the highlight up one item.
+func (m *Model) CursorUp() {
+	if m.cursor > 0 {
+		m.cursor--
+	}
+}
+
+// CursorDown moves the highlight down one item.
+func (m *Model) CursorDown() {
+	if m.cursor < len(m.items)-1 {
+		m.cursor++
+	}
+}
+
+// Selected returns the highlighted action.
+func (m *Model) Selected() (Action, bool) {
+	if len(m.items) == 0 {
+		return 0, false
+	}
+	return m.items[m.cursor].Action, true
+}
+
+// Shortcut returns the action whose shortcut is key.
+func (m *Model) Shortcut(key string) (Action, bool) {
+	for _, item := range m.items {
+		if item.Key == key {
+			return item.Action, true
+		}
+	}
+	return 0, false
+}
+
+// SetSize updates the screen dimensions the menu is centered in.
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+// Init initializes the component.
+//
+//nolint:gocritic // hugeParam: value receiver required by tea.Model interface
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages.
+//
+//nolint:gocritic // hugeParam: value receiver required by tea.Model interface
+func (m Model) Update(_ tea.Msg) (Model, tea.Cmd) {
+	return m, nil
+}
+
+// View renders the component.
+//
+//nolint:gocritic // hugeParam: value receiver required by tea.Model interface
+func (m Model) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	menuWidth := 40
+	if m.width > 0 && m.width < menuWidth+4 {
+		menuWidth = m.width - 4
+	}
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(1, 2).
+		Width(menuWidth)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("229"))
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("229")).
+		Bold(true)
+
+	normalStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252"))
+
+	keyStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("75"))
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240"))
+
+	title := m.title
+	if maxLen := menuWidth - 4; len(title) > maxLen && maxLen > 3 {
+		title = "..." + title[len(title)-(maxLen-3):]
+	}
+
+	lines := []string{titleStyle.Render(title), ""}
+	for i, item := range m.items {
+		label := keyStyle.Render(item.Key) + "  " + item.Label
+		if i == m.cursor {
+			lines = append(lines, selectedStyle.Render("> ")+selectedStyle.Render(item.Key+"  "+item.Label))
+		} else {
+			lines = append(lines, normalStyle.Render("  ")+label)
+		}
+	}
+	lines = append(lines, "", hintStyle.Render("Enter: run  Esc: close"))
+
+	return borderStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
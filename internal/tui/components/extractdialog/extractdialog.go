@@ -0,0 +1,221 @@
+// Package extractdialog provides a modal dialog for extracting a directory
+// and showing the extraction progress.
+package extractdialog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Model represents the extract dialog component state.
+type Model struct {
+	input      textinput.Model
+	sourcePath string // archive directory, "" for the whole archive
+	visible    bool
+	running    bool
+	filesDone  int
+	filesTotal int
+	bytesDone  uint64
+	current    string // file last extracted
+	width      int
+	height     int
+}
+
+// New creates a new extract dialog component.
+func New() Model {
+	ti := textinput.New()
+	ti.Placeholder = "destination directory"
+	ti.CharLimit = 256
+	ti.Width = 40
+
+	return Model{
+		input: ti,
+	}
+}
+
+// Show displays the dialog for extracting a directory. Files keep their
+// archive paths below the destination, which defaults to the current
+// directory.
+func (m *Model) Show(sourcePath string) {
+	m.sourcePath = sourcePath
+	m.visible = true
+	m.running = false
+	m.input.SetValue(".")
+	m.input.Focus()
+	m.input.CursorEnd()
+}
+
+// Hide hides the dialog.
+func (m *Model) Hide() {
+	m.visible = false
+	m.running = false
+	m.input.Blur()
+}
+
+// Visible returns whether the dialog is visible.
+func (m *Model) Visible() bool {
+	return m.visible
+}
+
+// Running returns whether the extraction has started.
+func (m *Model) Running() bool {
+	return m.running
+}
+
+// SourcePath returns the archive directory to extract.
+func (m *Model) SourcePath() string {
+	return m.sourcePath
+}
+
+// Destination returns the entered destination directory.
+func (m *Model) Destination() string {
+	return m.input.Value()
+}
+
+// Start switches the dialog to the progress screen.
+func (m *Model) Start(filesTotal int) {
+	m.running = true
+	m.filesDone = 0
+	m.filesTotal = filesTotal
+	m.bytesDone = 0
+	m.current = ""
+	m.input.Blur()
+}
+
+// SetProgress updates the progress screen.
+func (m *Model) SetProgress(filesDone, filesTotal int, bytesDone uint64, current string) {
+	// Progress events may arrive out of order
+	if filesDone < m.filesDone {
+		return
+	}
+	m.filesDone = filesDone
+	if filesTotal > 0 {
+		m.filesTotal = filesTotal
+	}
+	m.bytesDone = bytesDone
+	m.current = current
+}
+
+// SetSize updates the dialog dimensions.
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	inputWidth := min(width-10, 60)
+	inputWidth = max(inputWidth, 20)
+	m.input.Width = inputWidth
+}
+
+// Init initializes the component.
+//
+//nolint:gocritic // hugeParam: value receiver required by tea.Model interface
+func (m Model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles messages.
+//
+//nolint:gocritic // hugeParam: value receiver required by tea.Model interface
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.visible || m.running {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// View renders the component.
+//
+//nolint:gocritic // hugeParam: value receiver required by tea.Model interface
+func (m Model) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	dialogWidth := 50
+	if m.width > 0 && m.width < dialogWidth+4 {
+		dialogWidth = m.width - 4
+	}
+
+	borderStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(1, 2).
+		Width(dialogWidth)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("229"))
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252"))
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240"))
+
+	source := "/" + m.sourcePath
+	if m.sourcePath == "" {
+		source = "/ (whole archive)"
+	}
+
+	if m.running {
+		barWidth := max(dialogWidth-6, 10)
+		current := m.current
+		if maxLen := dialogWidth - 4; len(current) > maxLen && maxLen > 3 {
+			current = "..." + current[len(current)-(maxLen-3):]
+		}
+		content := lipgloss.JoinVertical(lipgloss.Left,
+			titleStyle.Render("Extracting"),
+			"",
+			labelStyle.Render(source+" → "+m.input.Value()),
+			"",
+			renderBar(m.filesDone, m.filesTotal, barWidth),
+			labelStyle.Render(fmt.Sprintf("%d/%d files, %s", m.filesDone, m.filesTotal, formatBytes(m.bytesDone))),
+			hintStyle.Render(current),
+		)
+		return borderStyle.Render(content)
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render("Extract Directory"),
+		"",
+		labelStyle.Render("Source: "+source),
+		"",
+		labelStyle.Render("Destination directory:"),
+		m.input.View(),
+		"",
+		hintStyle.Render("Enter: extract  Esc: cancel"),
+	)
+
+	return borderStyle.Render(content)
+}
+
+// renderBar renders a progress bar of width cells.
+func renderBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = min(done*width/total, width)
+	}
+	bar := lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Render(strings.Repeat("█", filled))
+	rest := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(strings.Repeat("░", width-filled))
+	return bar + rest
+}
+
+// formatBytes formats a byte count as a human-readable string.
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
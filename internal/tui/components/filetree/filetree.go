@@ -2,6 +2,7 @@
 package filetree
 
 import (
+	"io/fs"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -113,6 +114,30 @@ func (m *Model) Enter() bool {
 	return false
 }
 
+// Reveal navigates to entry, which may be anywhere in the archive: a
+// directory is opened, and a file is selected in its parent directory.
+// The current location is saved so Back returns to it.
+func (m *Model) Reveal(entry *archive.DirEntry) {
+	m.history = append(m.history, historyEntry{
+		dir:    m.currentDir,
+		cursor: m.cursor,
+		offset: m.offset,
+	})
+	if entry.IsDir {
+		m.loadDir(entry.Path)
+		return
+	}
+
+	m.loadDir(parentPath(entry.Path))
+	for i, e := range m.entries {
+		if e.Path == entry.Path {
+			m.cursor = i
+			break
+		}
+	}
+	m.adjustScroll()
+}
+
 // Back goes to the parent directory.
 // Returns true if navigation occurred.
 func (m *Model) Back() bool {
@@ -210,6 +235,8 @@ type viewStyles struct {
 	selected lipgloss.Style
 	normal   lipgloss.Style
 	dir      lipgloss.Style
+	link     lipgloss.Style
+	special  lipgloss.Style
 	box      lipgloss.Style
 }
 
@@ -232,6 +259,11 @@ func newViewStyles(focused bool, width, height int) viewStyles {
 			Foreground(lipgloss.Color("252")),
 		dir: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("75")),
+		link: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("80")).
+			Italic(true),
+		special: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214")),
 		box: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(borderColor).
@@ -242,9 +274,17 @@ func newViewStyles(focused bool, width, height int) viewStyles {
 
 // formatEntry formats a single entry for display.
 func (m *Model) formatEntry(entry *archive.DirEntry, index int, styles *viewStyles) string {
+	// Type suffixes as in ls -F
 	name := entry.Name
-	if entry.IsDir {
+	switch {
+	case entry.IsDir:
 		name += "/"
+	case entry.IsSymlink():
+		name += "@"
+	case entry.Mode&fs.ModeNamedPipe != 0:
+		name += "|"
+	case entry.Mode&fs.ModeSocket != 0:
+		name += "="
 	}
 
 	var line string
@@ -255,6 +295,10 @@ func (m *Model) formatEntry(entry *archive.DirEntry, index int, styles *viewStyl
 		line = styles.normal.Render("> " + name)
 	case entry.IsDir:
 		line = styles.dir.Render("  " + name)
+	case entry.IsSymlink():
+		line = styles.link.Render("  " + name)
+	case entry.IsSpecial():
+		line = styles.special.Render("  " + name)
 	default:
 		line = styles.normal.Render("  " + name)
 	}
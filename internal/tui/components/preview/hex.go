@@ -12,6 +12,12 @@ const bytesPerLine = 16
 // Format: offset  hex-bytes  |ascii|
 // Example: 00000000  48 65 6c 6c 6f 20 57 6f 72 6c 64 21 0a 00 00 00  |Hello World!....|
 func FormatHex(content []byte) string {
+	return FormatHexAt(content, 0)
+}
+
+// FormatHexAt formats content read from base in a file as a hex dump, with
+// offsets relative to the start of the file.
+func FormatHexAt(content []byte, base int64) string {
 	if len(content) == 0 {
 		return "(empty)"
 	}
@@ -20,14 +26,14 @@ func FormatHex(content []byte) string {
 	for offset := 0; offset < len(content); offset += bytesPerLine {
 		end := min(offset+bytesPerLine, len(content))
 		line := content[offset:end]
-		sb.WriteString(formatHexLine(offset, line))
+		sb.WriteString(formatHexLine(base+int64(offset), line))
 		sb.WriteByte('\n')
 	}
 	return sb.String()
 }
 
 // formatHexLine formats a single line of hex dump.
-func formatHexLine(offset int, line []byte) string {
+func formatHexLine(offset int64, line []byte) string {
 	var sb strings.Builder
 
 	// Offset (8 hex digits)
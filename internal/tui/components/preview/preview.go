@@ -3,8 +3,10 @@ package preview
 
 import (
 	"fmt"
+	"io/fs"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -21,8 +23,33 @@ const (
 	StateError                 // Error loading file
 	StateDir                   // Directory selected (no preview)
 	StateTooLarge              // File too large for preview
+	StateAbout                 // Archive README/metadata start page
+	StateLink                  // Symbolic link selected
+	StateSpecial               // Device, pipe, or socket selected
+	StateHexPage               // One page of a large file as a hex dump
 )
 
+// Mode selects how file content is shown.
+type Mode int
+
+const (
+	ModeAuto Mode = iota // Highlighted text, or a hex dump for binary files
+	ModeRaw              // Text without syntax highlighting
+	ModeHex              // Hex dump
+)
+
+// HexPageBytes is the size of the ranges fetched for the paged hex view of
+// files larger than MaxPreviewBytes.
+const HexPageBytes = 64 * 1024
+
+// PageRequestMsg is sent when scrolling past either end of a hex page.
+// The page at Offset should be loaded and passed to SetHexPage.
+type PageRequestMsg struct {
+	Path   string
+	Offset int64
+	AtEnd  bool // show the end of the page, when scrolling backwards
+}
+
 // MaxPreviewBytes is the maximum size of file content to preview.
 // Files larger than this will show a "too large" message instead of loading.
 const MaxPreviewBytes = 512 * 1024 // 512KB
@@ -34,6 +61,15 @@ type Model struct {
 	path     string
 	language string // Detected language for syntax highlighting
 	errMsg   string
+	content  []byte // Loaded file content
+	isBinary bool
+	mode     Mode // Mode requested for modePath
+	modePath string
+	shown    Mode   // Mode the content is shown in
+	size     uint64 // Size of the file paged through
+	offset   int64  // Offset of the hex page shown
+	pageLen  int    // Length of the hex page shown
+	paging   bool   // A page request is pending
 	width    int
 	height   int
 	focused  bool
@@ -85,13 +121,36 @@ func (m *Model) SetLoading(path string) {
 	}
 }
 
+// SetMode shows the content of path in mode. It returns false if the
+// content of path is not loaded yet; it is shown in mode once SetContent
+// is called for path.
+func (m *Model) SetMode(path string, mode Mode) bool {
+	m.mode = mode
+	m.modePath = path
+	if m.path != path || (m.state != StateText && m.state != StateBinary) {
+		return false
+	}
+	m.SetContent(path, m.content, m.isBinary)
+	return true
+}
+
 // SetContent sets the content to display.
 func (m *Model) SetContent(path string, content []byte, isBinary bool) {
 	m.path = path
 	m.errMsg = ""
+	m.content = content
+	m.isBinary = isBinary
+
+	// A mode chosen for another file does not carry over
+	mode := ModeAuto
+	if path == m.modePath {
+		mode = m.mode
+	}
+	m.shown = mode
 
-	if isBinary {
+	if mode == ModeHex || (isBinary && mode == ModeAuto) {
 		m.state = StateBinary
+		m.language = ""
 		// Truncate for hex display
 		displayContent := content
 		truncated := false
@@ -118,7 +177,10 @@ func (m *Model) SetContent(path string, content []byte, isBinary bool) {
 
 		// Apply syntax highlighting if available
 		var text string
-		m.language = GetLanguage(path)
+		m.language = ""
+		if mode == ModeAuto {
+			m.language = GetLanguage(path)
+		}
 		if m.language != "" {
 			text = Highlight(path, displayContent)
 		} else {
@@ -170,6 +232,97 @@ func (m *Model) SetDir(path string) {
 	}
 }
 
+// SetLink shows the target of a symbolic link. inArchive reports whether
+// the target exists in the archive and can be followed.
+func (m *Model) SetLink(path, target string, inArchive bool) {
+	m.state = StateLink
+	m.path = path
+	m.language = ""
+	m.errMsg = ""
+	if m.ready {
+		hint := "Press Enter to follow"
+		if !inArchive {
+			hint = "The target is not in the archive"
+		}
+		m.viewport.SetContent(m.wrapText(fmt.Sprintf("Symbolic link: %s\n\nTarget: %s\n\n%s", path, target, hint)))
+		m.viewport.GotoTop()
+	}
+}
+
+// SetSpecial shows the special file state for entries without content,
+// such as devices, named pipes, and sockets.
+func (m *Model) SetSpecial(path string, mode fs.FileMode) {
+	m.state = StateSpecial
+	m.path = path
+	m.language = ""
+	m.errMsg = ""
+	if m.ready {
+		m.viewport.SetContent(fmt.Sprintf("Special file: %s\n\nType: %s\n\nSpecial files have no content to preview", path, specialType(mode)))
+		m.viewport.GotoTop()
+	}
+}
+
+// specialType names the type of a special file mode.
+func specialType(mode fs.FileMode) string {
+	switch {
+	case mode&fs.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "character device"
+	case mode&fs.ModeDevice != 0:
+		return "block device"
+	default:
+		return "irregular file"
+	}
+}
+
+// SetHexPage shows data, read from offset in a file of size bytes, as one
+// page of a hex dump. Scrolling past the page requests the next or previous
+// one with a PageRequestMsg. With atEnd, the end of the page is shown.
+func (m *Model) SetHexPage(path string, size uint64, offset int64, data []byte, atEnd bool) {
+	m.state = StateHexPage
+	m.path = path
+	m.language = ""
+	m.errMsg = ""
+	m.content = nil
+	m.size = size
+	m.offset = offset
+	m.pageLen = len(data)
+	m.paging = false
+	if m.ready {
+		m.viewport.SetContent(FormatHexAt(data, offset))
+		if atEnd {
+			m.viewport.GotoBottom()
+		} else {
+			m.viewport.GotoTop()
+		}
+	}
+}
+
+// pageRequest returns the page to load when msg scrolls past the current
+// hex page, or nil.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) pageRequest(msg tea.KeyMsg) *PageRequestMsg {
+	km := m.viewport.KeyMap
+	switch {
+	case m.viewport.AtBottom() && key.Matches(msg, km.Down, km.PageDown, km.HalfPageDown):
+		next := m.offset + int64(m.pageLen)
+		if next >= int64(m.size) { //nolint:gosec // archive sizes fit in int64
+			return nil
+		}
+		return &PageRequestMsg{Path: m.path, Offset: next}
+	case m.viewport.AtTop() && key.Matches(msg, km.Up, km.PageUp, km.HalfPageUp):
+		if m.offset == 0 {
+			return nil
+		}
+		return &PageRequestMsg{Path: m.path, Offset: max(m.offset-HexPageBytes, 0), AtEnd: true}
+	}
+	return nil
+}
+
 // SetTooLarge shows the file-too-large state.
 func (m *Model) SetTooLarge(path string, size uint64) {
 	m.state = StateTooLarge
@@ -192,6 +345,18 @@ func (m *Model) SetTooLarge(path string, size uint64) {
 	}
 }
 
+// SetAbout shows the archive start page built from its README and metadata.
+func (m *Model) SetAbout(content string) {
+	m.state = StateAbout
+	m.path = ""
+	m.language = ""
+	m.errMsg = ""
+	if m.ready {
+		m.viewport.SetContent(m.wrapText(content))
+		m.viewport.GotoTop()
+	}
+}
+
 // formatBytes formats a byte count in human-readable form.
 func formatBytes(b uint64) string {
 	const unit = 1024
@@ -248,6 +413,13 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.state == StateHexPage && !m.paging {
+		if req := m.pageRequest(keyMsg); req != nil {
+			m.paging = true
+			return m, func() tea.Msg { return *req }
+		}
+	}
+
 	var cmd tea.Cmd
 	m.viewport, cmd = m.viewport.Update(msg)
 	return m, cmd
@@ -269,15 +441,32 @@ func (m Model) View() string {
 	case StateLoading:
 		header = "Loading: " + m.path
 	case StateText:
-		header = m.buildHeader("Text", m.path)
+		prefix := "Text"
+		if m.shown == ModeRaw {
+			prefix = "Raw"
+		}
+		header = m.buildHeader(prefix, m.path)
 	case StateBinary:
-		header = m.buildHeader("Binary", m.path)
+		prefix := "Binary"
+		if !m.isBinary {
+			prefix = "Hex"
+		}
+		header = m.buildHeader(prefix, m.path)
 	case StateError:
 		header = "Error: " + m.path
 	case StateDir:
 		header = "Directory: " + m.path
 	case StateTooLarge:
 		header = "Too Large: " + m.path
+	case StateAbout:
+		header = "About"
+	case StateLink:
+		header = "Link: " + m.path
+	case StateSpecial:
+		header = "Special: " + m.path
+	case StateHexPage:
+		end := m.offset + int64(m.pageLen)
+		header = fmt.Sprintf("Hex: %s [%d-%d of %s]", m.path, m.offset, end, formatBytes(m.size))
 	}
 
 	// Style based on focus
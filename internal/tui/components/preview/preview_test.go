@@ -0,0 +1,81 @@
+package preview
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSetMode(t *testing.T) {
+	t.Parallel()
+
+	m := New()
+	m.SetSize(80, 20)
+
+	// Not loaded yet: the mode applies once the content arrives
+	if m.SetMode("config.yaml", ModeHex) {
+		t.Fatal("SetMode() = true before the content was loaded")
+	}
+	m.SetContent("config.yaml", []byte("key: value\n"), false)
+	if m.State() != StateBinary || !strings.Contains(m.View(), "Hex: config.yaml") {
+		t.Errorf("content not shown as hex: state %v", m.State())
+	}
+
+	if !m.SetMode("config.yaml", ModeRaw) {
+		t.Fatal("SetMode() = false for loaded content")
+	}
+	if m.State() != StateText || !strings.Contains(m.View(), "Raw: config.yaml") {
+		t.Errorf("content not shown raw: state %v", m.State())
+	}
+
+	// Another file is shown in the default mode
+	m.SetContent("other.yaml", []byte("a: b\n"), false)
+	if !strings.Contains(m.View(), "Text: other.yaml") {
+		t.Error("mode carried over to another file")
+	}
+}
+
+func TestHexPaging(t *testing.T) {
+	t.Parallel()
+
+	m := New()
+	m.SetSize(80, 20)
+	size := uint64(3 * HexPageBytes)
+	page := make([]byte, HexPageBytes)
+
+	m.SetHexPage("disk.img", size, HexPageBytes, page, false)
+	if !strings.Contains(m.View(), "00010000") {
+		t.Error("page offsets are not relative to the file")
+	}
+
+	// Scrolling up from the top requests the previous page
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if cmd == nil {
+		t.Fatal("no page requested above the top of the page")
+	}
+	req, ok := cmd().(PageRequestMsg)
+	if !ok || req.Offset != 0 || !req.AtEnd {
+		t.Errorf("request = %+v, want the previous page's end", req)
+	}
+
+	// Scrolling down from the bottom requests the next page
+	m.SetHexPage("disk.img", size, HexPageBytes, page, true)
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if cmd == nil {
+		t.Fatal("no page requested below the bottom of the page")
+	}
+	req, ok = cmd().(PageRequestMsg)
+	if !ok || req.Offset != 2*HexPageBytes || req.AtEnd {
+		t.Errorf("request = %+v, want the next page", req)
+	}
+
+	// Nothing follows the last page
+	m.SetHexPage("disk.img", size, 2*HexPageBytes, page, true)
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if cmd != nil {
+		if _, ok := cmd().(PageRequestMsg); ok {
+			t.Error("page requested past the end of the file")
+		}
+	}
+}
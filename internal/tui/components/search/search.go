@@ -0,0 +1,224 @@
+// Package search provides a search panel that filters archive entries
+// recursively and lists the matches flat.
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/meigma/blob"
+
+	"github.com/meigma/blob-cli/internal/archive"
+)
+
+// Model represents the search panel state.
+type Model struct {
+	index   *blob.IndexView
+	input   textinput.Model
+	dir     string // directory searched, "" for the whole archive
+	query   string // query the results are for
+	results []*archive.DirEntry
+	cursor  int
+	offset  int // scroll offset
+	width   int
+	height  int
+	visible bool
+}
+
+// New creates a new search panel for the archive index.
+func New(index *blob.IndexView) Model {
+	ti := textinput.New()
+	ti.Placeholder = "substring or glob (*.yaml)"
+	ti.Prompt = "/"
+	ti.CharLimit = 256
+
+	return Model{
+		index: index,
+		input: ti,
+	}
+}
+
+// Show opens the panel to search below dir, keeping the previous query.
+func (m *Model) Show(dir string) {
+	m.dir = dir
+	m.visible = true
+	m.input.Focus()
+	m.input.CursorEnd()
+	m.results = nil
+	m.search()
+}
+
+// Hide closes the panel.
+func (m *Model) Hide() {
+	m.visible = false
+	m.input.Blur()
+}
+
+// Visible returns whether the panel is shown.
+func (m *Model) Visible() bool {
+	return m.visible
+}
+
+// Selected returns the highlighted match, or nil if there are none.
+func (m *Model) Selected() *archive.DirEntry {
+	if len(m.results) == 0 {
+		return nil
+	}
+	return m.results[m.cursor]
+}
+
+// ResultCount returns the number of matches.
+func (m *Model) ResultCount() int {
+	return len(m.results)
+}
+
+// SetSize updates the component dimensions.
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.input.Width = max(width-8, 10)
+	m.adjustScroll()
+}
+
+// CursorUp moves the highlight up one match.
+func (m *Model) CursorUp() {
+	if m.cursor > 0 {
+		m.cursor--
+		m.adjustScroll()
+	}
+}
+
+// CursorDown moves the highlight down one match.
+func (m *Model) CursorDown() {
+	if m.cursor < len(m.results)-1 {
+		m.cursor++
+		m.adjustScroll()
+	}
+}
+
+// search refreshes the results if the query changed.
+func (m *Model) search() {
+	query := strings.TrimSpace(m.input.Value())
+	if query == m.query && m.results != nil {
+		return
+	}
+	m.query = query
+	m.results = archive.Search(m.index, m.dir, query)
+	if m.results == nil {
+		m.results = []*archive.DirEntry{}
+	}
+	m.cursor = 0
+	m.offset = 0
+}
+
+// adjustScroll ensures the cursor is visible within the viewport.
+func (m *Model) adjustScroll() {
+	visibleLines := m.visibleLines()
+	if visibleLines <= 0 {
+		return
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+visibleLines {
+		m.offset = m.cursor - visibleLines + 1
+	}
+}
+
+// visibleLines returns the number of result lines in the viewport.
+func (m *Model) visibleLines() int {
+	// Account for: 2 border lines + input + separator + count line
+	return m.height - 7
+}
+
+// Init initializes the component.
+//
+//nolint:gocritic // hugeParam: value receiver required by tea.Model interface
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update forwards messages to the query input and refreshes the results.
+//
+//nolint:gocritic // hugeParam: value receiver required by tea.Model interface
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.visible {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.search()
+	return m, cmd
+}
+
+// View renders the component.
+//
+//nolint:gocritic // hugeParam: value receiver required by tea.Model interface
+func (m Model) View() string {
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Width(m.width - 2).
+		Height(m.height - 2)
+	selected := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("229")).
+		Bold(true)
+	normal := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252"))
+	dir := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("75"))
+	hint := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		Padding(0, 1)
+
+	var lines []string
+	visibleLines := m.visibleLines()
+	maxWidth := m.width - 6
+	for i := m.offset; i < len(m.results) && i < m.offset+visibleLines; i++ {
+		entry := m.results[i]
+		name := entry.Name
+		if entry.IsDir {
+			name += "/"
+		}
+		if len(name) > maxWidth-2 && maxWidth > 5 {
+			// Keep the end of long paths, where the file name is
+			name = "..." + name[len(name)-(maxWidth-5):]
+		}
+
+		switch {
+		case i == m.cursor:
+			lines = append(lines, selected.Render("> "+name))
+		case entry.IsDir:
+			lines = append(lines, dir.Render("  "+name))
+		default:
+			lines = append(lines, normal.Render("  "+name))
+		}
+	}
+	for len(lines) < visibleLines {
+		lines = append(lines, "")
+	}
+
+	scope := "/" + m.dir
+	var count string
+	switch {
+	case m.query == "":
+		count = "Search in " + scope
+	case len(m.results) == 1:
+		count = "1 match in " + scope
+	default:
+		count = fmt.Sprintf("%d matches in %s", len(m.results), scope)
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		" "+m.input.View(),
+		strings.Repeat("─", max(m.width-4, 0)),
+		strings.Join(lines, "\n"),
+		hint.Render(count+"  Enter: jump  Esc: close"),
+	)
+
+	return box.Render(content)
+}
@@ -27,6 +27,7 @@ type Model struct {
 	selectedSize  uint64
 	selectedTime  time.Time
 	selectedIsDir bool
+	selectedLink  string // target of a selected symbolic link
 	hasSelection  bool
 }
 
@@ -77,9 +78,15 @@ func (m *Model) SetSelectedFile(name string, size uint64, modTime time.Time, isD
 	m.selectedSize = size
 	m.selectedTime = modTime
 	m.selectedIsDir = isDir
+	m.selectedLink = ""
 	m.hasSelection = true
 }
 
+// SetLinkTarget shows the target of the selected symbolic link.
+func (m *Model) SetLinkTarget(target string) {
+	m.selectedLink = target
+}
+
 // ClearSelection clears the selected file metadata.
 func (m *Model) ClearSelection() {
 	m.hasSelection = false
@@ -93,6 +100,9 @@ func (m Model) formatSelectionInfo(style lipgloss.Style) string {
 	if m.selectedIsDir {
 		return style.Render("directory")
 	}
+	if m.selectedLink != "" {
+		return style.Render("→ " + m.selectedLink)
+	}
 
 	// Format: "4.2 KB · Jan 15 10:30" or "4.2 KB · 2d ago"
 	size := formatBytes(m.selectedSize)
@@ -4,16 +4,23 @@ import "github.com/charmbracelet/bubbles/key"
 
 // keyMap defines the key bindings for the TUI.
 type keyMap struct {
-	Up     key.Binding
-	Down   key.Binding
-	Left   key.Binding
-	Right  key.Binding
-	Enter  key.Binding
-	Tab    key.Binding
-	Copy   key.Binding
-	Quit   key.Binding
-	Escape key.Binding
-	Help   key.Binding
+	Up         key.Binding
+	Down       key.Binding
+	Left       key.Binding
+	Right      key.Binding
+	Enter      key.Binding
+	Tab        key.Binding
+	Copy       key.Binding
+	Extract    key.Binding
+	Actions    key.Binding
+	CopyPath   key.Binding
+	CopyDigest key.Binding
+	Search     key.Binding
+	About      key.Binding
+	Quit       key.Binding
+	Escape     key.Binding
+	Help       key.Binding
+	Override   key.Binding
 }
 
 // keys is the default key mapping.
@@ -46,6 +53,30 @@ var keys = keyMap{
 		key.WithKeys("c"),
 		key.WithHelp("c", "copy file"),
 	),
+	Extract: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "extract dir"),
+	),
+	Actions: key.NewBinding(
+		key.WithKeys(".", "alt+enter"),
+		key.WithHelp(".", "actions"),
+	),
+	CopyPath: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy path to clipboard"),
+	),
+	CopyDigest: key.NewBinding(
+		key.WithKeys("Y"),
+		key.WithHelp("Y", "copy digest to clipboard"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	About: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "about archive"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("q"),
 		key.WithHelp("q", "quit"),
@@ -58,13 +89,17 @@ var keys = keyMap{
 		key.WithKeys("?"),
 		key.WithHelp("?", "help"),
 	),
+	Override: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open anyway"),
+	),
 }
 
 // ShortHelp returns key bindings for the short help view.
 //
 //nolint:gocritic // hugeParam: value receiver required by help.KeyMap interface
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Left, k.Right, k.Tab, k.Copy, k.Quit}
+	return []key.Binding{k.Up, k.Down, k.Left, k.Right, k.Tab, k.Actions, k.Search, k.Quit}
 }
 
 // FullHelp returns key bindings for the full help view.
@@ -73,6 +108,7 @@ func (k keyMap) ShortHelp() []key.Binding {
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Left, k.Right},
-		{k.Tab, k.Copy, k.Quit, k.Help},
+		{k.Tab, k.Actions, k.Copy, k.Extract, k.CopyPath, k.CopyDigest},
+		{k.Search, k.About, k.Quit, k.Help},
 	}
 }
@@ -1,11 +1,18 @@
 package open
 
-import "github.com/meigma/blob"
+import (
+	"io/fs"
+
+	"github.com/meigma/blob"
+
+	"github.com/meigma/blob-cli/internal/archive"
+)
 
 // ArchiveLoadedMsg is sent when the archive has been loaded successfully.
 type ArchiveLoadedMsg struct {
 	Index   *blob.IndexView
 	Archive *blob.Archive
+	About   *archive.About // nil if the archive has no README or metadata
 }
 
 // ArchiveErrorMsg is sent when loading the archive fails.
@@ -26,6 +33,37 @@ type FileErrorMsg struct {
 	Err  error
 }
 
+// HexPageMsg is sent when a page of a large file has been read for the
+// paged hex view.
+type HexPageMsg struct {
+	Path   string
+	Size   uint64
+	Offset int64
+	Data   []byte
+	AtEnd  bool
+}
+
+// LinkTargetMsg is sent when the target of a symbolic link has been read.
+// Entry is the target in the archive, or nil if it is not in the archive.
+type LinkTargetMsg struct {
+	Path   string
+	Target string
+	Entry  *archive.DirEntry
+	Follow bool // navigate to the target
+}
+
+// SpecialFileMsg is sent when a device, pipe, or socket is selected.
+type SpecialFileMsg struct {
+	Path string
+	Mode fs.FileMode
+}
+
+// ClipboardMsg is sent when a value has been placed on the clipboard.
+type ClipboardMsg struct {
+	What string // what was copied, for the status bar
+	Err  error
+}
+
 // CopyCompleteMsg is sent when a file copy completes successfully.
 type CopyCompleteMsg struct {
 	SourcePath string
@@ -38,3 +76,25 @@ type CopyErrorMsg struct {
 	DestPath   string
 	Err        error
 }
+
+// ExtractProgressMsg is sent as files of a directory extraction are written.
+type ExtractProgressMsg struct {
+	FilesDone  int
+	FilesTotal int
+	BytesDone  uint64
+	Path       string
+}
+
+// ExtractCompleteMsg is sent when a directory extraction completes.
+type ExtractCompleteMsg struct {
+	SourcePath string
+	DestPath   string
+	Stats      blob.CopyStats
+}
+
+// ExtractErrorMsg is sent when a directory extraction fails.
+type ExtractErrorMsg struct {
+	SourcePath string
+	DestPath   string
+	Err        error
+}
@@ -2,14 +2,21 @@
 package open
 
 import (
+	"strings"
+
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/meigma/blob"
 
+	"github.com/meigma/blob-cli/internal/archive"
+	"github.com/meigma/blob-cli/internal/tui/components/actionmenu"
 	"github.com/meigma/blob-cli/internal/tui/components/copydialog"
+	"github.com/meigma/blob-cli/internal/tui/components/extractdialog"
 	"github.com/meigma/blob-cli/internal/tui/components/filetree"
 	"github.com/meigma/blob-cli/internal/tui/components/preview"
+	"github.com/meigma/blob-cli/internal/tui/components/search"
 	"github.com/meigma/blob-cli/internal/tui/components/statusbar"
 )
 
@@ -20,6 +27,7 @@ const (
 	stateLoading state = iota
 	stateReady
 	stateError
+	statePolicy
 )
 
 // focus indicates which pane has focus.
@@ -34,31 +42,58 @@ const (
 // It's called asynchronously in Init().
 type LoadFunc func() (*blob.IndexView, *blob.Archive, error)
 
+// PolicyError is returned by a LoadFunc when the archive fails
+// verification. The failures are shown on a screen that blocks browsing.
+type PolicyError struct {
+	// Failures describes each failed policy.
+	Failures []string
+
+	// Override loads the archive despite the failures. It is set only when
+	// every failed policy is in warn mode, and is bound to a key on the
+	// failure screen.
+	Override LoadFunc
+}
+
+func (e *PolicyError) Error() string {
+	return "verification failed: " + strings.Join(e.Failures, "; ")
+}
+
 // Model is the main TUI model for blob open.
 type Model struct {
 	// Loading state
-	state   state
-	loader  LoadFunc
-	loadErr error
-	spinner spinner.Model
+	state     state
+	loader    LoadFunc
+	loadErr   error
+	policyErr *PolicyError
+	spinner   spinner.Model
+
+	// unverified is set when the archive was opened despite failed policies
+	unverified bool
 
 	// Archive data (set after loading)
 	ref     string
 	index   *blob.IndexView
 	archive *blob.Archive
+	about   *archive.About
 
 	// Components (initialized after loading)
-	tree       filetree.Model
-	preview    preview.Model
-	copyDialog copydialog.Model
-	statusBar  statusbar.Model
-	help       help.Model
+	tree          filetree.Model
+	preview       preview.Model
+	actionMenu    actionmenu.Model
+	copyDialog    copydialog.Model
+	extractDialog extractdialog.Model
+	search        search.Model
+	statusBar     statusbar.Model
+	help          help.Model
 
 	// State
 	focus    focus
 	showHelp bool
 	styles   Styles
 
+	// extractEvents delivers the progress of a running extraction
+	extractEvents <-chan tea.Msg
+
 	// Dimensions
 	width  int
 	height int
@@ -80,3 +115,11 @@ func New(ref string, loader LoadFunc) Model {
 		styles:  DefaultStyles(),
 	}
 }
+
+// Unverified reports whether the archive was opened despite failed
+// policies in warn mode.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) Unverified() bool {
+	return m.unverified
+}
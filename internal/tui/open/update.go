@@ -1,16 +1,30 @@
 package open
 
 import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync/atomic"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/meigma/blob"
+	blobcore "github.com/meigma/blob/core"
 
+	"github.com/meigma/blob-cli/internal/archive"
+	"github.com/meigma/blob-cli/internal/clipboard"
+	"github.com/meigma/blob-cli/internal/tui/components/actionmenu"
 	"github.com/meigma/blob-cli/internal/tui/components/copydialog"
+	"github.com/meigma/blob-cli/internal/tui/components/extractdialog"
 	"github.com/meigma/blob-cli/internal/tui/components/filetree"
 	"github.com/meigma/blob-cli/internal/tui/components/preview"
+	"github.com/meigma/blob-cli/internal/tui/components/search"
 	"github.com/meigma/blob-cli/internal/tui/components/statusbar"
 	"github.com/meigma/blob-cli/internal/tui/detect"
 )
@@ -32,11 +46,18 @@ func (m Model) Init() tea.Cmd {
 func (m Model) loadArchive() tea.Cmd {
 	loader := m.loader
 	return func() tea.Msg {
-		index, archive, err := loader()
+		index, blobArchive, err := loader()
 		if err != nil {
 			return ArchiveErrorMsg{Err: err}
 		}
-		return ArchiveLoadedMsg{Index: index, Archive: archive}
+		msg := ArchiveLoadedMsg{Index: index, Archive: blobArchive}
+		if archive.HasAbout(index) {
+			// A broken README/metadata file should not prevent browsing
+			if about, err := archive.LoadAbout(index, blobArchive); err == nil {
+				msg.About = about
+			}
+		}
+		return msg
 	}
 }
 
@@ -55,8 +76,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		// Allow quitting with 'q' in any state
-		if key.Matches(msg, keys.Quit) {
+		// Allow quitting with 'q' in any state, unless it is being typed
+		if key.Matches(msg, keys.Quit) && !m.typing() {
 			return m, tea.Quit
 		}
 		// Escape is handled per-state (may close dialogs/help first)
@@ -68,6 +89,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateLoading(msg)
 	case stateError:
 		return m.updateError(msg)
+	case statePolicy:
+		return m.updatePolicy(msg)
 	case stateReady:
 		return m.updateReady(msg)
 	}
@@ -90,10 +113,17 @@ func (m Model) updateLoading(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.state = stateReady
 		m.index = msg.Index
 		m.archive = msg.Archive
+		m.about = msg.About
 		m.tree = filetree.New(msg.Index)
 		m.preview = preview.New()
+		m.actionMenu = actionmenu.New()
 		m.copyDialog = copydialog.New()
+		m.extractDialog = extractdialog.New()
+		m.search = search.New(msg.Index)
 		m.statusBar = statusbar.New(m.ref)
+		if m.unverified {
+			m.statusBar.SetRef(m.ref + " (unverified)")
+		}
 		m.help = help.New()
 
 		// Set initial focus
@@ -117,18 +147,28 @@ func (m Model) updateLoading(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.tree.Init(),
 			m.preview.Init(),
 			m.copyDialog.Init(),
+			m.extractDialog.Init(),
 			m.statusBar.Init(),
 		)
 
-		// Load initial preview and selection status for the first selected item
+		// Show the archive start page if present; otherwise load the
+		// initial preview for the first selected item
 		m.updateSelectionStatus()
-		if cmd := m.loadSelectedPreview(); cmd != nil {
+		if m.about != nil {
+			m.showAbout()
+		} else if cmd := m.loadSelectedPreview(); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
 
 		return m, tea.Batch(cmds...)
 
 	case ArchiveErrorMsg:
+		var policyErr *PolicyError
+		if errors.As(msg.Err, &policyErr) {
+			m.state = statePolicy
+			m.policyErr = policyErr
+			return m, nil
+		}
 		m.state = stateError
 		m.loadErr = msg.Err
 		return m, nil
@@ -154,6 +194,28 @@ func (m Model) updateError(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updatePolicy handles messages on the failed verification screen. The
+// archive is loaded only if the failures may be overridden.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) updatePolicy(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, keys.Escape):
+		return m, tea.Quit
+	case key.Matches(keyMsg, keys.Override) && m.policyErr.Override != nil:
+		m.state = stateLoading
+		m.loader = m.policyErr.Override
+		m.policyErr = nil
+		m.unverified = true
+		return m, tea.Batch(m.spinner.Tick, m.loadArchive())
+	}
+	return m, nil
+}
+
 // updateReady handles messages when the archive is loaded.
 //
 //nolint:gocritic // hugeParam: consistent with tea.Model pattern
@@ -162,21 +224,60 @@ func (m Model) updateReady(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.actionMenu.Visible() {
+			return m.handleActionMenuKeys(msg)
+		}
 		// Handle copy dialog if visible
 		if m.copyDialog.Visible() {
 			return m.handleCopyDialogKeys(msg)
 		}
+		if m.extractDialog.Visible() {
+			return m.handleExtractDialogKeys(msg)
+		}
+		if m.search.Visible() {
+			return m.handleSearchKeys(msg)
+		}
 		return m.handleKeys(msg)
 
 	case FileContentMsg:
 		m.preview.SetContent(msg.Path, msg.Content, msg.IsBinary)
 		return m, nil
 
+	case LinkTargetMsg:
+		return m.handleLinkTarget(msg)
+
+	case SpecialFileMsg:
+		if selected := m.tree.Selected(); selected != nil && selected.Path == msg.Path {
+			m.preview.SetSpecial(msg.Path, msg.Mode)
+		}
+		return m, nil
+
+	case HexPageMsg:
+		if selected := m.tree.Selected(); selected != nil && selected.Path == msg.Path {
+			m.preview.SetHexPage(msg.Path, msg.Size, msg.Offset, msg.Data, msg.AtEnd)
+		}
+		return m, nil
+
+	case preview.PageRequestMsg:
+		selected := m.tree.Selected()
+		if selected == nil || selected.Path != msg.Path {
+			return m, nil
+		}
+		return m, m.loadHexPage(msg.Path, selected.Size, msg.Offset, msg.AtEnd)
+
 	case FileErrorMsg:
 		m.preview.SetError(msg.Path, msg.Err)
 		m.statusBar.SetError(msg.Err)
 		return m, m.statusBar.ScheduleClear()
 
+	case ClipboardMsg:
+		if msg.Err != nil {
+			m.statusBar.SetError(msg.Err)
+		} else {
+			m.statusBar.SetMessage("Copied " + msg.What + " to clipboard")
+		}
+		return m, m.statusBar.ScheduleClear()
+
 	case CopyCompleteMsg:
 		m.copyDialog.Hide()
 		m.statusBar.SetMessage("Copied to " + msg.DestPath)
@@ -187,6 +288,26 @@ func (m Model) updateReady(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.statusBar.SetError(msg.Err)
 		return m, m.statusBar.ScheduleClear()
 
+	case ExtractProgressMsg:
+		m.extractDialog.SetProgress(msg.FilesDone, msg.FilesTotal, msg.BytesDone, msg.Path)
+		return m, waitForExtract(m.extractEvents)
+
+	case ExtractCompleteMsg:
+		m.extractDialog.Hide()
+		m.extractEvents = nil
+		message := fmt.Sprintf("Extracted %d files to %s", msg.Stats.FileCount, msg.DestPath)
+		if msg.Stats.Skipped > 0 {
+			message += fmt.Sprintf(" (%d existing files skipped)", msg.Stats.Skipped)
+		}
+		m.statusBar.SetMessage(message)
+		return m, m.statusBar.ScheduleClear()
+
+	case ExtractErrorMsg:
+		m.extractDialog.Hide()
+		m.extractEvents = nil
+		m.statusBar.SetError(msg.Err)
+		return m, m.statusBar.ScheduleClear()
+
 	case statusbar.ClearMessageMsg:
 		m.statusBar, _ = m.statusBar.Update(msg)
 		return m, nil
@@ -199,6 +320,20 @@ func (m Model) updateReady(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 	}
 
+	// Forward messages to extract dialog if visible
+	if m.extractDialog.Visible() {
+		var cmd tea.Cmd
+		m.extractDialog, cmd = m.extractDialog.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	// Forward messages to search if visible
+	if m.search.Visible() {
+		var cmd tea.Cmd
+		m.search, cmd = m.search.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
 	// Forward to focused component
 	if m.focus == focusPreview {
 		var cmd tea.Cmd
@@ -226,7 +361,10 @@ func (m Model) handleResize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 
 	m.tree.SetSize(treeWidth, contentHeight)
 	m.preview.SetSize(previewWidth, contentHeight)
+	m.actionMenu.SetSize(m.width, m.height)
 	m.copyDialog.SetSize(m.width, m.height)
+	m.extractDialog.SetSize(m.width, m.height)
+	m.search.SetSize(treeWidth, contentHeight)
 	m.statusBar.SetWidth(m.width)
 
 	// Update status bar with entry count
@@ -262,6 +400,30 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case key.Matches(msg, keys.Copy):
 		return m.startCopy()
+
+	case key.Matches(msg, keys.Extract):
+		return m.startExtract()
+
+	case key.Matches(msg, keys.Actions):
+		return m.showActions()
+
+	case key.Matches(msg, keys.CopyPath):
+		return m.runAction(actionmenu.ActionCopyPath)
+
+	case key.Matches(msg, keys.CopyDigest):
+		return m.runAction(actionmenu.ActionCopyDigest)
+
+	case key.Matches(msg, keys.Search):
+		m.search.Show(m.tree.CurrentDir())
+		return m, textinput.Blink
+
+	case key.Matches(msg, keys.About):
+		if m.about == nil {
+			m.statusBar.SetMessage("Archive has no README or metadata")
+			return m, m.statusBar.ScheduleClear()
+		}
+		m.showAbout()
+		return m, nil
 	}
 
 	// Focus-specific handling
@@ -301,6 +463,10 @@ func (m Model) handleTreeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.updateSelectionStatus()
 			return m, m.loadSelectedPreview()
 		}
+		// Follow a selected link to its target
+		if selected := m.tree.Selected(); selected != nil && selected.IsSymlink() {
+			return m, m.readLink(selected.Path, true)
+		}
 		// Selected a file - load preview
 		m.updateSelectionStatus()
 		return m, m.loadSelectedPreview()
@@ -340,6 +506,109 @@ func (m Model) handleCopyDialogKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// handleActionMenuKeys handles key presses while the action menu is open.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) handleActionMenuKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Escape), key.Matches(msg, keys.Actions):
+		m.actionMenu.Hide()
+		return m, nil
+
+	case key.Matches(msg, keys.Up):
+		m.actionMenu.CursorUp()
+		return m, nil
+
+	case key.Matches(msg, keys.Down):
+		m.actionMenu.CursorDown()
+		return m, nil
+
+	case key.Matches(msg, keys.Enter):
+		action, ok := m.actionMenu.Selected()
+		m.actionMenu.Hide()
+		if !ok {
+			return m, nil
+		}
+		return m.runAction(action)
+	}
+
+	if action, ok := m.actionMenu.Shortcut(msg.String()); ok {
+		m.actionMenu.Hide()
+		return m.runAction(action)
+	}
+	return m, nil
+}
+
+// handleExtractDialogKeys handles key presses in extract dialog mode.
+// Keys are ignored while the extraction runs.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) handleExtractDialogKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.extractDialog.Running() {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, keys.Escape):
+		m.extractDialog.Hide()
+		return m, nil
+
+	case key.Matches(msg, keys.Enter):
+		return m.executeExtract()
+	}
+
+	// Forward other keys to the text input
+	var cmd tea.Cmd
+	m.extractDialog, cmd = m.extractDialog.Update(msg)
+	return m, cmd
+}
+
+// handleSearchKeys handles key presses while the search panel is open.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Escape):
+		m.search.Hide()
+		return m, nil
+
+	case key.Matches(msg, keys.Up):
+		m.search.CursorUp()
+		return m, nil
+
+	case key.Matches(msg, keys.Down):
+		m.search.CursorDown()
+		return m, nil
+
+	case key.Matches(msg, keys.Enter):
+		selected := m.search.Selected()
+		if selected == nil {
+			m.statusBar.SetMessage("No matches")
+			return m, m.statusBar.ScheduleClear()
+		}
+		m.search.Hide()
+		m.tree.Reveal(selected)
+		if m.focus != focusTree {
+			m = m.toggleFocus()
+		}
+		m.updateStatusBar()
+		m.updateSelectionStatus()
+		return m, m.loadSelectedPreview()
+	}
+
+	// Forward other keys to the query input
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	return m, cmd
+}
+
+// typing reports whether a text input has the keyboard.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) typing() bool {
+	return m.state == stateReady && (m.copyDialog.Visible() || m.extractDialog.Visible() || m.search.Visible())
+}
+
 // toggleFocus switches focus between tree and preview.
 //
 //nolint:gocritic // hugeParam: consistent with tea.Model pattern
@@ -381,10 +650,19 @@ func (m Model) loadSelectedPreview() tea.Cmd {
 		return nil
 	}
 
-	// Check file size before loading to prevent memory issues
+	if selected.IsSymlink() {
+		return m.readLink(selected.Path, false)
+	}
+	if selected.IsSpecial() {
+		path, mode := selected.Path, selected.Mode
+		return func() tea.Msg {
+			return SpecialFileMsg{Path: path, Mode: mode}
+		}
+	}
+
+	// Large files are paged through as a hex dump instead of loaded whole
 	if selected.Size > preview.MaxPreviewBytes {
-		m.preview.SetTooLarge(selected.Path, selected.Size)
-		return nil
+		return m.loadHexPage(selected.Path, selected.Size, 0, false)
 	}
 
 	// Load file content asynchronously
@@ -402,6 +680,92 @@ func (m Model) loadSelectedPreview() tea.Cmd {
 	}
 }
 
+// loadHexPage returns a command that reads the page at offset of a large
+// file for the paged hex view, using a range request where possible.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) loadHexPage(path string, size uint64, offset int64, atEnd bool) tea.Cmd {
+	blobArchive := m.archive
+
+	return func() tea.Msg {
+		r, err := archive.OpenRange(blobArchive, path, offset, offset+preview.HexPageBytes)
+		if err != nil {
+			return FileErrorMsg{Path: path, Err: err}
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return FileErrorMsg{Path: path, Err: err}
+		}
+		return HexPageMsg{Path: path, Size: size, Offset: offset, Data: data, AtEnd: atEnd}
+	}
+}
+
+// readLink returns a command that reads the target of the link at path,
+// which is the link's content, and resolves it in the archive.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) readLink(path string, follow bool) tea.Cmd {
+	blobArchive := m.archive
+	index := m.index
+
+	return func() tea.Msg {
+		content, err := blobArchive.ReadFile(path)
+		if err != nil {
+			return FileErrorMsg{Path: path, Err: err}
+		}
+		target := strings.TrimSpace(string(content))
+		entry, _ := archive.ResolveLink(index, path, target)
+		return LinkTargetMsg{Path: path, Target: target, Entry: entry, Follow: follow}
+	}
+}
+
+// handleLinkTarget shows a link's target, and navigates to it if the link
+// was followed.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) handleLinkTarget(msg LinkTargetMsg) (tea.Model, tea.Cmd) {
+	selected := m.tree.Selected()
+	if selected == nil || selected.Path != msg.Path {
+		// The selection moved on while the link was read
+		return m, nil
+	}
+
+	if !msg.Follow {
+		m.preview.SetLink(msg.Path, msg.Target, msg.Entry != nil)
+		m.statusBar.SetLinkTarget(msg.Target)
+		return m, nil
+	}
+	if msg.Entry == nil {
+		m.statusBar.SetMessage("Link target " + msg.Target + " is not in the archive")
+		return m, m.statusBar.ScheduleClear()
+	}
+
+	m.tree.Reveal(msg.Entry)
+	m.updateStatusBar()
+	m.updateSelectionStatus()
+	return m, m.loadSelectedPreview()
+}
+
+// showAbout displays the archive start page in the preview pane.
+func (m *Model) showAbout() {
+	// Summarize metadata only; the README is rendered in full below
+	meta := &archive.About{Metadata: m.about.Metadata}
+
+	var b strings.Builder
+	for _, line := range meta.Summary() {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if m.about.Readme != "" {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(preview.Highlight(archive.ReadmePath, []byte(m.about.Readme)))
+	}
+	m.preview.SetAbout(b.String())
+}
+
 // updateStatusBar updates the status bar with current state.
 func (m *Model) updateStatusBar() {
 	m.statusBar.SetPath(m.tree.CurrentDir())
@@ -449,3 +813,182 @@ func (m Model) executeCopy() (tea.Model, tea.Cmd) {
 		return CopyCompleteMsg{SourcePath: sourcePath, DestPath: destPath}
 	}
 }
+
+// startExtract opens the extract dialog for the selected directory, or for
+// the current directory if a file is selected.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) startExtract() (tea.Model, tea.Cmd) {
+	dir := m.tree.CurrentDir()
+	if selected := m.tree.Selected(); selected != nil && selected.IsDir {
+		dir = selected.Path
+	}
+
+	m.extractDialog.Show(dir)
+	return m, textinput.Blink
+}
+
+// executeExtract starts extracting the dialog's directory in the
+// background and switches the dialog to its progress screen.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) executeExtract() (tea.Model, tea.Cmd) {
+	sourcePath := m.extractDialog.SourcePath()
+	destPath := m.extractDialog.Destination()
+
+	if destPath == "" {
+		m.statusBar.SetMessage("Destination directory required")
+		return m, m.statusBar.ScheduleClear()
+	}
+
+	prefix := sourcePath
+	if prefix == "" {
+		prefix = "."
+	}
+	blobArchive := m.archive
+
+	// Progress updates are dropped while the UI is behind; the final
+	// message is always delivered before the channel is closed.
+	events := make(chan tea.Msg, 16)
+	go func() {
+		defer close(events)
+		var bytesDone atomic.Uint64
+		stats, err := blobArchive.CopyDir(destPath, prefix,
+			blob.CopyWithPreserveMode(true),
+			blob.CopyWithPreserveTimes(true),
+			blobcore.CopyWithProgress(func(ev blob.ProgressEvent) {
+				if ev.Stage != blob.StageExtracting {
+					return
+				}
+				msg := ExtractProgressMsg{
+					FilesDone:  ev.FilesDone,
+					FilesTotal: ev.FilesTotal,
+					BytesDone:  bytesDone.Add(ev.BytesDone),
+					Path:       ev.Path,
+				}
+				select {
+				case events <- msg:
+				default:
+				}
+			}),
+		)
+		if err != nil {
+			events <- ExtractErrorMsg{SourcePath: sourcePath, DestPath: destPath, Err: err}
+			return
+		}
+		events <- ExtractCompleteMsg{SourcePath: sourcePath, DestPath: destPath, Stats: stats}
+	}()
+
+	m.extractDialog.Start(0)
+	m.extractEvents = events
+	return m, waitForExtract(events)
+}
+
+// waitForExtract returns a command that delivers the next message of a
+// running extraction.
+func waitForExtract(events <-chan tea.Msg) tea.Cmd {
+	if events == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg, ok := <-events
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// showActions opens the action menu for the selected entry.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) showActions() (tea.Model, tea.Cmd) {
+	selected := m.tree.Selected()
+	if selected == nil {
+		m.statusBar.SetMessage("Nothing selected")
+		return m, m.statusBar.ScheduleClear()
+	}
+
+	if selected.IsDir {
+		m.actionMenu.Show("/"+selected.Path, []actionmenu.Item{
+			{Action: actionmenu.ActionExtract, Key: "x", Label: "Extract directory"},
+			{Action: actionmenu.ActionCopyPath, Key: "y", Label: "Copy path to clipboard"},
+		})
+		return m, nil
+	}
+
+	m.actionMenu.Show("/"+selected.Path, []actionmenu.Item{
+		{Action: actionmenu.ActionCopy, Key: "c", Label: "Copy to local file"},
+		{Action: actionmenu.ActionViewRaw, Key: "r", Label: "View raw"},
+		{Action: actionmenu.ActionViewHex, Key: "h", Label: "Hex view"},
+		{Action: actionmenu.ActionCopyPath, Key: "y", Label: "Copy path to clipboard"},
+		{Action: actionmenu.ActionCopyDigest, Key: "Y", Label: "Copy digest to clipboard"},
+		{Action: actionmenu.ActionOpenDir, Key: "o", Label: "Open containing directory"},
+	})
+	return m, nil
+}
+
+// runAction runs an action chosen from the action menu on the selected
+// entry.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) runAction(action actionmenu.Action) (tea.Model, tea.Cmd) {
+	selected := m.tree.Selected()
+	if selected == nil {
+		return m, nil
+	}
+
+	switch action {
+	case actionmenu.ActionCopy:
+		return m.startCopy()
+
+	case actionmenu.ActionExtract:
+		return m.startExtract()
+
+	case actionmenu.ActionViewRaw, actionmenu.ActionViewHex:
+		mode := preview.ModeRaw
+		if action == actionmenu.ActionViewHex {
+			mode = preview.ModeHex
+		}
+		if m.preview.SetMode(selected.Path, mode) {
+			return m, nil
+		}
+		if selected.Size > preview.MaxPreviewBytes {
+			if mode == preview.ModeHex {
+				// Large files are already shown in the paged hex view
+				return m, nil
+			}
+			m.statusBar.SetMessage("File too large for raw view")
+			return m, m.statusBar.ScheduleClear()
+		}
+		return m, m.loadSelectedPreview()
+
+	case actionmenu.ActionCopyPath:
+		return m, copyToClipboard("path", selected.Path)
+
+	case actionmenu.ActionCopyDigest:
+		if selected.IsDir {
+			m.statusBar.SetMessage("Directories have no digest")
+			return m, m.statusBar.ScheduleClear()
+		}
+		return m, copyToClipboard("digest", "sha256:"+hex.EncodeToString(selected.Hash))
+
+	case actionmenu.ActionOpenDir:
+		m.tree.Reveal(selected)
+		if m.focus != focusTree {
+			m = m.toggleFocus()
+		}
+		m.updateStatusBar()
+		m.updateSelectionStatus()
+		return m, nil
+	}
+	return m, nil
+}
+
+// copyToClipboard returns a command that places value on the clipboard.
+func copyToClipboard(what, value string) tea.Cmd {
+	return func() tea.Msg {
+		_, err := clipboard.Copy(value)
+		return ClipboardMsg{What: what, Err: err}
+	}
+}
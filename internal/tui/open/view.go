@@ -15,6 +15,8 @@ func (m Model) View() string {
 		return m.viewLoading()
 	case stateError:
 		return m.viewError()
+	case statePolicy:
+		return m.viewPolicy()
 	case stateReady:
 		return m.viewReady()
 	}
@@ -77,6 +79,44 @@ func (m Model) viewError() string {
 	return message
 }
 
+// viewPolicy renders the failed verification screen.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) viewPolicy() string {
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("196")).
+		Bold(true)
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240"))
+
+	title := "Verification failed for " + m.ref
+	hint := "Press q to quit"
+	if m.policyErr.Override != nil {
+		titleStyle = titleStyle.Foreground(lipgloss.Color("214"))
+		title = "Verification warning for " + m.ref
+		hint = "Press o to open anyway (unverified), q to quit"
+	}
+
+	lines := []string{titleStyle.Render(title), ""}
+	for _, failure := range m.policyErr.Failures {
+		lines = append(lines, "• "+failure)
+	}
+	lines = append(lines, "", hintStyle.Render(hint))
+	message := lipgloss.JoinVertical(lipgloss.Left, lines...)
+
+	// Center the message if we have dimensions, wrapping long failures
+	if m.width > 0 && m.height > 0 {
+		return lipgloss.Place(
+			m.width, m.height,
+			lipgloss.Center, lipgloss.Center,
+			lipgloss.NewStyle().Width(min(m.width, 100)).Render(message),
+		)
+	}
+
+	return message
+}
+
 // viewReady renders the main browser interface.
 //
 //nolint:gocritic // hugeParam: consistent with tea.Model pattern
@@ -87,6 +127,9 @@ func (m Model) viewReady() string {
 
 	// Build the main layout
 	treeView := m.tree.View()
+	if m.search.Visible() {
+		treeView = m.search.View()
+	}
 	previewView := m.preview.View()
 
 	// Join tree and preview horizontally
@@ -101,6 +144,16 @@ func (m Model) viewReady() string {
 		fullView = m.overlayDialog(fullView)
 	}
 
+	// Overlay action menu if visible
+	if m.actionMenu.Visible() {
+		fullView = m.overlayActionMenu()
+	}
+
+	// Overlay extract dialog if visible
+	if m.extractDialog.Visible() {
+		fullView = m.overlayExtractDialog()
+	}
+
 	// Overlay help if visible
 	if m.showHelp {
 		fullView = m.overlayHelp(fullView)
@@ -125,6 +178,32 @@ func (m Model) overlayDialog(_ string) string {
 	)
 }
 
+// overlayActionMenu overlays the action menu centered on the screen.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) overlayActionMenu() string {
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		m.actionMenu.View(),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+	)
+}
+
+// overlayExtractDialog overlays the extract dialog centered on the screen.
+//
+//nolint:gocritic // hugeParam: consistent with tea.Model pattern
+func (m Model) overlayExtractDialog() string {
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		m.extractDialog.View(),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+	)
+}
+
 // overlayHelp overlays the help panel centered on the screen.
 //
 //nolint:gocritic // hugeParam: consistent with tea.Model pattern
@@ -0,0 +1,62 @@
+// Package warn reports problems that do not stop a command, such as a
+// failed referrer lookup or a cache that could not be opened.
+//
+// Warnings are written to stderr and counted, so that strict mode
+// (--strict or strict: true in the config file) can fail a command that
+// completed with warnings instead of letting them scroll past.
+package warn
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	mu    sync.Mutex
+	count int
+	quiet bool
+
+	// output overrides os.Stderr, which is looked up on each warning so
+	// that it can be redirected.
+	output io.Writer
+)
+
+// Printf reports a warning. The message is written to stderr after a
+// "Warning: " prefix, unless output is quiet, and is always counted.
+func Printf(format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	count++
+	if quiet {
+		return
+	}
+	w := output
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, "Warning: "+format+"\n", args...)
+}
+
+// SetQuiet suppresses printing of later warnings. They are still counted.
+func SetQuiet(q bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	quiet = q
+}
+
+// Count returns the number of warnings reported.
+func Count() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return count
+}
+
+// Reset clears the count and quiet setting.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	count = 0
+	quiet = false
+}
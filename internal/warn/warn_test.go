@@ -0,0 +1,30 @@
+package warn
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintf(t *testing.T) {
+	var buf bytes.Buffer
+	output = &buf
+	t.Cleanup(func() {
+		output = nil
+		Reset()
+	})
+
+	Printf("cache disabled: %v", "no home")
+	assert.Equal(t, "Warning: cache disabled: no home\n", buf.String())
+	assert.Equal(t, 1, Count())
+
+	// Quiet warnings are counted but not printed
+	SetQuiet(true)
+	Printf("failed to fetch %s", "signatures")
+	assert.Equal(t, "Warning: cache disabled: no home\n", buf.String())
+	assert.Equal(t, 2, Count())
+
+	Reset()
+	assert.Equal(t, 0, Count())
+}